@@ -0,0 +1,355 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive defines a portable JSON archive of the review metadata of
+// a repository, so that it can be backed up, or migrated between tools,
+// without depending on how git stores notes.
+//
+// An archive is a single JSON object:
+//
+//	{
+//	  "format": "git-appraise-archive",
+//	  "version": 2,
+//	  "commits": {
+//	    "<commit hash>": {
+//	      "requests": [<request>...],
+//	      "comments": [<comment>...],
+//	      "ci": [<ci report>...],
+//	      "analyses": [<analyses report>...],
+//	      "notes": {
+//	        "<name>": [<note>...]
+//	      }
+//	    }
+//	  }
+//	}
+//
+// Each request, comment, and report is the JSON object of one note, as
+// described by the schemas in the schema directory. The notes under every
+// other ref in refs/notes/devtools/, such as benchmarks and submissions, are
+// kept under "notes", by the name of the ref within refs/notes/devtools/.
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/ci"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+const (
+	// Format identifies a JSON object as an archive.
+	Format = "git-appraise-archive"
+
+	// Version is the version of the archive format that is written. Archives
+	// of later versions cannot be read.
+	//
+	// Version 2 added the notes under refs other than those of requests,
+	// comments, and CI and analyses reports.
+	Version = 2
+
+	// NotesRefPrefix is the prefix of the notes refs that are archived.
+	NotesRefPrefix = "refs/notes/devtools/"
+)
+
+// Commit holds the notes on a single commit.
+type Commit struct {
+	Requests []json.RawMessage `json:"requests,omitempty"`
+	Comments []json.RawMessage `json:"comments,omitempty"`
+	CI       []json.RawMessage `json:"ci,omitempty"`
+	Analyses []json.RawMessage `json:"analyses,omitempty"`
+	// Notes holds the notes under the other archived refs, by the name of
+	// the ref within NotesRefPrefix.
+	Notes map[string][]json.RawMessage `json:"notes,omitempty"`
+}
+
+// notes returns a pointer to the notes of the commit under the given ref,
+// or nil if the ref is not archived.
+func (c *Commit) notes(notesRef string) *[]json.RawMessage {
+	switch notesRef {
+	case request.Ref:
+		return &c.Requests
+	case comment.Ref:
+		return &c.Comments
+	case ci.Ref:
+		return &c.CI
+	case analyses.Ref:
+		return &c.Analyses
+	}
+	return nil
+}
+
+// getNotes returns the notes of the commit under the given ref.
+func (c *Commit) getNotes(notesRef string) []json.RawMessage {
+	if notes := c.notes(notesRef); notes != nil {
+		return *notes
+	}
+	return c.Notes[strings.TrimPrefix(notesRef, NotesRefPrefix)]
+}
+
+// setNotes replaces the notes of the commit under the given ref.
+func (c *Commit) setNotes(notesRef string, notes []json.RawMessage) error {
+	if archived := c.notes(notesRef); archived != nil {
+		*archived = notes
+		return nil
+	}
+	name := strings.TrimPrefix(notesRef, NotesRefPrefix)
+	if name == notesRef || name == "" {
+		return fmt.Errorf("the notes under %q are not archived", notesRef)
+	}
+	if len(notes) == 0 {
+		delete(c.Notes, name)
+		return nil
+	}
+	if c.Notes == nil {
+		c.Notes = make(map[string][]json.RawMessage)
+	}
+	c.Notes[name] = notes
+	return nil
+}
+
+// Archive holds the review metadata of a repository, by commit.
+//
+// It is a storage.Backend, so a repository can read its notes from an archive
+// with storage.New.
+type Archive struct {
+	Format  string             `json:"format"`
+	Version int                `json:"version"`
+	Commits map[string]*Commit `json:"commits"`
+}
+
+// New returns an empty archive.
+func New() *Archive {
+	return &Archive{
+		Format:  Format,
+		Version: Version,
+		Commits: make(map[string]*Commit),
+	}
+}
+
+// Export returns an archive of the notes on every commit of the given
+// repository, along with the number of notes that were left out because they
+// are not JSON, such as notes that were edited by hand.
+func Export(repo repository.Repo) (*Archive, int, error) {
+	notesRefs, err := repo.ListRefs(NotesRefPrefix)
+	if err != nil {
+		return nil, 0, err
+	}
+	a := New()
+	skipped := 0
+	for notesRef := range notesRefs {
+		allNotes, err := repo.GetAllNotes(notesRef)
+		if err != nil {
+			return nil, 0, err
+		}
+		for commit, notes := range allNotes {
+			for _, note := range notes {
+				if len(bytes.TrimSpace(note)) == 0 {
+					continue
+				}
+				if !json.Valid(note) {
+					skipped++
+					continue
+				}
+				if err := a.AppendNote(notesRef, commit, note); err != nil {
+					return nil, 0, err
+				}
+			}
+		}
+	}
+	return a, skipped, nil
+}
+
+// compact returns the given note without insignificant whitespace, which is
+// how the notes in an archive are read.
+func compact(note repository.Note) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, note); err != nil {
+		return string(note)
+	}
+	return compacted.String()
+}
+
+// Import adds the notes in the archive to the given repository, skipping
+// those that it already has, and returns the number of notes that were added.
+//
+// Notes are compared without their insignificant whitespace, so importing an
+// archive into the repository it was exported from adds nothing.
+func Import(repo repository.Repo, a *Archive) (int, error) {
+	notesRefs, err := a.ListNotesRefs()
+	if err != nil {
+		return 0, err
+	}
+	imported := 0
+	for _, notesRef := range notesRefs {
+		objects, err := a.ListNotedObjects(notesRef)
+		if err != nil {
+			return imported, err
+		}
+		for _, object := range objects {
+			existing := make(map[string]bool)
+			for _, note := range repo.GetNotes(notesRef, object) {
+				existing[compact(note)] = true
+			}
+			for _, note := range a.GetNotes(notesRef, object) {
+				if existing[string(note)] {
+					continue
+				}
+				if err := repo.AppendNote(notesRef, object, note); err != nil {
+					return imported, fmt.Errorf("failed to import a note on %q: %v", object, err)
+				}
+				existing[string(note)] = true
+				imported++
+			}
+		}
+	}
+	return imported, nil
+}
+
+// Read reads an archive, checking that it is of a version that can be read.
+func Read(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, err
+	}
+	if a.Format != Format {
+		return nil, fmt.Errorf("not an archive of reviews: the format is %q rather than %q", a.Format, Format)
+	}
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d; only versions up to %d can be read", a.Version, Version)
+	}
+	if a.Commits == nil {
+		a.Commits = make(map[string]*Commit)
+	}
+	for commit, c := range a.Commits {
+		for name := range c.Notes {
+			if c.notes(NotesRefPrefix+name) != nil {
+				return nil, fmt.Errorf("the notes of %q under %q must not be kept under \"notes\"", commit, NotesRefPrefix+name)
+			}
+		}
+	}
+	return &a, nil
+}
+
+// Write writes the archive as indented JSON.
+func (a *Archive) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
+
+// GetNotes reads the notes from the given ref that annotate the given revision.
+func (a *Archive) GetNotes(notesRef, revision string) []repository.Note {
+	c, ok := a.Commits[revision]
+	if !ok {
+		return nil
+	}
+	var result []repository.Note
+	for _, note := range c.getNotes(notesRef) {
+		result = append(result, repository.Note(compact(repository.Note(note))))
+	}
+	return result
+}
+
+// GetAllNotes reads the notes under the given ref for every commit that they annotate.
+func (a *Archive) GetAllNotes(notesRef string) (map[string][]repository.Note, error) {
+	allNotes := make(map[string][]repository.Note)
+	objects, err := a.ListNotedObjects(notesRef)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		allNotes[object] = a.GetNotes(notesRef, object)
+	}
+	return allNotes, nil
+}
+
+// AppendNote appends a note to a revision under the given ref.
+//
+// Only the notes refs under NotesRefPrefix are archived, and their notes must
+// each be a JSON value.
+func (a *Archive) AppendNote(notesRef, revision string, note repository.Note) error {
+	var notes []repository.Note
+	if c, ok := a.Commits[revision]; ok {
+		for _, archived := range c.getNotes(notesRef) {
+			notes = append(notes, repository.Note(archived))
+		}
+	}
+	return a.SetNotes(notesRef, revision, append(notes, note))
+}
+
+// SetNotes replaces the notes on a commit under the given ref.
+func (a *Archive) SetNotes(notesRef, object string, notes []repository.Note) error {
+	var replaced []json.RawMessage
+	for _, note := range notes {
+		if !json.Valid(note) {
+			return fmt.Errorf("the note on %q is not valid JSON", object)
+		}
+		replaced = append(replaced, json.RawMessage(append([]byte(nil), note...)))
+	}
+	c, ok := a.Commits[object]
+	if !ok {
+		c = &Commit{}
+	}
+	if err := c.setNotes(notesRef, replaced); err != nil {
+		return err
+	}
+	a.Commits[object] = c
+	return nil
+}
+
+// ListNotedObjects returns the hashes of every commit annotated by notes in the given ref.
+func (a *Archive) ListNotedObjects(notesRef string) ([]string, error) {
+	var objects []string
+	for object, c := range a.Commits {
+		if len(c.getNotes(notesRef)) > 0 {
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
+// ListNotesRefs returns the refs that have notes in the archive.
+func (a *Archive) ListNotesRefs() ([]string, error) {
+	found := make(map[string]bool)
+	for _, c := range a.Commits {
+		for _, notesRef := range []string{request.Ref, comment.Ref, ci.Ref, analyses.Ref} {
+			if len(c.getNotes(notesRef)) > 0 {
+				found[notesRef] = true
+			}
+		}
+		for name, notes := range c.Notes {
+			if len(notes) > 0 {
+				found[NotesRefPrefix+name] = true
+			}
+		}
+	}
+	var notesRefs []string
+	for notesRef := range found {
+		notesRefs = append(notesRefs, notesRef)
+	}
+	sort.Strings(notesRefs)
+	return notesRefs, nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/benchmark"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/storage"
+)
+
+func TestExportImport(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	repo.AppendNote(comment.Ref, repository.TestCommitB, repository.Note("not json"))
+	repo.AppendNote(benchmark.Ref, repository.TestCommitB, repository.Note(`{"results": []}`))
+	a, skipped, err := Export(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 {
+		t.Errorf("Unexpected number of skipped notes: %d", skipped)
+	}
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing into the same repository adds nothing.
+	if imported, err := Import(repo, read); err != nil || imported != 0 {
+		t.Errorf("Unexpected import into the exported repository: %d, %v", imported, err)
+	}
+
+	// A repository with the same commits, but none of the notes.
+	want := review.ListAll(repo)
+	target := storage.New(repo, storage.Files(t.TempDir()))
+	if len(review.ListAll(target)) != 0 {
+		t.Fatal("The empty repository has reviews")
+	}
+	imported, err := Import(target, read)
+	if err != nil || imported == 0 {
+		t.Fatalf("Unexpected import: %d, %v", imported, err)
+	}
+	if notes := target.GetNotes(benchmark.Ref, repository.TestCommitB); len(notes) != 1 {
+		t.Errorf("Unexpected benchmark notes after the import: %q", notes)
+	}
+	got := review.ListAll(target)
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected reviews after the import: %d; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Revision != want[i].Revision || len(got[i].Comments) != len(want[i].Comments) {
+			t.Errorf("Unexpected review after the import: %+v; want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, archive := range []string{
+		`{"format": "something-else", "version": 1}`,
+		`{"format": "git-appraise-archive", "version": 3}`,
+		`{"format": "git-appraise-archive", "version": 2, "commits": {"abc": {"notes": {"reviews": [{}]}}}}`,
+		`{"format": "git-appraise-archive"}`,
+		`not json`,
+	} {
+		if _, err := Read(strings.NewReader(archive)); err == nil {
+			t.Errorf("Unexpectedly read the archive %s", archive)
+		}
+	}
+}
+
+func TestReadVersion1(t *testing.T) {
+	a, err := Read(strings.NewReader(`{"format": "git-appraise-archive", "version": 1, "commits": {"abc": {"comments": [{"description": "old"}]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := a.ListNotesRefs(); err != nil || len(refs) != 1 || refs[0] != comment.Ref {
+		t.Errorf("Unexpected refs in a version 1 archive: %v, %v", refs, err)
+	}
+}
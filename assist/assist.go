@@ -0,0 +1,191 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package assist hands the diff of a review to an external assistant, such
+// as a language model or a linter, and parses the comments that it proposes.
+package assist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/comment"
+)
+
+// File is the part of a review's diff that changes a single file.
+type File struct {
+	Path  string `json:"path"`
+	Patch string `json:"patch"`
+}
+
+// Request is what the assistant is given, as a JSON object.
+type Request struct {
+	Revision    string `json:"revision"`
+	Requester   string `json:"requester,omitempty"`
+	Description string `json:"description,omitempty"`
+	BaseCommit  string `json:"baseCommit"`
+	HeadCommit  string `json:"headCommit"`
+	Files       []File `json:"files"`
+}
+
+// Suggestion is a comment proposed by the assistant.
+//
+// Its JSON form is accepted by the --stdin-json flag of the comment command.
+type Suggestion struct {
+	Path  string         `json:"path,omitempty"`
+	Range *comment.Range `json:"range,omitempty"`
+	Body  string         `json:"body"`
+	Kind  string         `json:"kind,omitempty"`
+}
+
+// response is what the assistant returns, which is either a list of
+// comments, or analysis results in the format of the analyses schema.
+type response struct {
+	Comments        []Suggestion               `json:"comments,omitempty"`
+	AnalyzeResponse []analyses.AnalyzeResponse `json:"analyze_response,omitempty"`
+	Notes           []analyses.Note            `json:"note,omitempty"`
+}
+
+// Assistant is an external command or HTTP(S) endpoint that proposes
+// comments on a review.
+type Assistant struct {
+	// Command is run by the shell, with the request on its standard input,
+	// and writes its response to its standard output.
+	Command string
+	// URL is sent the request in the body of a POST, and responds with the
+	// response. It is only used if Command is empty.
+	URL string
+}
+
+// SplitDiff splits a diff in git's format into the changes to each file.
+func SplitDiff(diff string) []File {
+	var files []File
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			path := strings.TrimSpace(line)
+			if i := strings.LastIndex(path, " b/"); i >= 0 {
+				path = path[i+len(" b/"):]
+			}
+			files = append(files, File{Path: path})
+		}
+		if len(files) > 0 {
+			files[len(files)-1].Patch += line
+		}
+	}
+	return files
+}
+
+// ParseResponse parses the comments proposed by an assistant.
+func ParseResponse(data []byte) ([]Suggestion, error) {
+	var r response
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse the assistant's response: %v", err)
+	}
+	suggestions := r.Comments
+	notes := r.Notes
+	for _, analyzeResponse := range r.AnalyzeResponse {
+		notes = append(notes, analyzeResponse.Notes...)
+	}
+	for _, note := range notes {
+		suggestions = append(suggestions, fromNote(note))
+	}
+	for i, s := range suggestions {
+		if strings.TrimSpace(s.Body) == "" {
+			return nil, fmt.Errorf("proposed comment %d has no body", i+1)
+		}
+		if s.Range != nil && s.Path == "" {
+			return nil, fmt.Errorf("proposed comment %d has a range, but no path", i+1)
+		}
+		if err := comment.CheckKind(s.Kind); err != nil {
+			return nil, fmt.Errorf("proposed comment %d: %v", i+1, err)
+		}
+	}
+	return suggestions, nil
+}
+
+// fromNote converts an analysis message into a proposed comment.
+func fromNote(note analyses.Note) Suggestion {
+	s := Suggestion{Body: note.Description}
+	if note.Category != "" {
+		s.Body = fmt.Sprintf("[%s] %s", note.Category, note.Description)
+	}
+	if note.Location != nil {
+		s.Path = note.Location.Path
+		if r := note.Location.Range; r != nil && r.StartLine > 0 {
+			s.Range = &comment.Range{
+				StartLine:   r.StartLine,
+				StartColumn: r.StartColumn,
+				EndLine:     r.EndLine,
+				EndColumn:   r.EndColumn,
+			}
+		}
+	}
+	return s
+}
+
+// Run gives the request to the assistant, and returns the comments that it proposes.
+func (a Assistant) Run(req Request) ([]Suggestion, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch {
+	case a.Command != "":
+		data, err = a.runCommand(payload)
+	case a.URL != "":
+		data, err = a.post(payload)
+	default:
+		err = errors.New("no assistant is configured")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(data)
+}
+
+func (a Assistant) runCommand(payload []byte) ([]byte, error) {
+	cmd := exec.Command("sh", "-c", a.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = os.Stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("the assistant %q failed: %v", a.Command, err)
+	}
+	return data, nil
+}
+
+func (a Assistant) post(payload []byte) ([]byte, error) {
+	resp, err := http.Post(a.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("the assistant responded with %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return ioutil.ReadAll(resp.Body)
+}
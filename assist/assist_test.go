@@ -0,0 +1,111 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package foo
++package main
+diff --git a/docs/README b/docs/README
+new file mode 100644
+`
+
+func TestSplitDiff(t *testing.T) {
+	files := SplitDiff(testDiff)
+	if len(files) != 2 || files[0].Path != "main.go" || files[1].Path != "docs/README" {
+		t.Fatalf("Unexpected files: %+v", files)
+	}
+	if files[0].Patch+files[1].Patch != testDiff {
+		t.Errorf("The patches do not add up to the diff: %+v", files)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	suggestions, err := ParseResponse([]byte(`{"comments": [{"path": "main.go", "range": {"startLine": 1}, "body": "Why rename the package?", "kind": "question"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Range.StartLine != 1 || suggestions[0].Kind != "question" {
+		t.Errorf("Unexpected comments: %+v", suggestions)
+	}
+
+	suggestions, err = ParseResponse([]byte(`{"analyze_response": [{"note": [{"location": {"path": "main.go", "range": {"start_line": 1}}, "category": "lint", "description": "Missing doc comment"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Path != "main.go" || suggestions[0].Range.StartLine != 1 || suggestions[0].Body != "[lint] Missing doc comment" {
+		t.Errorf("Unexpected comments from analysis results: %+v", suggestions)
+	}
+
+	for _, invalid := range []string{
+		`{"comments": [{"path": "main.go"}]}`,
+		`{"comments": [{"range": {"startLine": 1}, "body": "No path"}]}`,
+		`{"comments": [{"body": "Unknown kind", "kind": "urgent"}]}`,
+		`not JSON`,
+	} {
+		if _, err := ParseResponse([]byte(invalid)); err == nil {
+			t.Errorf("Failed to reject the response %q", invalid)
+		}
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	a := Assistant{Command: `cat >/dev/null; echo '{"comments": [{"body": "Looks fine"}]}'`}
+	suggestions, err := a.Run(Request{Revision: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Body != "Looks fine" {
+		t.Errorf("Unexpected comments: %+v", suggestions)
+	}
+	if _, err := (Assistant{Command: "exit 1"}).Run(Request{}); err == nil {
+		t.Error("Unexpected success running a failing command")
+	}
+}
+
+func TestRunURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var r Request
+		if err := json.NewDecoder(req.Body).Decode(&r); err != nil || r.Revision != "abc" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"note": [{"description": "Consider a test"}]}`))
+	}))
+	defer server.Close()
+
+	suggestions, err := (Assistant{URL: server.URL}).Run(Request{Revision: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Body != "Consider a test" {
+		t.Errorf("Unexpected comments: %+v", suggestions)
+	}
+	if _, err := (Assistant{URL: server.URL}).Run(Request{}); err == nil {
+		t.Error("Unexpected success when the endpoint rejects the request")
+	}
+}
@@ -33,12 +33,16 @@ var abandonFlagSet = flag.NewFlagSet("abandon", flag.ExitOnError)
 
 var (
 	abandonMessageFile = abandonFlagSet.String("F", "", "Take the comment from the given file. Use - to read the message from the standard input")
-	abandonMessage     = abandonFlagSet.String("m", "", "Message to attach to the review")
+	abandonMessages    input.Messages
 
 	abandonSign = abandonFlagSet.Bool("S", false,
 		"Sign the contents of the abandonment")
 )
 
+func init() {
+	abandonFlagSet.Var(&abandonMessages, "m", "`Message` to attach to the review. If given multiple times, each becomes a separate paragraph; combined with -F, the file is used as the body")
+}
+
 // abandonReview adds an NMW comment to the current code review.
 func abandonReview(repo repository.Repo, args []string) error {
 	abandonFlagSet.Parse(args)
@@ -57,20 +61,19 @@ func abandonReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
 	}
 
-	if *abandonMessageFile != "" && *abandonMessage == "" {
-		*abandonMessage, err = input.FromFile(*abandonMessageFile)
-		if err != nil {
-			return err
-		}
+	message, err := input.AssembleMessage(abandonMessages, *abandonMessageFile)
+	if err != nil {
+		return err
 	}
-	if *abandonMessageFile == "" && *abandonMessage == "" {
-		*abandonMessage, err = input.LaunchEditor(repo, commentFilename)
+	if *abandonMessageFile == "" && message == "" {
+		message, err = input.LaunchEditor(repo, commentFilename,
+			fmt.Sprintf("Please explain why review %.12s is being abandoned.", r.Revision))
 		if err != nil {
 			return err
 		}
@@ -88,7 +91,7 @@ func abandonReview(repo repository.Repo, args []string) error {
 	if err != nil {
 		return err
 	}
-	c := comment.New(userEmail, *abandonMessage)
+	c := comment.New(userEmail, message)
 	c.Location = &location
 	c.Resolved = &resolved
 
@@ -133,7 +136,7 @@ var abandonCmd = &Command{
 		fmt.Printf("Usage: %s abandon [<option>...] [<commit>]\n\nOptions:\n", arg0)
 		abandonFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return abandonReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return abandonReview(ctx.Repo, args)
 	},
 }
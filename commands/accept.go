@@ -20,25 +20,191 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/ci"
 	"github.com/google/git-appraise/review/comment"
 	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/teams"
 )
 
 var acceptFlagSet = flag.NewFlagSet("accept", flag.ExitOnError)
 
 var (
 	acceptMessageFile = acceptFlagSet.String("F", "", "Take the comment from the given file. Use - to read the message from the standard input")
-	acceptMessage     = acceptFlagSet.String("m", "", "Message to attach to the review")
+	acceptMessages    input.Messages
 	acceptDate        = acceptFlagSet.String("date", "", "Date to use for the review")
 	acceptSign        = acceptFlagSet.Bool("S", false,
 		"sign the contents of the acceptance")
+	acceptAndSubmit = acceptFlagSet.Bool("and-submit", false,
+		"Also submit the review using the configured submit strategy (signed, if -S is given), and push the review notes")
+	acceptRemote = acceptFlagSet.String("remote", "origin",
+		"Remote to push the review notes to; only used with --and-submit")
+	acceptOnBehalfOf = acceptFlagSet.String("on-behalf-of", "",
+		"Accept as a delegate of the given reviewer team, which you must be a lead of (as listed in the team's "+teams.LeadsSuffix+" team in "+teams.File+")")
 )
 
+func init() {
+	acceptFlagSet.Var(&acceptMessages, "m", "`Message` to attach to the review. If given multiple times, each becomes a separate paragraph; combined with -F, the file is used as the body")
+}
+
+// checkSubmittableOnceAccepted verifies that the given review could be submitted
+// once the given user accepts it, so that the acceptance is not recorded
+// if the review is going to be blocked anyway.
+func checkSubmittableOnceAccepted(repo repository.Repo, r *review.Review, userEmail string) error {
+	if r.Submitted {
+		return errors.New("The review has already been submitted.")
+	}
+	if r.Resolved != nil && !*r.Resolved {
+		return errors.New("The review has been rejected.")
+	}
+	if unresolved := r.UnresolvedThreads(); len(unresolved) > 0 {
+		return unresolvedThreadsError(unresolved)
+	}
+	ciReport, err := ci.GetLatestCIReport(r.Reports)
+	if err != nil {
+		return err
+	}
+	if ciReport != nil && ciReport.Status == ci.StatusFailure {
+		return fmt.Errorf("The latest build of the review failed (%q).", ciReport.URL)
+	}
+	if r.Request.TargetTag != "" {
+		quorum, err := getReleaseQuorum(repo)
+		if err != nil {
+			return err
+		}
+		accepted := make(map[string]bool)
+		for _, reviewer := range r.AcceptedBy() {
+			accepted[reviewer] = true
+		}
+		accepted[userEmail] = true
+		if len(accepted) < quorum {
+			return fmt.Errorf("The release requires %d accepting reviewers, but would only have %d.", quorum, len(accepted))
+		}
+		return nil
+	}
+	if err := repo.VerifyGitRef(r.Request.TargetRef); err != nil {
+		return err
+	}
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	isAncestor, err := repo.IsAncestor(r.Request.TargetRef, head)
+	if err != nil {
+		return err
+	}
+	if !isAncestor {
+		return errors.New("The review is not a fast-forward of its target. First merge the target ref.")
+	}
+	return nil
+}
+
+// getDelegation returns the delegation for the given user accepting the review
+// on behalf of the given team, as defined in the given teams.
+//
+// The team must have been asked to review the change, and the user must be
+// one of its leads.
+func getDelegation(r *review.Review, definedTeams map[string][]string, team, userEmail string) (*comment.Delegation, error) {
+	team = strings.TrimPrefix(team, teams.Prefix)
+	if err := r.CheckDelegation(definedTeams, team, userEmail); err != nil {
+		return nil, err
+	}
+	return &comment.Delegation{Actor: userEmail, Team: team}, nil
+}
+
+// submitAcceptedReview submits a review that has just been accepted, and
+// then pushes the review notes.
+//
+// If the submit fails before the review's target was updated, then the
+// acceptance is withdrawn by restoring the comments ref to the given prior
+// state. Otherwise the review was submitted, so the acceptance is kept.
+func submitAcceptedReview(repo repository.Repo, r *review.Review, priorComments string) error {
+	acceptedComments, err := repo.GetCommitHash(comment.Ref)
+	if err != nil {
+		return err
+	}
+	target := r.Request.TargetRef
+	if r.Request.TargetTag != "" {
+		target = "refs/tags/" + r.Request.TargetTag
+	}
+	// The target might not exist yet, such as the tag of a release.
+	priorTarget, _ := repo.GetCommitHash(target)
+	submitArgs := []string{r.Revision}
+	if *acceptSign {
+		submitArgs = append([]string{"-S"}, submitArgs...)
+	}
+	if err := submitReview(repo, submitArgs); err != nil {
+		if submittedTarget, _ := repo.GetCommitHash(target); submittedTarget != priorTarget {
+			notifyReview(repo, r.Revision, "accepted")
+			return fmt.Errorf("The review was submitted, but then: %v", err)
+		}
+		if rollbackErr := repo.SetRef(comment.Ref, priorComments, acceptedComments); rollbackErr != nil {
+			return fmt.Errorf("Failed to submit the review (%v), and then failed to withdraw the acceptance: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("Failed to submit the review, so the acceptance was withdrawn: %v", err)
+	}
+	notifyReview(repo, r.Revision, "accepted")
+	if err := repo.PushNotesAndArchive(*acceptRemote, notesRefPattern, archiveRefPattern); err != nil {
+		return fmt.Errorf("The review was submitted, but pushing the review notes failed: %v", err)
+	}
+	return nil
+}
+
+// checkAcceptedForReuse returns an error unless the changes of the given
+// review were accepted in a way that lets them be accepted again without
+// another review: the review must have been submitted, its head must be a
+// commit that it accepted, and, if appraise.reuse.requireSigned is set, its
+// acceptance must verify.
+func checkAcceptedForReuse(repo repository.Repo, r *review.Review) error {
+	if !r.Submitted {
+		return fmt.Errorf("review %.12s has not been submitted", r.Revision)
+	}
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	if !r.IsAcceptedAt(head) {
+		return fmt.Errorf("the head %.12s of review %.12s was not accepted", head, r.Revision)
+	}
+	requireSigned, err := config.Get(repo, "appraise.reuse.requireSigned")
+	if err != nil {
+		return err
+	}
+	if requireSigned == "true" {
+		if err := r.VerifyAcceptance(); err != nil {
+			return fmt.Errorf("the acceptance of review %.12s failed verification: %v", r.Revision, err)
+		}
+	}
+	return nil
+}
+
+// acceptAutomatically accepts the given review at its head commit, as the
+// given user, with a message that explains why it did not need reviewing.
+func acceptAutomatically(r *review.Review, userEmail, message, key string) error {
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	resolved := true
+	c := comment.New(userEmail, message)
+	c.Timestamp = FormatDate(&now)
+	c.Location = &comment.Location{Commit: head}
+	c.Resolved = &resolved
+	if key != "" {
+		if err := gpg.Sign(key, &c); err != nil {
+			return err
+		}
+	}
+	return r.AddComment(c)
+}
+
 // acceptReview adds an LGTM comment to the current code review.
 func acceptReview(repo repository.Repo, args []string) error {
 	acceptFlagSet.Parse(args)
@@ -57,7 +223,7 @@ func acceptReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
@@ -75,12 +241,29 @@ func acceptReview(repo repository.Repo, args []string) error {
 	if err != nil {
 		return err
 	}
-
-	if *acceptMessageFile != "" && *acceptMessage == "" {
-		*acceptMessage, err = input.FromFile(*acceptMessageFile)
+	var delegation *comment.Delegation
+	if *acceptOnBehalfOf != "" {
+		definedTeams, err := teams.Load(repo, r.Request.TargetRef)
 		if err != nil {
 			return err
 		}
+		if delegation, err = getDelegation(r, definedTeams, *acceptOnBehalfOf, userEmail); err != nil {
+			return err
+		}
+	}
+	var priorComments string
+	if *acceptAndSubmit {
+		if err := checkSubmittableOnceAccepted(repo, r, userEmail); err != nil {
+			return fmt.Errorf("Not accepting the review, as it could not be submitted: %v", err)
+		}
+		// The comments ref might not exist yet, in which case withdrawing
+		// the acceptance deletes it.
+		priorComments, _ = repo.GetCommitHash(comment.Ref)
+	}
+
+	message, err := input.AssembleMessage(acceptMessages, *acceptMessageFile)
+	if err != nil {
+		return err
 	}
 
 	date, err := GetDate(*acceptDate)
@@ -92,9 +275,10 @@ func acceptReview(repo repository.Repo, args []string) error {
 		date = &now
 	}
 	timestamp := FormatDate(date)
-	c := comment.New(userEmail, *acceptMessage)
+	c := comment.New(userEmail, message)
 	c.Location = &location
 	c.Resolved = &resolved
+	c.Delegation = delegation
 	if len(timestamp) > 0 {
 		c.Timestamp = timestamp
 	}
@@ -109,7 +293,15 @@ func acceptReview(repo repository.Repo, args []string) error {
 			return err
 		}
 	}
-	return r.AddComment(c)
+	if err := r.AddComment(c); err != nil {
+		return err
+	}
+	if *acceptAndSubmit {
+		// The acceptance is only announced once it can no longer be withdrawn.
+		return submitAcceptedReview(repo, r, priorComments)
+	}
+	notifyReview(repo, r.Revision, "accepted")
+	return nil
 }
 
 // acceptCmd defines the "accept" subcommand.
@@ -118,7 +310,7 @@ var acceptCmd = &Command{
 		fmt.Printf("Usage: %s accept [<option>...] [<commit>]\n\nOptions:\n", arg0)
 		acceptFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return acceptReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return acceptReview(ctx.Repo, args)
 	},
 }
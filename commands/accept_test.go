@@ -0,0 +1,202 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+)
+
+func TestGetDelegation(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Request.ReviewerTeams = map[string][]string{"core": {"someone"}}
+	definedTeams := map[string][]string{
+		"core":       {"someone"},
+		"core/leads": {"lead@example.com"},
+	}
+
+	delegation, err := getDelegation(r, definedTeams, "team:core", "lead@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delegation.Actor != "lead@example.com" || delegation.Team != "core" {
+		t.Errorf("Unexpected delegation: %+v", delegation)
+	}
+	if _, err := getDelegation(r, definedTeams, "core", "someone"); err == nil {
+		t.Error("Unexpected delegation to a member who is not a lead")
+	}
+	if _, err := getDelegation(r, definedTeams, "docs", "lead@example.com"); err == nil {
+		t.Error("Unexpected delegation for a team that was not asked to review")
+	}
+}
+
+// repoForAcceptAndSubmit tracks the comments ref, which the mock repo keeps
+// no ref for, so that the acceptance can be withdrawn, and records the
+// remotes that notes are pushed to.
+type repoForAcceptAndSubmit struct {
+	repository.Repo
+	comments    int
+	withdrawn   bool
+	uncommitted bool
+	// failRestore makes checking out anything fail once the review was
+	// merged, so that the submit fails after the target was updated.
+	failRestore bool
+	merged      bool
+	pushedTo    []string
+}
+
+func (r *repoForAcceptAndSubmit) AppendNote(ref, revision string, note repository.Note) error {
+	if ref == comment.Ref {
+		r.comments++
+	}
+	return r.Repo.AppendNote(ref, revision, note)
+}
+
+func (r *repoForAcceptAndSubmit) GetCommitHash(ref string) (string, error) {
+	if ref == comment.Ref {
+		return fmt.Sprintf("comments-%d", r.comments), nil
+	}
+	return r.Repo.GetCommitHash(ref)
+}
+
+func (r *repoForAcceptAndSubmit) SetRef(ref, newCommitHash, previousCommitHash string) error {
+	if ref == comment.Ref {
+		if previousCommitHash != fmt.Sprintf("comments-%d", r.comments) {
+			return fmt.Errorf("unexpected previous comments %q", previousCommitHash)
+		}
+		r.withdrawn = newCommitHash == fmt.Sprintf("comments-%d", r.comments-1)
+		return nil
+	}
+	return r.Repo.SetRef(ref, newCommitHash, previousCommitHash)
+}
+
+func (r *repoForAcceptAndSubmit) MergeRef(ref string, fastForward bool, messages ...string) error {
+	r.merged = true
+	return r.Repo.MergeRef(ref, fastForward, messages...)
+}
+
+func (r *repoForAcceptAndSubmit) SwitchToRef(ref string) error {
+	if r.failRestore && r.merged {
+		return fmt.Errorf("unable to check out %q", ref)
+	}
+	return r.Repo.SwitchToRef(ref)
+}
+
+func (r *repoForAcceptAndSubmit) HasUncommittedChanges() (bool, error) {
+	return r.uncommitted, nil
+}
+
+func (r *repoForAcceptAndSubmit) PushNotesAndArchive(remote, notesRefPattern, archiveRefPattern string) error {
+	r.pushedTo = append(r.pushedTo, remote)
+	return nil
+}
+
+// newRepoForAcceptAndSubmit returns a repo in which the pending review G is a
+// fast-forward of its target, so that it can be submitted once accepted.
+func newRepoForAcceptAndSubmit(t *testing.T) *repoForAcceptAndSubmit {
+	repo := &repoForAcceptAndSubmit{Repo: repository.NewMockRepoForTest()}
+	if err := repo.SetRef(repository.TestTargetRef, repository.TestCommitF, repository.TestCommitJ); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// resetAcceptAndSubmitFlags resets the flags set by accept --and-submit,
+// including the submit strategy that submitting sets from the config.
+func resetAcceptAndSubmitFlags() {
+	*acceptAndSubmit = false
+	*acceptSign = false
+	*submitSign = false
+	*submitMerge = false
+	*submitRebase = false
+	*submitFastForward = false
+}
+
+func TestAcceptAndSubmit(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	repo := newRepoForAcceptAndSubmit(t)
+	if err := acceptReview(repo, []string{"--and-submit", repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Resolved == nil || !*r.Resolved {
+		t.Errorf("The review was not accepted: %v", r.Resolved)
+	}
+	if submitted, err := repo.IsAncestor(repository.TestCommitI, repository.TestTargetRef); err != nil || !submitted {
+		t.Errorf("The review was not submitted into its target: %v", err)
+	}
+	if len(repo.pushedTo) != 1 || repo.pushedTo[0] != "origin" {
+		t.Errorf("Unexpected pushes of the review notes: %q", repo.pushedTo)
+	}
+}
+
+func TestAcceptAndSubmitWithdrawsAcceptance(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	repo := newRepoForAcceptAndSubmit(t)
+	repo.uncommitted = true
+	err := acceptReview(repo, []string{"--and-submit", repository.TestCommitG})
+	if err == nil || !strings.Contains(err.Error(), "the acceptance was withdrawn") {
+		t.Fatalf("Unexpected result of accepting a review that can not be submitted: %v", err)
+	}
+	if !repo.withdrawn {
+		t.Error("The acceptance was not withdrawn")
+	}
+	if len(repo.pushedTo) != 0 {
+		t.Errorf("Unexpected pushes of the review notes: %q", repo.pushedTo)
+	}
+}
+
+func TestAcceptAndSubmitKeepsAcceptanceOnceSubmitted(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	repo := newRepoForAcceptAndSubmit(t)
+	repo.failRestore = true
+	err := acceptReview(repo, []string{"--and-submit", repository.TestCommitG})
+	if err == nil || !strings.Contains(err.Error(), "The review was submitted") {
+		t.Fatalf("Unexpected result of a submit that failed after updating the target: %v", err)
+	}
+	if repo.withdrawn {
+		t.Error("The acceptance of a submitted review was withdrawn")
+	}
+	if submitted, err := repo.IsAncestor(repository.TestCommitI, repository.TestTargetRef); err != nil || !submitted {
+		t.Errorf("The review was not submitted into its target: %v", err)
+	}
+}
+
+func TestAcceptAndSubmitBlocked(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	// The target of the review has moved past it.
+	repo := &repoForAcceptAndSubmit{Repo: repository.NewMockRepoForTest()}
+	err := acceptReview(repo, []string{"--and-submit", repository.TestCommitG})
+	if err == nil || !strings.Contains(err.Error(), "Not accepting the review") {
+		t.Fatalf("Unexpected result of accepting a review that is not a fast-forward: %v", err)
+	}
+	if repo.comments != 0 {
+		t.Errorf("Unexpected comments on a review that could not be submitted: %d", repo.comments)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+)
+
+var retargetFlagSet = flag.NewFlagSet("retarget", flag.ExitOnError)
+
+var (
+	retargetFrom         = retargetFlagSet.String("from", "", "The target ref to move reviews away from (e.g. refs/heads/master)")
+	retargetTo           = retargetFlagSet.String("to", "", "The target ref to move reviews to (e.g. refs/heads/main)")
+	retargetUpdateConfig = retargetFlagSet.Bool("update-config", false, "Also make the new ref the default target for future reviews")
+	retargetDryRun       = retargetFlagSet.Bool("n", false, "Only list the reviews that would be retargeted")
+	retargetSign         = retargetFlagSet.Bool("S", false, "Sign the updated review requests")
+)
+
+// retargetReviews moves every open review from one target ref to another.
+//
+// This is done by appending an updated copy of each affected review request,
+// so the history of the original target is preserved in the notes.
+func retargetReviews(repo repository.Repo, args []string) error {
+	retargetFlagSet.Parse(args)
+	if len(retargetFlagSet.Args()) > 0 {
+		return errors.New("The retarget command does not take any positional arguments.")
+	}
+	if *retargetFrom == "" || *retargetTo == "" {
+		return errors.New("Both the --from and --to flags are required.")
+	}
+	if *retargetFrom == *retargetTo {
+		return errors.New("The --from and --to refs must be different.")
+	}
+	if err := repo.VerifyGitRef(*retargetTo); err != nil {
+		return fmt.Errorf("Unable to retarget to %q: %v", *retargetTo, err)
+	}
+
+	var key string
+	if *retargetSign && !*retargetDryRun {
+		var err error
+		key, err = repo.GetUserSigningKey()
+		if err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	timestamp := FormatDate(&now)
+	for _, r := range review.ListOpen(repo) {
+		if r.Request.TargetRef != *retargetFrom {
+			continue
+		}
+		output.Infof("Retargeting review %.12s: %q -> %q\n", r.Revision, *retargetFrom, *retargetTo)
+		if *retargetDryRun {
+			continue
+		}
+		updated := r.Request
+		updated.TargetRef = *retargetTo
+		updated.Timestamp = timestamp
+		updated.Sig = gpg.Sig{}
+		if *retargetSign {
+			if err := gpg.Sign(key, &updated); err != nil {
+				return err
+			}
+		}
+		note, err := updated.Write()
+		if err != nil {
+			return err
+		}
+		if err := repo.AppendNote(request.Ref, r.Revision, note); err != nil {
+			return err
+		}
+	}
+
+	if *retargetUpdateConfig && !*retargetDryRun {
+		return repo.SetDefaultTargetRef(*retargetTo)
+	}
+	return nil
+}
+
+var retargetCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s admin retarget --from <ref> --to <ref> [<option>...]\n\nOptions:\n", arg0)
+		retargetFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return retargetReviews(ctx.Repo, args)
+	},
+}
+
+// adminCmd defines the "admin" subcommand, which groups together the
+// maintenance operations that affect every review in the repository.
+var adminCmd = newCommandGroup("admin", map[string]*Command{
+	"retarget": retargetCmd,
+})
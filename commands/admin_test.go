@@ -0,0 +1,49 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestRetargetReviews(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	args := []string{"--from", repository.TestTargetRef, "--to", repository.TestAlternateReviewRef, "--update-config"}
+	if err := retargetReviews(repo, args); err != nil {
+		t.Fatal(err)
+	}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Request.TargetRef != repository.TestAlternateReviewRef {
+		t.Fatalf("Unexpected target ref after retargeting: %q", r.Request.TargetRef)
+	}
+	if r.Request.Description != "Final description of G" {
+		t.Fatalf("Unexpected description after retargeting: %q", r.Request.Description)
+	}
+	if target, _ := repo.GetDefaultTargetRef(); target != repository.TestAlternateReviewRef {
+		t.Fatalf("Unexpected default target after retargeting: %q", target)
+	}
+
+	if err := retargetReviews(repo, []string{"--from", repository.TestTargetRef, "--to", "refs/heads/missing"}); err == nil {
+		t.Fatal("Failed to reject a missing target ref")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/analyses"
+)
+
+var applyFixFlagSet = flag.NewFlagSet("apply-fix", flag.ExitOnError)
+
+var (
+	applyFixList = applyFixFlagSet.Bool("list", false,
+		"List the analysis notes of the review that have suggested fixes, instead of applying one")
+	applyFixMessage = applyFixFlagSet.String("m", "",
+		"Message of the commit that applies the fix. Defaults to the description of the note")
+)
+
+// getFixableNotes returns the notes from the latest analyses of the review
+// that include a suggested fix.
+func getFixableNotes(r *review.Review) ([]analyses.Note, error) {
+	notes, err := r.GetAnalysesNotes()
+	if err != nil {
+		return nil, err
+	}
+	var fixable []analyses.Note
+	for _, note := range notes {
+		if note.Fix != "" {
+			fixable = append(fixable, note)
+		}
+	}
+	return fixable, nil
+}
+
+// getFixMessage returns the message of the commit that applies the fix
+// suggested by the given note.
+func getFixMessage(note *analyses.Note) string {
+	if *applyFixMessage != "" {
+		return *applyFixMessage
+	}
+	summary := strings.SplitN(strings.TrimSpace(note.Description), "\n", 2)[0]
+	if note.Category != "" {
+		summary = note.Category + ": " + summary
+	}
+	return "Apply suggested fix for " + summary
+}
+
+// applyFix commits the fix suggested by an analysis note to the review ref.
+func applyFix(repo repository.Repo, r *review.Review, id string) error {
+	if !r.IsOpen() {
+		return fmt.Errorf("The review %.12s is not open.", r.Revision)
+	}
+	notes, err := getFixableNotes(r)
+	if err != nil {
+		return err
+	}
+	note, err := analyses.FindNote(notes, id)
+	if err != nil {
+		return err
+	}
+
+	reviewRef := r.Request.ReviewRef
+	head, err := repo.GetCommitHash(reviewRef)
+	if err != nil {
+		return fmt.Errorf("The review ref %q must exist locally to apply a fix to it: %v", reviewRef, err)
+	}
+	analyzed, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	if head != analyzed {
+		return fmt.Errorf("The review ref %q has changed since it was analyzed.", reviewRef)
+	}
+	checkedOut := false
+	if headRef, err := repo.GetHeadRef(); err == nil && headRef == reviewRef {
+		checkedOut = true
+		hasUncommitted, err := repo.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if hasUncommitted {
+			return errors.New("You have uncommitted or untracked files; commit or stash them before applying a fix.")
+		}
+	}
+
+	tree, err := repo.ApplyPatch(head, note.Fix)
+	if err != nil {
+		return err
+	}
+	fixed, err := repo.CreateCommit(&repository.CommitDetails{
+		Tree:    tree,
+		Parents: []string{head},
+		Summary: getFixMessage(note),
+	})
+	if err != nil {
+		return err
+	}
+	if checkedOut {
+		// Fast-forwarding the checked out ref also updates the work tree.
+		err = repo.MergeRef(fixed, true)
+	} else {
+		err = repo.SetRef(reviewRef, fixed, head)
+	}
+	if err != nil {
+		return err
+	}
+	output.Infof("Applied the suggested fix to %s in %.12s\n", reviewRef, fixed)
+	return nil
+}
+
+// applyFixCmd defines the "apply-fix" subcommand.
+var applyFixCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s apply-fix [<option>...] <note-id> [<review-hash>]\n", arg0)
+		fmt.Printf("       %s apply-fix --list [<review-hash>]\n\nOptions:\n", arg0)
+		applyFixFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		applyFixFlagSet.Parse(args)
+		args = applyFixFlagSet.Args()
+
+		var id string
+		if !*applyFixList {
+			if len(args) == 0 {
+				return errors.New("The ID of the analysis note whose fix to apply is required.")
+			}
+			id, args = args[0], args[1:]
+		}
+		var r *review.Review
+		var err error
+		if len(args) > 1 {
+			return errors.New("Only applying fixes to a single review is supported.")
+		}
+		if len(args) == 1 {
+			r, err = review.Get(ctx.Repo, args[0])
+		} else {
+			r, err = review.GetCurrent(ctx.Repo)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to load the review: %v", err)
+		}
+		if r == nil {
+			return errors.New("There is no matching review.")
+		}
+
+		if *applyFixList {
+			notes, err := getFixableNotes(r)
+			if err != nil {
+				return err
+			}
+			return output.PrintFixes(notes)
+		}
+		return applyFix(ctx.Repo, r, id)
+	},
+}
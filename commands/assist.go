@@ -0,0 +1,217 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/git-appraise/assist"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+// assistDraftsDirname is the name of the directory (relative to the repo's
+// .git directory) that holds the comments proposed for each review, which
+// have not yet been published.
+const assistDraftsDirname = "appraise-assist"
+
+var assistFlagSet = flag.NewFlagSet("assist", flag.ExitOnError)
+
+var (
+	assistPublish = assistFlagSet.Bool("publish", false,
+		"Publish the proposed comments that were saved by an earlier run")
+	assistDiscard = assistFlagSet.Bool("discard", false,
+		"Discard the proposed comments that were saved by an earlier run")
+)
+
+// getAssistant returns the configured assistant.
+//
+// It is only read from git config, never from the committed settings file,
+// as the command would otherwise be run by anyone who clones the repo.
+func getAssistant(repo repository.Repo) (assist.Assistant, error) {
+	command, err := repo.GetConfig("appraise.assist.command")
+	if err != nil {
+		return assist.Assistant{}, err
+	}
+	url, err := repo.GetConfig("appraise.assist.url")
+	if err != nil {
+		return assist.Assistant{}, err
+	}
+	if command == "" && url == "" {
+		return assist.Assistant{}, errors.New("No assistant is configured; set appraise.assist.command or appraise.assist.url.")
+	}
+	return assist.Assistant{Command: command, URL: url}, nil
+}
+
+func assistDraftsPath(repo repository.Repo, revision string) string {
+	return filepath.Join(repo.GetGitDir(), assistDraftsDirname, revision+".jsonl")
+}
+
+// buildAssistRequest builds the request that describes the review to the assistant.
+func buildAssistRequest(r *review.Review) (assist.Request, error) {
+	baseCommit, err := r.GetBaseCommit()
+	if err != nil {
+		return assist.Request{}, err
+	}
+	headCommit, err := r.GetHeadCommit()
+	if err != nil {
+		return assist.Request{}, err
+	}
+	diff, err := r.Repo.Diff(baseCommit, headCommit)
+	if err != nil {
+		return assist.Request{}, err
+	}
+	return assist.Request{
+		Revision:    r.Revision,
+		Requester:   r.Request.Requester,
+		Description: r.Request.Description,
+		BaseCommit:  baseCommit,
+		HeadCommit:  headCommit,
+		Files:       assist.SplitDiff(diff),
+	}, nil
+}
+
+// saveAssistDrafts saves the proposed comments, one JSON object per line,
+// in the format read by the --stdin-json flag of the comment command.
+func saveAssistDrafts(path string, suggestions []assist.Suggestion) error {
+	var contents bytes.Buffer
+	encoder := json.NewEncoder(&contents)
+	encoder.SetEscapeHTML(false)
+	for _, s := range suggestions {
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents.Bytes(), 0644)
+}
+
+// publishAssistDrafts adds the saved proposed comments to the review.
+func publishAssistDrafts(r *review.Review, path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("There are no proposed comments on review %.12s; run git appraise assist first.", r.Revision)
+	}
+	if err != nil {
+		return err
+	}
+	jsonComments, err := readJSONComments(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if len(jsonComments) > 0 {
+		headCommit, err := r.GetHeadCommit()
+		if err != nil {
+			return err
+		}
+		comments, err := buildCommentsFromJSON(r, headCommit, jsonComments)
+		if err != nil {
+			return err
+		}
+		if err := r.AddComments(comments); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	output.Infof("Published %d comments on review %.12s\n", len(jsonComments), r.Revision)
+	return nil
+}
+
+// assistReview asks the configured assistant to propose comments on a
+// review, and saves them to be checked before they are published.
+func assistReview(repo repository.Repo, args []string) error {
+	assistFlagSet.Parse(args)
+	args = assistFlagSet.Args()
+	if *assistPublish && *assistDiscard {
+		return errors.New("You cannot combine the flags --publish and --discard.")
+	}
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only assisting with a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	path := assistDraftsPath(repo, r.Revision)
+	if *assistDiscard {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if *assistPublish {
+		return publishAssistDrafts(r, path)
+	}
+
+	assistant, err := getAssistant(repo)
+	if err != nil {
+		return err
+	}
+	req, err := buildAssistRequest(r)
+	if err != nil {
+		return err
+	}
+	suggestions, err := assistant.Run(req)
+	if err != nil {
+		return err
+	}
+	if len(suggestions) == 0 {
+		output.Infof("The assistant did not propose any comments on review %.12s\n", r.Revision)
+		return nil
+	}
+	output.PrintSuggestions(suggestions)
+	if err := saveAssistDrafts(path, suggestions); err != nil {
+		return err
+	}
+	output.Infof("Saved the proposed comments to %s; edit or remove any of them there, then publish them with git appraise assist --publish.\n", path)
+	return nil
+}
+
+// assistCmd defines the "assist" subcommand.
+var assistCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s assist [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		assistFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return assistReview(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+)
+
+// backportAutoAcceptConfig is the git config key that makes backports of
+// accepted reviews be accepted automatically, since their changes were
+// already reviewed.
+const backportAutoAcceptConfig = "appraise.backport.autoAccept"
+
+var backportFlagSet = flag.NewFlagSet("backport", flag.ExitOnError)
+
+var (
+	backportBranch = backportFlagSet.String("branch", "",
+		"Name of the branch to create for the backport; defaults to backport/<target-branch>/<review-hash>")
+	backportSign = backportFlagSet.Bool("S", false, "Sign the request, and the acceptance if there is one")
+)
+
+// getBackportRefs returns the ref that a review is backported to, and the
+// branch created for the backport, given the name of either.
+func getBackportRefs(target, branch, revision string) (string, string) {
+	targetRef := target
+	if !strings.HasPrefix(targetRef, "refs/") {
+		targetRef = "refs/heads/" + targetRef
+	}
+	if branch == "" {
+		branch = fmt.Sprintf("backport/%s/%.12s", strings.TrimPrefix(targetRef, "refs/heads/"), revision)
+	}
+	if !strings.HasPrefix(branch, "refs/") {
+		branch = "refs/heads/" + branch
+	}
+	return targetRef, branch
+}
+
+// cherryPickOnto cherry-picks the given commits on top of the given target
+// commit, and returns the new commits.
+//
+// The commits are cherry-picked onto a detached HEAD, so that no branch is
+// changed, and the current ref is restored afterwards.
+func cherryPickOnto(repo repository.Repo, targetCommit string, commits []string) ([]string, error) {
+	current, err := repo.GetHeadRef()
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.SwitchToRef(targetCommit); err != nil {
+		return nil, err
+	}
+	pickErr := repo.CherryPick(commits...)
+	var head string
+	if pickErr == nil {
+		head, pickErr = repo.GetCommitHash("HEAD")
+	}
+	if err := repo.SwitchToRef(current); err != nil {
+		return nil, err
+	}
+	if pickErr != nil {
+		return nil, fmt.Errorf("Failed to cherry-pick the review's commits: %v", pickErr)
+	}
+	return repo.ListCommitsBetween(targetCommit, head)
+}
+
+// backport cherry-picks the commits of an accepted review onto the given
+// target branch, and requests a review of them that is linked to the
+// original review.
+//
+// If the backportAutoAcceptConfig setting is enabled, and the original review
+// was submitted at a commit it accepted (with an acceptance that verifies,
+// if reviews must be signed), then the new review is also accepted, since
+// its changes were already reviewed.
+func backport(repo repository.Repo, r *review.Review, target, branch, userEmail, key string) (*review.Review, bool, error) {
+	if r.Resolved == nil || !*r.Resolved {
+		return nil, false, errors.New("Only accepted reviews can be backported.")
+	}
+	targetRef, backportRef := getBackportRefs(target, branch, r.Revision)
+	if err := repo.VerifyGitRef(targetRef); err != nil {
+		return nil, false, fmt.Errorf("The target branch %q does not exist.", target)
+	}
+	if exists, err := repo.HasRef(backportRef); err != nil {
+		return nil, false, err
+	} else if exists {
+		return nil, false, fmt.Errorf("The ref %q already exists.", backportRef)
+	}
+	commits, err := r.ListCommits()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(commits) == 0 {
+		return nil, false, errors.New("The review has no commits to backport.")
+	}
+	targetCommit, err := repo.GetCommitHash(targetRef)
+	if err != nil {
+		return nil, false, err
+	}
+	picked, err := cherryPickOnto(repo, targetCommit, commits)
+	if err != nil {
+		return nil, false, err
+	}
+	if requests := request.ParseAllValid(repo.GetNotes(request.Ref, picked[0])); len(requests) > 0 {
+		return nil, false, fmt.Errorf("The backported commit %.12s already has its own review.", picked[0])
+	}
+	if err := repo.SetRef(backportRef, picked[len(picked)-1], ""); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	title := strings.SplitN(strings.TrimSpace(r.Request.Description), "\n", 2)[0]
+	description := fmt.Sprintf("%s (backport to %s)\n\nBackport of review %s.", title,
+		strings.TrimPrefix(targetRef, "refs/heads/"), r.Revision)
+	req := request.New(userEmail, r.Request.Reviewers, backportRef, targetRef, description)
+	req.Timestamp = FormatDate(&now)
+	req.CC = r.Request.CC
+	req.BaseCommit = targetCommit
+	req.BackportOf = r.Revision
+	if key != "" {
+		if err := gpg.Sign(key, &req); err != nil {
+			return nil, false, err
+		}
+	}
+	note, err := req.Write()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := repo.AppendNote(request.Ref, picked[0], note); err != nil {
+		return nil, false, err
+	}
+	backported, err := review.Get(repo, picked[0])
+	if err != nil {
+		return nil, false, err
+	}
+
+	autoAccept, err := config.Get(repo, backportAutoAcceptConfig)
+	if err != nil || autoAccept != "true" {
+		return backported, false, err
+	}
+	if err := checkAcceptedForReuse(repo, r); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not accepting the backport automatically, as %v.\n", err)
+		return backported, false, nil
+	}
+	message := fmt.Sprintf("Accepted automatically as a backport of review %.12s", r.Revision)
+	if acceptedBy := r.AcceptedBy(); len(acceptedBy) > 0 {
+		message += ", which was accepted by " + strings.Join(acceptedBy, ", ")
+	}
+	if err := acceptAutomatically(backported, userEmail, message+".", key); err != nil {
+		return nil, false, err
+	}
+	return backported, true, nil
+}
+
+// backportReview cherry-picks the commits of an accepted review onto
+// another branch, and requests a review of the result.
+func backportReview(repo repository.Repo, args []string) error {
+	backportFlagSet.Parse(args)
+	args = backportFlagSet.Args()
+	if len(args) != 2 {
+		return errors.New("A review and a target branch must be given.")
+	}
+	r, err := review.Get(repo, args[0])
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+	if uncommitted, err := repo.HasUncommittedChanges(); err != nil {
+		return err
+	} else if uncommitted {
+		return errors.New("You have uncommitted or untracked files, which would be lost by cherry-picking.")
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	var key string
+	if *backportSign {
+		if key, err = repo.GetUserSigningKey(); err != nil {
+			return err
+		}
+	}
+	backported, accepted, err := backport(repo, r, args[1], *backportBranch, userEmail, key)
+	if err != nil {
+		return err
+	}
+	notifyReview(repo, backported.Revision, "requested")
+	output.Infof("Backported review %.12s to %s as review %.12s of %s.\n", r.Revision,
+		backported.Request.TargetRef, backported.Revision, backported.Request.ReviewRef)
+	if accepted {
+		output.Infof("The backport was accepted automatically, as set by %s.\n", backportAutoAcceptConfig)
+	}
+	return nil
+}
+
+// backportCmd defines the "backport" subcommand.
+var backportCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s backport [<option>...] <review-hash> <target-branch>\n\nOptions:\n", arg0)
+		backportFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return backportReview(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestGetBackportRefs(t *testing.T) {
+	if target, branch := getBackportRefs("release-1.0", "", "0123456789abcdef"); target != "refs/heads/release-1.0" || branch != "refs/heads/backport/release-1.0/0123456789ab" {
+		t.Errorf("Unexpected default refs: %q, %q", target, branch)
+	}
+	if target, branch := getBackportRefs("refs/heads/release", "fix", "0123"); target != "refs/heads/release" || branch != "refs/heads/fix" {
+		t.Errorf("Unexpected refs: %q, %q", target, branch)
+	}
+}
+
+func TestBackport(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetRef("refs/heads/release", repository.TestCommitA, ""); err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.GetHeadRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := backport(repo, pending, "release", "", "user@example.com", ""); err == nil {
+		t.Error("Failed to reject backporting a review that has not been accepted")
+	}
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := backport(repo, r, "missing", "", "user@example.com", ""); err == nil {
+		t.Error("Failed to reject backporting to a missing branch")
+	}
+	backported, accepted, err := backport(repo, r, "release", "", "user@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted || backported.Resolved != nil {
+		t.Error("Unexpected acceptance of the backport by default")
+	}
+	if backported.Request.BackportOf != r.Revision || backported.Request.TargetRef != "refs/heads/release" {
+		t.Errorf("Unexpected backport request: %+v", backported.Request)
+	}
+	if message, err := repo.GetCommitMessage(backported.Revision); err != nil || !strings.Contains(message, "cherry picked from commit "+repository.TestCommitB) {
+		t.Errorf("Unexpected message of the backported commit: %q, %v", message, err)
+	}
+	if current, err := repo.GetHeadRef(); err != nil || current != head {
+		t.Errorf("Failed to restore the current ref: %q, %v", current, err)
+	}
+	if _, _, err := backport(repo, r, "release", "", "user@example.com", ""); err == nil {
+		t.Error("Failed to reject backporting to an existing branch")
+	}
+	if _, _, err := backport(repo, r, "release", "again", "user@example.com", ""); err == nil {
+		t.Error("Failed to reject backporting a commit that already has its own review")
+	}
+	if exists, err := repo.HasRef("refs/heads/again"); err != nil || exists {
+		t.Errorf("Unexpected branch left behind by a rejected backport: %v, %v", exists, err)
+	}
+
+	if err := repo.SetRef("refs/heads/stable", repository.TestCommitE, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetConfig(backportAutoAcceptConfig, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetConfig("appraise.reuse.requireSigned", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetRef("refs/heads/oldstable", repository.TestCommitC, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, accepted, err := backport(repo, r, "oldstable", "", "user@example.com", ""); err != nil || accepted {
+		t.Errorf("Unexpected automatic acceptance of a backport of an unsigned acceptance: %v, %v", accepted, err)
+	}
+	if err := repo.SetConfig("appraise.reuse.requireSigned", "false"); err != nil {
+		t.Fatal(err)
+	}
+	// Requiring reviews to be signed before they are sent does not apply.
+	if err := repo.SetConfig("appraise.send.requireSigned", "true"); err != nil {
+		t.Fatal(err)
+	}
+	backported, accepted, err = backport(repo, r, "stable", "", "user@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backported, err = review.Get(repo, backported.Revision); err != nil {
+		t.Fatal(err)
+	}
+	if backported.Request.TargetRef != "refs/heads/stable" {
+		t.Errorf("Unexpected target of the backport: %q", backported.Request.TargetRef)
+	}
+	if !accepted || backported.Resolved == nil || !*backported.Resolved {
+		t.Error("Failed to accept the backport automatically")
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/benchmark"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+var benchmarkFlagSet = flag.NewFlagSet("benchmark", flag.ExitOnError)
+
+var (
+	benchmarkAgent = benchmarkFlagSet.String("agent", "",
+		"Name of the benchmark runner; each runner's latest report replaces its earlier ones")
+	benchmarkURL = benchmarkFlagSet.String("url", "",
+		"Link to the details of the benchmark run")
+	benchmarkFile = benchmarkFlagSet.String("F", "-",
+		"Read the results from the given file, or from the standard input if it is -. The results are a JSON array of objects with the fields \"metric\", \"unit\", \"baseline\", \"delta\", \"higherIsBetter\", and \"threshold\"")
+)
+
+// parseBenchmarkResults parses a JSON array of benchmark results.
+func parseBenchmarkResults(contents string) ([]benchmark.Result, error) {
+	var results []benchmark.Result
+	if err := json.Unmarshal([]byte(contents), &results); err != nil {
+		return nil, fmt.Errorf("Failed to parse the benchmark results: %v", err)
+	}
+	if len(results) == 0 {
+		return nil, errors.New("There are no benchmark results.")
+	}
+	for i, result := range results {
+		if result.Metric == "" {
+			return nil, fmt.Errorf("Benchmark result %d has no metric.", i+1)
+		}
+	}
+	return results, nil
+}
+
+// publishBenchmarks attaches a report of benchmark results to the head of a review.
+func publishBenchmarks(repo repository.Repo, args []string) error {
+	benchmarkFlagSet.Parse(args)
+	args = benchmarkFlagSet.Args()
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only publishing the benchmarks of a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	contents, err := input.FromFile(*benchmarkFile)
+	if err != nil {
+		return err
+	}
+	results, err := parseBenchmarkResults(contents)
+	if err != nil {
+		return err
+	}
+	headCommit, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	report := benchmark.Report{
+		Timestamp: timestamp.Format(time.Now()),
+		Agent:     *benchmarkAgent,
+		URL:       *benchmarkURL,
+		Results:   results,
+	}
+	note, err := report.Write()
+	if err != nil {
+		return err
+	}
+	if err := repo.AppendNote(benchmark.Ref, headCommit, note); err != nil {
+		return err
+	}
+	threshold, err := review.GetBenchmarkThreshold(repo)
+	if err != nil {
+		return err
+	}
+	output.Infof("Published %d benchmark results for review %.12s, of which %d regressed.\n",
+		len(results), r.Revision, len(report.Failures(threshold)))
+	return nil
+}
+
+// benchmarkCmd defines the "benchmark" subcommand.
+var benchmarkCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s benchmark [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		benchmarkFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return publishBenchmarks(ctx.Repo, args)
+	},
+}
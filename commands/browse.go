@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	exec "golang.org/x/sys/execabs"
+)
+
+var browseFlagSet = flag.NewFlagSet("browse", flag.ExitOnError)
+
+var (
+	browsePrint = browseFlagSet.Bool("print", false, "Print the URL of the review instead of opening it")
+)
+
+// browserCommand returns the command that opens the given URL in a browser on
+// the given OS, which is the one named by the given $BROWSER, if it is set.
+func browserCommand(goos, browser, url string) []string {
+	if browser != "" {
+		return []string{browser, url}
+	}
+	switch goos {
+	case "darwin":
+		return []string{"open", url}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", url}
+	}
+	return []string{"xdg-open", url}
+}
+
+// openBrowser opens the given URL in the user's browser, without waiting for
+// the browser to exit.
+func openBrowser(url string) error {
+	args := browserCommand(runtime.GOOS, os.Getenv("BROWSER"), url)
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Failed to open a browser with %q: %v", args[0], err)
+	}
+	return cmd.Process.Release()
+}
+
+// browseReview opens the given review in the configured web UI.
+func browseReview(repo repository.Repo, args []string) error {
+	browseFlagSet.Parse(args)
+	args = browseFlagSet.Args()
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only browsing a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	url, err := review.GetWebURL(repo, r.Revision)
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return fmt.Errorf("There is no web UI to open the review in; set %s or %s.",
+			review.WebURLTemplateConfig, review.ReviewURLPrefixConfig)
+	}
+	if *browsePrint {
+		fmt.Println(url)
+		return nil
+	}
+	if err := openBrowser(url); err != nil {
+		return err
+	}
+	output.Infof("Opened review %.12s at %s\n", r.Revision, url)
+	return nil
+}
+
+// browseCmd defines the "browse" subcommand.
+var browseCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s browse [<option>...] [<review-hash>]\n\nOpens the review in the web UI given by the %s setting.\n\nOptions:\n",
+			arg0, review.WebURLTemplateConfig)
+		browseFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return browseReview(ctx.Repo, args)
+	},
+}
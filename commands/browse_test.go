@@ -0,0 +1,57 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	for _, tc := range []struct {
+		goos, browser string
+		want          []string
+	}{
+		{"linux", "", []string{"xdg-open", "https://example.com"}},
+		{"darwin", "", []string{"open", "https://example.com"}},
+		{"windows", "", []string{"rundll32", "url.dll,FileProtocolHandler", "https://example.com"}},
+		{"linux", "firefox", []string{"firefox", "https://example.com"}},
+	} {
+		if got := browserCommand(tc.goos, tc.browser, "https://example.com"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("browserCommand(%q, %q) = %q; want %q", tc.goos, tc.browser, got, tc.want)
+		}
+	}
+}
+
+func TestGetWebURL(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if url, err := review.GetWebURL(repo, repository.TestCommitB); err != nil || url != "" {
+		t.Errorf("Unexpected URL without any settings: %q, %v", url, err)
+	}
+	repo.SetConfig(review.ReviewURLPrefixConfig, "https://example.com/r/")
+	if url, err := review.GetWebURL(repo, repository.TestCommitB); err != nil || url != "https://example.com/r/B" {
+		t.Errorf("Unexpected URL from the review URL prefix: %q, %v", url, err)
+	}
+	repo.SetConfig("remote.origin.url", "git@example.com:team/project.git")
+	repo.SetConfig(review.WebURLTemplateConfig, "https://reviews.example.com/{{.Repo}}/{{.Review}}")
+	if url, err := review.GetWebURL(repo, repository.TestCommitB); err != nil || url != "https://reviews.example.com/example.com/team/project/B" {
+		t.Errorf("Unexpected URL from the template: %q, %v", url, err)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+)
+
+// bundleManifestSuffix is appended to the name of a bundle file to get the
+// name of the manifest that describes it.
+const bundleManifestSuffix = ".manifest.json"
+
+var bundleCreateFlagSet = flag.NewFlagSet("create", flag.ExitOnError)
+var bundleApplyFlagSet = flag.NewFlagSet("apply", flag.ExitOnError)
+
+var (
+	bundleCreateNoManifest = bundleCreateFlagSet.Bool("no-manifest", false, "Do not write a manifest alongside the bundle")
+)
+
+// bundleManifest describes the contents of a review metadata bundle, so that
+// it can be inspected without having to read the bundle itself.
+type bundleManifest struct {
+	Creator   string            `json:"creator,omitempty"`
+	Timestamp string            `json:"timestamp"`
+	Refs      map[string]string `json:"refs"`
+}
+
+func bundleManifestPath(bundlePath string) string {
+	return bundlePath + bundleManifestSuffix
+}
+
+// createBundle writes all of the review notes and archives to a bundle file,
+// along with the refs of forks, so that the reviews requested from a fork can
+// be checked out by the clone that applies it.
+func createBundle(repo repository.Repo, args []string) error {
+	bundleCreateFlagSet.Parse(args)
+	args = bundleCreateFlagSet.Args()
+	if len(args) != 1 {
+		return errors.New("You must specify exactly one bundle file to create.")
+	}
+	// Git runs in the top-level directory of the repo, rather than the
+	// directory that the command was run from, so relative paths have to be
+	// resolved first.
+	bundlePath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	refs, err := repo.CreateBundle(bundlePath, notesRefPattern, archiveRefPattern, forkRefPrefix+"*")
+	if err != nil {
+		return err
+	}
+	output.Infof("Bundled %d refs into %q\n", len(refs), bundlePath)
+	if *bundleCreateNoManifest {
+		return nil
+	}
+
+	creator, _ := repo.GetUserEmail()
+	now := time.Now()
+	manifest := bundleManifest{
+		Creator:   creator,
+		Timestamp: FormatDate(&now),
+		Refs:      refs,
+	}
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(bundleManifestPath(bundlePath), contents, 0644)
+}
+
+// readBundleManifest reads the manifest for the given bundle file, returning
+// nil if the bundle does not have one.
+func readBundleManifest(bundlePath string) (*bundleManifest, error) {
+	contents, err := ioutil.ReadFile(bundleManifestPath(bundlePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, fmt.Errorf("Unable to parse the bundle manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// applyBundle merges the review notes and archives from a bundle file into
+// the local repo, and updates the refs of forks from the bundle.
+func applyBundle(repo repository.Repo, args []string) error {
+	bundleApplyFlagSet.Parse(args)
+	args = bundleApplyFlagSet.Args()
+	if len(args) != 1 {
+		return errors.New("You must specify exactly one bundle file to apply.")
+	}
+	// As in createBundle, the path is resolved before git sees it.
+	bundlePath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	manifest, err := readBundleManifest(bundlePath)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		output.Infof("Applying a bundle of %d refs created by %q at %s\n", len(manifest.Refs), manifest.Creator, manifest.Timestamp)
+		var refs []string
+		for ref := range manifest.Refs {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			output.Infof("  %.12s %s\n", manifest.Refs[ref], ref)
+		}
+	}
+	if err := repo.PullNotesAndArchiveFromBundle(bundlePath, notesRefPattern, archiveRefPattern); err != nil {
+		return err
+	}
+	// As when pulling from a fork, its refs are force-updated.
+	forkRefs := forkRefPrefix + "*"
+	return repo.Fetch(bundlePath, "+"+forkRefs+":"+forkRefs)
+}
+
+var bundleCreateCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s bundle create [<option>...] <file>\n\nOptions:\n", arg0)
+		bundleCreateFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return createBundle(ctx.Repo, args)
+	},
+}
+
+var bundleApplyCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s bundle apply <file>\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return applyBundle(ctx.Repo, args)
+	},
+}
+
+// bundleCmd defines the "bundle" subcommand, which moves review metadata
+// between clones that cannot fetch from each other directly.
+var bundleCmd = newCommandGroup("bundle", map[string]*Command{
+	"create": bundleCreateCmd,
+	"apply":  bundleApplyCmd,
+})
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/request"
+)
+
+// repoRecordingFetches records the refspecs fetched from each remote.
+type repoRecordingFetches struct {
+	repository.Repo
+	fetched map[string][]string
+}
+
+func (r *repoRecordingFetches) Fetch(remote string, refspecs ...string) error {
+	r.fetched[remote] = append(r.fetched[remote], refspecs...)
+	return nil
+}
+
+func TestCreateBundle(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	forkRef := getForkRefsPrefix("alice") + "feature"
+	if err := repo.SetRef(forkRef, repository.TestCommitE, ""); err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := filepath.Join(t.TempDir(), "reviews.bundle")
+	if err := createBundle(repo, []string{bundlePath}); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := readBundleManifest(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest == nil {
+		t.Fatal("Missing the manifest of the bundle")
+	}
+	if manifest.Creator != "user@example.com" {
+		t.Errorf("Unexpected creator of the bundle: %q", manifest.Creator)
+	}
+	if manifest.Refs[forkRef] != repository.TestCommitE {
+		t.Errorf("Missing the fork ref from the bundle: %v", manifest.Refs)
+	}
+	if _, ok := manifest.Refs[request.Ref]; !ok {
+		t.Errorf("Missing the requests from the bundle: %v", manifest.Refs)
+	}
+
+	if err := createBundle(repo, []string{"one", "two"}); err == nil {
+		t.Error("Unexpected success creating two bundles at once")
+	}
+}
+
+func TestCreateBundleWithoutManifest(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	bundlePath := filepath.Join(t.TempDir(), "reviews.bundle")
+	defer func() { *bundleCreateNoManifest = false }()
+	if err := createBundle(repo, []string{"--no-manifest", bundlePath}); err != nil {
+		t.Fatal(err)
+	}
+	if manifest, err := readBundleManifest(bundlePath); err != nil || manifest != nil {
+		t.Errorf("Unexpected manifest written with --no-manifest: %v, %v", manifest, err)
+	}
+}
+
+func TestApplyBundle(t *testing.T) {
+	repo := &repoRecordingFetches{
+		Repo:    repository.NewMockRepoForTest(),
+		fetched: make(map[string][]string),
+	}
+	bundlePath := filepath.Join(t.TempDir(), "reviews.bundle")
+	if err := createBundle(repo, []string{bundlePath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyBundle(repo, []string{bundlePath}); err != nil {
+		t.Fatal(err)
+	}
+	fetched := repo.fetched[bundlePath]
+	if len(fetched) != 1 || fetched[0] != "+refs/forks/*:refs/forks/*" {
+		t.Errorf("Unexpected refspecs fetched from the bundle: %q", fetched)
+	}
+	if err := applyBundle(repo, nil); err == nil {
+		t.Error("Unexpected success applying a bundle without naming it")
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/claim"
+)
+
+// defaultClaimDuration is how long a claim lasts when neither --for nor
+// appraise.claim.duration is set.
+const defaultClaimDuration = 4 * time.Hour
+
+var claimFlagSet = flag.NewFlagSet("claim", flag.ExitOnError)
+
+var (
+	claimDuration = claimFlagSet.Duration("for", 0,
+		"How long the claim lasts, e.g. 2h. Defaults to the value of appraise.claim.duration, or 4h")
+	claimRelease = claimFlagSet.Bool("release", false,
+		"Release your claim on the review")
+	claimForce = claimFlagSet.Bool("f", false,
+		"Claim the review even if someone else has already claimed it")
+)
+
+// getClaimDuration returns how long a new claim lasts.
+func getClaimDuration(repo repository.Repo) (time.Duration, error) {
+	if *claimDuration > 0 {
+		return *claimDuration, nil
+	}
+	configured, err := config.Get(repo, "appraise.claim.duration")
+	if err != nil || configured == "" {
+		return defaultClaimDuration, err
+	}
+	duration, err := time.ParseDuration(configured)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("Invalid value for appraise.claim.duration: %q", configured)
+	}
+	return duration, nil
+}
+
+// othersClaims returns the active claims on the review by reviewers other
+// than the given one.
+func othersClaims(r *review.Summary, reviewer string, now time.Time) []string {
+	var others []string
+	for _, c := range r.ActiveClaims(now) {
+		if c.Reviewer != reviewer {
+			others = append(others, c.Reviewer)
+		}
+	}
+	return others
+}
+
+// claimReview records that the user is reviewing a change, or that they
+// no longer are.
+func claimReview(repo repository.Repo, args []string) error {
+	claimFlagSet.Parse(args)
+	args = claimFlagSet.Args()
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only claiming a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+	if !r.IsOpen() {
+		return fmt.Errorf("The review %.12s is not open.", r.Revision)
+	}
+
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var c claim.Claim
+	if *claimRelease {
+		c = claim.New(userEmail, now, 0)
+		c.Released = true
+	} else {
+		if others := othersClaims(r.Summary, userEmail, now); len(others) > 0 && !*claimForce {
+			return fmt.Errorf("The review %.12s is already being reviewed by %s; use -f to claim it anyway.",
+				r.Revision, strings.Join(others, ", "))
+		}
+		duration, err := getClaimDuration(repo)
+		if err != nil {
+			return err
+		}
+		c = claim.New(userEmail, now, duration)
+	}
+	note, err := c.Write()
+	if err != nil {
+		return err
+	}
+	if err := repo.AppendNote(claim.Ref, r.Revision, note); err != nil {
+		return err
+	}
+	if *claimRelease {
+		output.Infof("Released your claim on review %.12s.\n", r.Revision)
+	} else {
+		output.Infof("Claimed review %.12s until %s.\n", r.Revision, output.FormatTimestamp(c.Expires))
+	}
+	return nil
+}
+
+// claimCmd defines the "claim" subcommand.
+var claimCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s claim [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		claimFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return claimReview(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/claim"
+)
+
+func TestClaimReview(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	other := claim.New("other@example.com", time.Now(), time.Hour)
+	note, err := other.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(claim.Ref, repository.TestCommitG, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimReview(repo, []string{repository.TestCommitG}); err == nil {
+		t.Error("Failed to reject claiming a review that someone else has claimed")
+	}
+	if err := claimReview(repo, []string{"-f", "-for", "30m", repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := review.GetSummary(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := r.ActiveClaims(time.Now())
+	if len(active) != 2 || active[1].Reviewer != "user@example.com" {
+		t.Errorf("Unexpected active claims: %+v", active)
+	}
+	if err := claimReview(repo, []string{repository.TestCommitB}); err == nil {
+		t.Error("Failed to reject claiming a submitted review")
+	}
+}
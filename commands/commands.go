@@ -18,7 +18,9 @@ limitations under the License.
 package commands
 
 import (
-	"github.com/google/git-appraise/repository"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 const notesRefPattern = "refs/notes/devtools/*"
@@ -28,28 +30,85 @@ const commentFilename = "APPRAISE_COMMENT_EDITMSG"
 // Command represents the definition of a single command.
 type Command struct {
 	Usage     func(string)
-	RunMethod func(repository.Repo, []string) error
+	RunMethod func(*Context, []string) error
+	// NoRepo is set for commands that can be run outside of a git repo,
+	// in which case the Repo of their Context is nil.
+	NoRepo bool
 }
 
 // Run executes a command, given its arguments.
 //
 // The args parameter is all of the command line args that followed the
 // subcommand.
-func (cmd *Command) Run(repo repository.Repo, args []string) error {
-	return cmd.RunMethod(repo, args)
+func (cmd *Command) Run(ctx *Context, args []string) error {
+	return cmd.RunMethod(ctx, args)
+}
+
+// newCommandGroup returns a command that dispatches to one of the given
+// subcommands based on its first argument (e.g. "admin retarget").
+func newCommandGroup(name string, subcommandMap map[string]*Command) *Command {
+	var subcommands []string
+	for subcommand := range subcommandMap {
+		subcommands = append(subcommands, subcommand)
+	}
+	sort.Strings(subcommands)
+	subcommandList := strings.Join(subcommands, "\n  ")
+	return &Command{
+		Usage: func(arg0 string) {
+			fmt.Printf("Usage: %s %s <subcommand> [<option>...]\n\nWhere <subcommand> is one of:\n  %s\n\nFor subcommand usage, run:\n  %s %s <subcommand> -h\n",
+				arg0, name, subcommandList, arg0, name)
+		},
+		RunMethod: func(ctx *Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("You must specify one of the %s subcommands:\n  %s", name, subcommandList)
+			}
+			subcommand, ok := subcommandMap[args[0]]
+			if !ok {
+				return fmt.Errorf("Unknown %s subcommand %q", name, args[0])
+			}
+			return subcommand.Run(ctx, args[1:])
+		},
+	}
 }
 
 // CommandMap defines all of the available (sub)commands.
 var CommandMap = map[string]*Command{
-	"abandon": abandonCmd,
-	"accept":  acceptCmd,
-	"comment": commentCmd,
-	"list":    listCmd,
-	"pull":    pullCmd,
-	"push":    pushCmd,
-	"rebase":  rebaseCmd,
-	"reject":  rejectCmd,
-	"request": requestCmd,
-	"show":    showCmd,
-	"submit":  submitCmd,
+	"abandon":       abandonCmd,
+	"abort":         abortCmd,
+	"admin":         adminCmd,
+	"accept":        acceptCmd,
+	"apply-fix":     applyFixCmd,
+	"assist":        assistCmd,
+	"backport":      backportCmd,
+	"benchmark":     benchmarkCmd,
+	"browse":        browseCmd,
+	"bundle":        bundleCmd,
+	"claim":         claimCmd,
+	"comment":       commentCmd,
+	"compare":       compareCmd,
+	"config":        configCmd,
+	"continue":      continueCmd,
+	"doctor":        doctorCmd,
+	"export":        exportCmd,
+	"fork":          forkCmd,
+	"hash-object":   hashObjectCmd,
+	"hook":          hookCmd,
+	"import":        importCmd,
+	"init":          initCmd,
+	"list":          listCmd,
+	"metrics":       metricsCmd,
+	"mirror-status": mirrorStatusCmd,
+	"next":          nextCmd,
+	"notify":        notifyCmd,
+	"pull":          pullCmd,
+	"push":          pushCmd,
+	"rebase":        rebaseCmd,
+	"reject":        rejectCmd,
+	"request":       requestCmd,
+	"search":        searchCmd,
+	"send":          sendCmd,
+	"serve":         serveCmd,
+	"show":          showCmd,
+	"split":         splitCmd,
+	"submit":        submitCmd,
 }
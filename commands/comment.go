@@ -17,12 +17,19 @@ limitations under the License.
 package commands
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
 	"github.com/google/git-appraise/review/comment"
@@ -34,17 +41,27 @@ var commentLocation = comment.Range{}
 
 var (
 	commentMessageFile = commentFlagSet.String("F", "", "Take the comment from the given file. Use - to read the message from the standard input")
-	commentMessage     = commentFlagSet.String("m", "", "Message body of the comment")
+	commentMessages    input.Messages
 	commentParent      = commentFlagSet.String("p", "", "Parent comment")
 	commentFile        = commentFlagSet.String("f", "", "File being commented upon")
 	commentDetached    = commentFlagSet.Bool("d", false, "Do not attach the comment to a review")
 	commentLgtm        = commentFlagSet.Bool("lgtm", false, "'Looks Good To Me'. Set this to express your approval. This cannot be combined with nmw")
 	commentNmw         = commentFlagSet.Bool("nmw", false, "'Needs More Work'. Set this to express your disapproval. This cannot be combined with lgtm")
-	commentSign        = commentFlagSet.Bool("S", false, "Sign the contents of the comment")
-	commentDate        = commentFlagSet.String("date", "", "comment date")
+	commentKind        = commentFlagSet.String("kind", "",
+		"Classify the comment as a nit, blocking, question, or praise. Blocking comments need work until they are resolved, while nits and praise never do")
+	commentSign   = commentFlagSet.Bool("S", false, "Sign the contents of the comment")
+	commentDate   = commentFlagSet.String("date", "", "comment date")
+	commentCanned = commentFlagSet.String("canned", "",
+		"Start the comment with the canned response of the given `name`, which is set by the appraise.canned.<name> config")
+	commentStdinJSON = commentFlagSet.Bool("stdin-json", false,
+		"Read a stream of comments from the standard input and add them in one batch. Each comment is a JSON object with the fields \"path\", \"range\", \"body\", \"resolved\", \"kind\", and \"parent\"")
+	commentForce = commentFlagSet.Bool("force", false,
+		"Comment on the review even if it has already been submitted or abandoned")
 )
 
 func init() {
+	commentFlagSet.Var(&commentMessages, "m",
+		"`Message` body of the comment. If given multiple times, each becomes a separate paragraph; combined with -F, the file is used as the body")
 	commentFlagSet.Var(&commentLocation, "l",
 		`File location to be commented upon; requires that the -f flag also be set.
 Location follows the following format:
@@ -57,7 +74,7 @@ including) the 4th character of the 7th line, use:
 // commentHashExists checks if the given comment hash exists in the given comment threads.
 func commentHashExists(hashToFind string, threads []review.CommentThread) bool {
 	for _, thread := range threads {
-		if thread.Hash == hashToFind {
+		if thread.HasHash(hashToFind) {
 			return true
 		}
 		if commentHashExists(hashToFind, thread.Children) {
@@ -67,35 +84,116 @@ func commentHashExists(hashToFind string, threads []review.CommentThread) bool {
 	return false
 }
 
-func validateArgs(repo repository.Repo, args []string, threads []review.CommentThread) error {
+// findComment returns the comment with the given hash in the given comment
+// threads, or nil if there is none.
+func findComment(hashToFind string, threads []review.CommentThread) *comment.Comment {
+	for i, thread := range threads {
+		if thread.HasHash(hashToFind) {
+			return &threads[i].Comment
+		}
+		if c := findComment(hashToFind, thread.Children); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// expandCannedComment returns the canned response of the given name, with
+// its {file}, {line}, and {author} placeholders replaced by the file and
+// line being commented upon, and the author being responded to.
+func expandCannedComment(repo repository.Repo, name, file string, line uint32, author string) (string, error) {
+	key := "appraise.canned." + name
+	text, err := config.Lookup(repo, key)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("There is no canned response named %q; set it with the %s config.", name, key)
+	}
+	lineString := ""
+	if line > 0 {
+		lineString = strconv.FormatUint(uint64(line), 10)
+	}
+	return strings.NewReplacer(
+		"{file}", file,
+		"{line}", lineString,
+		"{author}", author,
+	).Replace(text), nil
+}
+
+// getCommentEditorHeader returns the lines shown above the comment when it
+// is written in an editor, which describe what is being commented upon.
+func getCommentEditorHeader(subject string) []string {
+	header := []string{fmt.Sprintf("Please enter the comment on %s.", subject)}
+	if *commentFile != "" {
+		location := *commentFile
+		if commentLocation != (comment.Range{}) {
+			location += ":" + commentLocation.String()
+		}
+		header = append(header, "File: "+location)
+	}
+	if *commentParent != "" {
+		header = append(header, "In reply to: "+*commentParent)
+	}
+	return header
+}
+
+// checkCommentKind checks that the given kind of comment can be combined
+// with the given -lgtm and -nmw flags.
+func checkCommentKind(kind string, lgtm, nmw bool) error {
+	if err := comment.CheckKind(kind); err != nil {
+		return err
+	}
+	if kind == comment.KindBlocking && lgtm {
+		return errors.New("A blocking comment cannot also be marked -lgtm.")
+	}
+	if (kind == comment.KindNit || kind == comment.KindPraise) && nmw {
+		return fmt.Errorf("A %s never needs work, so it cannot be marked -nmw.", kind)
+	}
+	return nil
+}
+
+// validateArgs checks the comment flags, and returns the message of the comment.
+//
+// The subject describes what is being commented upon, for when the message
+// is written in an editor, and the author is who a canned response addresses
+// when the comment is not a reply.
+func validateArgs(repo repository.Repo, args []string, threads []review.CommentThread, subject, author string) (string, error) {
 	if *commentLgtm && *commentNmw {
-		return errors.New("You cannot combine the flags -lgtm and -nmw.")
+		return "", errors.New("You cannot combine the flags -lgtm and -nmw.")
+	}
+	if err := checkCommentKind(*commentKind, *commentLgtm, *commentNmw); err != nil {
+		return "", err
 	}
 	if commentLocation != (comment.Range{}) && *commentFile == "" {
-		return errors.New("Specifying a line number with the -l flag requires that you also specify a file name with the -f flag.")
+		return "", errors.New("Specifying a line number with the -l flag requires that you also specify a file name with the -f flag.")
 	}
 	if *commentParent != "" && !commentHashExists(*commentParent, threads) {
-		return errors.New("There is no matching parent comment.")
+		return "", errors.New("There is no matching parent comment.")
 	}
 
-	if *commentMessageFile != "" && *commentMessage == "" {
-		var err error
-		*commentMessage, err = input.FromFile(*commentMessageFile)
-		if err != nil {
-			return err
+	messages := commentMessages
+	if *commentCanned != "" {
+		if *commentParent != "" {
+			author = findComment(*commentParent, threads).Author
 		}
-	}
-	if *commentMessageFile == "" && *commentMessage == "" {
-		var err error
-		*commentMessage, err = input.LaunchEditor(repo, commentFilename)
+		canned, err := expandCannedComment(repo, *commentCanned, *commentFile, commentLocation.StartLine, author)
 		if err != nil {
-			return err
+			return "", err
 		}
+		messages = append([]string{canned}, messages...)
 	}
-	return nil
+	message, err := input.AssembleMessage(messages, *commentMessageFile)
+	if err != nil {
+		return "", err
+	}
+	if *commentMessageFile == "" && message == "" {
+		return input.LaunchEditor(repo, commentFilename, getCommentEditorHeader(subject)...)
+	}
+	return message, nil
 }
 
-func buildCommentFromFlags(repo repository.Repo, commentedUponCommit string) (*comment.Comment, error) {
+func buildCommentFromFlags(repo repository.Repo, commentedUponCommit, message string) (*comment.Comment, error) {
 	location := comment.Location{
 		Commit: commentedUponCommit,
 	}
@@ -121,9 +219,10 @@ func buildCommentFromFlags(repo repository.Repo, commentedUponCommit string) (*c
 		date = &now
 	}
 	timestamp := FormatDate(date)
-	c := comment.New(userEmail, *commentMessage)
+	c := comment.New(userEmail, message)
 	c.Location = &location
 	c.Parent = *commentParent
+	c.Kind = *commentKind
 	if len(timestamp) > 0 {
 		c.Timestamp = timestamp
 	}
@@ -145,6 +244,163 @@ func buildCommentFromFlags(repo repository.Repo, commentedUponCommit string) (*c
 	return &c, nil
 }
 
+// jsonComment is a single comment read by the --stdin-json flag.
+type jsonComment struct {
+	Path     string         `json:"path,omitempty"`
+	Range    *comment.Range `json:"range,omitempty"`
+	Body     string         `json:"body"`
+	Resolved *bool          `json:"resolved,omitempty"`
+	Parent   string         `json:"parent,omitempty"`
+	Kind     string         `json:"kind,omitempty"`
+}
+
+// readJSONComments reads a stream of JSON comment objects, such as one
+// object per line.
+func readJSONComments(r io.Reader) ([]jsonComment, error) {
+	var comments []jsonComment
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	for {
+		var c jsonComment
+		if err := decoder.Decode(&c); err == io.EOF {
+			return comments, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("Failed to parse comment %d: %v", len(comments)+1, err)
+		}
+		if c.Body == "" {
+			return nil, fmt.Errorf("Comment %d has no body.", len(comments)+1)
+		}
+		if c.Range != nil && c.Path == "" {
+			return nil, fmt.Errorf("Comment %d has a range, but no path.", len(comments)+1)
+		}
+		lgtm, nmw := c.Resolved != nil && *c.Resolved, c.Resolved != nil && !*c.Resolved
+		if err := checkCommentKind(c.Kind, lgtm, nmw); err != nil {
+			return nil, fmt.Errorf("Comment %d: %v", len(comments)+1, err)
+		}
+		comments = append(comments, c)
+	}
+}
+
+// buildCommentsFromJSON builds the comments read by the --stdin-json flag,
+// checking that each of them can be added to the given review.
+func buildCommentsFromJSON(r *review.Review, commentedUponCommit string, jsonComments []jsonComment) ([]comment.Comment, error) {
+	userEmail, err := r.Repo.GetUserEmail()
+	if err != nil {
+		return nil, err
+	}
+	date, err := GetDate(*commentDate)
+	if err != nil {
+		return nil, err
+	}
+	if date == nil {
+		now := time.Now()
+		date = &now
+	}
+	timestamp := FormatDate(date)
+	var key string
+	if *commentSign {
+		if key, err = r.Repo.GetUserSigningKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	var comments []comment.Comment
+	// Identical comments in one batch would have the same hash, so replies
+	// and edits could not tell them apart.
+	hashes := make(map[string]int)
+	for i, jc := range jsonComments {
+		location := comment.Location{
+			Commit: commentedUponCommit,
+			Path:   jc.Path,
+			Range:  jc.Range,
+		}
+		if jc.Path != "" {
+			if err := location.Check(r.Repo); err != nil {
+				return nil, fmt.Errorf("Unable to add comment %d on the given location: %v", i+1, err)
+			}
+		}
+		if jc.Parent != "" && !commentHashExists(jc.Parent, r.Comments) {
+			return nil, fmt.Errorf("There is no matching parent for comment %d.", i+1)
+		}
+		c := comment.New(userEmail, jc.Body)
+		c.Location = &location
+		c.Parent = jc.Parent
+		c.Resolved = jc.Resolved
+		c.Kind = jc.Kind
+		if len(timestamp) > 0 {
+			c.Timestamp = timestamp
+		}
+		hash, err := c.Hash()
+		if err != nil {
+			return nil, err
+		}
+		if previous, ok := hashes[hash]; ok {
+			return nil, fmt.Errorf("Comment %d is a duplicate of comment %d.", i+1, previous+1)
+		}
+		hashes[hash] = i
+		if *commentSign {
+			if err := gpg.Sign(key, &c); err != nil {
+				return nil, err
+			}
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+// commentFromJSON adds the comments read from the given reader to the
+// review in a single batch.
+func commentFromJSON(r *review.Review, reader io.Reader) error {
+	if len(commentMessages) > 0 || *commentMessageFile != "" || *commentCanned != "" || *commentFile != "" || *commentParent != "" ||
+		*commentLgtm || *commentNmw || *commentKind != "" || commentLocation != (comment.Range{}) {
+		return errors.New("The --stdin-json flag can only be combined with the -S, -date, and --force flags.")
+	}
+	jsonComments, err := readJSONComments(reader)
+	if err != nil {
+		return err
+	}
+	commentedUponCommit, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	comments, err := buildCommentsFromJSON(r, commentedUponCommit, jsonComments)
+	if err != nil {
+		return err
+	}
+	if err := r.AddComments(comments); err != nil {
+		return err
+	}
+	output.Infof("Added %d comments to review %.12s (%s)\n", len(comments), r.Revision, currentStatus(r))
+	return nil
+}
+
+// currentStatus returns the status of the given review, reloaded to include
+// any comments that were just added to it.
+func currentStatus(r *review.Review) string {
+	if updated, err := review.GetSummary(r.Repo, r.Revision); err == nil && updated != nil {
+		return updated.StatusString()
+	}
+	return r.Summary.StatusString()
+}
+
+// checkReviewOpen returns an error if the given review has already been
+// submitted or abandoned, since comments on it are unlikely to be seen,
+// unless force is set, in which case it only prints a warning.
+func checkReviewOpen(r *review.Review, force bool) error {
+	if r.IsOpen() {
+		return nil
+	}
+	state := "submitted"
+	if r.IsAbandoned() {
+		state = "abandoned"
+	}
+	if force {
+		fmt.Fprintf(os.Stderr, "Warning: review %.12s has already been %s, so your comment is unlikely to be seen.\n", r.Revision, state)
+		return nil
+	}
+	return fmt.Errorf("Review %.12s has already been %s, so comments on it are unlikely to be seen. Use --force to comment anyway.", r.Revision, state)
+}
+
 // commentOnReview adds a comment to the current code review.
 func commentOnReview(repo repository.Repo, args []string) error {
 	var r *review.Review
@@ -160,13 +416,20 @@ func commentOnReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
 	}
+	if err := checkReviewOpen(r, *commentForce); err != nil {
+		return err
+	}
+	if *commentStdinJSON {
+		return commentFromJSON(r, os.Stdin)
+	}
 
-	if err := validateArgs(repo, args, r.Comments); err != nil {
+	message, err := validateArgs(repo, args, r.Comments, fmt.Sprintf("review %.12s", r.Revision), r.Request.Requester)
+	if err != nil {
 		return err
 	}
 
@@ -175,11 +438,18 @@ func commentOnReview(repo repository.Repo, args []string) error {
 		return err
 	}
 
-	c, err := buildCommentFromFlags(r.Repo, commentedUponCommit)
+	c, err := buildCommentFromFlags(r.Repo, commentedUponCommit, message)
 	if err != nil {
 		return err
 	}
-	return r.AddComment(*c)
+	if err := r.AddComment(*c); err != nil {
+		return err
+	}
+	// Without a hash, the notification is sent without the comment's permalink.
+	commentHash, _ := c.Hash()
+	notifyComment(repo, r.Revision, "commented on", commentHash)
+	output.Infof("Commented on review %.12s (%s)\n", r.Revision, currentStatus(r))
+	return nil
 }
 
 // commentOnPath adds a comment about the given file without attaching it to a review.
@@ -199,18 +469,23 @@ func commentOnPath(repo repository.Repo, args []string) error {
 	}
 	commentedUponCommit, err := repo.ResolveRefCommit(commentedUponRef)
 	if err != nil {
-		return fmt.Errorf("Failed to resolve the comment location: %v\n", err)
+		return fmt.Errorf("Failed to resolve the comment location: %v", err)
 	}
 
 	commentThreads, err := review.GetDetachedComments(repo, *commentFile)
 	if err != nil {
 		return err
 	}
-	if err := validateArgs(repo, args, commentThreads); err != nil {
+	details, err := repo.GetCommitDetails(commentedUponCommit)
+	if err != nil {
+		return err
+	}
+	message, err := validateArgs(repo, args, commentThreads, fmt.Sprintf("commit %.12s", commentedUponCommit), details.AuthorEmail)
+	if err != nil {
 		return err
 	}
 
-	c, err := buildCommentFromFlags(repo, commentedUponCommit)
+	c, err := buildCommentFromFlags(repo, commentedUponCommit, message)
 	if err != nil {
 		return err
 	}
@@ -223,12 +498,15 @@ var commentCmd = &Command{
 		fmt.Printf("Usage: %s comment [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
 		commentFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
+	RunMethod: func(ctx *Context, args []string) error {
 		commentFlagSet.Parse(args)
 		args = commentFlagSet.Args()
 		if *commentDetached {
-			return commentOnPath(repo, args)
+			if *commentStdinJSON {
+				return errors.New("The --stdin-json flag can not be combined with the -d flag.")
+			}
+			return commentOnPath(ctx.Repo, args)
 		}
-		return commentOnReview(repo, args)
+		return commentOnReview(ctx.Repo, args)
 	},
 }
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestReadJSONComments(t *testing.T) {
+	stream := `{"path": "a.go", "range": {"startLine": 3, "endLine": 5}, "body": "Extract this", "resolved": false}
+{"body": "Looks good overall"}
+`
+	comments, err := readJSONComments(strings.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Unexpected comments: %v", comments)
+	}
+	if c := comments[0]; c.Path != "a.go" || c.Range == nil || c.Range.StartLine != 3 || c.Range.EndLine != 5 || c.Resolved == nil || *c.Resolved {
+		t.Errorf("Unexpected inline comment: %+v", c)
+	}
+	if c := comments[1]; c.Path != "" || c.Range != nil || c.Resolved != nil || c.Body != "Looks good overall" {
+		t.Errorf("Unexpected review comment: %+v", c)
+	}
+
+	for _, invalid := range []string{
+		`{"path": "a.go"}`,
+		`{"range": {"startLine": 1}, "body": "No path"}`,
+		`{"body": "Unknown field", "line": 1}`,
+		`{"body": "Truncated"`,
+	} {
+		if _, err := readJSONComments(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for the comment stream %q", invalid)
+		}
+	}
+}
+
+func TestBuildCommentsFromJSONRejectsDuplicates(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.GetHeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := []jsonComment{{Body: "Fix this"}, {Body: "And this"}}
+	if built, err := buildCommentsFromJSON(r, commit, comments); err != nil || len(built) != 2 {
+		t.Errorf("Unexpected comments built: %v, %v", built, err)
+	}
+	comments = append(comments, jsonComment{Body: "Fix this"})
+	if _, err := buildCommentsFromJSON(r, commit, comments); err == nil || !strings.Contains(err.Error(), "Comment 3 is a duplicate of comment 1") {
+		t.Errorf("Unexpected error for duplicate comments: %v", err)
+	}
+}
+
+func TestExpandCannedComment(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.WriteConfigFile(config.FileName, "appraise.canned.style-nit", "Nit: {file}:{line} does not follow the style guide."); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddConfigValue("appraise.canned.thanks", "Thanks, {author}!"); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, err := expandCannedComment(repo, "style-nit", "a.go", 12, "alice"); err != nil || text != "Nit: a.go:12 does not follow the style guide." {
+		t.Errorf("Unexpected canned comment from the settings file: %q, %v", text, err)
+	}
+	if text, err := expandCannedComment(repo, "thanks", "", 0, "alice"); err != nil || text != "Thanks, alice!" {
+		t.Errorf("Unexpected canned comment from git config: %q, %v", text, err)
+	}
+	if _, err := expandCannedComment(repo, "missing", "", 0, "alice"); err == nil {
+		t.Error("Unexpected success expanding an undefined canned comment")
+	}
+}
+
+func TestCheckCommentKind(t *testing.T) {
+	if err := checkCommentKind("blocking", false, true); err != nil {
+		t.Errorf("Unexpected error for a blocking comment that needs work: %v", err)
+	}
+	for _, invalid := range []struct {
+		kind      string
+		lgtm, nmw bool
+	}{
+		{"urgent", false, false},
+		{"blocking", true, false},
+		{"nit", false, true},
+		{"praise", false, true},
+	} {
+		if err := checkCommentKind(invalid.kind, invalid.lgtm, invalid.nmw); err == nil {
+			t.Errorf("Failed to reject %+v", invalid)
+		}
+	}
+}
+
+func TestCheckReviewOpen(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Submitted = false
+	if err := checkReviewOpen(r, false); err != nil {
+		t.Errorf("Unexpected error for an open review: %v", err)
+	}
+	r.Submitted = true
+	if err := checkReviewOpen(r, false); err == nil {
+		t.Error("Unexpected success commenting on a submitted review")
+	}
+	if err := checkReviewOpen(r, true); err != nil {
+		t.Errorf("Unexpected error when forcing a comment on a submitted review: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+var compareFlagSet = flag.NewFlagSet("compare", flag.ExitOnError)
+
+var (
+	compareJSONOutput       = compareFlagSet.Bool("json", false, "Format the comparison as JSON, with the diff split into files, hunks, and lines")
+	compareDiffOptions      = compareFlagSet.String("diff-opts", "", "Options to pass to the diff tool")
+	compareIgnoreWhitespace = compareFlagSet.Bool("ignore-whitespace", false, "Ignore changes in whitespace in the diff")
+	compareStat             = compareFlagSet.Bool("stat", false, "Show a summary of the changes to each file instead of the diff")
+	comparePaths            pathGlobs
+)
+
+func init() {
+	compareFlagSet.Var(&comparePaths, "path", "Only include the files matching the given `glob` in the diff; can be given multiple times")
+}
+
+// compareReviews compares the heads and comment threads of two reviews.
+func compareReviews(repo repository.Repo, args []string) error {
+	compareFlagSet.Parse(args)
+	args = compareFlagSet.Args()
+	if len(args) != 2 {
+		return errors.New("You must specify the two reviews to compare.")
+	}
+	var reviews []*review.Review
+	for _, arg := range args {
+		r, err := review.Get(repo, arg)
+		if err != nil {
+			return fmt.Errorf("Failed to load the review %q: %v", arg, err)
+		}
+		if r == nil {
+			return fmt.Errorf("There is no review matching %q.", arg)
+		}
+		reviews = append(reviews, r)
+	}
+	comparison, err := review.Compare(reviews[0], reviews[1])
+	if err != nil {
+		return err
+	}
+	options := repository.DiffOptions{
+		IgnoreWhitespace: *compareIgnoreWhitespace,
+		Stat:             *compareStat,
+		Paths:            comparePaths,
+	}
+	var diffArgs []string
+	if *compareDiffOptions != "" {
+		diffArgs = strings.Split(*compareDiffOptions, ",")
+	}
+	if *compareJSONOutput {
+		comparison.Diff, err = comparison.GetStructuredDiff(options, diffArgs...)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	return output.PrintComparison(comparison, options, diffArgs...)
+}
+
+// compareCmd defines the "compare" subcommand.
+var compareCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s compare [<option>...] <review-hash> <review-hash>\n\nOptions:\n", arg0)
+		compareFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return compareReviews(ctx.Repo, args)
+	},
+}
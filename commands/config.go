@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+var configFlagSet = flag.NewFlagSet("config", flag.ExitOnError)
+
+// showConfig prints the effective value of one or all of the settings, and
+// where each one came from.
+func showConfig(repo repository.Repo, args []string) error {
+	configFlagSet.Parse(args)
+	args = configFlagSet.Args()
+	if len(args) > 1 {
+		return errors.New("Only showing a single setting is supported.")
+	}
+	if len(args) == 1 {
+		if config.Find(args[0]) == nil {
+			return fmt.Errorf("Unknown setting %q.", args[0])
+		}
+		value, err := config.Get(repo, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+	values, err := config.List(repo)
+	if err != nil {
+		return err
+	}
+	output.PrintConfig(values)
+	invalid := 0
+	for _, v := range values {
+		if v.Err != nil {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("Found %d invalid settings.", invalid)
+	}
+	return nil
+}
+
+// configCmd defines the "config" subcommand.
+var configCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s config [<key>]\n\n", arg0)
+		fmt.Printf(`Shows the effective value of each setting, and where it came from. Settings
+are read from git config, and otherwise from the %s file committed to the
+repo, which uses the same syntax as git config files.
+
+Given a key, only the value of that setting is printed.
+`, config.FileName)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return showConfig(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/storage"
+)
+
+// GlobalOptions holds the options that apply to every command, which are
+// given before the name of the command.
+type GlobalOptions struct {
+	// RepoPath is a path within the repository to operate on, which
+	// defaults to the current working directory.
+	RepoPath string
+	// Verbose causes every git command to be logged to stderr.
+	Verbose bool
+	// Color is when to color the output; one of auto, always, or never.
+	Color string
+	// Timeout is the longest that any single git command may run, or zero for no limit.
+	Timeout time.Duration
+	// Porcelain switches the output to the stable, tab-separated formats.
+	Porcelain bool
+	// Quiet suppresses informational messages.
+	Quiet bool
+}
+
+// newGlobalFlagSet returns a flag set that parses the global options into the given struct.
+func newGlobalFlagSet(options *GlobalOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet("git-appraise", flag.ContinueOnError)
+	flags.StringVar(&options.RepoPath, "repo", "", "Path of the repository to operate on, instead of the current directory")
+	flags.StringVar(&options.RepoPath, "C", "", "Shorthand for --repo, like the -C option of git")
+	flags.BoolVar(&options.Verbose, "verbose", false, "Log every git command that is run to stderr")
+	flags.StringVar(&options.Color, "color", output.ColorAuto, "When to color the output: auto, always, or never")
+	flags.DurationVar(&options.Timeout, "timeout", 0, "Maximum `duration` of any single git command, e.g. 30s")
+	flags.BoolVar(&options.Porcelain, "porcelain", false, "Print output in stable, tab-separated formats for scripts")
+	flags.BoolVar(&options.Quiet, "quiet", false, "Do not print informational messages")
+	flags.BoolVar(&options.Quiet, "q", false, "Shorthand for --quiet")
+	return flags
+}
+
+// ParseGlobalOptions parses the global options at the start of the given
+// command line arguments, and returns them along with the remaining arguments,
+// which start with the name of the command.
+func ParseGlobalOptions(args []string) (*GlobalOptions, []string, error) {
+	options := &GlobalOptions{}
+	flags := newGlobalFlagSet(options)
+	flags.SetOutput(ioutil.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if err := output.SetColor(options.Color); err != nil {
+		return nil, nil, err
+	}
+	return options, flags.Args(), nil
+}
+
+// GlobalOptionsUsage returns the usage message of the global options.
+func GlobalOptionsUsage() string {
+	var usage bytes.Buffer
+	flags := newGlobalFlagSet(&GlobalOptions{})
+	flags.SetOutput(&usage)
+	flags.PrintDefaults()
+	return usage.String()
+}
+
+// Context holds the state shared by every command, which is set up from the
+// global options.
+type Context struct {
+	// Repo is the repository that the command operates on.
+	Repo repository.Repo
+	// Options are the global options that the command was run with.
+	Options GlobalOptions
+}
+
+// NewContext opens the repository and configures the output according to the given options.
+//
+// If a storage is configured, the repository's notes are kept in it.
+func NewContext(options GlobalOptions) (*Context, error) {
+	output.SetQuiet(options.Quiet)
+	output.SetPorcelain(options.Porcelain)
+	path := options.RepoPath
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to get the current working directory: %q", err)
+		}
+		path = cwd
+	}
+	repo, err := repository.NewGitRepo(path)
+	if err != nil && options.RepoPath != "" {
+		return nil, fmt.Errorf("%q is not a git repo, or a path within one: %v", options.RepoPath, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s must be run from within a git repo: %v", os.Args[0], err)
+	}
+	repo.Verbose = options.Verbose
+	repo.Timeout = options.Timeout
+	stored, err := storage.FromConfig(repo)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{
+		Repo:    stored,
+		Options: options,
+	}, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGlobalOptions(t *testing.T) {
+	options, args, err := ParseGlobalOptions([]string{
+		"--repo", "/tmp/repo", "--verbose", "--timeout=30s", "-q", "show", "--json", "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := GlobalOptions{
+		RepoPath: "/tmp/repo",
+		Verbose:  true,
+		Color:    "auto",
+		Timeout:  30 * time.Second,
+		Quiet:    true,
+	}
+	if *options != expected {
+		t.Errorf("Unexpected global options: %+v", *options)
+	}
+	if !reflect.DeepEqual(args, []string{"show", "--json", "abc123"}) {
+		t.Errorf("Unexpected remaining args: %q", args)
+	}
+	if options, _, err := ParseGlobalOptions([]string{"-C", "../other", "list"}); err != nil || options.RepoPath != "../other" {
+		t.Errorf("Unexpected result parsing the -C option: %+v, %v", options, err)
+	}
+	if _, _, err := ParseGlobalOptions([]string{"--color=sometimes", "list"}); err == nil {
+		t.Error("Unexpected success parsing an unsupported color option")
+	}
+	if _, _, err := ParseGlobalOptions([]string{"--unknown", "list"}); err == nil {
+		t.Error("Unexpected success parsing an unknown global option")
+	}
+}
+
+func TestNewContextOutsideRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	_, err := NewContext(GlobalOptions{RepoPath: dir})
+	if err == nil {
+		t.Fatalf("Unexpected success opening %q as a repo", dir)
+	}
+	// The error from git explains why the path could not be opened.
+	if !strings.Contains(err.Error(), "is not a git repo") || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("Unexpected error opening %q as a repo: %v", dir, err)
+	}
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+	exec "golang.org/x/sys/execabs"
+)
+
+var doctorFlagSet = flag.NewFlagSet("doctor", flag.ExitOnError)
+
+var (
+	doctorOffline = doctorFlagSet.Bool("offline", false, "Skip the checks that contact the remotes")
+)
+
+// The severities of a diagnosis.
+const (
+	diagnosisOK      = "ok"
+	diagnosisWarning = "warning"
+	diagnosisError   = "error"
+)
+
+// slowNotesRead is how long reading all of the review requests may take
+// before doctor suggests how to speed it up.
+const slowNotesRead = 5 * time.Second
+
+// diagnosis is the result of one of the checks run by doctor.
+type diagnosis struct {
+	severity string
+	message  string
+	// fix describes how to fix the problem, if there is one.
+	fix string
+}
+
+// gitFeature is a feature of git that the tool relies on, and the version of git that introduced it.
+type gitFeature struct {
+	major, minor, patch int
+	description         string
+}
+
+var gitFeatures = []gitFeature{
+	{1, 7, 4, "the cat_sort_uniq notes merge strategy, used to merge pulled review notes"},
+	{1, 8, 4, "custom cat-file --batch-check formats, used to read all of the review notes at once"},
+}
+
+var gitVersionPattern = regexp.MustCompile(`^git version (\d+)\.(\d+)(?:\.(\d+))?`)
+
+// parseGitVersion parses the output of `git version`.
+func parseGitVersion(out string) (major, minor, patch int, err error) {
+	match := gitVersionPattern.FindStringSubmatch(out)
+	if match == nil {
+		return 0, 0, 0, fmt.Errorf("unrecognized git version %q", out)
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	patch, _ = strconv.Atoi(match[3])
+	return major, minor, patch, nil
+}
+
+// checkGitVersion reports the features that the installed version of git is missing.
+func checkGitVersion(version string) []diagnosis {
+	major, minor, patch, err := parseGitVersion(version)
+	if err != nil {
+		return []diagnosis{{diagnosisWarning, err.Error(), ""}}
+	}
+	var diagnoses []diagnosis
+	for _, feature := range gitFeatures {
+		if major < feature.major ||
+			(major == feature.major && minor < feature.minor) ||
+			(major == feature.major && minor == feature.minor && patch < feature.patch) {
+			diagnoses = append(diagnoses, diagnosis{
+				diagnosisError,
+				fmt.Sprintf("%s is missing %s", version, feature.description),
+				fmt.Sprintf("upgrade git to version %d.%d.%d or later", feature.major, feature.minor, feature.patch),
+			})
+		}
+	}
+	if len(diagnoses) == 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisOK, version, ""})
+	}
+	return diagnoses
+}
+
+// checkGPG reports whether the program used to sign and verify reviews is installed.
+func checkGPG(repo repository.Repo) []diagnosis {
+	if path, err := exec.LookPath(gpg.Program); err == nil {
+		return []diagnosis{{diagnosisOK, fmt.Sprintf("%s is installed at %s", gpg.Program, path), ""}}
+	}
+	severity := diagnosisWarning
+	if requireSigned, _ := config.Get(repo, "appraise.send.requireSigned"); requireSigned == "true" {
+		severity = diagnosisError
+	}
+	return []diagnosis{{severity,
+		fmt.Sprintf("%s was not found, so reviews can not be signed (-S) or verified", gpg.Program),
+		"install GnuPG, or set gpg.program to the path of your gpg executable"}}
+}
+
+// checkSettings reports invalid settings.
+func checkSettings(repo repository.Repo) []diagnosis {
+	values, err := config.List(repo)
+	if err != nil {
+		return []diagnosis{{diagnosisError, err.Error(), "fix the syntax of " + config.FileName}}
+	}
+	var diagnoses []diagnosis
+	for _, v := range values {
+		if v.Err != nil {
+			diagnoses = append(diagnoses, diagnosis{diagnosisError, v.Err.Error(),
+				fmt.Sprintf("fix the value in %s, and see `git appraise config`", v.Source)})
+		}
+	}
+	conflicts, err := getNotesConfigConflicts(repo)
+	if err != nil {
+		return append(diagnoses, diagnosis{diagnosisWarning, fmt.Sprintf("unable to check the git notes configuration: %v", err), ""})
+	}
+	for _, conflict := range conflicts {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning, conflict,
+			"limit the setting to your own notes refs, outside of " + notesNamespace})
+	}
+	if len(diagnoses) == 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisOK, "the settings are valid", ""})
+	}
+	return diagnoses
+}
+
+// checkReviews explains why `git appraise list` might not show the reviews
+// that the user expects, and how long it takes to read them.
+func checkReviews(repo repository.Repo) []diagnosis {
+	start := time.Now()
+	all := review.ListAll(repo)
+	elapsed := time.Since(start)
+	var diagnoses []diagnosis
+	if skips, err := review.ListSkipped(repo); err != nil {
+		diagnoses = append(diagnoses, diagnosis{diagnosisError, err.Error(), ""})
+	} else if len(skips) > 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+			fmt.Sprintf("%d notes in %s are skipped when listing reviews", len(skips), request.Ref),
+			"run `git appraise list -a --debug-skips` to see why"})
+	}
+	if count, err := review.CountInvalidTimestamps(repo); err != nil {
+		diagnoses = append(diagnoses, diagnosis{diagnosisError, err.Error(), ""})
+	} else if count > 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+			fmt.Sprintf("%d review requests and comments have timestamps that are not a number of seconds, so they are sorted as the oldest", count),
+			""})
+	}
+	var orphans, orphanedReviews, cycles, cyclicReviews int
+	for _, r := range all {
+		if n := len(r.Orphans()); n > 0 {
+			orphans += n
+			orphanedReviews++
+		}
+		if n := len(r.Cycles()); n > 0 {
+			cycles += n
+			cyclicReviews++
+		}
+	}
+	if orphans > 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+			fmt.Sprintf("%d comments in %d reviews reply to (or edit) comments that could not be found", orphans, orphanedReviews),
+			"run `git appraise show <review-hash>` to see them marked as orphaned at the top level of the review"})
+	}
+	if cycles > 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+			fmt.Sprintf("%d cycles of comments that reply to (or edit) each other were broken in %d reviews", cycles, cyclicReviews),
+			"run `git appraise show <review-hash>` to see the comments they were broken at marked as cyclic at the top level of the review"})
+	}
+	if len(all) > 0 && len(review.ListOpen(repo)) == 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisOK,
+			fmt.Sprintf("all %d reviews are closed, so `git appraise list` shows none of them", len(all)),
+			"run `git appraise list -a` to show closed reviews"})
+	}
+	if elapsed > slowNotesRead {
+		diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+			fmt.Sprintf("reading %d reviews took %v", len(all), elapsed.Round(time.Millisecond)),
+			"run `git gc` to pack the repo's objects"})
+	}
+	if len(diagnoses) == 0 {
+		diagnoses = append(diagnoses, diagnosis{diagnosisOK,
+			fmt.Sprintf("read %d reviews in %v", len(all), elapsed.Round(time.Millisecond)), ""})
+	}
+	return diagnoses
+}
+
+// compareNotesRefs reports how the local review notes differ from those of a remote.
+//
+// The local and remote maps hold the hashes of each notes ref, by name.
+func compareNotesRefs(repo repository.Repo, remote string, local, remoteRefs map[string]string) []diagnosis {
+	var diagnoses []diagnosis
+	for _, ref := range reviewNotesRefs {
+		localHash, remoteHash := local[ref], remoteRefs[ref]
+		switch {
+		case localHash == remoteHash:
+			continue
+		case localHash == "":
+			diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+				fmt.Sprintf("%q has %s, but it has not been pulled", remote, ref),
+				fmt.Sprintf("run `git appraise pull %s`", remote)})
+		case remoteHash == "":
+			diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+				fmt.Sprintf("%s has not been pushed to %q", ref, remote),
+				fmt.Sprintf("run `git appraise push %s`", remote)})
+		default:
+			diagnoses = append(diagnoses, describeNotesDivergence(repo, remote, ref, localHash, remoteHash))
+		}
+	}
+	return diagnoses
+}
+
+// describeNotesDivergence reports how a local notes ref differs from the same ref on a remote.
+func describeNotesDivergence(repo repository.Repo, remote, ref, localHash, remoteHash string) diagnosis {
+	pull := fmt.Sprintf("run `git appraise pull %s`", remote)
+	if has, err := repo.HasObject(remoteHash); err != nil || !has {
+		return diagnosis{diagnosisWarning, fmt.Sprintf("%q has changes to %s that have not been pulled", remote, ref), pull}
+	}
+	ahead, behind, err := repo.CountAheadBehind(remoteHash, localHash)
+	if err != nil {
+		return diagnosis{diagnosisWarning, fmt.Sprintf("unable to compare %s with %q: %v", ref, remote, err), ""}
+	}
+	push := fmt.Sprintf("run `git appraise push %s`", remote)
+	message := fmt.Sprintf("%s is %d ahead and %d behind %q", ref, ahead, behind, remote)
+	if behind == 0 {
+		return diagnosis{diagnosisWarning, message, push}
+	}
+	if ahead == 0 {
+		return diagnosis{diagnosisWarning, message, pull}
+	}
+	return diagnosis{diagnosisWarning, message, pull + ", and then " + push}
+}
+
+// checkRemotes reports the remotes, and how their review notes differ from the local ones.
+func checkRemotes(repo repository.Repo, offline bool) []diagnosis {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return []diagnosis{{diagnosisError, fmt.Sprintf("unable to list the remotes: %v", err), ""}}
+	}
+	if len(remotes) == 0 || (len(remotes) == 1 && remotes[0] == "") {
+		return []diagnosis{{diagnosisWarning, "there are no remotes to share reviews with",
+			"add one with `git remote add origin <url>`"}}
+	}
+	local, err := repo.ListRefs(notesNamespace)
+	if err != nil {
+		return []diagnosis{{diagnosisError, fmt.Sprintf("unable to list the review notes: %v", err), ""}}
+	}
+	var diagnoses []diagnosis
+	for _, remote := range remotes {
+		if incoming, err := getIncomingNotes(repo, remote); err == nil && len(incoming) > 0 {
+			diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+				fmt.Sprintf("notes pulled from %q are quarantined, so they are not shown", remote),
+				fmt.Sprintf("inspect them with `git appraise fork diff %s`, and merge them with `git appraise fork approve %s`", remote, remote)})
+		}
+		if offline {
+			continue
+		}
+		remoteRefs, err := repo.ListRemoteRefs(remote, notesRefPattern)
+		if err != nil {
+			diagnoses = append(diagnoses, diagnosis{diagnosisWarning,
+				fmt.Sprintf("unable to reach %q: %v", remote, err),
+				fmt.Sprintf("run `git appraise fork test %s` for details", remote)})
+			continue
+		}
+		remoteDiagnoses := compareNotesRefs(repo, remote, local, remoteRefs)
+		if len(remoteDiagnoses) == 0 {
+			remoteDiagnoses = append(remoteDiagnoses, diagnosis{diagnosisOK,
+				fmt.Sprintf("the review notes are in sync with %q", remote), ""})
+		}
+		diagnoses = append(diagnoses, remoteDiagnoses...)
+	}
+	return diagnoses
+}
+
+// diagnose runs all of the checks.
+func diagnose(repo repository.Repo, gitVersion string, offline bool) []diagnosis {
+	var diagnoses []diagnosis
+	diagnoses = append(diagnoses, checkGitVersion(gitVersion)...)
+	diagnoses = append(diagnoses, checkGPG(repo)...)
+	diagnoses = append(diagnoses, checkSettings(repo)...)
+	diagnoses = append(diagnoses, checkReviews(repo)...)
+	diagnoses = append(diagnoses, checkRemotes(repo, offline)...)
+	return diagnoses
+}
+
+// doctor checks the environment that the tool runs in, and suggests fixes
+// for any problems that it finds.
+func doctor(repo repository.Repo, args []string) error {
+	doctorFlagSet.Parse(args)
+	gitVersion, err := repository.GitVersion()
+	if err != nil {
+		return fmt.Errorf("Unable to run git: %v", err)
+	}
+	errors := 0
+	for _, d := range diagnose(repo, gitVersion, *doctorOffline) {
+		fmt.Printf("[%s] %s\n", d.severity, d.message)
+		if d.fix != "" {
+			fmt.Printf("  fix: %s\n", d.fix)
+		}
+		if d.severity == diagnosisError {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("Found %d problems.", errors)
+	}
+	return nil
+}
+
+// doctorCmd defines the "doctor" subcommand.
+var doctorCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s doctor [<option>...]\n\nChecks for problems with git, gpg, the settings, the review notes, and the remotes, and suggests how to fix them.\n\nOptions:\n", arg0)
+		doctorFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return doctor(ctx.Repo, args)
+	},
+}
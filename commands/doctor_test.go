@@ -0,0 +1,71 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/ci"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/submission"
+)
+
+func TestCheckGitVersion(t *testing.T) {
+	if d := checkGitVersion("git version 2.39.2 (Apple Git-143)"); len(d) != 1 || d[0].severity != diagnosisOK {
+		t.Errorf("Unexpected diagnosis of a recent git version: %+v", d)
+	}
+	if d := checkGitVersion("git version 1.8.3.1"); len(d) != 1 || d[0].severity != diagnosisError {
+		t.Errorf("Unexpected diagnosis of an old git version: %+v", d)
+	}
+	if d := checkGitVersion("not git"); len(d) != 1 || d[0].severity != diagnosisWarning {
+		t.Errorf("Unexpected diagnosis of an unrecognized git version: %+v", d)
+	}
+}
+
+func TestCompareNotesRefs(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	local := map[string]string{
+		request.Ref:    repository.TestCommitB,
+		comment.Ref:    repository.TestCommitA,
+		analyses.Ref:   repository.TestCommitB,
+		submission.Ref: repository.TestCommitA,
+	}
+	remote := map[string]string{
+		request.Ref:  repository.TestCommitA,
+		comment.Ref:  repository.TestCommitB,
+		ci.Ref:       repository.TestCommitA,
+		analyses.Ref: repository.TestCommitC,
+	}
+	var fixes []string
+	for _, d := range compareNotesRefs(repo, "origin", local, remote) {
+		fixes = append(fixes, d.fix)
+	}
+	expected := []string{
+		"run `git appraise push origin`",
+		"run `git appraise pull origin`",
+		"run `git appraise pull origin`",
+		"run `git appraise pull origin`, and then run `git appraise push origin`",
+		"run `git appraise push origin`",
+	}
+	if !reflect.DeepEqual(fixes, expected) {
+		t.Errorf("Unexpected fixes: %q", fixes)
+	}
+}
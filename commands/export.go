@@ -0,0 +1,148 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/git-appraise/archive"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/site"
+)
+
+var exportSiteFlagSet = flag.NewFlagSet("site", flag.ExitOnError)
+
+var (
+	exportSiteOutput = exportSiteFlagSet.String("o", "public", "The directory to write the site to")
+	exportSiteTitle  = exportSiteFlagSet.String("title", "", "The title of the site; defaults to the name of the repository in permalinks")
+)
+
+// siteReview loads everything that the site shows about the given review.
+func siteReview(summary review.Summary) (site.Review, error) {
+	r, err := summary.Details()
+	if err != nil {
+		return site.Review{}, err
+	}
+	sr := site.Review{
+		Review: r,
+		Status: r.Summary.StatusString(),
+	}
+	if r.Missing {
+		sr.DiffError = "the reviewed commit has not been fetched"
+	} else if diff, err := r.GetDiff(); err != nil {
+		sr.DiffError = err.Error()
+	} else {
+		sr.Diff = diff
+	}
+	return sr, nil
+}
+
+// exportSite renders every review into a static website.
+func exportSite(repo repository.Repo, args []string) error {
+	exportSiteFlagSet.Parse(args)
+	if len(exportSiteFlagSet.Args()) > 0 {
+		return errors.New("The export site command does not take any positional arguments.")
+	}
+	title := *exportSiteTitle
+	if title == "" {
+		permalinker, err := review.GetPermalinker(repo)
+		if err != nil {
+			return err
+		}
+		title = "Reviews of " + permalinker.RepoID
+	}
+	var reviews []site.Review
+	for _, summary := range review.ListAll(repo) {
+		r, err := siteReview(summary)
+		if err != nil {
+			return fmt.Errorf("Failed to load review %.12s: %v", summary.Revision, err)
+		}
+		reviews = append(reviews, r)
+	}
+	if err := site.Write(*exportSiteOutput, title, reviews); err != nil {
+		return fmt.Errorf("Failed to write the site to %q: %v", *exportSiteOutput, err)
+	}
+	output.Infof("Exported %d reviews to %s\n", len(reviews), *exportSiteOutput)
+	return nil
+}
+
+var exportSiteCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s export site [<option>...]\n\nRenders every review into a static website, with an index and a page for each review.\n\nOptions:\n", arg0)
+		exportSiteFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return exportSite(ctx.Repo, args)
+	},
+}
+
+var exportArchiveFlagSet = flag.NewFlagSet("json-archive", flag.ExitOnError)
+
+var exportArchiveOutput = exportArchiveFlagSet.String("o", "-", "The file to write the archive to. Use - to write it to the standard output")
+
+// exportArchive writes the review metadata of every commit to a JSON archive.
+func exportArchive(repo repository.Repo, args []string) error {
+	exportArchiveFlagSet.Parse(args)
+	if len(exportArchiveFlagSet.Args()) > 0 {
+		return errors.New("The export json-archive command does not take any positional arguments.")
+	}
+	a, skipped, err := archive.Export(repo)
+	if err != nil {
+		return fmt.Errorf("Failed to read the reviews: %v", err)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: left out %d notes that are not JSON\n", skipped)
+	}
+	if *exportArchiveOutput == "-" {
+		return a.Write(os.Stdout)
+	}
+	file, err := os.Create(*exportArchiveOutput)
+	if err != nil {
+		return err
+	}
+	if err := a.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to write the archive to %q: %v", *exportArchiveOutput, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	output.Infof("Exported the reviews of %d commits to %s\n", len(a.Commits), *exportArchiveOutput)
+	return nil
+}
+
+var exportArchiveCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s export json-archive [<option>...]\n\nWrites the requests, comments, and reports on every commit to a portable JSON archive.\n\nOptions:\n", arg0)
+		exportArchiveFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return exportArchive(ctx.Repo, args)
+	},
+}
+
+// exportCmd defines the "export" subcommand, which writes the reviews in
+// formats that can be read without git-appraise.
+var exportCmd = newCommandGroup("export", map[string]*Command{
+	"json-archive": exportArchiveCmd,
+	"site":         exportSiteCmd,
+})
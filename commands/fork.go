@@ -0,0 +1,597 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/provenance"
+	"github.com/google/git-appraise/review/request"
+)
+
+var forkAddFlagSet = flag.NewFlagSet("add", flag.ExitOnError)
+var forkPullFlagSet = flag.NewFlagSet("pull", flag.ExitOnError)
+var forkPruneFlagSet = flag.NewFlagSet("prune", flag.ExitOnError)
+var forkApproveFlagSet = flag.NewFlagSet("approve", flag.ExitOnError)
+
+var (
+	forkAddHint = forkAddFlagSet.String("hint", "", "A hint shown when the fork can not be reached, e.g. how to get credentials for it")
+
+	forkPullDepth = forkPullFlagSet.Int("depth", 0, "Limit the history fetched for each of the fork's branches to the given number of commits")
+	forkPullPrune = forkPullFlagSet.Bool("prune", false, "Delete the local refs of the fork's branches that no longer exist upstream")
+
+	forkPruneForce = forkPruneFlagSet.Bool("force", false, "Also delete refs that are the review ref of an open review")
+
+	forkApproveVerify = forkApproveFlagSet.Bool("verify-signatures", false, "Verify the signatures of the quarantined reviews, as `pull --verify-signatures` does, before merging them")
+)
+
+// getForkURLsKey returns the config key that lists the URLs of the given fork,
+// in order of preference.
+func getForkURLsKey(name string) string {
+	return "appraise.fork." + name + ".url"
+}
+
+// getForkHintKey returns the config key that holds the credentials hint for
+// the given fork.
+func getForkHintKey(name string) string {
+	return "appraise.fork." + name + ".hint"
+}
+
+// getURLProtocol returns the protocol that git would use for the given URL.
+func getURLProtocol(url string) string {
+	if i := strings.Index(url, "://"); i > 0 {
+		scheme := url[:i]
+		if strings.Contains(scheme, "ssh") {
+			// Including the "git+ssh" and "ssh+git" aliases.
+			return "ssh"
+		}
+		return scheme
+	}
+	colon := strings.Index(url, ":")
+	slash := strings.Index(url, "/")
+	if colon > 1 && (slash < 0 || colon < slash) {
+		// An scp-like address, e.g. "git@github.com:user/repo".
+		return "ssh"
+	}
+	return "file"
+}
+
+// getProtocolHint returns advice for when a URL using the given protocol
+// can not be reached.
+func getProtocolHint(protocol string) string {
+	switch protocol {
+	case "https", "http":
+		return "If the fork is private, configure a credential helper (see `git help credentials`), or use an SSH URL instead."
+	case "ssh":
+		return "Check that your SSH key is loaded (e.g. with `ssh-add -l`) and has access to the fork."
+	default:
+		return "Check that the URL is correct, and that the repository exists."
+	}
+}
+
+// getForkURLs returns the URLs of the given fork in order of preference,
+// falling back to the URL of the remote with the same name.
+func getForkURLs(repo repository.Repo, name string) ([]string, error) {
+	urls, err := repo.GetConfigValues(getForkURLsKey(name))
+	if err != nil || len(urls) > 0 {
+		return urls, err
+	}
+	url, err := repo.GetConfig("remote." + name + ".url")
+	if err != nil || url == "" {
+		return nil, err
+	}
+	return []string{url}, nil
+}
+
+// addFork registers the URLs of a fork, in order of preference, and adds a
+// remote for it if there is not one already.
+func addFork(repo repository.Repo, args []string) error {
+	forkAddFlagSet.Parse(args)
+	args = forkAddFlagSet.Args()
+	if len(args) < 2 {
+		return errors.New("You must specify the name of the fork, and at least one URL for it.")
+	}
+	name, urls := args[0], args[1:]
+	if strings.Contains(name, "/") || name+"/" == review.URLForkName {
+		return fmt.Errorf("Invalid fork name %q: the names of forks can not contain slashes, and %q is reserved for the forks of `request --from-remote`.",
+			name, strings.TrimSuffix(review.URLForkName, "/"))
+	}
+	for _, url := range urls {
+		if err := repo.AddConfigValue(getForkURLsKey(name), url); err != nil {
+			return err
+		}
+	}
+	if *forkAddHint != "" {
+		if err := repo.AddConfigValue(getForkHintKey(name), *forkAddHint); err != nil {
+			return err
+		}
+	}
+	remoteURL, err := repo.GetConfig("remote." + name + ".url")
+	if err != nil {
+		return err
+	}
+	if remoteURL != "" {
+		return nil
+	}
+	// The fork's branches are fetched into the same namespace that is used
+	// by `request --from-remote`.
+	if err := repo.AddConfigValue("remote."+name+".url", urls[0]); err != nil {
+		return err
+	}
+	return repo.AddConfigValue("remote."+name+".fetch", "+refs/heads/*:"+forkRefPrefix+name+"/*")
+}
+
+// testFork checks that each URL of the given fork can be reached, and that
+// the fork has review metadata to pull.
+func testFork(repo repository.Repo, args []string) error {
+	name, err := parseForkName(args)
+	if err != nil {
+		return err
+	}
+	urls, err := getForkURLs(repo, name)
+	if err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("There is no fork or remote named %q.", name)
+	}
+	hint, err := repo.GetConfig(getForkHintKey(name))
+	if err != nil {
+		return err
+	}
+	remoteURL, err := repo.GetConfig("remote." + name + ".url")
+	if err != nil {
+		return err
+	}
+
+	var working []string
+	for _, url := range urls {
+		protocol := getURLProtocol(url)
+		refs, err := repo.ListRemoteRefs(url, "refs/heads/*", notesRefPattern)
+		if err != nil {
+			fmt.Printf("%s (%s): unreachable: %v\n  %s\n", url, protocol, err, getProtocolHint(protocol))
+			if hint != "" {
+				fmt.Printf("  %s\n", hint)
+			}
+			continue
+		}
+		working = append(working, url)
+		var branches, notes int
+		for ref := range refs {
+			if strings.HasPrefix(ref, "refs/heads/") {
+				branches++
+			} else {
+				notes++
+			}
+		}
+		fmt.Printf("%s (%s): ok, %d branches", url, protocol, branches)
+		if _, ok := refs[request.Ref]; ok {
+			fmt.Printf(", %d review notes refs\n", notes)
+		} else {
+			fmt.Printf(", but no review requests to pull\n")
+		}
+	}
+	if len(working) == 0 {
+		return fmt.Errorf("None of the URLs for %q can be reached.", name)
+	}
+	if remoteURL != "" && remoteURL != working[0] {
+		fmt.Printf("The remote %q uses %s; to use the preferred working URL instead, run:\n  git remote set-url %s %s\n",
+			name, remoteURL, name, working[0])
+	}
+	return nil
+}
+
+// getQuarantinedNotesRef returns the ref into which the given remote's copy
+// of a local notes ref is fetched.
+func getQuarantinedNotesRef(remote, notesRef string) string {
+	return "refs/notes/remotes/" + remote + "/" + strings.TrimPrefix(notesRef, "refs/notes/")
+}
+
+// getIncomingNotes returns the notes that were fetched from the given remote
+// but that are not yet in the corresponding local notes refs.
+//
+// The result maps each local notes ref to the revisions whose notes would
+// change, and the notes that would be added to each of them.
+func getIncomingNotes(repo repository.Repo, remote string) (map[string]map[string][]repository.Note, error) {
+	incoming := make(map[string]map[string][]repository.Note)
+	for _, localRef := range reviewNotesRefs {
+		remoteRef := getQuarantinedNotesRef(remote, localRef)
+		if hasRef, err := repo.HasRef(remoteRef); err != nil {
+			return nil, err
+		} else if !hasRef {
+			continue
+		}
+		remoteNotes, err := repo.GetAllNotes(remoteRef)
+		if err != nil {
+			return nil, err
+		}
+		localNotes := make(map[string][]repository.Note)
+		if hasRef, err := repo.HasRef(localRef); err != nil {
+			return nil, err
+		} else if hasRef {
+			localNotes, err = repo.GetAllNotes(localRef)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for revision, notes := range remoteNotes {
+			existing := make(map[string]bool)
+			for _, note := range localNotes[revision] {
+				existing[string(note)] = true
+			}
+			var added []repository.Note
+			for _, note := range notes {
+				if len(note) > 0 && !existing[string(note)] {
+					added = append(added, note)
+				}
+			}
+			if len(added) == 0 {
+				continue
+			}
+			if incoming[localRef] == nil {
+				incoming[localRef] = make(map[string][]repository.Note)
+			}
+			incoming[localRef][revision] = added
+		}
+	}
+	return incoming, nil
+}
+
+// getIncomingRevisions returns the revisions, in sorted order, of the reviews
+// whose requests or comments would change by merging the given notes.
+func getIncomingRevisions(incoming map[string]map[string][]repository.Note) []string {
+	seen := make(map[string]bool)
+	var revisions []string
+	for _, ref := range []string{request.Ref, comment.Ref} {
+		for revision := range incoming[ref] {
+			if !seen[revision] {
+				seen[revision] = true
+				revisions = append(revisions, revision)
+			}
+		}
+	}
+	sort.Strings(revisions)
+	return revisions
+}
+
+// printIncomingNotes prints the notes that approving the given remote's
+// quarantined notes would add, returning the number of such notes.
+func printIncomingNotes(incoming map[string]map[string][]repository.Note) int {
+	var refs []string
+	for ref := range incoming {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	count := 0
+	for _, ref := range refs {
+		fmt.Printf("%s:\n", ref)
+		var revisions []string
+		for revision := range incoming[ref] {
+			revisions = append(revisions, revision)
+		}
+		sort.Strings(revisions)
+		for _, revision := range revisions {
+			fmt.Printf("  %s\n", revision)
+			for _, note := range incoming[ref][revision] {
+				fmt.Printf("    + %s\n", note)
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func parseForkName(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("You must specify exactly one fork (remote) name.")
+	}
+	return args[0], nil
+}
+
+// diffFork shows the notes quarantined from the given fork.
+func diffFork(repo repository.Repo, args []string) error {
+	remote, err := parseForkName(args)
+	if err != nil {
+		return err
+	}
+	incoming, err := getIncomingNotes(repo, remote)
+	if err != nil {
+		return err
+	}
+	if printIncomingNotes(incoming) == 0 {
+		output.Infof("There are no quarantined notes from %q.\n", remote)
+	}
+	return nil
+}
+
+// approveFork merges the notes and archives quarantined from the given fork
+// into the local refs.
+func approveFork(repo repository.Repo, args []string) error {
+	forkApproveFlagSet.Parse(args)
+	remote, err := parseForkName(forkApproveFlagSet.Args())
+	if err != nil {
+		return err
+	}
+	incoming, err := getIncomingNotes(repo, remote)
+	if err != nil {
+		return err
+	}
+	if *forkApproveVerify {
+		if err := verifyFetchedReviews(context.Background(), repo, remote, getIncomingRevisions(incoming), os.Stderr); err != nil {
+			return err
+		}
+	}
+	count := printIncomingNotes(incoming)
+	if err := repo.MergeArchives(remote, archiveRefPattern); err != nil {
+		return err
+	}
+	if err := repo.MergeNotes(remote, notesRefPattern); err != nil {
+		return err
+	}
+	if err := recordProvenance(repo, remote, incoming); err != nil {
+		return err
+	}
+	output.Infof("Merged %d notes from %q.\n", count, remote)
+	return nil
+}
+
+// recordProvenance records which of the given incoming requests and
+// comments were merged from the given fork.
+func recordProvenance(repo repository.Repo, remote string, incoming map[string]map[string][]repository.Note) error {
+	url, err := repo.GetConfig("remote." + remote + ".url")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	records := make(map[string]*provenance.Record)
+	getRecord := func(revision string) *provenance.Record {
+		if records[revision] == nil {
+			records[revision] = &provenance.Record{
+				Timestamp: FormatDate(&now),
+				Fork:      remote,
+				URL:       url,
+				SourceRef: getQuarantinedNotesRef(remote, notesRefPattern),
+			}
+		}
+		return records[revision]
+	}
+	for revision, notes := range incoming[request.Ref] {
+		for _, r := range request.ParseAllValid(notes) {
+			hash, err := r.Hash()
+			if err != nil {
+				return err
+			}
+			record := getRecord(revision)
+			record.Requests = append(record.Requests, hash)
+		}
+	}
+	for revision, notes := range incoming[comment.Ref] {
+		for hash := range comment.ParseAllValid(notes) {
+			record := getRecord(revision)
+			record.Comments = append(record.Comments, hash)
+		}
+	}
+	for revision, record := range records {
+		sort.Strings(record.Comments)
+		note, err := record.Write()
+		if err != nil {
+			return err
+		}
+		if err := repo.AppendNote(provenance.Ref, revision, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isForkName reports whether the given name can be that of a fork: either
+// one with no slashes, or one registered by `request --from-remote`.
+func isForkName(name string) bool {
+	if name+"/" == review.URLForkName {
+		return false
+	}
+	name = strings.TrimPrefix(name, review.URLForkName)
+	return name != "" && !strings.Contains(name, "/")
+}
+
+// getForkRefsPrefix returns the prefix of the local refs that hold the
+// branches fetched from the given fork.
+func getForkRefsPrefix(name string) string {
+	return forkRefPrefix + name + "/"
+}
+
+// pullFork fetches the branches of the given fork, and quarantines its
+// review notes so that they can be inspected with `fork diff`.
+func pullFork(repo repository.Repo, args []string) error {
+	forkPullFlagSet.Parse(args)
+	name, err := parseForkName(forkPullFlagSet.Args())
+	if err != nil {
+		return err
+	}
+	if *forkPullDepth < 0 {
+		return errors.New("The depth must not be negative.")
+	}
+	options := repository.FetchOptions{
+		Depth: *forkPullDepth,
+		Prune: *forkPullPrune,
+	}
+	branchRefSpec := "+refs/heads/*:" + getForkRefsPrefix(name) + "*"
+	if err := repo.FetchWithOptions(name, options, branchRefSpec); err != nil {
+		return err
+	}
+	if _, err := repo.FetchAndReturnNewReviewHashes(name, notesRefPattern, archiveRefPattern); err != nil {
+		return err
+	}
+	output.Infof("Fetched the branches and review notes of %q. Run `git appraise fork diff %s` to inspect the notes, and `git appraise fork approve %s` to merge them.\n",
+		name, name, name)
+	return nil
+}
+
+// getStaleForkRefs returns the local refs of the given fork that should be
+// pruned, in sorted order.
+//
+// If the fork is no longer registered, then all of its refs are stale.
+// Otherwise, only the refs of branches that no longer exist upstream are.
+func getStaleForkRefs(name string, localRefs, remoteRefs map[string]string, registered bool) []string {
+	prefix := getForkRefsPrefix(name)
+	var stale []string
+	for ref := range localRefs {
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		if registered {
+			branch := "refs/heads/" + strings.TrimPrefix(ref, prefix)
+			if _, ok := remoteRefs[branch]; ok {
+				continue
+			}
+		}
+		stale = append(stale, ref)
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// pruneFork deletes the local refs of the given fork's branches that no
+// longer exist upstream, or all of them if the fork is no longer registered.
+func pruneFork(repo repository.Repo, args []string) error {
+	forkPruneFlagSet.Parse(args)
+	name, err := parseForkName(forkPruneFlagSet.Args())
+	if err != nil {
+		return err
+	}
+	urls, err := getForkURLs(repo, name)
+	if err != nil {
+		return err
+	}
+	registered := len(urls) > 0
+	if !registered && !isForkName(name) {
+		// The refs under this prefix belong to other forks.
+		return fmt.Errorf("%q is not the name of a fork.", name)
+	}
+	localRefs, err := repo.ListRefs(getForkRefsPrefix(name))
+	if err != nil {
+		return err
+	}
+	if len(localRefs) == 0 {
+		output.Infof("There are no refs for the fork %q.\n", name)
+		return nil
+	}
+	var remoteRefs map[string]string
+	if registered {
+		remoteRefs, err = repo.ListRemoteRefs(urls[0], "refs/heads/*")
+		if err != nil {
+			return fmt.Errorf("Unable to list the branches of %q: %v", name, err)
+		}
+	}
+	inUse := make(map[string]bool)
+	if !*forkPruneForce {
+		for _, summary := range review.ListOpen(repo) {
+			inUse[summary.Request.ReviewRef] = true
+		}
+	}
+	for _, ref := range getStaleForkRefs(name, localRefs, remoteRefs, registered) {
+		if inUse[ref] {
+			output.Infof("Keeping %q, as it is the review ref of an open review.\n", ref)
+			continue
+		}
+		if err := repo.SetRef(ref, "", localRefs[ref]); err != nil {
+			return err
+		}
+		output.Infof("Deleted %q.\n", ref)
+	}
+	return nil
+}
+
+var forkAddCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork add [<option>...] <name> <url>...\n\nRegisters the URLs of a fork, in order of preference.\n\nOptions:\n", arg0)
+		forkAddFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return addFork(ctx.Repo, args)
+	},
+}
+
+var forkTestCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork test <name>\n\nChecks that each URL of a fork can be reached, and has review metadata.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return testFork(ctx.Repo, args)
+	},
+}
+
+var forkPullCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork pull [<option>...] <name>\n\nFetches the branches of a fork, and quarantines its review notes.\n\nOptions:\n", arg0)
+		forkPullFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return pullFork(ctx.Repo, args)
+	},
+}
+
+var forkPruneCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork prune [<option>...] <name>\n\nDeletes the local refs of a fork's branches that no longer exist upstream,\nor all of its refs if the fork is no longer registered.\n\nOptions:\n", arg0)
+		forkPruneFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return pruneFork(ctx.Repo, args)
+	},
+}
+
+var forkDiffCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork diff <name>\n\nShows the review notes fetched by `pull --quarantine <name>` that have not been approved.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return diffFork(ctx.Repo, args)
+	},
+}
+
+var forkApproveCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s fork approve [<option>...] <name>\n\nMerges the review notes fetched by `pull --quarantine <name>` into the local notes.\n\nOptions:\n", arg0)
+		forkApproveFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return approveFork(ctx.Repo, args)
+	},
+}
+
+// forkCmd defines the "fork" subcommand, which manages review metadata
+// pulled from less trusted remotes, such as contributors' forks.
+var forkCmd = newCommandGroup("fork", map[string]*Command{
+	"add":     forkAddCmd,
+	"approve": forkApproveCmd,
+	"diff":    forkDiffCmd,
+	"prune":   forkPruneCmd,
+	"pull":    forkPullCmd,
+	"test":    forkTestCmd,
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/provenance"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestGetURLProtocol(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/user/repo.git": "https",
+		"ssh://git@example.com/repo":       "ssh",
+		"git+ssh://git@example.com/repo":   "ssh",
+		"git@github.com:user/repo.git":     "ssh",
+		"/srv/git/repo.git":                "file",
+		"../repo":                          "file",
+		`C:\repos\repo`:                    "file",
+		"file:///srv/git/repo.git":         "file",
+	} {
+		if got := getURLProtocol(url); got != want {
+			t.Errorf("getURLProtocol(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestGetForkURLs(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if urls, err := getForkURLs(repo, "alice"); err != nil || len(urls) != 0 {
+		t.Fatalf("Unexpected URLs for an unknown fork: %v, %v", urls, err)
+	}
+	if err := repo.AddConfigValue(getForkURLsKey("alice"), "git@example.com:alice/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if urls, err := getForkURLs(repo, "alice"); err != nil || len(urls) != 1 || urls[0] != "git@example.com:alice/repo" {
+		t.Fatalf("Unexpected URLs for a registered fork: %v, %v", urls, err)
+	}
+	if err := addFork(repo, []string{"bob", "git@example.com:bob/repo", "https://example.com/bob/repo"}); err != nil {
+		t.Fatal(err)
+	}
+	if urls, err := getForkURLs(repo, "bob"); err != nil || len(urls) != 2 || urls[0] != "git@example.com:bob/repo" || urls[1] != "https://example.com/bob/repo" {
+		t.Fatalf("Unexpected URLs for a fork added with several: %v, %v", urls, err)
+	}
+}
+
+func TestGetStaleForkRefs(t *testing.T) {
+	localRefs := map[string]string{
+		"refs/forks/alice/feature": "a",
+		"refs/forks/alice/gone":    "b",
+		"refs/forks/bob/feature":   "c",
+	}
+	remoteRefs := map[string]string{
+		"refs/heads/feature": "a",
+	}
+	stale := getStaleForkRefs("alice", localRefs, remoteRefs, true)
+	if len(stale) != 1 || stale[0] != "refs/forks/alice/gone" {
+		t.Errorf("Unexpected stale refs for a registered fork: %v", stale)
+	}
+	stale = getStaleForkRefs("alice", localRefs, nil, false)
+	if len(stale) != 2 || stale[0] != "refs/forks/alice/feature" || stale[1] != "refs/forks/alice/gone" {
+		t.Errorf("Unexpected stale refs for an unregistered fork: %v", stale)
+	}
+}
+
+func TestPruneForkOfOtherForks(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	const forkRef = "refs/forks/url/0123/feature"
+	if err := repo.SetRef(forkRef, repository.TestCommitB, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"url", "url/0123/feature"} {
+		if err := pruneFork(repo, []string{name}); err == nil {
+			t.Errorf("Unexpected pruning of %q", name)
+		}
+	}
+	if exists, err := repo.HasRef(forkRef); err != nil || !exists {
+		t.Errorf("The ref of another fork was pruned: %v", err)
+	}
+	if err := addFork(repo, []string{"url", "https://example.com/repo"}); err == nil {
+		t.Error("Unexpected fork with a reserved name")
+	}
+}
+
+func TestApproveFork(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetConfig("remote.alice.url", "git@example.com:alice/repo"); err != nil {
+		t.Fatal(err)
+	}
+	// An unsigned request, as fetched by `pull --quarantine alice`.
+	incoming := request.New("alice@example.com", nil, "refs/heads/feature", "refs/heads/master", "From a fork")
+	note, err := incoming.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(getQuarantinedNotesRef("alice", request.Ref), repository.TestCommitJ, note); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyFetchedReviews(context.Background(), repo, "alice", []string{repository.TestCommitJ}, nil); err == nil {
+		t.Error("Unexpected verification of an unsigned review")
+	}
+	defer func() { *forkApproveVerify = false }()
+	if err := approveFork(repo, []string{"--verify-signatures", "alice"}); err == nil {
+		t.Fatal("Unexpected approval of an unsigned review with --verify-signatures")
+	}
+	if notes := request.ParseAllValid(repo.GetNotes(request.Ref, repository.TestCommitJ)); len(notes) != 0 {
+		t.Fatalf("Unexpected notes merged despite the failed verification: %v", notes)
+	}
+
+	*forkApproveVerify = false
+	if err := approveFork(repo, []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if notes := request.ParseAllValid(repo.GetNotes(request.Ref, repository.TestCommitJ)); len(notes) != 1 || notes[0].Description != "From a fork" {
+		t.Errorf("Unexpected requests after the approval: %v", notes)
+	}
+	if records := provenance.ParseAllValid(repo.GetNotes(provenance.Ref, repository.TestCommitJ)); len(records) != 1 || records[0].Fork != "alice" {
+		t.Errorf("Unexpected provenance after the approval: %+v", records)
+	}
+}
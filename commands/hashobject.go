@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+)
+
+var hashObjectFlagSet = flag.NewFlagSet("hash-object", flag.ExitOnError)
+
+var (
+	hashObjectComment = hashObjectFlagSet.Bool("comment", false,
+		"Hash the given files as review comments. Use - to read a comment from the standard input")
+)
+
+// hashComment returns the hash that the tool uses to identify the comment in
+// the given JSON object, e.g. in the "parent" field of replies to it.
+func hashComment(contents string) (string, error) {
+	c, err := comment.Parse(repository.Note(contents))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse the comment: %v", err)
+	}
+	if c.Version != comment.FormatVersion {
+		return "", fmt.Errorf("Unsupported comment format version %d.", c.Version)
+	}
+	return c.Hash()
+}
+
+// hashObject prints the hashes of the given review metadata files.
+func hashObject(args []string) error {
+	hashObjectFlagSet.Parse(args)
+	args = hashObjectFlagSet.Args()
+	if !*hashObjectComment {
+		return errors.New("You must specify the type of object to hash, e.g. --comment.")
+	}
+	if len(args) == 0 {
+		return errors.New("You must specify at least one file to hash.")
+	}
+	for _, file := range args {
+		contents, err := input.FromFile(file)
+		if err != nil {
+			return err
+		}
+		hash, err := hashComment(contents)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		fmt.Println(hash)
+	}
+	return nil
+}
+
+// hashObjectCmd defines the "hash-object" subcommand.
+var hashObjectCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s hash-object --comment <file>...\n\nPrints the hash that identifies each given review comment, computed from its canonical serialization.\n\nOptions:\n", arg0)
+		hashObjectFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return hashObject(args)
+	},
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+)
+
+func TestHashComment(t *testing.T) {
+	// The expected hashes are the SHA1 hashes of the canonical serializations,
+	// e.g. `{"author":"user@example.com","description":"<b>Nice</b>","timestamp":"0000000001"}`,
+	// so that they change if the canonical form ever does.
+	for _, tc := range []struct {
+		note     string
+		expected string
+	}{
+		{
+			note:     `{"timestamp": "0000000001", "author": "user@example.com", "description": "<b>Nice</b>", "v": 0}`,
+			expected: "96d3d90001c51104d52ca33e553f413066970170",
+		},
+		{
+			note:     `{"timestamp": "2", "author": "user@example.com", "location": {"commit": "abc", "path": "a.go", "range": {"startLine": 3}}, "description": "Nit", "positionEncoding": "1-based-bytes"}`,
+			expected: "3888dc8d1ff6064b82f611fdea91840be315619c",
+		},
+	} {
+		hash, err := hashComment(tc.note)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != tc.expected {
+			t.Errorf("Unexpected hash for %s: %q, expected %q", tc.note, hash, tc.expected)
+		}
+	}
+	if _, err := hashComment(`{"description": "Future", "v": 1}`); err == nil {
+		t.Error("Unexpected success hashing a comment with an unsupported version")
+	}
+	if _, err := hashComment(`not json`); err == nil {
+		t.Error("Unexpected success hashing an invalid comment")
+	}
+}
@@ -64,6 +64,23 @@ func GetDate(timestamp string) (*time.Time, error) {
 	return nil, nil
 }
 
+// GetDueDate parses the deadline for a review, which is either a date in one of
+// the formats supported by GetDate, or a duration (e.g. "3d" or "36h") after
+// the given start time.
+func GetDueDate(due string, start time.Time) (*time.Time, error) {
+	if days := strings.TrimSuffix(due, "d"); days != due {
+		if count, err := strconv.Atoi(days); err == nil && count >= 0 {
+			date := start.AddDate(0, 0, count)
+			return &date, nil
+		}
+	}
+	if duration, err := time.ParseDuration(due); err == nil && duration >= 0 {
+		date := start.Add(duration)
+		return &date, nil
+	}
+	return GetDate(due)
+}
+
 func FormatDate(date *time.Time) string {
 	if date == nil {
 		return ""
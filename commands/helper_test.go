@@ -3,6 +3,7 @@ package commands
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestGetDate(t *testing.T) {
@@ -29,3 +30,22 @@ func TestGetDate(t *testing.T) {
 		t.Errorf("Expected no error, got %v", err)
 	}
 }
+
+func TestGetDueDate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	due, err := GetDueDate("3d", start)
+	if err != nil || !due.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("Unexpected due date for 3d: %v, %v", due, err)
+	}
+	due, err = GetDueDate("36h", start)
+	if err != nil || !due.Equal(start.Add(36*time.Hour)) {
+		t.Errorf("Unexpected due date for 36h: %v, %v", due, err)
+	}
+	due, err = GetDueDate("1488452400 +0100", start)
+	if err != nil || due.Unix() != 1488452400 {
+		t.Errorf("Unexpected due date for an absolute date: %v, %v", due, err)
+	}
+	if _, err := GetDueDate("-3d", start); err == nil {
+		t.Error("Expected an error for a negative duration")
+	}
+}
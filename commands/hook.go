@@ -0,0 +1,267 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/owners"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/teams"
+)
+
+// autoRequestConfig is the git config key listing the patterns (e.g.
+// "alice/*") of the branches whose reviews are requested when they are pushed.
+const autoRequestConfig = "appraise.autoRequest.branches"
+
+// prePushHook is the hook that `hook install` installs.
+const prePushHook = `#!/bin/sh
+# Installed by git appraise hook install: request reviews of pushed branches.
+exec git appraise hook pre-push "$@"
+`
+
+// zeroHash is the hash that git passes to hooks for refs that do not exist.
+const zeroHash = "0000000000000000000000000000000000000000"
+
+// getAutoRequestPatterns returns the patterns of the branches whose reviews
+// are requested when they are pushed.
+func getAutoRequestPatterns(repo repository.Repo) ([]string, error) {
+	values, err := repo.GetConfigValues(autoRequestConfig)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, value := range values {
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// matchesAnyPattern reports whether the short name of the given branch
+// matches one of the given patterns.
+func matchesAnyPattern(ref string, patterns []string) bool {
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return false
+	}
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// getChangedPaths returns the paths of the files changed between the given commits.
+func getChangedPaths(repo repository.Repo, from, to string) ([]string, error) {
+	out, err := repo.Diff(from, to, "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// getOwnerReviewers returns the owners of the files changed between the given
+// commits, other than the given user, as read from the owners files at the
+// target ref.
+func getOwnerReviewers(repo repository.Repo, targetRef, from, to, user string) ([]string, error) {
+	paths, err := getChangedPaths(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	pathOwners, err := owners.ForPaths(repo, targetRef, paths)
+	if err != nil {
+		return nil, err
+	}
+	var reviewers []string
+	for _, owner := range pathOwners {
+		if owner != user {
+			reviewers = append(reviewers, owner)
+		}
+	}
+	return reviewers, nil
+}
+
+// autoRequestReview creates a review request for the given branch, or, if it
+// already has an open review, adds the owners of any newly changed files to
+// its reviewers. It returns a description of what it did, or the empty
+// string if the review was already up to date.
+func autoRequestReview(repo repository.Repo, reviewRef string) (string, error) {
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return "", err
+	}
+	existing, err := review.GetByReviewRef(repo, reviewRef)
+	if err != nil {
+		return "", err
+	}
+	var r request.Request
+	var reviewCommit string
+	if existing != nil {
+		r = existing.Request
+		reviewCommit = existing.Revision
+	} else {
+		targetRef, err := getDefaultTarget(repo)
+		if err != nil {
+			return "", err
+		}
+		r = request.New(userEmail, nil, reviewRef, targetRef, "")
+		var baseCommit string
+		if reviewCommit, baseCommit, err = getReviewCommit(repo, r, nil); err != nil {
+			return "", err
+		}
+		r.BaseCommit = baseCommit
+		if r.Description, err = repo.GetCommitMessage(reviewCommit); err != nil {
+			return "", err
+		}
+	}
+	base, err := repo.MergeBase(r.TargetRef, reviewRef)
+	if err != nil {
+		return "", err
+	}
+	owned, err := getOwnerReviewers(repo, r.TargetRef, base, reviewRef, userEmail)
+	if err != nil {
+		return "", err
+	}
+	expanded, referenced, err := teams.Expand(repo, r.TargetRef, owned)
+	if err != nil {
+		return "", err
+	}
+	var added []string
+	for _, reviewer := range expanded {
+		if reviewer != userEmail && reviewer != r.Requester && !containsString(r.Reviewers, reviewer) {
+			added = append(added, reviewer)
+		}
+	}
+	if existing != nil && len(added) == 0 {
+		return "", nil
+	}
+	r.Reviewers = append(r.Reviewers, added...)
+	for name, members := range referenced {
+		if r.ReviewerTeams == nil {
+			r.ReviewerTeams = make(map[string][]string)
+		}
+		r.ReviewerTeams[name] = members
+	}
+	now := time.Now()
+	r.Timestamp = FormatDate(&now)
+	// The requester of an existing review stays the same, even when someone
+	// else pushes to its branch.
+	r.Sig = gpg.Sig{}
+	note, err := r.Write()
+	if err != nil {
+		return "", err
+	}
+	if err := repo.AppendNote(request.Ref, reviewCommit, note); err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return fmt.Sprintf("Added %s as reviewers of review %.12s.", strings.Join(added, ", "), reviewCommit), nil
+	}
+	if len(r.Reviewers) == 0 {
+		return fmt.Sprintf("Requested review %.12s of %q, which has no owners to review it.", reviewCommit, reviewRef), nil
+	}
+	return fmt.Sprintf("Requested review %.12s of %q from %s.", reviewCommit, reviewRef, strings.Join(r.Reviewers, ", ")), nil
+}
+
+// containsString reports whether the given list contains the given value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runPrePushHook requests reviews of the pushed branches that match the
+// configured patterns, reading the pushed refs from the given input in the
+// format that git passes to pre-push hooks.
+//
+// A failure to request a review is reported as a warning, rather than
+// returned, so that it does not stop the push.
+func runPrePushHook(repo repository.Repo, in io.Reader, out io.Writer) error {
+	patterns, err := getAutoRequestPatterns(repo)
+	if err != nil || len(patterns) == 0 {
+		return err
+	}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		// Each line is "<local ref> <local hash> <remote ref> <remote hash>".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || fields[1] == zeroHash || !matchesAnyPattern(fields[0], patterns) {
+			continue
+		}
+		message, err := autoRequestReview(repo, fields[0])
+		if err != nil {
+			fmt.Fprintf(out, "Warning: unable to request a review of %q: %v\n", fields[0], err)
+			continue
+		}
+		if message != "" {
+			fmt.Fprintln(out, message+" Run `git appraise push` to share it.")
+		}
+	}
+	return scanner.Err()
+}
+
+var hookPrePushCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s hook pre-push [<remote> [<url>]]\n\nRequests reviews of the pushed branches that match the patterns in %s.\nThis is run by the hook that `hook install` installs.\n", arg0, autoRequestConfig)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return runPrePushHook(ctx.Repo, os.Stdin, os.Stderr)
+	},
+}
+
+var hookInstallCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s hook install\n\nInstalls a pre-push hook that runs `%s hook pre-push`.\n", arg0, arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		if len(args) > 0 {
+			return errors.New("The install subcommand takes no arguments.")
+		}
+		return installHook(ctx.Repo, os.Stdout, "pre-push", prePushHook)
+	},
+}
+
+// hookCmd defines the "hook" subcommand, which implements the git hooks that
+// automate parts of the review workflow.
+var hookCmd = newCommandGroup("hook", map[string]*Command{
+	"install":  hookInstallCmd,
+	"pre-push": hookPrePushCmd,
+})
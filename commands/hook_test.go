@@ -0,0 +1,122 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/owners"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestAutoRequestPatterns(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetConfig(autoRequestConfig, "alice/*, release-*"); err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := getAutoRequestPatterns(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patterns, []string{"alice/*", "release-*"}) {
+		t.Fatalf("Unexpected patterns: %q", patterns)
+	}
+	for ref, expected := range map[string]bool{
+		"refs/heads/alice/fix":     true,
+		"refs/heads/alice/fix/sub": false,
+		"refs/heads/release-1.0":   true,
+		"refs/heads/bob/fix":       false,
+		"refs/tags/release-1.0":    false,
+	} {
+		if matched := matchesAnyPattern(ref, patterns); matched != expected {
+			t.Errorf("matchesAnyPattern(%q) = %v, expected %v", ref, matched, expected)
+		}
+	}
+}
+
+func TestRunPrePushHookSkipsUnmatchedRefs(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetConfig(autoRequestConfig, "alice/*"); err != nil {
+		t.Fatal(err)
+	}
+	in := strings.NewReader(repository.TestReviewRef + " I refs/heads/ojarjur/mychange H\n" +
+		"refs/heads/alice/deleted " + zeroHash + " refs/heads/alice/deleted H\n")
+	var out bytes.Buffer
+	if err := runPrePushHook(repo, in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Unexpected output for refs that do not match: %q", out.String())
+	}
+}
+
+// repoWithRootOwners has an owners file at the top of every commit.
+type repoWithRootOwners struct {
+	repository.Repo
+}
+
+func (r repoWithRootOwners) HasObject(object string) (bool, error) {
+	if strings.HasSuffix(object, ":"+owners.FileName) {
+		return true, nil
+	}
+	return r.Repo.HasObject(object)
+}
+
+func (r repoWithRootOwners) Show(commit, path string) (string, error) {
+	if path == owners.FileName {
+		return "owner@example.com\n", nil
+	}
+	return r.Repo.Show(commit, path)
+}
+
+func TestAutoRequestReviewKeepsRequester(t *testing.T) {
+	repo := repoWithRootOwners{repository.NewMockRepoForTest()}
+	const reviewRef = "refs/heads/other/fix"
+	if err := repo.SetRef(reviewRef, repository.TestCommitE, ""); err != nil {
+		t.Fatal(err)
+	}
+	req := request.New("other@example.com", nil, reviewRef, repository.TestTargetRef, "Fix")
+	note, err := req.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(request.Ref, repository.TestCommitE, note); err != nil {
+		t.Fatal(err)
+	}
+	message, err := autoRequestReview(repo, reviewRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message, "owner@example.com") {
+		t.Errorf("Unexpected message: %q", message)
+	}
+	r, err := review.GetByReviewRef(repo, reviewRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Request.Requester != "other@example.com" {
+		t.Errorf("Unexpected requester after pushing someone else's review: %q", r.Request.Requester)
+	}
+	if !containsString(r.Request.Reviewers, "owner@example.com") {
+		t.Errorf("Missing the owner from the reviewers: %q", r.Request.Reviewers)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+)
+
+// notesHTTPTimeout is how long reading or writing the notes bundle at an
+// HTTP(S) URL may take.
+const notesHTTPTimeout = 5 * time.Minute
+
+// notesHTTPClient reads and writes the notes bundles at HTTP(S) URLs.
+var notesHTTPClient = &http.Client{Timeout: notesHTTPTimeout}
+
+// maxHTTPPushAttempts is the number of times that pushing to an HTTP(S) notes
+// URL is retried when someone else updated the notes at the same time.
+const maxHTTPPushAttempts = 3
+
+// errNotesBundleConflict is returned when the notes bundle at an HTTP(S) URL
+// changed between reading it and writing the new one.
+var errNotesBundleConflict = errors.New("the notes bundle was changed by someone else")
+
+// getNotesURL returns the HTTP(S) URL that the review notes of the given
+// remote are synced with instead of its refs/notes/* refs, or the empty
+// string if the notes are pushed to and fetched from the remote itself.
+//
+// This is set with the remote.<name>.notesUrl git config, for hosts that do
+// not accept pushes to the refs/notes/ namespace.
+func getNotesURL(repo repository.Repo, remote string) (string, error) {
+	notesURL, err := repo.GetConfig("remote." + remote + ".notesUrl")
+	if err != nil || notesURL == "" {
+		return "", err
+	}
+	parsed, err := url.Parse(notesURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("Invalid value for remote.%s.notesUrl: %q; must be an http or https URL", remote, notesURL)
+	}
+	return notesURL, nil
+}
+
+// redactURL removes the password, if any, from the given URL so that it can
+// be included in messages.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
+
+// getNotesBundle downloads the notes bundle at the given URL to the given
+// path, and returns its ETag.
+//
+// The returned bool is false if there is no bundle at the URL yet.
+func getNotesBundle(notesURL, path string) (string, bool, error) {
+	resp, err := notesHTTPClient.Get(notesURL)
+	if err != nil {
+		return "", false, fmt.Errorf("failure reading the notes from %q: %v", redactURL(notesURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("failure reading the notes from %q: %s", redactURL(notesURL), resp.Status)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return "", false, fmt.Errorf("failure reading the notes from %q: %v", redactURL(notesURL), err)
+	}
+	return resp.Header.Get("ETag"), true, nil
+}
+
+// putNotesBundle uploads the bundle at the given path to the given URL.
+//
+// The etag is that of the bundle that the upload replaces, so that the
+// upload fails with errNotesBundleConflict, rather than dropping someone
+// else's notes, if the bundle has changed since it was read. If there was
+// no bundle, then the upload only succeeds if there still is none. Replacing
+// a bundle that had no ETag is refused, as it could not be done safely.
+func putNotesBundle(notesURL, path, etag string, existed bool) error {
+	if existed && etag == "" {
+		return fmt.Errorf("the notes bundle at %q has no ETag, so replacing it could drop other people's notes", redactURL(notesURL))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	req, err := http.NewRequest(http.MethodPut, notesURL, f)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if existed {
+		req.Header.Set("If-Match", etag)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+	resp, err := notesHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failure writing the notes to %q: %v", redactURL(notesURL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return errNotesBundleConflict
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failure writing the notes to %q: %s %s", redactURL(notesURL), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// pullNotesOverHTTP merges the review notes from the bundle at the given URL
+// into the local refs, and returns the ETag of the bundle and whether there
+// was one.
+func pullNotesOverHTTP(repo repository.Repo, notesURL string) (string, bool, error) {
+	dir, err := ioutil.TempDir("", "git-appraise-notes")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.RemoveAll(dir)
+	bundlePath := filepath.Join(dir, "notes.bundle")
+	etag, found, err := getNotesBundle(notesURL, bundlePath)
+	if err != nil || !found {
+		return etag, found, err
+	}
+	if err := repo.PullNotesAndArchiveFromBundle(bundlePath, notesRefPattern, archiveRefPattern); err != nil {
+		return "", false, err
+	}
+	return etag, true, nil
+}
+
+// pushNotesOverHTTP replaces the bundle at the given URL with one of the
+// local review notes.
+//
+// The archives of reviewed commits are not included, as they reach the
+// whole history of the repository, which would be uploaded on every push.
+//
+// The notes in the existing bundle are merged into the local ones first, so
+// that the new bundle still contains them, and the whole push is retried if
+// someone else pushed in the meantime.
+func pushNotesOverHTTP(repo repository.Repo, notesURL string) error {
+	dir, err := ioutil.TempDir("", "git-appraise-notes")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	bundlePath := filepath.Join(dir, "notes.bundle")
+	for attempt := 1; ; attempt++ {
+		etag, existed, err := pullNotesOverHTTP(repo, notesURL)
+		if err != nil {
+			return err
+		}
+		os.Remove(bundlePath)
+		if _, err := repo.CreateBundle(bundlePath, notesRefPattern); errors.Is(err, repository.ErrNoRefsToBundle) {
+			output.Infof("There are no review notes to push to %q\n", redactURL(notesURL))
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = putNotesBundle(notesURL, bundlePath, etag, existed)
+		if err != errNotesBundleConflict {
+			return err
+		}
+		if attempt == maxHTTPPushAttempts {
+			return fmt.Errorf("Failed to push the notes to %q, because %v while pushing; try again later", redactURL(notesURL), err)
+		}
+		output.Infof("The notes at %q changed while pushing; retrying\n", redactURL(notesURL))
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestGetNotesURL(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if notesURL, err := getNotesURL(repo, "origin"); err != nil || notesURL != "" {
+		t.Errorf("getNotesURL() = %q, %v; want no URL", notesURL, err)
+	}
+	repo.AddConfigValue("remote.origin.notesUrl", "https://example.com/notes")
+	if notesURL, err := getNotesURL(repo, "origin"); err != nil || notesURL != "https://example.com/notes" {
+		t.Errorf("getNotesURL() = %q, %v; want the configured URL", notesURL, err)
+	}
+	repo.AddConfigValue("remote.fork.notesUrl", "git@example.com:notes")
+	if _, err := getNotesURL(repo, "fork"); err == nil {
+		t.Error("Failed to reject a notes URL that is not http or https")
+	}
+}
+
+// notesServer serves a single notes bundle, honoring the If-Match and
+// If-None-Match preconditions of uploads.
+type notesServer struct {
+	contents []byte
+	etag     string
+}
+
+func (s *notesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if s.contents == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", s.etag)
+		w.Write(s.contents)
+	case http.MethodPut:
+		if (r.Header.Get("If-None-Match") == "*" && s.contents != nil) ||
+			(r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != s.etag) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.contents, _ = ioutil.ReadAll(r.Body)
+		s.etag += "x"
+	}
+}
+
+func TestNotesBundleRoundTrip(t *testing.T) {
+	server := &notesServer{etag: `"v"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.bundle")
+
+	if _, found, err := getNotesBundle(ts.URL, path); err != nil || found {
+		t.Fatalf("getNotesBundle() of a missing bundle = %v, %v", found, err)
+	}
+	ioutil.WriteFile(path, []byte("first"), 0644)
+	if err := putNotesBundle(ts.URL, path, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := putNotesBundle(ts.URL, path, "", false); err != errNotesBundleConflict {
+		t.Errorf("Failed to reject creating a bundle that already exists: %v", err)
+	}
+
+	etag, found, err := getNotesBundle(ts.URL, path)
+	if err != nil || !found {
+		t.Fatalf("getNotesBundle() = %v, %v", found, err)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "first" {
+		t.Errorf("Unexpected bundle contents %q", contents)
+	}
+	ioutil.WriteFile(path, []byte("second"), 0644)
+	if err := putNotesBundle(ts.URL, path, etag, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := putNotesBundle(ts.URL, path, etag, true); err != errNotesBundleConflict {
+		t.Errorf("Failed to reject replacing a bundle that changed: %v", err)
+	}
+	if string(server.contents) != "second" {
+		t.Errorf("Unexpected stored bundle %q", server.contents)
+	}
+}
+
+// repoWithoutNotes is a mock repo that has no review notes to bundle.
+type repoWithoutNotes struct {
+	repository.Repo
+}
+
+func (repoWithoutNotes) CreateBundle(path string, refPatterns ...string) (map[string]string, error) {
+	return nil, repository.ErrNoRefsToBundle
+}
+
+func TestPushNotesOverHTTP(t *testing.T) {
+	server := &notesServer{etag: `"v"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	if err := pushNotesOverHTTP(repoWithoutNotes{repository.NewMockRepoForTest()}, ts.URL); err != nil || server.contents != nil {
+		t.Fatalf("Unexpected push without any notes: %q, %v", server.contents, err)
+	}
+	repo := repository.NewMockRepoForTest()
+	if err := pushNotesOverHTTP(repo, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(server.contents), "refs/notes/devtools/reviews") || strings.Contains(string(server.contents), "refs/devtools/archives/") {
+		t.Errorf("Unexpected first bundle %q", server.contents)
+	}
+	if etag, found, err := pullNotesOverHTTP(repo, ts.URL); err != nil || !found || etag != server.etag {
+		t.Errorf("pullNotesOverHTTP() = %q, %v, %v", etag, found, err)
+	}
+	if err := pushNotesOverHTTP(repo, ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if server.etag != `"v"xx` {
+		t.Errorf("Unexpected number of pushes: %q", server.etag)
+	}
+
+	server.etag = ""
+	if err := pushNotesOverHTTP(repo, ts.URL); err == nil {
+		t.Error("Failed to refuse replacing a bundle without an ETag")
+	}
+}
+
+func TestPushOverHTTPWithQuarantine(t *testing.T) {
+	server := &notesServer{etag: `"v"`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetConfig("remote.origin.notesUrl", ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetConfig("appraise.pull.quarantine", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := push(repo, nil); err == nil || !strings.Contains(err.Error(), "quarantine") {
+		t.Errorf("Failed to refuse pushing over HTTP with quarantine: %v", err)
+	}
+	if server.contents != nil {
+		t.Errorf("Unexpected bundle pushed with quarantine: %q", server.contents)
+	}
+}
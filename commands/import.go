@@ -0,0 +1,409 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/git-appraise/archive"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/hosting"
+	"github.com/google/git-appraise/interop"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/trailer"
+)
+
+var importTrailersFlagSet = flag.NewFlagSet("trailers", flag.ExitOnError)
+
+var (
+	importTrailersRef    = importTrailersFlagSet.String("ref", "HEAD", "The ref whose history should be scanned for review trailers")
+	importTrailersDryRun = importTrailersFlagSet.Bool("n", false, "Only list the reviews that would be imported")
+)
+
+// importedReviewers returns the email addresses of everyone recorded as
+// having reviewed or acknowledged the given commit message.
+func importedReviewers(message string) []string {
+	var reviewers []string
+	seen := make(map[string]bool)
+	for _, t := range trailer.Parse(message) {
+		if t.Key != trailer.ReviewedBy && t.Key != trailer.AckedBy {
+			continue
+		}
+		email := trailer.Email(t.Value)
+		if email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		reviewers = append(reviewers, email)
+	}
+	return reviewers
+}
+
+// importCommitTrailers records a submitted review for a single commit that
+// carries review trailers, returning whether or not a review was created.
+func importCommitTrailers(repo repository.Repo, commit, targetRef string) (bool, error) {
+	if existing := request.ParseAllValid(repo.GetNotes(request.Ref, commit)); len(existing) > 0 {
+		// The commit already has a review, so there is nothing to import.
+		return false, nil
+	}
+	message, err := repo.GetCommitMessage(commit)
+	if err != nil {
+		return false, err
+	}
+	reviewers := importedReviewers(message)
+	if len(reviewers) == 0 {
+		return false, nil
+	}
+	details, err := repo.GetCommitDetails(commit)
+	if err != nil {
+		return false, err
+	}
+	output.Infof("Importing review %.12s accepted by %v\n", commit, reviewers)
+	if *importTrailersDryRun {
+		return true, nil
+	}
+
+	r := request.New(details.AuthorEmail, reviewers, "", targetRef, message)
+	r.Timestamp = details.Time
+	if len(details.Parents) > 0 {
+		r.BaseCommit = details.Parents[0]
+	}
+	requestNote, err := r.Write()
+	if err != nil {
+		return false, err
+	}
+	if err := repo.AppendNote(request.Ref, commit, requestNote); err != nil {
+		return false, err
+	}
+	for _, reviewer := range reviewers {
+		resolved := true
+		c := comment.New(reviewer, "")
+		c.Timestamp = details.Time
+		c.Location = &comment.Location{Commit: commit}
+		c.Resolved = &resolved
+		commentNote, err := c.Write()
+		if err != nil {
+			return false, err
+		}
+		if err := repo.AppendNote(comment.Ref, commit, commentNote); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// importTrailers synthesizes submitted reviews from the Reviewed-by and
+// Acked-by trailers found in the history of the given ref.
+func importTrailers(repo repository.Repo, args []string) error {
+	importTrailersFlagSet.Parse(args)
+	if len(importTrailersFlagSet.Args()) > 0 {
+		return errors.New("The import trailers command does not take any positional arguments.")
+	}
+	targetRef := *importTrailersRef
+	if targetRef == "HEAD" {
+		headRef, err := repo.GetHeadRef()
+		if err != nil {
+			return err
+		}
+		targetRef = headRef
+	}
+	if err := repo.VerifyGitRef(targetRef); err != nil {
+		return err
+	}
+
+	imported := 0
+	for _, commit := range repo.ListCommits(targetRef) {
+		if commit == "" {
+			continue
+		}
+		created, err := importCommitTrailers(repo, commit, targetRef)
+		if err != nil {
+			return fmt.Errorf("Failed to import the review trailers for %q: %v", commit, err)
+		}
+		if created {
+			imported++
+		}
+	}
+	output.Infof("Imported %d reviews\n", imported)
+	return nil
+}
+
+var importTrailersCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s import trailers [<option>...]\n\nOptions:\n", arg0)
+		importTrailersFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return importTrailers(ctx.Repo, args)
+	},
+}
+
+// importArchive adds the review metadata in a JSON archive, as written by
+// the export json-archive command, to the repository.
+func importArchive(repo repository.Repo, args []string) error {
+	if len(args) != 1 {
+		return errors.New("The import json-archive command takes exactly one argument: the archive to import, or - to read it from the standard input.")
+	}
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	a, err := archive.Read(r)
+	if err != nil {
+		return fmt.Errorf("Failed to read the archive: %v", err)
+	}
+	imported, err := archive.Import(repo, a)
+	if err != nil {
+		return err
+	}
+	output.Infof("Imported %d notes\n", imported)
+	return nil
+}
+
+var importArchiveCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s import json-archive <file>\n\nAdds the requests, comments, and reports in a JSON archive written by export json-archive, skipping those that are already present.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return importArchive(ctx.Repo, args)
+	},
+}
+
+// pullRequestImport holds the flags of a command that imports the pull
+// requests of another code review tool.
+type pullRequestImport struct {
+	flagSet *flag.FlagSet
+	// tool is the name of the tool, and host is the host of its public
+	// instance.
+	tool string
+	host string
+	// tokenVariable is the environment variable that holds the API token.
+	tokenVariable string
+	// apiURLForHost returns the URL of the API of a self-hosted instance of
+	// the tool, or is nil if the tool can not be self-hosted with the same
+	// API as its public instance.
+	apiURLForHost func(host string) string
+	newSource     func(apiURL, project, token string) interop.Source
+	project       *string
+	apiURL        *string
+	remote        *string
+	dryRun        *bool
+}
+
+// newPullRequestImport returns the flags of the command that imports the pull
+// requests of the given tool.
+func newPullRequestImport(tool, host, tokenVariable string, apiURLForHost func(host string) string, newSource func(apiURL, project, token string) interop.Source) *pullRequestImport {
+	flagSet := flag.NewFlagSet(tool, flag.ExitOnError)
+	return &pullRequestImport{
+		flagSet:       flagSet,
+		tool:          tool,
+		host:          host,
+		tokenVariable: tokenVariable,
+		apiURLForHost: apiURLForHost,
+		newSource:     newSource,
+		project:       flagSet.String("project", "", "The project to import the pull requests of, e.g. \"owner/repo\"; defaults to that of the remote's URL"),
+		apiURL:        flagSet.String("api-url", "", "The URL of the API, for instances other than the public one"),
+		remote:        flagSet.String("remote", "origin", "Remote whose URL identifies the project, unless --project is set"),
+		dryRun:        flagSet.Bool("n", false, "Only list the pull requests that would be imported"),
+	}
+}
+
+// run imports the pull requests of the project.
+func (p *pullRequestImport) run(repo repository.Repo, args []string) error {
+	p.flagSet.Parse(args)
+	if len(p.flagSet.Args()) > 0 {
+		return fmt.Errorf("The import %s command does not take any positional arguments.", p.tool)
+	}
+	project, host, apiURL := *p.project, p.host, *p.apiURL
+	if project == "" {
+		remoteURL, err := repo.GetConfig("remote." + *p.remote + ".url")
+		if err != nil {
+			return err
+		}
+		remoteHost, path, err := hosting.ParseRemoteURL(remoteURL)
+		if err != nil {
+			return fmt.Errorf("Set the --project flag, as %v", err)
+		}
+		project, host = path, remoteHost
+	}
+	if apiURL == "" && !strings.EqualFold(host, p.host) {
+		// The project is on a self-hosted instance, whose API the token
+		// is for, rather than the public one.
+		if p.apiURLForHost == nil {
+			return fmt.Errorf("Set the --api-url flag, as %q is not %s.", host, p.host)
+		}
+		apiURL = p.apiURLForHost(host)
+	}
+	prs, err := p.newSource(apiURL, project, os.Getenv(p.tokenVariable)).PullRequests()
+	if err != nil {
+		return fmt.Errorf("Failed to read the pull requests of %q: %v", project, err)
+	}
+	return importPullRequests(repo, prs, host, *p.dryRun)
+}
+
+// importPullRequests imports the given pull requests whose head commits have
+// been fetched, and that do not already have reviews, unless this is a dry run.
+//
+// The emails of the users are set by the appraise.import.email.<username>
+// configs, and are otherwise <username>@<host>.
+func importPullRequests(repo repository.Repo, prs []interop.PullRequest, host string, dryRun bool) error {
+	importer := &interop.Importer{
+		Repo: repo,
+		Host: host,
+		Lookup: func(username string) string {
+			email, _ := config.Lookup(repo, "appraise.import.email."+username)
+			return email
+		},
+	}
+	imported := 0
+	for _, pr := range prs {
+		if dryRun {
+			fmt.Printf("%s\t%.12s\t%s\t%s\n", pr.ID, pr.HeadCommit, pr.State, pr.Title)
+			continue
+		}
+		created, err := importer.Import(pr)
+		if err != nil {
+			return fmt.Errorf("Failed to import pull request %s: %v", pr.ID, err)
+		}
+		if created {
+			output.Infof("Imported pull request %s as review %.12s\n", pr.ID, pr.HeadCommit)
+			imported++
+		}
+	}
+	if !dryRun {
+		output.Infof("Imported %d of %d pull requests\n", imported, len(prs))
+	}
+	return nil
+}
+
+// command returns the subcommand of the import command.
+func (p *pullRequestImport) command() *Command {
+	return &Command{
+		Usage: func(arg0 string) {
+			fmt.Printf("Usage: %s import %s [<option>...]\n\nImports the pull requests of a %s project, with their comments and approvals, as reviews. The API token is read from $%s.\n\nOptions:\n",
+				arg0, p.tool, p.host, p.tokenVariable)
+			p.flagSet.PrintDefaults()
+		},
+		RunMethod: func(ctx *Context, args []string) error {
+			return p.run(ctx.Repo, args)
+		},
+	}
+}
+
+var importGitLab = newPullRequestImport("gitlab", "gitlab.com", "GITLAB_TOKEN", func(host string) string {
+	return "https://" + host + "/api/v4"
+}, func(apiURL, project, token string) interop.Source {
+	return interop.NewGitLab(apiURL, project, token)
+})
+
+// Bitbucket Data Center has a different API from that of Bitbucket Cloud, so
+// the API of a self-hosted instance can not be derived from its host.
+var importBitbucket = newPullRequestImport("bitbucket", "bitbucket.org", "BITBUCKET_TOKEN", nil, func(apiURL, project, token string) interop.Source {
+	return interop.NewBitbucket(apiURL, project, token)
+})
+
+var importPhabricatorFlagSet = flag.NewFlagSet("phabricator", flag.ExitOnError)
+
+var (
+	importPhabricatorURL        = importPhabricatorFlagSet.String("url", "", "The URL of the Phabricator instance to read the revisions from")
+	importPhabricatorRepository = importPhabricatorFlagSet.String("repository", "", "The PHID of the repository to import the revisions of; defaults to every repository")
+	importPhabricatorDump       = importPhabricatorFlagSet.String("dump", "", "Read the revisions from the given dump, instead of from --url")
+	importPhabricatorSaveDump   = importPhabricatorFlagSet.String("save-dump", "", "Write the revisions read from --url to the given dump, instead of importing them")
+	importPhabricatorDomain     = importPhabricatorFlagSet.String("email-domain", "", "The domain of the emails of users with no appraise.import.email.<username> config; defaults to the host of --url")
+	importPhabricatorDryRun     = importPhabricatorFlagSet.Bool("n", false, "Only list the revisions that would be imported")
+)
+
+// importPhabricator imports the Differential revisions of a Phabricator
+// instance, or saves them to a dump so that they can be imported later.
+func importPhabricator(repo repository.Repo, args []string) error {
+	importPhabricatorFlagSet.Parse(args)
+	if len(importPhabricatorFlagSet.Args()) > 0 {
+		return errors.New("The import phabricator command does not take any positional arguments.")
+	}
+	if (*importPhabricatorURL == "") == (*importPhabricatorDump == "") {
+		return errors.New("Exactly one of the --url and --dump flags is required.")
+	}
+	source := interop.NewPhabricator(*importPhabricatorURL, *importPhabricatorRepository, os.Getenv("PHABRICATOR_TOKEN"))
+	if *importPhabricatorDump != "" {
+		file, err := os.Open(*importPhabricatorDump)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if source, err = interop.ReadPhabricatorDump(file); err != nil {
+			return fmt.Errorf("Failed to read the dump: %v", err)
+		}
+	}
+	if *importPhabricatorSaveDump != "" {
+		file, err := os.Create(*importPhabricatorSaveDump)
+		if err != nil {
+			return err
+		}
+		if err := source.WriteDump(file); err != nil {
+			file.Close()
+			return fmt.Errorf("Failed to write the dump: %v", err)
+		}
+		return file.Close()
+	}
+	prs, err := source.PullRequests()
+	if err != nil {
+		return fmt.Errorf("Failed to read the revisions: %v", err)
+	}
+	domain := *importPhabricatorDomain
+	if domain == "" {
+		if parsed, err := url.Parse(*importPhabricatorURL); err == nil && parsed.Hostname() != "" {
+			domain = parsed.Hostname()
+		} else {
+			return errors.New("The --email-domain flag is required with --dump.")
+		}
+	}
+	return importPullRequests(repo, prs, domain, *importPhabricatorDryRun)
+}
+
+var importPhabricatorCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s import phabricator (--url <url> | --dump <file>) [<option>...]\n\nImports the Differential revisions of Phabricator, with their comments and accepts, as reviews. The Conduit API token is read from $PHABRICATOR_TOKEN.\n\nOptions:\n", arg0)
+		importPhabricatorFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return importPhabricator(ctx.Repo, args)
+	},
+}
+
+// importCmd defines the "import" subcommand, which creates review records
+// from data that was stored outside of git-appraise.
+var importCmd = newCommandGroup("import", map[string]*Command{
+	"bitbucket":    importBitbucket.command(),
+	"gitlab":       importGitLab.command(),
+	"json-archive": importArchiveCmd,
+	"phabricator":  importPhabricatorCmd,
+	"trailers":     importTrailersCmd,
+})
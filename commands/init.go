@@ -0,0 +1,270 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+var initFlagSet = flag.NewFlagSet("init", flag.ExitOnError)
+
+var (
+	initDefaults = initFlagSet.Bool("defaults", false, "Accept the default answer to every question, without prompting")
+)
+
+// The alias that init offers to add for the tool.
+const (
+	initAliasKey   = "alias.review"
+	initAliasValue = "appraise"
+)
+
+// initHookName is the hook that init offers to install, and initHook its contents.
+const initHookName = "post-merge"
+
+const initHook = `#!/bin/sh
+# Installed by git appraise init: pull the review notes along with the code.
+exec git appraise --quiet pull
+`
+
+// getInitialTarget returns the suggested default target ref for new reviews.
+func getInitialTarget(repo repository.Repo) (string, error) {
+	if target, err := config.Get(repo, "appraise.target"); err != nil || target != "" {
+		return target, err
+	}
+	hasMaster, err := repo.HasRef(defaultTargetRef)
+	if err != nil {
+		return "", err
+	}
+	if hasMaster {
+		return defaultTargetRef, nil
+	}
+	if hasMain, err := repo.HasRef("refs/heads/main"); err == nil && hasMain {
+		return "refs/heads/main", nil
+	}
+	return defaultTargetRef, nil
+}
+
+// askSetting asks for the value of the given setting, until the answer is valid.
+func askSetting(prompter *input.Prompter, out io.Writer, key, question, defaultValue string) (string, error) {
+	setting := config.Find(key)
+	for {
+		answer, err := prompter.Ask(question, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if setting.Validate == nil {
+			return answer, nil
+		}
+		err = setting.Validate(answer)
+		if err == nil {
+			return answer, nil
+		}
+		fmt.Fprintf(out, "Invalid value for %s: %v.\n", key, err)
+	}
+}
+
+// getHooksDir returns the directory that git runs hooks from.
+func getHooksDir(repo repository.Repo) (string, error) {
+	hooksPath, err := repo.GetConfig("core.hooksPath")
+	if err != nil {
+		return "", err
+	}
+	if hooksPath == "" {
+		return filepath.Join(repo.GetGitDir(), "hooks"), nil
+	}
+	if !filepath.IsAbs(hooksPath) {
+		hooksPath = filepath.Join(repo.GetPath(), hooksPath)
+	}
+	return hooksPath, nil
+}
+
+// installHook writes the given hook, unless there already is one.
+func installHook(repo repository.Repo, out io.Writer, name, contents string) error {
+	hooksDir, err := getHooksDir(repo)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(hooksDir, name)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(out, "Not installing the %s hook, as %q already exists.\n", name, path)
+		return nil
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(contents), 0755)
+}
+
+// hasConfigValue returns whether the given value is one of those of the given config key.
+func hasConfigValue(repo repository.Repo, key, value string) (bool, error) {
+	values, err := repo.GetConfigValues(key)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range values {
+		if v == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// initRepo asks the user how to set up the tool in the repo, and then writes
+// the shared settings to the committed settings file (or the repo's config),
+// and the personal ones to the repo's config.
+func initRepo(repo repository.Repo, in io.Reader, out io.Writer) error {
+	prompter := input.NewPrompter(in, out)
+
+	fmt.Fprintln(out, "Settings shared by everyone working on the repo:")
+	initialTarget, err := getInitialTarget(repo)
+	if err != nil {
+		return err
+	}
+	target, err := askSetting(prompter, out, "appraise.target", "Default target ref for new reviews", initialTarget)
+	if err != nil {
+		return err
+	}
+	initialStrategy, err := config.Get(repo, "appraise.submit")
+	if err != nil {
+		return err
+	}
+	if initialStrategy == "" {
+		initialStrategy = "merge"
+	}
+	strategy, err := prompter.Choose("How reviews are submitted", []string{"merge", "rebase", "fast-forward"}, initialStrategy)
+	if err != nil {
+		return err
+	}
+	requireSigned, err := config.Get(repo, "appraise.send.requireSigned")
+	if err != nil {
+		return err
+	}
+	signed, err := prompter.Confirm("Require reviews to be signed before they are sent upstream?", requireSigned == "true")
+	if err != nil {
+		return err
+	}
+	shared := []struct{ key, value string }{
+		{"appraise.target", target},
+		{"appraise.submit", strategy},
+		{"appraise.send.requireSigned", fmt.Sprintf("%t", signed)},
+	}
+	bare := repo.GetPath() == repo.GetGitDir()
+	committed := false
+	if !bare {
+		committed, err = prompter.Confirm(fmt.Sprintf("Write these to the %s file, to commit and share them?", config.FileName), true)
+		if err != nil {
+			return err
+		}
+	}
+	for _, setting := range shared {
+		// Settings that could weaken checks are only read from git config.
+		if committed && config.Find(setting.key).Committable {
+			err = repo.WriteConfigFile(config.FileName, setting.key, setting.value)
+		} else {
+			err = repo.SetConfig(setting.key, setting.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out, "\nYour own settings for this repo:")
+	if signed {
+		if key, err := repo.GetUserSigningKey(); err != nil || key == "" {
+			fmt.Fprintln(out, "Note: set user.signingKey to the ID of your GPG key, and use the -S flag, to sign your reviews.")
+		}
+	}
+	warnAboutNotesConfig(repo, out)
+	showNotes, err := prompter.Confirm("Show review requests and comments in `git log`?", false)
+	if err != nil {
+		return err
+	}
+	if showNotes {
+		for _, ref := range []string{request.Ref, comment.Ref} {
+			if exists, err := hasConfigValue(repo, "notes.displayRef", ref); err != nil {
+				return err
+			} else if !exists {
+				if err := repo.AddConfigValue("notes.displayRef", ref); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if alias, err := repo.GetConfig(initAliasKey); err != nil {
+		return err
+	} else if alias == "" {
+		addAlias, err := prompter.Confirm("Add `git review` as an alias for `git appraise`?", false)
+		if err != nil {
+			return err
+		}
+		if addAlias {
+			if err := repo.SetConfig(initAliasKey, initAliasValue); err != nil {
+				return err
+			}
+		}
+	}
+	if !bare {
+		addHook, err := prompter.Confirm(fmt.Sprintf("Install a %s hook that pulls the review notes whenever you pull?", initHookName), false)
+		if err != nil {
+			return err
+		}
+		if addHook {
+			if err := installHook(repo, out, initHookName, initHook); err != nil {
+				return err
+			}
+		}
+	}
+
+	if committed {
+		fmt.Fprintf(out, "\nDone. Commit %s to share its settings with everyone working on the repo.\n", config.FileName)
+	} else {
+		fmt.Fprintln(out, "\nDone.")
+	}
+	return nil
+}
+
+// initCmd defines the "init" subcommand.
+var initCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s init [<option>...]\n\nInteractively sets up the repo for code reviews.\n\nOptions:\n", arg0)
+		initFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		initFlagSet.Parse(args)
+		if len(initFlagSet.Args()) > 0 {
+			return errors.New("The init command does not take any arguments.")
+		}
+		var in io.Reader = os.Stdin
+		if *initDefaults {
+			in = strings.NewReader("")
+		}
+		return initRepo(ctx.Repo, in, os.Stdout)
+	},
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+func TestInitRepo(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	// Answer each question in turn, starting with an invalid target ref.
+	answers := strings.Join([]string{"dev", "refs/heads/dev", "rebase", "y", "y", "n", "yes", "n"}, "\n")
+	if err := initRepo(repo, strings.NewReader(answers), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := config.FromFile(repo, "appraise.target"); err != nil || target != "refs/heads/dev" {
+		t.Errorf("Unexpected committed target: %q, %v", target, err)
+	}
+	if strategy, err := config.FromFile(repo, "appraise.submit"); err != nil || strategy != "rebase" {
+		t.Errorf("Unexpected committed submit strategy: %q, %v", strategy, err)
+	}
+	if signed, err := repo.GetConfig("appraise.send.requireSigned"); err != nil || signed != "true" {
+		t.Errorf("Unexpected configured signing requirement: %q, %v", signed, err)
+	}
+	if displayRef, _ := repo.GetConfig("notes.displayRef"); displayRef != "" {
+		t.Errorf("Unexpected notes.displayRef: %q", displayRef)
+	}
+	if alias, _ := repo.GetConfig(initAliasKey); alias != initAliasValue {
+		t.Errorf("Unexpected alias: %q", alias)
+	}
+}
+
+func TestInitRepoDefaults(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := initRepo(repo, strings.NewReader(""), ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := config.FromFile(repo, "appraise.target"); err != nil || target != defaultTargetRef {
+		t.Errorf("Unexpected default target: %q, %v", target, err)
+	}
+	if strategy, err := config.FromFile(repo, "appraise.submit"); err != nil || strategy != "merge" {
+		t.Errorf("Unexpected default submit strategy: %q, %v", strategy, err)
+	}
+	if alias, _ := repo.GetConfig(initAliasKey); alias != "" {
+		t.Errorf("Unexpected alias: %q", alias)
+	}
+}
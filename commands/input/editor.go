@@ -0,0 +1,37 @@
+//go:build !windows
+
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+// editorCommands returns the commands to try, in order, to open the given
+// file in the given editor.
+//
+// The editor string might not be a path to an executable, but rather a
+// shell command with arguments and quoting (e.g. "emacsclient --tty" or
+// "'/opt/my editor/bin/edit' -w"). As such, if running it directly does not
+// work, then it is run through sh, and failing that, bash. Like git, the
+// file is passed as a positional parameter rather than being spliced into
+// the command, so that its path does not need to be quoted.
+func editorCommands(editor, path string) [][]string {
+	shellCommand := editor + ` "$@"`
+	return [][]string{
+		{editor, path},
+		{"sh", "-c", shellCommand, editor, path},
+		{"bash", "-c", shellCommand, editor, path},
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditorCommands(t *testing.T) {
+	path := filepath.Join("repo", ".git", "APPRAISE_COMMENT_EDITMSG")
+	commands := editorCommands("emacsclient --tty", path)
+	if len(commands) < 2 {
+		t.Fatalf("Expected a fallback for editors that are shell commands: %v", commands)
+	}
+	if first := commands[0]; len(first) != 2 || first[0] != "emacsclient --tty" || first[1] != path {
+		t.Errorf("Expected the editor to be run directly first, got %v", first)
+	}
+	for _, command := range commands[1:] {
+		if !strings.Contains(strings.Join(command, " "), "emacsclient --tty ") {
+			t.Errorf("Unexpected shell command %q", command)
+		}
+		if !strings.Contains(strings.Join(command, " "), "APPRAISE_COMMENT_EDITMSG") {
+			t.Errorf("Shell command %q does not include the file to edit", command)
+		}
+	}
+}
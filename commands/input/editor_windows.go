@@ -0,0 +1,37 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// editorCommands returns the commands to try, in order, to open the given
+// file in the given editor.
+//
+// Git for Windows runs the editor through its bundled sh, which is where
+// quoted editor paths such as "'C:/Program Files/Vim/gvim.exe' -f" come
+// from, so that is tried after running the editor directly. Since that sh
+// is not always on the PATH, cmd is used as the last resort.
+func editorCommands(editor, path string) [][]string {
+	return [][]string{
+		{editor, path},
+		{"sh", "-c", fmt.Sprintf("%s '%s'", editor, filepath.ToSlash(path))},
+		{"cmd", "/C", fmt.Sprintf("%s \"%s\"", editor, path)},
+	}
+}
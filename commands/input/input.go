@@ -22,11 +22,74 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/google/git-appraise/repository"
 	exec "golang.org/x/sys/execabs"
 )
 
+// defaultCommentChar starts the lines of an edited message that are ignored,
+// unless core.commentChar says otherwise.
+const defaultCommentChar = "#"
+
+// getCommentChar returns the string that starts the lines of an edited
+// message that are ignored, as configured by core.commentChar.
+func getCommentChar(repo repository.Repo) string {
+	commentChar, err := repo.GetConfig("core.commentChar")
+	if err != nil || commentChar == "" || commentChar == "auto" {
+		return defaultCommentChar
+	}
+	return commentChar
+}
+
+// headerLines returns the given lines as comments to be shown beneath an
+// edited message.
+func headerLines(header []string, commentChar string) []string {
+	if len(header) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, line := range header {
+		lines = append(lines, strings.TrimRight(commentChar+" "+line, " "))
+	}
+	return append(lines, commentChar+" These commented lines will be removed.")
+}
+
+// formatHeader formats the given header lines to be written beneath an
+// edited message.
+func formatHeader(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// stripHeader removes the given header lines from an edited message, along
+// with any surrounding blank lines.
+//
+// Only the lines of the header itself are removed, rather than every line
+// starting with the comment character as `git commit` does, since comments
+// are often Markdown, where that character starts a heading.
+func stripHeader(message string, header []string) string {
+	isHeader := make(map[string]bool)
+	for _, line := range header {
+		isHeader[line] = true
+	}
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if !isHeader[line] {
+			lines = append(lines, line)
+		}
+	}
+	stripped := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if stripped == "" {
+		return ""
+	}
+	return stripped + "\n"
+}
+
 // LaunchEditor launches the default editor configured for the given repo. This
 // method blocks until the editor command has returned.
 //
@@ -34,43 +97,47 @@ import (
 // from the repo (e.g. "FILENAME" will be converted to ".git/FILENAME"). This file
 // will be deleted after the editor is closed and its contents have been read.
 //
+// The optional header lines (e.g. the review and location being commented
+// upon) are shown to the user as comments, which start with core.commentChar.
+// Those lines are removed from the edited text, while any other lines are
+// kept as they are.
+//
 // This method returns the text that was read from the temporary file, or
 // an error if any step in the process failed.
-func LaunchEditor(repo repository.Repo, fileName string) (string, error) {
+func LaunchEditor(repo repository.Repo, fileName string, header ...string) (string, error) {
 	editor, err := repo.GetCoreEditor()
 	if err != nil {
-		return "", fmt.Errorf("Unable to detect default git editor: %v\n", err)
+		return "", fmt.Errorf("Unable to detect default git editor: %v", err)
 	}
 
-	path := fmt.Sprintf("%s/.git/%s", repo.GetPath(), fileName)
+	path := filepath.Join(repo.GetGitDir(), fileName)
+	lines := headerLines(header, getCommentChar(repo))
+	if err := ioutil.WriteFile(path, []byte(formatHeader(lines)), 0644); err != nil {
+		return "", fmt.Errorf("Unable to create the file to edit: %v", err)
+	}
 
-	cmd, err := startInlineCommand(editor, path)
-	if err != nil {
-		// Running the editor directly did not work. This might mean that
-		// the editor string is not a path to an executable, but rather
-		// a shell command (e.g. "emacsclient --tty"). As such, we'll try
-		// to run the command through bash, and if that fails, try with sh
-		args := []string{"-c", fmt.Sprintf("%s %q", editor, path)}
-		cmd, err = startInlineCommand("bash", args...)
-		if err != nil {
-			cmd, err = startInlineCommand("sh", args...)
+	var cmd *exec.Cmd
+	for _, command := range editorCommands(editor, path) {
+		cmd, err = startInlineCommand(command[0], command[1:]...)
+		if err == nil {
+			break
 		}
 	}
 	if err != nil {
-		return "", fmt.Errorf("Unable to start editor: %v\n", err)
+		return "", fmt.Errorf("Unable to start editor: %v", err)
 	}
 
 	if err := cmd.Wait(); err != nil {
-		return "", fmt.Errorf("Editing finished with error: %v\n", err)
+		return "", fmt.Errorf("Editing finished with error: %v", err)
 	}
 
 	output, err := ioutil.ReadFile(path)
 	if err != nil {
 		os.Remove(path)
-		return "", fmt.Errorf("Error reading edited file: %v\n", err)
+		return "", fmt.Errorf("Error reading edited file: %v", err)
 	}
 	os.Remove(path)
-	return string(output), err
+	return stripHeader(string(output), lines), err
 }
 
 // FromFile loads and returns the contents of a given file. If - is passed
@@ -81,13 +148,13 @@ func FromFile(fileName string) (string, error) {
 	if fileName == "-" {
 		stat, err := os.Stdin.Stat()
 		if err != nil {
-			return "", fmt.Errorf("Error reading from stdin: %v\n", err)
+			return "", fmt.Errorf("Error reading from stdin: %v", err)
 		}
 		if (stat.Mode() & os.ModeCharDevice) == 0 {
 			// There is no tty. This will allow us to read piped data instead.
 			output, err := ioutil.ReadAll(os.Stdin)
 			if err != nil {
-				return "", fmt.Errorf("Error reading from stdin: %v\n", err)
+				return "", fmt.Errorf("Error reading from stdin: %v", err)
 			}
 			return string(output), err
 		}
@@ -104,11 +171,51 @@ func FromFile(fileName string) (string, error) {
 
 	output, err := ioutil.ReadFile(fileName)
 	if err != nil {
-		return "", fmt.Errorf("Error reading file: %v\n", err)
+		return "", fmt.Errorf("Error reading file: %v", err)
 	}
 	return string(output), err
 }
 
+// Messages is a flag.Value that collects the values of a repeated -m flag.
+type Messages []string
+
+// String returns the collected messages as separate paragraphs.
+func (m *Messages) String() string {
+	return strings.Join(*m, "\n\n")
+}
+
+// Set adds the given message to the collected messages.
+func (m *Messages) Set(message string) error {
+	*m = append(*m, message)
+	return nil
+}
+
+// AssembleMessage combines the values of the -m and -F flags into a single
+// message, similar to how `git commit` builds a commit message.
+//
+// Each message becomes a separate paragraph, so the first one acts as the
+// title, and the contents of the message file, if any, follow them as the
+// body. If neither is given, then the empty string is returned, so that the
+// caller can fall back to launching an editor.
+func AssembleMessage(messages []string, messageFile string) (string, error) {
+	paragraphs := append([]string(nil), messages...)
+	if messageFile != "" {
+		body, err := FromFile(messageFile)
+		if err != nil {
+			return "", err
+		}
+		paragraphs = append(paragraphs, body)
+	}
+	if len(paragraphs) == 0 {
+		return "", nil
+	}
+	last := len(paragraphs) - 1
+	for i := range paragraphs[:last] {
+		paragraphs[i] = strings.TrimRight(paragraphs[i], "\n")
+	}
+	return strings.Join(paragraphs, "\n\n"), nil
+}
+
 func startInlineCommand(command string, args ...string) (*exec.Cmd, error) {
 	cmd := exec.Command(command, args...)
 	cmd.Stdin = os.Stdin
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssembleMessage(t *testing.T) {
+	if message, err := AssembleMessage(nil, ""); err != nil || message != "" {
+		t.Errorf("Unexpected message without any input: %q, %v", message, err)
+	}
+	if message, err := AssembleMessage([]string{"Title"}, ""); err != nil || message != "Title" {
+		t.Errorf("Unexpected message for a single -m flag: %q, %v", message, err)
+	}
+	message, err := AssembleMessage([]string{"Title\n", "Second paragraph"}, "")
+	if err != nil || message != "Title\n\nSecond paragraph" {
+		t.Errorf("Unexpected message for repeated -m flags: %q, %v", message, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "message")
+	if err := ioutil.WriteFile(file, []byte("Body from a file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	message, err = AssembleMessage([]string{"Title"}, file)
+	if err != nil || message != "Title\n\nBody from a file\n" {
+		t.Errorf("Unexpected message for -m combined with -F: %q, %v", message, err)
+	}
+}
+
+func TestStripHeader(t *testing.T) {
+	header := headerLines([]string{"Please enter the comment on review 0123456789ab.", "File: a.go:3"}, ";")
+	edited := "\n\nFirst line\n# Not a comment with this comment char\n\n" + formatHeader(header)
+	want := "First line\n# Not a comment with this comment char\n"
+	if got := stripHeader(edited, header); got != want {
+		t.Errorf("stripHeader(%q) = %q, want %q", edited, got, want)
+	}
+	header = headerLines([]string{"Nothing entered"}, "#")
+	if got := stripHeader(formatHeader(header), header); got != "" {
+		t.Errorf("Expected an unedited header to result in an empty message, got %q", got)
+	}
+	edited = "# Summary\n\nA Markdown heading is kept.\n" + formatHeader(header)
+	want = "# Summary\n\nA Markdown heading is kept.\n"
+	if got := stripHeader(edited, header); got != want {
+		t.Errorf("stripHeader(%q) = %q, want %q", edited, got, want)
+	}
+}
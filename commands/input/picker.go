@@ -0,0 +1,282 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/git-appraise/commands/output"
+)
+
+// ErrNothingPicked is returned when the user cancels picking an item.
+var ErrNothingPicked = errors.New("nothing was picked")
+
+const (
+	// pickerPrompt is shown before the query that filters the items.
+	pickerPrompt = "> "
+	// pickerHeight is the number of matching items shown at once.
+	pickerHeight = 10
+	// pickerWidth is the width to which items are truncated, if the width
+	// of the terminal is not known.
+	pickerWidth = 80
+)
+
+// FuzzyMatch reports whether the characters of the given query appear, in
+// order but not necessarily next to each other, in the given item, ignoring
+// case.
+//
+// It also returns a score for the match, which is lower for better matches:
+// those in which the matched characters are closer together, and, failing
+// that, start earlier in the item.
+func FuzzyMatch(query, item string) (int, bool) {
+	queryRunes := []rune(strings.ToLower(query))
+	if len(queryRunes) == 0 {
+		return 0, true
+	}
+	itemRunes := []rune(strings.ToLower(item))
+	best, found := 0, false
+	for start, r := range itemRunes {
+		if r != queryRunes[0] {
+			continue
+		}
+		gaps, last, next := 0, start, 1
+		for i := start + 1; i < len(itemRunes) && next < len(queryRunes); i++ {
+			if itemRunes[i] == queryRunes[next] {
+				gaps += i - last - 1
+				last = i
+				next++
+			}
+		}
+		if next < len(queryRunes) {
+			// No later start can match either.
+			break
+		}
+		if score := gaps*(len(itemRunes)+1) + start; !found || score < best {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// FuzzyFilter returns the indices of the given items that match the given
+// query, from the best match to the worst. Items that match equally well
+// keep their order.
+func FuzzyFilter(query string, items []string) []int {
+	var matches []int
+	scores := make(map[int]int)
+	for i, item := range items {
+		if score, ok := FuzzyMatch(query, item); ok {
+			matches = append(matches, i)
+			scores[i] = score
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] < scores[matches[j]]
+	})
+	return matches
+}
+
+// picker holds the state of an interactive fuzzy finder.
+type picker struct {
+	items    []string
+	query    []rune
+	matches  []int
+	selected int
+	width    int
+}
+
+func newPicker(items []string, width int) *picker {
+	p := &picker{items: items, width: width}
+	p.filter()
+	return p
+}
+
+// filter updates the matching items after the query changes.
+func (p *picker) filter() {
+	p.matches = FuzzyFilter(string(p.query), p.items)
+	p.selected = 0
+}
+
+// handle updates the picker for the given input from a terminal in raw mode.
+//
+// It returns whether picking is over, and, if so, the index of the picked item,
+// or -1 if picking was cancelled.
+func (p *picker) handle(input []byte) (bool, int) {
+	for len(input) > 0 {
+		switch {
+		case input[0] == '\r' || input[0] == '\n':
+			if len(p.matches) == 0 {
+				return true, -1
+			}
+			return true, p.matches[p.selected]
+		case input[0] == 3 || input[0] == 4 || (input[0] == 27 && len(input) == 1):
+			// Ctrl-C, Ctrl-D, or a lone escape.
+			return true, -1
+		case input[0] == 27 && len(input) >= 3 && input[1] == '[':
+			switch input[2] {
+			case 'A':
+				p.move(-1)
+			case 'B':
+				p.move(1)
+			}
+			input = input[3:]
+			continue
+		case input[0] == 16:
+			// Ctrl-P
+			p.move(-1)
+		case input[0] == 14:
+			// Ctrl-N
+			p.move(1)
+		case input[0] == 127 || input[0] == 8:
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		case input[0] == 21:
+			// Ctrl-U
+			p.query = nil
+			p.filter()
+		default:
+			r, size := utf8.DecodeRune(input)
+			if unicode.IsPrint(r) {
+				p.query = append(p.query, r)
+				p.filter()
+			}
+			input = input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+	return false, -1
+}
+
+// move moves the selection by the given number of items.
+func (p *picker) move(delta int) {
+	p.selected += delta
+	if p.selected >= len(p.matches) {
+		p.selected = len(p.matches) - 1
+	}
+	if p.selected < 0 {
+		p.selected = 0
+	}
+}
+
+// render draws the picker on a terminal in raw mode, leaving the cursor at
+// the end of the query.
+func (p *picker) render(out io.Writer) {
+	var b strings.Builder
+	b.WriteString("\r\x1b[J" + pickerPrompt + string(p.query))
+	fmt.Fprintf(&b, "  (%d/%d)", len(p.matches), len(p.items))
+	start := 0
+	if p.selected >= pickerHeight {
+		start = p.selected - pickerHeight + 1
+	}
+	shown := 0
+	for i := start; i < len(p.matches) && shown < pickerHeight; i++ {
+		marker := "  "
+		if i == p.selected {
+			marker = "> "
+		}
+		b.WriteString("\r\n" + output.Truncate(marker+p.items[p.matches[i]], p.width))
+		shown++
+	}
+	if shown > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", shown)
+	}
+	fmt.Fprintf(&b, "\r\x1b[%dC", output.Width(pickerPrompt)+output.Width(string(p.query)))
+	io.WriteString(out, b.String())
+}
+
+// pickInteractively lets the user pick one of the given items using a
+// terminal in raw mode, and returns its index.
+func pickInteractively(items []string, in io.Reader, out io.Writer, width int) (int, error) {
+	p := newPicker(items, width)
+	defer io.WriteString(out, "\r\x1b[J")
+	buf := make([]byte, 64)
+	for {
+		p.render(out)
+		n, err := in.Read(buf)
+		if err != nil {
+			return -1, err
+		}
+		if done, picked := p.handle(buf[:n]); done {
+			if picked < 0 {
+				return -1, ErrNothingPicked
+			}
+			return picked, nil
+		}
+	}
+}
+
+// pickByLines lets the user pick one of the given items by typing lines of
+// text, for terminals that can not be put in raw mode, and returns its index.
+func pickByLines(items []string, in io.Reader, out io.Writer) (int, error) {
+	prompter := NewPrompter(in, out)
+	query := ""
+	for {
+		matches := FuzzyFilter(query, items)
+		if len(matches) > pickerHeight {
+			matches = matches[:pickerHeight]
+		}
+		for i, match := range matches {
+			fmt.Fprintf(out, "%2d) %s\n", i+1, output.Truncate(items[match], pickerWidth))
+		}
+		answer, err := prompter.Ask("Pick a number, or type to filter", "")
+		if err != nil {
+			return -1, err
+		}
+		if answer == "" {
+			return -1, ErrNothingPicked
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(matches) {
+			return matches[n-1], nil
+		}
+		query = answer
+	}
+}
+
+// Pick lets the user interactively pick one of the given items with a fuzzy
+// finder, and returns its index.
+//
+// The finder is shown on the terminal rather than the standard output, so
+// that the output of the command can still be captured.
+func Pick(items []string) (int, error) {
+	if len(items) == 0 {
+		return -1, ErrNothingPicked
+	}
+	in, out, err := openTerminal()
+	if err != nil {
+		return -1, fmt.Errorf("picking requires a terminal: %v", err)
+	}
+	defer in.Close()
+	if out != in {
+		defer out.Close()
+	}
+	restore, width, err := makeRaw(in)
+	if err != nil {
+		return pickByLines(items, in, out)
+	}
+	defer restore()
+	return pickInteractively(items, in, out, width)
+}
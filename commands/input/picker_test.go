@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyFilter(t *testing.T) {
+	items := []string{
+		"[pending] abc123 fix-parser alice: Fix the parser",
+		"[accepted] def456 add-docs bob: Add docs for the picker",
+		"[pending] 789abc refactor carol: Refactor the diff output",
+	}
+	if matches := FuzzyFilter("", items); !reflect.DeepEqual(matches, []int{0, 1, 2}) {
+		t.Errorf("Unexpected matches for an empty query: %v", matches)
+	}
+	if matches := FuzzyFilter("PICKER", items); !reflect.DeepEqual(matches, []int{1}) {
+		t.Errorf("Unexpected matches for a case-insensitive query: %v", matches)
+	}
+	if matches := FuzzyFilter("fxpr", items); !reflect.DeepEqual(matches, []int{0}) {
+		t.Errorf("Unexpected matches for a fuzzy query: %v", matches)
+	}
+	if matches := FuzzyFilter("diff out", items); len(matches) != 1 || matches[0] != 2 {
+		t.Errorf("Unexpected matches for a query: %v", matches)
+	}
+	if matches := FuzzyFilter("zzz", items); len(matches) != 0 {
+		t.Errorf("Unexpected matches for a query that matches nothing: %v", matches)
+	}
+	better, _ := FuzzyMatch("pars", "fix the parser")
+	if worse, _ := FuzzyMatch("pars", "p a r s"); worse <= better {
+		t.Errorf("A scattered match scored %d, which is not worse than %d for a contiguous one", worse, better)
+	}
+}
+
+func TestPickInteractively(t *testing.T) {
+	items := []string{"alpha", "beta", "gamma"}
+	// Type "a", which matches alpha, then gamma, then beta, move down to the
+	// second match, delete and retype the query, which resets the selection,
+	// move down again, and press enter.
+	in := strings.NewReader("a\x1b[B\x7fa\x0e\r")
+	var out bytes.Buffer
+	picked, err := pickInteractively(items, &oneByteReader{in}, &out, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked != 2 {
+		t.Errorf("Unexpected picked item %d", picked)
+	}
+
+	if _, err := pickInteractively(items, strings.NewReader("\x1b"), &out, 80); err != ErrNothingPicked {
+		t.Errorf("Unexpected result of cancelling: %v", err)
+	}
+}
+
+func TestPickByLines(t *testing.T) {
+	items := []string{"alpha", "beta", "gamma"}
+	var out bytes.Buffer
+	picked, err := pickByLines(items, strings.NewReader("mm\n1\n"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked != 2 {
+		t.Errorf("Unexpected picked item %d", picked)
+	}
+	if _, err := pickByLines(items, strings.NewReader(""), &out); err != ErrNothingPicked {
+		t.Errorf("Unexpected result of picking nothing: %v", err)
+	}
+}
+
+// oneByteReader reads one key press at a time, as a terminal in raw mode
+// would, except for escape sequences.
+type oneByteReader struct {
+	in *strings.Reader
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	b, err := r.in.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 27 || r.in.Len() < 2 {
+		p[0] = b
+		return 1, nil
+	}
+	p[0] = b
+	n, err := r.in.Read(p[1:3])
+	return n + 1, err
+}
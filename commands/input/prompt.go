@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prompter asks the user questions, for commands that are interactive.
+//
+// If the input ends, every remaining question is given its default answer,
+// so that e.g. redirecting the input from /dev/null accepts all of the defaults.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewPrompter returns a Prompter that reads answers from in, and writes questions to out.
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// readLine reads the user's answer, returning the empty string once the input has ended.
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF {
+		// Finish the line of the question, as the user did not.
+		fmt.Fprintln(p.out)
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+// Ask asks the given question, returning the default value if the user
+// does not give an answer.
+func (p *Prompter) Ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, err := p.readLine()
+	if err != nil || answer == "" {
+		return defaultValue, err
+	}
+	return answer, nil
+}
+
+// Confirm asks the given yes or no question.
+func (p *Prompter) Confirm(question string, defaultValue bool) (bool, error) {
+	options := "y/N"
+	if defaultValue {
+		options = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, options)
+		answer, err := p.readLine()
+		if err != nil || answer == "" {
+			return defaultValue, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "Please answer yes or no.")
+	}
+}
+
+// Choose asks the user to pick one of the given choices.
+func (p *Prompter) Choose(question string, choices []string, defaultValue string) (string, error) {
+	for {
+		answer, err := p.Ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, ", ")), defaultValue)
+		if err != nil {
+			return "", err
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				return answer, nil
+			}
+		}
+		fmt.Fprintf(p.out, "Please answer one of %s.\n", strings.Join(choices, ", "))
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// openTerminal opens the controlling terminal of the process, for both
+// reading and writing.
+func openTerminal() (*os.File, *os.File, error) {
+	terminal, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	return terminal, terminal, err
+}
+
+// stty runs the stty command on the given terminal.
+func stty(terminal *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = terminal
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// makeRaw puts the given terminal in raw mode, so that each key press can be
+// read as soon as it is made, and without being echoed.
+//
+// It returns a function that restores the previous mode of the terminal,
+// along with the terminal's width, or zero if that is not known.
+func makeRaw(terminal *os.File) (func(), int, error) {
+	state, err := stty(terminal, "-g")
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := stty(terminal, "raw", "-echo"); err != nil {
+		return nil, 0, err
+	}
+	width := 0
+	if size, err := stty(terminal, "size"); err == nil {
+		if fields := strings.Fields(size); len(fields) == 2 {
+			width, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return func() { stty(terminal, state) }, width, nil
+}
@@ -18,9 +18,14 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"time"
 
+	"github.com/google/git-appraise/commands/input"
 	"github.com/google/git-appraise/commands/output"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
@@ -31,37 +36,202 @@ var listFlagSet = flag.NewFlagSet("list", flag.ExitOnError)
 var (
 	listAll        = listFlagSet.Bool("a", false, "List all reviews (not just the open ones).")
 	listJSONOutput = listFlagSet.Bool("json", false, "Format the output as JSON")
+	listDate       = listFlagSet.String("date", output.DateRelative, "Format for dates: relative, local, iso, or utc")
+	listDebugSkips = listFlagSet.Bool("debug-skips", false, "Report each review request note that was skipped, and why, on stderr")
+	listWIP        = listFlagSet.Bool("wip", false, "Also list the work-in-progress reviews requested by others")
+	listQueue      = listFlagSet.Bool("queue", false, "List the open reviews ordered by priority, as weighted by appraise.queue.weights")
+	listNDJSON     = listFlagSet.Bool("ndjson", false, "Stream the reviews as JSON, one per line, as soon as each one is read. The reviews are not sorted")
+	listPick       = listFlagSet.Bool("pick", false, "Pick one of the reviews with an interactive fuzzy finder, and print its hash")
+	listCC         = listFlagSet.String("cc", "", "Only list the reviews on which the given `email` is CC'd; use \"me\" for your own")
 )
 
 // listReviews lists all extant reviews.
 // TODO(ojarjur): Add more flags for filtering the output (e.g. filtering by reviewer or status).
 func listReviews(repo repository.Repo, args []string) error {
 	listFlagSet.Parse(args)
+	if *listQueue && *listAll {
+		return errors.New("The --queue and -a flags can not be combined.")
+	}
+	if *listNDJSON && (*listQueue || *listJSONOutput) {
+		return errors.New("The --ndjson flag can not be combined with the --queue or --json flags.")
+	}
+	if *listNDJSON && *listCC != "" {
+		return errors.New("The --ndjson and --cc flags can not be combined.")
+	}
+	if *listPick && (*listQueue || *listJSONOutput || *listNDJSON || *listDebugSkips) {
+		return errors.New("The --pick flag can not be combined with the --queue, --json, --ndjson, or --debug-skips flags.")
+	}
+	if err := output.SetDateFormat(*listDate); err != nil {
+		return err
+	}
+	if *listNDJSON {
+		if err := streamReviews(repo, os.Stdout, *listAll, *listWIP); err != nil {
+			return err
+		}
+		return printListSkips(repo)
+	}
 	var reviews []review.Summary
 	if *listAll {
 		reviews = review.ListAll(repo)
 	} else {
 		reviews = review.ListOpen(repo)
 	}
-	if *listJSONOutput {
+	if !*listAll && !*listWIP {
+		var err error
+		if reviews, err = withoutOthersDrafts(repo, reviews); err != nil {
+			return err
+		}
+	}
+	if *listCC != "" {
+		var err error
+		if reviews, err = withCC(repo, reviews, *listCC); err != nil {
+			return err
+		}
+	}
+	if *listPick {
+		return pickReview(reviews)
+	}
+	if *listQueue {
+		queue, err := review.Queue(repo, reviews, time.Now())
+		if err != nil {
+			return err
+		}
+		if *listJSONOutput {
+			b, err := json.MarshalIndent(queue, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		} else {
+			output.PrintQueue(queue)
+		}
+	} else if *listJSONOutput {
 		b, err := json.MarshalIndent(reviews, "", "  ")
 		if err != nil {
 			return err
 		}
 		fmt.Println(string(b))
+	} else {
+		output.PrintSummaries(reviews, *listAll)
+	}
+	return printListSkips(repo)
+}
+
+// pickReview lets the user pick one of the given reviews, and prints its hash.
+func pickReview(reviews []review.Summary) error {
+	if len(reviews) == 0 {
+		return errors.New("There are no reviews to pick from.")
+	}
+	var items []string
+	for i := range reviews {
+		items = append(items, output.OneLineSummary(&reviews[i]))
+	}
+	picked, err := input.Pick(items)
+	if err != nil {
+		return err
+	}
+	fmt.Println(reviews[picked].Revision)
+	return nil
+}
+
+// printListSkips prints the review request notes that were not listed, if
+// the --debug-skips flag was given.
+func printListSkips(repo repository.Repo) error {
+	if !*listDebugSkips {
 		return nil
 	}
-	output.PrintSummaries(reviews, *listAll)
+	skips, err := getListSkips(repo, *listAll, *listWIP)
+	if err != nil {
+		return err
+	}
+	output.PrintSkips(os.Stderr, skips)
 	return nil
 }
 
+// streamReviews writes the summary of each review that would be listed to
+// the given writer as a line of JSON, as soon as it has been read.
+func streamReviews(repo repository.Repo, out io.Writer, listAll, listWIP bool) error {
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	return review.EachSummary(repo, func(r review.Summary) error {
+		if !listAll && !r.IsOpen() {
+			return nil
+		}
+		if !listAll && !listWIP && r.IsDraft() && r.Request.Requester != userEmail {
+			return nil
+		}
+		return encoder.Encode(r)
+	})
+}
+
+// withCC returns the given reviews on which the given email, or the user's
+// email if it is "me", is CC'd.
+func withCC(repo repository.Repo, reviews []review.Summary, email string) ([]review.Summary, error) {
+	if email == "me" {
+		var err error
+		if email, err = repo.GetUserEmail(); err != nil {
+			return nil, err
+		}
+	}
+	var filtered []review.Summary
+	for _, r := range reviews {
+		if containsString(r.Request.CC, email) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// withoutOthersDrafts returns the given reviews, other than the work-in-progress
+// reviews requested by someone other than the user.
+func withoutOthersDrafts(repo repository.Repo, reviews []review.Summary) ([]review.Summary, error) {
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return nil, err
+	}
+	var filtered []review.Summary
+	for _, r := range reviews {
+		if !r.IsDraft() || r.Request.Requester == userEmail {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// getListSkips returns the review request notes that were not listed, either
+// because they could not be read, or because the reviews are closed, or are
+// the work in progress of others.
+func getListSkips(repo repository.Repo, listAll, listWIP bool) ([]review.Skip, error) {
+	skips, err := review.ListSkipped(repo)
+	if err != nil || listAll {
+		return skips, err
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range review.ListAll(repo) {
+		if r.IsDraft() && !listWIP && r.Request.Requester != userEmail {
+			skips = append(skips, review.Skip{Revision: r.Revision, Reason: "the review is a work in progress; use --wip to list it"})
+		} else if r.IsAbandoned() {
+			skips = append(skips, review.Skip{Revision: r.Revision, Reason: "the review was abandoned; use -a to list it"})
+		} else if r.Submitted {
+			skips = append(skips, review.Skip{Revision: r.Revision, Reason: "the review was submitted; use -a to list it"})
+		}
+	}
+	return skips, nil
+}
+
 // listCmd defines the "list" subcommand.
 var listCmd = &Command{
 	Usage: func(arg0 string) {
 		fmt.Printf("Usage: %s list [<option>...]\n\nOptions:\n", arg0)
 		listFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return listReviews(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return listReviews(ctx.Repo, args)
 	},
 }
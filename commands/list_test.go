@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestStreamReviews(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	for _, listAll := range []bool{false, true} {
+		var out bytes.Buffer
+		if err := streamReviews(repo, &out, listAll, true); err != nil {
+			t.Fatal(err)
+		}
+		streamed := make(map[string]bool)
+		scanner := bufio.NewScanner(&out)
+		for scanner.Scan() {
+			var summary review.Summary
+			if err := json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+				t.Fatalf("Unexpected line %q: %v", scanner.Text(), err)
+			}
+			streamed[summary.Revision] = true
+		}
+		expected := review.ListOpen(repo)
+		if listAll {
+			expected = review.ListAll(repo)
+		}
+		if len(streamed) != len(expected) {
+			t.Fatalf("Unexpected streamed reviews %v; expected %d reviews", streamed, len(expected))
+		}
+		for _, r := range expected {
+			if !streamed[r.Revision] {
+				t.Errorf("Review %q was not streamed", r.Revision)
+			}
+		}
+	}
+}
+
+func TestWithCC(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews := []review.Summary{
+		{Revision: "A", Request: request.Request{CC: []string{userEmail}}},
+		{Revision: "B", Request: request.Request{Reviewers: []string{userEmail}}},
+		{Revision: "C", Request: request.Request{CC: []string{"other@example.com", userEmail}}},
+	}
+	filtered, err := withCC(repo, reviews, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 2 || filtered[0].Revision != "A" || filtered[1].Revision != "C" {
+		t.Errorf("Unexpected reviews on which the user is CC'd: %+v", filtered)
+	}
+	if filtered, _ := withCC(repo, reviews, "other@example.com"); len(filtered) != 1 || filtered[0].Revision != "C" {
+		t.Errorf("Unexpected reviews on which other@example.com is CC'd: %+v", filtered)
+	}
+}
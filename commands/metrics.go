@@ -0,0 +1,52 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/git-appraise/metrics"
+	"github.com/google/git-appraise/repository"
+)
+
+var metricsFlagSet = flag.NewFlagSet("metrics", flag.ExitOnError)
+
+// reportMetrics prints the metrics of the repository's reviews.
+func reportMetrics(repo repository.Repo, args []string) error {
+	metricsFlagSet.Parse(args)
+	if len(metricsFlagSet.Args()) > 0 {
+		return errors.New("The metrics command does not take any positional arguments.")
+	}
+	s, err := metrics.Collect(repo, reviewNotesRefs)
+	if err != nil {
+		return err
+	}
+	return s.Write(os.Stdout)
+}
+
+// metricsCmd defines the "metrics" subcommand.
+var metricsCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s metrics\n\nPrints the number of reviews, the number of notes in each notes ref, and the durations and failures of pulls, in the Prometheus text format. The durations and failures of pulls are only recorded when the appraise.metrics.enabled config is set, and the serve command serves the metrics of every repository it serves at /metrics.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return reportMetrics(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/hosting"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+var mirrorStatusFlagSet = flag.NewFlagSet("mirror-status", flag.ExitOnError)
+
+var (
+	mirrorStatusAll = mirrorStatusFlagSet.Bool("all", false,
+		"Mirror the state of every open review")
+	mirrorStatusDryRun = mirrorStatusFlagSet.Bool("dry-run", false,
+		"Print the statuses without setting them")
+	mirrorStatusRemote = mirrorStatusFlagSet.String("remote", "origin",
+		"Remote whose URL identifies the hosting provider and project, unless they are configured")
+)
+
+// statusTokenVariables are the environment variables that hold the API
+// token of each hosting provider.
+var statusTokenVariables = map[string]string{
+	hosting.GitHub: "GITHUB_TOKEN",
+	hosting.GitLab: "GITLAB_TOKEN",
+}
+
+// getStatusProviderAndProject returns the hosting provider and the project
+// that commit statuses are set on.
+//
+// These are read from the appraise.status.provider and
+// appraise.status.project settings, and otherwise derived from the URL of
+// the given remote, if that is on the public instance of a provider.
+func getStatusProviderAndProject(repo repository.Repo, remote string) (string, string, error) {
+	provider, err := config.Get(repo, "appraise.status.provider")
+	if err != nil {
+		return "", "", err
+	}
+	project, err := config.Get(repo, "appraise.status.project")
+	if err != nil {
+		return "", "", err
+	}
+	if provider != "" && project != "" {
+		return provider, project, nil
+	}
+	remoteURL, err := repo.GetConfig("remote." + remote + ".url")
+	if err != nil {
+		return "", "", err
+	}
+	host, path, err := hosting.ParseRemoteURL(remoteURL)
+	if err != nil {
+		return "", "", fmt.Errorf("Set appraise.status.provider and appraise.status.project, as %v", err)
+	}
+	if provider == "" {
+		provider = hosting.ProviderForHost(host)
+	}
+	if provider == "" {
+		return "", "", fmt.Errorf("Set appraise.status.provider, as %q is not a known hosting provider", host)
+	}
+	if project == "" {
+		project = path
+	}
+	return provider, project, nil
+}
+
+// getReviewStatus returns the commit status that reflects the given review.
+func getReviewStatus(repo repository.Repo, r *review.Review) (hosting.Status, error) {
+	statusContext, err := config.Get(repo, "appraise.status.context")
+	if err != nil {
+		return hosting.Status{}, err
+	}
+	if statusContext == "" {
+		statusContext = hosting.DefaultContext
+	}
+	urlPrefix, err := config.Get(repo, "appraise.reviewURLPrefix")
+	if err != nil {
+		return hosting.Status{}, err
+	}
+	state, description := hosting.ReviewState(r)
+	status := hosting.Status{
+		State:       state,
+		Context:     statusContext,
+		Description: description,
+	}
+	if urlPrefix != "" {
+		status.TargetURL = urlPrefix + r.Revision
+	}
+	return status, nil
+}
+
+// mirrorStatus sets the commit status of the head commit of the given
+// reviews on the hosting provider to reflect their state.
+func mirrorStatus(repo repository.Repo, args []string) error {
+	mirrorStatusFlagSet.Parse(args)
+	args = mirrorStatusFlagSet.Args()
+
+	var reviews []*review.Review
+	if *mirrorStatusAll {
+		if len(args) > 0 {
+			return errors.New("The --all flag can not be combined with a review.")
+		}
+		for _, summary := range review.ListOpen(repo) {
+			r, err := summary.Details()
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, r)
+		}
+	} else {
+		if len(args) > 1 {
+			return errors.New("Only mirroring the status of a single review is supported.")
+		}
+		var r *review.Review
+		var err error
+		if len(args) == 1 {
+			r, err = review.Get(repo, args[0])
+		} else {
+			r, err = review.GetCurrent(repo)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to load the review: %v", err)
+		}
+		if r == nil {
+			return errors.New("There is no matching review.")
+		}
+		reviews = append(reviews, r)
+	}
+
+	providerName, project, err := getStatusProviderAndProject(repo, *mirrorStatusRemote)
+	if err != nil {
+		return err
+	}
+	apiURL, err := config.Get(repo, "appraise.status.apiUrl")
+	if err != nil {
+		return err
+	}
+	provider, err := hosting.NewProvider(providerName, apiURL, project, os.Getenv(statusTokenVariables[providerName]))
+	if err != nil {
+		return err
+	}
+	for _, r := range reviews {
+		head, err := r.GetHeadCommit()
+		if err != nil {
+			return fmt.Errorf("Failed to find the head commit of review %.12s: %v", r.Revision, err)
+		}
+		status, err := getReviewStatus(repo, r)
+		if err != nil {
+			return err
+		}
+		if *mirrorStatusDryRun {
+			fmt.Printf("%.12s\t%.12s\t%s\t%s\n", r.Revision, head, status.State, status.Description)
+			continue
+		}
+		if err := provider.SetStatus(head, status); err != nil {
+			return fmt.Errorf("Failed to set the status of %.12s for review %.12s on %s: %v", head, r.Revision, providerName, err)
+		}
+		output.Infof("Set the %q status of %.12s for review %.12s to %s: %s\n",
+			status.Context, head, r.Revision, status.State, status.Description)
+	}
+	return nil
+}
+
+// mirrorStatusCmd defines the "mirror-status" subcommand.
+var mirrorStatusCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s mirror-status [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		mirrorStatusFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return mirrorStatus(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/gpg"
+)
+
+var nextFlagSet = flag.NewFlagSet("next", flag.ExitOnError)
+
+var (
+	nextWorktree = nextFlagSet.String("worktree", "",
+		"Check each review out into a new worktree under the given `directory`, instead of switching the current one")
+	nextBatch = nextFlagSet.Bool("batch", false,
+		"Triage the reviews one after another, asking whether to accept, reject, or skip each one before moving on to the next")
+	nextSign = nextFlagSet.Bool("S", false,
+		"Sign the contents of each acceptance or rejection made while triaging")
+)
+
+// Choices for what to do with a review while triaging in batch mode.
+const (
+	nextAccept = "a"
+	nextReject = "r"
+	nextSkip   = "s"
+	nextQuit   = "q"
+)
+
+// hasVoted returns whether the given user has accepted or rejected the
+// review in any of the given comment threads.
+func hasVoted(threads []review.CommentThread, userEmail string) bool {
+	for _, thread := range threads {
+		if thread.Comment.Author == userEmail && thread.Comment.Resolved != nil {
+			return true
+		}
+		if hasVoted(thread.Children, userEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextReviews returns the open reviews that the given user has been asked
+// to review, by someone else, and has neither accepted nor rejected yet,
+// oldest first.
+func nextReviews(repo repository.Repo, userEmail string) []review.Summary {
+	var assigned []review.Summary
+	for _, r := range review.ListOpen(repo) {
+		if r.IsDraft() || r.Request.Requester == userEmail || r.Request.TargetRef == "" {
+			continue
+		}
+		if !containsString(r.Request.Reviewers, userEmail) || hasVoted(r.Comments, userEmail) {
+			continue
+		}
+		assigned = append(assigned, r)
+	}
+	sort.SliceStable(assigned, func(i, j int) bool {
+		return firstRequestTime(&assigned[i]).Before(firstRequestTime(&assigned[j]))
+	})
+	return assigned
+}
+
+// firstRequestTime returns the time at which the given review was first requested.
+func firstRequestTime(r *review.Summary) time.Time {
+	if len(r.AllRequests) > 0 {
+		return r.AllRequests[0].Time()
+	}
+	return r.Request.Time()
+}
+
+// checkoutReview checks out the head of the given review, either by
+// switching to its review ref, or into a new worktree under the given
+// directory. It returns the directory in which the review was checked out.
+func checkoutReview(repo repository.Repo, r *review.Review, worktree string) (string, error) {
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return "", err
+	}
+	if worktree != "" {
+		path := filepath.Join(worktree, fmt.Sprintf("%.12s", r.Revision))
+		if err := repo.AddWorktree(path, head); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	ref := r.Request.ReviewRef
+	if ref == "" {
+		ref = head
+	}
+	if err := repo.SwitchToRef(ref); err != nil {
+		return "", err
+	}
+	return repo.GetPath(), nil
+}
+
+// voteOnReview accepts or rejects the given review at its head commit, as
+// the given user, signing the vote like the accept and reject commands do
+// when -S is given.
+func voteOnReview(repo repository.Repo, r *review.Review, userEmail, message string, accept bool) error {
+	if !accept {
+		if err := checkRejectMessage(repo, message); err != nil {
+			return err
+		}
+	}
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	c := comment.New(userEmail, message)
+	c.Timestamp = FormatDate(&now)
+	c.Location = &comment.Location{Commit: head}
+	c.Resolved = &accept
+	if *nextSign {
+		key, err := repo.GetUserSigningKey()
+		if err != nil {
+			return err
+		}
+		if err := gpg.Sign(key, &c); err != nil {
+			return err
+		}
+	}
+	if err := r.AddComment(c); err != nil {
+		return err
+	}
+	if accept {
+		notifyReview(repo, r.Revision, "accepted")
+	} else {
+		notifyReview(repo, r.Revision, "rejected")
+	}
+	return nil
+}
+
+// triageReview asks the user what to do with the given review, which has
+// been shown to them, and does it. It returns false if the user wants to
+// stop triaging.
+func triageReview(repo repository.Repo, prompter *input.Prompter, r *review.Review, userEmail string) (bool, error) {
+	choice, err := prompter.Choose("Accept, reject, skip, or quit?", []string{nextAccept, nextReject, nextSkip, nextQuit}, nextSkip)
+	if err != nil {
+		return false, err
+	}
+	switch choice {
+	case nextAccept:
+		message, err := prompter.Ask("Message", "")
+		if err != nil {
+			return false, err
+		}
+		return true, voteOnReview(repo, r, userEmail, message, true)
+	case nextReject:
+		message, err := prompter.Ask("Why is it rejected", "")
+		if err != nil {
+			return false, err
+		}
+		return true, voteOnReview(repo, r, userEmail, message, false)
+	case nextQuit:
+		return false, nil
+	}
+	return true, nil
+}
+
+// showNextReview checks out and shows the given review, with its diff.
+func showNextReview(repo repository.Repo, summary *review.Summary, worktree string) (*review.Review, error) {
+	r, err := summary.Details()
+	if err != nil {
+		return nil, err
+	}
+	path, err := checkoutReview(repo, r, worktree)
+	if err != nil {
+		return nil, err
+	}
+	if err := output.PrintDetails(r); err != nil {
+		return nil, err
+	}
+	if err := output.PrintDiff(r, repository.DiffOptions{}); err != nil {
+		return nil, err
+	}
+	output.Infof("Checked out review %.12s in %s.\n", r.Revision, path)
+	return r, nil
+}
+
+// nextReview checks out and shows the oldest open review that is waiting for
+// the user, and, in batch mode, goes on to each of the others in turn.
+func nextReview(repo repository.Repo, args []string, in io.Reader) (err error) {
+	nextFlagSet.Parse(args)
+	if nextFlagSet.NArg() > 0 {
+		return errors.New("The next command does not take any arguments.")
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	reviews := nextReviews(repo, userEmail)
+	if len(reviews) == 0 {
+		output.Infof("There are no reviews waiting for you.\n")
+		return nil
+	}
+	if *nextWorktree == "" {
+		if uncommitted, err := repo.HasUncommittedChanges(); err != nil {
+			return err
+		} else if uncommitted {
+			return errors.New("You have uncommitted or untracked files. Commit or stash them, or use --worktree.")
+		}
+	}
+	if !*nextBatch {
+		if _, err := showNextReview(repo, &reviews[0], *nextWorktree); err != nil {
+			return err
+		}
+		if len(reviews) > 1 {
+			output.Infof("%d more reviews are waiting for you.\n", len(reviews)-1)
+		}
+		return nil
+	}
+
+	if *nextWorktree == "" {
+		original, err := getOriginalHead(repo)
+		if err != nil {
+			return err
+		}
+		// Each review is checked out in turn, so the original HEAD is
+		// restored however the triage ends.
+		defer func() {
+			if switchErr := repo.SwitchToRef(original); switchErr != nil && err == nil {
+				err = switchErr
+			}
+		}()
+	}
+	prompter := input.NewPrompter(in, os.Stdout)
+	for i := range reviews {
+		output.Infof("Review %d of %d:\n", i+1, len(reviews))
+		r, err := showNextReview(repo, &reviews[i], *nextWorktree)
+		if err != nil {
+			return err
+		}
+		more, err := triageReview(repo, prompter, r, userEmail)
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	return nil
+}
+
+// nextCmd defines the "next" subcommand.
+var nextCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s next [<option>...]\n\nOptions:\n", arg0)
+		nextFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return nextReview(ctx.Repo, args, os.Stdin)
+	},
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestNextReviews(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	const reviewer = "user@example.com"
+	if reviews := nextReviews(repo, reviewer); len(reviews) != 0 {
+		t.Errorf("Unexpected reviews for a user who is not a reviewer: %v", reviews)
+	}
+	for revision, timestamp := range map[string]string{
+		repository.TestCommitF: "0000000010",
+		repository.TestCommitE: "0000000020",
+	} {
+		req := request.New("other@example.com", []string{reviewer}, repository.TestReviewRef, repository.TestTargetRef, "Review "+revision)
+		req.Timestamp = timestamp
+		note, err := req.Write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AppendNote(request.Ref, revision, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reviews := nextReviews(repo, reviewer)
+	if len(reviews) != 2 || reviews[0].Revision != repository.TestCommitF || reviews[1].Revision != repository.TestCommitE {
+		t.Fatalf("Unexpected reviews waiting for the reviewer: %v", reviews)
+	}
+	if reviews := nextReviews(repo, "other@example.com"); len(reviews) != 0 {
+		t.Errorf("Unexpected reviews waiting for the requester: %v", reviews)
+	}
+
+	r, err := review.Get(repo, repository.TestCommitF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := voteOnReview(repo, r, reviewer, "", false); err == nil {
+		t.Error("Unexpected success rejecting a review without a message")
+	}
+	if err := voteOnReview(repo, r, reviewer, "LGTM", true); err != nil {
+		t.Fatal(err)
+	}
+	if reviews := nextReviews(repo, reviewer); len(reviews) != 1 || reviews[0].Revision != repository.TestCommitE {
+		t.Errorf("Unexpected reviews waiting for the reviewer once one is accepted: %v", reviews)
+	}
+}
+
+func TestTriageReview(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := strings.NewReader("x\ns\nr\nNeeds tests.\nq\n")
+	prompter := input.NewPrompter(in, ioutil.Discard)
+	for _, wantMore := range []bool{true, true, false} {
+		more, err := triageReview(repo, prompter, r, "user@example.com")
+		if err != nil || more != wantMore {
+			t.Fatalf("Unexpected result of triaging: %v, %v; want %v", more, err, wantMore)
+		}
+	}
+	if r, err = review.Get(repo, repository.TestCommitG); err != nil {
+		t.Fatal(err)
+	}
+	if r.Resolved == nil || *r.Resolved {
+		t.Error("Failed to reject the review")
+	}
+}
+
+func TestNextReviewBatchRestoresHead(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	req := request.New("other@example.com", []string{"user@example.com"}, repository.TestReviewRef, repository.TestTargetRef, "Review F")
+	note, err := req.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(request.Ref, repository.TestCommitF, note); err != nil {
+		t.Fatal(err)
+	}
+	original, err := repo.GetHeadRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { *nextBatch = false }()
+	// Rejecting without a message fails after the review has been checked out.
+	if err := nextReview(repo, []string{"--batch"}, strings.NewReader("r\n\n")); err == nil {
+		t.Fatal("Unexpected success rejecting a review without a message")
+	}
+	if head, _ := repo.GetHeadRef(); head != original {
+		t.Errorf("Unexpected HEAD after a failed triage: %q, want %q", head, original)
+	}
+}
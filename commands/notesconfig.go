@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/benchmark"
+	"github.com/google/git-appraise/review/ci"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/provenance"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/submission"
+)
+
+// notesNamespace is the prefix shared by every notes ref that the tool owns.
+const notesNamespace = "refs/notes/devtools/"
+
+// reviewNotesRefs lists the notes refs in which review metadata is stored.
+var reviewNotesRefs = []string{request.Ref, comment.Ref, ci.Ref, analyses.Ref, benchmark.Ref, submission.Ref, provenance.Ref}
+
+// expandNotesRef expands a notes ref the same way git does, so that e.g.
+// "devtools/reviews" refers to "refs/notes/devtools/reviews".
+func expandNotesRef(ref string) string {
+	if strings.HasPrefix(ref, "refs/notes/") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "notes/") {
+		return "refs/" + ref
+	}
+	return "refs/notes/" + ref
+}
+
+// notesGlobOverlaps returns whether or not the given notes ref glob (as used
+// by settings such as notes.rewriteRef, where "*" also matches slashes)
+// matches any of the tool's notes refs.
+func notesGlobOverlaps(glob string) bool {
+	glob = expandNotesRef(glob)
+	if strings.HasPrefix(glob, notesNamespace) {
+		return true
+	}
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(glob), `\*`, ".*") + "$"
+	for _, ref := range reviewNotesRefs {
+		if matched, err := regexp.MatchString(pattern, ref); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// getNotesConfigConflicts returns a description of each setting in the user's
+// notes configuration that would cause git to read or modify the review
+// metadata as if it were the user's own notes.
+func getNotesConfigConflicts(repo repository.Repo) ([]string, error) {
+	var conflicts []string
+	defaultRef, err := repo.GetConfig("core.notesRef")
+	if err != nil {
+		return nil, err
+	}
+	if env := os.Getenv("GIT_NOTES_REF"); env != "" {
+		defaultRef = env
+	}
+	if defaultRef != "" && strings.HasPrefix(expandNotesRef(defaultRef), notesNamespace) {
+		conflicts = append(conflicts, fmt.Sprintf(
+			"the default notes ref %q is used for review metadata, so `git notes` commands will edit reviews", defaultRef))
+	}
+
+	rewriteRefs, err := repo.GetConfigValues("notes.rewriteRef")
+	if err != nil {
+		return nil, err
+	}
+	if env, ok := os.LookupEnv("GIT_NOTES_REWRITE_REF"); ok {
+		rewriteRefs = strings.Split(env, ":")
+	}
+	for _, rewriteRef := range rewriteRefs {
+		if rewriteRef != "" && notesGlobOverlaps(rewriteRef) {
+			conflicts = append(conflicts, fmt.Sprintf(
+				"notes.rewriteRef %q includes review metadata, so rebasing or amending a reviewed commit will copy its review", rewriteRef))
+		}
+	}
+	return conflicts, nil
+}
+
+// warnAboutNotesConfig prints a warning for each setting in the user's notes
+// configuration that conflicts with the review metadata.
+func warnAboutNotesConfig(repo repository.Repo, w io.Writer) {
+	conflicts, err := getNotesConfigConflicts(repo)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: unable to check the git notes configuration: %v\n", err)
+		return
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(w, "Warning: %s.\n", conflict)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestNotesGlobOverlaps(t *testing.T) {
+	for glob, want := range map[string]bool{
+		"refs/notes/*":                true,
+		"*":                           true,
+		"devtools/reviews":            true,
+		"refs/notes/devtools/ci":      true,
+		"refs/notes/dev*/discuss":     true,
+		"refs/notes/commits":          false,
+		"commits":                     false,
+		"refs/notes/*/other":          false,
+		"refs/notes/remotes/origin/*": false,
+	} {
+		if got := notesGlobOverlaps(glob); got != want {
+			t.Errorf("notesGlobOverlaps(%q) = %v, want %v", glob, got, want)
+		}
+	}
+}
+
+func TestNotesConfigConflicts(t *testing.T) {
+	t.Setenv("GIT_NOTES_REF", "")
+	t.Setenv("GIT_NOTES_REWRITE_REF", "refs/notes/commits:refs/notes/*")
+	conflicts, err := getNotesConfigConflicts(repository.NewMockRepoForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 {
+		t.Errorf("Unexpected conflicts: %v", conflicts)
+	}
+
+	t.Setenv("GIT_NOTES_REF", "devtools/reviews")
+	t.Setenv("GIT_NOTES_REWRITE_REF", "refs/notes/commits")
+	conflicts, err = getNotesConfigConflicts(repository.NewMockRepoForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 {
+		t.Errorf("Unexpected conflicts: %v", conflicts)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/notify"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+var notifyFlagSet = flag.NewFlagSet("notify", flag.ExitOnError)
+
+var (
+	notifyEvent = notifyFlagSet.String("event", "updated",
+		"What happened to the review, e.g. \"updated\" or \"pushed\"")
+	notifyDryRun = notifyFlagSet.Bool("dry-run", false,
+		"Print the message payload instead of posting it")
+)
+
+// getWebhook returns the configured notification webhook, or nil if none
+// has been configured.
+func getWebhook(repo repository.Repo) (*notify.Webhook, error) {
+	webhookURL, err := config.Get(repo, "appraise.notify.url")
+	if err != nil || webhookURL == "" {
+		return nil, err
+	}
+	webhookType, err := config.Get(repo, "appraise.notify.type")
+	if err != nil {
+		return nil, err
+	}
+	messageTemplate, err := config.Get(repo, "appraise.notify.template")
+	if err != nil {
+		return nil, err
+	}
+	return notify.NewWebhook(webhookURL, webhookType, messageTemplate)
+}
+
+// buildNotification returns the message describing the given event on the
+// given review, which added the comment with the given hash, if it is not empty.
+func buildNotification(repo repository.Repo, r *review.Summary, event, commentHash string) (notify.Message, error) {
+	actor, err := repo.GetUserEmail()
+	if err != nil {
+		return notify.Message{}, err
+	}
+	urlPrefix, err := config.Get(repo, "appraise.reviewURLPrefix")
+	if err != nil {
+		return notify.Message{}, err
+	}
+	mentions, err := repo.GetConfigValues("appraise.notify.mention")
+	if err != nil {
+		return notify.Message{}, err
+	}
+	m := notify.Message{
+		Event:     event,
+		Actor:     actor,
+		Revision:  r.Revision,
+		Title:     strings.SplitN(strings.TrimSpace(r.Request.Description), "\n", 2)[0],
+		Requester: r.Request.Requester,
+		Status:    r.StatusString(),
+		Mentions:  notify.Mention(append(append([]string(nil), r.Request.Reviewers...), r.Request.CC...), notify.ParseMentions(mentions)),
+	}
+	if r.Request.DueBy != "" && r.IsOpen() {
+		m.DueBy = output.FormatTimestamp(r.Request.DueBy)
+		m.Overdue = r.IsOverdue(time.Now())
+	}
+	if urlPrefix != "" {
+		m.URL = urlPrefix + r.Revision
+	}
+	if commentHash != "" {
+		permalinker, err := review.GetPermalinker(repo)
+		if err != nil {
+			return notify.Message{}, err
+		}
+		m.Comment = commentHash
+		m.Permalink = permalinker.Link(r.Revision, commentHash)
+		if m.CommentURL, err = permalinker.URL(r.Revision, commentHash); err != nil {
+			return notify.Message{}, err
+		}
+	}
+	return m, nil
+}
+
+// notifyReview posts a message about the given event on a review to the
+// configured webhook, if there is one.
+//
+// The event has already been recorded, so failures are reported as warnings.
+func notifyReview(repo repository.Repo, revision, event string) {
+	notifyComment(repo, revision, event, "")
+}
+
+// notifyComment is like notifyReview, for an event that added the comment
+// with the given hash, whose permalink is included in the message.
+//
+// The message is posted in the background, so that a slow webhook does not
+// hold up the rest of the command; WaitForNotifications waits for it.
+func notifyComment(repo repository.Repo, revision, event, commentHash string) {
+	warn := func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: unable to send the notification for review %.12s: %v\n", revision, err)
+	}
+	webhook, m, err := prepareNotification(repo, revision, event, commentHash)
+	if err != nil {
+		warn(err)
+		return
+	}
+	if webhook == nil {
+		return
+	}
+	pendingNotifications.Add(1)
+	go func() {
+		defer pendingNotifications.Done()
+		if err := webhook.Send(m); err != nil {
+			warn(err)
+		}
+	}()
+}
+
+// pendingNotifications tracks the notifications being posted in the
+// background by notifyComment.
+var pendingNotifications sync.WaitGroup
+
+// WaitForNotifications waits for the notifications that are being posted in
+// the background to be sent, or to time out, so that they are not lost when
+// the command exits.
+func WaitForNotifications() {
+	pendingNotifications.Wait()
+}
+
+// prepareNotification returns the configured webhook and the message to post
+// to it about the given event, or a nil webhook if there is nothing to post.
+// Work-in-progress reviews are not announced until they are ready.
+func prepareNotification(repo repository.Repo, revision, event, commentHash string) (*notify.Webhook, notify.Message, error) {
+	webhook, err := getWebhook(repo)
+	if err != nil || webhook == nil {
+		return nil, notify.Message{}, err
+	}
+	r, err := review.GetSummary(repo, revision)
+	if err != nil || r == nil || r.IsDraft() {
+		return nil, notify.Message{}, err
+	}
+	m, err := buildNotification(repo, r, event, commentHash)
+	if err != nil {
+		return nil, notify.Message{}, err
+	}
+	return webhook, m, nil
+}
+
+// notifyAboutReview posts a message about a review to the configured webhook.
+func notifyAboutReview(repo repository.Repo, args []string) error {
+	notifyFlagSet.Parse(args)
+	args = notifyFlagSet.Args()
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only notifying about a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	webhook, err := getWebhook(repo)
+	if err != nil {
+		return err
+	}
+	if webhook == nil {
+		return errors.New("There is no webhook to notify; set appraise.notify.url.")
+	}
+	m, err := buildNotification(repo, r.Summary, *notifyEvent, "")
+	if err != nil {
+		return err
+	}
+	if *notifyDryRun {
+		payload, err := webhook.Format(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(payload))
+		return nil
+	}
+	if err := webhook.Send(m); err != nil {
+		return err
+	}
+	output.Infof("Sent the notification for review %.12s to the %s webhook.\n", r.Revision, webhook.Type)
+	return nil
+}
+
+// notifyCmd defines the "notify" subcommand.
+var notifyCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s notify [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		notifyFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return notifyAboutReview(ctx.Repo, args)
+	},
+}
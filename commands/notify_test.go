@@ -0,0 +1,59 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestBuildNotificationDueBy(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.GetSummary(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := buildNotification(repo, r, "updated", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.DueBy != "" || m.Overdue {
+		t.Errorf("Unexpected deadline of a review without one: %q, %v", m.DueBy, m.Overdue)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	r.Request.DueBy = FormatDate(&past)
+	if m, err = buildNotification(repo, r, "updated", ""); err != nil {
+		t.Fatal(err)
+	}
+	if m.DueBy != output.FormatTimestamp(r.Request.DueBy) || !m.Overdue {
+		t.Errorf("Unexpected deadline of an overdue review: %q, %v", m.DueBy, m.Overdue)
+	}
+
+	future := time.Now().Add(time.Hour)
+	r.Request.DueBy = FormatDate(&future)
+	if m, err = buildNotification(repo, r, "updated", ""); err != nil {
+		t.Fatal(err)
+	}
+	if m.DueBy == "" || m.Overdue {
+		t.Errorf("Unexpected deadline of a review that is not yet due: %q, %v", m.DueBy, m.Overdue)
+	}
+}
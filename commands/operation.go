@@ -0,0 +1,308 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+// operationStateFilename is the name of the file (relative to the repo's .git
+// directory) that records an operation which stopped before it was completed.
+const operationStateFilename = "appraise-operation.json"
+
+// interruptedOperation describes a rebase of a review that was started by
+// either the "rebase" or "submit" subcommands, but which has not completed.
+type interruptedOperation struct {
+	// Command is the subcommand that started the operation.
+	Command string `json:"command"`
+	// Review is the revision of the review being rebased.
+	Review string `json:"review"`
+	// OriginalHead is the ref (or commit) that was checked out beforehand.
+	OriginalHead string `json:"originalHead"`
+	// Sign records whether the rebased review should be signed.
+	Sign bool `json:"sign,omitempty"`
+	// KeepCheckout records whether the review ref should be left checked
+	// out once the operation completes, rather than OriginalHead.
+	KeepCheckout bool `json:"keepCheckout,omitempty"`
+	// Stashed records whether uncommitted changes were stashed before the
+	// operation started, and so need to be restored once it is done.
+	Stashed bool `json:"stashed,omitempty"`
+}
+
+func operationStatePath(repo repository.Repo) string {
+	return filepath.Join(repo.GetGitDir(), operationStateFilename)
+}
+
+// loadInterruptedOperation returns the interrupted operation, if there is one.
+func loadInterruptedOperation(repo repository.Repo) (*interruptedOperation, error) {
+	contents, err := ioutil.ReadFile(operationStatePath(repo))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var op interruptedOperation
+	if err := json.Unmarshal(contents, &op); err != nil {
+		return nil, fmt.Errorf("Unable to read the state of the interrupted operation: %v", err)
+	}
+	return &op, nil
+}
+
+func (op *interruptedOperation) save(repo repository.Repo) error {
+	contents, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(operationStatePath(repo), contents, 0644)
+}
+
+func clearInterruptedOperation(repo repository.Repo) error {
+	if err := os.Remove(operationStatePath(repo)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// getOriginalHead returns the currently checked out ref, or the current
+// commit if HEAD is detached.
+func getOriginalHead(repo repository.Repo) (string, error) {
+	if headRef, err := repo.GetHeadRef(); err == nil {
+		return headRef, nil
+	}
+	return repo.GetCommitHash("HEAD")
+}
+
+// restoreCheckout checks out the given original HEAD again, unless the
+// user asked to keep whatever the operation left checked out.
+func restoreCheckout(repo repository.Repo, originalHead string, keepCheckout bool) error {
+	if keepCheckout {
+		return nil
+	}
+	if err := repo.SwitchToRef(originalHead); err != nil {
+		return fmt.Errorf("Failed to check out %q again: %v", originalHead, err)
+	}
+	return nil
+}
+
+// stashUncommittedChanges checks for uncommitted changes before an operation
+// that switches refs. If there are any, they are either stashed (returning
+// true), ignored, or reported as an error, depending on the given options.
+func stashUncommittedChanges(repo repository.Repo, allowUncommitted, stash bool) (bool, error) {
+	if allowUncommitted && !stash {
+		return false, nil
+	}
+	hasUncommitted, err := repo.HasUncommittedChanges()
+	if err != nil || !hasUncommitted {
+		return false, err
+	}
+	if stash {
+		if err := repo.Stash(); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, errors.New("You have uncommitted or untracked files. Use --allow-uncommitted to ignore those, or --stash to set them aside until this is done.")
+}
+
+// restoreStash restores the changes set aside by stashUncommittedChanges.
+func restoreStash(repo repository.Repo, stashed bool) error {
+	if !stashed {
+		return nil
+	}
+	if err := repo.PopStash(); err != nil {
+		return fmt.Errorf("Failed to restore your stashed changes; they are still in the stash: %v", err)
+	}
+	return nil
+}
+
+// withStashNotice adds a reminder about stashed changes to the given error.
+func withStashNotice(err error, stashed bool) error {
+	if err == nil || !stashed {
+		return err
+	}
+	return fmt.Errorf("%v\n\nYour uncommitted changes were stashed; run `git stash pop` to restore them.", err)
+}
+
+// checkNoInterruptedOperation returns an error if a previous operation (or a
+// rebase started outside of the tool) still needs to be continued or aborted.
+func checkNoInterruptedOperation(repo repository.Repo) error {
+	op, err := loadInterruptedOperation(repo)
+	if err != nil {
+		return err
+	}
+	if op != nil {
+		return fmt.Errorf("The %s of review %.12s was interrupted. Run `git appraise continue` to finish it, or `git appraise abort` to undo it.", op.Command, op.Review)
+	}
+	inProgress, err := repo.IsRebaseInProgress()
+	if err != nil {
+		return err
+	}
+	if inProgress {
+		return errors.New("A rebase is in progress. Finish or abort it before continuing.")
+	}
+	return nil
+}
+
+// rebaseWithRecovery rebases the given review, recording enough state that
+// the rebase can be continued or aborted if it stops part way through.
+//
+// The caller fills in the details of the operation other than the review
+// and the original HEAD. Unless op.KeepCheckout is set, the original HEAD is
+// checked out again once the rebase succeeds. Stashed changes are left for
+// the caller to restore.
+func rebaseWithRecovery(repo repository.Repo, r *review.Review, op *interruptedOperation, archive bool) error {
+	originalHead, err := getOriginalHead(repo)
+	if err != nil {
+		return err
+	}
+	op.Review = r.Revision
+	op.OriginalHead = originalHead
+	if err := op.save(repo); err != nil {
+		return err
+	}
+	if op.Sign {
+		err = r.RebaseAndSign(archive)
+	} else {
+		err = r.Rebase(archive)
+	}
+	if err != nil {
+		return fmt.Errorf("%v\n\nThe rebase of review %.12s stopped before it was complete. Resolve any conflicts and run `git appraise continue`, or run `git appraise abort` to return to %q.",
+			err, r.Revision, originalHead)
+	}
+	if err := clearInterruptedOperation(repo); err != nil {
+		return err
+	}
+	return restoreCheckout(repo, originalHead, op.KeepCheckout)
+}
+
+// continueOperation resumes an interrupted rebase of a review.
+func continueOperation(repo repository.Repo, args []string) error {
+	if len(args) > 0 {
+		return errors.New("The continue command does not take any arguments.")
+	}
+	op, err := loadInterruptedOperation(repo)
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		return errors.New("There is no interrupted operation to continue.")
+	}
+	r, err := review.Get(repo, op.Review)
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	inProgress, err := repo.IsRebaseInProgress()
+	if err != nil {
+		return err
+	}
+	if inProgress {
+		if err := repo.ContinueRebase(); err != nil {
+			return fmt.Errorf("%v\n\nResolve any remaining conflicts and run `git appraise continue` again.", err)
+		}
+	}
+	// Only record the rebase if the review ref really has been rebased,
+	// rather than the operation having stopped before the rebase started.
+	headRef, err := repo.GetHeadRef()
+	if err != nil || headRef != r.Request.ReviewRef {
+		return fmt.Errorf("Expected the review ref %q to be checked out; run `git appraise abort` instead.", r.Request.ReviewRef)
+	}
+	rebased, err := repo.IsAncestor(r.Request.TargetRef, "HEAD")
+	if err != nil {
+		return err
+	}
+	if !rebased {
+		return fmt.Errorf("The review ref %q has not been rebased onto %q; run `git appraise abort` instead.", r.Request.ReviewRef, r.Request.TargetRef)
+	}
+	if err := r.RecordRebase(op.Sign); err != nil {
+		return err
+	}
+	if err := clearInterruptedOperation(repo); err != nil {
+		return err
+	}
+	if op.Command == "submit" {
+		output.Infof("Rebased review %.12s; run `git appraise submit` again to finish submitting it.\n", r.Revision)
+	}
+	if err := restoreCheckout(repo, op.OriginalHead, op.KeepCheckout); err != nil {
+		return err
+	}
+	return restoreStash(repo, op.Stashed)
+}
+
+// abortOperation undoes an interrupted rebase of a review, and checks out
+// whatever was checked out before it started.
+func abortOperation(repo repository.Repo, args []string) error {
+	if len(args) > 0 {
+		return errors.New("The abort command does not take any arguments.")
+	}
+	op, err := loadInterruptedOperation(repo)
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		return errors.New("There is no interrupted operation to abort.")
+	}
+	inProgress, err := repo.IsRebaseInProgress()
+	if err != nil {
+		return err
+	}
+	if inProgress {
+		if err := repo.AbortRebase(); err != nil {
+			return err
+		}
+	}
+	if err := repo.SwitchToRef(op.OriginalHead); err != nil {
+		return err
+	}
+	if err := clearInterruptedOperation(repo); err != nil {
+		return err
+	}
+	return restoreStash(repo, op.Stashed)
+}
+
+// continueCmd defines the "continue" subcommand.
+var continueCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s continue\n\nResumes a rebase or submit that stopped part way through.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return continueOperation(ctx.Repo, args)
+	},
+}
+
+// abortCmd defines the "abort" subcommand.
+var abortCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s abort\n\nUndoes a rebase or submit that stopped part way through.\n", arg0)
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return abortOperation(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"os"
+)
+
+// The supported values of the --color option, which mirror those of git.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// color is when diffs printed by this package are colored.
+var color = ColorAuto
+
+// SetColor sets when subsequently printed diffs are colored.
+func SetColor(when string) error {
+	switch when {
+	case ColorAuto, ColorAlways, ColorNever:
+		color = when
+		return nil
+	}
+	return fmt.Errorf("Unsupported color option %q; must be one of %q, %q, or %q.",
+		when, ColorAuto, ColorAlways, ColorNever)
+}
+
+// isTerminal reports whether the standard output is a terminal.
+func isTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	return err == nil && (stat.Mode()&os.ModeCharDevice) != 0
+}
+
+// colorEnabled reports whether output should be colored.
+//
+// Git never colors the diffs we ask for automatically, as their output is
+// not written directly to a terminal, so the auto mode checks for one here.
+func colorEnabled() bool {
+	if color == ColorAuto {
+		return !porcelain && isTerminal()
+	}
+	return color == ColorAlways
+}
+
+// colorDiffArgs returns the given diff options, preceded by the one that
+// stops git from coloring the diff, so that highlightDiff can color it.
+func colorDiffArgs(diffArgs []string) []string {
+	if !colorEnabled() {
+		return diffArgs
+	}
+	return append([]string{"--color=never"}, diffArgs...)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+// The supported formats for displaying dates, which mirror the corresponding
+// values of the --date flag for `git log`.
+const (
+	DateLocal    = "local"
+	DateUTC      = "utc"
+	DateISO      = "iso"
+	DateRelative = "relative"
+)
+
+// isoDateLayout matches the format used by `git log --date=iso`.
+const isoDateLayout = "2006-01-02 15:04:05 -0700"
+
+// dateFormat is the format used for every date printed by this package.
+var dateFormat = DateLocal
+
+// SetDateFormat sets the format used for all subsequently printed dates.
+func SetDateFormat(format string) error {
+	switch format {
+	case DateLocal, DateUTC, DateISO, DateRelative:
+		dateFormat = format
+		return nil
+	}
+	return fmt.Errorf("Unsupported date format %q; must be one of %q, %q, %q, or %q.",
+		format, DateRelative, DateLocal, DateISO, DateUTC)
+}
+
+// FormatTimestamp takes a timestamp string of the form "0123456789" and formats
+// it using the current date format.
+//
+// Timestamps that are not in the format we expect are left alone.
+func FormatTimestamp(ts string) string {
+	if _, err := timestamp.Normalize(ts); err != nil || ts == "" {
+		return ts
+	}
+	return formatTime(timestamp.Time(ts), time.Now(), dateFormat)
+}
+
+func formatTime(t, now time.Time, format string) string {
+	switch format {
+	case DateUTC:
+		return t.UTC().Format(time.UnixDate)
+	case DateISO:
+		return t.Format(isoDateLayout)
+	case DateRelative:
+		return formatRelative(t, now)
+	}
+	return t.Format(time.UnixDate)
+}
+
+// formatRelative describes how long before (or after) now the given time is,
+// using the same thresholds as `git log --date=relative`.
+func formatRelative(t, now time.Time) string {
+	age := now.Sub(t)
+	if age < 0 {
+		return "in " + formatDuration(-age)
+	}
+	return formatDuration(age) + " ago"
+}
+
+func formatDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	switch {
+	case seconds < 90:
+		return pluralize(seconds, "second")
+	case seconds < 90*60:
+		return pluralize((seconds+30)/60, "minute")
+	case seconds < 36*60*60:
+		return pluralize((seconds+30*60)/(60*60), "hour")
+	}
+	days := (seconds + 12*60*60) / (24 * 60 * 60)
+	switch {
+	case days < 14:
+		return pluralize(days, "day")
+	case days < 70:
+		return pluralize((days+3)/7, "week")
+	case days < 365:
+		return pluralize((days+15)/30, "month")
+	}
+	return pluralize((days+183)/365, "year")
+}
+
+func pluralize(count int64, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cases := map[time.Duration]string{
+		1 * time.Second:      "1 second ago",
+		45 * time.Second:     "45 seconds ago",
+		10 * time.Minute:     "10 minutes ago",
+		5 * time.Hour:        "5 hours ago",
+		3 * 24 * time.Hour:   "3 days ago",
+		21 * 24 * time.Hour:  "3 weeks ago",
+		100 * 24 * time.Hour: "3 months ago",
+		800 * 24 * time.Hour: "2 years ago",
+		-2 * time.Hour:       "in 2 hours",
+	}
+	for age, expected := range cases {
+		if got := formatRelative(now.Add(-age), now); got != expected {
+			t.Errorf("Unexpected relative time for an age of %v: %q", age, got)
+		}
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	defer SetDateFormat(DateLocal)
+	if err := SetDateFormat("bogus"); err == nil {
+		t.Fatal("Failed to reject an unsupported date format")
+	}
+	if err := SetDateFormat(DateUTC); err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatTimestamp("0000000001"); got != "Thu Jan  1 00:00:01 UTC 1970" {
+		t.Errorf("Unexpected UTC date: %q", got)
+	}
+	if got := FormatTimestamp("not a timestamp"); got != "not a timestamp" {
+		t.Errorf("Unexpected formatting of an invalid timestamp: %q", got)
+	}
+}
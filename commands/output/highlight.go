@@ -0,0 +1,160 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/google/git-appraise/review"
+)
+
+const (
+	// highlightStyle is the chroma style used to highlight source code. It
+	// leaves plain text uncolored, and is mapped to the 8 standard terminal
+	// colors, so that it is readable on both light and dark backgrounds.
+	highlightStyle = "pygments"
+
+	// The escape sequences used for the parts of a diff that are not source
+	// code, which match the default colors of git.
+	diffMetaColor    = "\033[1m"
+	diffFragColor    = "\033[36m"
+	diffNewColor     = "\033[32m"
+	diffOldColor     = "\033[31m"
+	resetColorEscape = "\033[0m"
+)
+
+// highlightLines returns the given lines of the file at the given path, with
+// the source code highlighted according to the file's extension.
+//
+// The lines are returned unchanged if output is not colored, or if the
+// language of the file is unknown.
+func highlightLines(path string, lines []string) []string {
+	if !colorEnabled() {
+		return lines
+	}
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		return lines
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, strings.Join(lines, "\n"))
+	if err != nil {
+		return lines
+	}
+	tokenLines := chroma.SplitTokensIntoLines(iterator.Tokens())
+	if len(tokenLines) < len(lines) {
+		return lines
+	}
+	highlighted := make([]string, len(lines))
+	for i := range lines {
+		highlighted[i] = formatTokens(tokenLines[i])
+	}
+	return highlighted
+}
+
+// formatTokens returns the given line of tokens as a string containing the
+// escape sequences that color them, without the trailing newline.
+func formatTokens(tokens []chroma.Token) string {
+	if len(tokens) > 0 {
+		last := &tokens[len(tokens)-1]
+		last.Value = strings.TrimSuffix(last.Value, "\n")
+	}
+	// Splitting a multi-line token leaves an empty token, which would
+	// otherwise be printed as a pair of needless escape sequences.
+	var nonEmpty []chroma.Token
+	for _, token := range tokens {
+		if token.Value != "" {
+			nonEmpty = append(nonEmpty, token)
+		}
+	}
+	tokens = nonEmpty
+	var buf bytes.Buffer
+	if err := formatters.TTY8.Format(&buf, styles.Get(highlightStyle), chroma.Literator(tokens...)); err != nil {
+		var plain strings.Builder
+		for _, token := range tokens {
+			plain.WriteString(token.Value)
+		}
+		return plain.String()
+	}
+	return buf.String()
+}
+
+// highlightDiff returns the given uncolored diff, with the headers and the
+// added and removed lines colored as git does, and the source code in each
+// line highlighted according to the extension of the file it is from.
+func highlightDiff(diff string) string {
+	if !colorEnabled() {
+		return diff
+	}
+	var lexer chroma.Lexer
+	inHeader := false
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			lexer = nil
+			inHeader = true
+			lines[i] = diffMetaColor + line + resetColorEscape
+		case inHeader && !strings.HasPrefix(line, "@@"):
+			if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+				if path := review.DiffPath(line[len("+++ "):]); path != "" {
+					lexer = lexers.Match(path)
+				}
+			}
+			lines[i] = diffMetaColor + line + resetColorEscape
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			lines[i] = diffFragColor + line + resetColorEscape
+		case strings.HasPrefix(line, "+"):
+			lines[i] = highlightDiffLine(lexer, diffNewColor, line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = highlightDiffLine(lexer, diffOldColor, line)
+		case strings.HasPrefix(line, " "):
+			lines[i] = highlightDiffLine(lexer, "", line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlightDiffLine returns the given line of a diff, with its marker in the
+// given color, and its source code highlighted by the given lexer. Without
+// a lexer, the whole line is in the marker's color, as in git's own diffs.
+func highlightDiffLine(lexer chroma.Lexer, color, line string) string {
+	marker, code := line[:1], line[1:]
+	if color != "" {
+		marker = color + marker + resetColorEscape
+	}
+	if lexer == nil {
+		if color == "" {
+			return line
+		}
+		return color + line + resetColorEscape
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return marker + code
+	}
+	tokenLines := chroma.SplitTokensIntoLines(iterator.Tokens())
+	if len(tokenLines) == 0 {
+		return marker
+	}
+	return marker + formatTokens(tokenLines[0])
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var escapeSequence = regexp.MustCompile("\033\\[[0-9;]*m")
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-func old() {}
++func new() { /* comment */ }
+---- not a header
+diff --git a/notes.unknownext b/notes.unknownext
+--- a/notes.unknownext
++++ b/notes.unknownext
+@@ -1 +1 @@
+-before
++after`
+
+func TestHighlightDiff(t *testing.T) {
+	defer SetColor(ColorAuto)
+	SetColor(ColorNever)
+	if highlighted := highlightDiff(testDiff); highlighted != testDiff {
+		t.Errorf("The diff was modified without color: %q", highlighted)
+	}
+	SetColor(ColorAlways)
+	highlighted := highlightDiff(testDiff)
+	if highlighted == testDiff {
+		t.Fatal("The diff was not colored")
+	}
+	if stripped := escapeSequence.ReplaceAllString(highlighted, ""); stripped != testDiff {
+		t.Errorf("Unexpected text of the highlighted diff: %q", stripped)
+	}
+	lines := strings.Split(highlighted, "\n")
+	if !strings.HasPrefix(lines[7], diffNewColor+"+"+resetColorEscape) || strings.Count(lines[7], "\033[") <= 2 {
+		t.Errorf("Unexpected highlighting of an added line: %q", lines[7])
+	}
+	if !strings.HasPrefix(lines[8], diffOldColor+"-"+resetColorEscape) {
+		t.Errorf("A removed line was colored as a header: %q", lines[8])
+	}
+	if expected := diffNewColor + "+after" + resetColorEscape; lines[len(lines)-1] != expected {
+		t.Errorf("Unexpected coloring of an added line of an unknown language: %q", lines[len(lines)-1])
+	}
+}
+
+func TestHighlightLines(t *testing.T) {
+	defer SetColor(ColorAuto)
+	SetColor(ColorAlways)
+	lines := []string{"/* a multi-line", "   comment */", "func main() {}"}
+	highlighted := highlightLines("main.go", lines)
+	if len(highlighted) != len(lines) {
+		t.Fatalf("Unexpected number of highlighted lines: %q", highlighted)
+	}
+	for i, line := range highlighted {
+		if line == lines[i] {
+			t.Errorf("Line %d was not highlighted: %q", i, line)
+		}
+		if stripped := escapeSequence.ReplaceAllString(line, ""); stripped != lines[i] {
+			t.Errorf("Unexpected text of highlighted line %d: %q", i, stripped)
+		}
+	}
+	if highlighted := highlightLines("notes.unknownext", lines); strings.Join(highlighted, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("Lines of an unknown language were modified: %q", highlighted)
+	}
+}
@@ -18,13 +18,20 @@ limitations under the License.
 package output
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/git-appraise/assist"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/teams"
 )
 
 const (
@@ -33,19 +40,84 @@ const (
 `
 	// Template for printing the summary of a list of open reviews.
 	openReviewListTemplate = `Loaded %d open reviews:
+`
+	// Template for printing the summary of the review queue.
+	reviewQueueTemplate = `Loaded %d open reviews, highest priority first:
+`
+	// Template for printing the priority of a review in the queue.
+	reviewPriorityTemplate = `  priority: %.2f (%s)
+`
+	// Template for printing the summary of a list of search results.
+	searchResultListTemplate = `Found %d matching reviews:
 `
 	// Template for printing the summary of a list of comment threads.
 	commentListTemplate = `Loaded %d comment threads:
 `
 	// Template for printing the summary of a code review.
-	reviewSummaryTemplate = `[%s] %.12s
+	reviewSummaryTemplate = `[%s] %.12s%s
   %s
 `
+	// Template for the one-line summary of a code review, such as in a picker.
+	reviewOneLineTemplate = `[%s] %.12s%s %s: %s`
 	// Template for printing the summary of a code review.
 	reviewDetailsTemplate = `  %q -> %q
   reviewers: %q
   requester: %q
   build status: %s
+`
+	// Template for printing the people who are CC'd on a review.
+	reviewCCTemplate = `  cc: %q
+`
+	// Template for printing the labels of a review.
+	reviewLabelsTemplate = `  labels: %q
+`
+	// Template for printing the committer of a review's commit, if that is not the requester.
+	reviewForeignCommitterTemplate = `  committed by: %q (not the requester)
+`
+	// Template for printing the review that a review is a backport of.
+	reviewBackportTemplate = `  backport of: %.12s
+`
+	// Template for printing a commit whose changes were already accepted in another review.
+	reviewPreviouslyReviewedTemplate = `  previously reviewed: %.12s (accepted in review %.12s)
+`
+	// Template for printing the size of a review.
+	reviewSizeTemplate = `  size: %s
+`
+	// Template for printing the acceptance status of the teams asked to review a change.
+	reviewTeamsTemplate = `  teams: %s
+`
+	// Template for printing where some of a review's requests and comments came from.
+	reviewProvenanceTemplate = `  from fork %q (%s), merged %s: %s
+`
+	// Template for printing the number of heads that a review has had.
+	reviewHistoryTemplate = `Loaded %d versions of review %.12s:
+`
+	// Template for printing one of the heads that a review has had.
+	historyEntryTemplate = `  %.12s %s (%s)
+`
+	// Template for printing the header of the diff between two versions of a review.
+	historyDiffTemplate = `
+Changes from %.12s to %.12s:
+`
+	// Template for printing the summary of the revisions of a review's request.
+	requestHistoryTemplate = `Loaded %d revisions of the request of review %.12s:
+`
+	// Template for printing one of the revisions of a review's request.
+	requestRevisionTemplate = `
+[%s] by %s:
+`
+	// Template for printing the change to one field of a review's request.
+	requestChangeTemplate = `  %s: %q -> %q
+`
+	// Template for printing the heads of two reviews that are compared.
+	comparisonTemplate = `Comparing review %.12s (at %.12s) with review %.12s (at %.12s):
+`
+	// Template for printing a heading over comment threads of a comparison.
+	comparisonThreadsTemplate = `
+%s:
+`
+	// Template for printing a comment thread in a comparison.
+	comparisonThreadTemplate = `  [%s] %.12s%s %s: %s
 `
 	// Template for printing the location of an inline comment
 	commentLocationTemplate = `%s%q@%.12s
@@ -55,85 +127,230 @@ const (
 author: %s
 time:   %s
 status: %s
-%s`
+%s%s`
+	// Templates for printing the permalink and URL of a comment.
+	commentLinkTemplate = `link:   %s
+`
+	commentURLTemplate = `url:    %s
+`
 	// Template for displaying the summary of the comment threads for a review
 	commentSummaryTemplate = `  comments (%d threads):
+`
+	// Template for printing the summary of a single comment thread.
+	threadContextTemplate = `Loaded comment thread %.12s of review %.12s, with the %d comments it replies to:
+`
+	// Templates for noting the replies that were left out of a comment
+	// thread, with and without the review that it can be shown from.
+	truncatedRepliesTemplate = `... %d more replies; run "git appraise show --thread %.12s %.12s" to see them
+`
+	truncatedDetachedRepliesTemplate = `... %d more replies
+`
+	// Template for noting the comment threads that were left out of a list.
+	truncatedThreadsTemplate = `... %d more threads; use --skip-threads %d to see them
+`
+	// Template for printing the summary of the notes skipped when listing reviews.
+	skipListTemplate = `Skipped %d review request notes:
+`
+	// Template for printing why a note was skipped when listing reviews.
+	skipTemplate = `  %.12s: %s
+`
+	// Maximum number of bytes of a skipped note to print.
+	maxSkippedNoteLength = 100
+	// Template for printing the effective value of a setting.
+	configValueTemplate = `%s = %q (%s)
+`
+	// Template for printing the summary of a list of suggested fixes.
+	fixListTemplate = `Found %d suggested fixes:
+`
+	// Template for printing an analysis note that suggests a fix.
+	fixTemplate = `
+%.12s %s%s
+`
+	// Template for printing the summary of a benchmark report.
+	benchmarkReportTemplate = `  benchmarks from %s: %d of %d regressed
+`
+	// Template for printing a single benchmark result.
+	benchmarkResultTemplate = `    %s: %s%s %+.1f%% %s
+`
+	// Template for printing why benchmark results could not be shown.
+	benchmarkErrorTemplate = `  benchmarks: unknown: %v
+`
+	// Template for printing a run of changed lines that the tests did not run.
+	coverageWarningTemplate = `warning: %s is not covered by tests
 `
 	// Number of lines of context to print for inline comments
 	contextLineCount = 5
 )
 
-// getStatusString returns a human friendly string encapsulating both the review's
-// resolved status, and its submitted status.
-func getStatusString(r *review.Summary) string {
-	if r.Resolved == nil && r.Submitted {
-		return "tbr"
+// PrintSummaries prints single-line summaries of a slice of reviews.
+func PrintSummaries(reviews []review.Summary, listAll bool) {
+	if porcelain {
+		printPorcelainSummaries(reviews)
+		return
 	}
-	if r.Resolved == nil {
-		return "pending"
+	if listAll {
+		fmt.Printf(reviewListTemplate, len(reviews))
+	} else {
+		fmt.Printf(openReviewListTemplate, len(reviews))
 	}
-	if *r.Resolved && r.Submitted {
-		return "submitted"
+	printSummariesWithDivergences(reviews)
+}
+
+// PrintQueue prints single-line summaries of the given queued reviews, along
+// with their priorities and the factors that contributed to them.
+func PrintQueue(queue []review.QueuedReview) {
+	if porcelain {
+		for _, r := range queue {
+			fmt.Println(porcelainSummary(&r.Summary))
+		}
+		return
 	}
-	if *r.Resolved {
-		return "accepted"
+	fmt.Printf(reviewQueueTemplate, len(queue))
+	var reviews []review.Summary
+	for _, r := range queue {
+		reviews = append(reviews, r.Summary)
 	}
-	if r.Submitted {
-		return "danger"
+	divergences := review.GetDivergences(reviews)
+	for _, r := range queue {
+		var divergence *review.Divergence
+		if d, ok := divergences[r.Revision]; ok {
+			divergence = &d
+		}
+		printSummary(&r.Summary, divergence, r.Size)
+		var factors []string
+		for _, factor := range review.QueueFactors {
+			if value, ok := r.Priority.Factors[factor]; ok {
+				factors = append(factors, fmt.Sprintf("%s %.2f", factor, value))
+			}
+		}
+		fmt.Printf(reviewPriorityTemplate, r.Priority.Score, strings.Join(factors, ", "))
 	}
-	if r.Request.TargetRef == "" {
-		return "abandon"
+}
+
+// PrintSearchResults prints single-line summaries of the reviews matching a search.
+func PrintSearchResults(reviews []review.Summary) {
+	if porcelain {
+		printPorcelainSummaries(reviews)
+		return
 	}
-	return "rejected"
+	fmt.Printf(searchResultListTemplate, len(reviews))
+	printSummariesWithDivergences(reviews)
 }
 
-// PrintSummaries prints single-line summaries of a slice of reviews.
-func PrintSummaries(reviews []review.Summary, listAll bool) {
-	if listAll {
-		fmt.Printf(reviewListTemplate, len(reviews))
-	} else {
-		fmt.Printf(openReviewListTemplate, len(reviews))
+// printPorcelainSummaries prints the "review" record of each of the given reviews.
+func printPorcelainSummaries(reviews []review.Summary) {
+	for _, r := range reviews {
+		fmt.Println(porcelainSummary(&r))
 	}
+}
+
+// printSummariesWithDivergences prints the summaries of the given reviews,
+// computing how far each open review has diverged from its target in one batch.
+func printSummariesWithDivergences(reviews []review.Summary) {
+	divergences := review.GetDivergences(reviews)
 	for _, r := range reviews {
-		PrintSummary(&r)
+		var divergence *review.Divergence
+		if d, ok := divergences[r.Revision]; ok {
+			divergence = &d
+		}
+		printSummary(&r, divergence, nil)
 	}
 }
 
 // PrintSummary prints a single-line summary of a review.
 func PrintSummary(r *review.Summary) {
-	statusString := getStatusString(r)
-	indentedDescription := strings.Replace(r.Request.Description, "\n", "\n  ", -1)
-	fmt.Printf(reviewSummaryTemplate, statusString, r.Revision, indentedDescription)
+	if porcelain {
+		fmt.Println(porcelainSummary(r))
+		return
+	}
+	var divergence *review.Divergence
+	if r.IsOpen() {
+		// The divergence is best effort, as the review's code may not have been fetched.
+		divergence, _ = r.Divergence()
+	}
+	printSummary(r, divergence, nil)
 }
 
-// reformatTimestamp takes a timestamp string of the form "0123456789" and changes it
-// to the form "Mon Jan _2 13:04:05 UTC 2006".
+// printSummary prints a single-line summary of a review, including how far
+// it has diverged from its target, if known, and its size.
 //
-// Timestamps that are not in the format we expect are left alone.
-func reformatTimestamp(timestamp string) string {
-	parsedTimestamp, err := strconv.ParseInt(timestamp, 10, 64)
-	if err != nil {
-		// The timestamp is an unexpected format, so leave it alone
-		return timestamp
+// The size is computed here unless it is given, and is only shown when the
+// code of the review is available, which the divergence having been
+// computed shows.
+func printSummary(r *review.Summary, divergence *review.Divergence, size *review.Size) {
+	statusString := r.StatusString()
+	description := wrapText(r.Request.Description, "  ", TerminalWidth())
+	indentedDescription := strings.Replace(description, "\n", "\n  ", -1)
+	var dates []string
+	if r.Request.Timestamp != "" {
+		dates = append(dates, FormatTimestamp(r.Request.Timestamp))
+	}
+	if r.Request.DueBy != "" && r.IsOpen() {
+		dates = append(dates, "due "+FormatTimestamp(r.Request.DueBy))
+	}
+	requestTime := ""
+	if len(dates) > 0 {
+		requestTime = " (" + strings.Join(dates, ", ") + ")"
+	}
+	if r.IsOverdue(time.Now()) {
+		requestTime += " OVERDUE"
+	}
+	if claims := r.ActiveClaims(time.Now()); len(claims) > 0 {
+		var reviewers []string
+		for _, c := range claims {
+			reviewers = append(reviewers, c.Reviewer)
+		}
+		requestTime += " {claimed by " + strings.Join(reviewers, ", ") + "}"
 	}
-	t := time.Unix(parsedTimestamp, 0)
-	return t.Format(time.UnixDate)
+	if divergence != nil {
+		requestTime += " [" + divergence.String() + "]"
+		if size == nil {
+			size, _ = (&review.Review{Summary: r}).GetSize()
+		}
+		if size != nil {
+			requestTime += " (" + size.String() + ")"
+		}
+	}
+	branch := ""
+	if r.Fork != "" {
+		branch = " " + r.Fork + "/" + r.Branch
+	} else if r.Branch != "" {
+		branch = " " + r.Branch
+	}
+	fmt.Printf(reviewSummaryTemplate, statusString, r.Revision, branch+requestTime, indentedDescription)
 }
 
-// showThread prints the detailed output for an entire comment thread.
-func showThread(repo repository.Repo, thread review.CommentThread, indent string) error {
+// OneLineSummary returns a summary of the given review that fits on a
+// single line: its status, revision, branch, requester, and the first line
+// of its description.
+func OneLineSummary(r *review.Summary) string {
+	branch := ""
+	if r.Fork != "" {
+		branch = " " + r.Fork + "/" + r.Branch
+	} else if r.Branch != "" {
+		branch = " " + r.Branch
+	}
+	title := strings.SplitN(strings.TrimSpace(r.Request.Description), "\n", 2)[0]
+	summary := fmt.Sprintf(reviewOneLineTemplate, r.StatusString(), r.Revision, branch, r.Request.Requester, title)
+	return Truncate(summary, TerminalWidth())
+}
+
+// showThread prints the detailed output for an entire comment thread of the
+// review for the given revision, which is empty for detached comments.
+func showThread(repo repository.Repo, revision string, thread review.CommentThread, indent string) error {
 	comment := thread.Comment
 	if comment.Location != nil && comment.Location.Path != "" && comment.Location.Range != nil && comment.Location.Range.StartLine > 0 {
-		contents, err := repo.Show(comment.Location.Commit, comment.Location.Path)
-		if err != nil {
-			return err
-		}
-		lines := strings.Split(contents, "\n")
-		err = comment.Location.Check(repo)
-		if err != nil {
+		// A comment whose location is not valid is still shown, without the
+		// lines that it refers to.
+		var lines []string
+		if err := comment.Location.Check(repo); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not showing the lines that comment %.12s refers to: %v\n", thread.Hash, err)
+		} else if contents, err := repo.Show(comment.Location.Commit, comment.Location.Path); err != nil {
 			return err
+		} else {
+			lines = strings.Split(contents, "\n")
 		}
-		if comment.Location.Range.StartLine <= uint32(len(lines)) {
+		if len(lines) > 0 && comment.Location.Range.StartLine <= uint32(len(lines)) {
 			firstLine := comment.Location.Range.StartLine
 			lastLine := comment.Location.Range.EndLine
 
@@ -154,82 +371,375 @@ func showThread(repo repository.Repo, thread review.CommentThread, indent string
 			}
 
 			fmt.Printf(commentLocationTemplate, indent, comment.Location.Path, comment.Location.Commit)
-			fmt.Println(indent + "|" + strings.Join(lines[firstLine-1:lastLine], "\n"+indent+"|"))
+			snippet := highlightLines(comment.Location.Path, lines[firstLine-1:lastLine])
+			fmt.Println(indent + "|" + strings.Join(snippet, "\n"+indent+"|"))
 		}
 	}
-	return showSubThread(repo, thread, indent)
+	return showSubThread(repo, revision, thread, indent)
 }
 
 // showSubThread prints the given comment (sub)thread, indented by the given prefix string.
-func showSubThread(repo repository.Repo, thread review.CommentThread, indent string) error {
-	statusString := "fyi"
-	if thread.Resolved != nil {
-		if *thread.Resolved {
-			statusString = "lgtm"
-		} else {
-			statusString = "needs work"
-		}
-	}
+func showSubThread(repo repository.Repo, revision string, thread review.CommentThread, indent string) error {
+	statusString := threadStatusString(thread)
 	comment := thread.Comment
+	if comment.Kind != "" {
+		statusString += " [" + comment.Kind + "]"
+	}
+	if comment.Reason != "" {
+		statusString += " (reason: " + comment.Reason + ")"
+	}
+	if comment.Delegation != nil {
+		statusString += " (on behalf of " + teams.Prefix + comment.Delegation.Team + ")"
+	}
+	if thread.Orphaned {
+		statusString += " " + orphanedString(thread)
+	}
+	if thread.Cyclic {
+		statusString += " " + cyclicString(thread)
+	}
 	threadHash := thread.Hash
-	timestamp := reformatTimestamp(comment.Timestamp)
-	commentSummary := fmt.Sprintf(indent+commentTemplate, threadHash, comment.Author, timestamp, statusString, comment.Description)
+	timestamp := FormatTimestamp(comment.Timestamp)
+	var links string
+	if thread.Permalink != "" {
+		links += fmt.Sprintf(commentLinkTemplate, thread.Permalink)
+	}
+	if thread.URL != "" {
+		links += fmt.Sprintf(commentURLTemplate, thread.URL)
+	}
+	description := wrapText(comment.Description, indent+"  ", TerminalWidth())
+	commentSummary := fmt.Sprintf(indent+commentTemplate, threadHash, comment.Author, timestamp, statusString, links, description)
 	indent = indent + "  "
 	indentedSummary := strings.Replace(commentSummary, "\n", "\n"+indent, -1)
 	fmt.Println(indentedSummary)
 	for _, child := range thread.Children {
-		err := showSubThread(repo, child, indent)
+		err := showSubThread(repo, revision, child, indent)
 		if err != nil {
 			return err
 		}
 	}
+	if thread.ChildrenTruncated > 0 && revision != "" {
+		fmt.Printf(indent+truncatedRepliesTemplate, thread.ChildrenTruncated, thread.Hash, revision)
+	} else if thread.ChildrenTruncated > 0 {
+		fmt.Printf(indent+truncatedDetachedRepliesTemplate, thread.ChildrenTruncated)
+	}
 	return nil
 }
 
+// orphanedString describes the missing comment that an orphaned thread refers to.
+func orphanedString(thread review.CommentThread) string {
+	if thread.Original != nil && thread.Original.Original != "" {
+		return fmt.Sprintf("(orphaned: edit of missing comment %.12s)", thread.Original.Original)
+	}
+	return fmt.Sprintf("(orphaned: reply to missing comment %.12s)", thread.Comment.Parent)
+}
+
+// cyclicString describes the comment that a thread was detached from to break a cycle.
+func cyclicString(thread review.CommentThread) string {
+	if thread.Original != nil && thread.Original.Original != "" {
+		return fmt.Sprintf("(cyclic: detached from comment %.12s, which it edits, to break a cycle)", thread.Original.Original)
+	}
+	return fmt.Sprintf("(cyclic: detached from comment %.12s, which it replies to, to break a cycle)", thread.Comment.Parent)
+}
+
+// printTeams prints the acceptance status of every team asked to review the change.
+func printTeams(r *review.Summary) {
+	acceptance := r.TeamAcceptance()
+	if len(acceptance) == 0 {
+		return
+	}
+	var statuses []string
+	for _, team := range teams.Names(r.Request.ReviewerTeams) {
+		if approval := acceptance[team]; approval.Delegated {
+			statuses = append(statuses, fmt.Sprintf("%s (accepted by %s as a delegate)", team, approval.Reviewer))
+		} else if approval.Reviewer != "" {
+			statuses = append(statuses, fmt.Sprintf("%s (accepted by %s)", team, approval.Reviewer))
+		} else {
+			statuses = append(statuses, fmt.Sprintf("%s (pending)", team))
+		}
+	}
+	fmt.Printf(reviewTeamsTemplate, strings.Join(statuses, ", "))
+}
+
+// printProvenance prints which of the review's requests and comments were
+// merged from forks.
+func printProvenance(r *review.Review) {
+	currentRequest, _ := r.Request.Hash()
+	for _, record := range r.Provenance {
+		var items []string
+		for _, hash := range record.Requests {
+			if hash == currentRequest {
+				items = append(items, "the current request")
+			} else {
+				items = append(items, fmt.Sprintf("request %.12s", hash))
+			}
+		}
+		for _, hash := range record.Comments {
+			items = append(items, fmt.Sprintf("comment %.12s", hash))
+		}
+		fmt.Printf(reviewProvenanceTemplate, record.Fork, record.URL,
+			FormatTimestamp(record.Timestamp), strings.Join(items, ", "))
+	}
+}
+
 // printAnalyses prints the static analysis results for the latest commit in the review.
 func printAnalyses(r *review.Review) {
 	fmt.Println("  analyses: ", r.GetAnalysesMessage())
 }
 
-// printCommentsWithIndent prints all of the comment threads with the given indent before each line.
-func printCommentsWithIndent(repo repository.Repo, c []review.CommentThread, indent string) error {
+// printBenchmarks prints the results of the latest benchmark report of each
+// agent, and whether each passes the configured threshold.
+func printBenchmarks(r *review.Review) {
+	reports, err := r.GetLatestBenchmarks()
+	if err != nil {
+		fmt.Printf(benchmarkErrorTemplate, err)
+		return
+	}
+	if len(reports) == 0 {
+		return
+	}
+	threshold, err := review.GetBenchmarkThreshold(r.Repo)
+	if err != nil {
+		fmt.Printf(benchmarkErrorTemplate, err)
+		return
+	}
+	for _, report := range reports {
+		agent := report.Agent
+		if agent == "" {
+			agent = "unknown agent"
+		}
+		if report.URL != "" {
+			agent += fmt.Sprintf(" (%q)", report.URL)
+		}
+		fmt.Printf(benchmarkReportTemplate, agent, len(report.Failures(threshold)), len(report.Results))
+		for _, result := range report.Results {
+			status := "pass"
+			if !result.Passes(threshold) {
+				status = "FAIL"
+			}
+			fmt.Printf(benchmarkResultTemplate, result.Metric,
+				strconv.FormatFloat(result.Baseline, 'g', -1, 64), result.Unit, 100*result.Delta, status)
+		}
+	}
+}
+
+// PrintFixes prints each of the given analysis notes along with the fix
+// that it suggests.
+func PrintFixes(notes []analyses.Note) error {
+	fmt.Printf(fixListTemplate, len(notes))
+	for _, note := range notes {
+		id, err := note.ID()
+		if err != nil {
+			return err
+		}
+		location := ""
+		if note.Location != nil && note.Location.Path != "" {
+			location = note.Location.Path
+			if note.Location.Range != nil && note.Location.Range.StartLine > 0 {
+				location += fmt.Sprintf(":%d", note.Location.Range.StartLine)
+			}
+			location += " "
+		}
+		if note.Category != "" {
+			location += "[" + note.Category + "] "
+		}
+		fmt.Printf(fixTemplate, id, location, note.Description)
+		fmt.Println(highlightDiff(strings.TrimRight(note.Fix, "\n")))
+	}
+	return nil
+}
+
+// printCommentsWithIndent prints all of the comment threads with the given indent before each line,
+// followed by how many threads were truncated from them, if any.
+//
+// The truncated threads include those skipped by --skip-threads, which the
+// user has already seen, so only those after the printed ones are counted.
+func printCommentsWithIndent(repo repository.Repo, revision string, c []review.CommentThread, truncated int, indent string) error {
 	for _, thread := range c {
-		err := showThread(repo, thread, indent)
+		err := showThread(repo, revision, thread, indent)
 		if err != nil {
 			return err
 		}
 	}
+	skipped := threadSkip
+	if total := len(c) + truncated; skipped > total {
+		skipped = total
+	}
+	if remaining := truncated - skipped; remaining > 0 {
+		fmt.Printf(indent+truncatedThreadsTemplate, remaining, skipped+len(c))
+	}
 	return nil
 }
 
 // PrintComments prints all of the given comment threads.
 func PrintComments(repo repository.Repo, c []review.CommentThread) error {
+	limited, truncated := limitThreads(c)
+	if porcelain {
+		printPorcelain(porcelainComments(limited, ""))
+		return nil
+	}
 	fmt.Printf(commentListTemplate, len(c))
-	return printCommentsWithIndent(repo, c, "  ")
+	return printCommentsWithIndent(repo, "", limited, truncated, "  ")
+}
+
+// PrintThread prints a single comment thread, nested under each of the
+// comments that it replies to.
+func PrintThread(repo repository.Repo, t *review.ThreadContext) error {
+	t, err := limitThreadContext(t)
+	if err != nil {
+		return err
+	}
+	thread := t.Thread
+	if porcelain {
+		parent := ""
+		if len(t.Ancestors) > 0 {
+			parent = t.Ancestors[len(t.Ancestors)-1].Hash
+		}
+		printPorcelain(porcelainComments([]review.CommentThread{thread}, parent))
+		return nil
+	}
+	fmt.Printf(threadContextTemplate, t.Thread.Hash, t.Revision, len(t.Ancestors))
+	var path []review.CommentThread
+	path = append(path, t.Ancestors...)
+	path = append(path, thread)
+	indent := "  "
+	for i, thread := range path {
+		show := showSubThread
+		if i == 0 {
+			show = showThread
+		}
+		if err := show(repo, t.Revision, thread, indent); err != nil {
+			return err
+		}
+		indent += "  "
+	}
+	return nil
+}
+
+// PrintThreadJSON prints a single comment thread, along with the comments
+// that it replies to, in JSON format.
+func PrintThreadJSON(t *review.ThreadContext) error {
+	limited, err := limitThreadContext(t)
+	if err != nil {
+		return err
+	}
+	serialized, err := json.MarshalIndent(limited, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(serialized))
+	return nil
+}
+
+// PrintSuggestions prints the numbered comments proposed by an assistant.
+func PrintSuggestions(suggestions []assist.Suggestion) {
+	for i, s := range suggestions {
+		location := "review"
+		if s.Path != "" {
+			location = s.Path
+			if s.Range != nil {
+				location += ":" + s.Range.String()
+			}
+		}
+		if s.Kind != "" {
+			location += " [" + s.Kind + "]"
+		}
+		body := strings.Replace(strings.TrimRight(s.Body, "\n"), "\n", "\n    ", -1)
+		fmt.Printf("%d. %s\n    %s\n", i+1, location, body)
+	}
 }
 
 // printComments prints all of the comments for the review, with snippets of the preceding source code.
 func printComments(r *review.Review) error {
+	limited, truncated := limitThreads(r.Comments)
+	limited, err := addPermalinks(r.Revision, limited)
+	if err != nil {
+		return err
+	}
 	fmt.Printf(commentSummaryTemplate, len(r.Comments))
-	return printCommentsWithIndent(r.Repo, r.Comments, "    ")
+	return printCommentsWithIndent(r.Repo, r.Revision, limited, truncated, "    ")
+}
+
+// printPreviouslyReviewed prints the commits of a review whose changes were
+// already accepted in other reviews, in the order of the review's commits.
+func printPreviouslyReviewed(r *review.Review) {
+	if len(r.Request.PreviouslyReviewed) == 0 {
+		return
+	}
+	commits, err := r.ListCommits()
+	if err != nil {
+		return
+	}
+	for _, commit := range commits {
+		if reviewed, ok := r.Request.PreviouslyReviewed[commit]; ok {
+			fmt.Printf(reviewPreviouslyReviewedTemplate, commit, reviewed)
+		}
+	}
 }
 
 // PrintDetails prints a multi-line overview of a review, including all comments.
 func PrintDetails(r *review.Review) error {
+	if porcelain {
+		fmt.Println(porcelainSummary(r.Summary))
+		limited, _ := limitThreads(r.Comments)
+		printPorcelain(porcelainComments(limited, ""))
+		return nil
+	}
 	PrintSummary(r.Summary)
 	fmt.Printf(reviewDetailsTemplate, r.Request.ReviewRef, r.Request.TargetRef,
 		strings.Join(r.Request.Reviewers, ", "),
 		r.Request.Requester, r.GetBuildStatusMessage())
+	if len(r.Request.CC) > 0 {
+		fmt.Printf(reviewCCTemplate, strings.Join(r.Request.CC, ", "))
+	}
+	if len(r.Request.Labels) > 0 {
+		fmt.Printf(reviewLabelsTemplate, strings.Join(r.Request.Labels, ", "))
+	}
+	if r.Request.BackportOf != "" {
+		fmt.Printf(reviewBackportTemplate, r.Request.BackportOf)
+	}
+	if committer, err := r.GetForeignCommitter(); err == nil && committer != "" {
+		fmt.Printf(reviewForeignCommitterTemplate, committer)
+	}
+	printPreviouslyReviewed(r)
+	printSize(r)
+	printTeams(r.Summary)
+	printProvenance(r)
 	printAnalyses(r)
+	printBenchmarks(r)
 	if err := printComments(r); err != nil {
 		return err
 	}
 	return nil
 }
 
+// printSize prints the size of the review, if its code is available.
+func printSize(r *review.Review) {
+	size, err := r.GetSize()
+	if err != nil {
+		return
+	}
+	fmt.Printf(reviewSizeTemplate, FormatSize(r.Repo, *size))
+}
+
+// FormatSize returns a description of the given size of a review, including
+// roughly how long it takes to review.
+func FormatSize(repo repository.Repo, size review.Size) string {
+	description := fmt.Sprintf("%s changed, %s(+), %s(-)", pluralize(int64(size.Files), "file"),
+		pluralize(int64(size.Insertions), "insertion"), pluralize(int64(size.Deletions), "deletion"))
+	estimate, err := review.EstimateReviewTime(repo, size)
+	if err != nil {
+		return description
+	}
+	if estimate < time.Hour {
+		return fmt.Sprintf("%s; about %s to review", description, pluralize(int64(estimate.Minutes()), "minute"))
+	}
+	return fmt.Sprintf("%s; about %.1f hours to review", description, estimate.Hours())
+}
+
 // PrintCommentsJSON pretty prints the given review in JSON format.
 func PrintCommentsJSON(c []review.CommentThread) error {
-	json, err := review.GetCommentsJSON(c)
+	limited, _ := limitThreads(c)
+	json, err := review.GetCommentsJSON(limited)
 	if err != nil {
 		return err
 	}
@@ -239,7 +749,11 @@ func PrintCommentsJSON(c []review.CommentThread) error {
 
 // PrintJSON pretty prints the given review in JSON format.
 func PrintJSON(r *review.Review) error {
-	json, err := r.GetJSON()
+	limited, err := limitReview(r)
+	if err != nil {
+		return err
+	}
+	json, err := limited.GetJSON()
 	if err != nil {
 		return err
 	}
@@ -247,12 +761,225 @@ func PrintJSON(r *review.Review) error {
 	return nil
 }
 
-// PrintDiff prints the diff of the review.
-func PrintDiff(r *review.Review, diffArgs ...string) error {
-	diff, err := r.GetDiff(diffArgs...)
+// PrintHistory prints each of the heads that a review has had, followed by
+// the diffs between successive heads, as controlled by the given options.
+func PrintHistory(r *review.Review, options repository.DiffOptions, diffArgs ...string) error {
+	history, err := r.GetHistory()
+	if err != nil {
+		return err
+	}
+	if porcelain {
+		// The diffs between the heads can be computed with `git diff`.
+		printPorcelain(porcelainHistory(history))
+		return nil
+	}
+	fmt.Printf(reviewHistoryTemplate, len(history), r.Revision)
+	for _, entry := range history {
+		fmt.Printf(historyEntryTemplate, entry.Commit, FormatTimestamp(entry.Timestamp), entry.Source)
+	}
+	for i := 1; i < len(history); i++ {
+		previous, current := history[i-1].Commit, history[i].Commit
+		fmt.Printf(historyDiffTemplate, previous, current)
+		diff, err := r.Repo.DiffWithOptions(previous, current, options, colorDiffArgs(diffArgs)...)
+		if err != nil {
+			fmt.Printf("  unavailable: %v\n", err)
+			continue
+		}
+		fmt.Println(highlightDiff(diff))
+	}
+	return nil
+}
+
+// threadStatusString returns the status of a comment thread, as it is shown
+// in its thread.
+func threadStatusString(thread review.CommentThread) string {
+	if thread.Resolved == nil {
+		return "fyi"
+	}
+	if *thread.Resolved {
+		return "lgtm"
+	}
+	return "needs work"
+}
+
+// printComparisonThread prints a single-line summary of a comment thread.
+func printComparisonThread(thread review.CommentThread, status string) {
+	location := ""
+	if l := thread.Comment.Location; l != nil && l.Path != "" {
+		location = " " + l.Path
+		if l.Range != nil && l.Range.StartLine > 0 {
+			location += fmt.Sprintf(":%d", l.Range.StartLine)
+		}
+	}
+	title := strings.SplitN(strings.TrimSpace(thread.Comment.Description), "\n", 2)[0]
+	fmt.Printf(comparisonThreadTemplate, status, thread.Hash, location, thread.Comment.Author, title)
+}
+
+// PrintComparison prints the diff between the heads of two reviews, followed
+// by the comment threads that differ between them.
+func PrintComparison(c *review.Comparison, options repository.DiffOptions, diffArgs ...string) error {
+	fmt.Printf(comparisonTemplate, c.Left.Revision, c.LeftHead, c.Right.Revision, c.RightHead)
+	diff, err := c.Left.Repo.DiffWithOptions(c.LeftHead, c.RightHead, options, colorDiffArgs(diffArgs)...)
+	if err != nil {
+		return err
+	}
+	fmt.Println(highlightDiff(diff))
+	if len(c.OnlyLeft) > 0 {
+		fmt.Printf(comparisonThreadsTemplate, fmt.Sprintf("Threads only in review %.12s", c.Left.Revision))
+		for _, thread := range c.OnlyLeft {
+			printComparisonThread(thread, threadStatusString(thread))
+		}
+	}
+	if len(c.OnlyRight) > 0 {
+		fmt.Printf(comparisonThreadsTemplate, fmt.Sprintf("Threads only in review %.12s", c.Right.Revision))
+		for _, thread := range c.OnlyRight {
+			printComparisonThread(thread, threadStatusString(thread))
+		}
+	}
+	if len(c.StatusChanged) > 0 {
+		fmt.Printf(comparisonThreadsTemplate, "Threads in both reviews whose status changed")
+		for _, pair := range c.StatusChanged {
+			printComparisonThread(pair[1], threadStatusString(pair[0])+" -> "+threadStatusString(pair[1]))
+		}
+	}
+	return nil
+}
+
+// PrintRequestHistory prints each revision of the review's request, with
+// how it changed the request.
+func PrintRequestHistory(r *review.Review) error {
+	history := r.RequestHistory()
+	fmt.Printf(requestHistoryTemplate, len(history), r.Revision)
+	for i, revision := range history {
+		fmt.Printf(requestRevisionTemplate, FormatTimestamp(revision.Request.Timestamp), revision.Request.Requester)
+		if i == 0 {
+			fmt.Printf("  requested a review against %q\n", revision.Request.TargetRef+revision.Request.TargetTag)
+			if len(revision.Request.Reviewers) > 0 {
+				fmt.Printf("  reviewers: %s\n", strings.Join(revision.Request.Reviewers, ", "))
+			}
+			fmt.Println("  " + strings.Replace(revision.Request.Description, "\n", "\n  ", -1))
+			continue
+		}
+		if len(revision.Changes) == 0 {
+			fmt.Println("  no changes")
+		}
+		for _, change := range revision.Changes {
+			if change.Field == "description" {
+				fmt.Println("  description:")
+				fmt.Println("    " + strings.Replace(change.New, "\n", "\n    ", -1))
+				continue
+			}
+			fmt.Printf(requestChangeTemplate, change.Field, change.Old, change.New)
+		}
+	}
+	return nil
+}
+
+// PrintRequestHistoryJSON prints each revision of the review's request, with
+// how it changed the request, as JSON.
+func PrintRequestHistoryJSON(r *review.Review) error {
+	serialized, err := json.MarshalIndent(r.RequestHistory(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(serialized))
+	return nil
+}
+
+// PrintSkips prints why each of the given notes was skipped when listing reviews.
+func PrintSkips(w io.Writer, skips []review.Skip) {
+	fmt.Fprintf(w, skipListTemplate, len(skips))
+	for _, skip := range skips {
+		fmt.Fprintf(w, skipTemplate, skip.Revision, skip.Reason)
+		if len(skip.Note) == 0 {
+			continue
+		}
+		note := string(skip.Note)
+		if len(note) > maxSkippedNoteLength {
+			note = note[:maxSkippedNoteLength] + "..."
+		}
+		fmt.Fprintf(w, "    %s\n", note)
+	}
+}
+
+// PrintConfig prints the effective value of each of the given settings, and
+// where it came from.
+func PrintConfig(values []config.Value) {
+	for _, v := range values {
+		if porcelain {
+			status := "ok"
+			if v.Err != nil {
+				status = "invalid"
+			}
+			fmt.Println(porcelainLine("config", v.Key, v.Value, v.Source, status))
+			continue
+		}
+		fmt.Printf(configValueTemplate, v.Key, v.Value, v.Source)
+		if v.Err != nil {
+			fmt.Printf("  %v\n", v.Err)
+		}
+	}
+}
+
+// PrintDiff prints the diff of the review, as controlled by the given options.
+func PrintDiff(r *review.Review, options repository.DiffOptions, diffArgs ...string) error {
+	diff, err := r.GetDiffWithOptions(options, colorDiffArgs(diffArgs)...)
 	if err != nil {
 		return err
 	}
-	fmt.Println(diff)
+	fmt.Println(highlightDiff(diff))
+	files := review.ParseDiff(diff)
+	if err := r.AnnotateCoverage(files); err != nil {
+		return err
+	}
+	printCoverageWarnings(files)
 	return nil
 }
+
+// PrintDiffJSON prints the diff of the review as JSON, parsed into the
+// changes to each file, as controlled by the given options.
+func PrintDiffJSON(r *review.Review, options repository.DiffOptions, diffArgs ...string) error {
+	files, err := r.GetStructuredDiff(options, diffArgs...)
+	if err != nil {
+		return err
+	}
+	serialized, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(serialized))
+	return nil
+}
+
+// printCoverageWarnings prints a warning for each run of consecutive added
+// lines in the given files that are not covered by tests.
+func printCoverageWarnings(files []review.DiffFile) {
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			var start, end uint32
+			flush := func() {
+				if start == 0 {
+					return
+				}
+				location := fmt.Sprintf("%s:%d", file.NewPath, start)
+				if end > start {
+					location += fmt.Sprintf("-%d", end)
+				}
+				fmt.Printf(coverageWarningTemplate, location)
+				start, end = 0, 0
+			}
+			for _, line := range hunk.Lines {
+				switch {
+				case line.Uncovered && start != 0 && line.NewLine == end+1:
+					end = line.NewLine
+				case line.Uncovered:
+					flush()
+					start, end = line.NewLine, line.NewLine
+				case line.Kind != review.DiffLineRemoved:
+					flush()
+				}
+			}
+			flush()
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+)
+
+// captureStdout returns what the given function prints to stdout.
+func captureStdout(t *testing.T, f func() error) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func newThread(hash string, children ...review.CommentThread) review.CommentThread {
+	return review.CommentThread{
+		Hash:     hash,
+		Comment:  comment.Comment{Author: "user@example.com", Timestamp: "0000000001", Description: hash},
+		Children: children,
+	}
+}
+
+func TestPrintTruncatedThreads(t *testing.T) {
+	defer SetThreadLimits(0, 0, 0)
+	if err := SetThreadLimits(1, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+	threads := []review.CommentThread{
+		newThread("aaaa"),
+		newThread("bbbb", newThread("b1"), newThread("b2"), newThread("b3")),
+		newThread("cccc"),
+		newThread("dddd"),
+		newThread("eeee"),
+	}
+	repo := repository.NewMockRepoForTest()
+	limited, truncated := limitThreads(threads)
+	out := captureStdout(t, func() error {
+		return printCommentsWithIndent(repo, repository.TestCommitB, limited, truncated, "")
+	})
+	if !strings.Contains(out, "... 2 more threads; use --skip-threads 3 to see them") {
+		t.Errorf("Unexpected note of the truncated threads: %q", out)
+	}
+	if !strings.Contains(out, `... 1 more replies; run "git appraise show --thread bbbb B" to see them`) {
+		t.Errorf("Unexpected note of the truncated replies: %q", out)
+	}
+
+	out = captureStdout(t, func() error {
+		return printCommentsWithIndent(repo, "", limited, truncated, "")
+	})
+	if !strings.Contains(out, "... 1 more replies\n") {
+		t.Errorf("Unexpected note of the truncated replies of detached comments: %q", out)
+	}
+
+	if err := SetThreadLimits(3, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	limited, truncated = limitThreads(threads)
+	out = captureStdout(t, func() error {
+		return printCommentsWithIndent(repo, repository.TestCommitB, limited, truncated, "")
+	})
+	if strings.Contains(out, "more threads") {
+		t.Errorf("Unexpected note of skipped threads on the last page: %q", out)
+	}
+}
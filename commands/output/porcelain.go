@@ -0,0 +1,126 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/git-appraise/review"
+)
+
+// porcelain indicates that output should use the stable, tab-separated
+// formats documented in the README rather than the human readable ones.
+var porcelain = false
+
+// quiet indicates that informational messages should not be printed.
+var quiet = false
+
+// SetPorcelain switches all subsequent output to the porcelain formats.
+//
+// Porcelain output is meant for scripts, so it also suppresses informational messages.
+func SetPorcelain(enabled bool) {
+	porcelain = enabled
+	if enabled {
+		quiet = true
+	}
+}
+
+// SetQuiet suppresses all subsequent informational messages.
+func SetQuiet(enabled bool) {
+	quiet = enabled
+}
+
+// Infof prints an informational message, such as a report of what a command
+// did, unless the quiet or porcelain modes are enabled.
+func Infof(format string, a ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// porcelainEscaper escapes the characters that would break up a porcelain field.
+var porcelainEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\t", "\\t",
+	"\n", "\\n",
+	"\r", "\\r",
+)
+
+// porcelainLine joins the given fields into a single, tab-separated record.
+func porcelainLine(fields ...string) string {
+	var escaped []string
+	for _, field := range fields {
+		escaped = append(escaped, porcelainEscaper.Replace(field))
+	}
+	return strings.Join(escaped, "\t")
+}
+
+// porcelainSummary returns the "review" record for the given review.
+func porcelainSummary(r *review.Summary) string {
+	return porcelainLine("review", r.Revision, r.StatusString(), r.Request.Timestamp,
+		r.Request.Requester, r.Request.TargetRef, r.Request.ReviewRef, r.Request.Description)
+}
+
+// porcelainThreadStatus returns the status of a comment thread as a single word.
+func porcelainThreadStatus(thread review.CommentThread) string {
+	if thread.Resolved == nil {
+		return "fyi"
+	}
+	if *thread.Resolved {
+		return "lgtm"
+	}
+	return "needs-work"
+}
+
+// porcelainComments returns the "comment" records for the given threads and
+// all of their replies, with each reply following its parent.
+func porcelainComments(threads []review.CommentThread, parent string) []string {
+	var lines []string
+	for _, thread := range threads {
+		c := thread.Comment
+		var path, line string
+		if c.Location != nil {
+			path = c.Location.Path
+			if c.Location.Range != nil && c.Location.Range.StartLine > 0 {
+				line = strconv.FormatUint(uint64(c.Location.Range.StartLine), 10)
+			}
+		}
+		lines = append(lines, porcelainLine("comment", thread.Hash, parent,
+			porcelainThreadStatus(thread), c.Timestamp, c.Author, path, line, c.Description))
+		lines = append(lines, porcelainComments(thread.Children, thread.Hash)...)
+	}
+	return lines
+}
+
+// porcelainHistory returns the "history" records for the given heads of a review.
+func porcelainHistory(history []review.HistoryEntry) []string {
+	var lines []string
+	for _, entry := range history {
+		lines = append(lines, porcelainLine("history", entry.Commit, entry.Timestamp, entry.Source))
+	}
+	return lines
+}
+
+// printPorcelain prints each of the given porcelain records on its own line.
+func printPorcelain(lines []string) {
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
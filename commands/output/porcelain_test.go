@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestPorcelainSummary(t *testing.T) {
+	r := &review.Summary{
+		Revision: "abc123",
+		Request: request.Request{
+			Timestamp:   "0000000001",
+			Requester:   "user@example.com",
+			TargetRef:   "refs/heads/master",
+			ReviewRef:   "refs/heads/feature",
+			Description: "Add a feature\n\nIt uses\ttabs and \\ backslashes",
+		},
+	}
+	expected := "review\tabc123\tpending\t0000000001\tuser@example.com\trefs/heads/master\trefs/heads/feature\tAdd a feature\\n\\nIt uses\\ttabs and \\\\ backslashes"
+	if line := porcelainSummary(r); line != expected {
+		t.Errorf("Unexpected porcelain summary: %q", line)
+	}
+}
+
+func TestPorcelainComments(t *testing.T) {
+	resolved := false
+	threads := []review.CommentThread{
+		{
+			Hash: "parent",
+			Comment: comment.Comment{
+				Timestamp: "0000000001",
+				Author:    "reviewer@example.com",
+				Location: &comment.Location{
+					Commit: "abc123",
+					Path:   "main.go",
+					Range:  &comment.Range{StartLine: 7},
+				},
+				Description: "Rename this",
+			},
+			Resolved: &resolved,
+			Children: []review.CommentThread{
+				{
+					Hash: "child",
+					Comment: comment.Comment{
+						Timestamp:   "0000000002",
+						Author:      "user@example.com",
+						Description: "Done",
+					},
+				},
+			},
+		},
+	}
+	expected := []string{
+		"comment\tparent\t\tneeds-work\t0000000001\treviewer@example.com\tmain.go\t7\tRename this",
+		"comment\tchild\tparent\tfyi\t0000000002\tuser@example.com\t\t\tDone",
+	}
+	if lines := porcelainComments(threads, ""); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected porcelain comments: %q", lines)
+	}
+}
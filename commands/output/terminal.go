@@ -0,0 +1,49 @@
+//go:build !windows
+
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// terminalColumns returns the width of the controlling terminal, or zero if
+// that is not known.
+func terminalColumns() int {
+	terminal, err := os.Open("/dev/tty")
+	if err != nil {
+		return 0
+	}
+	defer terminal.Close()
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = terminal
+	out, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0
+	}
+	columns, _ := strconv.Atoi(fields[1])
+	return columns
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"errors"
+
+	"github.com/google/git-appraise/review"
+)
+
+// The limits on the comment threads printed by this package; a limit of 0
+// means that there is no limit.
+var (
+	threadSkip     = 0
+	threadMax      = 0
+	threadMaxDepth = 0
+)
+
+// SetThreadLimits sets how many top-level comment threads to skip, how many
+// threads to print at each level, and how deeply to print them, for all
+// subsequently printed comments.
+func SetThreadLimits(skip, maxThreads, maxDepth int) error {
+	if skip < 0 || maxThreads < 0 || maxDepth < 0 {
+		return errors.New("The thread limits must not be negative.")
+	}
+	threadSkip, threadMax, threadMaxDepth = skip, maxThreads, maxDepth
+	return nil
+}
+
+// limitThreads applies the thread limits to the given comment threads,
+// returning the threads that are kept, and how many top-level threads are not.
+func limitThreads(threads []review.CommentThread) ([]review.CommentThread, int) {
+	return review.TruncateThreads(threads, threadSkip, threadMax, threadMaxDepth)
+}
+
+// limitThread applies the thread limits, other than the number of top-level
+// threads to skip, to the replies of the given comment thread.
+func limitThread(thread review.CommentThread) review.CommentThread {
+	threads, _ := review.TruncateThreads([]review.CommentThread{thread}, 0, threadMax, threadMaxDepth)
+	return threads[0]
+}
+
+// limitReview returns a copy of the given review with the thread limits
+// applied to its comments, and their permalinks filled in. The status of the
+// review is left alone, since it depends on every comment.
+func limitReview(r *review.Review) (*review.Review, error) {
+	limited := *r
+	summary := *r.Summary
+	summary.Comments, summary.CommentsTruncated = limitThreads(r.Comments)
+	var err error
+	if summary.Comments, err = addPermalinks(r.Revision, summary.Comments); err != nil {
+		return nil, err
+	}
+	limited.Summary = &summary
+	return &limited, nil
+}
+
+// limitThreadContext returns a copy of the given comment thread with the
+// thread limits applied to its replies, and the permalinks of it and the
+// comments that it replies to filled in.
+func limitThreadContext(t *review.ThreadContext) (*review.ThreadContext, error) {
+	limited := *t
+	var err error
+	if limited.Ancestors, err = addPermalinks(t.Revision, t.Ancestors); err != nil {
+		return nil, err
+	}
+	threads, err := addPermalinks(t.Revision, []review.CommentThread{limitThread(t.Thread)})
+	if err != nil {
+		return nil, err
+	}
+	limited.Thread = threads[0]
+	return &limited, nil
+}
+
+// permalinker generates the permalinks printed with comments, if it is set.
+var permalinker *review.Permalinker
+
+// SetPermalinker sets the permalinker used to generate the permalinks of all
+// subsequently printed review comments.
+func SetPermalinker(p *review.Permalinker) {
+	permalinker = p
+}
+
+// addPermalinks fills in the permalinks of the given comment threads of the
+// given review, if a permalinker is set.
+func addPermalinks(revision string, threads []review.CommentThread) ([]review.CommentThread, error) {
+	if permalinker == nil {
+		return threads, nil
+	}
+	return permalinker.AddPermalinks(revision, threads)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// ellipsis marks where text was truncated.
+	ellipsis = "…"
+	// minWrapWidth is the fewest columns to which text is wrapped, so that
+	// deeply indented text stays readable on narrow terminals.
+	minWrapWidth = 20
+)
+
+// wideRanges are the ranges of runes that take up two columns of a
+// terminal, such as East Asian wide and fullwidth characters, and emoji.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16fe0, Hi: 0x18cff, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f251, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f7e0, Hi: 0x1f7eb, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// RuneWidth returns the number of terminal columns that the given rune
+// takes up: 0 for combining marks, zero-width and control characters, 2 for
+// wide characters and emoji, and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0x200b || r == 0x200c || r == 0x200d || r == 0x2060 || r == 0xfeff:
+		return 0
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Variation_Selector, r):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	case unicode.Is(wideRanges, r):
+		return 2
+	}
+	return 1
+}
+
+// Width returns the number of terminal columns that the given text takes up
+// when printed on a single line.
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// Truncate shortens the given single line of text to at most the given
+// number of terminal columns, ending it with an ellipsis if it was cut. It
+// never cuts through a rune, or between a rune and the combining marks that
+// follow it. A width of zero or less means that there is no limit.
+func Truncate(s string, width int) string {
+	if width <= 0 || Width(s) <= width {
+		return s
+	}
+	limit := width - Width(ellipsis)
+	used := 0
+	for i, r := range s {
+		w := RuneWidth(r)
+		if w > 0 && used+w > limit {
+			return s[:i] + ellipsis
+		}
+		used += w
+	}
+	return s
+}
+
+// Wrap breaks the given single line of text into lines of at most the given
+// number of terminal columns, at spaces where possible. Words that are too
+// long for a line on their own are broken between runes. The leading
+// whitespace of the text (e.g. of an indented list or code) is kept, and
+// repeated on every line. A width of zero or less means that there is no
+// limit.
+func Wrap(s string, width int) []string {
+	if width <= 0 || Width(s) <= width {
+		return []string{s}
+	}
+	indent := s[:len(s)-len(strings.TrimLeftFunc(s, unicode.IsSpace))]
+	if indent != "" {
+		available := width - Width(indent)
+		if available < minWrapWidth {
+			available = minWrapWidth
+		}
+		lines := Wrap(s[len(indent):], available)
+		for i := range lines {
+			lines[i] = indent + lines[i]
+		}
+		return lines
+	}
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range strings.Fields(s) {
+		wordWidth := Width(word)
+		if lineWidth > 0 && lineWidth+1+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		for wordWidth > width-lineWidth {
+			// The word does not fit on a line of its own, so break it.
+			used, i := 0, 0
+			for i < len(word) {
+				r, size := utf8.DecodeRuneInString(word[i:])
+				if w := RuneWidth(r); w > 0 && used+w > width-lineWidth && used > 0 {
+					break
+				} else {
+					used += w
+				}
+				i += size
+			}
+			line.WriteString(word[:i])
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+			word = word[i:]
+			wordWidth = Width(word)
+		}
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// wrapText wraps every line of the given text to fit within the given
+// number of terminal columns, less the width of the indent that the lines
+// will be printed with. A width of zero or less means that there is no
+// limit.
+func wrapText(text, indent string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	available := width - Width(indent)
+	if available < minWrapWidth {
+		available = minWrapWidth
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, Wrap(line, available)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sttyColumns caches the width of the terminal, as it is looked up by
+// running stty, and every summary that is printed needs it.
+var (
+	sttyOnce    sync.Once
+	sttyColumns int
+)
+
+// TerminalWidth returns the number of columns of the terminal that the
+// output is written to, or zero if it is not written to a terminal, or is
+// meant for scripts. The COLUMNS environment variable overrides the width
+// of the terminal.
+func TerminalWidth() int {
+	if porcelain || !isTerminal() {
+		return 0
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	sttyOnce.Do(func() {
+		sttyColumns = terminalColumns()
+	})
+	return sttyColumns
+}
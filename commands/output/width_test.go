@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	cases := map[string]int{
+		"":            0,
+		"hello":       5,
+		"naïve":       5,
+		"nai\u0308ve": 5,
+		"日本語":         6,
+		"fix 🐛":       6,
+		"a\u200db":    2,
+		"한국어 review":  13,
+	}
+	for text, expected := range cases {
+		if got := Width(text); got != expected {
+			t.Errorf("Unexpected width of %q: %d", text, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		text, expected string
+		width          int
+	}{
+		{"hello", "hello", 5},
+		{"hello", "hello", 0},
+		{"hello world", "hello…", 6},
+		{"日本語のテキスト", "日本…", 6},
+		{"日本語のテキスト", "日本…", 5},
+		{"naïve text", "naï…", 4},
+		{"🐛🐛🐛", "🐛…", 4},
+	}
+	for _, c := range cases {
+		got := Truncate(c.text, c.width)
+		if got != c.expected {
+			t.Errorf("Unexpected truncation of %q to %d columns: %q", c.text, c.width, got)
+		}
+		if c.width > 0 && Width(got) > c.width {
+			t.Errorf("Truncation of %q is %d columns wide, more than %d", c.text, Width(got), c.width)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		text     string
+		width    int
+		expected []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"no limit at all", 0, []string{"no limit at all"}},
+		{"the quick brown fox", 10, []string{"the quick", "brown fox"}},
+		{"日本語 のテキスト", 8, []string{"日本語", "のテキス", "ト"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"a abcdef", 4, []string{"a", "abcd", "ef"}},
+		{"    indented code that is long", 24, []string{"    indented code that", "    is long"}},
+		{"  - a list item that wraps", 20, []string{"  - a list item that", "  wraps"}},
+	}
+	for _, c := range cases {
+		if got := Wrap(c.text, c.width); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("Unexpected wrapping of %q to %d columns: %q", c.text, c.width, got)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	text := "Fix the parser\n\nIt used to crash on empty input."
+	if got := wrapText(text, "  ", 0); got != text {
+		t.Errorf("Unexpected wrapping without a width: %q", got)
+	}
+	expected := "Fix the parser\n\nIt used to crash on\nempty input."
+	if got := wrapText(text, "  ", 22); got != expected {
+		t.Errorf("Unexpected wrapping to 22 columns: %q", got)
+	}
+}
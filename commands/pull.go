@@ -17,20 +17,44 @@ limitations under the License.
 package commands
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
 
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/metrics"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/provenance"
+	"github.com/google/git-appraise/review/request"
 )
 
 var (
 	pullFlagSet = flag.NewFlagSet("pull", flag.ExitOnError)
 	pullVerify  = pullFlagSet.Bool("verify-signatures", false,
 		"verify the signatures of pulled reviews")
+	pullQuiet = pullFlagSet.Bool("quiet", false,
+		"do not report the progress of the pull")
+	pullQuarantine = pullFlagSet.Bool("quarantine", false,
+		"fetch the remote's review notes without merging them, so that they can be inspected with `fork diff` and merged with `fork approve`. Defaults to the value of appraise.pull.quarantine")
+	pullWithCode = pullFlagSet.Bool("with-code", false,
+		"also fetch the review refs of open reviews that do not exist locally, from the remote or the fork they were recorded from. Defaults to the value of appraise.pull.withCode")
+	pullFetchMissing = pullFlagSet.Bool("fetch-missing", false,
+		"also fetch the reviewed commits that do not exist locally, from the remote or the fork they were recorded from")
 )
 
+// errPullInterrupted is returned when the user interrupts a pull.
+var errPullInterrupted = errors.New(
+	"The pull was interrupted. Every local ref was left in a consistent state, so it is safe to pull again.")
+
 // pull updates the local git-notes used for reviews with those from a remote
 // repo.
 func pull(repo repository.Repo, args []string) error {
@@ -46,11 +70,83 @@ func pull(repo repository.Repo, args []string) error {
 	if len(pullArgs) == 1 {
 		remote = pullArgs[0]
 	}
+	start := time.Now()
+	err := pullFromRemote(repo, remote)
+	if metricsEnabled(repo) {
+		if recordErr := metrics.RecordPull(repo, remote, time.Now(), time.Since(start), err == nil); recordErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to record the pull for the metrics: %v\n", recordErr)
+		}
+	}
+	return err
+}
+
+// metricsEnabled returns whether pulls are recorded for the metrics, as they
+// are only when the appraise.metrics.enabled config is set.
+func metricsEnabled(repo repository.Repo) bool {
+	enabled, err := config.Get(repo, "appraise.metrics.enabled")
+	return err == nil && enabled == "true"
+}
+
+// pullFromRemote implements pull, for the given remote.
+func pullFromRemote(repo repository.Repo, remote string) error {
+	warnAboutNotesConfig(repo, os.Stderr)
+	// Interrupting the pull stops it between steps, rather than killing the
+	// tool while it is in the middle of updating the local refs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var progress io.Writer = os.Stderr
+	if *pullQuiet {
+		progress = nil
+	}
+
+	quarantine := *pullQuarantine
+	if !quarantine {
+		configured, err := config.Get(repo, "appraise.pull.quarantine")
+		if err != nil {
+			return err
+		}
+		quarantine = configured == "true"
+	}
+	notesURL, err := getNotesURL(repo, remote)
+	if err != nil {
+		return err
+	}
+	if notesURL != "" {
+		if quarantine || *pullVerify {
+			return fmt.Errorf("Quarantining and verifying pulled reviews are not supported for the remote %q, whose notes are synced with %q", remote, redactURL(notesURL))
+		}
+		if _, _, err := pullNotesOverHTTP(repo, notesURL); err != nil {
+			return err
+		}
+		return fetchReviewCodeIfRequested(repo, remote)
+	}
+	if quarantine {
+		revisions, err := repo.FetchAndReturnNewReviewHashes(remote, notesRefPattern, archiveRefPattern)
+		if err != nil {
+			return err
+		}
+		if *pullVerify {
+			if err := verifyFetchedReviews(ctx, repo, remote, revisions, progress); err != nil {
+				return err
+			}
+		}
+		output.Infof("Fetched the review notes from %q into quarantine. Run `git appraise fork diff %s` to inspect them, and `git appraise fork approve %s` to merge them.\n",
+			remote, remote, remote)
+		return nil
+	}
+
 	// This is the easy case. We're not checking signatures so just go the
 	// normal route.
 	if !*pullVerify {
-		return repo.PullNotesAndArchive(remote, notesRefPattern,
-			archiveRefPattern)
+		err := repo.PullNotesAndArchiveWithProgress(ctx, remote, notesRefPattern,
+			archiveRefPattern, progress)
+		if ctx.Err() != nil {
+			return errPullInterrupted
+		}
+		if err != nil {
+			return err
+		}
+		return fetchReviewCodeIfRequested(repo, remote)
 	}
 
 	// Otherwise, we collect the fetched reviewed revisions (their hashes), get
@@ -58,28 +154,204 @@ func pull(repo repository.Repo, args []string) error {
 	// the set, _then_ we merge the remote reference into the local branch.
 	revisions, err := repo.FetchAndReturnNewReviewHashes(remote,
 		notesRefPattern, archiveRefPattern)
+	if ctx.Err() != nil {
+		return errPullInterrupted
+	}
+	if err != nil {
+		return err
+	}
+	if err := verifyFetchedReviews(ctx, repo, remote, revisions, progress); err != nil {
+		return err
+	}
+
+	err = repo.MergeNotes(remote, notesRefPattern)
 	if err != nil {
 		return err
 	}
+	if err := repo.MergeArchives(remote, archiveRefPattern); err != nil {
+		return err
+	}
+	return fetchReviewCodeIfRequested(repo, remote)
+}
+
+// verifyFetchedReviews verifies the signatures of the given reviews, as they
+// were fetched from the given remote into its remote notes refs. Every review
+// is verified, so that each failure is recorded for the metrics, and an error
+// is returned if any of them failed.
+func verifyFetchedReviews(ctx context.Context, repo repository.Repo, remote string, revisions []string, progress io.Writer) error {
+	var failures []error
 	for _, revision := range revisions {
+		if ctx.Err() != nil {
+			return errPullInterrupted
+		}
 		rvw, err := review.GetSummaryViaRefs(repo,
-			"refs/notes/"+remote+"/devtools/reviews",
-			"refs/notes/"+remote+"/devtools/discuss", revision)
+			getQuarantinedNotesRef(remote, request.Ref),
+			getQuarantinedNotesRef(remote, comment.Ref), revision)
 		if err != nil {
 			return err
 		}
-		err = rvw.Verify()
+		if err := rvw.Verify(); err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		if progress != nil {
+			output.Infof("verified review: %s\n", revision)
+		}
+	}
+	if len(failures) > 0 && metricsEnabled(repo) {
+		if err := metrics.RecordVerificationFailures(repo, remote, len(failures)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to record the verification failures for the metrics: %v\n", err)
+		}
+	}
+	switch {
+	case len(failures) == 1:
+		return failures[0]
+	case len(failures) > 1:
+		return fmt.Errorf("%d of the pulled reviews failed verification, the first with: %v", len(failures), failures[0])
+	}
+	if ctx.Err() != nil {
+		return errPullInterrupted
+	}
+	return nil
+}
+
+// getReviewCodeSource returns the remote that the given review ref should be
+// fetched from, and the refspec that fetches it.
+//
+// Refs under refs/forks/<fork>/ are fetched from the branches of that fork,
+// as are refs of requests that were merged from a fork. Other refs are
+// fetched from the same ref of the given remote. Only the refs under
+// refs/forks/ are force-updated, as the others may be local branches.
+//
+// If such a local branch already exists (e.g. because the request is aliased
+// to a commit that it does not include), then only the commits are fetched,
+// leaving the branch alone, since git refuses to update a branch that is
+// checked out or that has diverged from the remote one.
+func getReviewCodeSource(repo repository.Repo, remote, reviewRef string, source *provenance.Record) (string, string) {
+	fetchFrom := remote
+	if source != nil && source.Fork != "" {
+		fetchFrom = source.Fork
+	}
+	remoteRef := reviewRef
+	if strings.HasPrefix(reviewRef, forkRefPrefix) {
+		rest := strings.TrimPrefix(reviewRef, forkRefPrefix)
+		if strings.HasPrefix(rest, review.URLForkName) {
+			// The forks of `request --from-remote` are fetched from their URLs.
+			if i := strings.Index(rest[len(review.URLForkName):], "/"); i > 0 {
+				fork := rest[:len(review.URLForkName)+i]
+				if urls, err := repo.GetConfigValues(getForkURLsKey(fork)); err == nil && len(urls) > 0 {
+					fetchFrom = urls[0]
+					remoteRef = "refs/heads/" + rest[len(fork)+1:]
+				}
+			}
+		} else if i := strings.Index(rest, "/"); i > 0 {
+			if url, err := repo.GetConfig("remote." + rest[:i] + ".url"); err == nil && url != "" {
+				fetchFrom = rest[:i]
+				remoteRef = "refs/heads/" + rest[i+1:]
+			}
+		}
+	}
+	if strings.HasPrefix(reviewRef, forkRefPrefix) {
+		return fetchFrom, "+" + remoteRef + ":" + reviewRef
+	}
+	if exists, err := repo.HasRef(reviewRef); err == nil && exists {
+		return fetchFrom, remoteRef
+	}
+	return fetchFrom, remoteRef + ":" + reviewRef
+}
+
+// isReviewCodeMissing reports whether the review ref of the given review, or
+// the commit that its request is aliased to, does not exist locally.
+func isReviewCodeMissing(repo repository.Repo, summary review.Summary) bool {
+	if exists, err := repo.HasRef(summary.Request.ReviewRef); err != nil || !exists {
+		return true
+	}
+	return summary.Request.Alias != "" && repo.VerifyCommit(summary.Request.Alias) != nil
+}
+
+// fetchReviewCodeIfRequested fetches the missing review refs of open
+// reviews, if the user asked for that with --with-code or the
+// appraise.pull.withCode config.
+func fetchReviewCodeIfRequested(repo repository.Repo, remote string) error {
+	withCode := *pullWithCode
+	if !withCode {
+		configured, err := config.Get(repo, "appraise.pull.withCode")
 		if err != nil {
 			return err
 		}
-		fmt.Println("verified review:", revision)
+		withCode = configured == "true"
+	}
+	if withCode {
+		fetchReviewCode(repo, remote)
 	}
+	if *pullFetchMissing {
+		fetchMissingReviews(repo, remote)
+	}
+	return nil
+}
 
-	err = repo.MergeNotes(remote, notesRefPattern)
-	if err != nil {
-		return err
+// fetchReviewCode fetches the review refs of open reviews that do not exist
+// locally, or that do not include the commits their requests are aliased to.
+//
+// Failures are reported as warnings, since the review notes have already
+// been pulled, and the code of one review being unavailable should not
+// prevent fetching the code of the others.
+func fetchReviewCode(repo repository.Repo, remote string) {
+	for _, summary := range review.ListOpen(repo) {
+		reviewRef := summary.Request.ReviewRef
+		if reviewRef == "" || !isReviewCodeMissing(repo, summary) {
+			continue
+		}
+		var source *provenance.Record
+		if hash, err := summary.Request.Hash(); err == nil {
+			records := provenance.ParseAllValid(repo.GetNotes(provenance.Ref, summary.Revision))
+			source = provenance.RequestSource(records, hash)
+		}
+		fetchFrom, refSpec := getReviewCodeSource(repo, remote, reviewRef, source)
+		if err := repo.Fetch(fetchFrom, refSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to fetch %q for review %.12s from %q: %v\n",
+				reviewRef, summary.Revision, fetchFrom, err)
+			continue
+		}
+		output.Infof("Fetched %q for review %.12s from %q.\n", reviewRef, summary.Revision, fetchFrom)
+	}
+}
+
+// fetchMissingReviews fetches the commits of the reviews that are listed as
+// unfetched, by fetching their review refs, or, if those do not include the
+// reviewed commit, the commit itself.
+//
+// As with fetchReviewCode, failures are reported as warnings.
+func fetchMissingReviews(repo repository.Repo, remote string) {
+	for _, summary := range review.ListAll(repo) {
+		if !summary.Missing {
+			continue
+		}
+		var source *provenance.Record
+		if hash, err := summary.Request.Hash(); err == nil {
+			records := provenance.ParseAllValid(repo.GetNotes(provenance.Ref, summary.Revision))
+			source = provenance.RequestSource(records, hash)
+		}
+		fetchFrom := remote
+		if reviewRef := summary.Request.ReviewRef; reviewRef != "" {
+			var refSpec string
+			fetchFrom, refSpec = getReviewCodeSource(repo, remote, reviewRef, source)
+			if err := repo.Fetch(fetchFrom, refSpec); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: unable to fetch %q for review %.12s from %q: %v\n",
+					reviewRef, summary.Revision, fetchFrom, err)
+			}
+		}
+		if exists, err := repo.HasObject(summary.Revision); err == nil && exists {
+			output.Infof("Fetched review %.12s from %q.\n", summary.Revision, fetchFrom)
+			continue
+		}
+		if err := repo.Fetch(fetchFrom, summary.Revision); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to fetch the commit of review %.12s from %q: %v\n",
+				summary.Revision, fetchFrom, err)
+			continue
+		}
+		output.Infof("Fetched review %.12s from %q.\n", summary.Revision, fetchFrom)
 	}
-	return repo.MergeArchives(remote, archiveRefPattern)
 }
 
 var pullCmd = &Command{
@@ -87,7 +359,7 @@ var pullCmd = &Command{
 		fmt.Printf("Usage: %s pull [<option>] [<remote>]\n\nOptions:\n", arg0)
 		pullFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return pull(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return pull(ctx.Repo, args)
 	},
 }
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/metrics"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/provenance"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestGetReviewCodeSource(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.AddConfigValue("remote.alice.url", "git@example.com:alice/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddConfigValue(getForkURLsKey("url/0123"), "https://example.com/dave/repo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		reviewRef   string
+		source      *provenance.Record
+		wantRemote  string
+		wantRefSpec string
+	}{
+		{"refs/heads/feature", nil, "origin", "refs/heads/feature:refs/heads/feature"},
+		{"refs/heads/feature", &provenance.Record{Fork: "bob"}, "bob", "refs/heads/feature:refs/heads/feature"},
+		{repository.TestReviewRef, nil, "origin", repository.TestReviewRef},
+		{"refs/forks/alice/fix", nil, "alice", "+refs/heads/fix:refs/forks/alice/fix"},
+		{"refs/forks/example.com/carol/repo/fix", nil, "origin", "+refs/forks/example.com/carol/repo/fix:refs/forks/example.com/carol/repo/fix"},
+		{"refs/forks/url/0123/feature/x", nil, "https://example.com/dave/repo", "+refs/heads/feature/x:refs/forks/url/0123/feature/x"},
+	} {
+		remote, refSpec := getReviewCodeSource(repo, "origin", tc.reviewRef, tc.source)
+		if remote != tc.wantRemote || refSpec != tc.wantRefSpec {
+			t.Errorf("getReviewCodeSource(%q, %v) = %q, %q; want %q, %q",
+				tc.reviewRef, tc.source, remote, refSpec, tc.wantRemote, tc.wantRefSpec)
+		}
+	}
+}
+
+// repoRecordingPull records the writer that the progress of pulling notes is
+// reported to, and can interrupt the pull while the notes are being pulled.
+type repoRecordingPull struct {
+	repository.Repo
+	progress  io.Writer
+	interrupt bool
+}
+
+func (r *repoRecordingPull) PullNotesAndArchiveWithProgress(ctx context.Context, remote, notesRefPattern, archiveRefPattern string, progress io.Writer) error {
+	r.progress = progress
+	if !r.interrupt {
+		return nil
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+		return errors.New("the pull was not interrupted")
+	}
+}
+
+func TestPullProgress(t *testing.T) {
+	defer func() { *pullQuiet = false }()
+	repo := &repoRecordingPull{Repo: repository.NewMockRepoForTest()}
+	if err := pull(repo, []string{"origin"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.progress != os.Stderr {
+		t.Errorf("Unexpected progress writer: %v", repo.progress)
+	}
+	if err := pull(repo, []string{"--quiet", "origin"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.progress != nil {
+		t.Errorf("Unexpected progress writer with --quiet: %v", repo.progress)
+	}
+}
+
+func TestPullInterrupted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts can not be sent to the test process on Windows")
+	}
+	repo := &repoRecordingPull{Repo: repository.NewMockRepoForTest(), interrupt: true}
+	if err := pull(repo, []string{"origin"}); err != errPullInterrupted {
+		t.Errorf("Unexpected result of an interrupted pull: %v", err)
+	}
+}
+
+func TestPullMetrics(t *testing.T) {
+	repo := repoWithGitDir{&repoRecordingPull{Repo: repository.NewMockRepoForTest()}, t.TempDir()}
+	if err := pull(repo, []string{"origin"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.gitDir, metrics.StateFile)); !os.IsNotExist(err) {
+		t.Errorf("The pull was recorded even though the metrics are not enabled: %v", err)
+	}
+
+	if err := repo.SetConfig("appraise.metrics.enabled", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pull(repo, []string{"origin"}); err != nil {
+		t.Fatal(err)
+	}
+	// Unsigned requests, which fail verification.
+	for _, revision := range []string{repository.TestCommitE, repository.TestCommitJ} {
+		unsigned := request.New("alice@example.com", nil, "refs/heads/feature", "refs/heads/master", "Unsigned")
+		note, err := unsigned.Write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AppendNote(getQuarantinedNotesRef("alice", request.Ref), revision, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := verifyFetchedReviews(context.Background(), repo, "alice", []string{repository.TestCommitE, repository.TestCommitJ}, nil); err == nil {
+		t.Error("Unexpected verification of unsigned reviews")
+	}
+	state, err := metrics.ReadState(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pull, ok := state.Pulls["origin"]; !ok || !pull.Succeeded {
+		t.Errorf("The pull was not recorded: %v", state.Pulls)
+	}
+	if failures := state.Pulls["alice"].VerificationFailures; failures != 2 {
+		t.Errorf("Unexpected number of verification failures: %d", failures)
+	}
+}
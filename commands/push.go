@@ -19,6 +19,7 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 )
 
@@ -33,6 +34,22 @@ func push(repo repository.Repo, args []string) error {
 		remote = args[0]
 	}
 
+	notesURL, err := getNotesURL(repo, remote)
+	if err != nil {
+		return err
+	}
+	if notesURL != "" {
+		// Pushing first merges in the notes at the URL, which would bypass
+		// the quarantine of pulled notes.
+		quarantine, err := config.Get(repo, "appraise.pull.quarantine")
+		if err != nil {
+			return err
+		}
+		if quarantine == "true" {
+			return fmt.Errorf("Pushing to the remote %q is not supported while appraise.pull.quarantine is set, as its notes, which are synced with %q, would be merged without being quarantined", remote, redactURL(notesURL))
+		}
+		return pushNotesOverHTTP(repo, notesURL)
+	}
 	if err := repo.PushNotesAndArchive(remote, notesRefPattern, archiveRefPattern); err != nil {
 		return err
 	}
@@ -43,7 +60,7 @@ var pushCmd = &Command{
 	Usage: func(arg0 string) {
 		fmt.Printf("Usage: %s push [<remote>]\n", arg0)
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return push(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return push(ctx.Repo, args)
 	},
 }
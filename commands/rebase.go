@@ -20,7 +20,9 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
 
+	"github.com/google/git-appraise/commands/output"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
 )
@@ -31,6 +33,14 @@ var (
 	rebaseArchive = rebaseFlagSet.Bool("archive", true, "Prevent the original commit from being garbage collected.")
 	rebaseSign    = rebaseFlagSet.Bool("S", false,
 		"Sign the contents of the request after the rebase")
+	rebaseAll = rebaseFlagSet.Bool("all", false,
+		"Rebase every open review requested by the current user whose target has moved")
+	rebaseKeepCheckout = rebaseFlagSet.Bool("keep-checkout", false,
+		"Leave the review ref checked out, rather than restoring the previously checked out branch")
+	rebaseAllowUncommitted = rebaseFlagSet.Bool("allow-uncommitted", false,
+		"Allow uncommitted local changes")
+	rebaseStash = rebaseFlagSet.Bool("stash", false,
+		"Stash any uncommitted local changes, and restore them once the rebase is done")
 )
 
 // Validate that the user's request to rebase a review makes sense.
@@ -49,7 +59,7 @@ func validateRebaseRequest(repo repository.Repo, args []string) (*review.Review,
 		r, err = review.GetCurrent(repo)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load the review: %v\n", err)
+		return nil, fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return nil, errors.New("There is no matching review.")
@@ -71,6 +81,105 @@ func validateRebaseRequest(repo repository.Repo, args []string) (*review.Review,
 	return r, nil
 }
 
+// rebaseOneOfMany rebases a single review as part of a batch, aborting the
+// rebase if it fails so that the next review can still be attempted.
+func rebaseOneOfMany(repo repository.Repo, r *review.Review) error {
+	var err error
+	if *rebaseSign {
+		err = r.RebaseAndSign(*rebaseArchive)
+	} else {
+		err = r.Rebase(*rebaseArchive)
+	}
+	if err != nil {
+		if abortErr := repo.AbortRebase(); abortErr != nil {
+			return fmt.Errorf("%v; additionally, failed to abort the rebase: %v", err, abortErr)
+		}
+	}
+	return err
+}
+
+// Rebase all of the current user's open reviews onto their targets.
+//
+// Conflicts are reported for each review, without stopping the rest of the
+// reviews from being rebased. Once done, the original HEAD is checked out again.
+func rebaseAllReviews(repo repository.Repo, args []string) (err error) {
+	if len(args) > 0 {
+		return errors.New("A review can not be specified when rebasing all reviews.")
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	headRef, err := getOriginalHead(repo)
+	if err != nil {
+		return err
+	}
+	// The original HEAD is restored even if one of the reviews fails to load,
+	// as the previous rebases will have left another branch checked out.
+	defer func() {
+		if switchErr := repo.SwitchToRef(headRef); switchErr != nil {
+			if err == nil {
+				err = switchErr
+			} else {
+				err = fmt.Errorf("%v; additionally, failed to check out %q again: %v", err, headRef, switchErr)
+			}
+		}
+	}()
+
+	// The rebases are run non-interactively, accepting the default todo list.
+	// The user's own editor, if any, is restored afterwards.
+	previousEditor, hadEditor := os.LookupEnv("GIT_SEQUENCE_EDITOR")
+	if err := os.Setenv("GIT_SEQUENCE_EDITOR", ":"); err != nil {
+		return err
+	}
+	defer func() {
+		if hadEditor {
+			os.Setenv("GIT_SEQUENCE_EDITOR", previousEditor)
+		} else {
+			os.Unsetenv("GIT_SEQUENCE_EDITOR")
+		}
+	}()
+
+	attempted := 0
+	var failed []string
+	for _, summary := range review.ListOpen(repo) {
+		if summary.Request.Requester != userEmail || summary.Request.ReviewRef == "" {
+			continue
+		}
+		r, err := summary.Details()
+		if err != nil {
+			return err
+		}
+		if err := repo.VerifyGitRef(r.Request.TargetRef); err != nil {
+			fmt.Printf("Skipping review %.12s: %v\n", r.Revision, err)
+			continue
+		}
+		head, err := r.GetHeadCommit()
+		if err != nil {
+			return err
+		}
+		upToDate, err := repo.IsAncestor(r.Request.TargetRef, head)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			continue
+		}
+		attempted++
+		output.Infof("Rebasing review %.12s onto %q\n", r.Revision, r.Request.TargetRef)
+		if err := rebaseOneOfMany(repo, r); err != nil {
+			fmt.Printf("Failed to rebase review %.12s: %v\n", r.Revision, err)
+			failed = append(failed, r.Revision)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to rebase %d of %d reviews; rebase them individually to resolve the conflicts.", len(failed), attempted)
+	}
+	output.Infof("Rebased %d reviews\n", attempted)
+	return nil
+}
+
 // Rebase the current code review.
 //
 // The "args" parameter contains all of the command line arguments that followed the subcommand.
@@ -78,14 +187,38 @@ func rebaseReview(repo repository.Repo, args []string) error {
 	rebaseFlagSet.Parse(args)
 	args = rebaseFlagSet.Args()
 
+	if err := checkNoInterruptedOperation(repo); err != nil {
+		return err
+	}
+	warnAboutNotesConfig(repo, os.Stderr)
+	if *rebaseAll {
+		stashed, err := stashUncommittedChanges(repo, *rebaseAllowUncommitted, *rebaseStash)
+		if err != nil {
+			return err
+		}
+		if err := rebaseAllReviews(repo, args); err != nil {
+			return withStashNotice(err, stashed)
+		}
+		return restoreStash(repo, stashed)
+	}
 	r, err := validateRebaseRequest(repo, args)
 	if err != nil {
 		return err
 	}
-	if *rebaseSign {
-		return r.RebaseAndSign(*rebaseArchive)
+	stashed, err := stashUncommittedChanges(repo, *rebaseAllowUncommitted, *rebaseStash)
+	if err != nil {
+		return err
+	}
+	op := &interruptedOperation{
+		Command:      "rebase",
+		Sign:         *rebaseSign,
+		KeepCheckout: *rebaseKeepCheckout,
+		Stashed:      stashed,
+	}
+	if err := rebaseWithRecovery(repo, r, op, *rebaseArchive); err != nil {
+		return err
 	}
-	return r.Rebase(*rebaseArchive)
+	return restoreStash(repo, stashed)
 }
 
 // rebaseCmd defines the "rebase" subcommand.
@@ -94,7 +227,7 @@ var rebaseCmd = &Command{
 		fmt.Printf("Usage: %s rebase [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
 		rebaseFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return rebaseReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return rebaseReview(ctx.Repo, args)
 	},
 }
@@ -0,0 +1,233 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+// repoFailingAfterCheckout simulates a rebase that leaves another branch
+// checked out, followed by an error for the next review.
+type repoFailingAfterCheckout struct {
+	repository.Repo
+}
+
+func (r repoFailingAfterCheckout) GetUserEmail() (string, error) { return "ojarjur", nil }
+
+func (r repoFailingAfterCheckout) IsAncestor(ancestor, descendant string) (bool, error) {
+	if err := r.SwitchToRef(repository.TestReviewRef); err != nil {
+		return false, err
+	}
+	return false, errors.New("failed to compare the commits")
+}
+
+func TestRebaseAllReviewsRestoresHead(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	original, err := repo.GetHeadRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rebaseAllReviews(repoFailingAfterCheckout{repo}, nil); err == nil {
+		t.Fatal("Unexpected success rebasing the reviews")
+	}
+	if head, _ := repo.GetHeadRef(); head != original {
+		t.Errorf("Unexpected HEAD after a failed rebase: %q, want %q", head, original)
+	}
+}
+
+func TestRebaseAllReviewsRestoresSequenceEditor(t *testing.T) {
+	t.Setenv("GIT_SEQUENCE_EDITOR", "vim")
+	repo := repository.NewMockRepoForTest()
+	rebaseAllReviews(repoFailingAfterCheckout{repo}, nil)
+	if editor := os.Getenv("GIT_SEQUENCE_EDITOR"); editor != "vim" {
+		t.Errorf("Unexpected GIT_SEQUENCE_EDITOR after rebasing: %q", editor)
+	}
+}
+
+// repoWithDetachedHead fails to resolve HEAD to a ref, as git does when a
+// commit rather than a branch is checked out.
+type repoWithDetachedHead struct {
+	repoFailingAfterCheckout
+}
+
+func (r repoWithDetachedHead) GetHeadRef() (string, error) {
+	return "", errors.New("ref HEAD is not a symbolic ref")
+}
+
+func TestRebaseAllReviewsFromDetachedHead(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SwitchToRef(repository.TestCommitB); err != nil {
+		t.Fatal(err)
+	}
+	err := rebaseAllReviews(repoWithDetachedHead{repoFailingAfterCheckout{repo}}, nil)
+	if err == nil || err.Error() != "failed to compare the commits" {
+		t.Fatalf("Unexpected error rebasing from a detached HEAD: %v", err)
+	}
+	if head, _ := repo.GetHeadRef(); head != repository.TestCommitB {
+		t.Errorf("Unexpected HEAD after rebasing from a detached HEAD: %q, want %q", head, repository.TestCommitB)
+	}
+}
+
+// repoWithGitDir keeps the state of interrupted operations in a temporary
+// directory.
+type repoWithGitDir struct {
+	repository.Repo
+	gitDir string
+}
+
+func (r repoWithGitDir) GetGitDir() string { return r.gitDir }
+
+func TestRebaseRestoresCheckout(t *testing.T) {
+	for _, keepCheckout := range []bool{false, true} {
+		repo := repoWithGitDir{repository.NewMockRepoForTest(), t.TempDir()}
+		if err := repo.SwitchToRef(repository.TestAlternateReviewRef); err != nil {
+			t.Fatal(err)
+		}
+		r, err := review.Get(repo, repository.TestCommitG)
+		if err != nil {
+			t.Fatal(err)
+		}
+		op := &interruptedOperation{Command: "rebase", KeepCheckout: keepCheckout}
+		if err := rebaseWithRecovery(repo, r, op, false); err != nil {
+			t.Fatal(err)
+		}
+		expected := repository.TestAlternateReviewRef
+		if keepCheckout {
+			expected = r.Request.ReviewRef
+		}
+		if head, _ := repo.GetHeadRef(); head != expected {
+			t.Errorf("Unexpected HEAD after a rebase with keepCheckout %v: %q, want %q", keepCheckout, head, expected)
+		}
+	}
+}
+
+// repoWithConflictingRebase stops every rebase part way through, as git does
+// when a commit conflicts, until the rebase is continued or aborted.
+type repoWithConflictingRebase struct {
+	repository.Repo
+	gitDir    string
+	rebasing  bool
+	continued bool
+	aborted   bool
+	popped    bool
+}
+
+func (r *repoWithConflictingRebase) GetGitDir() string { return r.gitDir }
+
+func (r *repoWithConflictingRebase) RebaseRef(ref string) error {
+	r.rebasing = true
+	return errors.New("could not apply a commit")
+}
+
+func (r *repoWithConflictingRebase) IsRebaseInProgress() (bool, error) { return r.rebasing, nil }
+
+func (r *repoWithConflictingRebase) ContinueRebase() error {
+	r.rebasing = false
+	r.continued = true
+	return r.Repo.RebaseRef(repository.TestTargetRef)
+}
+
+func (r *repoWithConflictingRebase) AbortRebase() error {
+	r.rebasing = false
+	r.aborted = true
+	return nil
+}
+
+func (r *repoWithConflictingRebase) PopStash() error {
+	r.popped = true
+	return nil
+}
+
+// startConflictingRebase starts a rebase of the pending review G that stops
+// part way through.
+func startConflictingRebase(t *testing.T) *repoWithConflictingRebase {
+	repo := &repoWithConflictingRebase{Repo: repository.NewMockRepoForTest(), gitDir: t.TempDir()}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := &interruptedOperation{Command: "rebase", Stashed: true}
+	err = rebaseWithRecovery(repo, r, op, false)
+	if err == nil || !strings.Contains(err.Error(), "git appraise continue") {
+		t.Fatalf("Unexpected result of a conflicting rebase: %v", err)
+	}
+	if err := checkNoInterruptedOperation(repo); err == nil || !strings.Contains(err.Error(), "was interrupted") {
+		t.Fatalf("Unexpected state after a conflicting rebase: %v", err)
+	}
+	return repo
+}
+
+func TestContinueOperation(t *testing.T) {
+	repo := startConflictingRebase(t)
+	if err := continueOperation(repo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.continued || repo.aborted {
+		t.Errorf("Unexpected handling of the rebase: continued %v, aborted %v", repo.continued, repo.aborted)
+	}
+	if head, _ := repo.GetHeadRef(); head != repository.TestTargetRef {
+		t.Errorf("Unexpected HEAD after continuing: %q", head)
+	}
+	if !repo.popped {
+		t.Error("The stashed changes were not restored")
+	}
+	if err := checkNoInterruptedOperation(repo); err != nil {
+		t.Errorf("Unexpected state after continuing: %v", err)
+	}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebased, err := repo.IsAncestor(repository.TestTargetRef, r.Request.Alias); err != nil || !rebased {
+		t.Errorf("The rebase was not recorded in the request: %q, %v", r.Request.Alias, err)
+	}
+	if err := continueOperation(repo, nil); err == nil {
+		t.Error("Unexpected success continuing when no operation was interrupted")
+	}
+}
+
+func TestAbortOperation(t *testing.T) {
+	repo := startConflictingRebase(t)
+	if err := abortOperation(repo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.aborted || repo.continued {
+		t.Errorf("Unexpected handling of the rebase: continued %v, aborted %v", repo.continued, repo.aborted)
+	}
+	if head, _ := repo.GetHeadRef(); head != repository.TestTargetRef {
+		t.Errorf("Unexpected HEAD after aborting: %q", head)
+	}
+	if !repo.popped {
+		t.Error("The stashed changes were not restored")
+	}
+	if err := checkNoInterruptedOperation(repo); err != nil {
+		t.Errorf("Unexpected state after aborting: %v", err)
+	}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Request.Alias != "" {
+		t.Errorf("Unexpected rebase recorded after aborting: %q", r.Request.Alias)
+	}
+}
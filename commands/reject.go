@@ -20,8 +20,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
 	"github.com/google/git-appraise/review/comment"
@@ -32,12 +34,39 @@ var rejectFlagSet = flag.NewFlagSet("reject", flag.ExitOnError)
 
 var (
 	rejectMessageFile = rejectFlagSet.String("F", "", "Take the comment from the given file. Use - to read the message from the standard input")
-	rejectMessage     = rejectFlagSet.String("m", "", "Message to attach to the review")
+	rejectMessages    input.Messages
 
 	rejectSign = rejectFlagSet.Bool("S", false,
 		"Sign the contents of the rejection")
+	rejectReason = rejectFlagSet.String("reason", "",
+		"Reason for the rejection: one of "+strings.Join(comment.Reasons, ", "))
 )
 
+// rejectRequireMessageConfig is the git config key that, unless set to
+// false, makes rejecting a review require a message saying why.
+const rejectRequireMessageConfig = "appraise.reject.requireMessage"
+
+// checkRejectMessage returns an error if the given rejection message is empty
+// and the repo requires one.
+func checkRejectMessage(repo repository.Repo, message string) error {
+	if strings.TrimSpace(message) != "" {
+		return nil
+	}
+	required, err := config.Get(repo, rejectRequireMessageConfig)
+	if err != nil {
+		return err
+	}
+	if required == "false" {
+		return nil
+	}
+	return fmt.Errorf("a rejection must explain what needs to change; "+
+		"use -m or -F, or allow empty rejections with `git config %s false`", rejectRequireMessageConfig)
+}
+
+func init() {
+	rejectFlagSet.Var(&rejectMessages, "m", "`Message` to attach to the review. If given multiple times, each becomes a separate paragraph; combined with -F, the file is used as the body")
+}
+
 // rejectReview adds an NMW comment to the current code review.
 func rejectReview(repo repository.Repo, args []string) error {
 	rejectFlagSet.Parse(args)
@@ -56,7 +85,7 @@ func rejectReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
@@ -65,19 +94,24 @@ func rejectReview(repo repository.Repo, args []string) error {
 	if r.Request.TargetRef == "" {
 		return errors.New("The review was abandoned.")
 	}
+	if err := comment.CheckReason(*rejectReason); err != nil {
+		return err
+	}
 
-	if *rejectMessageFile != "" && *rejectMessage == "" {
-		*rejectMessage, err = input.FromFile(*rejectMessageFile)
-		if err != nil {
-			return err
-		}
+	message, err := input.AssembleMessage(rejectMessages, *rejectMessageFile)
+	if err != nil {
+		return err
 	}
-	if *rejectMessageFile == "" && *rejectMessage == "" {
-		*rejectMessage, err = input.LaunchEditor(repo, commentFilename)
+	if *rejectMessageFile == "" && message == "" {
+		message, err = input.LaunchEditor(repo, commentFilename,
+			fmt.Sprintf("Please explain why review %.12s is being rejected.", r.Revision))
 		if err != nil {
 			return err
 		}
 	}
+	if err := checkRejectMessage(repo, message); err != nil {
+		return err
+	}
 
 	rejectedCommit, err := r.GetHeadCommit()
 	if err != nil {
@@ -91,9 +125,10 @@ func rejectReview(repo repository.Repo, args []string) error {
 	if err != nil {
 		return err
 	}
-	c := comment.New(userEmail, *rejectMessage)
+	c := comment.New(userEmail, message)
 	c.Location = &location
 	c.Resolved = &resolved
+	c.Reason = *rejectReason
 	if *rejectSign {
 		key, err := repo.GetUserSigningKey()
 		if err != nil {
@@ -104,7 +139,11 @@ func rejectReview(repo repository.Repo, args []string) error {
 			return err
 		}
 	}
-	return r.AddComment(c)
+	if err := r.AddComment(c); err != nil {
+		return err
+	}
+	notifyReview(repo, r.Revision, "rejected")
+	return nil
 }
 
 // rejectCmd defines the "reject" subcommand.
@@ -113,7 +152,7 @@ var rejectCmd = &Command{
 		fmt.Printf("Usage: %s reject [<option>...] [<commit>]\n\nOptions:\n", arg0)
 		rejectFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return rejectReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return rejectReview(ctx.Repo, args)
 	},
 }
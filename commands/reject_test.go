@@ -0,0 +1,39 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestCheckRejectMessage(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := checkRejectMessage(repo, "Please add tests."); err != nil {
+		t.Errorf("Unexpected error for a rejection with a message: %v", err)
+	}
+	if err := checkRejectMessage(repo, " \n"); err == nil {
+		t.Error("Unexpected success rejecting without a message")
+	}
+	if err := repo.AddConfigValue(rejectRequireMessageConfig, "false"); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRejectMessage(repo, ""); err != nil {
+		t.Errorf("Unexpected error for an empty rejection that is allowed: %v", err)
+	}
+}
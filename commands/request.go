@@ -17,18 +17,27 @@ limitations under the License.
 package commands
 
 import (
+	"crypto/sha1"
 	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/google/git-appraise/commands/input"
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
 	"github.com/google/git-appraise/review/gpg"
 	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/teams"
 )
 
+// defaultTargetRef is the target of a review when none is specified or configured.
+const defaultTargetRef = "refs/heads/master"
+
 // Template for the "request" subcommand's output.
 const requestSummaryTemplate = `Review requested:
 Commit: %s
@@ -41,31 +50,210 @@ var requestFlagSet = flag.NewFlagSet("request", flag.ExitOnError)
 
 var (
 	requestMessageFile      = requestFlagSet.String("F", "", "Take the comment from the given file. Use - to read the message from the standard input")
-	requestMessage          = requestFlagSet.String("m", "", "Message to attach to the review")
-	requestReviewers        = requestFlagSet.String("r", "", "Comma-separated list of reviewers")
+	requestMessages         input.Messages
+	requestReviewers        = requestFlagSet.String("r", "", "Comma-separated list of reviewers; use team:<name> for the members of a team defined in "+teams.File)
+	requestCC               = requestFlagSet.String("cc", "", "Comma-separated list of people to notify of the review, without asking them to review it")
+	requestLabels           = requestFlagSet.String("labels", "", "Comma-separated list of labels of the review, e.g. urgent; see "+review.QueueLabelsConfig)
 	requestSource           = requestFlagSet.String("source", "HEAD", "Revision to review")
-	requestTarget           = requestFlagSet.String("target", "refs/heads/master", "Revision against which to review")
+	requestTarget           = requestFlagSet.String("target", "", "Revision against which to review; defaults to the value of appraise.target, or "+defaultTargetRef)
 	requestQuiet            = requestFlagSet.Bool("quiet", false, "Suppress review summary output")
 	requestAllowUncommitted = requestFlagSet.Bool("allow-uncommitted", false, "Allow uncommitted local changes.")
 	requestSign             = requestFlagSet.Bool("S", false, "GPG sign the content of the request")
 	requestDate             = requestFlagSet.String("date", "", "request date")
+	requestDue              = requestFlagSet.String("due", "", "Deadline for the review, either as a date or as a duration after the request date (e.g. \"3d\" or \"36h\")")
+	requestTag              = requestFlagSet.String("tag", "", "Request a release review of the source revision, which creates the given tag once submitted")
+	requestFromRemote       = requestFlagSet.String("from-remote", "", "URL of a repository (e.g. a contributor's fork) from which to fetch the branch to review, given as the only argument")
+	requestWIP              = requestFlagSet.Bool("wip", false, "Mark the review as a work in progress, which is hidden from the default lists of reviewers until it is marked as ready")
+	requestReady            = requestFlagSet.Bool("ready", false, "Mark the given (or current) work-in-progress review as ready to be reviewed")
+	requestAllowForeign     = requestFlagSet.Bool("allow-foreign", false, "Anchor the review at a commit that was committed by someone else without a warning")
+	requestFindReviewed     = requestFlagSet.Bool("find-reviewed", false, "Find and record the commits whose changes were already accepted in another review; always done if "+review.NovelOnlyConfig+" is set")
+	requestDescribe         = requestFlagSet.String("describe", describeSeries,
+		"How to describe a review of several commits, unless a message is given: \""+describeSeries+"\" for a summary of every commit, or \""+describeFirst+"\" for the message of the first commit")
 )
 
-// Build the template review request based solely on the parsed flag values.
-func buildRequestFromFlags(requester string) (request.Request, error) {
-	var reviewers []string
-	if len(*requestReviewers) > 0 {
-		for _, reviewer := range strings.Split(*requestReviewers, ",") {
-			reviewers = append(reviewers, strings.TrimSpace(reviewer))
+func init() {
+	requestFlagSet.Var(&requestMessages, "m", "`Message` to attach to the review. If given multiple times, each becomes a separate paragraph; combined with -F, the file is used as the body")
+}
+
+// forkRefPrefix is the prefix of the refs into which branches fetched with
+// --from-remote are stored.
+const forkRefPrefix = review.ForkRefPrefix
+
+// getDefaultTarget returns the target of a review when none is specified.
+func getDefaultTarget(repo repository.Repo) (string, error) {
+	configuredTarget, err := repo.GetDefaultTargetRef()
+	if err == nil && configuredTarget == "" {
+		configuredTarget, err = config.FromFile(repo, "appraise.target")
+	}
+	if err != nil {
+		return "", err
+	}
+	if configuredTarget == "" {
+		return defaultTargetRef, nil
+	}
+	return configuredTarget, nil
+}
+
+// getForkRef returns the name of the fork that the given branch of the
+// repository at the given URL is fetched from, and the local ref into which
+// it is fetched.
+//
+// The fork is named after a hash of the host and path of the URL, so that for
+// example both "https://github.com/user/repo.git" and
+// "git@github.com:user/repo" map to the same fork, and its refs can not
+// collide with those of a fork added with `fork add`.
+func getForkRef(url, branch string) (string, string, error) {
+	name := url
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+len("://"):]
+	} else {
+		// An scp-like address, e.g. "git@github.com:user/repo".
+		name = strings.Replace(name, ":", "/", 1)
+	}
+	if i := strings.Index(name, "@"); i >= 0 && i < strings.Index(name, "/") {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		// Drop any port from the host.
+		host := name[:i]
+		if j := strings.Index(host, ":"); j >= 0 {
+			host = host[:j]
+		}
+		name = host + name[i:]
+	}
+	name = strings.Trim(strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git"), "/")
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	if name == "" || branch == "" || strings.Contains(name, "..") {
+		return "", "", fmt.Errorf("Unable to derive a fork ref from %q and %q", url, branch)
+	}
+	fork := review.URLForkName + fmt.Sprintf("%x", sha1.Sum([]byte(name)))
+	return fork, getForkRefsPrefix(fork) + branch, nil
+}
+
+// fetchFromRemote fetches the given branch from the repository at the
+// given URL, and returns the local ref that it was fetched into.
+//
+// The URL is registered as a fork, if it is not already, so that the branch
+// can be fetched again and pruned with the fork commands.
+func fetchFromRemote(repo repository.Repo, url string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("You must specify exactly one branch to fetch with --from-remote.")
+	}
+	if strings.HasPrefix(url, "-") {
+		return "", fmt.Errorf("Refusing to fetch from %q, as it looks like an option rather than a URL.", url)
+	}
+	fork, forkRef, err := getForkRef(url, args[0])
+	if err != nil {
+		return "", err
+	}
+	branchRef := "refs/heads/" + strings.TrimPrefix(args[0], "refs/heads/")
+	if err := repo.Fetch(url, "+"+branchRef+":"+forkRef); err != nil {
+		return "", fmt.Errorf("Failed to fetch %q from %q: %v", branchRef, url, err)
+	}
+	urls, err := repo.GetConfigValues(getForkURLsKey(fork))
+	if err != nil {
+		return "", err
+	}
+	for _, registered := range urls {
+		if registered == url {
+			return forkRef, nil
+		}
+	}
+	if err := repo.AddConfigValue(getForkURLsKey(fork), url); err != nil {
+		return "", err
+	}
+	output.Infof("Registered %q as the fork %q.\n", url, fork)
+	return forkRef, nil
+}
+
+// splitPeople splits a comma-separated list of people.
+func splitPeople(list string) []string {
+	var people []string
+	if len(list) > 0 {
+		for _, person := range strings.Split(list, ",") {
+			people = append(people, strings.TrimSpace(person))
 		}
 	}
-	if *requestMessageFile != "" && *requestMessage == "" {
-		var err error
-		*requestMessage, err = input.FromFile(*requestMessageFile)
+	return people
+}
+
+// withoutReviewers returns the given CC list, other than the reviewers, who
+// are already notified as such.
+func withoutReviewers(cc, reviewers []string) []string {
+	var filtered []string
+	for _, person := range cc {
+		if !containsString(reviewers, person) && !containsString(filtered, person) {
+			filtered = append(filtered, person)
+		}
+	}
+	return filtered
+}
+
+// The strategies for describing a review of several commits.
+const (
+	describeSeries = "series"
+	describeFirst  = "first"
+)
+
+// splitCommitMessage splits a commit message into its subject and its body.
+func splitCommitMessage(message string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+// checkDescribeStrategy returns an error if the given strategy for describing
+// a review of several commits is not known.
+func checkDescribeStrategy(strategy string) error {
+	if strategy != describeSeries && strategy != describeFirst {
+		return fmt.Errorf("Unknown description strategy %q; expected %q or %q.", strategy, describeSeries, describeFirst)
+	}
+	return nil
+}
+
+// describeCommits returns the description of a review of the given commits,
+// oldest first, according to the given strategy.
+//
+// A series of commits is described by the subject of the first commit, as
+// the title, followed by a list of the subjects of the others, and then by
+// the bodies of all of them.
+func describeCommits(repo repository.Repo, commits []string, strategy string) (string, error) {
+	if err := checkDescribeStrategy(strategy); err != nil {
+		return "", err
+	}
+	if strategy == describeFirst || len(commits) == 1 {
+		return repo.GetCommitMessage(commits[0])
+	}
+	var title string
+	var subjects, bodies []string
+	for i, commit := range commits {
+		message, err := repo.GetCommitMessage(commit)
 		if err != nil {
-			return request.Request{}, err
+			return "", err
+		}
+		subject, body := splitCommitMessage(message)
+		if i == 0 {
+			title = subject
+		} else {
+			subjects = append(subjects, "- "+subject)
+		}
+		if body != "" {
+			bodies = append(bodies, body)
 		}
 	}
+	sections := append([]string{title, strings.Join(subjects, "\n")}, bodies...)
+	return strings.Join(sections, "\n\n") + "\n", nil
+}
+
+// Build the template review request based solely on the parsed flag values.
+func buildRequestFromFlags(requester string) (request.Request, error) {
+	reviewers := splitPeople(*requestReviewers)
+	message, err := input.AssembleMessage(requestMessages, *requestMessageFile)
+	if err != nil {
+		return request.Request{}, err
+	}
 
 	date, err := GetDate(*requestDate)
 	if err != nil {
@@ -77,10 +265,19 @@ func buildRequestFromFlags(requester string) (request.Request, error) {
 	}
 	timestamp := FormatDate(date)
 
-	req := request.New(requester, reviewers, *requestSource, *requestTarget, *requestMessage)
+	req := request.New(requester, reviewers, *requestSource, *requestTarget, message)
+	req.CC = splitPeople(*requestCC)
+	req.Labels = splitPeople(*requestLabels)
 	if len(timestamp) > 0 {
 		req.Timestamp = timestamp
 	}
+	if *requestDue != "" {
+		due, err := GetDueDate(*requestDue, *date)
+		if err != nil {
+			return request.Request{}, err
+		}
+		req.DueBy = FormatDate(due)
+	}
 	return req, nil
 }
 
@@ -111,6 +308,29 @@ func getReviewCommit(repo repository.Repo, r request.Request, args []string) (st
 	return reviewCommits[0], base, nil
 }
 
+// Get the commit at which a release review request should be anchored.
+//
+// Release reviews cover a single, fixed candidate commit, so the review ref
+// is cleared once the candidate has been resolved.
+func getReleaseCommit(repo repository.Repo, r *request.Request, args []string) (string, string, error) {
+	if len(args) > 0 {
+		return "", "", errors.New("Updating an existing review is not supported for release reviews.")
+	}
+	if err := repo.VerifyGitRef("refs/tags/" + r.TargetTag); err == nil {
+		return "", "", fmt.Errorf("The tag %q already exists.", r.TargetTag)
+	}
+	candidate, err := repo.GetCommitHash(r.ReviewRef)
+	if err != nil {
+		return "", "", err
+	}
+	base, err := repo.MergeBase(r.TargetRef, candidate)
+	if err != nil {
+		return "", "", err
+	}
+	r.ReviewRef = ""
+	return candidate, base, nil
+}
+
 // Create a new code review request.
 //
 // The "args" parameter is all of the command line arguments that followed the subcommand.
@@ -118,7 +338,28 @@ func requestReview(repo repository.Repo, args []string) error {
 	requestFlagSet.Parse(args)
 	args = requestFlagSet.Args()
 
-	if !*requestAllowUncommitted {
+	// The strategy is only used when no message is given, but a mistyped one
+	// is still reported rather than silently ignored.
+	if err := checkDescribeStrategy(*requestDescribe); err != nil {
+		return err
+	}
+	if *requestReady {
+		if *requestWIP {
+			return errors.New("The --wip and --ready flags can not be combined.")
+		}
+		return markReviewReady(repo, args)
+	}
+	if *requestFromRemote != "" {
+		if *requestTag != "" {
+			return errors.New("The --from-remote and --tag flags can not be combined.")
+		}
+		forkRef, err := fetchFromRemote(repo, *requestFromRemote, args)
+		if err != nil {
+			return err
+		}
+		*requestSource = forkRef
+		args = nil
+	} else if !*requestAllowUncommitted {
 		// Requesting a code review with uncommited local changes is usually a mistake, so
 		// we want to report that to the user instead of creating the request.
 		hasUncommitted, err := repo.HasUncommittedChanges()
@@ -138,6 +379,16 @@ func requestReview(repo repository.Repo, args []string) error {
 	if err != nil {
 		return err
 	}
+	if r.TargetRef == "" {
+		if r.TargetRef, err = getDefaultTarget(repo); err != nil {
+			return err
+		}
+	}
+	r.Reviewers, r.ReviewerTeams, err = teams.Expand(repo, r.TargetRef, r.Reviewers)
+	if err != nil {
+		return err
+	}
+	r.CC = withoutReviewers(r.CC, r.Reviewers)
 	if r.ReviewRef == "HEAD" {
 		headRef, err := repo.GetHeadRef()
 		if err != nil {
@@ -152,20 +403,52 @@ func requestReview(repo repository.Repo, args []string) error {
 		return err
 	}
 
-	reviewCommit, baseCommit, err := getReviewCommit(repo, r, args)
+	var reviewCommit, baseCommit string
+	if *requestTag != "" {
+		r.TargetTag = *requestTag
+		reviewCommit, baseCommit, err = getReleaseCommit(repo, &r, args)
+	} else {
+		reviewCommit, baseCommit, err = getReviewCommit(repo, r, args)
+	}
 	if err != nil {
 		return err
 	}
 	r.BaseCommit = baseCommit
+	r.WIP = *requestWIP
+	if r.ForeignCommitter, err = review.GetForeignCommitter(repo, reviewCommit, r.Requester); err != nil {
+		return err
+	}
+	if r.ForeignCommitter != "" && !*requestAllowForeign && *requestFromRemote == "" {
+		fmt.Fprintf(os.Stderr, "Warning: commit %.12s was committed by %s, not by you; reviews of other people's commits may be distrusted. Use --allow-foreign to request it anyway without this warning.\n", reviewCommit, r.ForeignCommitter)
+	}
+	commits := []string{reviewCommit}
+	if r.ReviewRef != "" {
+		if series, err := repo.ListCommitsBetween(baseCommit, r.ReviewRef); err == nil && len(series) > 0 {
+			commits = series
+		}
+	}
+	if err := lintCommits(repo, commits); err != nil {
+		return err
+	}
+	novelOnly, err := config.Get(repo, review.NovelOnlyConfig)
+	if err != nil {
+		return err
+	}
+	if *requestFindReviewed || novelOnly == "true" {
+		if r.PreviouslyReviewed, err = findPreviouslyReviewed(repo, commits, reviewCommit, *requestQuiet); err != nil {
+			return err
+		}
+	}
 	if r.Description == "" {
-		description, err := repo.GetCommitMessage(reviewCommit)
+		description, err := describeCommits(repo, commits, *requestDescribe)
 		if err != nil {
 			return err
 		}
 		r.Description = description
 	}
+	var key string
 	if *requestSign {
-		key, err := repo.GetUserSigningKey()
+		key, err = repo.GetUserSigningKey()
 		if err != nil {
 			return err
 		}
@@ -174,13 +457,194 @@ func requestReview(repo repository.Repo, args []string) error {
 			return err
 		}
 	}
+	// The size limits are checked before the request is written, so that an
+	// invalid limit does not leave a request behind along with the error.
+	size, sizeWarnings, err := checkRequestSize(repo, baseCommit, r.ReviewRef)
+	if err != nil {
+		return err
+	}
 	note, err := r.Write()
 	if err != nil {
 		return err
 	}
 	repo.AppendNote(request.Ref, reviewCommit, note)
+	notifyReview(repo, reviewCommit, "requested")
+	if !*requestQuiet {
+		output.Infof(requestSummaryTemplate, reviewCommit, r.TargetRef, r.ReviewRef, r.Description)
+		if r.TargetTag != "" {
+			output.Infof("Target Tag: %s\n", r.TargetTag)
+		}
+	}
+	if novelOnly == "true" && len(r.PreviouslyReviewed) == len(commits) {
+		if err := acceptPreviouslyReviewed(repo, reviewCommit, r.PreviouslyReviewed, userEmail, key); err != nil {
+			return err
+		}
+	}
+	reportRequestSize(repo, size, sizeWarnings, *requestQuiet)
+	return nil
+}
+
+// findPreviouslyReviewed returns, for each of the given commits of a review
+// whose changes were already accepted in another review, the revision of
+// that review, and reports them unless quiet.
+func findPreviouslyReviewed(repo repository.Repo, commits []string, reviewCommit string, quiet bool) (map[string]string, error) {
+	index, err := review.NewPatchIDIndex(repo)
+	if err != nil {
+		return nil, err
+	}
+	found, err := index.FindReviewed(commits, reviewCommit)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	previouslyReviewed := make(map[string]string)
+	for _, commit := range commits {
+		if reviewed, ok := found[commit]; ok {
+			previouslyReviewed[commit] = reviewed.Review
+			if !quiet {
+				output.Infof("Commit %.12s was already accepted as %.12s in review %.12s.\n", commit, reviewed.Commit, reviewed.Review)
+			}
+		}
+	}
+	return previouslyReviewed, nil
+}
+
+// acceptPreviouslyReviewed accepts a newly requested review whose changes
+// were all already accepted in the given other reviews, keyed by commit, as
+// only novel changes need reviewing.
+//
+// Since the acceptance is written by the requester, the other reviews must
+// have been accepted in a way that lets their changes be reused.
+func acceptPreviouslyReviewed(repo repository.Repo, reviewCommit string, previouslyReviewed map[string]string, userEmail, key string) error {
+	checked := make(map[string]bool)
+	for _, revision := range previouslyReviewed {
+		if checked[revision] {
+			continue
+		}
+		checked[revision] = true
+		previous, err := review.Get(repo, revision)
+		if err == nil && previous == nil {
+			err = fmt.Errorf("review %.12s was not found", revision)
+		}
+		if err == nil {
+			err = checkAcceptedForReuse(repo, previous)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not accepting the review automatically, as %v.\n", err)
+			return nil
+		}
+	}
+	r, err := review.Get(repo, reviewCommit)
+	if err != nil {
+		return err
+	}
+	if err := acceptAutomatically(r, userEmail, "Accepted automatically, as every change was already accepted in another review.", key); err != nil {
+		return err
+	}
+	output.Infof("The review was accepted automatically, as set by %s.\n", review.NovelOnlyConfig)
+	return nil
+}
+
+// lintCommits warns about the commits of a review whose messages fail the
+// configured checks, and returns an error if the policy is to fail.
+func lintCommits(repo repository.Repo, commits []string) error {
+	rules, err := review.GetLintRules(repo)
+	if err != nil {
+		return err
+	}
+	problems, err := rules.LintCommits(repo, commits)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: commit %.12s: %s.\n", p.Commit, p.Problem)
+	}
+	if rules.Fail && len(problems) > 0 {
+		return fmt.Errorf("%d commit message check(s) failed, and %s is \"fail\"", len(problems), review.LintPolicyConfig)
+	}
+	return nil
+}
+
+// checkRequestSize computes the size of the review being requested, and
+// returns a warning for each configured size limit that it exceeds.
+//
+// Failing to compute the size is not an error, in which case the size is
+// nil, but an invalid size limit is.
+func checkRequestSize(repo repository.Repo, baseCommit, reviewRef string) (*review.Size, []string, error) {
+	if baseCommit == "" {
+		return nil, nil, nil
+	}
+	size, err := review.GetSize(repo, baseCommit, reviewRef)
+	if err != nil {
+		return nil, nil, nil
+	}
+	warnings, err := review.CheckSize(repo, *size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return size, warnings, nil
+}
+
+// reportRequestSize prints the size of a newly requested review, and warns
+// if it is large enough that it should be split into smaller reviews.
+func reportRequestSize(repo repository.Repo, size *review.Size, warnings []string, quiet bool) {
+	if size != nil && !quiet {
+		output.Infof("Size: %s\n", output.FormatSize(repo, *size))
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s; consider splitting it into smaller reviews.\n", warning)
+	}
+}
+
+// markReviewReady marks a work-in-progress review as ready to be reviewed, by
+// adding a copy of its latest request without the WIP flag.
+func markReviewReady(repo repository.Repo, args []string) error {
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only marking a single review as ready is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+	if !r.IsDraft() {
+		return fmt.Errorf("The review %.12s is not an open work-in-progress review.", r.Revision)
+	}
+	now := time.Now()
+	ready := r.Request
+	ready.WIP = false
+	ready.Timestamp = FormatDate(&now)
+	ready.Sig = gpg.Sig{}
+	if *requestSign {
+		key, err := repo.GetUserSigningKey()
+		if err != nil {
+			return err
+		}
+		if err := gpg.Sign(key, &ready); err != nil {
+			return err
+		}
+	}
+	note, err := ready.Write()
+	if err != nil {
+		return err
+	}
+	if err := repo.AppendNote(request.Ref, r.Revision, note); err != nil {
+		return err
+	}
+	notifyReview(repo, r.Revision, "marked as ready")
 	if !*requestQuiet {
-		fmt.Printf(requestSummaryTemplate, reviewCommit, r.TargetRef, r.ReviewRef, r.Description)
+		if len(ready.Reviewers) > 0 {
+			output.Infof("Review %.12s is ready to be reviewed by %s.\n", r.Revision, strings.Join(ready.Reviewers, ", "))
+		} else {
+			output.Infof("Review %.12s is ready to be reviewed.\n", r.Revision)
+		}
 	}
 	return nil
 }
@@ -188,10 +652,10 @@ func requestReview(repo repository.Repo, args []string) error {
 // requestCmd defines the "request" subcommand.
 var requestCmd = &Command{
 	Usage: func(arg0 string) {
-		fmt.Printf("Usage: %s request [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		fmt.Printf("Usage: %s request [<option>...] [<review-hash>]\n       %s request --from-remote <url> [<option>...] <branch>\n       %s request --ready [<review-hash>]\n\nOptions:\n", arg0, arg0, arg0)
 		requestFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return requestReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return requestReview(ctx.Repo, args)
 	},
 }
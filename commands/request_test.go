@@ -17,7 +17,12 @@ limitations under the License.
 package commands
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/request"
 )
 
 func TestBuildRequestFromFlags(t *testing.T) {
@@ -34,3 +39,211 @@ func TestBuildRequestFromFlags(t *testing.T) {
 		t.Fatalf("Unexpected reviewers list: '%v'", r.Reviewers)
 	}
 }
+
+func TestBuildRequestCC(t *testing.T) {
+	requestFlagSet.Parse([]string{"-m", "Request message", "-r", "Me", "-cc", "Me, Observer, Observer"})
+	defer requestFlagSet.Set("cc", "")
+	r, err := buildRequestFromFlags("user@hostname.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc := withoutReviewers(r.CC, r.Reviewers); len(cc) != 1 || cc[0] != "Observer" {
+		t.Fatalf("Unexpected CC list: '%v'", cc)
+	}
+}
+
+func TestBuildRequestLabels(t *testing.T) {
+	requestFlagSet.Parse([]string{"-m", "Request message", "--labels", "urgent, docs"})
+	defer requestFlagSet.Set("labels", "")
+	r, err := buildRequestFromFlags("user@hostname.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Labels) != 2 || r.Labels[0] != "urgent" || r.Labels[1] != "docs" {
+		t.Fatalf("Unexpected labels: %q", r.Labels)
+	}
+}
+
+func TestDescribeCommits(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	commits := []string{repository.TestCommitA, repository.TestCommitB, repository.TestCommitD}
+	for strategy, want := range map[string]string{
+		describeSeries: "First commit\n\n- Second commit\n- Fourth commit\n",
+		describeFirst:  "First commit",
+	} {
+		description, err := describeCommits(repo, commits, strategy)
+		if err != nil || description != want {
+			t.Errorf("Unexpected %s description: %q, %v; want %q", strategy, description, err, want)
+		}
+	}
+	if description, err := describeCommits(repo, commits[:1], describeSeries); err != nil || description != "First commit" {
+		t.Errorf("Unexpected description of a single commit: %q, %v", description, err)
+	}
+	if _, err := describeCommits(repo, commits, "last"); err == nil {
+		t.Error("Failed to reject an unknown strategy")
+	}
+	defer requestFlagSet.Set("describe", describeSeries)
+	if err := requestReview(repo, []string{"-m", "Request message", "--describe", "last"}); err == nil {
+		t.Error("Failed to reject an unknown strategy along with a message")
+	}
+	if subject, body := splitCommitMessage("Subject\n\nFirst paragraph.\n\nSecond paragraph.\n"); subject != "Subject" || body != "First paragraph.\n\nSecond paragraph." {
+		t.Errorf("Unexpected split of a commit message: %q, %q", subject, body)
+	}
+}
+
+func TestGetForkRef(t *testing.T) {
+	const fork = "url/3b7fe722ee63d2330d3e6ed37137edfe86227c5c"
+	for _, tc := range []struct {
+		url, branch, want string
+	}{
+		{"https://github.com/user/repo.git", "fix", "refs/forks/" + fork + "/fix"},
+		{"https://github.com/user/repo/", "refs/heads/fix", "refs/forks/" + fork + "/fix"},
+		{"git@github.com:user/repo.git", "feature/x", "refs/forks/" + fork + "/feature/x"},
+	} {
+		name, got, err := getForkRef(tc.url, tc.branch)
+		if err != nil {
+			t.Errorf("getForkRef(%q, %q) failed: %v", tc.url, tc.branch, err)
+		} else if name != fork || got != tc.want {
+			t.Errorf("getForkRef(%q, %q) = %q, %q, want %q, %q", tc.url, tc.branch, name, got, fork, tc.want)
+		}
+	}
+	if name, _, err := getForkRef("ssh://git@example.com:2222/user/repo", "fix"); err != nil || name == fork {
+		t.Errorf("Unexpected fork for another host: %q, %v", name, err)
+	}
+	for _, url := range []string{"", "https://../x"} {
+		if _, _, err := getForkRef(url, "fix"); err == nil {
+			t.Errorf("Expected an error for %q", url)
+		}
+	}
+}
+
+func TestFetchFromRemoteRegistersFork(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	const url = "https://github.com/user/repo.git"
+	forkRef, err := fetchFromRemote(repo, url, []string{"feature/x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, branch := review.URLForkName, ""
+	if rest := strings.TrimPrefix(forkRef, forkRefPrefix+review.URLForkName); rest != forkRef {
+		i := strings.Index(rest, "/")
+		fork, branch = fork+rest[:i], rest[i+1:]
+	}
+	if branch != "feature/x" {
+		t.Fatalf("Unexpected fork ref: %q", forkRef)
+	}
+	if urls, err := getForkURLs(repo, fork); err != nil || len(urls) != 1 || urls[0] != url {
+		t.Errorf("The fork was not registered: %v, %v", urls, err)
+	}
+	if _, err := fetchFromRemote(repo, url, []string{"fix"}); err != nil {
+		t.Fatal(err)
+	}
+	if urls, err := getForkURLs(repo, fork); err != nil || len(urls) != 1 {
+		t.Errorf("The fork was registered twice: %v, %v", urls, err)
+	}
+	if _, err := fetchFromRemote(repo, "--upload-pack=touch pwned", []string{"fix"}); err == nil {
+		t.Error("Failed to reject fetching from a URL that looks like an option")
+	}
+}
+
+func TestWIPReviews(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft := r.Request
+	draft.WIP = true
+	draft.Timestamp = "0000000009"
+	note, err := draft.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(request.Ref, repository.TestCommitG, note); err != nil {
+		t.Fatal(err)
+	}
+	isListed := func() bool {
+		reviews, err := withoutOthersDrafts(repo, review.ListOpen(repo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, listed := range reviews {
+			if listed.Revision == repository.TestCommitG {
+				return true
+			}
+		}
+		return false
+	}
+	if isListed() {
+		t.Error("The work-in-progress review of another user was listed")
+	}
+	if err := markReviewReady(repo, []string{repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	if !isListed() {
+		t.Error("The review was not listed once it was ready")
+	}
+	if err := markReviewReady(repo, []string{repository.TestCommitG}); err == nil {
+		t.Error("Failed to reject marking a review that is not a work in progress as ready")
+	}
+}
+
+// repoWithNumstat reports the same changed lines for every diff.
+type repoWithNumstat struct {
+	repository.Repo
+}
+
+func (r repoWithNumstat) Diff(left, right string, diffArgs ...string) (string, error) {
+	return "10\t5\tmain.go\n", nil
+}
+
+func TestCheckRequestSize(t *testing.T) {
+	repo := repoWithNumstat{repository.NewMockRepoForTest()}
+	size, warnings, err := checkRequestSize(repo, repository.TestCommitE, repository.TestReviewRef)
+	if err != nil || size == nil || size.Lines() != 15 || len(warnings) != 0 {
+		t.Errorf("Unexpected size of a small review: %v, %q, %v", size, warnings, err)
+	}
+	if size, _, err := checkRequestSize(repo, "", repository.TestReviewRef); err != nil || size != nil {
+		t.Errorf("Unexpected size without a base commit: %v, %v", size, err)
+	}
+	if err := repo.SetConfig(review.MaxLinesConfig, "10"); err != nil {
+		t.Fatal(err)
+	}
+	if _, warnings, err := checkRequestSize(repo, repository.TestCommitE, repository.TestReviewRef); err != nil || len(warnings) != 1 {
+		t.Errorf("Unexpected warnings for a large review: %q, %v", warnings, err)
+	}
+	if err := repo.SetConfig(review.MaxLinesConfig, "lots"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := checkRequestSize(repo, repository.TestCommitE, repository.TestReviewRef); err == nil {
+		t.Error("Unexpected success with an invalid line limit")
+	}
+}
+
+func TestAcceptPreviouslyReviewed(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	previouslyReviewed := map[string]string{repository.TestCommitG: repository.TestCommitB}
+	if err := repo.SetConfig("appraise.reuse.requireSigned", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := acceptPreviouslyReviewed(repo, repository.TestCommitG, previouslyReviewed, "user@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := review.Get(repo, repository.TestCommitG); err != nil || (r.Resolved != nil && *r.Resolved) {
+		t.Fatalf("Unexpected automatic acceptance reusing an unsigned acceptance: %v", err)
+	}
+
+	if err := repo.SetConfig("appraise.reuse.requireSigned", "false"); err != nil {
+		t.Fatal(err)
+	}
+	// Requiring reviews to be signed before they are sent does not apply.
+	if err := repo.SetConfig("appraise.send.requireSigned", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := acceptPreviouslyReviewed(repo, repository.TestCommitG, previouslyReviewed, "user@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := review.Get(repo, repository.TestCommitG); err != nil || r.Resolved == nil || !*r.Resolved {
+		t.Fatalf("Failed to accept the review automatically: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+var searchFlagSet = flag.NewFlagSet("search", flag.ExitOnError)
+
+var (
+	searchJSONOutput = searchFlagSet.Bool("json", false, "Format the output as JSON")
+)
+
+// searchReviews lists all reviews matching the given query.
+func searchReviews(repo repository.Repo, args []string) error {
+	searchFlagSet.Parse(args)
+	args = searchFlagSet.Args()
+	if len(args) == 0 {
+		return errors.New("You must specify a search query.")
+	}
+
+	reviews, err := review.Search(repo, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if *searchJSONOutput {
+		b, err := json.MarshalIndent(reviews, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	output.PrintSearchResults(reviews)
+	return nil
+}
+
+// searchCmd defines the "search" subcommand.
+var searchCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s search [<option>...] <query>\n\n", arg0)
+		fmt.Printf(`The query is a list of terms that must all appear in either the review
+description or one of its comments, optionally combined with the qualifiers:
+    author:<email>   The review was requested or commented on by the given user.
+    status:<status>  One of open, wip, pending, accepted, rejected, submitted, or abandoned.
+    path:<path>      The review has comments on the given file or directory.
+
+Options:
+`)
+		searchFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return searchReviews(ctx.Repo, args)
+	},
+}
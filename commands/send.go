@@ -0,0 +1,168 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+)
+
+// Template for the instructions printed once a review has been sent.
+const sendInstructionsTemplate = `Sent review %.12s to %q.
+
+To pick it up, an upstream maintainer can run:
+  git remote add <name> %s
+  git appraise pull <name>
+  git fetch <name> %s
+`
+
+var sendFlagSet = flag.NewFlagSet("send", flag.ExitOnError)
+
+var (
+	sendRemote        = sendFlagSet.String("remote", "origin", "The remote (usually your fork) to send the review to")
+	sendRequireSigned = sendFlagSet.Bool("require-signed", false,
+		"Refuse to send the review unless its request and your comments are signed. Defaults to the value of appraise.send.requireSigned.")
+)
+
+// verifyOwnNotesSigned verifies that the review request, and every comment
+// on the review written by the given user, is signed.
+func verifyOwnNotesSigned(r *review.Review, userEmail string) error {
+	if r.Request.Sig.Sig == "" {
+		return errors.New("The review request is not signed; rerun `git appraise request -S` to sign it.")
+	}
+	if err := gpg.Verify(&r.Request); err != nil {
+		return fmt.Errorf("The signature on the review request could not be verified: %v", err)
+	}
+	var verifyThreads func(threads []review.CommentThread) error
+	verifyThreads = func(threads []review.CommentThread) error {
+		for i := range threads {
+			thread := &threads[i]
+			if thread.Comment.Author == userEmail {
+				if thread.Comment.Sig.Sig == "" {
+					return fmt.Errorf("Your comment %.12s is not signed.", thread.Hash)
+				}
+				if err := gpg.Verify(&thread.Comment); err != nil {
+					return fmt.Errorf("The signature on your comment %.12s could not be verified: %v", thread.Hash, err)
+				}
+			}
+			if err := verifyThreads(thread.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return verifyThreads(r.Comments)
+}
+
+// validateSendRequest checks that the given review can be sent to a fork.
+func validateSendRequest(repo repository.Repo, args []string) (*review.Review, error) {
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return nil, errors.New("Only sending a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return nil, errors.New("There is no matching review.")
+	}
+	if !r.IsOpen() {
+		return nil, errors.New("Only open reviews can be sent.")
+	}
+	if !strings.HasPrefix(r.Request.ReviewRef, "refs/heads/") {
+		return nil, fmt.Errorf("The review ref %q is not a local branch, so it can not be sent.", r.Request.ReviewRef)
+	}
+	if err := repo.VerifyGitRef(r.Request.ReviewRef); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// send pushes a review, and the metadata for it, to a contributor's fork so
+// that an upstream maintainer can pull it from there.
+//
+// Only the review branch, the review notes, and the archives are pushed.
+func send(repo repository.Repo, args []string) error {
+	sendFlagSet.Parse(args)
+	args = sendFlagSet.Args()
+
+	r, err := validateSendRequest(repo, args)
+	if err != nil {
+		return err
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	if r.Request.Requester != userEmail {
+		return fmt.Errorf("The review was requested by %q, so it should be sent from their fork.", r.Request.Requester)
+	}
+	requireSigned := *sendRequireSigned
+	if !requireSigned {
+		configured, err := config.Get(repo, "appraise.send.requireSigned")
+		if err != nil {
+			return err
+		}
+		requireSigned = configured == "true"
+	}
+	if requireSigned {
+		if err := verifyOwnNotesSigned(r, userEmail); err != nil {
+			return err
+		}
+	}
+	url, err := repo.GetConfig("remote." + *sendRemote + ".url")
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return fmt.Errorf("There is no remote named %q.", *sendRemote)
+	}
+
+	branchRefspec := fmt.Sprintf("%s:%s", r.Request.ReviewRef, r.Request.ReviewRef)
+	notesRefspec := fmt.Sprintf("%s:%s", notesRefPattern, notesRefPattern)
+	archiveRefspec := fmt.Sprintf("%s:%s", archiveRefPattern, archiveRefPattern)
+	if err := repo.Push(*sendRemote, branchRefspec, notesRefspec, archiveRefspec); err != nil {
+		return err
+	}
+	output.Infof(sendInstructionsTemplate, r.Revision, *sendRemote, url, r.Request.ReviewRef)
+	return nil
+}
+
+// sendCmd defines the "send" subcommand.
+var sendCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s send [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		sendFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return send(ctx.Repo, args)
+	},
+}
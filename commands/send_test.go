@@ -0,0 +1,37 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestValidateSendRequest(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := validateSendRequest(repo, []string{repository.TestCommitG})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOwnNotesSigned(r, "ojarjur"); err == nil {
+		t.Error("Failed to reject an unsigned review request")
+	}
+	if _, err := validateSendRequest(repo, []string{repository.TestCommitB}); err == nil {
+		t.Error("Failed to reject sending a submitted review")
+	}
+}
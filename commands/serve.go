@@ -0,0 +1,157 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/server"
+)
+
+var serveFlagSet = flag.NewFlagSet("serve", flag.ExitOnError)
+
+var (
+	serveRoot   = serveFlagSet.String("root", "", "The directory containing the repositories to serve, such as a directory of bare repositories")
+	serveListen = serveFlagSet.String("listen", ":8080", "The address to serve the review APIs on")
+	serveAuth   = serveFlagSet.String("auth", "",
+		"How to authenticate requests: \"token\" for bearer tokens, \"header\" for a header set by an authenticating proxy, or \"tls\" for client certificates. Without it, comments cannot be posted")
+	serveTokens = serveFlagSet.String("tokens", "",
+		"The `file` of bearer tokens for --auth=token, with a token and the identity it was issued to on each line")
+	serveAuthHeader = serveFlagSet.String("auth-header", "X-Forwarded-Email",
+		"The header holding the authenticated identity for --auth=header")
+	serveIdentities = serveFlagSet.String("identities", "",
+		"The `file` mapping identities to git emails, with an identity and its email on each line. Identities that are emails need not be listed")
+	serveTLSCert  = serveFlagSet.String("tls-cert", "", "The `file` of the server's TLS certificate")
+	serveTLSKey   = serveFlagSet.String("tls-key", "", "The `file` of the server's TLS key")
+	serveClientCA = serveFlagSet.String("client-ca", "",
+		"The `file` of the CA certificates that client certificates must be signed by, for --auth=tls")
+)
+
+// getAuthenticator returns the authenticator set by the serve flags, or nil
+// if requests are not authenticated.
+func getAuthenticator() (server.Authenticator, error) {
+	switch *serveAuth {
+	case "":
+		return nil, nil
+	case "token":
+		if *serveTokens == "" {
+			return nil, errors.New("The --tokens flag is required by --auth=token.")
+		}
+		tokens, err := server.ReadPairs(*serveTokens)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the tokens: %v", err)
+		}
+		return server.Tokens(tokens), nil
+	case "header":
+		return server.TrustedHeader(*serveAuthHeader), nil
+	case "tls":
+		if *serveTLSCert == "" || *serveTLSKey == "" || *serveClientCA == "" {
+			return nil, errors.New("The --tls-cert, --tls-key, and --client-ca flags are required by --auth=tls.")
+		}
+		return server.ClientCert{}, nil
+	}
+	return nil, fmt.Errorf("Unknown authentication %q; expected token, header, or tls.", *serveAuth)
+}
+
+// getTLSConfig returns the TLS config that verifies client certificates
+// against the CAs in the given file.
+func getTLSConfig(clientCAFile string) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("There are no certificates in %q.", clientCAFile)
+	}
+	return &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}, nil
+}
+
+// openGitRepo opens the git repository at the given path.
+func openGitRepo(path string) (repository.Repo, error) {
+	return repository.NewGitRepo(path)
+}
+
+// serveRepos serves the review APIs of every repository under a root directory.
+func serveRepos(args []string) error {
+	serveFlagSet.Parse(args)
+	if len(serveFlagSet.Args()) > 0 {
+		return errors.New("The serve command does not take any positional arguments.")
+	}
+	if *serveRoot == "" {
+		return errors.New("The --root flag is required.")
+	}
+	s := server.New(*serveRoot, openGitRepo)
+	s.NotesRefs = reviewNotesRefs
+	names, err := s.Repos()
+	if err != nil {
+		return fmt.Errorf("Failed to find the repositories under %q: %v", *serveRoot, err)
+	}
+	auth, err := getAuthenticator()
+	if err != nil {
+		return err
+	}
+	var handler http.Handler = s
+	if auth != nil {
+		ids := server.Identities{}
+		if *serveIdentities != "" {
+			if ids, err = server.ReadPairs(*serveIdentities); err != nil {
+				return fmt.Errorf("Failed to read the identities: %v", err)
+			}
+		}
+		handler = server.Authenticate(auth, ids, s)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(server.APIPrefix, handler)
+	mux.Handle(server.APIPrefix+"/", handler)
+	mux.Handle(server.MetricsPath, handler)
+	httpServer := &http.Server{Addr: *serveListen, Handler: mux}
+	if *serveTLSCert == "" {
+		output.Infof("Serving the reviews of %d repositories at http://%s%s\n", len(names), *serveListen, server.APIPrefix)
+		return httpServer.ListenAndServe()
+	}
+	if *serveClientCA != "" {
+		if httpServer.TLSConfig, err = getTLSConfig(*serveClientCA); err != nil {
+			return fmt.Errorf("Failed to read the client CAs: %v", err)
+		}
+	}
+	output.Infof("Serving the reviews of %d repositories at https://%s%s\n", len(names), *serveListen, server.APIPrefix)
+	return httpServer.ListenAndServeTLS(*serveTLSCert, *serveTLSKey)
+}
+
+// serveCmd defines the "serve" subcommand.
+var serveCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s serve --root <directory> [<option>...]\n\nServes JSON APIs for the reviews of every repository under the given directory.\n\nOptions:\n", arg0)
+		serveFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return serveRepos(args)
+	},
+	NoRepo: true,
+}
@@ -31,15 +31,70 @@ var showFlagSet = flag.NewFlagSet("show", flag.ExitOnError)
 
 var (
 	showDetached    = showFlagSet.Bool("d", false, "Show the detached comments for the given path")
-	showJSONOutput  = showFlagSet.Bool("json", false, "Format the output as JSON")
+	showJSONOutput  = showFlagSet.Bool("json", false, "Format the output as JSON; with --diff, the diff is split into files, hunks, and lines")
 	showDiffOutput  = showFlagSet.Bool("diff", false, "Show the current diff for the review")
-	showDiffOptions = showFlagSet.String("diff-opts", "", "Options to pass to the diff tool; can only be used with the --diff option")
+	showDiffOptions = showFlagSet.String("diff-opts", "", "Options to pass to the diff tool; can only be used with the --diff or --history options")
+	showDate        = showFlagSet.String("date", output.DateLocal, "Format for dates: relative, local, iso, or utc")
+
+	showVerifySubmission = showFlagSet.Bool("verify-submission", false,
+		"Verify that the signed submission records of the review match the commits that were accepted")
+	showHistory = showFlagSet.Bool("history", false,
+		"Show each of the heads that the review has had, including those archived before it was rebased or force-pushed, and the diffs between them")
+	showRequestHistory = showFlagSet.Bool("request-history", false,
+		"Show each revision of the review's request, and how it changed the description, reviewers, target, and other fields")
+
+	showIgnoreWhitespace = showFlagSet.Bool("ignore-whitespace", false,
+		"Ignore changes in whitespace in the diff; implies --diff unless --history is set")
+	showStat = showFlagSet.Bool("stat", false,
+		"Show a summary of the changes to each file instead of the diff; implies --diff unless --history is set")
+	showPaths pathGlobs
+
+	showMaxDepth = showFlagSet.Int("max-depth", 0,
+		"Only show comment threads down to the given depth, where 1 only shows the top-level comments; 0 means no limit")
+	showMaxThreads = showFlagSet.Int("max-threads", 0,
+		"Only show the given number of comment threads at each level; 0 means no limit")
+	showSkipThreads = showFlagSet.Int("skip-threads", 0,
+		"Skip the given number of top-level comment threads, to page through them with --max-threads")
+	showThread = showFlagSet.String("thread", "",
+		"Only show the comment thread with the given hash, such as one whose replies were left out by --max-depth or --max-threads")
 )
 
+func init() {
+	showFlagSet.Var(&showPaths, "path", "Only include the files matching the given `glob` in the diff; can be given multiple times, and implies --diff unless --history is set")
+}
+
+// pathGlobs is a flag.Value that collects the values of a repeated --path flag.
+type pathGlobs []string
+
+// String returns the collected globs, separated by commas.
+func (g *pathGlobs) String() string {
+	return strings.Join(*g, ",")
+}
+
+// Set adds the given glob to the collected globs.
+func (g *pathGlobs) Set(glob string) error {
+	*g = append(*g, glob)
+	return nil
+}
+
+// getShowDiffOptions returns the options given for the diffs of a review.
+func getShowDiffOptions() repository.DiffOptions {
+	return repository.DiffOptions{
+		IgnoreWhitespace: *showIgnoreWhitespace,
+		Stat:             *showStat,
+		Paths:            showPaths,
+	}
+}
+
+// hasShowDiffOptions reports whether any of the first-class diff flags are set.
+func hasShowDiffOptions() bool {
+	return *showIgnoreWhitespace || *showStat || len(showPaths) > 0
+}
+
 // showDetachedComments prints the current code review.
 func showDetachedComments(repo repository.Repo, args []string) error {
-	if *showDiffOptions != "" || *showDiffOutput {
-		return errors.New("The --diff and --diff-opts flags can not be combined with the -d flag.")
+	if *showDiffOptions != "" || *showDiffOutput || *showHistory || *showRequestHistory || *showThread != "" || hasShowDiffOptions() {
+		return errors.New("The --diff, --diff-opts, --history, --request-history, --thread, --ignore-whitespace, --stat, and --path flags can not be combined with the -d flag.")
 	}
 	if len(args) > 1 {
 		return errors.New("Only showing comments for a single path is supported.")
@@ -49,7 +104,7 @@ func showDetachedComments(repo repository.Repo, args []string) error {
 	path := args[0]
 	comments, err := review.GetDetachedComments(repo, path)
 	if err != nil {
-		return fmt.Errorf("Failed to load the comments for %q: %v\n", path, err)
+		return fmt.Errorf("Failed to load the comments for %q: %v", path, err)
 	}
 	if *showJSONOutput {
 		return output.PrintCommentsJSON(comments)
@@ -59,15 +114,29 @@ func showDetachedComments(repo repository.Repo, args []string) error {
 
 // showReview prints the current code review.
 func showReview(repo repository.Repo, args []string) error {
-	if *showDiffOptions != "" && !*showDiffOutput {
-		return errors.New("The --diff-opts flag can only be used if the --diff flag is set.")
+	showDiff := *showDiffOutput || (hasShowDiffOptions() && !*showHistory)
+	if *showDiffOptions != "" && !showDiff && !*showHistory {
+		return errors.New("The --diff-opts flag can only be used if the --diff or --history flag is set.")
+	}
+	if showDiff && *showHistory {
+		return errors.New("The --diff and --history flags can not be combined.")
+	}
+	if *showRequestHistory && (showDiff || *showHistory || *showDiffOptions != "") {
+		return errors.New("The --request-history flag can not be combined with the --diff, --diff-opts, or --history flags.")
+	}
+	if *showThread != "" && (showDiff || *showHistory || *showRequestHistory || *showDiffOptions != "" || *showVerifySubmission) {
+		return errors.New("The --thread flag can not be combined with the --diff, --diff-opts, --history, --request-history, or --verify-submission flags.")
 	}
 
-	var r *review.Review
-	var err error
 	if len(args) > 1 {
 		return errors.New("Only showing a single review is supported.")
 	}
+	if *showThread != "" {
+		return showCommentThread(repo, args)
+	}
+
+	var r *review.Review
+	var err error
 
 	if len(args) == 1 {
 		r, err = review.Get(repo, args[0])
@@ -76,36 +145,94 @@ func showReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
 	}
+	if *showVerifySubmission {
+		if err := r.VerifySubmissions(); err != nil {
+			return fmt.Errorf("Failed to verify the submission: %v", err)
+		}
+		output.Infof("Verified %d submission records for review %.12s\n", len(r.Submissions), r.Revision)
+		return nil
+	}
+	var diffArgs []string
+	if *showDiffOptions != "" {
+		diffArgs = strings.Split(*showDiffOptions, ",")
+	}
+	if *showJSONOutput && showDiff {
+		return output.PrintDiffJSON(r, getShowDiffOptions(), diffArgs...)
+	}
+	if *showRequestHistory {
+		if *showJSONOutput {
+			return output.PrintRequestHistoryJSON(r)
+		}
+		return output.PrintRequestHistory(r)
+	}
 	if *showJSONOutput {
 		return output.PrintJSON(r)
 	}
-	if *showDiffOutput {
-		var diffArgs []string
-		if *showDiffOptions != "" {
-			diffArgs = strings.Split(*showDiffOptions, ",")
-		}
-		return output.PrintDiff(r, diffArgs...)
+	if *showHistory {
+		return output.PrintHistory(r, getShowDiffOptions(), diffArgs...)
+	}
+	if showDiff {
+		return output.PrintDiff(r, getShowDiffOptions(), diffArgs...)
 	}
 	return output.PrintDetails(r)
 }
 
+// showCommentThread prints a single comment thread of a review, along with
+// the comments that it replies to.
+func showCommentThread(repo repository.Repo, args []string) error {
+	var revision string
+	if len(args) == 1 {
+		revision = args[0]
+	} else {
+		// Only the revision of the current review is needed, as GetThread
+		// reads its comments directly.
+		r, err := review.GetCurrentSummary(repo)
+		if err != nil {
+			return fmt.Errorf("Failed to load the review: %v", err)
+		}
+		if r == nil {
+			return errors.New("There is no matching review.")
+		}
+		revision = r.Revision
+	}
+	thread, err := review.GetThread(repo, revision, *showThread)
+	if err != nil {
+		return err
+	}
+	if *showJSONOutput {
+		return output.PrintThreadJSON(thread)
+	}
+	return output.PrintThread(repo, thread)
+}
+
 // showCmd defines the "show" subcommand.
 var showCmd = &Command{
 	Usage: func(arg0 string) {
 		fmt.Printf("Usage: %s show [<option>...] [<commit>]\n\nOptions:\n", arg0)
 		showFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
+	RunMethod: func(ctx *Context, args []string) error {
 		showFlagSet.Parse(args)
 		args = showFlagSet.Args()
+		if err := output.SetDateFormat(*showDate); err != nil {
+			return err
+		}
+		if err := output.SetThreadLimits(*showSkipThreads, *showMaxThreads, *showMaxDepth); err != nil {
+			return err
+		}
 		if *showDetached {
-			return showDetachedComments(repo, args)
+			return showDetachedComments(ctx.Repo, args)
+		}
+		permalinker, err := review.GetPermalinker(ctx.Repo)
+		if err != nil {
+			return err
 		}
-		return showReview(repo, args)
+		output.SetPermalinker(permalinker)
+		return showReview(ctx.Repo, args)
 	},
 }
@@ -0,0 +1,60 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestShowDiffOptions(t *testing.T) {
+	defer func() {
+		*showIgnoreWhitespace = false
+		*showStat = false
+		showPaths = nil
+	}()
+	if hasShowDiffOptions() {
+		t.Fatal("Unexpected diff options before parsing any flags")
+	}
+	if err := showFlagSet.Parse([]string{"--ignore-whitespace", "--stat", "--path", "*.go", "--path", "docs/**"}); err != nil {
+		t.Fatal(err)
+	}
+	if !hasShowDiffOptions() {
+		t.Error("Expected the diff flags to imply showing the diff")
+	}
+	options := getShowDiffOptions()
+	expected := repository.DiffOptions{IgnoreWhitespace: true, Stat: true, Paths: []string{"*.go", "docs/**"}}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Unexpected diff options: %+v", options)
+	}
+
+	r, err := review.Get(repository.NewMockRepoForTest(), repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := r.GetDiffWithOptions(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(diff, `, ignoring whitespace, as a summary, limited to ["*.go" "docs/**"]`) {
+		t.Errorf("Unexpected diff with options: %q", diff)
+	}
+}
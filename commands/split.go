@@ -0,0 +1,278 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/commands/output"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+)
+
+var splitFlagSet = flag.NewFlagSet("split", flag.ExitOnError)
+
+var (
+	splitAt = splitFlagSet.String("at", "",
+		"Comma-separated list of the commits that end each part of the review, other than the last. By default, each commit becomes its own part")
+	splitDryRun = splitFlagSet.Bool("dry-run", false, "Print how the review would be split, without splitting it")
+	splitSign   = splitFlagSet.Bool("S", false, "Sign the requests of the parts")
+)
+
+// Template for printing one of the parts that a review is split into.
+const splitPartTemplate = `Part %d of %d: %s -> %s
+`
+
+// splitPart is one of the reviews that a review is split into.
+type splitPart struct {
+	// Commits are the commits of the part, oldest first.
+	Commits   []string
+	ReviewRef string
+	TargetRef string
+	// BaseCommit is the commit that the part's changes are relative to.
+	BaseCommit string
+}
+
+// planSplit returns the parts that the given review is split into, ending
+// each part, other than the last, at one of the given commits.
+//
+// The last part keeps the review ref of the review, so that the branch
+// being worked on becomes the top of the stack, and each of the other parts
+// gets a new branch named after it. Each part targets the branch of the part
+// before it, so that they are reviewed in order.
+func planSplit(repo repository.Repo, r *review.Review, ends []string) ([]splitPart, error) {
+	if !r.IsOpen() {
+		return nil, errors.New("Only open reviews can be split.")
+	}
+	if r.Request.ReviewRef == "" || r.Request.TargetTag != "" {
+		return nil, errors.New("Release reviews can not be split.")
+	}
+	commits, err := r.ListCommits()
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) < 2 {
+		return nil, errors.New("The review has only one commit, so it can not be split.")
+	}
+	isEnd := make(map[string]bool)
+	if len(ends) == 0 {
+		for _, commit := range commits[:len(commits)-1] {
+			isEnd[commit] = true
+		}
+	}
+	for _, end := range ends {
+		hash, err := repo.GetCommitHash(end)
+		if err != nil {
+			return nil, err
+		}
+		isEnd[hash] = true
+	}
+	baseCommit, err := r.GetBaseCommit()
+	if err != nil {
+		return nil, err
+	}
+	var parts []splitPart
+	var current []string
+	for i, commit := range commits {
+		current = append(current, commit)
+		if !isEnd[commit] && i < len(commits)-1 {
+			continue
+		}
+		delete(isEnd, commit)
+		parts = append(parts, splitPart{Commits: current, BaseCommit: baseCommit})
+		baseCommit = commit
+		current = nil
+	}
+	for end := range isEnd {
+		return nil, fmt.Errorf("The commit %.12s is not one of the review's commits.", end)
+	}
+	if len(parts) < 2 {
+		return nil, errors.New("The given commits do not split the review into more than one part.")
+	}
+	for i := range parts {
+		if i == len(parts)-1 {
+			parts[i].ReviewRef = r.Request.ReviewRef
+		} else {
+			parts[i].ReviewRef = fmt.Sprintf("%s-part%d", r.Request.ReviewRef, i+1)
+			if exists, err := repo.HasRef(parts[i].ReviewRef); err != nil {
+				return nil, err
+			} else if exists {
+				return nil, fmt.Errorf("The ref %q already exists.", parts[i].ReviewRef)
+			}
+		}
+		if i == 0 {
+			parts[i].TargetRef = r.Request.TargetRef
+		} else {
+			parts[i].TargetRef = parts[i-1].ReviewRef
+			if requests := request.ParseAllValid(repo.GetNotes(request.Ref, parts[i].Commits[0])); len(requests) > 0 {
+				return nil, fmt.Errorf("The commit %.12s already has its own review.", parts[i].Commits[0])
+			}
+		}
+	}
+	return parts, nil
+}
+
+// getPartDescription returns the description of the given part of a review.
+func getPartDescription(repo repository.Repo, r *review.Review, part splitPart, index, count int) (string, error) {
+	title := strings.SplitN(strings.TrimSpace(r.Request.Description), "\n", 2)[0]
+	lines := []string{fmt.Sprintf("%s (part %d of %d)", title, index+1, count), ""}
+	for _, commit := range part.Commits {
+		message, err := repo.GetCommitMessage(commit)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, "- "+strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// applySplit creates the branches and review requests of the given parts of
+// the review.
+//
+// The first part is anchored at the same commit as the review, so it keeps
+// the review's comments, while each of the other parts is a new review.
+func applySplit(repo repository.Repo, r *review.Review, parts []splitPart, requester, key string) error {
+	for _, part := range parts[:len(parts)-1] {
+		if err := repo.SetRef(part.ReviewRef, part.Commits[len(part.Commits)-1], ""); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for i, part := range parts {
+		description, err := getPartDescription(repo, r, part, i, len(parts))
+		if err != nil {
+			return err
+		}
+		req := r.Request
+		req.Timestamp = FormatDate(&now)
+		req.Requester = requester
+		req.ReviewRef = part.ReviewRef
+		req.TargetRef = part.TargetRef
+		req.BaseCommit = part.BaseCommit
+		req.Description = description
+		req.Alias = ""
+		if i == 0 {
+			// The first part is anchored at the review's commit, so its
+			// alias records the commit that the part now ends at.
+			req.Alias = part.Commits[len(part.Commits)-1]
+		}
+		req.Sig = gpg.Sig{}
+		if key != "" {
+			if err := gpg.Sign(key, &req); err != nil {
+				return err
+			}
+		}
+		note, err := req.Write()
+		if err != nil {
+			return err
+		}
+		anchor := part.Commits[0]
+		if i == 0 {
+			anchor = r.Revision
+		}
+		if err := repo.AppendNote(request.Ref, anchor, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSplit prints the parts that a review is split into.
+func printSplit(printf func(string, ...interface{}), repo repository.Repo, parts []splitPart) {
+	for i, part := range parts {
+		printf(splitPartTemplate, i+1, len(parts), part.ReviewRef, part.TargetRef)
+		for _, commit := range part.Commits {
+			subject := ""
+			if message, err := repo.GetCommitMessage(commit); err == nil {
+				subject = strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+			}
+			printf("  %.12s %s\n", commit, subject)
+		}
+	}
+}
+
+// splitReview splits a review into a stack of dependent reviews.
+func splitReview(repo repository.Repo, args []string) error {
+	splitFlagSet.Parse(args)
+	args = splitFlagSet.Args()
+
+	var r *review.Review
+	var err error
+	if len(args) > 1 {
+		return errors.New("Only splitting a single review is supported.")
+	}
+	if len(args) == 1 {
+		r, err = review.Get(repo, args[0])
+	} else {
+		r, err = review.GetCurrent(repo)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to load the review: %v", err)
+	}
+	if r == nil {
+		return errors.New("There is no matching review.")
+	}
+
+	var ends []string
+	if *splitAt != "" {
+		for _, end := range strings.Split(*splitAt, ",") {
+			ends = append(ends, strings.TrimSpace(end))
+		}
+	}
+	parts, err := planSplit(repo, r, ends)
+	if err != nil {
+		return err
+	}
+	if *splitDryRun {
+		printSplit(func(format string, a ...interface{}) { fmt.Printf(format, a...) }, repo, parts)
+		return nil
+	}
+	userEmail, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	var key string
+	if *splitSign {
+		key, err = repo.GetUserSigningKey()
+		if err != nil {
+			return err
+		}
+	}
+	if err := applySplit(repo, r, parts, userEmail, key); err != nil {
+		return err
+	}
+	output.Infof("Split review %.12s into %d parts:\n", r.Revision, len(parts))
+	printSplit(output.Infof, repo, parts)
+	return nil
+}
+
+// splitCmd defines the "split" subcommand.
+var splitCmd = &Command{
+	Usage: func(arg0 string) {
+		fmt.Printf("Usage: %s split [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
+		splitFlagSet.PrintDefaults()
+	},
+	RunMethod: func(ctx *Context, args []string) error {
+		return splitReview(ctx.Repo, args)
+	},
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestSplitReview(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planSplit(repo, r, []string{repository.TestCommitA}); err == nil {
+		t.Error("Failed to reject splitting at a commit outside of the review")
+	}
+	parts, err := planSplit(repo, r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part1 := repository.TestReviewRef + "-part1"
+	part2 := repository.TestReviewRef + "-part2"
+	expected := []splitPart{
+		{Commits: []string{repository.TestCommitG}, ReviewRef: part1, TargetRef: repository.TestTargetRef, BaseCommit: repository.TestCommitF},
+		{Commits: []string{repository.TestCommitH}, ReviewRef: part2, TargetRef: part1, BaseCommit: repository.TestCommitG},
+		{Commits: []string{repository.TestCommitI}, ReviewRef: repository.TestReviewRef, TargetRef: part2, BaseCommit: repository.TestCommitH},
+	}
+	if !reflect.DeepEqual(parts, expected) {
+		t.Fatalf("Unexpected parts: %+v", parts)
+	}
+	if err := applySplit(repo, r, parts, "user@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := repo.GetCommitHash(part2); err != nil || head != repository.TestCommitH {
+		t.Errorf("Unexpected head of the second part: %q, %v", head, err)
+	}
+	first, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Request.ReviewRef != part1 || first.Request.Description != "Final description of G (part 1 of 3)\n\n- No, I'm the sixth commit" {
+		t.Errorf("Unexpected request of the first part: %+v", first.Request)
+	}
+	last, err := review.Get(repo, repository.TestCommitI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || last.Request.TargetRef != part2 || last.Request.BaseCommit != repository.TestCommitH {
+		t.Errorf("Unexpected request of the last part: %+v", last)
+	}
+	if first.Request.Alias != repository.TestCommitG {
+		t.Errorf("Unexpected alias of the first part: %q", first.Request.Alias)
+	}
+	if last.Request.Alias != "" {
+		t.Errorf("Unexpected alias of the last part: %q", last.Request.Alias)
+	}
+	if _, err := planSplit(repo, first, nil); err == nil {
+		t.Error("Failed to reject splitting a review with a single commit")
+	}
+}
+
+func TestSplitReviewKeepsAliasOfFirstPart(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := planSplit(repo, r, []string{repository.TestCommitH})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || len(parts[0].Commits) != 2 {
+		t.Fatalf("Unexpected parts: %+v", parts)
+	}
+	if err := applySplit(repo, r, parts, "user@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	first, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Request.Alias != repository.TestCommitH {
+		t.Errorf("Unexpected alias of the first part: %q", first.Request.Alias)
+	}
+	if head, err := first.GetHeadCommit(); err != nil || head != repository.TestCommitH {
+		t.Errorf("Unexpected head of the first part: %q, %v", head, err)
+	}
+}
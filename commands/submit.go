@@ -20,8 +20,20 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/benchmark"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/submission"
+	"github.com/google/git-appraise/review/trailer"
 )
 
 var submitFlagSet = flag.NewFlagSet("submit", flag.ExitOnError)
@@ -31,12 +43,295 @@ var (
 	submitRebase      = submitFlagSet.Bool("rebase", false, "Rebase the source ref onto the target ref.")
 	submitFastForward = submitFlagSet.Bool("fast-forward", false, "Create a merge using the default fast-forward mode.")
 	submitTBR         = submitFlagSet.Bool("tbr", false, "(To be reviewed) Force the submission of a review that has not been accepted.")
+	submitForce       = submitFlagSet.Bool("force", false, "Submit the review even if it has unresolved comment threads.")
+	submitVerify      = submitFlagSet.Bool("verify", false, "Verify the signatures on the request, the accepting comments, and the submitted commits before submitting.")
 	submitArchive     = submitFlagSet.Bool("archive", true, "Prevent the original commit from being garbage collected; only affects rebased submits.")
+	submitTrailers    = submitFlagSet.Bool("trailers", false, "Add Reviewed-by and Reviewed-on trailers to the submitted commit message; only affects merged or rebased submits. Defaults to the value of appraise.submit.trailers.")
 
 	submitSign = submitFlagSet.Bool("S", false,
 		"Sign the contents of the submission")
+	submitKeepCheckout = submitFlagSet.Bool("keep-checkout", false,
+		"Leave the target ref checked out, rather than restoring the previously checked out branch.")
+	submitAllowUncommitted = submitFlagSet.Bool("allow-uncommitted", false,
+		"Allow uncommitted local changes.")
+	submitStash = submitFlagSet.Bool("stash", false,
+		"Stash any uncommitted local changes, and restore them once the submit is done.")
+	submitAllowRegressions = submitFlagSet.Bool("allow-regressions", false,
+		"Submit the review even if its benchmarks regressed by more than appraise.benchmark.threshold.")
+	submitMessage string
 )
 
+// defaultSubmitMessageTemplate is the merge commit message used when
+// appraise.submit.messageTemplate has not been configured.
+const defaultSubmitMessageTemplate = `Submitting review {{printf "%.12s" .Revision}}
+
+{{.Description}}`
+
+// submitMessageData is the data made available to merge commit message templates.
+type submitMessageData struct {
+	Revision    string
+	Description string
+	Requester   string
+	ReviewRef   string
+	TargetRef   string
+	Reviewers   []string
+	AcceptedBy  []string
+}
+
+func init() {
+	messageUsage := "Message to use for the merge commit; only affects merged submits. Overrides appraise.submit.messageTemplate."
+	submitFlagSet.StringVar(&submitMessage, "m", "", messageUsage)
+	submitFlagSet.StringVar(&submitMessage, "message", "", messageUsage)
+}
+
+// renderSubmitMessage expands the given merge commit message template for a review.
+//
+// Templates use the syntax of the text/template package, and can reference the
+// fields .Revision, .Description, .Requester, .ReviewRef, .TargetRef,
+// .Reviewers, and .AcceptedBy. The latter two are lists, which can be
+// formatted using the "join" function (e.g. {{join .Reviewers ", "}}).
+func renderSubmitMessage(messageTemplate string, r *review.Review) (string, error) {
+	t, err := template.New("submit").Funcs(template.FuncMap{"join": strings.Join}).Parse(messageTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Invalid submit message template: %v", err)
+	}
+	data := submitMessageData{
+		Revision:    r.Revision,
+		Description: r.Request.Description,
+		Requester:   r.Request.Requester,
+		ReviewRef:   r.Request.ReviewRef,
+		TargetRef:   r.Request.TargetRef,
+		Reviewers:   r.Request.Reviewers,
+		AcceptedBy:  r.AcceptedBy(),
+	}
+	var message strings.Builder
+	if err := t.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("Failed to expand the submit message template: %v", err)
+	}
+	return strings.TrimSpace(message.String()), nil
+}
+
+// getSubmitMessage returns the message for the merge commit created when submitting a review.
+func getSubmitMessage(repo repository.Repo, r *review.Review) (string, error) {
+	if submitMessage != "" {
+		return submitMessage, nil
+	}
+	messageTemplate, err := config.Get(repo, "appraise.submit.messageTemplate")
+	if err != nil {
+		return "", err
+	}
+	if messageTemplate == "" {
+		messageTemplate = defaultSubmitMessageTemplate
+	}
+	return renderSubmitMessage(messageTemplate, r)
+}
+
+// getSubmitTrailers returns the trailers recording who accepted the review, and where.
+//
+// The Reviewed-on trailer holds the review's revision, prefixed by the value
+// of appraise.reviewURLPrefix if that has been configured.
+func getSubmitTrailers(repo repository.Repo, r *review.Review) ([]trailer.Trailer, error) {
+	var trailers []trailer.Trailer
+	for _, reviewer := range r.AcceptedBy() {
+		trailers = append(trailers, trailer.Trailer{Key: trailer.ReviewedBy, Value: reviewer})
+	}
+	urlPrefix, err := config.Get(repo, "appraise.reviewURLPrefix")
+	if err != nil {
+		return nil, err
+	}
+	trailers = append(trailers, trailer.Trailer{Key: trailer.ReviewedOn, Value: urlPrefix + r.Revision})
+	return trailers, nil
+}
+
+// amendWithTrailers replaces the head commit of a rebased review with a copy
+// whose message includes the given trailers.
+//
+// The review ref and the review's alias are both updated to point to the
+// new commit, so that the review is still recognized once it is submitted.
+// The alias is recorded in a new request, with its own timestamp and, if the
+// submit is signed, signature.
+func amendWithTrailers(repo repository.Repo, r *review.Review, head string, trailers []trailer.Trailer) (string, error) {
+	message, err := repo.GetCommitMessage(head)
+	if err != nil {
+		return "", err
+	}
+	details, err := repo.GetCommitDetails(head)
+	if err != nil {
+		return "", err
+	}
+	amended := &repository.CommitDetails{
+		Author:      details.Author,
+		AuthorEmail: details.AuthorEmail,
+		AuthorTime:  details.Time,
+		Tree:        details.Tree,
+		Parents:     details.Parents,
+		Summary:     trailer.Append(message, trailers...),
+	}
+	amendedHead, err := repo.CreateCommit(amended)
+	if err != nil {
+		return "", err
+	}
+	if err := repo.SetRef(r.Request.ReviewRef, amendedHead, head); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	amendedRequest := r.Request
+	amendedRequest.Alias = amendedHead
+	amendedRequest.Timestamp = FormatDate(&now)
+	amendedRequest.Sig = gpg.Sig{}
+	if *submitSign {
+		key, err := repo.GetUserSigningKey()
+		if err != nil {
+			return "", err
+		}
+		if err := gpg.Sign(key, &amendedRequest); err != nil {
+			return "", err
+		}
+	}
+	r.Request = amendedRequest
+	note, err := r.Request.Write()
+	if err != nil {
+		return "", err
+	}
+	if err := repo.AppendNote(request.Ref, r.Revision, note); err != nil {
+		return "", err
+	}
+	return amendedHead, nil
+}
+
+// unresolvedThreadsError builds the error reported when a review still has unresolved comment threads.
+func unresolvedThreadsError(unresolved []review.CommentThread) error {
+	var lines []string
+	for _, thread := range unresolved {
+		description := strings.SplitN(strings.TrimSpace(thread.Comment.Description), "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf("  %.12s %s: %q", thread.Hash, thread.Comment.Author, description))
+	}
+	return fmt.Errorf("Not submitting as the review has %d unresolved comment threads; use --force to override:\n%s",
+		len(unresolved), strings.Join(lines, "\n"))
+}
+
+// benchmarkFailuresError builds the error reported when a review's benchmarks regressed.
+func benchmarkFailuresError(failures []benchmark.Result) error {
+	var lines []string
+	for _, result := range failures {
+		lines = append(lines, fmt.Sprintf("  %s: %+.1f%%", result.Metric, 100*result.Delta))
+	}
+	return fmt.Errorf("Not submitting as %d benchmark metrics regressed; use --allow-regressions to override:\n%s",
+		len(failures), strings.Join(lines, "\n"))
+}
+
+// verifySubmittedCommits verifies the signature of every commit that submitting
+// the given source commit would add to the target ref.
+func verifySubmittedCommits(repo repository.Repo, target, source string) error {
+	commits, err := repo.ListCommitsBetween(target, source)
+	if err != nil {
+		return err
+	}
+	for _, commit := range commits {
+		if err := repo.VerifyCommitSignature(commit); err != nil {
+			return fmt.Errorf("Not submitting as the review failed verification: %v", err)
+		}
+	}
+	return nil
+}
+
+// getReleaseQuorum returns the number of reviewers who must accept a release
+// review before its tag can be created.
+//
+// This is read from appraise.release.quorum, and defaults to 1.
+func getReleaseQuorum(repo repository.Repo) (int, error) {
+	configured, err := config.Get(repo, "appraise.release.quorum")
+	if err != nil {
+		return 0, err
+	}
+	if configured == "" {
+		return 1, nil
+	}
+	quorum, err := strconv.Atoi(configured)
+	if err != nil || quorum < 1 {
+		return 0, fmt.Errorf("Invalid value for appraise.release.quorum: %q", configured)
+	}
+	return quorum, nil
+}
+
+// submitRelease submits a release review by creating its target tag at the
+// reviewed commit.
+//
+// The tag message records everyone who accepted the release, using the same
+// trailers that are added to submitted commits.
+func submitRelease(repo repository.Repo, r *review.Review) error {
+	if *submitMerge || *submitRebase || *submitFastForward {
+		return errors.New("Release reviews are submitted by creating a tag, so --merge, --rebase, and --fast-forward do not apply.")
+	}
+	quorum, err := getReleaseQuorum(repo)
+	if err != nil {
+		return err
+	}
+	if accepted := r.AcceptedBy(); len(accepted) < quorum {
+		return fmt.Errorf("Not submitting as the release requires %d accepting reviewers, but only has %d.", quorum, len(accepted))
+	}
+	source, err := r.GetHeadCommit()
+	if err != nil {
+		return err
+	}
+	if *submitVerify {
+		if err := repo.VerifyCommitSignature(source); err != nil {
+			return fmt.Errorf("Not submitting as the review failed verification: %v", err)
+		}
+	}
+
+	message := submitMessage
+	if message == "" {
+		message = strings.TrimSpace(r.Request.Description)
+	}
+	if message == "" {
+		message = "Release " + r.Request.TargetTag
+	}
+	trailers, err := getSubmitTrailers(repo, r)
+	if err != nil {
+		return err
+	}
+	message = trailer.Append(message, trailers...)
+	// Release tags are always signed, so that the release can be verified
+	// without trusting wherever the tag was fetched from.
+	if err := repo.CreateAndSignTag(r.Request.TargetTag, source, message); err != nil {
+		return fmt.Errorf("Failed to create the signed release tag %q; release tags are always signed, so set up gpg (and user.signingKey) first: %v", r.Request.TargetTag, err)
+	}
+	if !*submitSign {
+		return nil
+	}
+	return recordSubmission(repo, r, source, source, "refs/tags/"+r.Request.TargetTag, submission.StrategyTag)
+}
+
+// recordSubmission writes a signed note recording how the review was
+// submitted, so that it can be verified that the submitted commit matches
+// the accepted one.
+func recordSubmission(repo repository.Repo, r *review.Review, accepted, source, target, strategy string) error {
+	submitter, err := repo.GetUserEmail()
+	if err != nil {
+		return err
+	}
+	submitted, err := repo.GetCommitHash(target)
+	if err != nil {
+		return err
+	}
+	s := submission.New(submitter, accepted, source, target, submitted, strategy)
+	now := time.Now()
+	s.Timestamp = FormatDate(&now)
+	key, err := repo.GetUserSigningKey()
+	if err != nil {
+		return err
+	}
+	if err := gpg.Sign(key, &s); err != nil {
+		return err
+	}
+	note, err := s.Write()
+	if err != nil {
+		return err
+	}
+	return repo.AppendNote(submission.Ref, r.Revision, note)
+}
+
 // Submit the current code review request.
 //
 // The "args" parameter contains all of the command line arguments that followed the subcommand.
@@ -47,6 +342,9 @@ func submitReview(repo repository.Repo, args []string) error {
 	if *submitMerge && *submitRebase {
 		return errors.New("Only one of --merge or --rebase is allowed.")
 	}
+	if err := checkNoInterruptedOperation(repo); err != nil {
+		return err
+	}
 
 	var r *review.Review
 	var err error
@@ -60,7 +358,7 @@ func submitReview(repo repository.Repo, args []string) error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("Failed to load the review: %v\n", err)
+		return fmt.Errorf("Failed to load the review: %v", err)
 	}
 	if r == nil {
 		return errors.New("There is no matching review.")
@@ -74,6 +372,30 @@ func submitReview(repo repository.Repo, args []string) error {
 		return errors.New("Not submitting as the review has not yet been accepted.")
 	}
 
+	if unresolved := r.UnresolvedThreads(); len(unresolved) > 0 && !*submitForce {
+		return unresolvedThreadsError(unresolved)
+	}
+
+	if !*submitAllowRegressions {
+		failures, err := r.GetBenchmarkFailures()
+		if err != nil {
+			return err
+		}
+		if len(failures) > 0 {
+			return benchmarkFailuresError(failures)
+		}
+	}
+
+	if *submitVerify {
+		if err := r.VerifyAcceptance(); err != nil {
+			return fmt.Errorf("Not submitting as the review failed verification: %v", err)
+		}
+	}
+
+	if r.Request.TargetTag != "" {
+		return submitRelease(repo, r)
+	}
+
 	target := r.Request.TargetRef
 	if err := repo.VerifyGitRef(target); err != nil {
 		return err
@@ -93,6 +415,9 @@ func submitReview(repo repository.Repo, args []string) error {
 
 	if !(*submitRebase || *submitMerge || *submitFastForward) {
 		submitStrategy, err := repo.GetSubmitStrategy()
+		if err == nil && submitStrategy == "" {
+			submitStrategy, err = config.FromFile(repo, "appraise.submit")
+		}
 		if err != nil {
 			return err
 		}
@@ -107,42 +432,121 @@ func submitReview(repo repository.Repo, args []string) error {
 		}
 	}
 
-	if *submitRebase {
-		var err error
-		if *submitSign {
-			err = r.RebaseAndSign(*submitArchive)
-		} else {
-			err = r.Rebase(*submitArchive)
+	var trailers []trailer.Trailer
+	addTrailers := *submitTrailers
+	if !addTrailers {
+		configured, err := config.Get(repo, "appraise.submit.trailers")
+		if err != nil {
+			return err
+		}
+		addTrailers = configured == "true"
+	}
+	if addTrailers && (*submitMerge || *submitRebase) {
+		if *submitRebase && *submitSign {
+			return errors.New("Adding trailers to a signed rebase is not supported.")
+		}
+		trailers, err = getSubmitTrailers(repo, r)
+		if err != nil {
+			return err
 		}
+	} else if *submitTrailers {
+		return errors.New("Trailers can only be added when submitting with --merge or --rebase.")
+	}
+
+	var mergeMessages []string
+	if *submitMerge {
+		mergeMessage, err := getSubmitMessage(repo, r)
 		if err != nil {
 			return err
 		}
+		mergeMessages = append(mergeMessages, mergeMessage)
+		if len(trailers) > 0 {
+			mergeMessages = append(mergeMessages, trailer.Format(trailers...))
+		}
+	} else if submitMessage != "" {
+		return errors.New("A merge commit message can only be specified when submitting with --merge.")
+	}
+
+	stashed, err := stashUncommittedChanges(repo, *submitAllowUncommitted, *submitStash)
+	if err != nil {
+		return err
+	}
+	accepted := source
+	strategy := submission.StrategyFastForward
+	if *submitMerge {
+		strategy = submission.StrategyMerge
+	} else if *submitRebase {
+		strategy = submission.StrategyRebase
+	}
+	if *submitRebase {
+		warnAboutNotesConfig(repo, os.Stderr)
+		op := &interruptedOperation{
+			Command:      "submit",
+			Sign:         *submitSign,
+			KeepCheckout: *submitKeepCheckout,
+			Stashed:      stashed,
+		}
+		// If the rebase is interrupted, then the stashed changes are
+		// restored by either continuing or aborting it.
+		if err := rebaseWithRecovery(repo, r, op, *submitArchive); err != nil {
+			return err
+		}
 
 		source, err = r.GetHeadCommit()
 		if err != nil {
+			return withStashNotice(err, stashed)
+		}
+		if len(trailers) > 0 {
+			source, err = amendWithTrailers(repo, r, source, trailers)
+			if err != nil {
+				return withStashNotice(err, stashed)
+			}
+		}
+	}
+	if err := mergeSubmittedReview(repo, target, source, mergeMessages); err != nil {
+		return withStashNotice(err, stashed)
+	}
+	if *submitSign {
+		if err := recordSubmission(repo, r, accepted, source, target, strategy); err != nil {
+			return withStashNotice(err, stashed)
+		}
+	}
+	return restoreStash(repo, stashed)
+}
+
+// mergeSubmittedReview merges the given source commit into the target ref,
+// and then checks out whatever was checked out before the submit started.
+func mergeSubmittedReview(repo repository.Repo, target, source string, mergeMessages []string) error {
+	if *submitVerify {
+		if err := verifySubmittedCommits(repo, target, source); err != nil {
 			return err
 		}
 	}
 
+	originalHead, err := getOriginalHead(repo)
+	if err != nil {
+		return err
+	}
 	if err := repo.SwitchToRef(target); err != nil {
 		return err
 	}
 	if *submitMerge {
-		submitMessage := fmt.Sprintf("Submitting review %.12s", r.Revision)
 		if *submitSign {
-			return repo.MergeAndSignRef(source, false, submitMessage,
-				r.Request.Description)
+			err = repo.MergeAndSignRef(source, false, mergeMessages...)
 		} else {
-			return repo.MergeRef(source, false, submitMessage,
-				r.Request.Description)
+			err = repo.MergeRef(source, false, mergeMessages...)
 		}
 	} else {
 		if *submitSign {
-			return repo.MergeAndSignRef(source, true)
+			err = repo.MergeAndSignRef(source, true)
 		} else {
-			return repo.MergeRef(source, true)
+			err = repo.MergeRef(source, true)
 		}
 	}
+	if err != nil {
+		return err
+	}
+	return restoreCheckout(repo, originalHead, *submitKeepCheckout)
 }
 
 // submitCmd defines the "submit" subcommand.
@@ -151,7 +555,7 @@ var submitCmd = &Command{
 		fmt.Printf("Usage: %s submit [<option>...] [<review-hash>]\n\nOptions:\n", arg0)
 		submitFlagSet.PrintDefaults()
 	},
-	RunMethod: func(repo repository.Repo, args []string) error {
-		return submitReview(repo, args)
+	RunMethod: func(ctx *Context, args []string) error {
+		return submitReview(ctx.Repo, args)
 	},
 }
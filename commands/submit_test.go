@@ -0,0 +1,264 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/trailer"
+)
+
+func TestRenderSubmitMessage(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := renderSubmitMessage(defaultSubmitMessageTemplate, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message != "Submitting review B\n\nB" {
+		t.Fatalf("Unexpected default submit message: %q", message)
+	}
+	message, err = renderSubmitMessage(`Merge {{.ReviewRef}} into {{.TargetRef}} (accepted by {{join .AcceptedBy ", "}})`, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message != "Merge refs/heads/ojarjur/mychange into refs/heads/master (accepted by ojarjur)" {
+		t.Fatalf("Unexpected custom submit message: %q", message)
+	}
+	if _, err := renderSubmitMessage("{{.Unknown}}", r); err == nil {
+		t.Fatal("Failed to reject a template referencing an unknown field")
+	}
+}
+
+func TestSubmitRelease(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Request.TargetTag = "v1.0"
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := submitRelease(repo, r); err != nil {
+		t.Fatal(err)
+	}
+	tagged, err := repo.GetCommitHash("refs/tags/v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged != head {
+		t.Fatalf("Unexpected commit for the release tag: %q", tagged)
+	}
+	if err := submitRelease(repo, r); err == nil {
+		t.Fatal("Failed to reject re-creating an existing release tag")
+	}
+}
+
+// repoWithoutGPG fails to sign tags, and records any unsigned tags that are
+// created instead.
+type repoWithoutGPG struct {
+	repository.Repo
+	unsignedTags []string
+}
+
+func (r *repoWithoutGPG) CreateTag(name, commit, message string) error {
+	r.unsignedTags = append(r.unsignedTags, name)
+	return r.Repo.CreateTag(name, commit, message)
+}
+
+func (r *repoWithoutGPG) CreateAndSignTag(name, commit, message string) error {
+	return errors.New("gpg failed to sign the data")
+}
+
+func TestSubmitReleaseRequiresSigning(t *testing.T) {
+	repo := &repoWithoutGPG{Repo: repository.NewMockRepoForTest()}
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Request.TargetTag = "v1.0"
+	if err := submitRelease(repo, r); err == nil || !strings.Contains(err.Error(), "always signed") {
+		t.Fatalf("Unexpected result of submitting a release without gpg: %v", err)
+	}
+	if len(repo.unsignedTags) != 0 {
+		t.Errorf("Unexpected unsigned release tags: %q", repo.unsignedTags)
+	}
+	if exists, err := repo.HasRef("refs/tags/v1.0"); err != nil || exists {
+		t.Errorf("Unexpected release tag without a signature: %v, %v", exists, err)
+	}
+}
+
+// newRepoWithAcceptedReview returns a repo in which the review G has been
+// accepted, and is a fast-forward of its target, with another branch checked
+// out.
+func newRepoWithAcceptedReview(t *testing.T) repository.Repo {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetRef(repository.TestTargetRef, repository.TestCommitF, repository.TestCommitJ); err != nil {
+		t.Fatal(err)
+	}
+	accepted := true
+	c := comment.New("ojarjur", "LGTM")
+	c.Location = &comment.Location{Commit: repository.TestCommitI}
+	c.Resolved = &accepted
+	note, err := c.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(comment.Ref, repository.TestCommitG, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SwitchToRef(repository.TestAlternateReviewRef); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestSubmitRestoresCheckout(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	repo := newRepoWithAcceptedReview(t)
+	if err := submitReview(repo, []string{repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	if submitted, err := repo.IsAncestor(repository.TestCommitI, repository.TestTargetRef); err != nil || !submitted {
+		t.Errorf("The review was not submitted into its target: %v", err)
+	}
+	if head, _ := repo.GetHeadRef(); head != repository.TestAlternateReviewRef {
+		t.Errorf("Unexpected HEAD after submitting: %q", head)
+	}
+}
+
+func TestSubmitKeepCheckout(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	defer func() { *submitKeepCheckout = false }()
+	repo := newRepoWithAcceptedReview(t)
+	if err := submitReview(repo, []string{"--keep-checkout", repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := repo.GetHeadRef(); head != repository.TestTargetRef {
+		t.Errorf("Unexpected HEAD after submitting with --keep-checkout: %q", head)
+	}
+}
+
+// repoWithUncommittedChanges has uncommitted changes, until they are stashed.
+type repoWithUncommittedChanges struct {
+	repository.Repo
+	stashed bool
+	popped  bool
+}
+
+func (r *repoWithUncommittedChanges) HasUncommittedChanges() (bool, error) {
+	return !r.stashed || r.popped, nil
+}
+
+func (r *repoWithUncommittedChanges) Stash() error {
+	r.stashed = true
+	return nil
+}
+
+func (r *repoWithUncommittedChanges) PopStash() error {
+	r.popped = true
+	return nil
+}
+
+func TestSubmitWithUncommittedChanges(t *testing.T) {
+	defer resetAcceptAndSubmitFlags()
+	defer func() {
+		*submitAllowUncommitted = false
+		*submitStash = false
+	}()
+
+	repo := &repoWithUncommittedChanges{Repo: newRepoWithAcceptedReview(t)}
+	err := submitReview(repo, []string{repository.TestCommitG})
+	if err == nil || !strings.Contains(err.Error(), "uncommitted") {
+		t.Fatalf("Unexpected result of submitting with uncommitted changes: %v", err)
+	}
+	if target, _ := repo.GetCommitHash(repository.TestTargetRef); target != repository.TestCommitF {
+		t.Errorf("Unexpected submit with uncommitted changes, into %q", target)
+	}
+
+	if err := submitReview(repo, []string{"--stash", repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.stashed || !repo.popped {
+		t.Errorf("Unexpected handling of the uncommitted changes: stashed %v, restored %v", repo.stashed, repo.popped)
+	}
+
+	*submitStash = false
+	repo = &repoWithUncommittedChanges{Repo: newRepoWithAcceptedReview(t)}
+	if err := submitReview(repo, []string{"--allow-uncommitted", repository.TestCommitG}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.stashed {
+		t.Error("Unexpected stash of the uncommitted changes with --allow-uncommitted")
+	}
+}
+
+// repoWithCreatedCommits records the commits that are created, which the mock
+// repo does not support, and returns a fixed hash for them.
+type repoWithCreatedCommits struct {
+	repository.Repo
+	created []*repository.CommitDetails
+}
+
+func (r *repoWithCreatedCommits) CreateCommit(details *repository.CommitDetails) (string, error) {
+	r.created = append(r.created, details)
+	return "amended", nil
+}
+
+func TestAmendWithTrailers(t *testing.T) {
+	repo := &repoWithCreatedCommits{Repo: repository.NewMockRepoForTest()}
+	r, err := review.Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Request.Sig = gpg.Sig{Sig: "signature of the original request"}
+	original := r.Request
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amended, err := amendWithTrailers(repo, r, head, []trailer.Trailer{{Key: "Reviewed-by", Value: "ojarjur"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended != "amended" || len(repo.created) != 1 || !strings.Contains(repo.created[0].Summary, "Reviewed-by: ojarjur") {
+		t.Fatalf("Unexpected amended commit %q: %+v", amended, repo.created)
+	}
+	requests := request.ParseAllValid(repo.GetNotes(request.Ref, repository.TestCommitG))
+	latest := requests[len(requests)-1]
+	if latest.Alias != "amended" {
+		t.Errorf("Unexpected alias of the amended request: %q", latest.Alias)
+	}
+	if latest.Sig.Sig != "" {
+		t.Errorf("The signature of the original request was kept: %q", latest.Sig.Sig)
+	}
+	if latest.Timestamp == original.Timestamp {
+		t.Errorf("The timestamp of the original request was kept: %q", latest.Timestamp)
+	}
+}
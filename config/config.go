@@ -0,0 +1,342 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config reads the settings of the tool, which can be committed to
+// the repo so that they are shared by everyone working on it, and overridden
+// by each user in their git config.
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// FileName is the path, relative to the top of the repo, of the committed
+// settings. It uses the same syntax as git config files, and is kept in the
+// .appraise directory along with the other committed files, such as the
+// definitions of teams.
+const FileName = ".appraise/config"
+
+// The sources that a setting's value can come from, in order of precedence.
+const (
+	SourceGitConfig = "git config"
+	SourceFile      = FileName
+	SourceDefault   = "default"
+)
+
+// Setting describes one of the keys that configure the tool.
+type Setting struct {
+	Key         string
+	Description string
+	// Validate returns an error if the given (non-empty) value is not valid.
+	Validate func(value string) error
+	// Committable is whether the setting can be read from the committed
+	// settings file. Settings that weaken the checks of signatures or reviews,
+	// or that say where requests and credentials are sent, are not, as
+	// otherwise checking out a branch could change them.
+	Committable bool
+}
+
+// Whether settings can be committed to the settings file.
+const (
+	committable   = true
+	gitConfigOnly = false
+)
+
+// Settings lists every setting that is validated and listed by the config
+// command.
+//
+// Other keys, such as the URLs of forks, only make sense for a single user,
+// so they are only read from git config.
+var Settings = []Setting{
+	{"appraise.target", "Ref that new reviews target by default", validateRef, committable},
+	{"appraise.submit", "Default submit strategy: merge, rebase, or fast-forward", validateOneOf("merge", "rebase", "fast-forward"), committable},
+	{"appraise.submit.trailers", "Add Reviewed-by and Reviewed-on trailers to submitted commits", validateBool, committable},
+	{"appraise.submit.messageTemplate", "Template of the message of merge commits created by submit", nil, committable},
+	{"appraise.reviewURLPrefix", "Prefix of the review's revision in the Reviewed-on trailer", nil, committable},
+	{"appraise.release.quorum", "Number of reviewers who must accept a release review", validatePositiveInt, committable},
+	{"appraise.comment.maxSize", "Maximum size of a comment, e.g. 64k, or 0 for no limit", validateSize, committable},
+	{"appraise.pull.quarantine", "Quarantine pulled review notes until they are approved", validateBool, gitConfigOnly},
+	{"appraise.pull.withCode", "Also fetch the review refs of open reviews when pulling", validateBool, gitConfigOnly},
+	{"appraise.send.requireSigned", "Refuse to send reviews whose request and comments are not signed", validateBool, gitConfigOnly},
+	{"appraise.reject.requireMessage", "Refuse to reject reviews without a message explaining why (true by default)", validateBool, gitConfigOnly},
+	{"appraise.size.maxLines", "Number of changed lines above which requesting a review warns that it is too large, or 0 for no limit", validateNonNegativeInt, committable},
+	{"appraise.size.maxFiles", "Number of changed files above which requesting a review warns that it is too large, or 0 for no limit", validateNonNegativeInt, committable},
+	{"appraise.size.linesPerHour", "Number of changed lines a reviewer reads per hour, used to estimate review times", validatePositiveInt, committable},
+	{"appraise.queue.weights", "Weights of the factors that order `list --queue`, e.g. deadline=4,labels=2,wait=2,age=1,size=1", nil, committable},
+	{"appraise.queue.labels", "How urgent the reviews with each label are in `list --queue`, between 0 and 1, e.g. urgent=1,docs=0.2", nil, committable},
+	{"appraise.claim.duration", "How long a claim on a review lasts unless it is released, e.g. 4h", validateDuration, committable},
+	{"appraise.status.provider", "Hosting provider that mirror-status sets commit statuses on: github or gitlab", validateOneOf("github", "gitlab"), gitConfigOnly},
+	{"appraise.status.project", "Project (e.g. owner/repo) that mirror-status sets commit statuses on", nil, gitConfigOnly},
+	{"appraise.status.apiUrl", "URL of the API of the hosting provider, for self-hosted instances", nil, gitConfigOnly},
+	{"appraise.status.context", "Name of the commit status set by mirror-status (git-appraise by default)", nil, committable},
+	{"appraise.notify.url", "Slack-compatible or Matrix webhook that review events are posted to", nil, gitConfigOnly},
+	{"appraise.notify.type", "Type of the notification webhook: slack or matrix, unless given by the URL scheme (e.g. slack+https://...)", validateOneOf("slack", "matrix"), committable},
+	{"appraise.notify.template", "Template of the notification messages", nil, committable},
+	{"appraise.benchmark.threshold", "Largest regression of a benchmark metric that passes, e.g. 5% or 0.05", validateFraction, committable},
+	{"appraise.analyses.timeout", "How long downloading the details of an analysis report may take, e.g. 10s", validateDuration, committable},
+	{"appraise.analyses.retries", "Number of times a failed download of the details of an analysis report is retried", validateNonNegativeInt, committable},
+	{"appraise.analyses.maxSize", "Maximum size of the details of an analysis report, e.g. 10m, or 0 for no limit", validateSize, committable},
+	{"appraise.permalink.repoId", "Name of the repository in comment permalinks, e.g. github.com/google/git-appraise (by default, from the URL of the origin remote)", nil, committable},
+	{"appraise.permalink.urlTemplate", "Template of the HTTP URLs of comments, e.g. https://reviews.example.com/{{.Review}}#{{.Comment}}", nil, committable},
+	{"appraise.web.urlTemplate", "Template of the URLs of reviews in a web UI, opened by browse, e.g. https://reviews.example.com/{{.Review}}", nil, gitConfigOnly},
+	{"appraise.lint.maxSubjectLength", "Longest subject line of the commits in a requested review, or 0 for no limit", validateNonNegativeInt, committable},
+	{"appraise.lint.requireBody", "Require the commits in a requested review to have a message body", validateBool, committable},
+	{"appraise.lint.issuePattern", "Regular expression that the messages of the commits in a requested review must match, e.g. an issue reference", validateRegexp, committable},
+	{"appraise.lint.requireSignoff", "Require the commits in a requested review to have a Signed-off-by trailer", validateBool, committable},
+	{"appraise.lint.policy", "Whether commit messages that fail the lint checks only warn, or prevent the review from being requested: warn or fail", validateOneOf("warn", "fail"), committable},
+	{"appraise.review.novelOnly", "Only require reviewing the commits whose changes were not already accepted in another review, so that a review of only such commits is accepted automatically", validateBool, gitConfigOnly},
+	{"appraise.storage.dir", "Directory that review notes are kept in instead of git notes, e.g. where reading git notes is too slow, and copied to and from when pushing and pulling", nil, gitConfigOnly},
+	{"appraise.metrics.enabled", "Record the durations and failures of pulls, and of the verification of pulled reviews, for the metrics", validateBool, gitConfigOnly},
+	{"appraise.backport.autoAccept", "Accept backports of accepted reviews automatically, since their changes were already reviewed", validateBool, gitConfigOnly},
+	{"appraise.reuse.requireSigned", "Only accept a backport, or a review of previously reviewed commits, automatically if the acceptances it reuses verify", validateBool, gitConfigOnly},
+}
+
+// Find returns the setting with the given key, or nil if there is none.
+func Find(key string) *Setting {
+	for i := range Settings {
+		if Settings[i].Key == key {
+			return &Settings[i]
+		}
+	}
+	return nil
+}
+
+// check validates the given value of the setting.
+func (s *Setting) check(value string) error {
+	if value == "" || s.Validate == nil {
+		return nil
+	}
+	if err := s.Validate(value); err != nil {
+		return fmt.Errorf("Invalid value for %s: %q; %v", s.Key, value, err)
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
+
+func validateRef(value string) error {
+	if !strings.HasPrefix(value, "refs/") {
+		return fmt.Errorf("must be a full ref name, such as refs/heads/master")
+	}
+	return nil
+}
+
+func validatePositiveInt(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 1 {
+		return fmt.Errorf("must be a positive number")
+	}
+	return nil
+}
+
+func validateNonNegativeInt(value string) error {
+	_, err := ParseNonNegativeInt(value)
+	return err
+}
+
+func validateDuration(value string) error {
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Errorf("must be a positive duration, such as 30m or 4h")
+	}
+	return nil
+}
+
+func validateFraction(value string) error {
+	_, err := ParseFraction(value)
+	return err
+}
+
+func validateSize(value string) error {
+	_, err := ParseSize(value)
+	return err
+}
+
+func validateRegexp(value string) error {
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("must be a regular expression: %v", err)
+	}
+	return nil
+}
+
+func validateOneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// ParseSize parses a number of bytes, optionally followed by a k or m suffix
+// for kibibytes or mebibytes.
+func ParseSize(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	multiplier := 1
+	if strings.HasSuffix(value, "k") {
+		multiplier = 1024
+	} else if strings.HasSuffix(value, "m") {
+		multiplier = 1024 * 1024
+	}
+	size, err := strconv.Atoi(strings.TrimRight(value, "km"))
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("must be a number of bytes, optionally followed by k or m")
+	}
+	return size * multiplier, nil
+}
+
+// ParseNonNegativeInt parses a non-negative number, such as a count or a
+// length, that has no unit.
+func ParseNonNegativeInt(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("must be a non-negative number")
+	}
+	return n, nil
+}
+
+// ParseFraction parses a non-negative fraction, given either as a percentage
+// (e.g. "5%") or as a number (e.g. "0.05").
+func ParseFraction(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	divisor := 1.0
+	if strings.HasSuffix(value, "%") {
+		value, divisor = strings.TrimSuffix(value, "%"), 100
+	}
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil || fraction < 0 {
+		return 0, fmt.Errorf("must be a non-negative percentage, e.g. 5%%, or fraction, e.g. 0.05")
+	}
+	return fraction / divisor, nil
+}
+
+// canonicalKey returns the form of the given key that git uses when listing
+// config files, in which the section and variable names are lowercase.
+func canonicalKey(key string) string {
+	first, last := strings.Index(key, "."), strings.LastIndex(key, ".")
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+// lookup returns the value of the given key in the parsed config file.
+func lookup(values map[string]string, key string) string {
+	key = canonicalKey(key)
+	for k, v := range values {
+		if canonicalKey(k) == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// FromFile returns the value of the given key in the committed settings
+// file, or the empty string if it is not set there or can not be committed.
+//
+// The value is validated, so that a mistake in the file is reported rather
+// than silently ignored.
+func FromFile(repo repository.Repo, key string) (string, error) {
+	setting := Find(key)
+	if setting == nil || !setting.Committable {
+		return "", nil
+	}
+	values, err := repo.ReadConfigFile(FileName)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %s: %v", FileName, err)
+	}
+	value := lookup(values, key)
+	if err := setting.check(value); err != nil {
+		return "", fmt.Errorf("%s: %v", FileName, err)
+	}
+	return value, nil
+}
+
+// Get returns the value of the given key, which is read from git config if it
+// is set there, and otherwise from the committed settings file if the setting
+// can be committed.
+func Get(repo repository.Repo, key string) (string, error) {
+	value, err := repo.GetConfig(key)
+	if err != nil || value != "" {
+		return value, err
+	}
+	return FromFile(repo, key)
+}
+
+// Lookup returns the value of the given key, which is read from git config if
+// it is set there, and otherwise from the committed settings file.
+//
+// Unlike Get, the key does not need to be one of the Settings, so this is
+// meant for user-defined keys such as canned comments, and the value is not
+// validated.
+func Lookup(repo repository.Repo, key string) (string, error) {
+	value, err := repo.GetConfig(key)
+	if err != nil || value != "" {
+		return value, err
+	}
+	if setting := Find(key); setting != nil && !setting.Committable {
+		return "", nil
+	}
+	values, err := repo.ReadConfigFile(FileName)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %s: %v", FileName, err)
+	}
+	return lookup(values, key), nil
+}
+
+// Value is the effective value of a setting, and where it came from.
+type Value struct {
+	Setting
+	Value  string
+	Source string
+	// Err is set if the value is invalid.
+	Err error
+}
+
+// List returns the effective value of every setting.
+func List(repo repository.Repo) ([]Value, error) {
+	fileValues, err := repo.ReadConfigFile(FileName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %v", FileName, err)
+	}
+	var values []Value
+	for _, setting := range Settings {
+		v := Value{Setting: setting, Source: SourceDefault}
+		configured, err := repo.GetConfig(setting.Key)
+		if err != nil {
+			return nil, err
+		}
+		if configured != "" {
+			v.Value, v.Source = configured, SourceGitConfig
+		} else if fileValue := lookup(fileValues, setting.Key); fileValue != "" && setting.Committable {
+			v.Value, v.Source = fileValue, SourceFile
+		}
+		v.Err = setting.check(v.Value)
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// repoWithFile is a mock repo with a committed settings file.
+type repoWithFile struct {
+	repository.Repo
+	file map[string]string
+}
+
+func (r repoWithFile) ReadConfigFile(path string) (map[string]string, error) {
+	if path != FileName {
+		return nil, nil
+	}
+	return r.file, nil
+}
+
+func TestGet(t *testing.T) {
+	repo := repoWithFile{
+		Repo: repository.NewMockRepoForTest(),
+		file: map[string]string{
+			"appraise.submit":                 "rebase",
+			"appraise.submit.messagetemplate": "Merge {{.Revision}}",
+			"appraise.release.quorum":         "two",
+			"appraise.pull.quarantine":        "false",
+			"appraise.reject.requireMessage":  "false",
+		},
+	}
+	if err := repo.AddConfigValue("appraise.submit", "merge"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := Get(repo, "appraise.submit"); err != nil || value != "merge" {
+		t.Errorf("Git config did not take precedence over the settings file: %q, %v", value, err)
+	}
+	if value, err := Get(repo, "appraise.submit.messageTemplate"); err != nil || value != "Merge {{.Revision}}" {
+		t.Errorf("Unexpected value from the settings file: %q, %v", value, err)
+	}
+	if _, err := Get(repo, "appraise.release.quorum"); err == nil {
+		t.Error("Unexpected success reading an invalid value from the settings file")
+	}
+	if value, err := FromFile(repo, "appraise.fork.origin.url"); err != nil || value != "" {
+		t.Errorf("Unexpected value of a setting that can not be committed: %q, %v", value, err)
+	}
+	if value, err := Get(repo, "appraise.pull.quarantine"); err != nil || value != "" {
+		t.Errorf("Unexpected committed value of a setting that is only read from git config: %q, %v", value, err)
+	}
+	if value, err := Get(repo, "appraise.reject.requireMessage"); err != nil || value != "" {
+		t.Errorf("Unexpected committed value of a setting that weakens rejections: %q, %v", value, err)
+	}
+	if value, err := Lookup(repo, "appraise.pull.quarantine"); err != nil || value != "" {
+		t.Errorf("Unexpected committed value of a setting that is only looked up in git config: %q, %v", value, err)
+	}
+
+	values, err := List(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]string)
+	invalid := make(map[string]bool)
+	for _, v := range values {
+		sources[v.Key] = v.Source
+		invalid[v.Key] = v.Err != nil
+	}
+	if sources["appraise.submit"] != SourceGitConfig || sources["appraise.submit.messageTemplate"] != SourceFile || sources["appraise.target"] != SourceDefault || sources["appraise.pull.quarantine"] != SourceDefault {
+		t.Errorf("Unexpected sources of the settings: %v", sources)
+	}
+	if !invalid["appraise.release.quorum"] || invalid["appraise.submit"] {
+		t.Errorf("Unexpected validation of the settings: %v", invalid)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int{
+		"0":    0,
+		"100":  100,
+		"64k":  64 * 1024,
+		"2M":   2 * 1024 * 1024,
+		" 8k ": 8 * 1024,
+	}
+	for value, expected := range cases {
+		if size, err := ParseSize(value); err != nil || size != expected {
+			t.Errorf("Unexpected size for %q: %d, %v", value, size, err)
+		}
+	}
+	for _, value := range []string{"", "-1", "big", "1g"} {
+		if _, err := ParseSize(value); err == nil {
+			t.Errorf("Unexpected success parsing %q", value)
+		}
+	}
+}
+
+func TestParseFraction(t *testing.T) {
+	cases := map[string]float64{
+		"0":     0,
+		"0.05":  0.05,
+		"5%":    0.05,
+		" 10% ": 0.1,
+	}
+	for value, expected := range cases {
+		if fraction, err := ParseFraction(value); err != nil || fraction != expected {
+			t.Errorf("Unexpected fraction for %q: %v, %v", value, fraction, err)
+		}
+	}
+	for _, value := range []string{"", "-1%", "five", "5%%"} {
+		if _, err := ParseFraction(value); err == nil {
+			t.Errorf("Unexpected success parsing %q", value)
+		}
+	}
+}
@@ -26,19 +26,23 @@ limitations under the License.
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/google/git-appraise/commands"
-	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/tracing"
 	"os"
 	"sort"
 	"strings"
 )
 
-const usageMessageTemplate = `Usage: %s <command>
+const usageMessageTemplate = `Usage: %s [<global option>...] <command>
 
 Where <command> is one of:
   %s
 
+And the global options are:
+%s
 For individual command usage, run:
   %s help <command>
 `
@@ -50,54 +54,96 @@ func usage() {
 		subcommands = append(subcommands, subcommand)
 	}
 	sort.Strings(subcommands)
-	fmt.Printf(usageMessageTemplate, command, strings.Join(subcommands, "\n  "), command)
+	fmt.Printf(usageMessageTemplate, command, strings.Join(subcommands, "\n  "),
+		commands.GlobalOptionsUsage(), command)
 }
 
-func help() {
-	if len(os.Args) < 3 {
+func help(args []string) {
+	if len(args) < 2 {
 		usage()
 		return
 	}
-	subcommand, ok := commands.CommandMap[os.Args[2]]
+	subcommand, ok := commands.CommandMap[args[1]]
 	if !ok {
-		fmt.Printf("Unknown command %q\n", os.Args[2])
+		fmt.Printf("Unknown command %q\n", args[1])
 		usage()
 		return
 	}
 	subcommand.Usage(os.Args[0])
 }
 
+// firstArg returns the first of the given args, or the empty string if there are none.
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// runCommand runs the given subcommand, tracing it if tracing is enabled,
+// waits for the notifications it posts in the background, and then exports
+// the trace.
+func runCommand(subcommand *commands.Command, ctx *commands.Context, name string, args []string) error {
+	span := tracing.Start("git-appraise "+name, tracing.Attribute{Key: "appraise.command", Value: name})
+	err := subcommand.Run(ctx, args)
+	commands.WaitForNotifications()
+	span.End(err)
+	if err := tracing.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to export the trace: %v\n", err)
+	}
+	return err
+}
+
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "help" {
-		help()
+	if err := tracing.SetupFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing is disabled: %v\n", err)
+	}
+	options, args, err := commands.ParseGlobalOptions(os.Args[1:])
+	if err == flag.ErrHelp {
+		usage()
 		return
 	}
-	cwd, err := os.Getwd()
 	if err != nil {
-		fmt.Printf("Unable to get the current working directory: %q\n", err)
+		fmt.Println(err.Error())
+		usage()
+		os.Exit(1)
+	}
+	if len(args) > 0 && args[0] == "help" {
+		help(args)
+		return
+	}
+	ctx, err := commands.NewContext(*options)
+	if subcommand, ok := commands.CommandMap[firstArg(args)]; err != nil && ok && subcommand.NoRepo {
+		// The command does not need a repo, so it runs without one.
+		if err := runCommand(subcommand, &commands.Context{Options: *options}, args[0], args[1:]); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
 		return
 	}
-	repo, err := repository.NewGitRepo(cwd)
 	if err != nil {
-		fmt.Printf("%s must be run from within a git repo.\n", os.Args[0])
+		fmt.Println(err.Error())
 		return
 	}
-	if len(os.Args) < 2 {
+	if program, err := ctx.Repo.GetConfig("gpg.program"); err == nil && program != "" {
+		gpg.Program = program
+	}
+	if len(args) < 1 {
 		subcommand, ok := commands.CommandMap["list"]
 		if !ok {
 			fmt.Printf("Unable to list reviews")
 			return
 		}
-		subcommand.Run(repo, []string{})
+		runCommand(subcommand, ctx, "list", []string{})
 		return
 	}
-	subcommand, ok := commands.CommandMap[os.Args[1]]
+	subcommand, ok := commands.CommandMap[args[0]]
 	if !ok {
-		fmt.Printf("Unknown command: %q\n", os.Args[1])
+		fmt.Printf("Unknown command: %q\n", args[0])
 		usage()
 		return
 	}
-	if err := subcommand.Run(repo, os.Args[2:]); err != nil {
+	if err := runCommand(subcommand, ctx, args[0], args[1:]); err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
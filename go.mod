@@ -2,4 +2,9 @@ module github.com/google/git-appraise
 
 go 1.18
 
-require golang.org/x/sys v0.0.0-20220406163625-3f8b81556e12
+require (
+	github.com/alecthomas/chroma/v2 v2.3.0
+	golang.org/x/sys v0.0.0-20220406163625-3f8b81556e12
+)
+
+require github.com/dlclark/regexp2 v1.4.0 // indirect
@@ -0,0 +1,260 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hosting mirrors the state of reviews to the commit statuses of
+// hosting providers, such as GitHub and GitLab, so that the branch
+// protection rules of the host can require a review to be accepted.
+package hosting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/ci"
+)
+
+// The names of the supported hosting providers.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// DefaultContext is the name under which the status is reported, unless
+// another one is configured.
+const DefaultContext = "git-appraise"
+
+// maxDescriptionLength is the longest description that GitHub accepts, in
+// characters.
+const maxDescriptionLength = 140
+
+// Timeout is how long a request to the API of a provider may take.
+const Timeout = 30 * time.Second
+
+// State is the state of a commit status.
+type State string
+
+// The states of a commit status, named as in the GitHub API.
+const (
+	StatePending State = "pending"
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+	StateError   State = "error"
+)
+
+// Status is a commit status that describes the state of a review.
+type Status struct {
+	State       State
+	Context     string
+	Description string
+	TargetURL   string
+}
+
+// Provider sets the statuses of commits on a hosting provider.
+type Provider interface {
+	// SetStatus sets the status of the given commit, replacing any
+	// previous status with the same context.
+	SetStatus(commit string, status Status) error
+}
+
+// DefaultAPIURL returns the URL of the API of the public instance of the
+// given provider.
+func DefaultAPIURL(provider string) string {
+	switch provider {
+	case GitHub:
+		return "https://api.github.com"
+	case GitLab:
+		return "https://gitlab.com/api/v4"
+	}
+	return ""
+}
+
+// NewProvider returns a client of the API of the given provider, which sets
+// the statuses of the commits of the given project (e.g. "owner/repo").
+func NewProvider(provider, apiURL, project, token string) (Provider, error) {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL(provider)
+	}
+	client := &apiClient{
+		client: &http.Client{Timeout: Timeout},
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+	}
+	switch provider {
+	case GitHub:
+		return &gitHubProvider{client, project}, nil
+	case GitLab:
+		return &gitLabProvider{client, project}, nil
+	}
+	return nil, fmt.Errorf("unsupported hosting provider %q; must be one of %s, %s", provider, GitHub, GitLab)
+}
+
+// ParseRemoteURL returns the host and the project path (without any ".git"
+// suffix) of the given git remote URL, which may be either a URL such as
+// "https://github.com/owner/repo.git" or an scp-like address such as
+// "git@github.com:owner/repo.git".
+func ParseRemoteURL(remoteURL string) (string, string, error) {
+	var host, path string
+	if parsed, err := url.Parse(remoteURL); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		host, path = parsed.Hostname(), parsed.Path
+	} else if i := strings.Index(remoteURL, ":"); i > 0 && !strings.Contains(remoteURL[:i], "/") {
+		host, path = remoteURL[:i], remoteURL[i+1:]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", "", fmt.Errorf("unable to find the project of the remote URL %q", remoteURL)
+	}
+	return host, path, nil
+}
+
+// ProviderForHost returns the provider of the public instance at the given
+// host, or the empty string if the host is not a known provider.
+func ProviderForHost(host string) string {
+	switch host {
+	case "github.com":
+		return GitHub
+	case "gitlab.com":
+		return GitLab
+	}
+	return ""
+}
+
+// ReviewState returns the state of the commit status that reflects the
+// given review, and a description of why the review is in that state.
+//
+// Only reviews that have been accepted, and whose latest CI report (if any)
+// did not fail, are successful.
+func ReviewState(r *review.Review) (State, string) {
+	if r.IsAbandoned() {
+		return StateFailure, "The review was abandoned"
+	}
+	if r.IsDraft() {
+		return StatePending, "The review is a work in progress"
+	}
+	if latest, err := ci.GetLatestCIReport(r.Reports); err == nil && latest != nil && latest.Status == ci.StatusFailure {
+		return StateFailure, "The latest CI run failed"
+	}
+	if r.Resolved == nil {
+		return StatePending, "Waiting for the reviewers"
+	}
+	if !*r.Resolved {
+		return StateFailure, fmt.Sprintf("Changes requested, with %d unresolved comments", len(r.UnresolvedThreads()))
+	}
+	if accepted := r.AcceptedBy(); len(accepted) > 0 {
+		return StateSuccess, "Accepted by " + strings.Join(accepted, ", ")
+	}
+	return StateSuccess, "Accepted"
+}
+
+// truncateDescription shortens the given description to the length limit
+// of the hosting providers, without splitting any characters.
+func truncateDescription(description string) string {
+	runes := []rune(description)
+	if len(runes) <= maxDescriptionLength {
+		return description
+	}
+	return string(runes[:maxDescriptionLength-3]) + "..."
+}
+
+// apiClient sends authenticated JSON requests to the API of a provider.
+type apiClient struct {
+	client *http.Client
+	apiURL string
+	token  string
+}
+
+// post sends the given body as JSON to the given path of the API, with the
+// given authentication header.
+func (c *apiClient) post(path string, body interface{}, authHeader, authValue string) error {
+	contents, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.apiURL+path, bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set(authHeader, authValue)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failure setting the commit status: %s %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// gitHubProvider sets commit statuses with the GitHub REST API.
+type gitHubProvider struct {
+	*apiClient
+	project string
+}
+
+// SetStatus sets the status of the given commit.
+func (p *gitHubProvider) SetStatus(commit string, status Status) error {
+	body := map[string]string{
+		"state":       string(status.State),
+		"context":     status.Context,
+		"description": truncateDescription(status.Description),
+	}
+	if status.TargetURL != "" {
+		body["target_url"] = status.TargetURL
+	}
+	return p.post("/repos/"+p.project+"/statuses/"+commit, body, "Authorization", "Bearer "+p.token)
+}
+
+// gitLabProvider sets commit statuses with the GitLab REST API.
+type gitLabProvider struct {
+	*apiClient
+	project string
+}
+
+// gitLabStates maps the states of commit statuses to their GitLab names.
+var gitLabStates = map[State]string{
+	StatePending: "pending",
+	StateSuccess: "success",
+	StateFailure: "failed",
+	StateError:   "failed",
+}
+
+// SetStatus sets the status of the given commit.
+func (p *gitLabProvider) SetStatus(commit string, status Status) error {
+	body := map[string]string{
+		"state":       gitLabStates[status.State],
+		"name":        status.Context,
+		"description": truncateDescription(status.Description),
+	}
+	if status.TargetURL != "" {
+		body["target_url"] = status.TargetURL
+	}
+	return p.post("/projects/"+url.PathEscape(p.project)+"/statuses/"+commit, body, "PRIVATE-TOKEN", p.token)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hosting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/ci"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	for _, tc := range []struct {
+		remoteURL   string
+		wantHost    string
+		wantProject string
+	}{
+		{"https://github.com/owner/repo.git", "github.com", "owner/repo"},
+		{"https://user@gitlab.com/group/sub/repo", "gitlab.com", "group/sub/repo"},
+		{"git@github.com:owner/repo.git", "github.com", "owner/repo"},
+		{"ssh://git@example.com:2222/owner/repo.git", "example.com", "owner/repo"},
+	} {
+		host, project, err := ParseRemoteURL(tc.remoteURL)
+		if err != nil || host != tc.wantHost || project != tc.wantProject {
+			t.Errorf("ParseRemoteURL(%q) = %q, %q, %v; want %q, %q",
+				tc.remoteURL, host, project, err, tc.wantHost, tc.wantProject)
+		}
+	}
+	if _, _, err := ParseRemoteURL("/local/path/repo"); err == nil {
+		t.Error("Failed to reject a local path")
+	}
+}
+
+func TestReviewState(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	for _, tc := range []struct {
+		revision string
+		want     State
+	}{
+		{repository.TestCommitB, StateSuccess},
+		{repository.TestCommitG, StatePending},
+	} {
+		r, err := review.Get(repo, tc.revision)
+		if err != nil || r == nil {
+			t.Fatalf("Failed to load the review %q: %v", tc.revision, err)
+		}
+		if state, description := ReviewState(r); state != tc.want {
+			t.Errorf("ReviewState(%q) = %q, %q; want %q", tc.revision, state, description, tc.want)
+		}
+	}
+
+	r, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected := false
+	r.Resolved = &rejected
+	if state, _ := ReviewState(r); state != StateFailure {
+		t.Errorf("ReviewState() of a rejected review = %q; want %q", state, StateFailure)
+	}
+	accepted := true
+	r.Resolved = &accepted
+	r.Reports = []ci.Report{{Timestamp: "1", Status: ci.StatusSuccess}, {Timestamp: "2", Status: ci.StatusFailure}}
+	if state, _ := ReviewState(r); state != StateFailure {
+		t.Errorf("ReviewState() of an accepted review with failing CI = %q; want %q", state, StateFailure)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization") + r.Header.Get("PRIVATE-TOKEN")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	status := Status{State: StateFailure, Context: DefaultContext, Description: "Changes requested"}
+	for _, tc := range []struct {
+		provider  string
+		wantPath  string
+		wantAuth  string
+		stateKey  string
+		wantState string
+	}{
+		{GitHub, "/repos/owner/repo/statuses/abc", "Bearer token", "state", "failure"},
+		{GitLab, "/projects/owner%2Frepo/statuses/abc", "token", "state", "failed"},
+	} {
+		provider, err := NewProvider(tc.provider, ts.URL, "owner/repo", "token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := provider.SetStatus("abc", status); err != nil {
+			t.Fatal(err)
+		}
+		if gotPath != tc.wantPath || gotAuth != tc.wantAuth || gotBody[tc.stateKey] != tc.wantState {
+			t.Errorf("%s: got request to %q with auth %q and body %v", tc.provider, gotPath, gotAuth, gotBody)
+		}
+	}
+	if _, err := NewProvider("bitbucket", ts.URL, "owner/repo", ""); err == nil {
+		t.Error("Failed to reject an unsupported provider")
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if got := truncateDescription("Accepted"); got != "Accepted" {
+		t.Errorf("Unexpected truncation of a short description: %q", got)
+	}
+	got := truncateDescription("Accepted by " + strings.Repeat("é", maxDescriptionLength))
+	if !utf8.ValidString(got) || utf8.RuneCountInString(got) != maxDescriptionLength || !strings.HasSuffix(got, "...") {
+		t.Errorf("Unexpected truncation of a long description: %q", got)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BitbucketAPIURL is the URL of the API of Bitbucket Cloud.
+const BitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// Bitbucket reads the pull requests of a Bitbucket repository.
+type Bitbucket struct {
+	client *apiClient
+	repo   string
+}
+
+// NewBitbucket returns a source of the pull requests of the given repository
+// (e.g. "workspace/repo") from the Bitbucket API at the given URL.
+func NewBitbucket(apiURL, repo, token string) *Bitbucket {
+	if apiURL == "" {
+		apiURL = BitbucketAPIURL
+	}
+	client := &apiClient{apiURL: strings.TrimSuffix(apiURL, "/"), authHeader: "Authorization"}
+	if token != "" {
+		client.authValue = "Bearer " + token
+	}
+	return &Bitbucket{client: client, repo: repo}
+}
+
+type bitbucketUser struct {
+	Nickname string `json:"nickname"`
+}
+
+type bitbucketEnd struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+type bitbucketPullRequest struct {
+	ID           int             `json:"id"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	State        string          `json:"state"`
+	Author       bitbucketUser   `json:"author"`
+	Reviewers    []bitbucketUser `json:"reviewers"`
+	Source       bitbucketEnd    `json:"source"`
+	Destination  bitbucketEnd    `json:"destination"`
+	CreatedOn    time.Time       `json:"created_on"`
+	Participants []struct {
+		User           bitbucketUser `json:"user"`
+		Approved       bool          `json:"approved"`
+		ParticipatedOn time.Time     `json:"participated_on"`
+	} `json:"participants"`
+}
+
+type bitbucketComment struct {
+	ID      int `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	User      bitbucketUser `json:"user"`
+	CreatedOn time.Time     `json:"created_on"`
+	Deleted   bool          `json:"deleted"`
+	Inline    *struct {
+		Path string  `json:"path"`
+		To   *uint32 `json:"to"`
+		From *uint32 `json:"from"`
+	} `json:"inline"`
+	Parent *struct {
+		ID int `json:"id"`
+	} `json:"parent"`
+}
+
+// bitbucketStates maps the states of Bitbucket pull requests to those of
+// pull requests.
+var bitbucketStates = map[string]State{
+	"OPEN":       StateOpen,
+	"MERGED":     StateMerged,
+	"DECLINED":   StateClosed,
+	"SUPERSEDED": StateClosed,
+}
+
+// getAll reads every item of the list at the given path, following the next
+// links of the pages.
+func (b *Bitbucket) getAll(path string) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	for next := path; next != ""; {
+		var page struct {
+			Values []json.RawMessage `json:"values"`
+			Next   string            `json:"next"`
+		}
+		if _, err := b.client.get(next, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Values...)
+		next = page.Next
+	}
+	return all, nil
+}
+
+// comments reads the comments on the given pull request, leaving out those
+// that were deleted.
+func (b *Bitbucket) comments(id int) ([]Comment, error) {
+	items, err := b.getAll(fmt.Sprintf("/repositories/%s/pullrequests/%d/comments?pagelen=100", b.repo, id))
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, item := range items {
+		var bc bitbucketComment
+		if err := json.Unmarshal(item, &bc); err != nil {
+			return nil, err
+		}
+		if bc.Deleted {
+			continue
+		}
+		c := Comment{
+			ID:     strconv.Itoa(bc.ID),
+			Author: bc.User.Nickname,
+			Body:   bc.Content.Raw,
+			Time:   bc.CreatedOn,
+		}
+		if bc.Parent != nil {
+			c.ParentID = strconv.Itoa(bc.Parent.ID)
+		}
+		if bc.Inline != nil {
+			c.Path = bc.Inline.Path
+			if bc.Inline.To != nil {
+				c.Line = *bc.Inline.To
+			} else if bc.Inline.From != nil {
+				c.Line, c.OldSide = *bc.Inline.From, true
+			}
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+// PullRequests reads every pull request of the repository.
+func (b *Bitbucket) PullRequests() ([]PullRequest, error) {
+	items, err := b.getAll(fmt.Sprintf("/repositories/%s/pullrequests?state=OPEN&state=MERGED&state=DECLINED&state=SUPERSEDED&pagelen=50", b.repo))
+	if err != nil {
+		return nil, err
+	}
+	var prs []PullRequest
+	for _, item := range items {
+		var bp bitbucketPullRequest
+		if err := json.Unmarshal(item, &bp); err != nil {
+			return nil, err
+		}
+		pr := PullRequest{
+			ID:           strconv.Itoa(bp.ID),
+			Title:        bp.Title,
+			Description:  bp.Description,
+			Author:       bp.Author.Nickname,
+			State:        bitbucketStates[bp.State],
+			Time:         bp.CreatedOn,
+			SourceBranch: bp.Source.Branch.Name,
+			TargetBranch: bp.Destination.Branch.Name,
+			HeadCommit:   bp.Source.Commit.Hash,
+			BaseCommit:   bp.Destination.Commit.Hash,
+		}
+		for _, reviewer := range bp.Reviewers {
+			pr.Reviewers = append(pr.Reviewers, reviewer.Nickname)
+		}
+		for _, participant := range bp.Participants {
+			if participant.Approved {
+				pr.Approvals = append(pr.Approvals, Approval{Author: participant.User.Nickname, Time: participant.ParticipatedOn})
+			}
+		}
+		if pr.Comments, err = b.comments(bp.ID); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
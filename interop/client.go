@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Timeout is how long each request to the API of a tool may take.
+const Timeout = 30 * time.Second
+
+// httpClient sends the requests to the APIs of tools.
+var httpClient = &http.Client{Timeout: Timeout}
+
+// apiClient sends authenticated requests to the REST API of a tool.
+type apiClient struct {
+	apiURL     string
+	authHeader string
+	authValue  string
+}
+
+// sameOrigin returns whether the given URLs have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	parsedA, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	parsedB, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return parsedA.Scheme == parsedB.Scheme && strings.EqualFold(parsedA.Host, parsedB.Host)
+}
+
+// get decodes the JSON response to a GET request for the given URL, which is
+// relative to the API unless it is absolute, and returns the response's
+// headers, which some APIs paginate with.
+//
+// The credentials are only sent to the host of the API, and not to the hosts
+// of absolute URLs that its responses link to.
+func (c *apiClient) get(url string, v interface{}) (http.Header, error) {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		url = c.apiURL + url
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authValue != "" && sameOrigin(url, c.apiURL) {
+		req.Header.Set(c.authHeader, c.authValue)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failure reading %s: %s %s", url, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
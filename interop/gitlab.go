@@ -0,0 +1,212 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitLabAPIURL is the URL of the API of the public GitLab instance.
+const GitLabAPIURL = "https://gitlab.com/api/v4"
+
+// GitLab reads the merge requests of a GitLab project.
+type GitLab struct {
+	client  *apiClient
+	project string
+}
+
+// NewGitLab returns a source of the merge requests of the given project
+// (e.g. "group/project") from the GitLab API at the given URL.
+func NewGitLab(apiURL, project, token string) *GitLab {
+	if apiURL == "" {
+		apiURL = GitLabAPIURL
+	}
+	return &GitLab{
+		client:  &apiClient{apiURL: strings.TrimSuffix(apiURL, "/"), authHeader: "PRIVATE-TOKEN", authValue: token},
+		project: project,
+	}
+}
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+type gitLabMergeRequest struct {
+	IID          int          `json:"iid"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	State        string       `json:"state"`
+	Author       gitLabUser   `json:"author"`
+	Reviewers    []gitLabUser `json:"reviewers"`
+	SourceBranch string       `json:"source_branch"`
+	TargetBranch string       `json:"target_branch"`
+	SHA          string       `json:"sha"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	DiffRefs     struct {
+		BaseSHA string `json:"base_sha"`
+	} `json:"diff_refs"`
+}
+
+type gitLabNote struct {
+	ID        int        `json:"id"`
+	Body      string     `json:"body"`
+	Author    gitLabUser `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	System    bool       `json:"system"`
+	Position  *struct {
+		NewPath string `json:"new_path"`
+		NewLine uint32 `json:"new_line"`
+		OldPath string `json:"old_path"`
+		OldLine uint32 `json:"old_line"`
+	} `json:"position"`
+}
+
+type gitLabDiscussion struct {
+	Notes []gitLabNote `json:"notes"`
+}
+
+type gitLabApprovals struct {
+	ApprovedBy []struct {
+		User gitLabUser `json:"user"`
+	} `json:"approved_by"`
+}
+
+// gitLabStates maps the states of merge requests to those of pull requests.
+var gitLabStates = map[string]State{
+	"opened": StateOpen,
+	"merged": StateMerged,
+	"closed": StateClosed,
+	"locked": StateClosed,
+}
+
+// getAll reads every item of the list at the given path, following the
+// X-Next-Page headers of the responses.
+func (g *GitLab) getAll(path string) ([]json.RawMessage, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	var all []json.RawMessage
+	for page := "1"; page != ""; {
+		var items []json.RawMessage
+		header, err := g.client.get(path+separator+"per_page=100&page="+page, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		page = header.Get("X-Next-Page")
+	}
+	return all, nil
+}
+
+// projectPath returns the path of the API of the project.
+func (g *GitLab) projectPath() string {
+	return "/projects/" + url.PathEscape(g.project)
+}
+
+// comments reads the comments on the given merge request. Each discussion
+// is a thread, whose first note is the one that the others reply to.
+func (g *GitLab) comments(iid int) ([]Comment, error) {
+	items, err := g.getAll(fmt.Sprintf("%s/merge_requests/%d/discussions", g.projectPath(), iid))
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, item := range items {
+		var discussion gitLabDiscussion
+		if err := json.Unmarshal(item, &discussion); err != nil {
+			return nil, err
+		}
+		parentID := ""
+		for _, note := range discussion.Notes {
+			if note.System {
+				continue
+			}
+			c := Comment{
+				ID:       strconv.Itoa(note.ID),
+				ParentID: parentID,
+				Author:   note.Author.Username,
+				Body:     note.Body,
+				Time:     note.CreatedAt,
+			}
+			if note.Position != nil {
+				c.Path, c.Line = note.Position.NewPath, note.Position.NewLine
+				if c.Line == 0 && note.Position.OldLine != 0 {
+					// The comment is on a line that was deleted.
+					c.Path, c.Line, c.OldSide = note.Position.OldPath, note.Position.OldLine, true
+				}
+				if c.Path == "" {
+					c.Path = note.Position.OldPath
+				}
+			}
+			if parentID == "" {
+				parentID = c.ID
+			}
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
+
+// PullRequests reads every merge request of the project.
+func (g *GitLab) PullRequests() ([]PullRequest, error) {
+	items, err := g.getAll(g.projectPath() + "/merge_requests?state=all")
+	if err != nil {
+		return nil, err
+	}
+	var prs []PullRequest
+	for _, item := range items {
+		var mr gitLabMergeRequest
+		if err := json.Unmarshal(item, &mr); err != nil {
+			return nil, err
+		}
+		pr := PullRequest{
+			ID:           strconv.Itoa(mr.IID),
+			Title:        mr.Title,
+			Description:  mr.Description,
+			Author:       mr.Author.Username,
+			State:        gitLabStates[mr.State],
+			Time:         mr.CreatedAt,
+			SourceBranch: mr.SourceBranch,
+			TargetBranch: mr.TargetBranch,
+			HeadCommit:   mr.SHA,
+			BaseCommit:   mr.DiffRefs.BaseSHA,
+		}
+		for _, reviewer := range mr.Reviewers {
+			pr.Reviewers = append(pr.Reviewers, reviewer.Username)
+		}
+		if pr.Comments, err = g.comments(mr.IID); err != nil {
+			return nil, err
+		}
+		var approvals gitLabApprovals
+		if _, err := g.client.get(fmt.Sprintf("%s/merge_requests/%d/approvals", g.projectPath(), mr.IID), &approvals); err != nil {
+			return nil, err
+		}
+		for _, approval := range approvals.ApprovedBy {
+			// GitLab does not say when each approval was given.
+			pr.Approvals = append(pr.Approvals, Approval{Author: approval.User.Username, Time: mr.UpdatedAt})
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package interop imports the pull requests of other code review tools, such
+// as the merge requests of GitLab, the pull requests of Bitbucket, and the
+// Differential revisions of Phabricator, as git-appraise reviews.
+//
+// Each tool's client maps its pull requests into the common PullRequest
+// type, which an Importer then writes as request and comment notes, so that
+// adding another tool only requires reading its API.
+package interop
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+// State is the state of a pull request.
+type State string
+
+// The states of a pull request.
+const (
+	StateOpen   State = "open"
+	StateMerged State = "merged"
+	StateClosed State = "closed"
+)
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	// ID identifies the comment within its tool, and ParentID identifies
+	// the comment that it replies to, if any.
+	ID       string
+	ParentID string
+	Author   string
+	Body     string
+	Time     time.Time
+	// Path and Line are the file and line that the comment is about, if it
+	// is an inline comment.
+	Path string
+	Line uint32
+	// OldSide is whether the line is in the file as it was at the base
+	// commit (e.g. a deleted line), rather than at the head commit.
+	OldSide bool
+}
+
+// Approval is the approval of a pull request by one of its reviewers.
+type Approval struct {
+	Author string
+	Time   time.Time
+}
+
+// PullRequest is a pull request, or merge request, in another code review tool.
+//
+// The authors of the pull request, comments, and approvals are the usernames
+// of the tool, which an Importer maps to emails.
+type PullRequest struct {
+	ID          string
+	Title       string
+	Description string
+	Author      string
+	Reviewers   []string
+	State       State
+	Time        time.Time
+	// SourceBranch and TargetBranch are the names of the branches that the
+	// pull request merges from and into.
+	SourceBranch string
+	TargetBranch string
+	// HeadCommit is the commit being reviewed, and BaseCommit is the commit
+	// of the target branch that it is compared against, if known. Either
+	// may be abbreviated.
+	HeadCommit string
+	BaseCommit string
+	Comments   []Comment
+	Approvals  []Approval
+}
+
+// Source lists the pull requests of a project in another code review tool.
+type Source interface {
+	PullRequests() ([]PullRequest, error)
+}
+
+// Importer writes pull requests to a repository as reviews.
+type Importer struct {
+	Repo repository.Repo
+	// Host is the host of the tool, which the emails of unmapped users are in.
+	Host string
+	// Lookup returns the email of the given username, or the empty string if
+	// it is not known, in which case the email is <username>@<host>.
+	Lookup func(username string) string
+}
+
+// Email returns the email of the given username.
+func (i *Importer) Email(username string) string {
+	if i.Lookup != nil {
+		if email := i.Lookup(username); email != "" {
+			return email
+		}
+	}
+	return username + "@" + i.Host
+}
+
+// description returns the description of the review of the given pull request.
+func description(pr PullRequest) string {
+	if strings.TrimSpace(pr.Description) == "" {
+		return pr.Title
+	}
+	return pr.Title + "\n\n" + pr.Description
+}
+
+// writeComment appends the given comment to the review of the given commit,
+// and returns its hash.
+func (i *Importer) writeComment(commit string, c comment.Comment) (string, error) {
+	note, err := c.Write()
+	if err != nil {
+		return "", err
+	}
+	if err := i.Repo.AppendNote(comment.Ref, commit, note); err != nil {
+		return "", err
+	}
+	return c.Hash()
+}
+
+// Import writes the given pull request as a review of its head commit,
+// returning whether a review was created. Pull requests whose head commits
+// have not been fetched, or that already have reviews, are skipped.
+func (i *Importer) Import(pr PullRequest) (bool, error) {
+	commit, err := i.Repo.ResolveRefCommit(pr.HeadCommit)
+	if err != nil || i.Repo.VerifyCommit(commit) != nil {
+		return false, nil
+	}
+	if existing := request.ParseAllValid(i.Repo.GetNotes(request.Ref, commit)); len(existing) > 0 {
+		return false, nil
+	}
+
+	var reviewers []string
+	for _, reviewer := range pr.Reviewers {
+		reviewers = append(reviewers, i.Email(reviewer))
+	}
+	reviewRef := ""
+	if pr.SourceBranch != "" {
+		reviewRef = "refs/heads/" + pr.SourceBranch
+	}
+	r := request.New(i.Email(pr.Author), reviewers, reviewRef, "refs/heads/"+pr.TargetBranch, description(pr))
+	r.Timestamp = timestamp.Format(pr.Time)
+	if pr.BaseCommit != "" {
+		if base, err := i.Repo.ResolveRefCommit(pr.BaseCommit); err == nil {
+			r.BaseCommit = base
+		}
+	}
+	note, err := r.Write()
+	if err != nil {
+		return false, err
+	}
+	if err := i.Repo.AppendNote(request.Ref, commit, note); err != nil {
+		return false, err
+	}
+
+	// Replies are written after the comments they reply to, so that the
+	// hashes of their parents are known.
+	comments := append([]Comment(nil), pr.Comments...)
+	sort.SliceStable(comments, func(a, b int) bool {
+		return comments[a].Time.Before(comments[b].Time)
+	})
+	hashes := make(map[string]string)
+	for _, c := range comments {
+		imported := comment.New(i.Email(c.Author), c.Body)
+		imported.Timestamp = timestamp.Format(c.Time)
+		imported.Location = &comment.Location{Commit: commit}
+		if c.Path != "" {
+			imported.Location.Path = c.Path
+			if c.Line > 0 && !c.OldSide {
+				imported.Location.Range = &comment.Range{StartLine: c.Line}
+			} else if c.Line > 0 && r.BaseCommit != "" {
+				// Lines of the old side of the diff are in the file as it
+				// was at the base commit, so the comment is placed there.
+				imported.Location.Commit = r.BaseCommit
+				imported.Location.Range = &comment.Range{StartLine: c.Line}
+			}
+		}
+		imported.Parent = hashes[c.ParentID]
+		hash, err := i.writeComment(commit, imported)
+		if err != nil {
+			return true, fmt.Errorf("failed to import comment %s: %v", c.ID, err)
+		}
+		hashes[c.ID] = hash
+	}
+	for _, approval := range pr.Approvals {
+		resolved := true
+		imported := comment.New(i.Email(approval.Author), "")
+		imported.Timestamp = timestamp.Format(approval.Time)
+		imported.Location = &comment.Location{Commit: commit}
+		imported.Resolved = &resolved
+		if _, err := i.writeComment(commit, imported); err != nil {
+			return true, fmt.Errorf("failed to import the approval of %s: %v", approval.Author, err)
+		}
+	}
+
+	if pr.State == StateClosed {
+		// Closing a pull request without merging it abandons the review.
+		r.TargetRef = ""
+		note, err := r.Write()
+		if err != nil {
+			return true, err
+		}
+		if err := i.Repo.AppendNote(request.Ref, commit, note); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+var testTime = time.Unix(1700000000, 0).UTC()
+
+func TestImport(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	importer := &Importer{
+		Repo: repo,
+		Host: "gitlab.com",
+		Lookup: func(username string) string {
+			if username == "alice" {
+				return "alice@example.com"
+			}
+			return ""
+		},
+	}
+	pr := PullRequest{
+		ID:           "7",
+		Title:        "Fix the parser",
+		Description:  "It crashed on empty input.",
+		Author:       "alice",
+		Reviewers:    []string{"bob"},
+		State:        StateMerged,
+		Time:         testTime,
+		SourceBranch: "fix",
+		TargetBranch: "master",
+		HeadCommit:   repository.TestCommitE,
+		BaseCommit:   repository.TestCommitA,
+		Comments: []Comment{
+			{ID: "2", ParentID: "1", Author: "alice", Body: "Done", Time: testTime.Add(2 * time.Minute)},
+			{ID: "1", Author: "bob", Body: "Check for nil", Time: testTime.Add(time.Minute), Path: "parser.go", Line: 12},
+			{ID: "3", Author: "bob", Body: "Why remove this?", Time: testTime.Add(4 * time.Minute), Path: "lexer.go", Line: 7, OldSide: true},
+		},
+		Approvals: []Approval{{Author: "bob", Time: testTime.Add(3 * time.Minute)}},
+	}
+	if created, err := importer.Import(pr); err != nil || !created {
+		t.Fatalf("Failed to import the pull request: %v, %v", created, err)
+	}
+	if created, err := importer.Import(pr); err != nil || created {
+		t.Errorf("The pull request was imported twice: %v, %v", created, err)
+	}
+
+	r, err := review.Get(repo, repository.TestCommitE)
+	if err != nil || r == nil {
+		t.Fatalf("Failed to load the imported review: %v", err)
+	}
+	if r.Request.Requester != "alice@example.com" || !reflect.DeepEqual(r.Request.Reviewers, []string{"bob@gitlab.com"}) {
+		t.Errorf("Unexpected request: %+v", r.Request)
+	}
+	if r.Request.Description != "Fix the parser\n\nIt crashed on empty input." || r.Request.BaseCommit != repository.TestCommitA {
+		t.Errorf("Unexpected request: %+v", r.Request)
+	}
+	if len(r.Comments) != 3 {
+		t.Fatalf("Unexpected comment threads: %+v", r.Comments)
+	}
+	thread := r.Comments[0]
+	if thread.Comment.Description != "Check for nil" || thread.Comment.Location.Path != "parser.go" || len(thread.Children) != 1 {
+		t.Errorf("Unexpected comment thread: %+v", thread)
+	}
+	// Comments on the old side of the diff are placed on the base commit.
+	if location := r.Comments[2].Comment.Location; location.Commit != repository.TestCommitA || location.Path != "lexer.go" || location.Range == nil || location.Range.StartLine != 7 {
+		t.Errorf("Unexpected location of a comment on a deleted line: %+v", location)
+	}
+	if r.Resolved == nil || !*r.Resolved {
+		t.Errorf("The approval was not imported: %+v", r.Summary)
+	}
+
+	closed := pr
+	closed.HeadCommit = repository.TestCommitF
+	closed.State = StateClosed
+	if _, err := importer.Import(closed); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := review.Get(repo, repository.TestCommitF); err != nil || r == nil || !r.IsAbandoned() {
+		t.Errorf("The closed pull request was not abandoned: %+v, %v", r, err)
+	}
+
+	missing := pr
+	missing.HeadCommit = "0123456789abcdef"
+	if created, err := importer.Import(missing); err != nil || created {
+		t.Errorf("Imported a pull request whose head commit is missing: %v, %v", created, err)
+	}
+}
+
+// serveJSON returns a server that responds to the given paths, including
+// their queries, with the given values as JSON.
+func serveJSON(t *testing.T, responses map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("Unexpected request for %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestGitLab(t *testing.T) {
+	server := serveJSON(t, map[string]interface{}{
+		"/projects/group%2Fproject/merge_requests?state=all&per_page=100&page=1": []map[string]interface{}{
+			{"iid": 3, "title": "T", "state": "closed", "author": map[string]string{"username": "alice"},
+				"source_branch": "s", "target_branch": "master", "sha": "abc", "created_at": testTime,
+				"diff_refs": map[string]string{"base_sha": "def"}},
+		},
+		"/projects/group%2Fproject/merge_requests/3/discussions?per_page=100&page=1": []map[string]interface{}{
+			{"notes": []map[string]interface{}{
+				{"id": 10, "body": "Why?", "author": map[string]string{"username": "bob"}, "created_at": testTime,
+					"position": map[string]interface{}{"new_path": "a.go", "new_line": 4}},
+				{"id": 11, "body": "Because", "author": map[string]string{"username": "alice"}, "created_at": testTime},
+				{"id": 12, "body": "changed the description", "system": true},
+			}},
+		},
+		"/projects/group%2Fproject/merge_requests/3/approvals": map[string]interface{}{
+			"approved_by": []map[string]interface{}{{"user": map[string]string{"username": "bob"}}},
+		},
+	})
+	defer server.Close()
+
+	prs, err := NewGitLab(server.URL, "group/project", "").PullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PullRequest{{
+		ID: "3", Title: "T", Author: "alice", State: StateClosed, Time: testTime,
+		SourceBranch: "s", TargetBranch: "master", HeadCommit: "abc", BaseCommit: "def",
+		Comments: []Comment{
+			{ID: "10", Author: "bob", Body: "Why?", Time: testTime, Path: "a.go", Line: 4},
+			{ID: "11", ParentID: "10", Author: "alice", Body: "Because", Time: testTime},
+		},
+		Approvals: []Approval{{Author: "bob"}},
+	}}
+	if !reflect.DeepEqual(prs, want) {
+		t.Errorf("Unexpected merge requests: %+v; want %+v", prs, want)
+	}
+}
+
+func TestBitbucket(t *testing.T) {
+	server := serveJSON(t, map[string]interface{}{
+		"/repositories/ws/repo/pullrequests?state=OPEN&state=MERGED&state=DECLINED&state=SUPERSEDED&pagelen=50": map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"id": 5, "title": "T", "state": "MERGED", "author": map[string]string{"nickname": "alice"},
+					"reviewers":   []map[string]string{{"nickname": "bob"}},
+					"source":      map[string]interface{}{"branch": map[string]string{"name": "s"}, "commit": map[string]string{"hash": "abc"}},
+					"destination": map[string]interface{}{"branch": map[string]string{"name": "main"}, "commit": map[string]string{"hash": "def"}},
+					"created_on":  testTime,
+					"participants": []map[string]interface{}{
+						{"user": map[string]string{"nickname": "bob"}, "approved": true, "participated_on": testTime},
+						{"user": map[string]string{"nickname": "carol"}, "approved": false, "participated_on": testTime},
+					}},
+			},
+		},
+		"/repositories/ws/repo/pullrequests/5/comments?pagelen=100": map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"id": 20, "content": map[string]string{"raw": "Why?"}, "user": map[string]string{"nickname": "bob"},
+					"created_on": testTime, "inline": map[string]interface{}{"path": "a.go", "to": 4}},
+				{"id": 23, "content": map[string]string{"raw": "Gone"}, "user": map[string]string{"nickname": "bob"},
+					"created_on": testTime, "inline": map[string]interface{}{"path": "a.go", "from": 9}},
+			},
+			"next": "/page2",
+		},
+		"/page2": map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"id": 21, "content": map[string]string{"raw": "Because"}, "user": map[string]string{"nickname": "alice"},
+					"created_on": testTime, "parent": map[string]int{"id": 20}},
+				{"id": 22, "deleted": true},
+			},
+		},
+	})
+	defer server.Close()
+
+	prs, err := NewBitbucket(server.URL, "ws/repo", "").PullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PullRequest{{
+		ID: "5", Title: "T", Author: "alice", Reviewers: []string{"bob"}, State: StateMerged, Time: testTime,
+		SourceBranch: "s", TargetBranch: "main", HeadCommit: "abc", BaseCommit: "def",
+		Comments: []Comment{
+			{ID: "20", Author: "bob", Body: "Why?", Time: testTime, Path: "a.go", Line: 4},
+			{ID: "23", Author: "bob", Body: "Gone", Time: testTime, Path: "a.go", Line: 9, OldSide: true},
+			{ID: "21", ParentID: "20", Author: "alice", Body: "Because", Time: testTime},
+		},
+		Approvals: []Approval{{Author: "bob", Time: testTime}},
+	}}
+	if !reflect.DeepEqual(prs, want) {
+		t.Errorf("Unexpected pull requests: %+v; want %+v", prs, want)
+	}
+}
+
+func TestCredentialsOnlySentToAPI(t *testing.T) {
+	var elsewhere string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		elsewhere = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(map[string]interface{}{"values": []interface{}{}})
+	}))
+	defer other.Close()
+	var api string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode(map[string]interface{}{"values": []interface{}{}, "next": other.URL + "/page2"})
+	}))
+	defer server.Close()
+
+	if _, err := NewBitbucket(server.URL, "ws/repo", "secret").getAll("/items"); err != nil {
+		t.Fatal(err)
+	}
+	if api != "Bearer secret" || elsewhere != "" {
+		t.Errorf("Unexpected credentials sent to the API (%q) and to another host (%q)", api, elsewhere)
+	}
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Phabricator reads the Differential revisions of a Phabricator repository,
+// either from its Conduit API or from a dump of what was read from it, so
+// that the revisions can still be imported once Phabricator is shut down.
+type Phabricator struct {
+	apiURL     string
+	repository string
+	token      string
+	dump       *phabricatorDump
+}
+
+// NewPhabricator returns a source of the revisions of the repository with the
+// given PHID (or of every repository, if it is empty) from the Conduit API of
+// the Phabricator instance at the given URL.
+func NewPhabricator(apiURL, repository, token string) *Phabricator {
+	return &Phabricator{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		repository: repository,
+		token:      token,
+	}
+}
+
+// ReadPhabricatorDump returns a source of the revisions in the given dump, as
+// written by WriteDump.
+func ReadPhabricatorDump(r io.Reader) (*Phabricator, error) {
+	var dump phabricatorDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, err
+	}
+	return &Phabricator{dump: &dump}, nil
+}
+
+// phabricatorDump holds what is read from the Conduit API: the results of
+// differential.revision.search, differential.diff.search, and user.search,
+// along with those of transaction.search for each revision, by its PHID.
+type phabricatorDump struct {
+	Revisions    []phabricatorRevision               `json:"revisions"`
+	Diffs        []phabricatorDiff                   `json:"diffs"`
+	Transactions map[string][]phabricatorTransaction `json:"transactions"`
+	Users        []phabricatorUser                   `json:"users"`
+}
+
+type phabricatorRevision struct {
+	ID     int    `json:"id"`
+	PHID   string `json:"phid"`
+	Fields struct {
+		Title      string `json:"title"`
+		Summary    string `json:"summary"`
+		AuthorPHID string `json:"authorPHID"`
+		DiffPHID   string `json:"diffPHID"`
+		Status     struct {
+			Value string `json:"value"`
+		} `json:"status"`
+		DateCreated int64 `json:"dateCreated"`
+	} `json:"fields"`
+	Attachments struct {
+		Reviewers struct {
+			Reviewers []struct {
+				ReviewerPHID string `json:"reviewerPHID"`
+			} `json:"reviewers"`
+		} `json:"reviewers"`
+	} `json:"attachments"`
+}
+
+type phabricatorDiff struct {
+	PHID   string `json:"phid"`
+	Fields struct {
+		Refs []struct {
+			Type       string `json:"type"`
+			Name       string `json:"name"`
+			Identifier string `json:"identifier"`
+		} `json:"refs"`
+	} `json:"fields"`
+	Attachments struct {
+		Commits struct {
+			Commits []struct {
+				Identifier string `json:"identifier"`
+			} `json:"commits"`
+		} `json:"commits"`
+	} `json:"attachments"`
+}
+
+type phabricatorTransaction struct {
+	Type        string `json:"type"`
+	AuthorPHID  string `json:"authorPHID"`
+	DateCreated int64  `json:"dateCreated"`
+	Comments    []struct {
+		PHID    string `json:"phid"`
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	} `json:"comments"`
+	Fields struct {
+		Path               string `json:"path"`
+		Line               uint32 `json:"line"`
+		ReplyToCommentPHID string `json:"replyToCommentPHID"`
+	} `json:"fields"`
+}
+
+type phabricatorUser struct {
+	PHID   string `json:"phid"`
+	Fields struct {
+		Username string `json:"username"`
+	} `json:"fields"`
+}
+
+// call calls the given Conduit method, and decodes its result.
+func (p *Phabricator) call(method string, params map[string]interface{}, result interface{}) error {
+	params["__conduit__"] = map[string]string{"token": p.token}
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	form := url.Values{
+		"params":      {string(encodedParams)},
+		"output":      {"json"},
+		"__conduit__": {"1"},
+	}
+	resp, err := httpClient.PostForm(p.apiURL+"/api/"+method, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failure calling %s: %s %s", method, resp.Status, strings.TrimSpace(string(message)))
+	}
+	var response struct {
+		Result    json.RawMessage `json:"result"`
+		ErrorCode string          `json:"error_code"`
+		ErrorInfo string          `json:"error_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return err
+	}
+	if response.ErrorCode != "" {
+		return fmt.Errorf("failure calling %s: %s: %s", method, response.ErrorCode, response.ErrorInfo)
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// search calls the given search method, following its cursor through every
+// page of results, and appends each result to the given slice.
+func (p *Phabricator) search(method string, params map[string]interface{}, results interface{}) error {
+	var all []json.RawMessage
+	for after := ""; ; {
+		if after != "" {
+			params["after"] = after
+		}
+		var page struct {
+			Data   []json.RawMessage `json:"data"`
+			Cursor struct {
+				After *string `json:"after"`
+			} `json:"cursor"`
+		}
+		if err := p.call(method, params, &page); err != nil {
+			return err
+		}
+		all = append(all, page.Data...)
+		if page.Cursor.After == nil || *page.Cursor.After == "" {
+			break
+		}
+		after = *page.Cursor.After
+	}
+	encoded, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, results)
+}
+
+// fetch reads the revisions, with their diffs, transactions, and users, from
+// the Conduit API, unless they have already been read.
+func (p *Phabricator) fetch() (*phabricatorDump, error) {
+	if p.dump != nil {
+		return p.dump, nil
+	}
+	if p.apiURL == "" {
+		return nil, errors.New("the URL of Phabricator is required")
+	}
+	dump := &phabricatorDump{Transactions: make(map[string][]phabricatorTransaction)}
+	constraints := map[string]interface{}{}
+	if p.repository != "" {
+		constraints["repositoryPHIDs"] = []string{p.repository}
+	}
+	if err := p.search("differential.revision.search", map[string]interface{}{
+		"constraints": constraints,
+		"attachments": map[string]bool{"reviewers": true},
+	}, &dump.Revisions); err != nil {
+		return nil, err
+	}
+	var diffPHIDs []string
+	userPHIDs := make(map[string]bool)
+	for _, revision := range dump.Revisions {
+		diffPHIDs = append(diffPHIDs, revision.Fields.DiffPHID)
+		userPHIDs[revision.Fields.AuthorPHID] = true
+		for _, reviewer := range revision.Attachments.Reviewers.Reviewers {
+			userPHIDs[reviewer.ReviewerPHID] = true
+		}
+		var transactions []phabricatorTransaction
+		if err := p.search("transaction.search", map[string]interface{}{
+			"objectIdentifier": revision.PHID,
+		}, &transactions); err != nil {
+			return nil, err
+		}
+		for _, transaction := range transactions {
+			userPHIDs[transaction.AuthorPHID] = true
+		}
+		dump.Transactions[revision.PHID] = transactions
+	}
+	if len(diffPHIDs) > 0 {
+		if err := p.search("differential.diff.search", map[string]interface{}{
+			"constraints": map[string]interface{}{"phids": diffPHIDs},
+			"attachments": map[string]bool{"commits": true},
+		}, &dump.Diffs); err != nil {
+			return nil, err
+		}
+	}
+	var users []string
+	for phid := range userPHIDs {
+		if strings.HasPrefix(phid, "PHID-USER-") {
+			users = append(users, phid)
+		}
+	}
+	if len(users) > 0 {
+		if err := p.search("user.search", map[string]interface{}{
+			"constraints": map[string]interface{}{"phids": users},
+		}, &dump.Users); err != nil {
+			return nil, err
+		}
+	}
+	p.dump = dump
+	return dump, nil
+}
+
+// WriteDump writes the revisions as JSON, which ReadPhabricatorDump reads.
+func (p *Phabricator) WriteDump(w io.Writer) error {
+	dump, err := p.fetch()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
+
+// phabricatorStates maps the statuses of revisions to the states of pull
+// requests. Published revisions are those that have landed.
+var phabricatorStates = map[string]State{
+	"published": StateMerged,
+	"abandoned": StateClosed,
+}
+
+// PullRequests returns the revisions, as pull requests. Inline comments are
+// on the lines of the new version of their files, and accepts are approvals.
+func (p *Phabricator) PullRequests() ([]PullRequest, error) {
+	dump, err := p.fetch()
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[string]string)
+	for _, user := range dump.Users {
+		usernames[user.PHID] = user.Fields.Username
+	}
+	username := func(phid string) string {
+		if name, ok := usernames[phid]; ok {
+			return name
+		}
+		return phid
+	}
+	diffs := make(map[string]phabricatorDiff)
+	for _, diff := range dump.Diffs {
+		diffs[diff.PHID] = diff
+	}
+
+	var prs []PullRequest
+	for _, revision := range dump.Revisions {
+		state, ok := phabricatorStates[revision.Fields.Status.Value]
+		if !ok {
+			state = StateOpen
+		}
+		pr := PullRequest{
+			ID:           fmt.Sprintf("D%d", revision.ID),
+			Title:        revision.Fields.Title,
+			Description:  revision.Fields.Summary,
+			Author:       username(revision.Fields.AuthorPHID),
+			State:        state,
+			Time:         time.Unix(revision.Fields.DateCreated, 0),
+			TargetBranch: "master",
+		}
+		for _, reviewer := range revision.Attachments.Reviewers.Reviewers {
+			if strings.HasPrefix(reviewer.ReviewerPHID, "PHID-USER-") {
+				pr.Reviewers = append(pr.Reviewers, username(reviewer.ReviewerPHID))
+			}
+		}
+		diff := diffs[revision.Fields.DiffPHID]
+		for _, ref := range diff.Fields.Refs {
+			switch ref.Type {
+			case "base":
+				pr.BaseCommit = ref.Identifier
+			case "branch":
+				pr.SourceBranch = ref.Name
+			case "onto":
+				pr.TargetBranch = ref.Name
+			}
+		}
+		if commits := diff.Attachments.Commits.Commits; len(commits) > 0 {
+			// The commits of a diff are listed from the newest.
+			pr.HeadCommit = commits[0].Identifier
+		}
+		for _, transaction := range dump.Transactions[revision.PHID] {
+			author := username(transaction.AuthorPHID)
+			when := time.Unix(transaction.DateCreated, 0)
+			switch transaction.Type {
+			case "accept":
+				pr.Approvals = append(pr.Approvals, Approval{Author: author, Time: when})
+			case "comment", "inline":
+				if len(transaction.Comments) == 0 {
+					continue
+				}
+				// Edited comments have a version for each edit, newest first.
+				c := transaction.Comments[0]
+				pr.Comments = append(pr.Comments, Comment{
+					ID:       c.PHID,
+					ParentID: transaction.Fields.ReplyToCommentPHID,
+					Author:   author,
+					Body:     c.Content.Raw,
+					Time:     when,
+					Path:     transaction.Fields.Path,
+					Line:     transaction.Fields.Line,
+				})
+			}
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
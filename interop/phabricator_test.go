@@ -0,0 +1,102 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interop
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// conduitResults are the results of the Conduit methods for a single revision.
+var conduitResults = map[string]string{
+	"differential.revision.search": `{"data": [{"id": 9, "phid": "PHID-DREV-1", "fields": {
+		"title": "T", "summary": "S", "authorPHID": "PHID-USER-a", "diffPHID": "PHID-DIFF-1",
+		"status": {"value": "published"}, "dateCreated": 1700000000},
+		"attachments": {"reviewers": {"reviewers": [{"reviewerPHID": "PHID-USER-b"}, {"reviewerPHID": "PHID-PROJ-x"}]}}}],
+		"cursor": {"after": null}}`,
+	"transaction.search": `{"data": [
+		{"type": "inline", "authorPHID": "PHID-USER-a", "dateCreated": 1700000200,
+			"comments": [{"phid": "PHID-XCMT-2", "content": {"raw": "Because"}}],
+			"fields": {"path": "a.go", "line": 4, "replyToCommentPHID": "PHID-XCMT-1"}},
+		{"type": "accept", "authorPHID": "PHID-USER-b", "dateCreated": 1700000300},
+		{"type": "inline", "authorPHID": "PHID-USER-b", "dateCreated": 1700000100,
+			"comments": [{"phid": "PHID-XCMT-1", "content": {"raw": "Why?"}}, {"phid": "PHID-XCMT-1", "content": {"raw": "Wh"}}],
+			"fields": {"path": "a.go", "line": 4}},
+		{"type": "title", "authorPHID": "PHID-USER-a", "dateCreated": 1700000000}],
+		"cursor": {"after": null}}`,
+	"differential.diff.search": `{"data": [{"phid": "PHID-DIFF-1", "fields": {"refs": [
+		{"type": "base", "identifier": "def"}, {"type": "branch", "name": "s"}, {"type": "onto", "name": "main"}]},
+		"attachments": {"commits": {"commits": [{"identifier": "abc"}, {"identifier": "older"}]}}}],
+		"cursor": {"after": null}}`,
+	"user.search": `{"data": [{"phid": "PHID-USER-a", "fields": {"username": "alice"}},
+		{"phid": "PHID-USER-b", "fields": {"username": "bob"}}], "cursor": {"after": null}}`,
+}
+
+func TestPhabricator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[len("/api/"):]
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(r.FormValue("params")), &params); err != nil {
+			t.Errorf("Unexpected params for %s: %v", method, err)
+		}
+		if token := params["__conduit__"].(map[string]interface{})["token"]; token != "api-secret" {
+			w.Write([]byte(`{"result": null, "error_code": "ERR-INVALID-AUTH", "error_info": "bad token"}`))
+			return
+		}
+		w.Write([]byte(`{"result": ` + conduitResults[method] + `, "error_code": null, "error_info": null}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewPhabricator(server.URL, "", "wrong").PullRequests(); err == nil {
+		t.Error("Failed to report a Conduit error")
+	}
+	source := NewPhabricator(server.URL, "PHID-REPO-1", "api-secret")
+	prs, err := source.PullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PullRequest{{
+		ID: "D9", Title: "T", Description: "S", Author: "alice", Reviewers: []string{"bob"},
+		State: StateMerged, Time: time.Unix(1700000000, 0),
+		SourceBranch: "s", TargetBranch: "main", HeadCommit: "abc", BaseCommit: "def",
+		Comments: []Comment{
+			{ID: "PHID-XCMT-2", ParentID: "PHID-XCMT-1", Author: "alice", Body: "Because", Time: time.Unix(1700000200, 0), Path: "a.go", Line: 4},
+			{ID: "PHID-XCMT-1", Author: "bob", Body: "Why?", Time: time.Unix(1700000100, 0), Path: "a.go", Line: 4},
+		},
+		Approvals: []Approval{{Author: "bob", Time: time.Unix(1700000300, 0)}},
+	}}
+	if !reflect.DeepEqual(prs, want) {
+		t.Errorf("Unexpected revisions: %+v; want %+v", prs, want)
+	}
+
+	var dump bytes.Buffer
+	if err := source.WriteDump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	fromDump, err := ReadPhabricatorDump(&dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prs, err := fromDump.PullRequests(); err != nil || !reflect.DeepEqual(prs, want) {
+		t.Errorf("Unexpected revisions from the dump: %+v, %v; want %+v", prs, err, want)
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics reports the health of a repository's reviews, such as one
+// that is a central mirror of them, in the Prometheus text format.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+// StateFile is the name of the file, in the repository's git directory, that
+// records the results of pulls for the metrics.
+const StateFile = "appraise-metrics.json"
+
+// Pull records the results of pulling reviews from a single remote.
+type Pull struct {
+	// Timestamp is when the last pull finished, in seconds since the epoch.
+	Timestamp int64 `json:"timestamp"`
+	// Seconds is how long the last pull took.
+	Seconds float64 `json:"seconds"`
+	// Succeeded is whether the last pull succeeded.
+	Succeeded bool `json:"succeeded"`
+	// Failures is the number of pulls that failed.
+	Failures int `json:"failures"`
+	// VerificationFailures is the number of pulled reviews whose
+	// signatures could not be verified.
+	VerificationFailures int `json:"verificationFailures"`
+}
+
+// State is what is recorded in the StateFile, by remote.
+type State struct {
+	Pulls map[string]Pull `json:"pulls"`
+}
+
+func statePath(repo repository.Repo) string {
+	return filepath.Join(repo.GetGitDir(), StateFile)
+}
+
+// ReadState reads the recorded results of pulls.
+func ReadState(repo repository.Repo) (*State, error) {
+	state := &State{Pulls: make(map[string]Pull)}
+	contents, err := ioutil.ReadFile(statePath(repo))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contents, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", StateFile, err)
+	}
+	if state.Pulls == nil {
+		state.Pulls = make(map[string]Pull)
+	}
+	return state, nil
+}
+
+// updatePull applies the given update to the recorded results of pulling
+// from the given remote.
+func updatePull(repo repository.Repo, remote string, update func(*Pull)) error {
+	state, err := ReadState(repo)
+	if err != nil {
+		return err
+	}
+	pull := state.Pulls[remote]
+	update(&pull)
+	state.Pulls[remote] = pull
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(statePath(repo), contents, 0644)
+}
+
+// RecordPull records that a pull from the given remote finished at the given
+// time after taking the given duration, and whether it succeeded.
+func RecordPull(repo repository.Repo, remote string, finished time.Time, duration time.Duration, succeeded bool) error {
+	return updatePull(repo, remote, func(pull *Pull) {
+		pull.Timestamp = finished.Unix()
+		pull.Seconds = duration.Seconds()
+		pull.Succeeded = succeeded
+		if !succeeded {
+			pull.Failures++
+		}
+	})
+}
+
+// RecordVerificationFailures records that the signatures of the given number
+// of reviews pulled from the given remote could not be verified.
+func RecordVerificationFailures(repo repository.Repo, remote string, count int) error {
+	return updatePull(repo, remote, func(pull *Pull) {
+		pull.VerificationFailures += count
+	})
+}
+
+// Snapshot is the state of a repository's reviews at one point in time.
+type Snapshot struct {
+	Reviews     int
+	OpenReviews int
+	// Notes is the number of objects that have notes in each notes ref.
+	Notes map[string]int
+	State *State
+}
+
+// Collect takes a snapshot of the state of the given repository's reviews,
+// counting the notes in each of the given notes refs.
+func Collect(repo repository.Repo, notesRefs []string) (*Snapshot, error) {
+	state, err := ReadState(repo)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{
+		Notes: make(map[string]int),
+		State: state,
+	}
+	for _, r := range review.ListAll(repo) {
+		s.Reviews++
+		if r.IsOpen() {
+			s.OpenReviews++
+		}
+	}
+	for _, ref := range notesRefs {
+		objects, err := repo.ListNotedObjects(ref)
+		if err != nil {
+			return nil, err
+		}
+		s.Notes[ref] = len(objects)
+	}
+	return s, nil
+}
+
+// metric writes the HELP and TYPE lines of a metric.
+func metric(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sortedKeys returns the keys of the given map in order.
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sample is a single value of a metric, with its labels other than the
+// repository's.
+type sample struct {
+	labels string
+	value  string
+}
+
+// snapshotMetrics lists the metrics of a snapshot, in the order they are
+// written.
+var snapshotMetrics = []struct {
+	name, kind, help string
+	samples          func(*Snapshot) []sample
+}{
+	{"appraise_reviews", "gauge", "Number of reviews.",
+		func(s *Snapshot) []sample { return []sample{{"", fmt.Sprint(s.Reviews)}} }},
+	{"appraise_open_reviews", "gauge", "Number of reviews that are neither submitted nor abandoned.",
+		func(s *Snapshot) []sample { return []sample{{"", fmt.Sprint(s.OpenReviews)}} }},
+	{"appraise_notes", "gauge", "Number of objects with notes in each notes ref.",
+		func(s *Snapshot) []sample {
+			var samples []sample
+			for _, ref := range sortedKeys(s.Notes) {
+				samples = append(samples, sample{fmt.Sprintf("ref=%q", ref), fmt.Sprint(s.Notes[ref])})
+			}
+			return samples
+		}},
+	{"appraise_last_pull_timestamp_seconds", "gauge", "When the last pull from each remote finished.",
+		pullSamples(func(p Pull) string { return fmt.Sprint(p.Timestamp) })},
+	{"appraise_last_pull_duration_seconds", "gauge", "How long the last pull from each remote took.",
+		pullSamples(func(p Pull) string { return fmt.Sprint(p.Seconds) })},
+	{"appraise_last_pull_success", "gauge", "Whether the last pull from each remote succeeded.",
+		pullSamples(func(p Pull) string {
+			if p.Succeeded {
+				return "1"
+			}
+			return "0"
+		})},
+	{"appraise_pull_failures_total", "counter", "Number of pulls from each remote that failed.",
+		pullSamples(func(p Pull) string { return fmt.Sprint(p.Failures) })},
+	{"appraise_verification_failures_total", "counter", "Number of reviews pulled from each remote whose signatures could not be verified.",
+		pullSamples(func(p Pull) string { return fmt.Sprint(p.VerificationFailures) })},
+}
+
+// pullSamples returns a function that lists the given value of the recorded
+// pull from each remote.
+func pullSamples(value func(Pull) string) func(*Snapshot) []sample {
+	return func(s *Snapshot) []sample {
+		var remotes []string
+		for remote := range s.State.Pulls {
+			remotes = append(remotes, remote)
+		}
+		sort.Strings(remotes)
+		var samples []sample
+		for _, remote := range remotes {
+			samples = append(samples, sample{fmt.Sprintf("remote=%q", remote), value(s.State.Pulls[remote])})
+		}
+		return samples
+	}
+}
+
+// Write writes the snapshot in the Prometheus text format.
+func (s *Snapshot) Write(w io.Writer) error {
+	return WriteAll(w, map[string]*Snapshot{"": s})
+}
+
+// WriteAll writes the snapshots of several repositories, keyed by their
+// names, in the Prometheus text format. Each sample is labelled with the name
+// of its repository, unless that name is empty.
+func WriteAll(w io.Writer, snapshots map[string]*Snapshot) error {
+	var names []string
+	for name := range snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, m := range snapshotMetrics {
+		metric(w, m.name, m.kind, m.help)
+		for _, name := range names {
+			for _, sample := range m.samples(snapshots[name]) {
+				var labels []string
+				if name != "" {
+					labels = append(labels, fmt.Sprintf("repo=%q", name))
+				}
+				if sample.labels != "" {
+					labels = append(labels, sample.labels)
+				}
+				series := m.name
+				if len(labels) > 0 {
+					series += "{" + strings.Join(labels, ",") + "}"
+				}
+				if _, err := fmt.Fprintf(w, "%s %s\n", series, sample.value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Handler returns an HTTP handler that serves a fresh snapshot of the given
+// repository's reviews on every request.
+func Handler(repo repository.Repo, notesRefs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := Collect(repo, notesRefs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.Write(w)
+	})
+}
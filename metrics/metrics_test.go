@@ -0,0 +1,96 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+// tempGitDirRepo is a mock repo whose git directory is a temporary directory.
+type tempGitDirRepo struct {
+	repository.Repo
+	gitDir string
+}
+
+func (r tempGitDirRepo) GetGitDir() string { return r.gitDir }
+
+func TestMetrics(t *testing.T) {
+	repo := tempGitDirRepo{repository.NewMockRepoForTest(), t.TempDir()}
+	finished := time.Unix(1700000000, 0)
+	if err := RecordPull(repo, "origin", finished, 1500*time.Millisecond, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordVerificationFailures(repo, "mirror", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordPull(repo, "mirror", finished, 2*time.Second, false); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Collect(repo, []string{request.Ref, comment.Ref})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := s.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE appraise_reviews gauge\nappraise_reviews 3\n",
+		"appraise_open_reviews ",
+		`appraise_notes{ref="refs/notes/devtools/reviews"} 3`,
+		`appraise_notes{ref="refs/notes/devtools/discuss"} 2`,
+		`appraise_last_pull_timestamp_seconds{remote="origin"} 1700000000`,
+		`appraise_last_pull_duration_seconds{remote="origin"} 1.5`,
+		`appraise_last_pull_success{remote="mirror"} 0`,
+		`appraise_last_pull_success{remote="origin"} 1`,
+		`appraise_pull_failures_total{remote="mirror"} 1`,
+		`appraise_verification_failures_total{remote="mirror"} 2`,
+		`appraise_verification_failures_total{remote="origin"} 0`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("The metrics do not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := WriteAll(&out, map[string]*Snapshot{"alpha": s, "beta": s}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE appraise_reviews gauge\nappraise_reviews{repo=\"alpha\"} 3\nappraise_reviews{repo=\"beta\"} 3\n",
+		`appraise_notes{repo="beta",ref="refs/notes/devtools/reviews"} 3`,
+		`appraise_pull_failures_total{repo="alpha",remote="mirror"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("The metrics of several repos do not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	Handler(repo, nil).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if recorder.Code != 200 || !strings.Contains(recorder.Body.String(), "appraise_reviews 3") {
+		t.Errorf("Unexpected response from the handler: %d %s", recorder.Code, recorder.Body.String())
+	}
+}
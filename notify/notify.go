@@ -0,0 +1,212 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notify posts messages about reviews to chat webhooks, such as
+// those of Slack (or Slack-compatible services) and Matrix.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Timeout is how long posting a message to a webhook may take.
+const Timeout = 10 * time.Second
+
+// The supported types of webhooks.
+const (
+	TypeSlack  = "slack"
+	TypeMatrix = "matrix"
+)
+
+// defaultTemplates are the message templates used for each type of webhook
+// when no template has been configured, using the link syntax of each.
+var defaultTemplates = map[string]string{
+	TypeSlack: `{{if .URL}}<{{.URL}}|Review {{printf "%.12s" .Revision}}>{{else}}Review {{printf "%.12s" .Revision}}{{end}} was {{.Event}} by {{.Actor}} ({{.Status}}): {{.Title}}` +
+		`{{if .Permalink}}` + "\n" + `Comment: {{if .CommentURL}}<{{.CommentURL}}|{{.Permalink}}>{{else}}{{.Permalink}}{{end}}{{end}}` +
+		`{{if .DueBy}}` + "\n" + `Due: {{.DueBy}}{{if .Overdue}} (overdue){{end}}{{end}}` +
+		`{{if .Mentions}}` + "\n" + `Reviewers: {{join .Mentions " "}}{{end}}`,
+	TypeMatrix: `{{if .URL}}[Review {{printf "%.12s" .Revision}}]({{.URL}}){{else}}Review {{printf "%.12s" .Revision}}{{end}} was {{.Event}} by {{.Actor}} ({{.Status}}): {{.Title}}` +
+		`{{if .Permalink}}` + "\n" + `Comment: {{if .CommentURL}}[{{.Permalink}}]({{.CommentURL}}){{else}}{{.Permalink}}{{end}}{{end}}` +
+		`{{if .DueBy}}` + "\n" + `Due: {{.DueBy}}{{if .Overdue}} (overdue){{end}}{{end}}` +
+		`{{if .Mentions}}` + "\n" + `Reviewers: {{join .Mentions " "}}{{end}}`,
+}
+
+// Message describes an event on a review.
+//
+// Its fields are available to message templates.
+type Message struct {
+	// Event is what happened to the review, e.g. "requested" or "accepted".
+	Event string
+	// Actor is the email of the user who caused the event.
+	Actor     string
+	Revision  string
+	Title     string
+	Requester string
+	// Status is the status of the review, as shown by the list command.
+	Status string
+	// URL is a link to the review, if a URL prefix has been configured.
+	URL string
+	// Comment is the hash of the comment that the event added, if any,
+	// and Permalink is its permalink. CommentURL is its HTTP URL, if a
+	// permalink URL template has been configured.
+	Comment    string
+	Permalink  string
+	CommentURL string
+	// DueBy is the deadline that the requester set for the review, if it is
+	// still open, and Overdue is whether that deadline has passed.
+	DueBy   string
+	Overdue bool
+	// Mentions are the reviewers, followed by the people who are CC'd, as the
+	// handles that they are mentioned with in the chat service.
+	Mentions []string
+}
+
+// slackEscaper escapes the characters that Slack's mrkdwn treats as control
+// characters, so that text such as a review's title can not inject links or
+// mentions into a message.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapedForSlack returns a copy of the message whose free-form text is
+// escaped for Slack. The URLs and the mentions are kept as they are, as the
+// default template wraps the URLs in links, and the mentions are handles
+// such as <@U123>.
+func (m Message) escapedForSlack() Message {
+	m.Event = slackEscaper.Replace(m.Event)
+	m.Actor = slackEscaper.Replace(m.Actor)
+	m.Title = slackEscaper.Replace(m.Title)
+	m.Requester = slackEscaper.Replace(m.Requester)
+	m.Status = slackEscaper.Replace(m.Status)
+	m.Permalink = slackEscaper.Replace(m.Permalink)
+	m.DueBy = slackEscaper.Replace(m.DueBy)
+	return m
+}
+
+// Webhook posts messages to a single chat webhook.
+type Webhook struct {
+	Type string
+	URL  string
+	// Client is used to post the messages.
+	Client   *http.Client
+	template *template.Template
+}
+
+// NewWebhook returns a webhook for the given URL.
+//
+// The type of the webhook is either given explicitly, or selected by a
+// "slack+" or "matrix+" prefix on the scheme of the URL (e.g.
+// "slack+https://hooks.slack.com/services/..."), which is removed.
+// If the given message template is empty, the default for the type is used.
+func NewWebhook(webhookURL, webhookType, messageTemplate string) (*Webhook, error) {
+	for _, t := range []string{TypeSlack, TypeMatrix} {
+		if strings.HasPrefix(webhookURL, t+"+") {
+			if webhookType != "" && webhookType != t {
+				return nil, fmt.Errorf("the webhook URL is for %s, but its type is %s", t, webhookType)
+			}
+			webhookType = t
+			webhookURL = strings.TrimPrefix(webhookURL, t+"+")
+		}
+	}
+	if webhookType != TypeSlack && webhookType != TypeMatrix {
+		return nil, fmt.Errorf("unknown webhook type %q; set it to %s or %s, or prefix the scheme of the URL with it (e.g. slack+https://...)",
+			webhookType, TypeSlack, TypeMatrix)
+	}
+	if messageTemplate == "" {
+		messageTemplate = defaultTemplates[webhookType]
+	}
+	t, err := template.New("notify").Funcs(template.FuncMap{"join": strings.Join}).Parse(messageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification message template: %v", err)
+	}
+	return &Webhook{
+		Type:     webhookType,
+		URL:      webhookURL,
+		Client:   &http.Client{Timeout: Timeout},
+		template: t,
+	}, nil
+}
+
+// Format returns the JSON payload that posts the given message.
+//
+// Slack-compatible webhooks take the message in a "text" field. Matrix
+// webhooks (such as the generic webhooks of matrix-hookshot) take it in the
+// same field, and render it as markdown.
+func (w *Webhook) Format(m Message) ([]byte, error) {
+	if w.Type == TypeSlack {
+		m = m.escapedForSlack()
+	}
+	var text strings.Builder
+	if err := w.template.Execute(&text, m); err != nil {
+		return nil, fmt.Errorf("failed to expand the notification message template: %v", err)
+	}
+	payload := map[string]string{"text": strings.TrimSpace(text.String())}
+	if w.Type == TypeMatrix {
+		payload["msgtype"] = "m.notice"
+	}
+	return json.Marshal(payload)
+}
+
+// Send posts the given message to the webhook.
+func (w *Webhook) Send(m Message) error {
+	payload, err := w.Format(m)
+	if err != nil {
+		return err
+	}
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("the %s webhook responded with %s %s", w.Type, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// ParseMentions parses entries of the form "<email> <handle>", which map the
+// emails of reviewers to their handles in a chat service.
+func ParseMentions(entries []string) map[string]string {
+	mentions := make(map[string]string)
+	for _, entry := range entries {
+		fields := strings.Fields(entry)
+		if len(fields) == 2 {
+			mentions[fields[0]] = fields[1]
+		}
+	}
+	return mentions
+}
+
+// Mention returns the handles of the given reviewers, falling back to their
+// emails for those who do not have one.
+func Mention(reviewers []string, mentions map[string]string) []string {
+	var handles []string
+	for _, reviewer := range reviewers {
+		if handle, ok := mentions[reviewer]; ok {
+			handles = append(handles, handle)
+		} else {
+			handles = append(handles, reviewer)
+		}
+	}
+	return handles
+}
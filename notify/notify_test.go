@@ -0,0 +1,194 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var testMessage = Message{
+	Event:    "accepted",
+	Actor:    "bob@example.com",
+	Revision: "0123456789abcdef",
+	Title:    "Fix the frobnicator",
+	Status:   "accepted",
+	URL:      "https://reviews.example.com/0123456789abcdef",
+	Mentions: []string{"<@U123>", "carol@example.com"},
+}
+
+func TestNewWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		url, webhookType string
+		wantType         string
+		wantURL          string
+	}{
+		{"slack+https://hooks.example.com/x", "", TypeSlack, "https://hooks.example.com/x"},
+		{"matrix+https://hookshot.example.com/x", "", TypeMatrix, "https://hookshot.example.com/x"},
+		{"https://hooks.example.com/x", TypeSlack, TypeSlack, "https://hooks.example.com/x"},
+	} {
+		w, err := NewWebhook(tc.url, tc.webhookType, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Type != tc.wantType || w.URL != tc.wantURL {
+			t.Errorf("NewWebhook(%q, %q) = %q, %q; want %q, %q", tc.url, tc.webhookType, w.Type, w.URL, tc.wantType, tc.wantURL)
+		}
+	}
+	if _, err := NewWebhook("https://hooks.example.com/x", "", ""); err == nil {
+		t.Error("Failed to reject a webhook without a type")
+	}
+	if _, err := NewWebhook("slack+https://hooks.example.com/x", TypeMatrix, ""); err == nil {
+		t.Error("Failed to reject a webhook whose URL and type disagree")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		webhookType string
+		want        map[string]string
+	}{
+		{TypeSlack, map[string]string{
+			"text": "<https://reviews.example.com/0123456789abcdef|Review 0123456789ab> was accepted by bob@example.com (accepted): Fix the frobnicator\nReviewers: <@U123> carol@example.com",
+		}},
+		{TypeMatrix, map[string]string{
+			"text":    "[Review 0123456789ab](https://reviews.example.com/0123456789abcdef) was accepted by bob@example.com (accepted): Fix the frobnicator\nReviewers: <@U123> carol@example.com",
+			"msgtype": "m.notice",
+		}},
+	} {
+		w, err := NewWebhook("https://hooks.example.com/x", tc.webhookType, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, err := w.Format(testMessage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(payload, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Format() for %s = %v; want %v", tc.webhookType, got, tc.want)
+		}
+	}
+}
+
+func TestFormatComment(t *testing.T) {
+	m := testMessage
+	m.Event = "commented on"
+	m.Comment = "fedcba9876543210"
+	m.Permalink = "appraise://example.com/repo/0123456789abcdef/fedcba9876543210"
+	m.CommentURL = "https://reviews.example.com/0123456789abcdef#fedcba9876543210"
+	w, err := NewWebhook("https://hooks.example.com/x", TypeSlack, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := w.Format(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := "<https://reviews.example.com/0123456789abcdef|Review 0123456789ab> was commented on by bob@example.com (accepted): Fix the frobnicator\n" +
+		"Comment: <https://reviews.example.com/0123456789abcdef#fedcba9876543210|appraise://example.com/repo/0123456789abcdef/fedcba9876543210>\n" +
+		"Reviewers: <@U123> carol@example.com"
+	if got["text"] != want {
+		t.Errorf("Format() = %q; want %q", got["text"], want)
+	}
+}
+
+func TestFormatDueBy(t *testing.T) {
+	m := testMessage
+	m.Mentions = nil
+	m.DueBy = "Mon Jan  2 15:04:05 UTC 2006"
+	m.Overdue = true
+	w, err := NewWebhook("https://hooks.example.com/x", TypeMatrix, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := w.Format(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := "[Review 0123456789ab](https://reviews.example.com/0123456789abcdef) was accepted by bob@example.com (accepted): Fix the frobnicator\n" +
+		"Due: Mon Jan  2 15:04:05 UTC 2006 (overdue)"
+	if got["text"] != want {
+		t.Errorf("Format() = %q; want %q", got["text"], want)
+	}
+}
+
+func TestFormatEscapesSlack(t *testing.T) {
+	m := testMessage
+	m.Title = "Use <!channel> & <https://evil.example.com|the fix>"
+	for webhookType, want := range map[string]string{
+		TypeSlack:  "accepted: Use &lt;!channel&gt; &amp; &lt;https://evil.example.com|the fix&gt; <@U123>",
+		TypeMatrix: "accepted: Use <!channel> & <https://evil.example.com|the fix> <@U123>",
+	} {
+		w, err := NewWebhook("https://hooks.example.com/x", webhookType, "{{.Event}}: {{.Title}} {{index .Mentions 0}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, err := w.Format(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := json.Unmarshal(payload, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["text"] != want {
+			t.Errorf("Format() for %s = %q; want %q", webhookType, got["text"], want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+	w, err := NewWebhook("slack+"+ts.URL, "", "{{.Event}} {{.Revision}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Send(testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "accepted 0123456789abcdef" {
+		t.Errorf("Unexpected payload %v", got)
+	}
+}
+
+func TestMention(t *testing.T) {
+	mentions := ParseMentions([]string{"alice@example.com <@U123>", "malformed"})
+	got := Mention([]string{"alice@example.com", "bob@example.com"}, mentions)
+	want := []string{"<@U123>", "bob@example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mention() = %v; want %v", got, want)
+	}
+}
@@ -20,15 +20,21 @@ package repository
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	exec "golang.org/x/sys/execabs"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/git-appraise/tracing"
 )
 
 const (
@@ -38,20 +44,51 @@ const (
 	remoteDevtoolsRefPrefix = "refs/remoteDevtools/"
 )
 
+// GitVersion returns the output of `git version`, e.g. "git version 2.43.0".
+func GitVersion() (string, error) {
+	out, err := exec.Command("git", "version").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
 // GitRepo represents an instance of a (local) git repository.
 type GitRepo struct {
+	// Path is the top level directory of the repo's work tree, or its git
+	// directory if it is a bare repo.
 	Path string
+	// GitDir is the repo's git directory.
+	GitDir string
+	// Verbose, if set, causes every git command to be logged to stderr before it is run.
+	Verbose bool
+	// Timeout, if non-zero, is the longest that any single git command is allowed to run.
+	Timeout time.Duration
 }
 
 // Run the given git command with the given I/O reader/writers and environment, returning an error if it fails.
 func (repo *GitRepo) runGitCommandWithIOAndEnv(stdin io.Reader, stdout, stderr io.Writer, env []string, args ...string) error {
-	cmd := exec.Command("git", args...)
+	ctx := context.Background()
+	if repo.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, repo.Timeout)
+		defer cancel()
+	}
+	if repo.Verbose {
+		fmt.Fprintf(os.Stderr, "git %s\n", strings.Join(args, " "))
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repo.Path
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	cmd.Env = env
-	return cmd.Run()
+	// Only the subcommand is recorded, as the other arguments may hold note
+	// contents, commit messages, or URLs with credentials.
+	span := tracing.Start("git "+args[0], tracing.Attribute{Key: "git.command", Value: args[0]})
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("the git command %q timed out after %v", strings.Join(args, " "), repo.Timeout)
+	}
+	span.End(err)
+	return err
 }
 
 // Run the given git command with the given I/O reader/writers, returning an error if it fails.
@@ -70,7 +107,7 @@ func (repo *GitRepo) runGitCommandRaw(args ...string) (string, string, error) {
 // Run the given git command and return its stdout, or an error if the command fails.
 func (repo *GitRepo) runGitCommand(args ...string) (string, error) {
 	stdout, stderr, err := repo.runGitCommandRaw(args...)
-	if err != nil {
+	if _, ok := err.(*exec.ExitError); ok {
 		if stderr == "" {
 			stderr = "Error running git command: " + strings.Join(args, " ")
 		}
@@ -101,16 +138,35 @@ func (repo *GitRepo) runGitCommandInline(args ...string) error {
 
 // NewGitRepo determines if the given working directory is inside of a git repository,
 // and returns the corresponding GitRepo instance if it is.
+//
+// The path may be anywhere within the repo's work tree, or the path of a
+// bare repo; the repo's top level directory is found the same way git finds it.
 func NewGitRepo(path string) (*GitRepo, error) {
 	repo := &GitRepo{Path: path}
-	_, _, err := repo.runGitCommandRaw("rev-parse")
-	if err == nil {
-		return repo, nil
+	gitDir, err := repo.runGitCommand("rev-parse", "--git-dir")
+	if err != nil {
+		return nil, err
 	}
-	if _, ok := err.(*exec.ExitError); ok {
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	repo.GitDir = gitDir
+	bare, err := repo.runGitCommand("rev-parse", "--is-bare-repository")
+	if err != nil {
 		return nil, err
 	}
-	return nil, err
+	if bare == "true" {
+		repo.Path = gitDir
+		return repo, nil
+	}
+	topLevel, err := repo.runGitCommand("rev-parse", "--show-toplevel")
+	if err != nil {
+		// This happens when the path is within the git directory itself.
+		repo.Path = gitDir
+		return repo, nil
+	}
+	repo.Path = topLevel
+	return repo, nil
 }
 
 func (repo *GitRepo) HasRef(ref string) (bool, error) {
@@ -127,7 +183,7 @@ func (repo *GitRepo) HasRef(ref string) (bool, error) {
 
 // HasObject returns whether or not the repo contains an object with the given hash.
 func (repo *GitRepo) HasObject(hash string) (bool, error) {
-	_, err := repo.runGitCommand("cat-file", "-e", hash)
+	_, _, err := repo.runGitCommandRaw("cat-file", "-e", hash)
 	if err == nil {
 		// We verified the object exists
 		return true, nil
@@ -144,6 +200,11 @@ func (repo *GitRepo) GetPath() string {
 	return repo.Path
 }
 
+// GetGitDir returns the path to the repo's git directory.
+func (repo *GitRepo) GetGitDir() string {
+	return repo.GitDir
+}
+
 // GetRepoStateHash returns a hash which embodies the entire current state of a repository.
 func (repo *GitRepo) GetRepoStateHash() (string, error) {
 	stateSummary, error := repo.runGitCommand("show-ref")
@@ -172,6 +233,109 @@ func (repo *GitRepo) GetSubmitStrategy() (string, error) {
 	return submitStrategy, nil
 }
 
+// GetConfig returns the value of the given git config key, or the empty
+// string if the key is not set.
+func (repo *GitRepo) GetConfig(key string) (string, error) {
+	value, _, err := repo.runGitCommandRaw("config", key)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// The key is not set.
+			return "", nil
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+// GetConfigValues returns every value of the given (possibly multi-valued)
+// git config key, or nil if the key is not set.
+func (repo *GitRepo) GetConfigValues(key string) ([]string, error) {
+	out, _, err := repo.runGitCommandRaw("config", "--get-all", key)
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// The key is not set.
+			return nil, nil
+		}
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// AddConfigValue adds a value to the given (possibly multi-valued) git
+// config key in the repo's local config.
+func (repo *GitRepo) AddConfigValue(key, value string) error {
+	_, err := repo.runGitCommand("config", "--add", key, value)
+	return err
+}
+
+// ReadConfigFile parses the file at the given path within the repo, which
+// uses the syntax of git config files, returning nil if it does not exist or
+// is not a file (e.g. if it is a directory).
+//
+// The copy of the file in the work tree is preferred, falling back to the one
+// committed at HEAD, which also allows reading it in a bare repo.
+func (repo *GitRepo) ReadConfigFile(path string) (map[string]string, error) {
+	source := []string{"--file", filepath.Join(repo.Path, path)}
+	if info, err := os.Stat(source[1]); err != nil || repo.Path == repo.GitDir {
+		blob := "HEAD:" + path
+		if objectType, _, err := repo.runGitCommandRaw("cat-file", "-t", blob); err != nil || objectType != "blob" {
+			return nil, nil
+		}
+		source = []string{"--blob", blob}
+	} else if info.IsDir() {
+		return nil, nil
+	}
+	out, err := repo.runGitCommand(append([]string{"config", "-z", "--list"}, source...)...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, entry := range strings.Split(out, "\x00") {
+		if entry == "" {
+			continue
+		}
+		// A key without a value is a boolean that is set to true.
+		value := "true"
+		if i := strings.Index(entry, "\n"); i >= 0 {
+			entry, value = entry[:i], entry[i+1:]
+		}
+		values[entry] = value
+	}
+	return values, nil
+}
+
+// WriteConfigFile sets the value of the given key in the file at the given
+// path within the repo's work tree, which uses the syntax of git config
+// files, creating the file if necessary.
+func (repo *GitRepo) WriteConfigFile(path, key, value string) error {
+	file := filepath.Join(repo.Path, path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	_, err := repo.runGitCommand("config", "--file", file, key, value)
+	return err
+}
+
+// SetConfig sets the value of the given git config key in the repo's local
+// config, replacing any existing value.
+func (repo *GitRepo) SetConfig(key, value string) error {
+	_, err := repo.runGitCommand("config", key, value)
+	return err
+}
+
+// GetDefaultTargetRef returns the ref that new reviews target by default,
+// or the empty string if no default has been configured.
+func (repo *GitRepo) GetDefaultTargetRef() (string, error) {
+	targetRef, _ := repo.runGitCommand("config", "appraise.target")
+	return targetRef, nil
+}
+
+// SetDefaultTargetRef sets the ref that new reviews target by default.
+func (repo *GitRepo) SetDefaultTargetRef(ref string) error {
+	_, err := repo.runGitCommand("config", "appraise.target", ref)
+	return err
+}
+
 // HasUncommittedChanges returns true if there are local, uncommitted changes.
 func (repo *GitRepo) HasUncommittedChanges() (bool, error) {
 	out, err := repo.runGitCommand("status", "--porcelain")
@@ -203,14 +367,43 @@ func (repo *GitRepo) VerifyGitRef(ref string) error {
 	return err
 }
 
+// VerifyCommitSignature verifies that the given commit has a valid GPG
+// signature from a trusted key.
+func (repo *GitRepo) VerifyCommitSignature(hash string) error {
+	if _, err := repo.runGitCommand("verify-commit", hash); err != nil {
+		return fmt.Errorf("Commit %q does not have a valid signature: %v", hash, err)
+	}
+	return nil
+}
+
 // GetHeadRef returns the ref that is the current HEAD.
 func (repo *GitRepo) GetHeadRef() (string, error) {
 	return repo.runGitCommand("symbolic-ref", "HEAD")
 }
 
+// GetPatchID returns the stable patch ID of the changes made by the given
+// commit, which is the same for commits that make the same changes, such
+// as a commit and its cherry-picks. It is empty for commits that change
+// nothing, and for merge commits.
+func (repo *GitRepo) GetPatchID(commit string) (string, error) {
+	diff, err := repo.runGitCommand("diff-tree", "-p", "--root", "--no-commit-id", commit)
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	if err := repo.runGitCommandWithIO(strings.NewReader(diff+"\n"), &stdout, &stderr, "patch-id", "--stable"); err != nil {
+		return "", fmt.Errorf("failed to compute the patch ID of %q: %s", commit, strings.TrimSpace(stderr.String()))
+	}
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], nil
+}
+
 // GetCommitHash returns the hash of the commit pointed to by the given ref.
 func (repo *GitRepo) GetCommitHash(ref string) (string, error) {
-	return repo.runGitCommand("show", "-s", "--format=%H", ref)
+	return repo.runGitCommand("show", "-s", "--format=%H", ref+"^{commit}")
 }
 
 // ResolveRefCommit returns the commit pointed to by the given ref, which may be a remote ref.
@@ -311,15 +504,36 @@ func (repo *GitRepo) IsAncestor(ancestor, descendant string) (bool, error) {
 
 // Diff computes the diff between two given commits.
 func (repo *GitRepo) Diff(left, right string, diffArgs ...string) (string, error) {
+	return repo.DiffWithOptions(left, right, DiffOptions{}, diffArgs...)
+}
+
+// DiffWithOptions computes the diff between two given commits, as
+// controlled by the given options.
+func (repo *GitRepo) DiffWithOptions(left, right string, options DiffOptions, diffArgs ...string) (string, error) {
 	args := []string{"diff"}
+	if options.IgnoreWhitespace {
+		args = append(args, "--ignore-all-space")
+	}
+	if options.Stat {
+		args = append(args, "--stat")
+	}
 	args = append(args, diffArgs...)
 	args = append(args, fmt.Sprintf("%s..%s", left, right))
+	if len(options.Paths) > 0 {
+		args = append(args, "--")
+		for _, path := range options.Paths {
+			args = append(args, ":(glob)"+filepath.ToSlash(path))
+		}
+	}
 	return repo.runGitCommand(args...)
 }
 
 // Show returns the contents of the given file at the given commit.
+//
+// The path may use the platform's separator (e.g. a backslash on Windows),
+// but git always expects forward slashes in "<commit>:<path>" arguments.
 func (repo *GitRepo) Show(commit, path string) (string, error) {
-	return repo.runGitCommand("show", fmt.Sprintf("%s:%s", commit, path))
+	return repo.runGitCommand("show", fmt.Sprintf("%s:%s", commit, filepath.ToSlash(path)))
 }
 
 // SwitchToRef changes the currently-checked-out ref.
@@ -333,6 +547,19 @@ func (repo *GitRepo) SwitchToRef(ref string) error {
 	return err
 }
 
+// mergeArchivesMessage is the message of the commits that merge a remote
+// archive ref into the local one.
+const mergeArchivesMessage = "Merge local and remote archives"
+
+// archiveMessagePrefix is the start of the message of the commits that add
+// a commit to an archive ref.
+const archiveMessagePrefix = "Archive "
+
+// archiveReviewInfix separates the archived commit from the review it was the
+// head of, in the message of the commits that add a review's head to an
+// archive ref.
+const archiveReviewInfix = " of review "
+
 // mergeArchives merges two archive refs.
 func (repo *GitRepo) mergeArchives(archive, remoteArchive string) error {
 	hasRemote, err := repo.HasRef(remoteArchive)
@@ -377,7 +604,7 @@ func (repo *GitRepo) mergeArchives(archive, remoteArchive string) error {
 	if err != nil {
 		return err
 	}
-	newArchiveHash, err := repo.runGitCommand("commit-tree", "-p", remoteHash, "-p", archiveHash, "-m", "Merge local and remote archives", refDetails.Tree)
+	newArchiveHash, err := repo.runGitCommand("commit-tree", "-p", remoteHash, "-p", archiveHash, "-m", mergeArchivesMessage, refDetails.Tree)
 	if err != nil {
 		return err
 	}
@@ -396,6 +623,18 @@ func (repo *GitRepo) mergeArchives(archive, remoteArchive string) error {
 // If the ref pointed to by the 'archive' argument does not exist
 // yet, then it will be created.
 func (repo *GitRepo) ArchiveRef(ref, archive string) error {
+	return repo.archiveRef(ref, archive, "")
+}
+
+// ArchiveReviewHead is like ArchiveRef, but also records that the archived
+// commit was a head of the review with the given revision.
+func (repo *GitRepo) ArchiveReviewHead(ref, archive, review string) error {
+	return repo.archiveRef(ref, archive, review)
+}
+
+// archiveRef adds the commit pointed to by the given ref to the given archive
+// ref, recording the review it was a head of, if any.
+func (repo *GitRepo) archiveRef(ref, archive, review string) error {
 	refHash, err := repo.GetCommitHash(ref)
 	if err != nil {
 		return err
@@ -418,7 +657,11 @@ func (repo *GitRepo) ArchiveRef(ref, archive string) error {
 		}
 		commitTreeArgs = append(commitTreeArgs, "-p", archiveHash)
 	}
-	commitTreeArgs = append(commitTreeArgs, "-p", refHash, "-m", fmt.Sprintf("Archive %s", refHash), refDetails.Tree)
+	message := archiveMessagePrefix + refHash
+	if review != "" {
+		message += archiveReviewInfix + review
+	}
+	commitTreeArgs = append(commitTreeArgs, "-p", refHash, "-m", message, refDetails.Tree)
 	newArchiveHash, err := repo.runGitCommand(commitTreeArgs...)
 	if err != nil {
 		return err
@@ -432,6 +675,54 @@ func (repo *GitRepo) ArchiveRef(ref, archive string) error {
 	return err
 }
 
+// ListArchivedCommits returns the commits that were added to the given
+// archive ref, with the most recently archived first.
+//
+// If the archive ref does not exist, then this returns an empty result.
+func (repo *GitRepo) ListArchivedCommits(archive string) ([]ArchivedCommit, error) {
+	hasArchive, err := repo.HasRef(archive)
+	if err != nil || !hasArchive {
+		return nil, err
+	}
+	archiveHash, err := repo.GetCommitHash(archive)
+	if err != nil {
+		return nil, err
+	}
+	// Only the commits created by ArchiveRef and mergeArchives are walked,
+	// so that the history of the archived commits is not traversed.
+	var archived []ArchivedCommit
+	visited := make(map[string]bool)
+	queue := []string{archiveHash}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if visited[hash] {
+			continue
+		}
+		visited[hash] = true
+		details, err := repo.GetCommitDetails(hash)
+		if err != nil {
+			return nil, err
+		}
+		parents := details.Parents
+		if strings.HasPrefix(details.Summary, archiveMessagePrefix) && len(parents) > 0 {
+			_, review, _ := strings.Cut(details.Summary, archiveReviewInfix)
+			archived = append(archived, ArchivedCommit{
+				Hash:   parents[len(parents)-1],
+				Time:   details.Time,
+				Review: review,
+			})
+			queue = append(queue, parents[:len(parents)-1]...)
+		} else if details.Summary == mergeArchivesMessage {
+			queue = append(queue, parents...)
+		}
+	}
+	sort.SliceStable(archived, func(i, j int) bool {
+		return archived[i].Time > archived[j].Time
+	})
+	return archived, nil
+}
+
 // MergeRef merges the given ref into the current one.
 //
 // The ref argument is the ref to merge, and fastForward indicates that the
@@ -477,6 +768,20 @@ func (repo *GitRepo) MergeAndSignRef(ref string, fastForward bool,
 	return repo.runGitCommandInline(args...)
 }
 
+// CreateTag creates an annotated tag with the given name and message,
+// pointing at the given commit.
+func (repo *GitRepo) CreateTag(name, commit, message string) error {
+	_, err := repo.runGitCommand("tag", "-a", "-m", message, name, commit)
+	return err
+}
+
+// CreateAndSignTag creates a signed tag with the given name and message,
+// pointing at the given commit.
+func (repo *GitRepo) CreateAndSignTag(name, commit, message string) error {
+	_, err := repo.runGitCommand("tag", "-s", "-m", message, name, commit)
+	return err
+}
+
 // RebaseRef rebases the current ref onto the given one.
 func (repo *GitRepo) RebaseRef(ref string) error {
 	return repo.runGitCommandInline("rebase", "-i", ref)
@@ -488,6 +793,69 @@ func (repo *GitRepo) RebaseAndSignRef(ref string) error {
 	return repo.runGitCommandInline("rebase", "-S", "-i", ref)
 }
 
+// IsRebaseInProgress returns whether or not a rebase has been started
+// but not yet completed or aborted.
+func (repo *GitRepo) IsRebaseInProgress() (bool, error) {
+	for _, stateDir := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := repo.runGitCommand("rev-parse", "--git-path", stateDir)
+		if err != nil {
+			return false, err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repo.Path, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ContinueRebase resumes an in-progress rebase, e.g. after conflicts have
+// been resolved.
+func (repo *GitRepo) ContinueRebase() error {
+	return repo.runGitCommandInline("rebase", "--continue")
+}
+
+// AbortRebase abandons an in-progress rebase, restoring the ref that
+// was being rebased to its original state.
+func (repo *GitRepo) AbortRebase() error {
+	_, err := repo.runGitCommand("rebase", "--abort")
+	return err
+}
+
+// AddWorktree checks out the given commit, detached, into a new working
+// tree in the given directory, leaving the current one unchanged.
+func (repo *GitRepo) AddWorktree(path, commit string) error {
+	_, err := repo.runGitCommand("worktree", "add", "--detach", path, commit)
+	return err
+}
+
+// CherryPick applies the changes of the given commits, in order, on top
+// of the current ref, noting the original commit in each new commit's
+// message. If any of them do not apply cleanly, then the cherry-pick is
+// abandoned and the current ref is left unchanged.
+func (repo *GitRepo) CherryPick(commits ...string) error {
+	args := append([]string{"cherry-pick", "-x"}, commits...)
+	if _, err := repo.runGitCommand(args...); err != nil {
+		repo.runGitCommand("cherry-pick", "--abort")
+		return err
+	}
+	return nil
+}
+
+// Stash sets aside all uncommitted changes, including untracked files.
+func (repo *GitRepo) Stash() error {
+	_, err := repo.runGitCommand("stash", "push", "--include-untracked", "-m", "git-appraise")
+	return err
+}
+
+// PopStash restores the most recently stashed changes.
+func (repo *GitRepo) PopStash() error {
+	_, err := repo.runGitCommand("stash", "pop")
+	return err
+}
+
 // ListCommits returns the list of commits reachable from the given ref.
 //
 // The generated list is in chronological order (with the oldest commit first).
@@ -533,6 +901,21 @@ func (repo *GitRepo) ListCommitsBetween(from, to string) ([]string, error) {
 	return strings.Split(out, "\n"), nil
 }
 
+// CountAheadBehind returns the number of commits reachable from the
+// "head" revision but not the "base" revision (ahead), and vice versa
+// (behind).
+func (repo *GitRepo) CountAheadBehind(base, head string) (int, int, error) {
+	out, err := repo.runGitCommand("rev-list", "--left-right", "--count", base+"..."+head)
+	if err != nil {
+		return 0, 0, err
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscanf(out, "%d %d", &behind, &ahead); err != nil {
+		return 0, 0, fmt.Errorf("unexpected output from rev-list: %q", out)
+	}
+	return ahead, behind, nil
+}
+
 // StoreBlob writes the given file to the repository and returns its hash.
 func (repo *GitRepo) StoreBlob(contents string) (string, error) {
 	stdin := strings.NewReader(contents)
@@ -664,29 +1047,56 @@ func (repo *GitRepo) CreateCommitWithTree(details *CommitDetails, t *Tree) (stri
 	return repo.CreateCommit(details)
 }
 
+// ApplyPatch applies the given patch, in the format of git diff, to the
+// tree of the given commit, and returns the hash of the resulting tree.
+//
+// This uses a temporary index, so neither the work tree nor the index change.
+func (repo *GitRepo) ApplyPatch(commit, patch string) (string, error) {
+	dir, err := os.MkdirTemp("", "git-appraise-apply")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	env := append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(dir, "index"))
+	if _, err := repo.runGitCommandWithEnv(env, "read-tree", commit); err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	if err := repo.runGitCommandWithIOAndEnv(strings.NewReader(patch), nil, &stderr, env, "apply", "--cached", "-"); err != nil {
+		return "", fmt.Errorf("failed to apply the patch: %s", strings.TrimSpace(stderr.String()))
+	}
+	return repo.runGitCommandWithEnv(env, "write-tree")
+}
+
 // SetRef sets the commit pointed to by the specified ref to `newCommitHash`,
-// iff the ref currently points `previousCommitHash`.
+// iff the ref currently points `previousCommitHash`, or does not exist if
+// that is empty.
+//
+// If `newCommitHash` is empty, then the ref is deleted.
 func (repo *GitRepo) SetRef(ref, newCommitHash, previousCommitHash string) error {
 	args := []string{"update-ref", ref, newCommitHash}
-	if previousCommitHash != "" {
-		args = append(args, previousCommitHash)
+	if newCommitHash == "" {
+		args = []string{"update-ref", "-d", ref}
 	}
+	// An empty old value makes git check that the ref does not exist yet.
+	args = append(args, previousCommitHash)
 	_, err := repo.runGitCommand(args...)
 	return err
 }
 
 // GetNotes uses the "git" command-line tool to read the notes from the given ref for a given revision.
+//
+// The raw contents of the notes object are read from 'git notes show', without
+// trimming them, so that they are split into notes the same way as in
+// GetAllNotes.
 func (repo *GitRepo) GetNotes(notesRef, revision string) []Note {
-	var notes []Note
-	rawNotes, err := repo.runGitCommand("notes", "--ref", notesRef, "show", revision)
-	if err != nil {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if err := repo.runGitCommandWithIO(nil, &stdout, &stderr, "notes", "--ref", notesRef, "show", revision); err != nil {
 		// We just assume that this means there are no notes
 		return nil
 	}
-	for _, line := range strings.Split(rawNotes, "\n") {
-		notes = append(notes, Note([]byte(line)))
-	}
-	return notes
+	return SplitNotes(bytes.TrimRight(stdout.Bytes(), "\n"))
 }
 
 func stringsReader(s []*string) io.Reader {
@@ -916,17 +1326,124 @@ func (repo *GitRepo) GetAllNotes(notesRef string) (map[string][]Note, error) {
 			continue
 		}
 		noteBytes := noteContentsMap[*notesMapping.NotesHash]
-		byteSlices := bytes.Split(noteBytes, []byte("\n"))
-		var notes []Note
-		for _, slice := range byteSlices {
-			notes = append(notes, Note(slice))
-		}
-		commitNotesMap[*notesMapping.ObjectHash] = notes
+		commitNotesMap[*notesMapping.ObjectHash] = SplitNotes(noteBytes)
 	}
 
 	return commitNotesMap, nil
 }
 
+// EachNotes reads the notes under the given refs for every commit that is
+// annotated in the first of them, calling visit with each commit's notes as
+// soon as they have been read.
+//
+// Like GetAllNotes, this uses a fixed number of invocations of 'git', but the
+// contents of the notes are read from a single 'git cat-file --batch' process
+// one commit at a time, so that only the hashes of the notes are held in
+// memory, and the first commit is visited without waiting for the rest.
+func (repo *GitRepo) EachNotes(notesRefs []string, visit func(commit string, notes [][]Note) error) error {
+	if len(notesRefs) == 0 {
+		return nil
+	}
+	notesHashes := make([]map[string]string, len(notesRefs))
+	var commits []string
+	for i, notesRef := range notesRefs {
+		overview, err := repo.notesOverview(notesRef)
+		if err != nil {
+			return err
+		}
+		notesHashes[i] = make(map[string]string)
+		for _, notesMapping := range overview.NotesMappings {
+			notesHashes[i][*notesMapping.ObjectHash] = *notesMapping.NotesHash
+		}
+		if i > 0 {
+			continue
+		}
+		isCommit, err := overview.getIsCommitMap(repo)
+		if err != nil {
+			return fmt.Errorf("Failure building the set of commit objects: %v", err)
+		}
+		for _, notesMapping := range overview.NotesMappings {
+			if isCommit[*notesMapping.ObjectHash] {
+				commits = append(commits, *notesMapping.ObjectHash)
+			}
+		}
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	var stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		err := repo.runGitCommandWithIO(stdinReader, stdoutWriter, &stderr, "cat-file", "--batch=%(objectname)\n%(objectsize)")
+		stdoutWriter.CloseWithError(err)
+		done <- err
+	}()
+	visitErr := visitBatchNotes(commits, notesHashes, stdinWriter, bufio.NewReader(stdoutReader), visit)
+	stdinWriter.Close()
+	io.Copy(ioutil.Discard, stdoutReader)
+	if err := <-done; err != nil && visitErr == nil {
+		return fmt.Errorf("Failure performing a batch file read: %v", err)
+	}
+	return visitErr
+}
+
+// visitBatchNotes calls visit with the notes of each of the given commits,
+// requesting the contents of their notes, whose hashes are given by ref, from
+// a 'git cat-file --batch=%(objectname)\n%(objectsize)' process.
+func visitBatchNotes(commits []string, notesHashes []map[string]string, requests io.Writer, responses *bufio.Reader, visit func(commit string, notes [][]Note) error) error {
+	for _, commit := range commits {
+		notes := make([][]Note, len(notesHashes))
+		for i := range notesHashes {
+			notesHash, ok := notesHashes[i][commit]
+			if !ok {
+				continue
+			}
+			if _, err := io.WriteString(requests, notesHash+"\n"); err != nil {
+				return fmt.Errorf("Failure requesting the notes of %q: %v", commit, err)
+			}
+			contents, err := readBatchObject(responses)
+			if err != nil {
+				return fmt.Errorf("Failure reading the notes of %q: %v", commit, err)
+			}
+			notes[i] = SplitNotes(contents)
+		}
+		if err := visit(commit, notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBatchObject reads the contents of a single object from the output of a
+// 'git cat-file --batch=%(objectname)\n%(objectsize)' command, in the format
+// that splitBatchCatFileOutput parses.
+func readBatchObject(reader *bufio.Reader) ([]byte, error) {
+	nameLine, err := reader.ReadString(byte('\n'))
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(nameLine, " missing\n") {
+		return nil, fmt.Errorf("the object %q is missing", strings.TrimSuffix(nameLine, " missing\n"))
+	}
+	sizeLine, err := reader.ReadString(byte('\n'))
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(sizeLine, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("Failure while parsing the object size: %q - %v", nameLine, err)
+	}
+	// The contents are followed by a newline.
+	contents := make([]byte, size+1)
+	if _, err := io.ReadFull(reader, contents); err != nil {
+		return nil, err
+	}
+	return contents[:size], nil
+}
+
 // AppendNote appends a note to a revision under the given ref.
 func (repo *GitRepo) AppendNote(notesRef, revision string, note Note) error {
 	_, err := repo.runGitCommand("notes", "--ref", notesRef, "append", "-m", string(note), revision)
@@ -935,25 +1452,38 @@ func (repo *GitRepo) AppendNote(notesRef, revision string, note Note) error {
 
 // ListNotedRevisions returns the collection of revisions that are annotated by notes in the given ref.
 func (repo *GitRepo) ListNotedRevisions(notesRef string) []string {
+	objects, err := repo.ListNotedObjects(notesRef)
+	if err != nil {
+		return nil
+	}
 	var revisions []string
+	for _, objHash := range objects {
+		objType, err := repo.runGitCommand("cat-file", "-t", objHash)
+		// If a note points to an object that we do not know about (yet), then err will not
+		// be nil. We can safely just ignore those notes.
+		if err == nil && objType == "commit" {
+			revisions = append(revisions, objHash)
+		}
+	}
+	return revisions
+}
+
+// ListNotedObjects returns the hashes of every object annotated by notes in
+// the given ref, including those that are not commits, and those that are
+// missing from the repo.
+func (repo *GitRepo) ListNotedObjects(notesRef string) ([]string, error) {
 	notesListOut, err := repo.runGitCommand("notes", "--ref", notesRef, "list")
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	notesList := strings.Split(notesListOut, "\n")
-	for _, notePair := range notesList {
+	var objects []string
+	for _, notePair := range strings.Split(notesListOut, "\n") {
 		noteParts := strings.SplitN(notePair, " ", 2)
 		if len(noteParts) == 2 {
-			objHash := noteParts[1]
-			objType, err := repo.runGitCommand("cat-file", "-t", objHash)
-			// If a note points to an object that we do not know about (yet), then err will not
-			// be nil. We can safely just ignore those notes.
-			if err == nil && objType == "commit" {
-				revisions = append(revisions, objHash)
-			}
+			objects = append(objects, noteParts[1])
 		}
 	}
-	return revisions
+	return objects, nil
 }
 
 // Remotes returns a list of the remotes.
@@ -973,9 +1503,152 @@ func (repo *GitRepo) Remotes() ([]string, error) {
 
 // Fetch fetches from the given remote using the supplied refspecs.
 func (repo *GitRepo) Fetch(remote string, refspecs ...string) error {
-	args := []string{"fetch", remote}
+	return repo.FetchWithOptions(remote, FetchOptions{}, refspecs...)
+}
+
+// FetchWithOptions fetches from the given remote using the supplied
+// refspecs, as controlled by the given options.
+func (repo *GitRepo) FetchWithOptions(remote string, options FetchOptions, refspecs ...string) error {
+	args := []string{"fetch"}
+	if options.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", options.Depth))
+	}
+	if options.Prune {
+		args = append(args, "--prune")
+	}
+	// The remote might be a URL given by the user, which must not be
+	// mistaken for an option if it starts with a dash.
+	args = append(args, "--", remote)
 	args = append(args, refspecs...)
-	return repo.runGitCommandInline(args...)
+	if options.Depth == 0 {
+		return repo.runGitCommandInline(args...)
+	}
+	shallowPath, err := repo.runGitCommand("rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(shallowPath) {
+		shallowPath = filepath.Join(repo.Path, shallowPath)
+	}
+	previous, err := readShallowCommits(shallowPath)
+	if err != nil {
+		return err
+	}
+	if err := repo.runGitCommandInline(args...); err != nil {
+		return err
+	}
+	return repo.restoreCompleteHistory(shallowPath, previous)
+}
+
+// readShallowCommits returns the commits listed in the given shallow file,
+// which git uses to record where the history of a shallow repo is cut off.
+func readShallowCommits(path string) (map[string]bool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]bool)
+	for _, line := range strings.Fields(string(contents)) {
+		commits[line] = true
+	}
+	return commits, nil
+}
+
+// restoreCompleteHistory undoes the side effect of a fetch with a depth
+// that cut off the history of commits that were already complete locally.
+//
+// Git records the commits at the fetched depth in a single shallow file for
+// the whole repo, including commits (such as the tip of a branch shared with
+// a fork) whose full history was present before the fetch, so without this
+// that history would be hidden from every command. Only the commits that
+// were newly cut off, and whose parents all exist, are restored.
+func (repo *GitRepo) restoreCompleteHistory(shallowPath string, previous map[string]bool) error {
+	current, err := readShallowCommits(shallowPath)
+	if err != nil {
+		return err
+	}
+	var kept []string
+	restored := false
+	for commit := range current {
+		if !previous[commit] {
+			complete, err := repo.hasAllParents(commit)
+			if err != nil {
+				return err
+			}
+			if complete {
+				restored = true
+				continue
+			}
+		}
+		kept = append(kept, commit)
+	}
+	if !restored {
+		return nil
+	}
+	if len(kept) == 0 {
+		return os.Remove(shallowPath)
+	}
+	sort.Strings(kept)
+	return ioutil.WriteFile(shallowPath, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+}
+
+// hasAllParents reports whether all of the parents of the given commit exist
+// locally, ignoring any shallow cut off of its history.
+func (repo *GitRepo) hasAllParents(commit string) (bool, error) {
+	// Unlike rev-list and log, cat-file shows the commit's recorded parents.
+	raw, err := repo.runGitCommand("cat-file", "commit", commit)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(raw, "\n") {
+		if line == "" {
+			break
+		}
+		if parent := strings.TrimPrefix(line, "parent "); parent != line {
+			if _, _, err := repo.runGitCommandRaw("cat-file", "-e", parent+"^{commit}"); err != nil {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// ListRefs returns the local refs that start with the given prefix,
+// mapped to the hashes they point to.
+func (repo *GitRepo) ListRefs(prefix string) (map[string]string, error) {
+	out, err := repo.runGitCommand("for-each-ref", "--format=%(objectname) %(refname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	return refs, nil
+}
+
+// ListRemoteRefs returns the refs of the given remote (or URL) that match
+// the given patterns, mapped to the hashes they point to.
+func (repo *GitRepo) ListRemoteRefs(remote string, refPatterns ...string) (map[string]string, error) {
+	args := append([]string{"ls-remote", remote}, refPatterns...)
+	out, err := repo.runGitCommand(args...)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	return refs, nil
 }
 
 // PushNotes pushes git notes to a remote repo.
@@ -1046,13 +1719,33 @@ func getLocalNotesRef(remote, remoteNotesRef string) string {
 // MergeNotes merges in the remote's state of the notes reference into the
 // local repository's.
 func (repo *GitRepo) MergeNotes(remote, notesRefPattern string) error {
+	return repo.mergeNotesWithProgress(context.Background(), remote, notesRefPattern, nil)
+}
+
+// mergeNotesWithProgress merges each of the remote's notes refs into the
+// corresponding local ref, reporting each merge to the given writer.
+//
+// Each local ref is updated in a single step, so stopping early (e.g.
+// because the context was cancelled) leaves every ref in a consistent state.
+func (repo *GitRepo) mergeNotesWithProgress(ctx context.Context, remote, notesRefPattern string, progress io.Writer) error {
 	remoteRefPattern := getRemoteNotesRef(remote, notesRefPattern)
 	refsMap, err := repo.getRefHashes(remoteRefPattern)
 	if err != nil {
 		return err
 	}
-	for remoteRef := range refsMap {
+	for _, remoteRef := range sortedKeys(refsMap) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		localRef := getLocalNotesRef(remote, remoteRef)
+		if !strings.HasPrefix(localRef, strings.TrimSuffix(notesRefPattern, "*")) {
+			// Never merge into notes refs outside of the requested namespace,
+			// as those may be used by other tools with other merge strategies.
+			continue
+		}
+		if progress != nil {
+			fmt.Fprintf(progress, "Merging notes from %s into %s\n", remoteRef, localRef)
+		}
 		if _, err := repo.runGitCommand("notes", "--ref", localRef, "merge", remoteRef, "-s", "cat_sort_uniq"); err != nil {
 			return err
 		}
@@ -1060,6 +1753,16 @@ func (repo *GitRepo) MergeNotes(remote, notesRefPattern string) error {
 	return nil
 }
 
+// sortedKeys returns the keys of the given map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // PullNotes fetches the contents of the given notes ref from a remote repo,
 // and then merges them with the corresponding local notes using the
 // "cat_sort_uniq" strategy.
@@ -1087,13 +1790,25 @@ func getLocalDevtoolsRef(remote, remoteDevtoolsRef string) string {
 // MergeArchives merges in the remote's state of the archives reference into
 // the local repository's.
 func (repo *GitRepo) MergeArchives(remote, archiveRefPattern string) error {
+	return repo.mergeArchivesWithProgress(context.Background(), remote, archiveRefPattern, nil)
+}
+
+// mergeArchivesWithProgress merges each of the remote's archive refs into the
+// corresponding local ref, reporting each merge to the given writer.
+func (repo *GitRepo) mergeArchivesWithProgress(ctx context.Context, remote, archiveRefPattern string, progress io.Writer) error {
 	remoteRefPattern := getRemoteDevtoolsRef(remote, archiveRefPattern)
 	refsMap, err := repo.getRefHashes(remoteRefPattern)
 	if err != nil {
 		return err
 	}
-	for remoteRef := range refsMap {
+	for _, remoteRef := range sortedKeys(refsMap) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		localRef := getLocalDevtoolsRef(remote, remoteRef)
+		if progress != nil {
+			fmt.Fprintf(progress, "Merging archive %s into %s\n", remoteRef, localRef)
+		}
 		if err := repo.mergeArchives(localRef, remoteRef); err != nil {
 			return err
 		}
@@ -1109,6 +1824,15 @@ func (repo *GitRepo) MergeArchives(remote, archiveRefPattern string) error {
 // changed because the _names_ of these files correspond to the revisions they
 // point to.
 func (repo *GitRepo) FetchAndReturnNewReviewHashes(remote, notesRefPattern string, devtoolsRefPatterns ...string) ([]string, error) {
+	return repo.fetchAndReturnNewReviewHashes(repo.Fetch, remote, remote, notesRefPattern, devtoolsRefPatterns...)
+}
+
+// fetchAndReturnNewReviewHashes implements FetchAndReturnNewReviewHashes, with
+// the added ability to fetch from a source (such as a bundle file) that is
+// not itself a named remote, and to control how the fetch is run.
+//
+// The fetched refs are stored as though they came from the given remote.
+func (repo *GitRepo) fetchAndReturnNewReviewHashes(fetch func(source string, refspecs ...string) error, source, remote, notesRefPattern string, devtoolsRefPatterns ...string) ([]string, error) {
 	for _, refPattern := range devtoolsRefPatterns {
 		if !strings.HasPrefix(refPattern, devtoolsRefPrefix) {
 			return nil, fmt.Errorf("Unsupported devtools ref: %q", refPattern)
@@ -1127,7 +1851,7 @@ func (repo *GitRepo) FetchAndReturnNewReviewHashes(remote, notesRefPattern strin
 		return nil, fmt.Errorf("failure reading the existing ref hashes for the remote %q: %v", remote, err)
 	}
 
-	if err := repo.Fetch(remote, notesFetchRefSpec, devtoolsFetchRefSpec); err != nil {
+	if err := fetch(source, notesFetchRefSpec, devtoolsFetchRefSpec); err != nil {
 		return nil, fmt.Errorf("failure fetching from the remote %q: %v", remote, err)
 	}
 
@@ -1195,6 +1919,95 @@ func (repo *GitRepo) PullNotesAndArchive(remote, notesRefPattern, archiveRefPatt
 	return nil
 }
 
+// PullNotesAndArchiveWithProgress behaves like PullNotesAndArchive, but
+// reports its progress to the given writer, and stops early if the given
+// context is cancelled.
+//
+// If the progress writer is nil, then nothing is reported, including the
+// progress of the underlying fetch.
+//
+// Every local ref is only updated once its merge has completed, so stopping
+// early leaves the local refs consistent; pulling again completes the merge.
+func (repo *GitRepo) PullNotesAndArchiveWithProgress(ctx context.Context, remote, notesRefPattern, archiveRefPattern string, progress io.Writer) error {
+	fetch := func(source string, refspecs ...string) error {
+		if progress == nil {
+			_, err := repo.runGitCommand(append([]string{"fetch", "--quiet", source}, refspecs...)...)
+			return err
+		}
+		args := append([]string{"fetch", "--progress", source}, refspecs...)
+		return repo.runGitCommandWithIO(nil, progress, progress, args...)
+	}
+	reviews, err := repo.fetchAndReturnNewReviewHashes(fetch, remote, remote, notesRefPattern, archiveRefPattern)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		fmt.Fprintf(progress, "Fetched updates for %d reviews\n", len(reviews))
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := repo.mergeArchivesWithProgress(ctx, remote, archiveRefPattern, progress); err != nil {
+		return fmt.Errorf("failure merging archives from the remote %q: %v", remote, err)
+	}
+	if err := repo.mergeNotesWithProgress(ctx, remote, notesRefPattern, progress); err != nil {
+		return fmt.Errorf("failure merging notes from the remote %q: %v", remote, err)
+	}
+	return nil
+}
+
+// bundleRemote is the name under which the contents of a bundle file are
+// recorded while they are being merged into the local refs.
+const bundleRemote = "bundle"
+
+// CreateBundle writes every ref matching the given patterns to a git
+// bundle file at the given path, and returns a mapping from the names
+// of the bundled refs to the commits they point to.
+//
+// Each ref pattern must be of the form "<prefix>/*".
+func (repo *GitRepo) CreateBundle(path string, refPatterns ...string) (map[string]string, error) {
+	bundledRefs := make(map[string]string)
+	args := []string{"bundle", "create", path}
+	for _, refPattern := range refPatterns {
+		refsMap, err := repo.getRefHashes(refPattern)
+		if err != nil {
+			return nil, err
+		}
+		for ref, hash := range refsMap {
+			bundledRefs[ref] = hash
+			args = append(args, ref)
+		}
+	}
+	if len(bundledRefs) == 0 {
+		return nil, fmt.Errorf("%w matching %v", ErrNoRefsToBundle, refPatterns)
+	}
+	if _, err := repo.runGitCommand(args...); err != nil {
+		return nil, fmt.Errorf("failure creating the bundle %q: %v", path, err)
+	}
+	return bundledRefs, nil
+}
+
+// PullNotesAndArchiveFromBundle reads the notes and archive refs from
+// a git bundle file, and merges them with the corresponding local refs.
+//
+// This behaves the same as PullNotesAndArchive, except that the bundle
+// file takes the place of the remote repo.
+func (repo *GitRepo) PullNotesAndArchiveFromBundle(path, notesRefPattern, archiveRefPattern string) error {
+	if _, err := repo.runGitCommand("bundle", "verify", path); err != nil {
+		return fmt.Errorf("failure verifying the bundle %q: %v", path, err)
+	}
+	if _, err := repo.fetchAndReturnNewReviewHashes(repo.Fetch, path, bundleRemote, notesRefPattern, archiveRefPattern); err != nil {
+		return fmt.Errorf("failure fetching from the bundle %q: %v", path, err)
+	}
+	if err := repo.MergeArchives(bundleRemote, archiveRefPattern); err != nil {
+		return fmt.Errorf("failure merging archives from the bundle %q: %v", path, err)
+	}
+	if err := repo.MergeNotes(bundleRemote, notesRefPattern); err != nil {
+		return fmt.Errorf("failure merging notes from the bundle %q: %v", path, err)
+	}
+	return nil
+}
+
 // Push pushes the given refs to a remote repo.
 func (repo *GitRepo) Push(remote string, refSpecs ...string) error {
 	pushArgs := append([]string{"push", remote}, refSpecs...)
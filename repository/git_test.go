@@ -18,6 +18,13 @@ package repository
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -92,3 +99,389 @@ func TestSplitBatchCatFileOutput(t *testing.T) {
 		t.Fatal("Failed to parse the contents of the last cat'ed file")
 	}
 }
+
+func TestSplitNotes(t *testing.T) {
+	contents := []byte(`{"author": "one"}
+
+{
+  "author": "two",
+  "description": "pretty-printed"
+}
+not json
+{"author": "three"}`)
+	notes := SplitNotes(contents)
+	want := []string{
+		`{"author": "one"}`,
+		``,
+		"{\n  \"author\": \"two\",\n  \"description\": \"pretty-printed\"\n}",
+		`not json`,
+		`{"author": "three"}`,
+	}
+	if len(notes) != len(want) {
+		t.Fatalf("Unexpected notes: %q", notes)
+	}
+	for i, note := range notes {
+		if string(note) != want[i] {
+			t.Errorf("Unexpected note %d: %q, want %q", i, note, want[i])
+		}
+	}
+
+	truncated := SplitNotes([]byte("{\n\"author\": \"four\""))
+	if len(truncated) != 2 {
+		t.Errorf("Expected an invalid multi-line note to be split into lines: %q", truncated)
+	}
+}
+
+func newGitRepoForTest(t *testing.T) *GitRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create a git repo: %v: %s", err, output)
+	}
+	repo, err := NewGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGitCommand("commit", "-q", "--allow-empty", "-m", "Initial commit"); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// nonEmptyNotes drops the blank lines that "git notes append" writes between notes.
+func nonEmptyNotes(notes []Note) []Note {
+	var result []Note
+	for _, note := range notes {
+		if len(note) > 0 {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+func TestGitRepoNotes(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	head, err := repo.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const notesRef = "refs/notes/devtools/test"
+	if notes := repo.GetNotes(notesRef, head); len(notes) != 0 {
+		t.Errorf("Unexpected notes before any were written: %q", notes)
+	}
+	for _, note := range []string{`{"note":"first"}`, `{"note":"second"}`} {
+		if err := repo.AppendNote(notesRef, head, Note(note)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notes := nonEmptyNotes(repo.GetNotes(notesRef, head))
+	if len(notes) != 2 || string(notes[0]) != `{"note":"first"}` || string(notes[1]) != `{"note":"second"}` {
+		t.Errorf("Unexpected notes: %q", notes)
+	}
+	allNotes, err := repo.GetAllNotes(notesRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allNotes) != 1 || len(nonEmptyNotes(allNotes[head])) != 2 {
+		t.Errorf("Unexpected notes from GetAllNotes: %q", allNotes)
+	}
+	if notes := repo.GetNotes(notesRef, "refs/does/not/exist"); len(notes) != 0 {
+		t.Errorf("Unexpected notes for a missing revision: %q", notes)
+	}
+}
+
+func TestGitRepoEachNotes(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	const requestsRef = "refs/notes/devtools/requests"
+	const commentsRef = "refs/notes/devtools/comments"
+	var commits []string
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			if _, err := repo.runGitCommand("commit", "-q", "--allow-empty", "-m", "Commit"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		head, err := repo.GetCommitHash("HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, head)
+		if err := repo.AppendNote(requestsRef, head, Note(`{"request":"`+head+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			if err := repo.AppendNote(commentsRef, head, Note(`{"comment":"`+head+`"}`)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Notes on objects that are not commits are not visited.
+	tree, err := repo.runGitCommand("rev-parse", "HEAD^{tree}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(requestsRef, tree, Note(`{"request":"tree"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	visited := make(map[string][][]Note)
+	err = repo.EachNotes([]string{requestsRef, commentsRef, "refs/notes/devtools/missing"}, func(commit string, notes [][]Note) error {
+		visited[commit] = notes
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visited) != len(commits) {
+		t.Fatalf("Unexpected commits visited: %q", visited)
+	}
+	for i, commit := range commits {
+		notes := visited[commit]
+		for j := range notes {
+			notes[j] = nonEmptyNotes(notes[j])
+		}
+		if len(notes) != 3 || len(notes[0]) != 1 || string(notes[0][0]) != `{"request":"`+commit+`"}` || len(notes[2]) != 0 {
+			t.Errorf("Unexpected notes for commit %d: %q", i, notes)
+		}
+		if wantComment := i > 0; wantComment != (len(notes[1]) == 1 && string(notes[1][0]) == `{"comment":"`+commit+`"}`) {
+			t.Errorf("Unexpected comments for commit %d: %q", i, notes[1])
+		}
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	err = repo.EachNotes([]string{requestsRef, commentsRef}, func(commit string, notes [][]Note) error {
+		count++
+		return errStop
+	})
+	if err != errStop || count != 1 {
+		t.Errorf("Unexpected result of stopping the visit: %v after %d commits", err, count)
+	}
+}
+
+func TestGitRepoSetRef(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	head, err := repo.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSetRef(t, repo, head)
+}
+
+func TestGitRepoReadConfigFile(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	if err := os.MkdirAll(filepath.Join(repo.Path, ".appraise"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(repo.Path, ".appraise", "teams.yml"), []byte("teams: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.WriteConfigFile(".appraise/config", "appraise.submit.strategy", "rebase"); err != nil {
+		t.Fatal(err)
+	}
+	checkConfigFile := func(source string) {
+		if values, err := repo.ReadConfigFile(".appraise"); err != nil || values != nil {
+			t.Errorf("Unexpected settings read from the %s directory: %v, %v", source, values, err)
+		}
+		values, err := repo.ReadConfigFile(".appraise/config")
+		if err != nil || values["appraise.submit.strategy"] != "rebase" {
+			t.Errorf("Unexpected settings read from the %s file: %v, %v", source, values, err)
+		}
+	}
+	checkConfigFile("work tree")
+
+	if _, err := repo.runGitCommand("add", ".appraise"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGitCommand("commit", "-q", "-m", "Add the settings"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(repo.Path, ".appraise")); err != nil {
+		t.Fatal(err)
+	}
+	checkConfigFile("committed")
+}
+
+func TestGitRepoFetchWithDepth(t *testing.T) {
+	upstream := newGitRepoForTest(t)
+	for _, message := range []string{"Second commit", "Third commit"} {
+		if _, err := upstream.runGitCommand("commit", "-q", "--allow-empty", "-m", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "clone", "-q", upstream.Path, dir).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to clone the repo: %v: %s", err, output)
+	}
+	repo, err := NewGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := upstream.runGitCommand("checkout", "-q", "-b", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"Fourth commit", "Fifth commit"} {
+		if _, err := upstream.runGitCommand("commit", "-q", "--allow-empty", "-m", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repo.FetchWithOptions(upstream.Path, FetchOptions{Depth: 1}, "+refs/heads/*:refs/forks/upstream/*"); err != nil {
+		t.Fatal(err)
+	}
+	// The history that was already complete locally is still complete,
+	// while the newly fetched branch is limited to the given depth.
+	if count, err := repo.runGitCommand("rev-list", "--count", "HEAD"); err != nil || count != "3" {
+		t.Errorf("Unexpected number of local commits after a shallow fetch: %q, %v", count, err)
+	}
+	if count, err := repo.runGitCommand("rev-list", "--count", "refs/forks/upstream/feature"); err != nil || count != "1" {
+		t.Errorf("Unexpected number of fetched commits: %q, %v", count, err)
+	}
+}
+
+func TestGitRepoFetchRemoteStartingWithDash(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	marker := filepath.Join(t.TempDir(), "marker")
+	if err := repo.Fetch("--upload-pack=touch "+marker, "+refs/heads/*:refs/forks/upstream/*"); err == nil {
+		t.Error("Unexpected success fetching from a remote that looks like an option")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("The remote was treated as an option")
+	}
+}
+
+func TestGitRepoDiffWithOptions(t *testing.T) {
+	repo := newGitRepoForTest(t)
+	base, err := repo.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, contents := range map[string]string{
+		"main.go":     "package main\n\nfunc main() {\n\tprintln(1)\n}\n",
+		"docs/a.md":   "# Docs\n",
+		"docs/b.txt":  "Notes\n",
+		"indented.go": "package main\n\n  var x = 1\n",
+	} {
+		full := filepath.Join(repo.Path, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(full, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.runGitCommand("add", "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGitCommand("commit", "-q", "-m", "Add files"); err != nil {
+		t.Fatal(err)
+	}
+	middle, err := repo.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only change the indentation.
+	if err := ioutil.WriteFile(filepath.Join(repo.Path, "indented.go"), []byte("package main\n\n\tvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.runGitCommand("commit", "-q", "-a", "-m", "Reindent"); err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff, err := repo.DiffWithOptions(middle, head, DiffOptions{}); err != nil || !strings.Contains(diff, "indented.go") {
+		t.Errorf("Unexpected diff of a change in indentation: %q, %v", diff, err)
+	}
+	if diff, err := repo.DiffWithOptions(middle, head, DiffOptions{IgnoreWhitespace: true}); err != nil || strings.Contains(diff, "var x") {
+		t.Errorf("Unexpected diff ignoring whitespace: %q, %v", diff, err)
+	}
+	diff, err := repo.DiffWithOptions(base, middle, DiffOptions{Stat: true})
+	if err != nil || !strings.Contains(diff, "4 files changed") || strings.Contains(diff, "@@") {
+		t.Errorf("Unexpected diff summary: %q, %v", diff, err)
+	}
+	// Globs match across directories only with "**", as in .gitignore files.
+	diff, err = repo.DiffWithOptions(base, middle, DiffOptions{Paths: []string{"*.go", "docs/*.md"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, included := range map[string]bool{"main.go": true, "indented.go": true, "docs/a.md": true, "docs/b.txt": false} {
+		if strings.Contains(diff, "+++ b/"+path) != included {
+			t.Errorf("Unexpected diff limited to globs, expected %q to be included: %v, got %q", path, included, diff)
+		}
+	}
+}
+
+func TestGitRepoPullNotesWithProgress(t *testing.T) {
+	upstream := newGitRepoForTest(t)
+	const notesRef = "refs/notes/devtools/reviews"
+	head, err := upstream.GetCommitHash("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := upstream.AppendNote(notesRef, head, Note(`{"description":"Pulled"}`)); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if output, err := exec.Command("git", "clone", "-q", upstream.Path, dir).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to clone the repo: %v: %s", err, output)
+	}
+	repo, err := NewGitRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A cancelled pull stops after fetching, without merging any notes.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := repo.PullNotesAndArchiveWithProgress(ctx, "origin", "refs/notes/devtools/*", "refs/devtools/archives/*", nil); err == nil {
+		t.Error("Unexpected success of a cancelled pull")
+	}
+	if exists, err := repo.HasRef(notesRef); err != nil || exists {
+		t.Errorf("Unexpected notes ref after a cancelled pull: %v, %v", exists, err)
+	}
+
+	var progress bytes.Buffer
+	if err := repo.PullNotesAndArchiveWithProgress(context.Background(), "origin", "refs/notes/devtools/*", "refs/devtools/archives/*", &progress); err != nil {
+		t.Fatal(err)
+	}
+	// The notes were already fetched by the cancelled pull.
+	if !strings.Contains(progress.String(), "Fetched updates for 0 reviews") || !strings.Contains(progress.String(), "Merging notes from refs/notes/remotes/origin/devtools/reviews") {
+		t.Errorf("Unexpected progress of the pull: %q", progress.String())
+	}
+	if notes := nonEmptyNotes(repo.GetNotes(notesRef, head)); len(notes) != 1 {
+		t.Errorf("Unexpected notes after the pull: %q", notes)
+	}
+}
+
+func TestMockRepoSetRef(t *testing.T) {
+	testSetRef(t, NewMockRepoForTest(), TestCommitA)
+}
+
+// testSetRef checks that SetRef only updates a ref from its expected value,
+// where an empty value means that the ref must not exist yet.
+func testSetRef(t *testing.T, repo Repo, commit string) {
+	const ref = "refs/heads/new"
+	if err := repo.SetRef(ref, commit, ""); err != nil {
+		t.Fatalf("Failed to create a new ref: %v", err)
+	}
+	if err := repo.SetRef(ref, commit, ""); err == nil {
+		t.Error("Unexpected success creating a ref that already exists")
+	}
+	if err := repo.SetRef(ref, "", commit); err != nil {
+		t.Fatalf("Failed to delete the ref: %v", err)
+	}
+	if exists, err := repo.HasRef(ref); err != nil || exists {
+		t.Errorf("Unexpected ref after deleting it: %v, %v", exists, err)
+	}
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package repository
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
 	"strings"
 )
 
@@ -74,10 +77,13 @@ type mockCommit struct {
 
 // mockRepoForTest defines an instance of Repo that can be used for testing.
 type mockRepoForTest struct {
-	Head    string
-	Refs    map[string]string            `json:"refs,omitempty"`
-	Commits map[string]mockCommit        `json:"commits,omitempty"`
-	Notes   map[string]map[string]string `json:"notes,omitempty"`
+	Head          string
+	DefaultTarget string
+	Config        map[string][]string // Every value of each key, in the order they were added.
+	ConfigFiles   map[string]map[string]string
+	Refs          map[string]string            `json:"refs,omitempty"`
+	Commits       map[string]mockCommit        `json:"commits,omitempty"`
+	Notes         map[string]map[string]string `json:"notes,omitempty"`
 }
 
 func (r *mockRepoForTest) createCommit(message string, time string, parents []string) (string, error) {
@@ -183,6 +189,9 @@ func NewMockRepoForTest() Repo {
 // GetPath returns the path to the repo.
 func (r *mockRepoForTest) GetPath() string { return "~/mockRepo/" }
 
+// GetGitDir returns the path to the repo's git directory.
+func (r *mockRepoForTest) GetGitDir() string { return "~/mockRepo/.git" }
+
 // GetRepoStateHash returns a hash which embodies the entire current state of a repository.
 func (r *mockRepoForTest) GetRepoStateHash() (string, error) {
 	repoJSON, err := json.Marshal(r)
@@ -207,6 +216,72 @@ func (r *mockRepoForTest) GetCoreEditor() (string, error) { return "vi", nil }
 // GetSubmitStrategy returns the way in which a review is submitted
 func (r *mockRepoForTest) GetSubmitStrategy() (string, error) { return "merge", nil }
 
+// GetConfig returns the value of the given git config key, or the empty
+// string if the key is not set.
+//
+// As with git, the last value of a multi-valued key is returned.
+func (r *mockRepoForTest) GetConfig(key string) (string, error) {
+	values := r.Config[key]
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[len(values)-1], nil
+}
+
+// GetConfigValues returns every value of the given (possibly multi-valued)
+// git config key, or nil if the key is not set.
+func (r *mockRepoForTest) GetConfigValues(key string) ([]string, error) {
+	return append([]string(nil), r.Config[key]...), nil
+}
+
+// AddConfigValue adds a value to the given (possibly multi-valued) git
+// config key in the repo's local config.
+func (r *mockRepoForTest) AddConfigValue(key, value string) error {
+	if r.Config == nil {
+		r.Config = make(map[string][]string)
+	}
+	r.Config[key] = append(r.Config[key], value)
+	return nil
+}
+
+// ReadConfigFile parses the file at the given path within the repo, which
+// uses the syntax of git config files, returning nil if it does not exist.
+func (r *mockRepoForTest) ReadConfigFile(path string) (map[string]string, error) {
+	return r.ConfigFiles[path], nil
+}
+
+// WriteConfigFile sets the value of the given key in the file at the given
+// path within the repo's work tree.
+func (r *mockRepoForTest) WriteConfigFile(path, key, value string) error {
+	if r.ConfigFiles == nil {
+		r.ConfigFiles = make(map[string]map[string]string)
+	}
+	if r.ConfigFiles[path] == nil {
+		r.ConfigFiles[path] = make(map[string]string)
+	}
+	r.ConfigFiles[path][key] = value
+	return nil
+}
+
+// SetConfig sets the value of the given git config key in the repo's local config.
+func (r *mockRepoForTest) SetConfig(key, value string) error {
+	if r.Config == nil {
+		r.Config = make(map[string][]string)
+	}
+	r.Config[key] = []string{value}
+	return nil
+}
+
+// GetDefaultTargetRef returns the ref that new reviews target by default,
+// or the empty string if no default has been configured.
+func (r *mockRepoForTest) GetDefaultTargetRef() (string, error) { return r.DefaultTarget, nil }
+
+// SetDefaultTargetRef sets the ref that new reviews target by default.
+func (r *mockRepoForTest) SetDefaultTargetRef(ref string) error {
+	r.DefaultTarget = ref
+	return nil
+}
+
 // HasUncommittedChanges returns true if there are local, uncommitted changes.
 func (r *mockRepoForTest) HasUncommittedChanges() (bool, error) { return false, nil }
 
@@ -225,15 +300,17 @@ func (r *mockRepoForTest) resolveLocalRef(ref string) (string, error) {
 
 // HasRef checks whether the specified ref exists in the repo.
 func (r *mockRepoForTest) HasRef(ref string) (bool, error) {
-	if _, ok := r.Refs[ref]; !ok {
-		return false, nil
+	if _, ok := r.Refs[ref]; ok {
+		return true, nil
 	}
-	return true, nil
+	_, ok := r.Notes[ref]
+	return ok, nil
 }
 
 // HasObject reports whether or not the repo contains an object with the given hash
 func (r *mockRepoForTest) HasObject(hash string) (bool, error) {
-	return false, errors.New("Not implemented")
+	_, ok := r.Commits[hash]
+	return ok, nil
 }
 
 // VerifyCommit verifies that the supplied hash points to a known commit.
@@ -250,6 +327,12 @@ func (r *mockRepoForTest) VerifyGitRef(ref string) error {
 	return err
 }
 
+// VerifyCommitSignature verifies that the given commit has a valid GPG
+// signature from a trusted key.
+func (r *mockRepoForTest) VerifyCommitSignature(hash string) error {
+	return r.VerifyCommit(hash)
+}
+
 // GetHeadRef returns the ref that is the current HEAD.
 func (r *mockRepoForTest) GetHeadRef() (string, error) { return r.Head, nil }
 
@@ -319,12 +402,28 @@ func (r *mockRepoForTest) GetCommitDetails(ref string) (*CommitDetails, error) {
 	var details CommitDetails
 	details.Author = "Test Author"
 	details.AuthorEmail = "author@example.com"
+	details.Committer = details.Author
+	details.CommitterEmail = details.AuthorEmail
 	details.Summary = commit.Message
 	details.Time = commit.Time
 	details.Parents = commit.Parents
 	return &details, nil
 }
 
+// GetPatchID returns the stable patch ID of the changes made by the given
+// commit.
+//
+// Mock commits have no contents, so commits with the same subject are
+// treated as making the same changes.
+func (r *mockRepoForTest) GetPatchID(commit string) (string, error) {
+	c, err := r.getCommit(commit)
+	if err != nil {
+		return "", err
+	}
+	subject := strings.SplitN(c.Message, "\n", 2)[0]
+	return fmt.Sprintf("%x", sha1.Sum([]byte(subject))), nil
+}
+
 // ancestors returns the breadth-first traversal of a commit's ancestors
 func (r *mockRepoForTest) ancestors(commit string) ([]string, error) {
 	queue := []string{commit}
@@ -390,6 +489,28 @@ func (r *mockRepoForTest) Diff(left, right string, diffArgs ...string) (string,
 	return fmt.Sprintf("Diff between %q and %q", left, right), nil
 }
 
+// DiffWithOptions computes the diff between two given commits, as
+// controlled by the given options.
+//
+// The options are described in the returned text, so that tests can check
+// that they were passed through.
+func (r *mockRepoForTest) DiffWithOptions(left, right string, options DiffOptions, diffArgs ...string) (string, error) {
+	diff, err := r.Diff(left, right, diffArgs...)
+	if err != nil {
+		return "", err
+	}
+	if options.IgnoreWhitespace {
+		diff += ", ignoring whitespace"
+	}
+	if options.Stat {
+		diff += ", as a summary"
+	}
+	if len(options.Paths) > 0 {
+		diff += fmt.Sprintf(", limited to %q", options.Paths)
+	}
+	return diff, nil
+}
+
 // Show returns the contents of the given file at the given commit.
 func (r *mockRepoForTest) Show(commit, path string) (string, error) {
 	return fmt.Sprintf("%s:%s", commit, path), nil
@@ -411,6 +532,12 @@ func (r *mockRepoForTest) SwitchToRef(ref string) error {
 // If the ref pointed to by the 'archive' argument does not exist
 // yet, then it will be created.
 func (r *mockRepoForTest) ArchiveRef(ref, archive string) error {
+	return r.ArchiveReviewHead(ref, archive, "")
+}
+
+// ArchiveReviewHead is like ArchiveRef, but also records that the archived
+// commit was a head of the review with the given revision.
+func (r *mockRepoForTest) ArchiveReviewHead(ref, archive, review string) error {
 	commitToArchive, err := r.resolveLocalRef(ref)
 	if err != nil {
 		return err
@@ -421,7 +548,11 @@ func (r *mockRepoForTest) ArchiveRef(ref, archive string) error {
 	} else {
 		archiveParents = []string{commitToArchive}
 	}
-	archiveCommit, err := r.createCommit("Archiving", "Nowish", archiveParents)
+	message := "Archiving"
+	if review != "" {
+		message += " review " + review
+	}
+	archiveCommit, err := r.createCommit(message, "Nowish", archiveParents)
 	if err != nil {
 		return err
 	}
@@ -429,6 +560,26 @@ func (r *mockRepoForTest) ArchiveRef(ref, archive string) error {
 	return nil
 }
 
+// ListArchivedCommits returns the commits that were added to the given
+// archive ref, with the most recently archived first.
+func (r *mockRepoForTest) ListArchivedCommits(archive string) ([]ArchivedCommit, error) {
+	var archived []ArchivedCommit
+	archiveCommit, err := r.resolveLocalRef(archive)
+	for err == nil && archiveCommit != "" {
+		commit := r.Commits[archiveCommit]
+		archived = append(archived, ArchivedCommit{
+			Hash:   commit.Parents[len(commit.Parents)-1],
+			Time:   commit.Time,
+			Review: strings.TrimPrefix(strings.TrimPrefix(commit.Message, "Archiving"), " review "),
+		})
+		archiveCommit = ""
+		if len(commit.Parents) > 1 {
+			archiveCommit = commit.Parents[0]
+		}
+	}
+	return archived, nil
+}
+
 // MergeRef merges the given ref into the current one.
 //
 // The ref argument is the ref to merge, and fastForward indicates that the
@@ -469,6 +620,23 @@ func (r *mockRepoForTest) MergeAndSignRef(ref string, fastForward bool,
 	return nil
 }
 
+// CreateTag creates an annotated tag with the given name and message,
+// pointing at the given commit.
+func (r *mockRepoForTest) CreateTag(name, commit, message string) error {
+	tagRef := "refs/tags/" + name
+	if _, ok := r.Refs[tagRef]; ok {
+		return fmt.Errorf("tag %q already exists", name)
+	}
+	r.Refs[tagRef] = commit
+	return nil
+}
+
+// CreateAndSignTag creates a signed tag with the given name and message,
+// pointing at the given commit.
+func (r *mockRepoForTest) CreateAndSignTag(name, commit, message string) error {
+	return r.CreateTag(name, commit, message)
+}
+
 // RebaseRef rebases the current ref onto the given one.
 func (r *mockRepoForTest) RebaseRef(ref string) error {
 	parentHash := r.Refs[ref]
@@ -494,6 +662,56 @@ func (r *mockRepoForTest) RebaseRef(ref string) error {
 // result.
 func (r *mockRepoForTest) RebaseAndSignRef(ref string) error { return nil }
 
+// IsRebaseInProgress returns whether or not a rebase has been started
+// but not yet completed or aborted.
+func (r *mockRepoForTest) IsRebaseInProgress() (bool, error) { return false, nil }
+
+// ContinueRebase resumes an in-progress rebase, e.g. after conflicts have
+// been resolved.
+func (r *mockRepoForTest) ContinueRebase() error { return nil }
+
+// AbortRebase abandons an in-progress rebase, restoring the ref that
+// was being rebased to its original state.
+func (r *mockRepoForTest) AbortRebase() error { return nil }
+
+// AddWorktree checks out the given commit, detached, into a new working
+// tree in the given directory, leaving the current one unchanged.
+func (r *mockRepoForTest) AddWorktree(path, commit string) error {
+	return r.VerifyCommit(commit)
+}
+
+// CherryPick applies the changes of the given commits, in order, on top
+// of the current ref, noting the original commit in each new commit's
+// message.
+func (r *mockRepoForTest) CherryPick(commits ...string) error {
+	head, err := r.resolveLocalRef(r.Head)
+	if err != nil {
+		return err
+	}
+	for _, commit := range commits {
+		original, err := r.getCommit(commit)
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("%s\n\n(cherry picked from commit %s)", original.Message, commit)
+		if head, err = r.createCommit(message, original.Time, []string{head}); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(r.Head, "refs/heads/") {
+		r.Refs[r.Head] = head
+	} else {
+		r.Head = head
+	}
+	return nil
+}
+
+// Stash sets aside all uncommitted changes, including untracked files.
+func (r *mockRepoForTest) Stash() error { return nil }
+
+// PopStash restores the most recently stashed changes.
+func (r *mockRepoForTest) PopStash() error { return nil }
+
 // ListCommits returns the list of commits reachable from the given ref.
 //
 // The generated list is in chronological order (with the oldest commit first).
@@ -517,8 +735,13 @@ func (r *mockRepoForTest) ListCommits(ref string) []string { return nil }
 // The generated list is in chronological order (with the oldest commit first).
 func (r *mockRepoForTest) ListCommitsBetween(from, to string) ([]string, error) {
 	commits := []string{to}
+	seen := map[string]bool{to: true}
 	potentialCommits, _ := r.ancestors(to)
 	for _, commit := range potentialCommits {
+		if seen[commit] {
+			continue
+		}
+		seen[commit] = true
 		blocked, err := r.IsAncestor(commit, from)
 		if err != nil {
 			return nil, err
@@ -527,9 +750,56 @@ func (r *mockRepoForTest) ListCommitsBetween(from, to string) ([]string, error)
 			commits = append(commits, commit)
 		}
 	}
+	// The commits were found starting from the newest one.
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
 	return commits, nil
 }
 
+// reachable returns the set of commits reachable from the given ref.
+func (r *mockRepoForTest) reachable(ref string) (map[string]bool, error) {
+	commit, err := r.resolveLocalRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := r.ancestors(commit)
+	if err != nil {
+		return nil, err
+	}
+	commits := map[string]bool{commit: true}
+	for _, ancestor := range ancestors {
+		commits[ancestor] = true
+	}
+	return commits, nil
+}
+
+// CountAheadBehind returns the number of commits reachable from the
+// "head" revision but not the "base" revision (ahead), and vice versa
+// (behind).
+func (r *mockRepoForTest) CountAheadBehind(base, head string) (int, int, error) {
+	baseCommits, err := r.reachable(base)
+	if err != nil {
+		return 0, 0, err
+	}
+	headCommits, err := r.reachable(head)
+	if err != nil {
+		return 0, 0, err
+	}
+	var ahead, behind int
+	for commit := range headCommits {
+		if !baseCommits[commit] {
+			ahead++
+		}
+	}
+	for commit := range baseCommits {
+		if !headCommits[commit] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
 // StoreBlob writes the given file to the repository and returns its hash.
 func (r *mockRepoForTest) StoreBlob(contents string) (string, error) {
 	return "", fmt.Errorf("not implemented")
@@ -555,20 +825,33 @@ func (r *mockRepoForTest) CreateCommitWithTree(details *CommitDetails, t *Tree)
 	return "", fmt.Errorf("not implemented")
 }
 
+// ApplyPatch applies the given patch to the tree of the given commit, and
+// returns the hash of the resulting tree.
+func (r *mockRepoForTest) ApplyPatch(commit, patch string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
 // SetRef sets the commit pointed to by the specified ref to `newCommitHash`,
-// iff the ref currently points `previousCommitHash`.
+// iff the ref currently points `previousCommitHash`, or does not exist if
+// that is empty.
 func (r *mockRepoForTest) SetRef(ref, newCommitHash, previousCommitHash string) error {
-	return fmt.Errorf("not implemented")
+	if current, ok := r.Refs[ref]; previousCommitHash == "" && ok {
+		return fmt.Errorf("the ref %q already exists", ref)
+	} else if current != previousCommitHash {
+		return fmt.Errorf("the ref %q does not point to %q", ref, previousCommitHash)
+	}
+	if newCommitHash == "" {
+		delete(r.Refs, ref)
+		return nil
+	}
+	r.Refs[ref] = newCommitHash
+	return nil
 }
 
 // GetNotes reads the notes from the given ref that annotate the given revision.
 func (r *mockRepoForTest) GetNotes(notesRef, revision string) []Note {
 	notesText := r.Notes[notesRef][revision]
-	var notes []Note
-	for _, line := range strings.Split(notesText, "\n") {
-		notes = append(notes, Note(line))
-	}
-	return notes
+	return SplitNotes([]byte(notesText))
 }
 
 // GetAllNotes reads the contents of the notes under the given ref for every commit.
@@ -584,8 +867,29 @@ func (r *mockRepoForTest) GetAllNotes(notesRef string) (map[string][]Note, error
 	return notesMap, nil
 }
 
+// EachNotes calls visit with the notes under the given refs for every commit
+// that is annotated in the first of them.
+func (r *mockRepoForTest) EachNotes(notesRefs []string, visit func(commit string, notes [][]Note) error) error {
+	if len(notesRefs) == 0 {
+		return nil
+	}
+	for _, commit := range r.ListNotedRevisions(notesRefs[0]) {
+		notes := make([][]Note, len(notesRefs))
+		for i, notesRef := range notesRefs {
+			notes[i] = r.GetNotes(notesRef, commit)
+		}
+		if err := visit(commit, notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AppendNote appends a note to a revision under the given ref.
 func (r *mockRepoForTest) AppendNote(ref, revision string, note Note) error {
+	if r.Notes[ref] == nil {
+		r.Notes[ref] = make(map[string]string)
+	}
 	existingNotes := r.Notes[ref][revision]
 	newNotes := existingNotes + "\n" + string(note)
 	r.Notes[ref][revision] = newNotes
@@ -603,6 +907,16 @@ func (r *mockRepoForTest) ListNotedRevisions(notesRef string) []string {
 	return revisions
 }
 
+// ListNotedObjects returns the hashes of every object annotated by notes in the given ref.
+func (r *mockRepoForTest) ListNotedObjects(notesRef string) ([]string, error) {
+	var objects []string
+	for object := range r.Notes[notesRef] {
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
 // Remotes returns a list of the remotes.
 func (r *mockRepoForTest) Remotes() ([]string, error) {
 	return []string{"origin"}, nil
@@ -611,6 +925,40 @@ func (r *mockRepoForTest) Remotes() ([]string, error) {
 // Fetch fetches from the given remote using the supplied refspecs.
 func (r *mockRepoForTest) Fetch(remote string, refspecs ...string) error { return nil }
 
+// FetchWithOptions fetches from the given remote using the supplied
+// refspecs, as controlled by the given options.
+func (r *mockRepoForTest) FetchWithOptions(remote string, options FetchOptions, refspecs ...string) error {
+	return nil
+}
+
+// ListRefs returns the local refs that start with the given prefix,
+// mapped to the hashes they point to.
+func (r *mockRepoForTest) ListRefs(prefix string) (map[string]string, error) {
+	refs := make(map[string]string)
+	for ref, hash := range r.Refs {
+		if strings.HasPrefix(ref, prefix) {
+			refs[ref] = hash
+		}
+	}
+	// Notes refs are not kept in r.Refs, so they point to a hash of their notes.
+	for ref, notes := range r.Notes {
+		if strings.HasPrefix(ref, prefix) && len(notes) > 0 {
+			notesJSON, err := json.Marshal(notes)
+			if err != nil {
+				return nil, err
+			}
+			refs[ref] = fmt.Sprintf("%x", sha1.Sum(notesJSON))
+		}
+	}
+	return refs, nil
+}
+
+// ListRemoteRefs returns the refs of the given remote (or URL) that match
+// the given patterns, mapped to the hashes they point to.
+func (r *mockRepoForTest) ListRemoteRefs(remote string, refPatterns ...string) (map[string]string, error) {
+	return nil, nil
+}
+
 // PushNotes pushes git notes to a remote repo.
 func (r *mockRepoForTest) PushNotes(remote, notesRefPattern string) error { return nil }
 
@@ -640,9 +988,40 @@ func (r *mockRepoForTest) PullNotesAndArchive(remote, notesRefPattern, archiveRe
 	return nil
 }
 
-// MergeNotes merges in the remote's state of the archives reference into
+// PullNotesAndArchiveWithProgress behaves like PullNotesAndArchive, but
+// reports its progress to the given writer, and stops early if the given
+// context is cancelled.
+func (r *mockRepoForTest) PullNotesAndArchiveWithProgress(ctx context.Context, remote, notesRefPattern, archiveRefPattern string, progress io.Writer) error {
+	return ctx.Err()
+}
+
+// MergeNotes merges in the remote's state of the notes references into
 // the local repository's.
+//
+// The remote's notes are those under refs/notes/remotes/<remote>/, and are
+// appended to the local notes that do not already contain them.
 func (r *mockRepoForTest) MergeNotes(remote, notesRefPattern string) error {
+	remotePrefix := "refs/notes/remotes/" + remote + "/"
+	for remoteRef, remoteNotes := range r.Notes {
+		if !strings.HasPrefix(remoteRef, remotePrefix) {
+			continue
+		}
+		localRef := "refs/notes/" + strings.TrimPrefix(remoteRef, remotePrefix)
+		if !strings.HasPrefix(localRef, strings.TrimSuffix(notesRefPattern, "*")) {
+			continue
+		}
+		for revision := range remoteNotes {
+			existing := make(map[string]bool)
+			for _, note := range r.GetNotes(localRef, revision) {
+				existing[string(note)] = true
+			}
+			for _, note := range r.GetNotes(remoteRef, revision) {
+				if len(note) > 0 && !existing[string(note)] {
+					r.AppendNote(localRef, revision, note)
+				}
+			}
+		}
+	}
 	return nil
 }
 
@@ -667,3 +1046,46 @@ func (r *mockRepoForTest) FetchAndReturnNewReviewHashes(remote, notesRefPattern
 func (r *mockRepoForTest) Push(remote string, refPattern ...string) error {
 	return nil
 }
+
+// CreateBundle writes every ref matching the given patterns to a git
+// bundle file at the given path, and returns a mapping from the names
+// of the bundled refs to the commits they point to.
+//
+// The bundle file only lists the bundled refs, as the mock has no objects.
+func (r *mockRepoForTest) CreateBundle(path string, refPatterns ...string) (map[string]string, error) {
+	bundledRefs := make(map[string]string)
+	for _, refPattern := range refPatterns {
+		prefix := strings.TrimSuffix(refPattern, "*")
+		for ref, hash := range r.Refs {
+			if strings.HasPrefix(ref, prefix) {
+				bundledRefs[ref] = hash
+			}
+		}
+		for ref, notes := range r.Notes {
+			if strings.HasPrefix(ref, prefix) && len(notes) > 0 {
+				serialized, err := json.Marshal(notes)
+				if err != nil {
+					return nil, err
+				}
+				bundledRefs[ref] = fmt.Sprintf("%x", sha1.Sum(serialized))
+			}
+		}
+	}
+	if len(bundledRefs) == 0 {
+		return nil, fmt.Errorf("%w matching %v", ErrNoRefsToBundle, refPatterns)
+	}
+	contents, err := json.Marshal(bundledRefs)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+		return nil, err
+	}
+	return bundledRefs, nil
+}
+
+// PullNotesAndArchiveFromBundle reads the notes and archive refs from
+// a git bundle file, and merges them with the corresponding local refs.
+func (r *mockRepoForTest) PullNotesAndArchiveFromBundle(path, notesRefPattern, archiveRefPattern string) error {
+	return nil
+}
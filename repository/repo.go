@@ -18,10 +18,19 @@ limitations under the License.
 package repository
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha1"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 )
 
+// ErrNoRefsToBundle is returned by CreateBundle when no refs match the given
+// patterns, as git can not create an empty bundle.
+var ErrNoRefsToBundle = errors.New("there are no refs to bundle")
+
 // Note represents the contents of a git-note
 type Note []byte
 
@@ -30,6 +39,68 @@ func (n Note) Hash() string {
 	return fmt.Sprintf("%x", sha1.Sum([]byte(n)))
 }
 
+// SplitNotes splits the contents of a notes object into individual notes.
+//
+// The notes written by this tool are each a single line of JSON, but other
+// tools (or people editing notes by hand) may write JSON values that span
+// several lines, e.g. when they are pretty-printed. Each such value is kept
+// together as a single note, rather than being split into lines that are
+// each invalid on their own. Every other line is returned as a separate note.
+//
+// Notes are deliberately not delimited by NUL bytes, and large notes are not
+// split into chunks: git merges notes with the cat_sort_uniq strategy, which
+// sorts the lines of a notes object, so anything other than one note per line
+// would be scrambled by the first merge.
+func SplitNotes(contents []byte) []Note {
+	var notes []Note
+	for {
+		line := contents
+		rest := []byte(nil)
+		if i := bytes.IndexByte(contents, '\n'); i >= 0 {
+			line, rest = contents[:i], contents[i+1:]
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && !json.Valid(line) {
+			decoder := json.NewDecoder(bytes.NewReader(contents))
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err == nil {
+				end := int(decoder.InputOffset())
+				line = bytes.TrimSpace(contents[:end])
+				rest = bytes.TrimPrefix(contents[end:], []byte("\n"))
+			}
+		}
+		notes = append(notes, Note(line))
+		if rest == nil {
+			return notes
+		}
+		contents = rest
+	}
+}
+
+// FetchOptions controls how refs are fetched by FetchWithOptions.
+type FetchOptions struct {
+	// Depth limits the fetched history to the given number of commits from
+	// the tip of each ref. Zero means that the full history is fetched.
+	//
+	// The history of commits that were already complete locally is not cut
+	// off, so only the newly fetched commits are shallow.
+	Depth int
+	// Prune deletes the local refs that the refspecs fetch into, but whose
+	// counterparts no longer exist in the remote.
+	Prune bool
+}
+
+// DiffOptions controls how diffs are computed by DiffWithOptions.
+type DiffOptions struct {
+	// IgnoreWhitespace ignores changes in whitespace when comparing lines.
+	IgnoreWhitespace bool
+	// Stat computes a summary of the changes to each file instead of a diff.
+	Stat bool
+	// Paths limits the diff to the files matching any of the given glob
+	// patterns. An empty list means that every file is included.
+	Paths []string
+}
+
 // CommitDetails represents the contents of a commit.
 type CommitDetails struct {
 	Author         string   `json:"author,omitempty"`
@@ -43,6 +114,17 @@ type CommitDetails struct {
 	Summary        string   `json:"summary,omitempty"`
 }
 
+// ArchivedCommit describes a commit that was added to an archive ref.
+type ArchivedCommit struct {
+	// Hash is the hash of the archived commit.
+	Hash string `json:"hash"`
+	// Time is when the commit was archived, in seconds since the epoch.
+	Time string `json:"time,omitempty"`
+	// Review is the revision of the review that the commit was a head of,
+	// if it was archived by ArchiveReviewHead.
+	Review string `json:"review,omitempty"`
+}
+
 type TreeChild interface {
 	// Type returns the type of the child object (e.g. "blob" vs. "tree").
 	Type() string
@@ -141,6 +223,10 @@ type Repo interface {
 	// GetPath returns the path to the repo.
 	GetPath() string
 
+	// GetGitDir returns the path to the repo's git directory, which holds
+	// files that are private to the repo, such as a message being edited.
+	GetGitDir() string
+
 	// GetRepoStateHash returns a hash which embodies the entire current state of a repository.
 	GetRepoStateHash() (string, error)
 
@@ -157,6 +243,39 @@ type Repo interface {
 	// GetSubmitStrategy returns the way in which a review is submitted
 	GetSubmitStrategy() (string, error)
 
+	// GetConfig returns the value of the given git config key, or the empty
+	// string if the key is not set.
+	GetConfig(key string) (string, error)
+
+	// GetConfigValues returns every value of the given (possibly multi-valued)
+	// git config key, or nil if the key is not set.
+	GetConfigValues(key string) ([]string, error)
+
+	// AddConfigValue adds a value to the given (possibly multi-valued) git
+	// config key in the repo's local config.
+	AddConfigValue(key, value string) error
+
+	// ReadConfigFile parses the file at the given path within the repo, which
+	// uses the syntax of git config files, returning nil if it does not exist
+	// or is not a file.
+	ReadConfigFile(path string) (map[string]string, error)
+
+	// WriteConfigFile sets the value of the given key in the file at the
+	// given path within the repo's work tree, which uses the syntax of git
+	// config files, creating the file if necessary.
+	WriteConfigFile(path, key, value string) error
+
+	// SetConfig sets the value of the given git config key in the repo's
+	// local config, replacing any existing value.
+	SetConfig(key, value string) error
+
+	// GetDefaultTargetRef returns the ref that new reviews target by default,
+	// or the empty string if no default has been configured.
+	GetDefaultTargetRef() (string, error)
+
+	// SetDefaultTargetRef sets the ref that new reviews target by default.
+	SetDefaultTargetRef(ref string) error
+
 	// HasUncommittedChanges returns true if there are local, uncommitted changes.
 	HasUncommittedChanges() (bool, error)
 
@@ -172,6 +291,10 @@ type Repo interface {
 	// VerifyGitRef verifies that the supplied ref points to a known commit.
 	VerifyGitRef(ref string) error
 
+	// VerifyCommitSignature verifies that the given commit has a valid GPG
+	// signature from a trusted key.
+	VerifyCommitSignature(hash string) error
+
 	// GetHeadRef returns the ref that is the current HEAD.
 	GetHeadRef() (string, error)
 
@@ -201,6 +324,12 @@ type Repo interface {
 	// GetCommitDetails returns the details of a commit's metadata.
 	GetCommitDetails(ref string) (*CommitDetails, error)
 
+	// GetPatchID returns the stable patch ID of the changes made by the given
+	// commit, which is the same for commits that make the same changes, such
+	// as a commit and its cherry-picks. It is empty for commits that change
+	// nothing, and for merge commits.
+	GetPatchID(commit string) (string, error)
+
 	// MergeBase determines if the first commit that is an ancestor of the two arguments.
 	MergeBase(a, b string) (string, error)
 
@@ -210,12 +339,20 @@ type Repo interface {
 	// Diff computes the diff between two given commits.
 	Diff(left, right string, diffArgs ...string) (string, error)
 
+	// DiffWithOptions computes the diff between two given commits, as
+	// controlled by the given options.
+	DiffWithOptions(left, right string, options DiffOptions, diffArgs ...string) (string, error)
+
 	// Show returns the contents of the given file at the given commit.
 	Show(commit, path string) (string, error)
 
 	// SwitchToRef changes the currently-checked-out ref.
 	SwitchToRef(ref string) error
 
+	// AddWorktree checks out the given commit, detached, into a new working
+	// tree in the given directory, leaving the current one unchanged.
+	AddWorktree(path, commit string) error
+
 	// ArchiveRef adds the current commit pointed to by the 'ref' argument
 	// under the ref specified in the 'archive' argument.
 	//
@@ -227,6 +364,16 @@ type Repo interface {
 	// yet, then it will be created.
 	ArchiveRef(ref, archive string) error
 
+	// ArchiveReviewHead is like ArchiveRef, but also records that the
+	// archived commit was a head of the review with the given revision.
+	ArchiveReviewHead(ref, archive, review string) error
+
+	// ListArchivedCommits returns the commits that were added to the given
+	// archive ref, with the most recently archived first.
+	//
+	// If the archive ref does not exist, then this returns an empty result.
+	ListArchivedCommits(archive string) ([]ArchivedCommit, error)
+
 	// MergeRef merges the given ref into the current one.
 	//
 	// The ref argument is the ref to merge, and fastForward indicates that the
@@ -247,10 +394,42 @@ type Repo interface {
 	// RebaseRef rebases the current ref onto the given one.
 	RebaseRef(ref string) error
 
+	// CreateTag creates an annotated tag with the given name and message,
+	// pointing at the given commit.
+	CreateTag(name, commit, message string) error
+
+	// CreateAndSignTag creates a signed tag with the given name and message,
+	// pointing at the given commit.
+	CreateAndSignTag(name, commit, message string) error
+
 	// RebaseAndSignRef rebases the current ref onto the given one and signs
 	// the result.
 	RebaseAndSignRef(ref string) error
 
+	// IsRebaseInProgress returns whether or not a rebase has been started
+	// but not yet completed or aborted.
+	IsRebaseInProgress() (bool, error)
+
+	// ContinueRebase resumes an in-progress rebase, e.g. after conflicts have
+	// been resolved.
+	ContinueRebase() error
+
+	// AbortRebase abandons an in-progress rebase, restoring the ref that
+	// was being rebased to its original state.
+	AbortRebase() error
+
+	// CherryPick applies the changes of the given commits, in order, on top
+	// of the current ref, noting the original commit in each new commit's
+	// message. If any of them do not apply cleanly, then the cherry-pick is
+	// abandoned and the current ref is left unchanged.
+	CherryPick(commits ...string) error
+
+	// Stash sets aside all uncommitted changes, including untracked files.
+	Stash() error
+
+	// PopStash restores the most recently stashed changes.
+	PopStash() error
+
 	// ListCommits returns the list of commits reachable from the given ref.
 	//
 	// The generated list is in chronological order (with the oldest commit first).
@@ -274,6 +453,11 @@ type Repo interface {
 	// The generated list is in chronological order (with the oldest commit first).
 	ListCommitsBetween(from, to string) ([]string, error)
 
+	// CountAheadBehind returns the number of commits reachable from the
+	// "head" revision but not the "base" revision (ahead), and vice versa
+	// (behind).
+	CountAheadBehind(base, head string) (ahead int, behind int, err error)
+
 	// StoreBlob writes the given file contents to the repository and returns its hash.
 	StoreBlob(contents string) (string, error)
 
@@ -289,8 +473,18 @@ type Repo interface {
 	// CreateCommitWithTree creates a commit object with the given tree and returns its hash.
 	CreateCommitWithTree(details *CommitDetails, t *Tree) (string, error)
 
+	// ApplyPatch applies the given patch, in the format of git diff, to the
+	// tree of the given commit, and returns the hash of the resulting tree.
+	//
+	// Neither the work tree nor the index are changed.
+	ApplyPatch(commit, patch string) (string, error)
+
 	// SetRef sets the commit pointed to by the specified ref to `newCommitHash`,
 	// iff the ref currently points `previousCommitHash`.
+	//
+	// As with `git update-ref`, an empty `previousCommitHash` means that the
+	// ref must not exist yet. If `newCommitHash` is empty, then the ref is
+	// deleted.
 	SetRef(ref, newCommitHash, previousCommitHash string) error
 
 	// GetNotes reads the notes from the given ref that annotate the given revision.
@@ -303,18 +497,45 @@ type Repo interface {
 	// This is the batch version of the corresponding GetNotes(...) method.
 	GetAllNotes(notesRef string) (map[string][]Note, error)
 
+	// EachNotes reads the notes under the given refs for every commit that is
+	// annotated in the first of them, calling visit with the commit's notes
+	// from each ref, in the order of the refs, as soon as they have been read.
+	//
+	// If visit returns an error, then no more commits are visited, and that
+	// error is returned.
+	//
+	// This is the streaming version of the corresponding GetAllNotes(...) method.
+	EachNotes(notesRefs []string, visit func(commit string, notes [][]Note) error) error
+
 	// AppendNote appends a note to a revision under the given ref.
 	AppendNote(ref, revision string, note Note) error
 
 	// ListNotedRevisions returns the collection of revisions that are annotated by notes in the given ref.
 	ListNotedRevisions(notesRef string) []string
 
+	// ListNotedObjects returns the hashes of every object annotated by notes
+	// in the given ref, including those that are not commits, and those that
+	// are missing from the repo.
+	ListNotedObjects(notesRef string) ([]string, error)
+
 	// Remotes returns a list of the remotes.
 	Remotes() ([]string, error)
 
 	// Fetch fetches from the given remote using the supplied refspecs.
 	Fetch(remote string, refspecs ...string) error
 
+	// FetchWithOptions fetches from the given remote using the supplied
+	// refspecs, as controlled by the given options.
+	FetchWithOptions(remote string, options FetchOptions, refspecs ...string) error
+
+	// ListRefs returns the local refs that start with the given prefix,
+	// mapped to the hashes they point to.
+	ListRefs(prefix string) (map[string]string, error)
+
+	// ListRemoteRefs returns the refs of the given remote (or URL) that match
+	// the given patterns, mapped to the hashes they point to.
+	ListRemoteRefs(remote string, refPatterns ...string) (map[string]string, error)
+
 	// PushNotes pushes git notes to a remote repo.
 	PushNotes(remote, notesRefPattern string) error
 
@@ -340,6 +561,14 @@ type Repo interface {
 	// intend to keep.
 	PullNotesAndArchive(remote, notesRefPattern, archiveRefPattern string) error
 
+	// PullNotesAndArchiveWithProgress behaves like PullNotesAndArchive, but
+	// reports its progress to the given writer, and stops early if the given
+	// context is cancelled.
+	//
+	// If the progress writer is nil, then nothing is reported. Stopping early
+	// always leaves the local refs in a consistent state.
+	PullNotesAndArchiveWithProgress(ctx context.Context, remote, notesRefPattern, archiveRefPattern string, progress io.Writer) error
+
 	// MergeNotes merges in the remote's state of the archives reference into
 	// the local repository's.
 	MergeNotes(remote, notesRefPattern string) error
@@ -359,4 +588,19 @@ type Repo interface {
 
 	// Push pushes the given refs to a remote repo.
 	Push(remote string, refPattern ...string) error
+
+	// CreateBundle writes every ref matching the given patterns to a git
+	// bundle file at the given path, and returns a mapping from the names
+	// of the bundled refs to the commits they point to.
+	//
+	// Each ref pattern must be of the form "<prefix>/*". If no refs match
+	// them, then ErrNoRefsToBundle is returned.
+	CreateBundle(path string, refPatterns ...string) (map[string]string, error)
+
+	// PullNotesAndArchiveFromBundle reads the notes and archive refs from
+	// a git bundle file, and merges them with the corresponding local refs.
+	//
+	// This behaves the same as PullNotesAndArchive, except that the bundle
+	// file takes the place of the remote repo.
+	PullNotesAndArchiveFromBundle(path, notesRefPattern, archiveRefPattern string) error
 }
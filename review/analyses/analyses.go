@@ -18,13 +18,19 @@ limitations under the License.
 package analyses
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
-	"net/http"
-	"sort"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/timestamp"
 )
 
 const (
@@ -48,6 +54,12 @@ type Report struct {
 	Timestamp string `json:"timestamp,omitempty"`
 	URL       string `json:"url,omitempty"`
 	Status    string `json:"status,omitempty"`
+	// AnalyzeResponse holds the results of the analysis inline, instead of
+	// at the URL, so that they outlive the server that produced them.
+	AnalyzeResponse []AnalyzeResponse `json:"analyze_response,omitempty"`
+	// CompressedResults holds the results of the analysis inline as the
+	// base64 encoding of the gzipped JSON of its ReportDetails.
+	CompressedResults string `json:"analyze_response_gz,omitempty"`
 	// Version represents the version of the metadata format.
 	Version int `json:"v,omitempty"`
 }
@@ -71,6 +83,39 @@ type Note struct {
 	Location    *Location `json:"location,omitempty"`
 	Category    string    `json:"category,omitempty"`
 	Description string    `json:"description"`
+	// Fix is an optional machine-generated fix for the problem, as a patch
+	// in the format of git diff against the analyzed revision.
+	Fix string `json:"fix,omitempty"`
+}
+
+// ID returns the SHA1 hash of the note's JSON serialization, which
+// identifies it within an analysis report.
+func (note Note) ID() (string, error) {
+	bytes, err := json.Marshal(note)
+	return fmt.Sprintf("%x", sha1.Sum(bytes)), err
+}
+
+// FindNote returns the note whose ID starts with the given prefix, or an
+// error if there is not exactly one.
+func FindNote(notes []Note, prefix string) (*Note, error) {
+	var found *Note
+	for i, note := range notes {
+		id, err := note.ID()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("the analysis note ID %q is ambiguous", prefix)
+		}
+		found = &notes[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("there is no analysis note with the ID %q", prefix)
+	}
+	return found, nil
 }
 
 // AnalyzeResponse represents the response from a static-analysis tool.
@@ -83,39 +128,84 @@ type ReportDetails struct {
 	AnalyzeResponse []AnalyzeResponse `json:"analyze_response,omitempty"`
 }
 
-// GetLintReportResult downloads the details of a lint report and returns the responses embedded in it.
-func (analysesReport Report) GetLintReportResult() ([]AnalyzeResponse, error) {
-	if analysesReport.URL == "" {
-		return nil, nil
+// HasInlineResults returns whether the results of the analysis are embedded
+// in the report itself, rather than at its URL.
+func (analysesReport Report) HasInlineResults() bool {
+	return analysesReport.AnalyzeResponse != nil || analysesReport.CompressedResults != ""
+}
+
+// inlineResults returns the results of the analysis embedded in the report.
+//
+// Compressed results are rejected if they decompress to more than the given
+// number of bytes, unless that is zero.
+func (analysesReport Report) inlineResults(maxSize int64) ([]AnalyzeResponse, error) {
+	if analysesReport.CompressedResults == "" {
+		return analysesReport.AnalyzeResponse, nil
 	}
-	res, err := http.Get(analysesReport.URL)
+	compressed, err := base64.StdEncoding.DecodeString(analysesReport.CompressedResults)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid compressed analysis results: %v", err)
 	}
-	analysesResults, err := ioutil.ReadAll(res.Body)
-	res.Body.Close()
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid compressed analysis results: %v", err)
 	}
-	var details ReportDetails
-	err = json.Unmarshal([]byte(analysesResults), &details)
+	defer reader.Close()
+	decompressed := io.Reader(reader)
+	if maxSize > 0 {
+		decompressed = io.LimitReader(reader, maxSize+1)
+	}
+	contents, err := ioutil.ReadAll(decompressed)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid compressed analysis results: %v", err)
+	}
+	if maxSize > 0 && int64(len(contents)) > maxSize {
+		return nil, fmt.Errorf("the analysis results are larger than %d bytes", maxSize)
+	}
+	details, err := parseDetails(contents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed analysis results: %v", err)
 	}
 	return details.AnalyzeResponse, nil
 }
 
-// GetNotes downloads the details of an analyses report and returns the notes embedded in it.
-func (analysesReport Report) GetNotes() ([]Note, error) {
-	reportResults, err := analysesReport.GetLintReportResult()
+// SetInlineResults embeds the given results in the report, compressing them
+// if requested. Reports with compressed results are smaller, but can not be
+// read by older versions of the tool.
+func (analysesReport *Report) SetInlineResults(results []AnalyzeResponse, compress bool) error {
+	if !compress {
+		analysesReport.AnalyzeResponse = results
+		analysesReport.CompressedResults = ""
+		return nil
+	}
+	serialized, err := json.Marshal(ReportDetails{AnalyzeResponse: results})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var reportNotes []Note
-	for _, reportResult := range reportResults {
-		reportNotes = append(reportNotes, reportResult.Notes...)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(serialized); err != nil {
+		return err
 	}
-	return reportNotes, nil
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	analysesReport.AnalyzeResponse = nil
+	analysesReport.CompressedResults = base64.StdEncoding.EncodeToString(compressed.Bytes())
+	return nil
+}
+
+// GetLintReportResult downloads the details of a lint report and returns the responses embedded in it.
+//
+// This uses the DefaultFetcher, which neither caches the details nor
+// retries failed downloads.
+func (analysesReport Report) GetLintReportResult() ([]AnalyzeResponse, error) {
+	return DefaultFetcher.Results(analysesReport)
+}
+
+// GetNotes downloads the details of an analyses report and returns the notes embedded in it.
+func (analysesReport Report) GetNotes() ([]Note, error) {
+	return DefaultFetcher.Notes(analysesReport)
 }
 
 // Parse parses an analysis report from a git note.
@@ -128,22 +218,18 @@ func Parse(note repository.Note) (Report, error) {
 
 // GetLatestAnalysesReport takes a collection of analysis reports, and returns the one with the most recent timestamp.
 func GetLatestAnalysesReport(reports []Report) (*Report, error) {
-	timestampReportMap := make(map[int]*Report)
-	var timestamps []int
-
-	for _, report := range reports {
-		timestamp, err := strconv.Atoi(report.Timestamp)
+	var latest *Report
+	var latestTime time.Time
+	for i, report := range reports {
+		t, err := timestamp.ParseReport(report.Timestamp)
 		if err != nil {
 			return nil, err
 		}
-		timestamps = append(timestamps, timestamp)
-		timestampReportMap[timestamp] = &report
-	}
-	if len(timestamps) == 0 {
-		return nil, nil
+		if latest == nil || !t.Before(latestTime) {
+			latest, latestTime = &reports[i], t
+		}
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(timestamps)))
-	return timestampReportMap[timestamps[0]], nil
+	return latest, nil
 }
 
 // ParseAllValid takes collection of git notes and tries to parse a analyses report
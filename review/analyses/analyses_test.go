@@ -17,10 +17,13 @@ limitations under the License.
 package analyses
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/google/git-appraise/repository"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +78,71 @@ func TestGetLatestResult(t *testing.T) {
 		t.Fatal("Unexpected report result", reportResult)
 	}
 }
+
+func TestFindNote(t *testing.T) {
+	notes := []Note{
+		{Description: "Unused variable", Fix: "diff --git a/a.go b/a.go\n"},
+		{Description: "Missing doc comment"},
+	}
+	id, err := notes[0].ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	note, err := FindNote(notes, id[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Description != "Unused variable" {
+		t.Errorf("Unexpected note: %+v", note)
+	}
+	if _, err := FindNote(notes, ""); err == nil {
+		t.Error("Unexpected success finding a note by an ambiguous ID")
+	}
+	if _, err := FindNote(notes, "not-an-id"); err == nil {
+		t.Error("Unexpected success finding a note that does not exist")
+	}
+}
+
+func TestInlineResults(t *testing.T) {
+	results := []AnalyzeResponse{{Notes: []Note{{Category: "test", Description: "This is a test"}}}}
+	for _, compress := range []bool{false, true} {
+		report := Report{Timestamp: "1", URL: "https://this-url-does-not-exist.test/analysis.json"}
+		if err := report.SetInlineResults(results, compress); err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := json.Marshal(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := Parse(repository.Note(serialized))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.HasInlineResults() {
+			t.Fatalf("Unexpected report without inline results: %s", serialized)
+		}
+		notes, err := parsed.GetNotes()
+		if err != nil {
+			t.Fatal("Unexpected error reading inline results", err)
+		}
+		if !reflect.DeepEqual(notes, results[0].Notes) {
+			t.Errorf("Unexpected inline notes %v with compression %v", notes, compress)
+		}
+	}
+
+	// Compressed results are limited by their decompressed size, as a small
+	// note could otherwise expand to fill the memory.
+	huge := []AnalyzeResponse{{Notes: []Note{{Description: strings.Repeat("x", 1024)}}}}
+	report := Report{}
+	if err := report.SetInlineResults(huge, true); err != nil {
+		t.Fatal(err)
+	}
+	fetcher := &Fetcher{MaxSize: 512}
+	if _, err := fetcher.Results(report); err == nil {
+		t.Error("Unexpected success decompressing results larger than the maximum size")
+	}
+	fetcher.MaxSize = 0
+	if results, err := fetcher.Results(report); err != nil || len(results) != 1 {
+		t.Errorf("Unexpected results without a maximum size: %v, %v", results, err)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyses
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultTimeout is how long a download of a report's details may take.
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is how many times a failed download is retried.
+	DefaultRetries = 2
+	// DefaultMaxSize is the largest size, in bytes, of a report's details.
+	DefaultMaxSize = 10 * 1024 * 1024
+	// DefaultMaxCacheEntries is how many reports' details are cached.
+	DefaultMaxCacheEntries = 1000
+)
+
+// DefaultFetcher downloads the details of reports with the default timeout
+// and size limit, but without retries or caching.
+var DefaultFetcher = &Fetcher{
+	Client:  &http.Client{Timeout: DefaultTimeout},
+	MaxSize: DefaultMaxSize,
+}
+
+// Fetcher downloads the details of analysis reports from their URLs.
+type Fetcher struct {
+	Client *http.Client
+	// Retries is how many times a download that failed because of a
+	// network error or a server error is retried.
+	Retries int
+	// RetryDelay is how long to wait before the first retry, which doubles
+	// before each following one.
+	RetryDelay time.Duration
+	// MaxSize is the largest size, in bytes, of a report's details, or zero
+	// for no limit.
+	MaxSize int64
+	// CacheDir, if set, is the directory in which downloaded details are
+	// cached, so that each report's details are only downloaded once.
+	CacheDir string
+	// MaxCacheEntries is how many reports' details are kept in the cache,
+	// evicting the least recently used ones, or zero for no limit.
+	MaxCacheEntries int
+}
+
+// cacheKey returns the name of the file in which the details of the given
+// report are cached.
+//
+// This hashes the whole report along with its URL, so that a new report that
+// reuses the URL of an earlier one (e.g. ".../latest.json") is downloaded again.
+func cacheKey(report Report) (string, error) {
+	serialized, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x.json", sha1.Sum(append([]byte(report.URL+"\n"), serialized...))), nil
+}
+
+// Results returns the responses embedded in the details of the given report,
+// downloading them if they are neither inline in the report nor cached.
+func (f *Fetcher) Results(report Report) ([]AnalyzeResponse, error) {
+	if report.HasInlineResults() {
+		return report.inlineResults(f.MaxSize)
+	}
+	if report.URL == "" {
+		return nil, nil
+	}
+	var cachePath string
+	if f.CacheDir != "" {
+		key, err := cacheKey(report)
+		if err != nil {
+			return nil, err
+		}
+		cachePath = filepath.Join(f.CacheDir, key)
+		if cached, err := ioutil.ReadFile(cachePath); err == nil {
+			if details, err := parseDetails(cached); err == nil {
+				// Marks the entry as recently used, so that it is evicted last.
+				now := time.Now()
+				os.Chtimes(cachePath, now, now)
+				return details.AnalyzeResponse, nil
+			}
+		}
+	}
+	contents, err := f.download(report.URL)
+	if err != nil {
+		return nil, err
+	}
+	details, err := parseDetails(contents)
+	if err != nil {
+		return nil, err
+	}
+	if cachePath != "" {
+		// The cache is only an optimization, so failing to write it is not an error.
+		if err := os.MkdirAll(f.CacheDir, 0755); err == nil {
+			if err := ioutil.WriteFile(cachePath, contents, 0644); err == nil {
+				f.evict()
+			}
+		}
+	}
+	return details.AnalyzeResponse, nil
+}
+
+// evict removes the least recently used entries from the cache until it has
+// at most MaxCacheEntries of them.
+func (f *Fetcher) evict() {
+	if f.MaxCacheEntries <= 0 {
+		return
+	}
+	entries, err := ioutil.ReadDir(f.CacheDir)
+	if err != nil || len(entries) <= f.MaxCacheEntries {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, entry := range entries[:len(entries)-f.MaxCacheEntries] {
+		os.Remove(filepath.Join(f.CacheDir, entry.Name()))
+	}
+}
+
+// Notes returns the notes embedded in the details of the given report.
+func (f *Fetcher) Notes(report Report) ([]Note, error) {
+	reportResults, err := f.Results(report)
+	if err != nil {
+		return nil, err
+	}
+	var reportNotes []Note
+	for _, reportResult := range reportResults {
+		reportNotes = append(reportNotes, reportResult.Notes...)
+	}
+	return reportNotes, nil
+}
+
+func parseDetails(contents []byte) (*ReportDetails, error) {
+	var details ReportDetails
+	if err := json.Unmarshal(contents, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// download returns the contents of the given URL, retrying network errors
+// and server errors.
+func (f *Fetcher) download(url string) ([]byte, error) {
+	delay := f.RetryDelay
+	for attempt := 0; ; attempt++ {
+		contents, retriable, err := f.downloadOnce(url)
+		if err == nil || !retriable || attempt >= f.Retries {
+			return contents, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// downloadOnce returns the contents of the given URL, and whether a failure
+// is worth retrying.
+func (f *Fetcher) downloadOnce(url string) ([]byte, bool, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, true, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		retriable := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+		return nil, retriable, fmt.Errorf("fetching the analysis results failed with %s", res.Status)
+	}
+	body := io.Reader(res.Body)
+	if f.MaxSize > 0 {
+		body = io.LimitReader(res.Body, f.MaxSize+1)
+	}
+	contents, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, true, err
+	}
+	if f.MaxSize > 0 && int64(len(contents)) > f.MaxSize {
+		return nil, false, fmt.Errorf("the analysis results are larger than %d bytes", f.MaxSize)
+	}
+	return contents, false, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyses
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFetcherTimeout(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprintln(w, mockResults)
+	}))
+	defer mockServer.Close()
+
+	fetcher := &Fetcher{Client: &http.Client{Timeout: 50 * time.Millisecond}}
+	if _, err := fetcher.Notes(Report{URL: mockServer.URL}); err == nil {
+		t.Fatal("Unexpected success fetching from a slow server")
+	}
+}
+
+func TestFetcherRetries(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, mockResults)
+	}))
+	defer mockServer.Close()
+
+	fetcher := &Fetcher{Retries: 1}
+	notes, err := fetcher.Notes(Report{URL: mockServer.URL})
+	if err != nil {
+		t.Fatal("Unexpected error fetching after a retry", err)
+	}
+	if len(notes) != 1 || requests != 2 {
+		t.Fatalf("Unexpected notes %v after %d requests", notes, requests)
+	}
+
+	requests = 0
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := fetcher.Notes(Report{URL: notFound.URL}); err == nil {
+		t.Fatal("Unexpected success fetching a missing report")
+	}
+}
+
+func TestFetcherMaxSize(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(mockHandler(t)))
+	defer mockServer.Close()
+
+	fetcher := &Fetcher{MaxSize: 16}
+	if _, err := fetcher.Notes(Report{URL: mockServer.URL}); err == nil {
+		t.Fatal("Unexpected success fetching a report larger than the limit")
+	}
+	fetcher.MaxSize = int64(len(mockResults) + 1)
+	if _, err := fetcher.Notes(Report{URL: mockServer.URL}); err != nil {
+		t.Fatal("Unexpected error fetching a report within the limit", err)
+	}
+}
+
+func TestFetcherCache(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "analyses-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(mockHandler(t)))
+	report := Report{Timestamp: "1", URL: mockServer.URL}
+	fetcher := &Fetcher{CacheDir: cacheDir}
+	if _, err := fetcher.Notes(report); err != nil {
+		t.Fatal("Unexpected error fetching a report", err)
+	}
+	mockServer.Close()
+
+	notes, err := fetcher.Notes(report)
+	if err != nil {
+		t.Fatal("Unexpected error reading a cached report", err)
+	}
+	if len(notes) != 1 {
+		t.Fatal("Unexpected cached notes", notes)
+	}
+	report.Timestamp = "2"
+	if _, err := fetcher.Notes(report); err == nil {
+		t.Fatal("Unexpected cache hit for a new report with the same URL")
+	}
+}
+
+func TestFetcherCacheEviction(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "analyses-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(mockHandler(t)))
+	defer mockServer.Close()
+	fetcher := &Fetcher{CacheDir: cacheDir, MaxCacheEntries: 2}
+	reports := []Report{
+		{Timestamp: "1", URL: mockServer.URL},
+		{Timestamp: "2", URL: mockServer.URL},
+		{Timestamp: "3", URL: mockServer.URL},
+	}
+	cachePath := func(report Report) string {
+		key, err := cacheKey(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filepath.Join(cacheDir, key)
+	}
+	for i, report := range reports[:2] {
+		if _, err := fetcher.Notes(report); err != nil {
+			t.Fatal("Unexpected error fetching a report", err)
+		}
+		// The first report is the least recently used one...
+		used := time.Now().Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(cachePath(report), used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ... until it is read again from the cache.
+	if _, err := fetcher.Notes(reports[0]); err != nil {
+		t.Fatal("Unexpected error reading a cached report", err)
+	}
+	if _, err := fetcher.Notes(reports[2]); err != nil {
+		t.Fatal("Unexpected error fetching a report", err)
+	}
+
+	for i, evicted := range []bool{false, true, false} {
+		if _, err := os.Stat(cachePath(reports[i])); os.IsNotExist(err) != evicted {
+			t.Errorf("Unexpected cache entry for report %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package benchmark defines the internal representation of benchmark
+// results, which compare the performance of a review to a baseline.
+package benchmark
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+const (
+	// Ref defines the git-notes ref that we expect to contain benchmark reports.
+	Ref = "refs/notes/devtools/benchmarks"
+
+	// DefaultThreshold is the largest regression of a metric, as a fraction
+	// of its baseline, that passes when no threshold has been configured.
+	DefaultThreshold = 0.05
+
+	// FormatVersion defines the latest version of the benchmark format supported by the tool.
+	FormatVersion = 0
+)
+
+// Result is the change in a single metric from its baseline.
+type Result struct {
+	Metric string `json:"metric"`
+	Unit   string `json:"unit,omitempty"`
+	// Baseline is the value of the metric before the change.
+	Baseline float64 `json:"baseline"`
+	// Delta is the change from the baseline, as a fraction of it, so that
+	// 0.1 means the metric is 10% larger.
+	Delta float64 `json:"delta"`
+	// HigherIsBetter is set for metrics such as throughput, for which an
+	// increase is an improvement rather than a regression.
+	HigherIsBetter bool `json:"higherIsBetter,omitempty"`
+	// Threshold optionally tightens the configured threshold for this metric.
+	// It cannot loosen it, as the configured threshold is the repository's gate.
+	Threshold *float64 `json:"threshold,omitempty"`
+}
+
+// Regression returns how much worse the metric got, as a fraction of its
+// baseline, which is negative if it improved.
+func (r Result) Regression() float64 {
+	if r.HigherIsBetter {
+		return -r.Delta
+	}
+	return r.Delta
+}
+
+// Passes returns whether the metric regressed by no more than the given
+// threshold, or its own threshold if that is stricter.
+func (r Result) Passes(threshold float64) bool {
+	if r.Threshold != nil && *r.Threshold < threshold {
+		threshold = *r.Threshold
+	}
+	return r.Regression() <= threshold
+}
+
+// Report is a set of benchmark results for a revision, published by a CI
+// bot or other benchmark runner.
+type Report struct {
+	Timestamp string   `json:"timestamp,omitempty"`
+	Agent     string   `json:"agent,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Results   []Result `json:"results"`
+	// Version represents the version of the metadata format.
+	Version int `json:"v,omitempty"`
+}
+
+// Failures returns the results that regressed by more than the given threshold.
+func (report Report) Failures(threshold float64) []Result {
+	var failures []Result
+	for _, result := range report.Results {
+		if !result.Passes(threshold) {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Write writes a benchmark report as a JSON-formatted git note.
+func (report Report) Write() (repository.Note, error) {
+	bytes, err := json.Marshal(report)
+	return repository.Note(bytes), err
+}
+
+// Parse parses a benchmark report from a git note.
+func Parse(note repository.Note) (Report, error) {
+	bytes := []byte(note)
+	var report Report
+	err := json.Unmarshal(bytes, &report)
+	return report, err
+}
+
+// ParseAllValid takes collection of git notes and tries to parse a benchmark
+// report from each one. Any notes that are not valid benchmark reports get ignored.
+func ParseAllValid(notes []repository.Note) []Report {
+	var reports []Report
+	for _, note := range notes {
+		report, err := Parse(note)
+		if err == nil && report.Version == FormatVersion && len(report.Results) > 0 {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// GetLatestReports returns the most recent report from each agent, ordered
+// by agent.
+func GetLatestReports(reports []Report) ([]Report, error) {
+	latest := make(map[string]Report)
+	times := make(map[string]time.Time)
+	var agents []string
+	for _, report := range reports {
+		t, err := timestamp.ParseReport(report.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		prior, ok := times[report.Agent]
+		if !ok {
+			agents = append(agents, report.Agent)
+		}
+		if !ok || !t.Before(prior) {
+			latest[report.Agent], times[report.Agent] = report, t
+		}
+	}
+	sort.Strings(agents)
+	var result []Report
+	for _, agent := range agents {
+		result = append(result, latest[agent])
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestPasses(t *testing.T) {
+	strict := 0.0
+	loose := 1.0
+	for _, test := range []struct {
+		result Result
+		passes bool
+	}{
+		{Result{Metric: "time/op", Delta: 0.04}, true},
+		{Result{Metric: "time/op", Delta: 0.06}, false},
+		{Result{Metric: "time/op", Delta: -0.5}, true},
+		{Result{Metric: "ops/s", Delta: 0.5, HigherIsBetter: true}, true},
+		{Result{Metric: "ops/s", Delta: -0.06, HigherIsBetter: true}, false},
+		{Result{Metric: "allocs/op", Delta: 0.01, Threshold: &strict}, false},
+		{Result{Metric: "allocs/op", Delta: 0.5, Threshold: &loose}, false},
+	} {
+		if passes := test.result.Passes(DefaultThreshold); passes != test.passes {
+			t.Errorf("Unexpected result for %+v: %v", test.result, passes)
+		}
+	}
+}
+
+func TestGetLatestReports(t *testing.T) {
+	reports := ParseAllValid([]repository.Note{
+		repository.Note(`{"timestamp": "1970-01-01T00:00:20Z", "agent": "perf", "results": [{"metric": "time/op", "baseline": 10, "delta": 0.2}]}`),
+		repository.Note(`{"timestamp": "0000000010", "agent": "perf", "results": [{"metric": "time/op", "baseline": 10, "delta": 0}]}`),
+		repository.Note(`{"timestamp": "0000000005", "agent": "bench", "results": [{"metric": "ops/s", "baseline": 100, "delta": 0.1, "higherIsBetter": true}]}`),
+		repository.Note(`{"timestamp": "0000000030", "agent": "perf", "results": []}`),
+		repository.Note(`not JSON`),
+	})
+	latest, err := GetLatestReports(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 || latest[0].Agent != "bench" || latest[1].Agent != "perf" || latest[1].Timestamp != "1970-01-01T00:00:20Z" {
+		t.Fatalf("Unexpected latest reports: %+v", latest)
+	}
+	if failures := latest[1].Failures(DefaultThreshold); len(failures) != 1 || failures[0].Metric != "time/op" {
+		t.Errorf("Unexpected failures: %+v", failures)
+	}
+}
@@ -19,9 +19,10 @@ package ci
 
 import (
 	"encoding/json"
+	"time"
+
 	"github.com/google/git-appraise/repository"
-	"sort"
-	"strconv"
+	"github.com/google/git-appraise/review/timestamp"
 )
 
 const (
@@ -45,10 +46,58 @@ type Report struct {
 	URL       string `json:"url,omitempty"`
 	Status    string `json:"status,omitempty"`
 	Agent     string `json:"agent,omitempty"`
+	// Coverage optionally records which lines of each file were run by the tests.
+	Coverage []FileCoverage `json:"coverage,omitempty"`
 	// Version represents the version of the metadata format.
 	Version int `json:"v,omitempty"`
 }
 
+// FileCoverage records which lines of a file were run by the tests.
+//
+// Lines that are in neither list (e.g. comments) are not instrumented.
+type FileCoverage struct {
+	Path      string   `json:"path"`
+	Covered   []uint32 `json:"covered,omitempty"`
+	Uncovered []uint32 `json:"uncovered,omitempty"`
+}
+
+// UncoveredLines returns the lines of each file that the tests did not run.
+func (report Report) UncoveredLines() map[string]map[uint32]bool {
+	uncovered := make(map[string]map[uint32]bool)
+	for _, file := range report.Coverage {
+		if uncovered[file.Path] == nil {
+			uncovered[file.Path] = make(map[uint32]bool)
+		}
+		for _, line := range file.Uncovered {
+			uncovered[file.Path][line] = true
+		}
+	}
+	return uncovered
+}
+
+// GetLatestCoverageReport takes the collection of reports and returns the
+// most recent one that includes coverage data, or nil if none do.
+//
+// Coverage is often reported separately from the build status, so the
+// latest report overall may not include it.
+func GetLatestCoverageReport(reports []Report) (*Report, error) {
+	var latest *Report
+	var latestTime time.Time
+	for i, report := range reports {
+		if len(report.Coverage) == 0 {
+			continue
+		}
+		t, err := timestamp.ParseReport(report.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || !t.Before(latestTime) {
+			latest, latestTime = &reports[i], t
+		}
+	}
+	return latest, nil
+}
+
 // Parse parses a CI report from a git note.
 func Parse(note repository.Note) (Report, error) {
 	bytes := []byte(note)
@@ -59,22 +108,18 @@ func Parse(note repository.Note) (Report, error) {
 
 // GetLatestCIReport takes the collection of reports and returns the one with the most recent timestamp.
 func GetLatestCIReport(reports []Report) (*Report, error) {
-	timestampReportMap := make(map[int]*Report)
-	var timestamps []int
-
-	for _, report := range reports {
-		timestamp, err := strconv.Atoi(report.Timestamp)
+	var latest *Report
+	var latestTime time.Time
+	for i, report := range reports {
+		t, err := timestamp.ParseReport(report.Timestamp)
 		if err != nil {
 			return nil, err
 		}
-		timestamps = append(timestamps, timestamp)
-		timestampReportMap[timestamp] = &report
-	}
-	if len(timestamps) == 0 {
-		return nil, nil
+		if latest == nil || !t.Before(latestTime) {
+			latest, latestTime = &reports[i], t
+		}
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(timestamps)))
-	return timestampReportMap[timestamps[0]], nil
+	return latest, nil
 }
 
 // ParseAllValid takes collection of git notes and tries to parse a CI report
@@ -18,6 +18,7 @@ package ci
 
 import (
 	"github.com/google/git-appraise/repository"
+	"reflect"
 	"testing"
 )
 
@@ -63,7 +64,7 @@ func TestCIReport(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to parse the expected report", err)
 	}
-	if *latestReport != expected {
+	if !reflect.DeepEqual(*latestReport, expected) {
 		t.Fatal("This is not the latest ", latestReport)
 	}
 	latestReport, err = GetLatestCIReport(ParseAllValid([]repository.Note{
@@ -79,7 +80,40 @@ func TestCIReport(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to parse the expected report", err)
 	}
-	if *latestReport != expected {
+	if !reflect.DeepEqual(*latestReport, expected) {
 		t.Fatal("This is not the latest ", latestReport)
 	}
+	latestReport, err = GetLatestCIReport(ParseAllValid([]repository.Note{
+		repository.Note(testCINote4),
+		repository.Note(`{"Timestamp": "1970-01-01T00:00:29Z", "URL": "www.google.com", "Status": "failure"}`),
+		repository.Note(testCINote5),
+	}))
+	if err != nil {
+		t.Fatal("Failed to properly fetch the latest report", err)
+	}
+	if latestReport.Timestamp != "1970-01-01T00:00:29Z" {
+		t.Fatal("This is not the latest ", latestReport)
+	}
+}
+
+func TestLatestCoverageReport(t *testing.T) {
+	reports := ParseAllValid([]repository.Note{
+		repository.Note(`{"timestamp": "10", "agent": "cover", "coverage": [{"path": "a.go", "covered": [1], "uncovered": [2, 3]}]}`),
+		repository.Note(`{"timestamp": "20", "agent": "build", "status": "success"}`),
+		repository.Note(`{"timestamp": "5", "agent": "cover", "coverage": [{"path": "a.go", "uncovered": [1]}]}`),
+	})
+	latest, err := GetLatestCoverageReport(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == nil || latest.Timestamp != "10" {
+		t.Fatalf("Unexpected latest coverage report: %+v", latest)
+	}
+	uncovered := latest.UncoveredLines()
+	if !uncovered["a.go"][2] || !uncovered["a.go"][3] || uncovered["a.go"][1] {
+		t.Errorf("Unexpected uncovered lines: %v", uncovered)
+	}
+	if latest, err := GetLatestCoverageReport(reports[1:2]); err != nil || latest != nil {
+		t.Errorf("Unexpected coverage report without coverage data: %+v, %v", latest, err)
+	}
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package claim defines the internal representation of a reviewer's claim
+// that they are actively reviewing a change.
+package claim
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+const (
+	// Ref defines the git-notes ref that we expect to contain claims.
+	Ref = "refs/notes/devtools/claims"
+
+	// FormatVersion defines the latest version of the claim format supported by the tool.
+	FormatVersion = 0
+)
+
+// Claim records that a reviewer is actively reviewing a change, so that
+// others do not redundantly pick it up.
+//
+// Claims are attached to the first revision in the review. Each reviewer's
+// latest claim replaces their earlier ones, and a claim only lasts until it
+// expires or is released.
+type Claim struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Reviewer  string `json:"reviewer"`
+	// Expires is when the claim lapses, if it has not been released.
+	Expires string `json:"expires,omitempty"`
+	// Released is set when the reviewer is no longer reviewing the change.
+	Released bool `json:"released,omitempty"`
+	// Version represents the version of the metadata format.
+	Version int `json:"v,omitempty"`
+}
+
+// New returns a claim by the given reviewer, made at the given time, which
+// expires after the given duration.
+func New(reviewer string, now time.Time, duration time.Duration) Claim {
+	return Claim{
+		Timestamp: timestamp.Format(now),
+		Reviewer:  reviewer,
+		Expires:   timestamp.Format(now.Add(duration)),
+	}
+}
+
+// Parse parses a claim from a git note.
+func Parse(note repository.Note) (Claim, error) {
+	bytes := []byte(note)
+	var c Claim
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return c, err
+	}
+	for _, field := range []*string{&c.Timestamp, &c.Expires} {
+		normalized, err := timestamp.Normalize(*field)
+		if err != nil {
+			return c, err
+		}
+		*field = normalized
+	}
+	return c, nil
+}
+
+// Time returns the time at which the claim was made.
+func (c *Claim) Time() time.Time {
+	return timestamp.Time(c.Timestamp)
+}
+
+// ExpiryTime returns the time at which the claim lapses.
+func (c *Claim) ExpiryTime() time.Time {
+	return timestamp.Time(c.Expires)
+}
+
+// IsActive returns whether the claim still holds at the given time.
+func (c *Claim) IsActive(now time.Time) bool {
+	return !c.Released && now.Before(c.ExpiryTime())
+}
+
+// ParseAllValid takes collection of git notes and tries to parse a claim
+// from each one. Any notes that are not valid claims get ignored.
+func ParseAllValid(notes []repository.Note) []Claim {
+	var claims []Claim
+	for _, note := range notes {
+		c, err := Parse(note)
+		if err == nil && c.Version == FormatVersion && c.Reviewer != "" {
+			claims = append(claims, c)
+		}
+	}
+	return claims
+}
+
+// Active returns the latest claim of each reviewer, if it still holds at the
+// given time, ordered by when the claims were made, and then by reviewer.
+func Active(claims []Claim, now time.Time) []Claim {
+	latest := make(map[string]Claim)
+	for _, c := range claims {
+		prior, ok := latest[c.Reviewer]
+		// Merged notes are sorted, so releasing a claim in the same second
+		// that it was made has to win regardless of the order of the notes.
+		if !ok || c.Time().After(prior.Time()) || (c.Time().Equal(prior.Time()) && !prior.Released) {
+			latest[c.Reviewer] = c
+		}
+	}
+	var active []Claim
+	for _, c := range latest {
+		if c.IsActive(now) {
+			active = append(active, c)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].Time().Equal(active[j].Time()) {
+			return active[i].Time().Before(active[j].Time())
+		}
+		return active[i].Reviewer < active[j].Reviewer
+	})
+	return active
+}
+
+// Write writes a claim as a JSON-formatted git note.
+func (c *Claim) Write() (repository.Note, error) {
+	bytes, err := json.Marshal(c)
+	return repository.Note(bytes), err
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package claim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestParseAllValid(t *testing.T) {
+	claims := ParseAllValid([]repository.Note{
+		repository.Note(`{"timestamp":"1234","reviewer":"alice","expires":"5678"}`),
+		repository.Note(`{"timestamp":"1234","expires":"5678"}`),
+		repository.Note(`{"timestamp":"1234","reviewer":"bob","expires":"56x8"}`),
+		repository.Note(`{"timestamp":"1234","reviewer":"carol","expires":"5678","v":1}`),
+		repository.Note(`not JSON`),
+	})
+	if len(claims) != 1 {
+		t.Fatalf("Unexpected claims: %v", claims)
+	}
+	if c := claims[0]; c.Reviewer != "alice" || c.Timestamp != "0000001234" || c.Expires != "0000005678" {
+		t.Errorf("Unexpected claim: %+v", c)
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Unix(10000, 0)
+	released := New("bob", now.Add(-time.Minute), 0)
+	released.Released = true
+	claims := []Claim{
+		New("alice", now.Add(-time.Hour), 2*time.Hour),
+		New("bob", now.Add(-time.Hour), 2*time.Hour),
+		released,
+		New("carol", now.Add(-3*time.Hour), 2*time.Hour),
+		New("dave", now.Add(-2*time.Hour), 4*time.Hour),
+	}
+	active := Active(claims, now)
+	if len(active) != 2 || active[0].Reviewer != "dave" || active[1].Reviewer != "alice" {
+		t.Errorf("Unexpected active claims: %+v", active)
+	}
+}
+
+func TestWriteAndParse(t *testing.T) {
+	c := New("user@example.com", time.Unix(1, 0), time.Hour)
+	note, err := c.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != c {
+		t.Errorf("Round trip mismatch: %+v vs %+v", parsed, c)
+	}
+}
@@ -18,15 +18,18 @@ limitations under the License.
 package comment
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/timestamp"
 )
 
 // Ref defines the git-notes ref that we expect to contain review comments.
@@ -35,11 +38,84 @@ const Ref = "refs/notes/devtools/discuss"
 // FormatVersion defines the latest version of the comment format supported by the tool.
 const FormatVersion = 0
 
+// The kinds of comment, which say what the reviewer intends by it.
+const (
+	// KindNit is a minor suggestion, which never needs to be resolved.
+	KindNit = "nit"
+	// KindBlocking is a problem that must be resolved before the change is accepted.
+	KindBlocking = "blocking"
+	// KindQuestion asks the author something.
+	KindQuestion = "question"
+	// KindPraise points out something done well, and never needs to be resolved.
+	KindPraise = "praise"
+)
+
+// Kinds lists every kind of comment.
+var Kinds = []string{KindNit, KindBlocking, KindQuestion, KindPraise}
+
+// CheckKind returns an error if the given kind is neither empty nor one of Kinds.
+func CheckKind(kind string) error {
+	if kind == "" {
+		return nil
+	}
+	for _, k := range Kinds {
+		if k == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown comment kind %q; must be one of %s", kind, strings.Join(Kinds, ", "))
+}
+
+// The reasons for rejecting a review, which let rejections be tallied.
+const (
+	// ReasonDesign means that the approach of the change should be reconsidered.
+	ReasonDesign = "design"
+	// ReasonCorrectness means that the change has bugs.
+	ReasonCorrectness = "correctness"
+	// ReasonTests means that the change is missing tests, or its tests fail.
+	ReasonTests = "tests"
+	// ReasonStyle means that the change does not follow the project's style.
+	ReasonStyle = "style"
+	// ReasonScope means that the change is too large, or should be split up.
+	ReasonScope = "scope"
+	// ReasonDocs means that the change is missing documentation.
+	ReasonDocs = "docs"
+	// ReasonSecurity means that the change introduces a security problem.
+	ReasonSecurity = "security"
+	// ReasonOther is any other reason, which the comment should explain.
+	ReasonOther = "other"
+)
+
+// Reasons lists every reason for rejecting a review.
+var Reasons = []string{ReasonDesign, ReasonCorrectness, ReasonTests, ReasonStyle, ReasonScope, ReasonDocs, ReasonSecurity, ReasonOther}
+
+// CheckReason returns an error if the given reason is neither empty nor one of Reasons.
+func CheckReason(reason string) error {
+	if reason == "" {
+		return nil
+	}
+	for _, r := range Reasons {
+		if r == reason {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown rejection reason %q; must be one of %s", reason, strings.Join(Reasons, ", "))
+}
+
 // ErrInvalidRange inidcates an error during parsing of a user-defined file
 // range
 var ErrInvalidRange = errors.New("invalid file location range. The required form is StartLine[+StartColumn][:EndLine[+EndColumn]]. The first line in a file is considered to be line 1")
 
+// PositionEncoding describes how the lines and columns of a Range are counted,
+// for clients that read ranges from the JSON output: both count from 1, and
+// columns count bytes.
+const PositionEncoding = "1-based-bytes"
+
 // Range represents the range of text that is under discussion.
+//
+// Lines and columns count from 1. A zero column means the whole line, and a
+// zero end line means that the range ends on its start line. A zero start
+// line is only valid on its own, as the whole file.
 type Range struct {
 	StartLine   uint32 `json:"startLine"`
 	StartColumn uint32 `json:"startColumn,omitempty"`
@@ -47,6 +123,47 @@ type Range struct {
 	EndColumn   uint32 `json:"endColumn,omitempty"`
 }
 
+// Validate returns an error if the range does not follow the 1-based
+// numbering of lines and columns.
+func (r *Range) Validate() error {
+	if r.StartLine == 0 {
+		if *r != (Range{}) {
+			return errors.New("line numbers in a range start at 1")
+		}
+		return nil
+	}
+	if r.EndLine == 0 {
+		if r.EndColumn != 0 {
+			return errors.New("an end column in a range requires an end line")
+		}
+		return nil
+	}
+	if r.StartLine > r.EndLine {
+		return errors.New("start line cannot be greater than end line in range")
+	}
+	if r.StartLine == r.EndLine && r.StartColumn != 0 && r.EndColumn != 0 && r.StartColumn > r.EndColumn {
+		return errors.New("start column cannot be greater than end column in a range within a single line")
+	}
+	return nil
+}
+
+// Normalized returns the legacy range, whose lines count from 0, with 1-based
+// lines.
+//
+// The lines are shifted by one, so that they are displayed where they were
+// meant. The columns are left alone, as a zero column already means the whole
+// line, as is a range of all zeros, which has always meant the whole file.
+func (r Range) Normalized() Range {
+	if r == (Range{}) {
+		return r
+	}
+	r.StartLine++
+	if r.EndLine != 0 {
+		r.EndLine++
+	}
+	return r
+}
+
 // Location represents the location of a comment within a commit.
 type Location struct {
 	Commit string `json:"commit,omitempty"`
@@ -58,35 +175,62 @@ type Location struct {
 
 // Check verifies that this location is valid in the provided
 // repository.
+//
+// The path and range are checked against the file as it is at the location's
+// commit, which is not necessarily what is currently checked out.
 func (location *Location) Check(repo repository.Repo) error {
+	if location.Commit == "" {
+		return errors.New("The location does not say which commit it refers to")
+	}
+	if err := repo.VerifyCommit(location.Commit); err != nil {
+		return fmt.Errorf("The commit %.12s of the location is not available: %v", location.Commit, err)
+	}
+	if location.Path == "" {
+		if location.Range != nil {
+			return errors.New("The location has a range, but no file")
+		}
+		return nil
+	}
 	contents, err := repo.Show(location.Commit, location.Path)
 	if err != nil {
+		return fmt.Errorf("File %q does not exist at commit %.12s", location.Path, location.Commit)
+	}
+	if location.Range == nil {
+		return nil
+	}
+	if err := location.Range.Validate(); err != nil {
 		return err
 	}
 	lines := strings.Split(contents, "\n")
 	if location.Range.StartLine > uint32(len(lines)) {
-		return fmt.Errorf("Line number %d does not exist in file %q",
+		return fmt.Errorf("Line number %d does not exist in file %q at commit %.12s, which has %d lines",
 			location.Range.StartLine,
-			location.Path)
+			location.Path,
+			location.Commit,
+			len(lines))
 	}
 	if location.Range.StartColumn != 0 &&
 		location.Range.StartColumn > uint32(len(lines[location.Range.StartLine-1])) {
-		return fmt.Errorf("Line %d in %q is too short for column %d",
+		return fmt.Errorf("Line %d in %q at commit %.12s is too short for column %d",
 			location.Range.StartLine,
 			location.Path,
+			location.Commit,
 			location.Range.StartColumn)
 	}
 	if location.Range.EndLine != 0 &&
 		location.Range.EndLine > uint32(len(lines)) {
-		return fmt.Errorf("End line number %d does not exist in file %q",
+		return fmt.Errorf("End line number %d does not exist in file %q at commit %.12s, which has %d lines",
 			location.Range.EndLine,
-			location.Path)
+			location.Path,
+			location.Commit,
+			len(lines))
 	}
 	if location.Range.EndColumn != 0 &&
 		location.Range.EndColumn > uint32(len(lines[location.Range.EndLine-1])) {
-		return fmt.Errorf("End line %d in %q is too short for column %d",
+		return fmt.Errorf("End line %d in %q at commit %.12s is too short for column %d",
 			location.Range.EndLine,
 			location.Path,
+			location.Commit,
 			location.Range.EndColumn)
 	}
 	return nil
@@ -116,23 +260,84 @@ type Comment struct {
 	// has been addressed. Otherwise, the parent is the commit, and this means that the
 	// change has been accepted. If the resolved bit is unset, then the comment is only an FYI.
 	Resolved *bool `json:"resolved,omitempty"`
+	// Kind optionally classifies the comment as one of Kinds.
+	Kind string `json:"kind,omitempty"`
+	// Reason optionally says why a review was rejected, as one of Reasons.
+	Reason string `json:"reason,omitempty"`
+	// Delegation is set on acceptances made on behalf of a reviewer team,
+	// rather than by the author personally.
+	Delegation *Delegation `json:"delegation,omitempty"`
+	// PositionEncoding says how the lines and columns of the location's range
+	// are counted. Comments written without it are from older clients, which
+	// counted lines from 0.
+	PositionEncoding string `json:"positionEncoding,omitempty"`
 	// Version represents the version of the metadata format.
 	Version int `json:"v,omitempty"`
 
 	gpg.Sig
 }
 
+// Delegation records that a comment accepted a review on behalf of a reviewer
+// team, such as by one of the team's leads.
+type Delegation struct {
+	// Actor is the user who accepted the review on the team's behalf.
+	Actor string `json:"actor"`
+	// Team is the name of the team whose acceptance this satisfies.
+	Team string `json:"team"`
+}
+
+// Status returns the resolved bit of the comment, as adjusted for its kind.
+//
+// Blocking comments always need work until a reply addresses them, while
+// nits and praise never do, so they are only FYIs unless they are approvals.
+func (comment Comment) Status() *bool {
+	switch comment.Kind {
+	case KindBlocking:
+		if comment.Resolved == nil {
+			resolved := false
+			return &resolved
+		}
+	case KindNit, KindPraise:
+		if comment.Resolved != nil && !*comment.Resolved {
+			return nil
+		}
+	}
+	return comment.Resolved
+}
+
+// WithNormalizedRange returns the comment with its range, if any, normalized
+// to 1-based lines, if it was written without a PositionEncoding.
+//
+// Signed comments are returned as they were written, so that their
+// signatures still verify.
+func (comment Comment) WithNormalizedRange() Comment {
+	if comment.PositionEncoding != "" || comment.Location == nil || comment.Location.Range == nil || comment.Sig.Sig != "" {
+		return comment
+	}
+	normalized := comment.Location.Range.Normalized()
+	location := *comment.Location
+	location.Range = &normalized
+	comment.Location = &location
+	comment.PositionEncoding = PositionEncoding
+	return comment
+}
+
 // New returns a new comment with the given description message.
 //
 // The Timestamp and Author fields are automatically filled in with the current time and user.
+// The PositionEncoding is set, as every range written by this tool counts from 1.
 func New(author string, description string) Comment {
 	return Comment{
-		Author:      author,
-		Description: description,
+		Author:           author,
+		Description:      description,
+		PositionEncoding: PositionEncoding,
 	}
 }
 
 // Parse parses a review comment from a git note.
+//
+// The comment's timestamp is left as it was written, so that its hash and
+// signature are unchanged, and is only interpreted by Time.
 func Parse(note repository.Note) (Comment, error) {
 	bytes := []byte(note)
 	var comment Comment
@@ -140,6 +345,19 @@ func Parse(note repository.Note) (Comment, error) {
 	return comment, err
 }
 
+// CheckTimestamp returns an error if the comment's timestamp is not a number
+// of seconds since the Unix epoch.
+func (comment Comment) CheckTimestamp() error {
+	_, err := timestamp.Normalize(comment.Timestamp)
+	return err
+}
+
+// Time returns the time at which the comment was written, or the zero time
+// if the comment does not have a timestamp.
+func (comment Comment) Time() time.Time {
+	return timestamp.Time(comment.Timestamp)
+}
+
 // ParseAllValid takes collection of git notes and tries to parse a review
 // comment from each one. Any notes that are not valid review comments get
 // ignored, as we expect the git notes to be a heterogenous list, with only
@@ -159,7 +377,7 @@ func ParseAllValid(notes []repository.Note) map[string]Comment {
 }
 
 func (comment Comment) serialize() ([]byte, error) {
-	if len(comment.Timestamp) < 10 {
+	if len(comment.Timestamp) < 10 && comment.Sig.Sig == "" {
 		// To make sure that timestamps from before 2001 appear in the correct
 		// alphabetical order, we reformat the timestamp to be at least 10 characters
 		// and zero-padded.
@@ -168,7 +386,8 @@ func (comment Comment) serialize() ([]byte, error) {
 			comment.Timestamp = fmt.Sprintf("%010d", time)
 		}
 		// We ignore the other case, as the comment timestamp is not in a format
-		// we expected, so we should just leave it alone.
+		// we expected, so we should just leave it alone. Signed comments are
+		// also left alone, so that their signatures still verify.
 	}
 	return json.Marshal(comment)
 }
@@ -179,10 +398,50 @@ func (comment Comment) Write() (repository.Note, error) {
 	return repository.Note(bytes), err
 }
 
-// Hash returns the SHA1 hash of a review comment.
+// canonicalize returns the canonical serialization of a review comment,
+// which is what its hash is computed from.
+//
+// This is the JSON object of the comment's known fields, with the empty
+// fields omitted, the keys of every object sorted, no insignificant
+// whitespace, and no escaping of HTML characters. Unlike the serialization
+// used to write the comment, this does not depend on the order in which the
+// fields are declared, so other implementations can reproduce it.
+func (comment Comment) canonicalize() ([]byte, error) {
+	serialized, err := comment.serialize()
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	// Maps are encoded with their keys in sorted order.
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(fields); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(canonical.Bytes(), []byte("\n")), nil
+}
+
+// Hash returns the SHA1 hash of the canonical serialization of a review comment.
 func (comment Comment) Hash() (string, error) {
-	bytes, err := comment.serialize()
-	return fmt.Sprintf("%x", sha1.Sum(bytes)), err
+	canonical, err := comment.canonicalize()
+	return fmt.Sprintf("%x", sha1.Sum(canonical)), err
+}
+
+// LegacyHash returns the SHA1 hash that was used for a review comment before
+// hashes were computed from its canonical serialization.
+//
+// That hash depends on the order of the fields in the Comment struct, and is
+// only used to resolve references (e.g. the parents of replies) in comments
+// that were written before the switch to canonical hashes.
+func (comment Comment) LegacyHash() (string, error) {
+	serialized, err := comment.serialize()
+	return fmt.Sprintf("%x", sha1.Sum(serialized)), err
 }
 
 // Set implenents flag.Value for the Range type
@@ -203,19 +462,17 @@ func (r *Range) Set(s string) error {
 		return err
 	}
 	if len(startEndParts) == 1 {
-		return nil
+		return r.Validate()
 	}
 
 	r.EndLine, r.EndColumn, err = parseRangePart(startEndParts[1])
 	if err != nil {
 		return err
 	}
-
-	if r.StartLine > r.EndLine {
-		return errors.New("start line cannot be greater than end line in range")
+	if r.EndLine == 0 {
+		return ErrInvalidRange
 	}
-
-	return nil
+	return r.Validate()
 }
 
 func parseRangePart(s string) (uint32, uint32, error) {
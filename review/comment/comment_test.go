@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comment
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	notes := []string{
+		`{"timestamp":"0000000001","author":"a@example.com","location":{"commit":"abc","path":"a.go","range":{"startLine":3}},"description":"<b> & more","resolved":true}`,
+		`{"resolved": true, "description": "<b> & more", "location": {"range": {"startLine": 3}, "path": "a.go", "commit": "abc"}, "author": "a@example.com", "timestamp": "1", "unknown": "ignored"}`,
+	}
+	want := `{"author":"a@example.com","description":"<b> & more","location":{"commit":"abc","path":"a.go","range":{"startLine":3}},"resolved":true,"timestamp":"0000000001"}`
+	for _, note := range notes {
+		c, err := Parse(repository.Note(note))
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonical, err := c.canonicalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(canonical) != want {
+			t.Errorf("Unexpected canonical serialization of %q: %s", note, canonical)
+		}
+		hash, err := c.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantHash := fmt.Sprintf("%x", sha1.Sum([]byte(want))); hash != wantHash {
+			t.Errorf("Unexpected hash of %q: %s, want %s", note, hash, wantHash)
+		}
+	}
+}
+
+func TestCheckKind(t *testing.T) {
+	for _, kind := range append([]string{""}, Kinds...) {
+		if err := CheckKind(kind); err != nil {
+			t.Errorf("Unexpected error for the kind %q: %v", kind, err)
+		}
+	}
+	if err := CheckKind("typo"); err == nil {
+		t.Error("Failed to reject an unknown kind")
+	}
+}
+
+func TestCheckReason(t *testing.T) {
+	for _, reason := range append([]string{""}, Reasons...) {
+		if err := CheckReason(reason); err != nil {
+			t.Errorf("Unexpected error for the reason %q: %v", reason, err)
+		}
+	}
+	if err := CheckReason("because"); err == nil {
+		t.Error("Failed to reject an unknown reason")
+	}
+}
+
+func TestRangeSet(t *testing.T) {
+	for value, expected := range map[string]Range{
+		"":        {},
+		"0":       {},
+		"3":       {StartLine: 3},
+		"3+0":     {StartLine: 3},
+		"2+5:7+4": {StartLine: 2, StartColumn: 5, EndLine: 7, EndColumn: 4},
+		"4+2:4+9": {StartLine: 4, StartColumn: 2, EndLine: 4, EndColumn: 9},
+	} {
+		var r Range
+		if err := r.Set(value); err != nil {
+			t.Errorf("Unexpected error parsing the range %q: %v", value, err)
+		} else if r != expected {
+			t.Errorf("Unexpected range %+v parsed from %q", r, value)
+		}
+	}
+	for _, invalid := range []string{"0+1", "0+5", "0:5", "3:0", "5:3", "4+9:4+2", "1:2:3", "a"} {
+		var r Range
+		if err := r.Set(invalid); err == nil {
+			t.Errorf("Failed to reject the invalid range %q", invalid)
+		}
+	}
+}
+
+func TestWithNormalizedRange(t *testing.T) {
+	legacy := Comment{Location: &Location{Path: "a.go", Range: &Range{StartLine: 0, StartColumn: 4, EndLine: 2}}}
+	normalized := legacy.WithNormalizedRange()
+	if *normalized.Location.Range != (Range{StartLine: 1, StartColumn: 4, EndLine: 3}) || normalized.PositionEncoding != PositionEncoding {
+		t.Errorf("Unexpected normalized range: %+v", *normalized.Location.Range)
+	}
+	if *legacy.Location.Range != (Range{StartLine: 0, StartColumn: 4, EndLine: 2}) {
+		t.Errorf("Normalizing a comment modified its original range: %+v", *legacy.Location.Range)
+	}
+	legacyLater := Comment{Location: &Location{Path: "a.go", Range: &Range{StartLine: 3, EndLine: 5}}}
+	if r := legacyLater.WithNormalizedRange().Location.Range; *r != (Range{StartLine: 4, EndLine: 6}) {
+		t.Errorf("Unexpected normalized range: %+v", *r)
+	}
+	wholeFile := Comment{Location: &Location{Path: "a.go", Range: &Range{}}}
+	if r := wholeFile.WithNormalizedRange().Location.Range; *r != (Range{}) {
+		t.Errorf("Unexpected change to a range of the whole file: %+v", *r)
+	}
+	current := New("user@example.com", "current")
+	current.Location = &Location{Path: "a.go", Range: &Range{StartLine: 3, EndLine: 5}}
+	if r := current.WithNormalizedRange().Location.Range; *r != *current.Location.Range {
+		t.Errorf("Unexpected change to a 1-based range: %+v", *r)
+	}
+	legacy.Sig.Sig = "signature"
+	if r := legacy.WithNormalizedRange().Location.Range; r.StartLine != 0 {
+		t.Errorf("Unexpected change to the range of a signed comment: %+v", *r)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// Comparison is a comparison of two reviews, such as a change and an earlier
+// attempt at it that was abandoned.
+type Comparison struct {
+	Left      *Review `json:"-"`
+	Right     *Review `json:"-"`
+	LeftHead  string  `json:"leftHead"`
+	RightHead string  `json:"rightHead"`
+	// OnlyLeft and OnlyRight are the top-level comment threads of each review
+	// that have no counterpart in the other one.
+	OnlyLeft  []CommentThread `json:"onlyLeft,omitempty"`
+	OnlyRight []CommentThread `json:"onlyRight,omitempty"`
+	// StatusChanged are the pairs of threads, from the left and then the right
+	// review, that are on the same subject, but whose statuses differ.
+	StatusChanged [][2]CommentThread `json:"statusChanged,omitempty"`
+	// Diff is the diff between the two heads, which is only filled in when
+	// the comparison is formatted as JSON.
+	Diff []DiffFile `json:"diff,omitempty"`
+}
+
+// threadKey returns what identifies the subject of a comment thread across
+// reviews: the file it is about, if any, and its description.
+//
+// Line numbers are not part of this, as they usually move between attempts.
+func threadKey(thread CommentThread) string {
+	path := ""
+	if thread.Comment.Location != nil {
+		path = thread.Comment.Location.Path
+	}
+	return path + "\x00" + strings.TrimSpace(thread.Comment.Description)
+}
+
+func sameStatus(left, right *bool) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	return *left == *right
+}
+
+// Compare compares the given reviews: the heads whose trees can be diffed,
+// and the comment threads of each that differ from those of the other.
+//
+// Acceptances and rejections of the reviews as a whole are not compared, as
+// they are about the review rather than a subject within it.
+func Compare(left, right *Review) (*Comparison, error) {
+	leftHead, err := left.GetHeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	rightHead, err := right.GetHeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	comparison := &Comparison{
+		Left:      left,
+		Right:     right,
+		LeftHead:  leftHead,
+		RightHead: rightHead,
+	}
+	// Threads are matched by hash first, as a thread that was copied from
+	// one review to the other is the same thread, and then by subject. Each
+	// thread is matched at most once, so several threads on the same subject
+	// are paired up in order rather than all matching the same one.
+	var rightThreads []CommentThread
+	rightByHash := make(map[string]int)
+	rightBySubject := make(map[string][]int)
+	for _, thread := range right.Comments {
+		if isVerdict(thread) {
+			continue
+		}
+		rightByHash[thread.Hash] = len(rightThreads)
+		rightBySubject[threadKey(thread)] = append(rightBySubject[threadKey(thread)], len(rightThreads))
+		rightThreads = append(rightThreads, thread)
+	}
+	matched := make(map[int]bool)
+	var unmatchedLeft []CommentThread
+	var pairs [][2]CommentThread
+	for _, thread := range left.Comments {
+		if isVerdict(thread) {
+			continue
+		}
+		if i, ok := rightByHash[thread.Hash]; ok && !matched[i] {
+			matched[i] = true
+			pairs = append(pairs, [2]CommentThread{thread, rightThreads[i]})
+			continue
+		}
+		unmatchedLeft = append(unmatchedLeft, thread)
+	}
+	for _, thread := range unmatchedLeft {
+		counterpart := -1
+		for _, i := range rightBySubject[threadKey(thread)] {
+			if !matched[i] {
+				counterpart = i
+				break
+			}
+		}
+		if counterpart < 0 {
+			comparison.OnlyLeft = append(comparison.OnlyLeft, thread)
+			continue
+		}
+		matched[counterpart] = true
+		pairs = append(pairs, [2]CommentThread{thread, rightThreads[counterpart]})
+	}
+	for _, pair := range pairs {
+		if !sameStatus(pair[0].Resolved, pair[1].Resolved) {
+			comparison.StatusChanged = append(comparison.StatusChanged, pair)
+		}
+	}
+	for i, thread := range rightThreads {
+		if !matched[i] {
+			comparison.OnlyRight = append(comparison.OnlyRight, thread)
+		}
+	}
+	return comparison, nil
+}
+
+// isVerdict reports whether the given thread accepts or rejects the review
+// as a whole, rather than discussing something within it.
+func isVerdict(thread CommentThread) bool {
+	location := thread.Comment.Location
+	return thread.Comment.Resolved != nil && (location == nil || location.Path == "")
+}
+
+// GetStructuredDiff returns the diff between the heads of the compared
+// reviews, as controlled by the given options, parsed into the changes to
+// each file.
+func (c *Comparison) GetStructuredDiff(options repository.DiffOptions, diffArgs ...string) ([]DiffFile, error) {
+	diff, err := c.Left.Repo.DiffWithOptions(c.LeftHead, c.RightHead, options, diffArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiff(diff), nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+)
+
+func TestCompare(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	left, err := Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := Get(repo, repository.TestCommitD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	needsWork, accepted := false, true
+	onFile := func(path, description string, resolved *bool) CommentThread {
+		return CommentThread{
+			Hash:     path + description,
+			Comment:  comment.Comment{Location: &comment.Location{Path: path, Range: &comment.Range{StartLine: 3}}, Description: description},
+			Resolved: resolved,
+		}
+	}
+	left.Comments = []CommentThread{
+		onFile("a.go", "Handle the error", &needsWork),
+		onFile("a.go", "Rename this", &needsWork),
+		onFile("b.go", "Nice", nil),
+		{Hash: "lgtm", Comment: comment.Comment{Description: "LGTM", Resolved: &accepted}, Resolved: &accepted},
+	}
+	right.Comments = []CommentThread{
+		onFile("a.go", "Handle the error", &needsWork),
+		onFile("a.go", "Rename this", &accepted),
+		onFile("c.go", "Add a test", &needsWork),
+	}
+
+	comparison, err := Compare(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comparison.OnlyLeft) != 1 || comparison.OnlyLeft[0].Comment.Description != "Nice" {
+		t.Errorf("Unexpected threads only in the left review: %v", comparison.OnlyLeft)
+	}
+	if len(comparison.OnlyRight) != 1 || comparison.OnlyRight[0].Comment.Description != "Add a test" {
+		t.Errorf("Unexpected threads only in the right review: %v", comparison.OnlyRight)
+	}
+	if len(comparison.StatusChanged) != 1 || comparison.StatusChanged[0][1].Comment.Description != "Rename this" {
+		t.Errorf("Unexpected threads whose status changed: %v", comparison.StatusChanged)
+	}
+	if comparison.LeftHead == "" || comparison.RightHead == "" {
+		t.Errorf("Unexpected heads: %q, %q", comparison.LeftHead, comparison.RightHead)
+	}
+}
+
+func TestCompareSameSubject(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	left, err := Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := Get(repo, repository.TestCommitD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	needsWork, accepted := false, true
+	thread := func(hash string, resolved *bool) CommentThread {
+		return CommentThread{
+			Hash:     hash,
+			Comment:  comment.Comment{Location: &comment.Location{Path: "a.go"}, Description: "Fix this"},
+			Resolved: resolved,
+		}
+	}
+	left.Comments = []CommentThread{
+		thread("first", &needsWork),
+		thread("second", &needsWork),
+		thread("third", &needsWork),
+	}
+	right.Comments = []CommentThread{
+		thread("other", &needsWork),
+		thread("second", &accepted),
+	}
+
+	comparison, err := Compare(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comparison.StatusChanged) != 1 || comparison.StatusChanged[0][0].Hash != "second" || comparison.StatusChanged[0][1].Hash != "second" {
+		t.Errorf("Unexpected threads whose status changed: %v", comparison.StatusChanged)
+	}
+	if len(comparison.OnlyLeft) != 1 || comparison.OnlyLeft[0].Hash != "third" {
+		t.Errorf("Unexpected threads only in the left review: %v", comparison.OnlyLeft)
+	}
+	if len(comparison.OnlyRight) != 0 {
+		t.Errorf("Unexpected threads only in the right review: %v", comparison.OnlyRight)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/ci"
+)
+
+// The kinds of line in a diff.
+const (
+	DiffLineContext = "context"
+	DiffLineAdded   = "added"
+	DiffLineRemoved = "removed"
+)
+
+// DiffLine is a single line of a hunk in a diff.
+type DiffLine struct {
+	Kind string `json:"kind"`
+	// OldLine and NewLine are the line numbers in the old and new versions
+	// of the file, and are zero for lines that are not in that version.
+	OldLine uint32 `json:"oldLine,omitempty"`
+	NewLine uint32 `json:"newLine,omitempty"`
+	Text    string `json:"text"`
+	// Uncovered is set for added lines that the tests did not run,
+	// according to the latest coverage report of the review.
+	Uncovered bool `json:"uncovered,omitempty"`
+}
+
+// DiffHunk is a contiguous set of changes to a file.
+type DiffHunk struct {
+	Header string     `json:"header"`
+	Lines  []DiffLine `json:"lines"`
+}
+
+// DiffFile is the set of changes to a single file. The old path is empty if
+// the file was added, and the new path is empty if it was deleted.
+type DiffFile struct {
+	OldPath string     `json:"oldPath,omitempty"`
+	NewPath string     `json:"newPath,omitempty"`
+	Hunks   []DiffHunk `json:"hunks,omitempty"`
+}
+
+// hunkHeaderPattern matches the header of a hunk, capturing the first line
+// of the hunk in the old and new versions of the file.
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// DiffPath returns the path of the file named in a "---" or "+++" line of a
+// diff, or the empty string if the file does not exist on that side.
+func DiffPath(name string) string {
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[len("a/"):]
+	}
+	return name
+}
+
+// ParseDiff parses a diff in git's format into the changes to each file.
+func ParseDiff(diff string) []DiffFile {
+	var files []DiffFile
+	var file *DiffFile
+	var hunk *DiffHunk
+	var oldLine, newLine uint32
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			files = append(files, DiffFile{})
+			file, hunk = &files[len(files)-1], nil
+		case file == nil:
+			continue
+		case hunk == nil && strings.HasPrefix(line, "--- "):
+			file.OldPath = DiffPath(line[len("--- "):])
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			file.NewPath = DiffPath(line[len("+++ "):])
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			oldStart, _ := strconv.ParseUint(match[1], 10, 32)
+			newStart, _ := strconv.ParseUint(match[2], 10, 32)
+			oldLine, newLine = uint32(oldStart), uint32(newStart)
+			file.Hunks = append(file.Hunks, DiffHunk{Header: line})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk == nil:
+			continue
+		case strings.HasPrefix(line, "+"):
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineAdded, NewLine: newLine, Text: line[1:]})
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineRemoved, OldLine: oldLine, Text: line[1:]})
+			oldLine++
+		case strings.HasPrefix(line, " "):
+			hunk.Lines = append(hunk.Lines, DiffLine{Kind: DiffLineContext, OldLine: oldLine, NewLine: newLine, Text: line[1:]})
+			oldLine++
+			newLine++
+		}
+	}
+	return files
+}
+
+// AnnotateCoverage marks the added lines in the given files that the tests
+// did not run, according to the latest coverage report of the review.
+func (r *Review) AnnotateCoverage(files []DiffFile) error {
+	report, err := ci.GetLatestCoverageReport(r.Reports)
+	if err != nil || report == nil {
+		return err
+	}
+	uncovered := report.UncoveredLines()
+	for i := range files {
+		lines := uncovered[files[i].NewPath]
+		if lines == nil {
+			continue
+		}
+		for j := range files[i].Hunks {
+			hunk := &files[i].Hunks[j]
+			for k := range hunk.Lines {
+				line := &hunk.Lines[k]
+				line.Uncovered = line.Kind == DiffLineAdded && lines[line.NewLine]
+			}
+		}
+	}
+	return nil
+}
+
+// GetStructuredDiff returns the diff for a review, as controlled by the
+// given options, parsed into the changes to each file and annotated with
+// the lines that are not covered by tests.
+func (r *Review) GetStructuredDiff(options repository.DiffOptions, diffArgs ...string) ([]DiffFile, error) {
+	diff, err := r.GetDiffWithOptions(options, diffArgs...)
+	if err != nil {
+		return nil, err
+	}
+	files := ParseDiff(diff)
+	if err := r.AnnotateCoverage(files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
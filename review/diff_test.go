@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/review/ci"
+)
+
+const testCoverageDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-func f() {}
++func f() {
++	g()
++}
+ 
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(testCoverageDiff)
+	if len(files) != 2 {
+		t.Fatalf("Unexpected files: %+v", files)
+	}
+	if files[0].OldPath != "main.go" || files[0].NewPath != "main.go" || files[1].OldPath != "old.go" || files[1].NewPath != "" {
+		t.Errorf("Unexpected paths: %+v", files)
+	}
+	lines := files[0].Hunks[0].Lines
+	if len(lines) != 6 {
+		t.Fatalf("Unexpected lines: %+v", lines)
+	}
+	if lines[1].Kind != DiffLineRemoved || lines[1].OldLine != 2 || lines[1].NewLine != 0 {
+		t.Errorf("Unexpected removed line: %+v", lines[1])
+	}
+	if lines[3].Kind != DiffLineAdded || lines[3].NewLine != 3 || lines[3].Text != "\tg()" {
+		t.Errorf("Unexpected added line: %+v", lines[3])
+	}
+	if lines[5].Kind != DiffLineContext || lines[5].OldLine != 3 || lines[5].NewLine != 5 {
+		t.Errorf("Unexpected context line: %+v", lines[5])
+	}
+}
+
+func TestAnnotateCoverage(t *testing.T) {
+	r := &Review{
+		Reports: []ci.Report{{
+			Timestamp: "10",
+			Coverage:  []ci.FileCoverage{{Path: "main.go", Covered: []uint32{2, 4}, Uncovered: []uint32{1, 3}}},
+		}},
+	}
+	files := ParseDiff(testCoverageDiff)
+	if err := r.AnnotateCoverage(files); err != nil {
+		t.Fatal(err)
+	}
+	var uncovered []uint32
+	for _, line := range files[0].Hunks[0].Lines {
+		if line.Uncovered {
+			uncovered = append(uncovered, line.NewLine)
+		}
+	}
+	// Line 1 is uncovered, but it is not a changed line.
+	if len(uncovered) != 1 || uncovered[0] != 3 {
+		t.Errorf("Unexpected uncovered lines: %v", uncovered)
+	}
+}
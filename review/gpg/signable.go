@@ -14,6 +14,11 @@ import (
 
 const placeholder = "gpgsig"
 
+// Program is the GPG executable used to sign and verify content. It can be
+// overridden to match git's "gpg.program" setting, e.g. to use the copy of
+// GPG bundled with Git for Windows.
+var Program = "gpg"
+
 // Sig provides an abstraction around shelling out to GPG to sign the
 // content it's given.
 type Sig struct {
@@ -63,7 +68,7 @@ func Sign(key string, s Signable) error {
 func signContent(key string, content []byte) (*bytes.Buffer,
 	error) {
 	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("gpg", "-u", key, "--detach-sign", "--armor")
+	cmd := exec.Command(Program, "-u", key, "--detach-sign", "--armor")
 	cmd.Stdin = bytes.NewReader(content)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -84,8 +89,11 @@ func Verify(s Signable) error {
 	defer func() { *sigPtr = sig }()
 
 	// 1. Marshal the content into JSON.
-	// 2. Write the signature and the content to temp files.
-	// 3. Use gpg to verify the signature.
+	// 2. Write the signature to a temp file.
+	// 3. Use gpg to verify the signature, passing the content on stdin.
+	//
+	// The temp file is closed before gpg runs, since Windows does not allow
+	// other processes to read (or anyone to remove) files that are still open.
 	content, err := json.Marshal(s)
 	if err != nil {
 		return err
@@ -97,6 +105,7 @@ func Verify(s Signable) error {
 	defer os.Remove(sigFile.Name())
 	_, err = sigFile.Write([]byte(sig))
 	if err != nil {
+		sigFile.Close()
 		return err
 	}
 	err = sigFile.Close()
@@ -104,22 +113,9 @@ func Verify(s Signable) error {
 		return err
 	}
 
-	contentFile, err := ioutil.TempFile("", "content")
-	if err != nil {
-		return err
-	}
-	defer os.Remove(contentFile.Name())
-	_, err = contentFile.Write(content)
-	if err != nil {
-		return err
-	}
-	err = contentFile.Close()
-	if err != nil {
-		return err
-	}
-
 	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("gpg", "--verify", sigFile.Name(), contentFile.Name())
+	cmd := exec.Command(Program, "--verify", sigFile.Name(), "-")
+	cmd.Stdin = bytes.NewReader(content)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
@@ -0,0 +1,172 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/trailer"
+)
+
+const (
+	// LintMaxSubjectLengthConfig is the git config key that sets the longest
+	// allowed subject line of a commit message. Zero disables the check.
+	LintMaxSubjectLengthConfig = "appraise.lint.maxSubjectLength"
+	// LintRequireBodyConfig is the git config key that requires every commit
+	// message to have a body after its subject, other than its trailers.
+	LintRequireBodyConfig = "appraise.lint.requireBody"
+	// LintIssuePatternConfig is the git config key that sets a regular
+	// expression that every commit message must match, such as a reference
+	// to an issue.
+	LintIssuePatternConfig = "appraise.lint.issuePattern"
+	// LintRequireSignoffConfig is the git config key that requires every
+	// commit message to have a Signed-off-by trailer.
+	LintRequireSignoffConfig = "appraise.lint.requireSignoff"
+	// LintPolicyConfig is the git config key that sets whether commit
+	// messages that fail the checks only warn ("warn", the default) or
+	// prevent the review from being requested ("fail").
+	LintPolicyConfig = "appraise.lint.policy"
+
+	// SignedOffBy is the trailer key that certifies the origin of a commit.
+	SignedOffBy = "Signed-off-by"
+)
+
+// LintRules are the checks that the messages of the commits in a review
+// must pass when it is requested.
+type LintRules struct {
+	MaxSubjectLength int
+	RequireBody      bool
+	IssuePattern     *regexp.Regexp
+	RequireSignoff   bool
+	// Fail is whether commits that fail the checks prevent the review from
+	// being requested, rather than only being warned about.
+	Fail bool
+}
+
+// LintProblem is a check that the message of a commit failed.
+type LintProblem struct {
+	Commit  string
+	Problem string
+}
+
+// getBoolSetting returns the value of the given boolean setting.
+func getBoolSetting(repo repository.Repo, key string) (bool, error) {
+	value, err := config.Get(repo, key)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(value) == "true", nil
+}
+
+// GetLintRules returns the configured checks of commit messages.
+func GetLintRules(repo repository.Repo) (*LintRules, error) {
+	var rules LintRules
+	var err error
+	maxSubjectLength, err := config.Get(repo, LintMaxSubjectLengthConfig)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(maxSubjectLength) != "" {
+		if rules.MaxSubjectLength, err = config.ParseNonNegativeInt(maxSubjectLength); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q", LintMaxSubjectLengthConfig, maxSubjectLength)
+		}
+	}
+	if rules.RequireBody, err = getBoolSetting(repo, LintRequireBodyConfig); err != nil {
+		return nil, err
+	}
+	if rules.RequireSignoff, err = getBoolSetting(repo, LintRequireSignoffConfig); err != nil {
+		return nil, err
+	}
+	pattern, err := config.Get(repo, LintIssuePatternConfig)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != "" {
+		if rules.IssuePattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", LintIssuePatternConfig, err)
+		}
+	}
+	policy, err := config.Get(repo, LintPolicyConfig)
+	if err != nil {
+		return nil, err
+	}
+	rules.Fail = strings.TrimSpace(policy) == "fail"
+	return &rules, nil
+}
+
+// messageBody returns the body of a commit message, which is everything
+// after its subject other than its trailers.
+func messageBody(message string) string {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	body := strings.TrimSpace(parts[1])
+	if len(trailer.Parse(message)) > 0 {
+		if i := strings.LastIndex(body, "\n\n"); i >= 0 {
+			body = body[:i]
+		} else {
+			body = ""
+		}
+	}
+	return strings.TrimSpace(body)
+}
+
+// Check returns the checks that the given commit message fails.
+func (rules *LintRules) Check(message string) []string {
+	var problems []string
+	subject := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+	if rules.MaxSubjectLength > 0 && len([]rune(subject)) > rules.MaxSubjectLength {
+		problems = append(problems, fmt.Sprintf("the subject is %d characters long, which is more than the %d set by %s",
+			len([]rune(subject)), rules.MaxSubjectLength, LintMaxSubjectLengthConfig))
+	}
+	if rules.RequireBody && messageBody(message) == "" {
+		problems = append(problems, fmt.Sprintf("the message has no body, which %s requires", LintRequireBodyConfig))
+	}
+	if rules.IssuePattern != nil && !rules.IssuePattern.MatchString(message) {
+		problems = append(problems, fmt.Sprintf("the message does not match %q, as %s requires", rules.IssuePattern, LintIssuePatternConfig))
+	}
+	if rules.RequireSignoff {
+		signed := false
+		for _, t := range trailer.Parse(message) {
+			signed = signed || strings.EqualFold(t.Key, SignedOffBy)
+		}
+		if !signed {
+			problems = append(problems, fmt.Sprintf("the message has no %s trailer, which %s requires", SignedOffBy, LintRequireSignoffConfig))
+		}
+	}
+	return problems
+}
+
+// LintCommits returns the checks that the messages of the given commits fail.
+func (rules *LintRules) LintCommits(repo repository.Repo, commits []string) ([]LintProblem, error) {
+	var problems []LintProblem
+	for _, commit := range commits {
+		message, err := repo.GetCommitMessage(commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, problem := range rules.Check(message) {
+			problems = append(problems, LintProblem{Commit: commit, Problem: problem})
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+func TestLintCheck(t *testing.T) {
+	rules := &LintRules{
+		MaxSubjectLength: 20,
+		RequireBody:      true,
+		IssuePattern:     regexp.MustCompile(`#[0-9]+`),
+		RequireSignoff:   true,
+	}
+	good := "Fix the parser\n\nThe parser failed on empty input. Fixes #12.\n\nSigned-off-by: Jane <jane@example.com>\n"
+	if problems := rules.Check(good); len(problems) != 0 {
+		t.Errorf("Unexpected problems with a good message: %q", problems)
+	}
+	if problems := rules.Check("Fix the parser\n\nSigned-off-by: Jane <jane@example.com>\n"); len(problems) != 2 {
+		t.Errorf("Unexpected problems with a message of only trailers: %q", problems)
+	}
+	if problems := rules.Check("Fix the parser when it is given no input at all"); len(problems) != 4 {
+		t.Errorf("Unexpected problems with a bad message: %q", problems)
+	}
+	if problems := (&LintRules{}).Check("x"); len(problems) != 0 {
+		t.Errorf("Unexpected problems with no rules: %q", problems)
+	}
+}
+
+func TestLintCommits(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	rules, err := GetLintRules(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Fail {
+		t.Error("Unexpected failing policy by default")
+	}
+	if problems, err := rules.LintCommits(repo, []string{repository.TestCommitB, repository.TestCommitD}); err != nil || len(problems) != 0 {
+		t.Errorf("Unexpected problems by default: %v, %v", problems, err)
+	}
+	for key, value := range map[string]string{
+		LintMaxSubjectLengthConfig: "12",
+		LintRequireBodyConfig:      "true",
+		LintPolicyConfig:           "fail",
+	} {
+		if err := repo.SetConfig(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rules, err = GetLintRules(repo); err != nil {
+		t.Fatal(err)
+	}
+	if !rules.Fail {
+		t.Error("Unexpected warning policy")
+	}
+	problems, err := rules.LintCommits(repo, []string{repository.TestCommitB, repository.TestCommitD})
+	if err != nil || len(problems) != 4 {
+		t.Errorf("Unexpected problems: %v, %v", problems, err)
+	}
+	for value, valid := range map[string]bool{" 12 ": true, "12k": false, "-1": false} {
+		if err := repo.SetConfig(LintMaxSubjectLengthConfig, value); err != nil {
+			t.Fatal(err)
+		}
+		_, err := GetLintRules(repo)
+		if validateErr := config.Find(LintMaxSubjectLengthConfig).Validate(value); (err == nil) != valid || (validateErr == nil) != valid {
+			t.Errorf("Unexpected results for a maximum subject length of %q: %v, %v", value, err, validateErr)
+		}
+	}
+	if err := repo.SetConfig(LintMaxSubjectLengthConfig, "12"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetConfig(LintIssuePatternConfig, "("); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetLintRules(repo); err == nil {
+		t.Error("Unexpected success with an invalid issue pattern")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package owners contains helper methods for finding the owners of the files
+// in a repository, who are the default reviewers of changes to them.
+package owners
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// FileName is the name of the files that list the owners of the directory
+// they are in, and of its subdirectories that do not have their own.
+const FileName = "OWNERS"
+
+// Parse parses the contents of an owners file into the list of its owners.
+//
+// Each non-empty line is the email address of an owner, or a reference to a
+// team (e.g. "team:backend"), and everything after a "#" is a comment.
+func Parse(contents string) []string {
+	var owners []string
+	for _, line := range strings.Split(contents, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if owner := strings.TrimSpace(line); owner != "" {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// finder looks up the owners files at a single ref, remembering the owners
+// of every directory it has visited, so that each owners file is read at
+// most once.
+type finder struct {
+	repo   repository.Repo
+	ref    string
+	owners map[string][]string
+}
+
+func newFinder(repo repository.Repo, ref string) *finder {
+	return &finder{repo: repo, ref: ref, owners: make(map[string][]string)}
+}
+
+// forDir returns the owners of the given directory, which are listed in its
+// owners file, or else inherited from its parent directory.
+func (f *finder) forDir(dir string) ([]string, error) {
+	if owners, ok := f.owners[dir]; ok {
+		return owners, nil
+	}
+	ownersPath := path.Join(dir, FileName)
+	exists, err := f.repo.HasObject(f.ref + ":" + ownersPath)
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	if exists {
+		contents, err := f.repo.Show(f.ref, ownersPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the owners file %q at %q: %v", ownersPath, f.ref, err)
+		}
+		owners = Parse(contents)
+	} else if dir != "." && dir != "/" {
+		if owners, err = f.forDir(path.Dir(dir)); err != nil {
+			return nil, err
+		}
+	}
+	f.owners[dir] = owners
+	return owners, nil
+}
+
+// ForPath returns the owners of the given file at the given ref, who are
+// listed in the owners file of the closest directory containing it.
+//
+// If none of the directories has an owners file, then the file has no owners.
+// An owners file that exists but can not be read is reported as an error.
+func ForPath(repo repository.Repo, ref, filePath string) ([]string, error) {
+	return newFinder(repo, ref).forDir(path.Dir(filePath))
+}
+
+// ForPaths returns the owners of any of the given files at the given ref, in
+// sorted order.
+func ForPaths(repo repository.Repo, ref string, filePaths []string) ([]string, error) {
+	f := newFinder(repo, ref)
+	seen := make(map[string]bool)
+	var owners []string
+	for _, filePath := range filePaths {
+		pathOwners, err := f.forDir(path.Dir(filePath))
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range pathOwners {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
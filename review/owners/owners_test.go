@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package owners
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// repoWithFiles overrides the files of the mock repository.
+type repoWithFiles struct {
+	repository.Repo
+	files map[string]string
+}
+
+func (r repoWithFiles) HasObject(object string) (bool, error) {
+	if i := strings.Index(object, ":"); i >= 0 {
+		_, ok := r.files[object[i+1:]]
+		return ok, nil
+	}
+	return r.Repo.HasObject(object)
+}
+
+func (r repoWithFiles) Show(commit, path string) (string, error) {
+	if contents, ok := r.files[path]; ok {
+		return contents, nil
+	}
+	return "", fmt.Errorf("%q does not exist in %q", path, commit)
+}
+
+// repoCountingReads counts the files read from the underlying repository,
+// and fails to read any of the given unreadable files.
+type repoCountingReads struct {
+	repoWithFiles
+	reads      map[string]int
+	unreadable map[string]bool
+}
+
+func (r repoCountingReads) Show(commit, path string) (string, error) {
+	r.reads[path]++
+	if r.unreadable[path] {
+		return "", errors.New("fatal: unable to read the object")
+	}
+	return r.repoWithFiles.Show(commit, path)
+}
+
+func TestParse(t *testing.T) {
+	owners := Parse("# Owners of the backend\nalice@example.com\n\n  bob@example.com  # on leave\nteam:backend\n")
+	expected := []string{"alice@example.com", "bob@example.com", "team:backend"}
+	if !reflect.DeepEqual(owners, expected) {
+		t.Errorf("Unexpected owners: %q", owners)
+	}
+}
+
+func TestForPaths(t *testing.T) {
+	repo := repoWithFiles{
+		Repo: repository.NewMockRepoForTest(),
+		files: map[string]string{
+			"OWNERS":         "root@example.com",
+			"backend/OWNERS": "bob@example.com\nalice@example.com",
+		},
+	}
+	if owners, err := ForPath(repo, "HEAD", "backend/db/schema.sql"); err != nil || !reflect.DeepEqual(owners, []string{"bob@example.com", "alice@example.com"}) {
+		t.Errorf("Unexpected owners of a nested file: %q, %v", owners, err)
+	}
+	if owners, err := ForPath(repo, "HEAD", "README.md"); err != nil || !reflect.DeepEqual(owners, []string{"root@example.com"}) {
+		t.Errorf("Unexpected owners of a top-level file: %q, %v", owners, err)
+	}
+	owners, err := ForPaths(repo, "HEAD", []string{"backend/main.go", "docs/index.md", "backend/db/schema.sql"})
+	if err != nil || !reflect.DeepEqual(owners, []string{"alice@example.com", "bob@example.com", "root@example.com"}) {
+		t.Errorf("Unexpected owners of several files: %q, %v", owners, err)
+	}
+	if owners, err := ForPath(repoWithFiles{Repo: repo.Repo}, "HEAD", "a/b/c"); err != nil || owners != nil {
+		t.Errorf("Unexpected owners without any owners files: %q, %v", owners, err)
+	}
+}
+
+func TestForPathsReadsEachOwnersFileOnce(t *testing.T) {
+	repo := repoCountingReads{
+		repoWithFiles: repoWithFiles{
+			Repo: repository.NewMockRepoForTest(),
+			files: map[string]string{
+				"OWNERS":         "root@example.com",
+				"backend/OWNERS": "bob@example.com",
+			},
+		},
+		reads: make(map[string]int),
+	}
+	owners, err := ForPaths(repo, "HEAD", []string{"backend/a.go", "backend/b.go", "backend/db/c.sql", "docs/d.md", "e.md"})
+	if err != nil || !reflect.DeepEqual(owners, []string{"bob@example.com", "root@example.com"}) {
+		t.Errorf("Unexpected owners: %q, %v", owners, err)
+	}
+	for path, reads := range repo.reads {
+		if reads != 1 {
+			t.Errorf("Read %q %d times", path, reads)
+		}
+	}
+}
+
+func TestForPathUnreadableOwnersFile(t *testing.T) {
+	repo := repoCountingReads{
+		repoWithFiles: repoWithFiles{
+			Repo:  repository.NewMockRepoForTest(),
+			files: map[string]string{"OWNERS": "root@example.com"},
+		},
+		reads:      make(map[string]int),
+		unreadable: map[string]bool{"OWNERS": true},
+	}
+	if owners, err := ForPath(repo, "HEAD", "README.md"); err == nil {
+		t.Errorf("Unexpected owners from an unreadable owners file: %q", owners)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// NovelOnlyConfig is the git config key that makes only the commits whose
+// changes were not already accepted in another review need reviewing, so
+// that a review of only already accepted changes is accepted automatically.
+const NovelOnlyConfig = "appraise.review.novelOnly"
+
+// patchIDCacheFilename is the name of the file (relative to the repo's .git
+// directory) in which the patch IDs of reviewed commits are cached.
+const patchIDCacheFilename = "appraise-patch-ids.json"
+
+// patchIDCacheVersion is bumped whenever the format of the cached patch IDs
+// changes, so that stale caches are discarded rather than misread.
+const patchIDCacheVersion = 1
+
+// patchIDCache is the locally cached patch ID of each commit, keyed by the
+// commit's hash. Commits never change, so neither do their patch IDs.
+type patchIDCache struct {
+	Version  int               `json:"v"`
+	PatchIDs map[string]string `json:"patchIds"`
+	changed  bool
+}
+
+func patchIDCachePath(repo repository.Repo) string {
+	return filepath.Join(repo.GetGitDir(), patchIDCacheFilename)
+}
+
+// loadPatchIDCache reads the cached patch IDs, returning an empty cache if
+// the file is missing or unreadable.
+func loadPatchIDCache(repo repository.Repo) *patchIDCache {
+	cache := &patchIDCache{
+		Version:  patchIDCacheVersion,
+		PatchIDs: make(map[string]string),
+	}
+	contents, err := ioutil.ReadFile(patchIDCachePath(repo))
+	if err != nil {
+		return cache
+	}
+	var cached patchIDCache
+	if err := json.Unmarshal(contents, &cached); err != nil || cached.Version != patchIDCacheVersion || cached.PatchIDs == nil {
+		return cache
+	}
+	return &cached
+}
+
+// save writes the patch IDs back to the cache, if any were added. The cache
+// is only an optimization, so failures to write it are not fatal.
+func (cache *patchIDCache) save(repo repository.Repo) {
+	if !cache.changed {
+		return
+	}
+	contents, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(patchIDCachePath(repo), contents, 0644)
+}
+
+// get returns the patch ID of the given commit, computing it if it is not
+// already cached.
+func (cache *patchIDCache) get(repo repository.Repo, commit string) (string, error) {
+	if patchID, ok := cache.PatchIDs[commit]; ok {
+		return patchID, nil
+	}
+	patchID, err := repo.GetPatchID(commit)
+	if err != nil {
+		return "", err
+	}
+	cache.PatchIDs[commit] = patchID
+	cache.changed = true
+	return patchID, nil
+}
+
+// ReviewedCommit identifies an accepted commit of a submitted review.
+type ReviewedCommit struct {
+	// Review is the revision of the accepted review.
+	Review string `json:"review"`
+	// Commit is the commit of that review which made the changes.
+	Commit string `json:"commit"`
+}
+
+// PatchIDIndex maps the patch ID of every accepted commit of a submitted
+// review to that commit, so that the same changes can be recognized when they
+// are requested again, e.g. after a rebase or a cherry-pick to another branch.
+type PatchIDIndex struct {
+	repo     repository.Repo
+	cache    *patchIDCache
+	reviewed map[string][]ReviewedCommit
+}
+
+// NewPatchIDIndex returns the index of the patch IDs of the accepted commits
+// of every review that has a record of being submitted.
+//
+// Only the commits up to the accepted commit of each submission record are
+// indexed, and only if the review was accepted at that commit, so commits that
+// were added after the review was accepted are not. Reviews whose commits can
+// not be listed, e.g. because they have not been fetched, are skipped.
+func NewPatchIDIndex(repo repository.Repo) (*PatchIDIndex, error) {
+	index := &PatchIDIndex{
+		repo:     repo,
+		cache:    loadPatchIDCache(repo),
+		reviewed: make(map[string][]ReviewedCommit),
+	}
+	defer index.cache.save(repo)
+	for _, summary := range ListAll(repo) {
+		if summary.Resolved == nil || !*summary.Resolved {
+			continue
+		}
+		r, err := summary.Details()
+		if err != nil || len(r.Submissions) == 0 {
+			continue
+		}
+		commits, err := r.acceptedCommits()
+		if err != nil {
+			continue
+		}
+		for _, commit := range commits {
+			patchID, err := index.cache.get(repo, commit)
+			if err != nil {
+				return nil, err
+			}
+			if patchID == "" {
+				continue
+			}
+			index.reviewed[patchID] = append(index.reviewed[patchID], ReviewedCommit{Review: r.Revision, Commit: commit})
+		}
+	}
+	return index, nil
+}
+
+// acceptedCommits returns the commits of the review up to the accepted commit
+// of each of its submission records that the review was actually accepted at.
+func (r *Review) acceptedCommits() ([]string, error) {
+	base, err := r.GetBaseCommit()
+	if err != nil {
+		return nil, err
+	}
+	var commits []string
+	seen := make(map[string]bool)
+	for _, s := range r.Submissions {
+		if !r.isAcceptedCommit(s.Accepted) {
+			continue
+		}
+		accepted, err := r.Repo.ListCommitsBetween(base, s.Accepted)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range accepted {
+			if !seen[commit] {
+				seen[commit] = true
+				commits = append(commits, commit)
+			}
+		}
+	}
+	return commits, nil
+}
+
+// FindReviewed returns, for each of the given commits whose changes were
+// already accepted in a review other than the excluded one, where they were
+// accepted, keyed by the commit.
+func (index *PatchIDIndex) FindReviewed(commits []string, exclude string) (map[string]ReviewedCommit, error) {
+	defer index.cache.save(index.repo)
+	found := make(map[string]ReviewedCommit)
+	for _, commit := range commits {
+		patchID, err := index.cache.get(index.repo, commit)
+		if err != nil {
+			return nil, err
+		}
+		if patchID == "" {
+			continue
+		}
+		for _, reviewed := range index.reviewed[patchID] {
+			if reviewed.Review != exclude {
+				found[commit] = reviewed
+				break
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/submission"
+)
+
+func TestPatchIDIndex(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.SetRef("refs/heads/release", repository.TestCommitA, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SwitchToRef("refs/heads/release"); err != nil {
+		t.Fatal(err)
+	}
+	// Review D was accepted at commit E.
+	if err := repo.CherryPick(repository.TestCommitE); err != nil {
+		t.Fatal(err)
+	}
+	picked, err := repo.GetCommitHash("refs/heads/release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := NewPatchIDIndex(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := index.FindReviewed([]string{picked}, ""); err != nil || len(found) != 0 {
+		t.Errorf("Unexpected previously reviewed commits of reviews without submission records: %+v, %v", found, err)
+	}
+
+	for _, accepted := range []string{repository.TestCommitE, repository.TestCommitI} {
+		record := submission.New("ojarjur", accepted, accepted, repository.TestTargetRef, accepted, submission.StrategyMerge)
+		note, err := record.Write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AppendNote(submission.Ref, repository.TestCommitD, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if index, err = NewPatchIDIndex(repo); err != nil {
+		t.Fatal(err)
+	}
+	found, err := index.FindReviewed([]string{picked, repository.TestCommitA, repository.TestCommitI}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[picked] != (ReviewedCommit{Review: repository.TestCommitD, Commit: repository.TestCommitE}) {
+		t.Errorf("Unexpected previously reviewed commits: %+v", found)
+	}
+	if found, err := index.FindReviewed([]string{picked}, repository.TestCommitD); err != nil || len(found) != 0 {
+		t.Errorf("Unexpected previously reviewed commit of the excluded review: %+v, %v", found, err)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+const (
+	// PermalinkRepoIDConfig is the git config key that sets the name of the
+	// repository in permalinks. It defaults to the URL of the origin remote,
+	// without its scheme, user, and ".git" suffix.
+	PermalinkRepoIDConfig = "appraise.permalink.repoId"
+	// PermalinkURLTemplateConfig is the git config key that sets the
+	// template of the HTTP URLs that permalinks are also given as, such as
+	// "https://reviews.example.com/{{.Review}}#{{.Comment}}".
+	PermalinkURLTemplateConfig = "appraise.permalink.urlTemplate"
+	// PermalinkScheme is the scheme of permalinks.
+	PermalinkScheme = "appraise://"
+	// WebURLTemplateConfig is the git config key that sets the template of
+	// the URLs of reviews in a web UI, such as
+	// "https://reviews.example.com/{{.Repo}}/{{.Review}}".
+	WebURLTemplateConfig = "appraise.web.urlTemplate"
+	// ReviewURLPrefixConfig is the git config key that sets the prefix of
+	// the revision of a review in links to it.
+	ReviewURLPrefixConfig = "appraise.reviewURLPrefix"
+)
+
+// PermalinkTarget is what a permalink refers to. Its fields are available to
+// URL templates.
+type PermalinkTarget struct {
+	// Repo is the name of the repository.
+	Repo string
+	// Review is the full revision of the review.
+	Review string
+	// Comment is the full hash of the comment, or empty for the review.
+	Comment string
+}
+
+// Permalinker generates the permalinks of reviews and comments, which stay
+// the same across clones of the repository so that they can be referenced
+// from issues and chat.
+type Permalinker struct {
+	RepoID      string
+	urlTemplate *template.Template
+}
+
+// NewPermalinker returns a permalinker for the repository with the given
+// name, and, if the given URL template is not empty, for HTTP URLs.
+func NewPermalinker(repoID, urlTemplate string) (*Permalinker, error) {
+	p := &Permalinker{RepoID: repoID}
+	if urlTemplate != "" {
+		t, err := template.New("permalink").Parse(urlTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", PermalinkURLTemplateConfig, err)
+		}
+		p.urlTemplate = t
+	}
+	return p, nil
+}
+
+// GetPermalinker returns the permalinker configured for the given repo.
+func GetPermalinker(repo repository.Repo) (*Permalinker, error) {
+	repoID, err := config.Get(repo, PermalinkRepoIDConfig)
+	if err != nil {
+		return nil, err
+	}
+	if repoID == "" {
+		originURL, err := repo.GetConfig("remote.origin.url")
+		if err != nil {
+			return nil, err
+		}
+		repoID = DefaultRepoID(originURL, repo.GetPath())
+	}
+	urlTemplate, err := config.Get(repo, PermalinkURLTemplateConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewPermalinker(repoID, urlTemplate)
+}
+
+// remoteURLPrefix matches the scheme and user of a remote URL, such as
+// "https://", "ssh://git@", or the "git@" of "git@github.com:owner/repo".
+var remoteURLPrefix = regexp.MustCompile(`^([a-z][a-z0-9+.-]*://)?([^@/]+@)?`)
+
+// DefaultRepoID returns the name of a repository in permalinks when none has
+// been configured: the given remote URL without its scheme, user, and ".git"
+// suffix, e.g. "github.com/google/git-appraise", or, if there is no remote,
+// the name of the repository's directory.
+func DefaultRepoID(remoteURL, repoPath string) string {
+	if remoteURL == "" {
+		return path.Base(strings.TrimRight(repoPath, "/"))
+	}
+	id := remoteURLPrefix.ReplaceAllString(remoteURL, "")
+	// Convert the scp-like syntax, "host:owner/repo", to "host/owner/repo".
+	hasScheme := strings.Contains(remoteURL, "://")
+	if i := strings.Index(id, ":"); !hasScheme && i >= 0 && !strings.Contains(id[:i], "/") {
+		id = id[:i] + "/" + strings.TrimLeft(id[i+1:], "/")
+	}
+	return strings.TrimSuffix(strings.TrimRight(id, "/"), ".git")
+}
+
+// Link returns the permalink of the given comment of the given review, or of
+// the review itself if the comment hash is empty, e.g.
+// "appraise://github.com/google/git-appraise/<review>/<comment>".
+func (p *Permalinker) Link(revision, commentHash string) string {
+	link := PermalinkScheme + p.RepoID + "/" + revision
+	if commentHash != "" {
+		link += "/" + commentHash
+	}
+	return link
+}
+
+// URL returns the HTTP URL of the given comment of the given review, or of
+// the review itself if the comment hash is empty. It is empty if no URL
+// template has been configured.
+func (p *Permalinker) URL(revision, commentHash string) (string, error) {
+	if p.urlTemplate == nil {
+		return "", nil
+	}
+	var url strings.Builder
+	target := PermalinkTarget{Repo: p.RepoID, Review: revision, Comment: commentHash}
+	if err := p.urlTemplate.Execute(&url, target); err != nil {
+		return "", fmt.Errorf("failed to expand the permalink URL template: %v", err)
+	}
+	return url.String(), nil
+}
+
+// AddPermalinks returns a copy of the given comment threads of the given
+// review, with the permalink (and URL) of each (sub)thread filled in.
+func (p *Permalinker) AddPermalinks(revision string, threads []CommentThread) ([]CommentThread, error) {
+	var result []CommentThread
+	for _, thread := range threads {
+		thread.Permalink = p.Link(revision, thread.Hash)
+		url, err := p.URL(revision, thread.Hash)
+		if err != nil {
+			return nil, err
+		}
+		thread.URL = url
+		if thread.Children, err = p.AddPermalinks(revision, thread.Children); err != nil {
+			return nil, err
+		}
+		result = append(result, thread)
+	}
+	return result, nil
+}
+
+// GetWebURL returns the URL of the given review in the configured web UI,
+// which is given either by WebURLTemplateConfig, or by ReviewURLPrefixConfig
+// followed by the review's revision. It is empty if neither is configured.
+func GetWebURL(repo repository.Repo, revision string) (string, error) {
+	webTemplate, err := config.Get(repo, WebURLTemplateConfig)
+	if err != nil {
+		return "", err
+	}
+	if webTemplate == "" {
+		urlPrefix, err := config.Get(repo, ReviewURLPrefixConfig)
+		if err != nil || urlPrefix == "" {
+			return "", err
+		}
+		return urlPrefix + revision, nil
+	}
+	t, err := template.New("web").Parse(webTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid value for %s: %v", WebURLTemplateConfig, err)
+	}
+	p, err := GetPermalinker(repo)
+	if err != nil {
+		return "", err
+	}
+	var url strings.Builder
+	if err := t.Execute(&url, PermalinkTarget{Repo: p.RepoID, Review: revision}); err != nil {
+		return "", fmt.Errorf("failed to expand %s: %v", WebURLTemplateConfig, err)
+	}
+	return url.String(), nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+)
+
+func TestDefaultRepoID(t *testing.T) {
+	for _, tc := range []struct {
+		remoteURL, repoPath, want string
+	}{
+		{"https://github.com/google/git-appraise.git", "/src/git-appraise", "github.com/google/git-appraise"},
+		{"https://user@example.com/repos/project/", "/src/project", "example.com/repos/project"},
+		{"ssh://git@example.com:2222/project.git", "/src/project", "example.com:2222/project"},
+		{"git@github.com:google/git-appraise.git", "/src/git-appraise", "github.com/google/git-appraise"},
+		{"", "/src/git-appraise/", "git-appraise"},
+	} {
+		if got := DefaultRepoID(tc.remoteURL, tc.repoPath); got != tc.want {
+			t.Errorf("DefaultRepoID(%q, %q) = %q; want %q", tc.remoteURL, tc.repoPath, got, tc.want)
+		}
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	p, err := NewPermalinker("example.com/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := p.Link("abcd", "ef01"); link != "appraise://example.com/repo/abcd/ef01" {
+		t.Errorf("Unexpected comment permalink: %q", link)
+	}
+	if link := p.Link("abcd", ""); link != "appraise://example.com/repo/abcd" {
+		t.Errorf("Unexpected review permalink: %q", link)
+	}
+	if url, err := p.URL("abcd", "ef01"); err != nil || url != "" {
+		t.Errorf("Unexpected URL without a template: %q, %v", url, err)
+	}
+
+	p, err = NewPermalinker("example.com/repo", "https://reviews.example.com/{{.Review}}{{if .Comment}}#{{.Comment}}{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads, err := p.AddPermalinks("abcd", []CommentThread{{Hash: "ef01", Children: []CommentThread{{Hash: "2345"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threads[0].Permalink != "appraise://example.com/repo/abcd/ef01" || threads[0].URL != "https://reviews.example.com/abcd#ef01" {
+		t.Errorf("Unexpected links: %q, %q", threads[0].Permalink, threads[0].URL)
+	}
+	if child := threads[0].Children[0]; child.Permalink != "appraise://example.com/repo/abcd/2345" || child.URL != "https://reviews.example.com/abcd#2345" {
+		t.Errorf("Unexpected links of a reply: %q, %q", child.Permalink, child.URL)
+	}
+
+	if _, err := NewPermalinker("example.com/repo", "{{.Review"); err == nil {
+		t.Error("Failed to reject an invalid URL template")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance defines the internal representation of a record of
+// where review metadata came from, such as a contributor's fork.
+package provenance
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+const (
+	// Ref defines the git-notes ref that we expect to contain provenance records.
+	Ref = "refs/notes/devtools/provenance"
+
+	// FormatVersion defines the latest version of the provenance format supported by the tool.
+	FormatVersion = 0
+)
+
+// Record describes a set of review requests and comments that were merged
+// into the local notes from another source.
+//
+// Records are attached to the first revision in the review that the
+// requests and comments belong to.
+type Record struct {
+	// Timestamp is when the requests and comments were merged.
+	Timestamp string `json:"timestamp,omitempty"`
+	// Fork is the name of the remote that the notes were pulled from.
+	Fork string `json:"fork"`
+	URL  string `json:"url,omitempty"`
+	// SourceRef is the ref that the notes were merged from.
+	SourceRef string `json:"sourceRef,omitempty"`
+	// Requests and Comments list the hashes of the merged requests and comments.
+	Requests []string `json:"requests,omitempty"`
+	Comments []string `json:"comments,omitempty"`
+	// Version represents the version of the metadata format.
+	Version int `json:"v,omitempty"`
+}
+
+// Parse parses a provenance record from a git note.
+func Parse(note repository.Note) (Record, error) {
+	bytes := []byte(note)
+	var record Record
+	if err := json.Unmarshal(bytes, &record); err != nil {
+		return record, err
+	}
+	normalized, err := timestamp.Normalize(record.Timestamp)
+	if err != nil {
+		return record, err
+	}
+	record.Timestamp = normalized
+	return record, nil
+}
+
+// Time returns the time at which the notes were merged.
+func (record *Record) Time() time.Time {
+	return timestamp.Time(record.Timestamp)
+}
+
+// ParseAllValid takes collection of git notes and tries to parse a
+// provenance record from each one. Any notes that are not valid provenance
+// records get ignored.
+func ParseAllValid(notes []repository.Note) []Record {
+	var records []Record
+	for _, note := range notes {
+		record, err := Parse(note)
+		if err == nil && record.Version == FormatVersion && record.Fork != "" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Write writes a provenance record as a JSON-formatted git note.
+func (record *Record) Write() (repository.Note, error) {
+	bytes, err := json.Marshal(record)
+	return repository.Note(bytes), err
+}
+
+// RequestSource returns the record describing where the request with the
+// given hash came from, or nil if it was not merged from elsewhere.
+func RequestSource(records []Record, hash string) *Record {
+	for i := range records {
+		for _, requestHash := range records[i].Requests {
+			if requestHash == hash {
+				return &records[i]
+			}
+		}
+	}
+	return nil
+}
+
+// CommentSource returns the record describing where the comment with the
+// given hash came from, or nil if it was not merged from elsewhere.
+func CommentSource(records []Record, hash string) *Record {
+	for i := range records {
+		for _, commentHash := range records[i].Comments {
+			if commentHash == hash {
+				return &records[i]
+			}
+		}
+	}
+	return nil
+}
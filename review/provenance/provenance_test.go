@@ -0,0 +1,44 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestParseAllValid(t *testing.T) {
+	records := ParseAllValid([]repository.Note{
+		repository.Note(`{"timestamp":"12","fork":"alice","requests":["r1"],"comments":["c1","c2"]}`),
+		repository.Note(`{"timestamp":"12","requests":["r2"]}`),
+		repository.Note(`{"timestamp":"1x","fork":"bob","comments":["c3"]}`),
+		repository.Note(`{"timestamp":"12","fork":"carol","comments":["c4"],"v":1}`),
+	})
+	if len(records) != 1 || records[0].Timestamp != "0000000012" {
+		t.Fatalf("Unexpected records: %+v", records)
+	}
+	if source := RequestSource(records, "r1"); source == nil || source.Fork != "alice" {
+		t.Errorf("Unexpected source for request r1: %+v", source)
+	}
+	if source := CommentSource(records, "c2"); source == nil || source.Fork != "alice" {
+		t.Errorf("Unexpected source for comment c2: %+v", source)
+	}
+	if source := CommentSource(records, "c3"); source != nil {
+		t.Errorf("Unexpected source for comment c3: %+v", source)
+	}
+}
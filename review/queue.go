@@ -0,0 +1,264 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+const (
+	// QueueWeightsConfig is the git config key that sets how much each
+	// factor contributes to the priority of a review in the queue, as a
+	// comma-separated list of <factor>=<weight> pairs.
+	QueueWeightsConfig = "appraise.queue.weights"
+	// DefaultQueueWeights is the default value of QueueWeightsConfig.
+	DefaultQueueWeights = "deadline=4,labels=2,wait=2,age=1,size=1"
+	// QueueLabelsConfig is the git config key that sets how urgent the
+	// reviews with each label are, as a comma-separated list of
+	// <label>=<value> pairs, with values between 0 and 1.
+	QueueLabelsConfig = "appraise.queue.labels"
+	// DefaultQueueLabels is the default value of QueueLabelsConfig.
+	DefaultQueueLabels = "urgent=1"
+)
+
+// The factors that contribute to the priority of a review. Each is scaled
+// to be between 0 and 1, with higher values meaning that the review is more
+// urgent.
+const (
+	// FactorDeadline grows as the deadline of the review approaches, and
+	// is 1 once it has passed.
+	FactorDeadline = "deadline"
+	// FactorWait grows with the time that the requester has been waiting
+	// for someone else to respond to their last request or comment.
+	FactorWait = "wait"
+	// FactorAge grows with the time since the review was first requested.
+	FactorAge = "age"
+	// FactorSize is larger for smaller reviews, as those can be finished
+	// quickly.
+	FactorSize = "size"
+	// FactorLabels is the value of the most urgent of the review's labels,
+	// as set by QueueLabelsConfig.
+	FactorLabels = "labels"
+)
+
+// QueueFactors lists every factor that can be weighted.
+var QueueFactors = []string{FactorDeadline, FactorLabels, FactorWait, FactorAge, FactorSize}
+
+// Priority is the computed priority of a review in the queue.
+type Priority struct {
+	Score   float64            `json:"score"`
+	Factors map[string]float64 `json:"factors"`
+}
+
+// QueuedReview is an open review along with its priority.
+type QueuedReview struct {
+	Summary
+	// Size is the size of the review, if it was needed for the priority and
+	// the review's code is available.
+	Size     *Size    `json:"size,omitempty"`
+	Priority Priority `json:"priority"`
+}
+
+// ParseQueueWeights parses a comma-separated list of <factor>=<weight> pairs.
+// Factors that are not listed have a weight of zero.
+func ParseQueueWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || !isQueueFactor(strings.TrimSpace(parts[0])) {
+			return nil, fmt.Errorf("must be a comma-separated list of <factor>=<weight>, where <factor> is one of %s", strings.Join(QueueFactors, ", "))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("the weight of %s must be a non-negative number", parts[0])
+		}
+		weights[strings.TrimSpace(parts[0])] = weight
+	}
+	return weights, nil
+}
+
+func isQueueFactor(factor string) bool {
+	for _, f := range QueueFactors {
+		if f == factor {
+			return true
+		}
+	}
+	return false
+}
+
+// GetQueueWeights returns the configured weight of each factor.
+func GetQueueWeights(repo repository.Repo) (map[string]float64, error) {
+	configured, err := config.Get(repo, QueueWeightsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(configured) == "" {
+		configured = DefaultQueueWeights
+	}
+	weights, err := ParseQueueWeights(configured)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %q; %v", QueueWeightsConfig, configured, err)
+	}
+	return weights, nil
+}
+
+// GetQueueLabels returns the configured value of each label, between 0 and 1.
+func GetQueueLabels(repo repository.Repo) (map[string]float64, error) {
+	configured, err := config.Get(repo, QueueLabelsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(configured) == "" {
+		configured = DefaultQueueLabels
+	}
+	labels := make(map[string]float64)
+	for _, pair := range strings.Split(configured, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid value for %s: %q; must be a comma-separated list of <label>=<value>", QueueLabelsConfig, configured)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || value < 0 || value > 1 {
+			return nil, fmt.Errorf("invalid value for %s: %q; the value of %s must be between 0 and 1", QueueLabelsConfig, configured, parts[0])
+		}
+		labels[strings.TrimSpace(parts[0])] = value
+	}
+	return labels, nil
+}
+
+// halfAt scales the given duration to between 0 and 1, so that it is 0.5
+// at the given midpoint.
+func halfAt(d, midpoint time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(d) / float64(d+midpoint)
+}
+
+// lastActivity returns the times of the latest request or comment by the
+// requester, and of the latest comment by anyone else.
+func (r *Summary) lastActivity() (time.Time, time.Time) {
+	var byRequester, byOthers time.Time
+	for _, req := range r.AllRequests {
+		if t := req.Time(); t.After(byRequester) {
+			byRequester = t
+		}
+	}
+	var visit func(threads []CommentThread)
+	visit = func(threads []CommentThread) {
+		for _, thread := range threads {
+			t := timestamp.Time(thread.Comment.Timestamp)
+			if thread.Comment.Author == r.Request.Requester {
+				if t.After(byRequester) {
+					byRequester = t
+				}
+			} else if t.After(byOthers) {
+				byOthers = t
+			}
+			visit(thread.Children)
+		}
+	}
+	visit(r.Comments)
+	return byRequester, byOthers
+}
+
+// getPriority computes the priority of the given review, which changes the
+// given number of lines, or a negative number if its size is unknown.
+func getPriority(r *Summary, lines int, weights, labels map[string]float64, now time.Time) Priority {
+	factors := make(map[string]float64)
+	for _, label := range r.Request.Labels {
+		if value, ok := labels[label]; ok && value >= factors[FactorLabels] {
+			factors[FactorLabels] = value
+		}
+	}
+	if r.Request.DueBy != "" {
+		untilDue := r.Request.DueTime().Sub(now)
+		if untilDue <= 0 {
+			factors[FactorDeadline] = 1
+		} else {
+			factors[FactorDeadline] = 1 - halfAt(untilDue, 24*time.Hour)
+		}
+	}
+	if len(r.AllRequests) > 0 {
+		factors[FactorAge] = halfAt(now.Sub(r.AllRequests[0].Time()), 7*24*time.Hour)
+	}
+	if byRequester, byOthers := r.lastActivity(); byRequester.After(byOthers) {
+		factors[FactorWait] = halfAt(now.Sub(byRequester), 48*time.Hour)
+	}
+	if lines >= 0 {
+		factors[FactorSize] = 1 / (1 + float64(lines)/DefaultMaxLines)
+	}
+	var score float64
+	for factor, value := range factors {
+		score += weights[factor] * value
+	}
+	return Priority{Score: score, Factors: factors}
+}
+
+// Queue returns the given reviews ordered by their priority, highest first.
+//
+// Reviews with equal priorities keep their relative order. The size of each
+// review, which takes a diff to compute, is only computed if it has a weight.
+func Queue(repo repository.Repo, reviews []Summary, now time.Time) ([]QueuedReview, error) {
+	weights, err := GetQueueWeights(repo)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := GetQueueLabels(repo)
+	if err != nil {
+		return nil, err
+	}
+	var queue []QueuedReview
+	for i := range reviews {
+		r := &reviews[i]
+		var size *Size
+		lines := -1
+		if weights[FactorSize] > 0 {
+			// The size is best effort, as the review's code may not have been
+			// fetched. Only the commits of the review are needed for it, so
+			// the rest of its details are not loaded.
+			if size, _ = (&Review{Summary: r}).GetSize(); size != nil {
+				lines = size.Lines()
+			}
+		}
+		queue = append(queue, QueuedReview{
+			Summary:  *r,
+			Size:     size,
+			Priority: getPriority(r, lines, weights, labels, now),
+		})
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Priority.Score > queue[j].Priority.Score
+	})
+	return queue, nil
+}
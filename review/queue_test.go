@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestParseQueueWeights(t *testing.T) {
+	weights, err := ParseQueueWeights(" deadline=4, size=0.5 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights) != 2 || weights[FactorDeadline] != 4 || weights[FactorSize] != 0.5 {
+		t.Errorf("Unexpected weights: %v", weights)
+	}
+	for _, invalid := range []string{"urgency=1", "age", "age=-1", "age=x"} {
+		if _, err := ParseQueueWeights(invalid); err == nil {
+			t.Errorf("Failed to reject the weights %q", invalid)
+		}
+	}
+}
+
+func TestGetPriority(t *testing.T) {
+	now := time.Unix(1000000000, 0)
+	ts := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(d).Unix(), 10)
+	}
+	weights := map[string]float64{FactorDeadline: 4, FactorLabels: 2, FactorWait: 2, FactorAge: 1, FactorSize: 1}
+	labels := map[string]float64{"urgent": 1, "docs": 0.25}
+
+	overdue := &Summary{
+		Request:     request.Request{Requester: "alice", DueBy: ts(-time.Hour)},
+		AllRequests: []request.Request{{Requester: "alice", Timestamp: ts(-time.Hour)}},
+	}
+	answered := &Summary{
+		Request:     request.Request{Requester: "alice"},
+		AllRequests: []request.Request{{Requester: "alice", Timestamp: ts(-48 * time.Hour)}},
+		Comments:    []CommentThread{{Comment: comment.Comment{Author: "bob", Timestamp: ts(-time.Hour)}}},
+	}
+	waiting := &Summary{
+		Request:     request.Request{Requester: "alice"},
+		AllRequests: []request.Request{{Requester: "alice", Timestamp: ts(-48 * time.Hour)}},
+	}
+
+	p := getPriority(overdue, -1, weights, labels, now)
+	if p.Factors[FactorDeadline] != 1 {
+		t.Errorf("Unexpected deadline factor of an overdue review: %v", p.Factors)
+	}
+	if _, ok := p.Factors[FactorSize]; ok {
+		t.Errorf("Unexpected size factor of a review of unknown size: %v", p.Factors)
+	}
+	if p := getPriority(answered, 0, weights, labels, now); p.Factors[FactorWait] != 0 || p.Factors[FactorSize] != 1 {
+		t.Errorf("Unexpected factors of an answered review: %v", p.Factors)
+	}
+	if p := getPriority(waiting, DefaultMaxLines, weights, labels, now); p.Factors[FactorWait] != 0.5 || p.Factors[FactorSize] != 0.5 {
+		t.Errorf("Unexpected factors of a waiting review: %v", p.Factors)
+	}
+	if getPriority(waiting, 0, weights, labels, now).Score <= getPriority(answered, 0, weights, labels, now).Score {
+		t.Error("A review whose requester is waiting should have a higher priority than one waiting on its requester")
+	}
+
+	labelled := &Summary{Request: request.Request{Requester: "alice", Labels: []string{"docs", "unknown", "urgent"}}}
+	if p := getPriority(labelled, -1, weights, labels, now); p.Factors[FactorLabels] != 1 || p.Score != 2 {
+		t.Errorf("Unexpected priority of a review with an urgent label: %+v", p)
+	}
+	labelled.Request.Labels = []string{"unknown"}
+	if p := getPriority(labelled, -1, weights, labels, now); p.Factors[FactorLabels] != 0 {
+		t.Errorf("Unexpected labels factor of a review without configured labels: %v", p.Factors)
+	}
+}
+
+func TestGetQueueLabels(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if labels, err := GetQueueLabels(repo); err != nil || len(labels) != 1 || labels["urgent"] != 1 {
+		t.Errorf("Unexpected default labels: %v, %v", labels, err)
+	}
+	if err := repo.SetConfig(QueueLabelsConfig, "security=1, docs=0.2"); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err := GetQueueLabels(repo); err != nil || len(labels) != 2 || labels["docs"] != 0.2 {
+		t.Errorf("Unexpected configured labels: %v, %v", labels, err)
+	}
+	for _, invalid := range []string{"urgent", "urgent=2", "urgent=x"} {
+		if err := repo.SetConfig(QueueLabelsConfig, invalid); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GetQueueLabels(repo); err == nil {
+			t.Errorf("Failed to reject the labels %q", invalid)
+		}
+	}
+}
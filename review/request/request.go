@@ -18,10 +18,14 @@ limitations under the License.
 package request
 
 import (
+	"crypto/sha1"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/timestamp"
 )
 
 // Ref defines the git-notes ref that we expect to contain review requests.
@@ -43,6 +47,18 @@ type Request struct {
 	Requester   string   `json:"requester,omitempty"`
 	Reviewers   []string `json:"reviewers,omitempty"`
 	Description string   `json:"description,omitempty"`
+	// CC lists the people who are kept informed of the review, without being
+	// asked to review it. They are notified like reviewers, but are never
+	// counted as reviewers by the acceptance policies.
+	CC []string `json:"cc,omitempty"`
+	// Labels are free-form tags of the review (e.g. "urgent"), which can be
+	// used to raise its priority in the review queue.
+	Labels []string `json:"labels,omitempty"`
+	// ReviewerTeams records the membership, at the time of the request, of every
+	// team that was asked to review the change. The members of these teams are
+	// also included in Reviewers, and an acceptance from any one member counts
+	// as an acceptance from the team.
+	ReviewerTeams map[string][]string `json:"reviewerTeams,omitempty"`
 	// Version represents the version of the metadata format.
 	Version int `json:"v,omitempty"`
 	// BaseCommit stores the commit ID of the target ref at the time the review was requested.
@@ -53,6 +69,30 @@ type Request struct {
 	// Alias stores a post-rebase commit ID for the review. This allows the tool
 	// to track the history of a review even if the commit history changes.
 	Alias string `json:"alias,omitempty"`
+	// TargetTag names a tag that should be created at the reviewed commit once
+	// the review is approved. This is used for release reviews, in which case
+	// the review is anchored at the release candidate and has no review ref.
+	TargetTag string `json:"targetTag,omitempty"`
+	// DueBy is an optional timestamp by which the requester would like the
+	// review to be completed.
+	DueBy string `json:"dueBy,omitempty"`
+	// WIP marks the review as a work in progress, which is not yet ready to
+	// be reviewed. Such reviews are hidden from the default lists of others.
+	WIP bool `json:"wip,omitempty"`
+	// ForeignCommitter records the email address of the committer of the
+	// commit at which the review is anchored, if that is not the requester.
+	// Such reviews may be distrusted by policies, since anyone can request a
+	// review of a commit that they did not write. As this is written by the
+	// requester, it is only informational; the committer is read from the
+	// commit wherever it is used.
+	ForeignCommitter string `json:"foreignCommitter,omitempty"`
+	// BackportOf is the revision of the review whose commits were
+	// cherry-picked to create the commits of this review.
+	BackportOf string `json:"backportOf,omitempty"`
+	// PreviouslyReviewed maps each commit of the review whose changes were
+	// already accepted in another review (e.g. before a rebase, or on
+	// another branch) to the revision of that review.
+	PreviouslyReviewed map[string]string `json:"previouslyReviewed,omitempty"`
 
 	gpg.Sig
 }
@@ -71,6 +111,9 @@ func New(requester string, reviewers []string, reviewRef, targetRef, description
 }
 
 // Parse parses a review request from a git note.
+//
+// The request's timestamps are left as they were written, so that its hash
+// and signature are unchanged, and are only interpreted by Time and DueTime.
 func Parse(note repository.Note) (Request, error) {
 	bytes := []byte(note)
 	var request Request
@@ -79,6 +122,28 @@ func Parse(note repository.Note) (Request, error) {
 	return request, err
 }
 
+// CheckTimestamps returns an error if either of the request's timestamps is
+// not a number of seconds since the Unix epoch.
+func (request *Request) CheckTimestamps() error {
+	if _, err := timestamp.Normalize(request.Timestamp); err != nil {
+		return err
+	}
+	_, err := timestamp.Normalize(request.DueBy)
+	return err
+}
+
+// Time returns the time at which the request was made, or the zero time
+// if the request does not have a timestamp.
+func (request *Request) Time() time.Time {
+	return timestamp.Time(request.Timestamp)
+}
+
+// DueTime returns the time by which the review should be completed, or the
+// zero time if the request does not have a deadline.
+func (request *Request) DueTime() time.Time {
+	return timestamp.Time(request.DueBy)
+}
+
 // ParseAllValid takes collection of git notes and tries to parse a review
 // request from each one. Any notes that are not valid review requests get
 // ignored, as we expect the git notes to be a heterogenous list, with only
@@ -99,3 +164,9 @@ func (request *Request) Write() (repository.Note, error) {
 	bytes, err := json.Marshal(request)
 	return repository.Note(bytes), err
 }
+
+// Hash returns the SHA1 hash of a review request.
+func (request *Request) Hash() (string, error) {
+	bytes, err := json.Marshal(request)
+	return fmt.Sprintf("%x", sha1.Sum(bytes)), err
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/review/request"
+)
+
+// RequestChange is a change to one field of a review request.
+type RequestChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// RequestRevision is one of the request notes of a review, along with how it
+// changed the request from the one before it.
+type RequestRevision struct {
+	Request request.Request `json:"request"`
+	// Changes is empty for the first revision, and for revisions that only
+	// re-recorded the request (e.g. to sign it).
+	Changes []RequestChange `json:"changes,omitempty"`
+}
+
+// requestFields lists the fields of a request whose changes are tracked,
+// along with how to format each one.
+var requestFields = []struct {
+	name   string
+	format func(request.Request) string
+}{
+	{"description", func(r request.Request) string { return r.Description }},
+	{"requester", func(r request.Request) string { return r.Requester }},
+	{"reviewers", func(r request.Request) string { return strings.Join(r.Reviewers, ", ") }},
+	{"cc", func(r request.Request) string { return strings.Join(r.CC, ", ") }},
+	{"targetRef", func(r request.Request) string { return r.TargetRef }},
+	{"targetTag", func(r request.Request) string { return r.TargetTag }},
+	{"reviewRef", func(r request.Request) string { return r.ReviewRef }},
+	{"dueBy", func(r request.Request) string { return r.DueBy }},
+	{"wip", func(r request.Request) string { return fmt.Sprintf("%t", r.WIP) }},
+	{"foreignCommitter", func(r request.Request) string { return r.ForeignCommitter }},
+	{"backportOf", func(r request.Request) string { return r.BackportOf }},
+	{"alias", func(r request.Request) string { return r.Alias }},
+}
+
+// RequestHistory returns every revision of the review's request, oldest
+// first, along with what each revision changed.
+func (r *Summary) RequestHistory() []RequestRevision {
+	var history []RequestRevision
+	for i, req := range r.AllRequests {
+		revision := RequestRevision{Request: req}
+		if i > 0 {
+			previous := r.AllRequests[i-1]
+			for _, field := range requestFields {
+				if old, new := field.format(previous), field.format(req); old != new {
+					revision.Changes = append(revision.Changes, RequestChange{Field: field.name, Old: old, New: new})
+				}
+			}
+		}
+		history = append(history, revision)
+	}
+	return history
+}
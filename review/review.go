@@ -21,18 +21,72 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/git-appraise/config"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/benchmark"
 	"github.com/google/git-appraise/review/ci"
+	"github.com/google/git-appraise/review/claim"
 	"github.com/google/git-appraise/review/comment"
 	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/provenance"
 	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/submission"
+	"github.com/google/git-appraise/review/teams"
+	"github.com/google/git-appraise/review/timestamp"
+	"github.com/google/git-appraise/tracing"
 )
 
 const archiveRef = "refs/devtools/archives/reviews"
 
+// ForkRefPrefix is the prefix of the refs into which the branches of forks
+// are fetched.
+const ForkRefPrefix = "refs/forks/"
+
+// URLForkName is the prefix of the names of the forks registered by
+// `request --from-remote`, whose names are this followed by a hash of the URL.
+const URLForkName = "url/"
+
+const (
+	// MaxCommentSizeConfig is the git config key that sets the maximum size of
+	// a comment's note, in bytes, optionally with a "k" or "m" suffix.
+	// A limit of zero means that comments of any size are allowed.
+	MaxCommentSizeConfig = "appraise.comment.maxSize"
+
+	// DefaultMaxCommentSize is the maximum size of a comment's note, in bytes,
+	// if the MaxCommentSizeConfig key is not set.
+	DefaultMaxCommentSize = 64 * 1024
+
+	// BenchmarkThresholdConfig is the git config key that sets the largest
+	// regression of a benchmark metric that passes, as a percentage or a
+	// fraction of its baseline.
+	BenchmarkThresholdConfig = "appraise.benchmark.threshold"
+
+	// AnalysesTimeoutConfig is the git config key that sets how long
+	// downloading the details of an analysis report may take.
+	AnalysesTimeoutConfig = "appraise.analyses.timeout"
+
+	// AnalysesRetriesConfig is the git config key that sets how many times
+	// a failed download of the details of an analysis report is retried.
+	AnalysesRetriesConfig = "appraise.analyses.retries"
+
+	// AnalysesMaxSizeConfig is the git config key that sets the maximum size
+	// of the details of an analysis report, in bytes, optionally with a "k"
+	// or "m" suffix. A limit of zero means that details of any size are allowed.
+	AnalysesMaxSizeConfig = "appraise.analyses.maxSize"
+
+	// analysesCacheDirname is the directory, under the git directory, in
+	// which the downloaded details of analysis reports are cached.
+	analysesCacheDirname = "appraise-analyses-cache"
+)
+
 var emptyTree = repository.NewTree(map[string]repository.TreeChild{})
 
 // CommentThread represents the tree-based hierarchy of comments.
@@ -51,6 +105,30 @@ type CommentThread struct {
 	Children []CommentThread    `json:"children,omitempty"`
 	Resolved *bool              `json:"resolved,omitempty"`
 	Edited   bool               `json:"edited,omitempty"`
+	// Orphaned is set for threads whose comment is a reply to (or an edit
+	// of) a comment that could not be found, such as one whose hash was
+	// computed differently by another tool. Rather than being dropped,
+	// such threads are attached at the root.
+	Orphaned bool `json:"orphaned,omitempty"`
+	// Cyclic is set for threads whose comment was detached from its parent
+	// (or original) to break a cycle of comments that reply to (or edit)
+	// each other.
+	Cyclic bool `json:"cyclic,omitempty"`
+	// ChildrenTruncated is the number of replies that were left out of
+	// the thread by TruncateThreads.
+	ChildrenTruncated int `json:"children_truncated,omitempty"`
+	// Permalink and URL are the stable links to the thread's comment,
+	// which are filled in by Permalinker.AddPermalinks.
+	Permalink string `json:"permalink,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// detached returns whether the thread was attached at the root because its
+// parent could not be found, or to break a cycle. Such threads are kept so
+// that they can be read, but since it is not known what they replied to,
+// they do not count towards the status of the review.
+func (thread *CommentThread) detached() bool {
+	return thread.Orphaned || thread.Cyclic
 }
 
 // Summary represents the high-level state of a code review.
@@ -71,6 +149,19 @@ type Summary struct {
 	Comments    []CommentThread   `json:"comments,omitempty"`
 	Resolved    *bool             `json:"resolved,omitempty"`
 	Submitted   bool              `json:"submitted"`
+	// Missing indicates that the reviewed commit has not been fetched, so
+	// only the review's notes are available.
+	Missing bool `json:"missing,omitempty"`
+	// Branch is the short name of the review ref, and Fork is the name of
+	// the fork that it was fetched from, if any.
+	Branch string `json:"branch,omitempty"`
+	Fork   string `json:"fork,omitempty"`
+	// Claims are the claims of reviewers that they are reviewing the
+	// change, including those that have expired or been released.
+	Claims []claim.Claim `json:"claims,omitempty"`
+	// CommentsTruncated is the number of top-level comment threads that
+	// were left out of Comments by TruncateThreads.
+	CommentsTruncated int `json:"comments_truncated,omitempty"`
 }
 
 // Review represents the entire state of a code review.
@@ -81,8 +172,11 @@ type Summary struct {
 // reviews), or to the last commented-upon commit (for submitted reviews).
 type Review struct {
 	*Summary
-	Reports  []ci.Report       `json:"reports,omitempty"`
-	Analyses []analyses.Report `json:"analyses,omitempty"`
+	Reports     []ci.Report             `json:"reports,omitempty"`
+	Analyses    []analyses.Report       `json:"analyses,omitempty"`
+	Benchmarks  []benchmark.Report      `json:"benchmarks,omitempty"`
+	Submissions []submission.Submission `json:"submissions,omitempty"`
+	Provenance  []provenance.Record     `json:"provenance,omitempty"`
 }
 
 type commentsByTimestamp []*comment.Comment
@@ -91,7 +185,7 @@ type commentsByTimestamp []*comment.Comment
 func (cs commentsByTimestamp) Len() int      { return len(cs) }
 func (cs commentsByTimestamp) Swap(i, j int) { cs[i], cs[j] = cs[j], cs[i] }
 func (cs commentsByTimestamp) Less(i, j int) bool {
-	return cs[i].Timestamp < cs[j].Timestamp
+	return cs[i].Time().Before(cs[j].Time())
 }
 
 type byTimestamp []CommentThread
@@ -100,7 +194,7 @@ type byTimestamp []CommentThread
 func (threads byTimestamp) Len() int      { return len(threads) }
 func (threads byTimestamp) Swap(i, j int) { threads[i], threads[j] = threads[j], threads[i] }
 func (threads byTimestamp) Less(i, j int) bool {
-	return threads[i].Comment.Timestamp < threads[j].Comment.Timestamp
+	return threads[i].Comment.Time().Before(threads[j].Comment.Time())
 }
 
 type requestsByTimestamp []request.Request
@@ -111,7 +205,7 @@ func (requests requestsByTimestamp) Swap(i, j int) {
 	requests[i], requests[j] = requests[j], requests[i]
 }
 func (requests requestsByTimestamp) Less(i, j int) bool {
-	return requests[i].Timestamp < requests[j].Timestamp
+	return requests[i].Time().Before(requests[j].Time())
 }
 
 type summariesWithNewestRequestsFirst []Summary
@@ -122,7 +216,7 @@ func (summaries summariesWithNewestRequestsFirst) Swap(i, j int) {
 	summaries[i], summaries[j] = summaries[j], summaries[i]
 }
 func (summaries summariesWithNewestRequestsFirst) Less(i, j int) bool {
-	return summaries[i].Request.Timestamp > summaries[j].Request.Timestamp
+	return summaries[i].Request.Time().After(summaries[j].Request.Time())
 }
 
 // updateThreadsStatus calculates the aggregate status of a sequence of comment threads.
@@ -137,7 +231,7 @@ func updateThreadsStatus(threads []CommentThread) *bool {
 	for i := range threads {
 		thread := &threads[i]
 		thread.updateResolvedStatus()
-		if thread.Resolved != nil {
+		if thread.Resolved != nil && !thread.detached() {
 			noUnresolved = noUnresolved && *thread.Resolved
 			result = &noUnresolved
 		}
@@ -148,9 +242,10 @@ func updateThreadsStatus(threads []CommentThread) *bool {
 // updateResolvedStatus calculates the aggregate status of a single comment thread,
 // and updates the "Resolved" field of that thread accordingly.
 func (thread *CommentThread) updateResolvedStatus() {
+	status := thread.Comment.Status()
 	resolved := updateThreadsStatus(thread.Children)
 	if resolved == nil {
-		thread.Resolved = thread.Comment.Resolved
+		thread.Resolved = status
 		return
 	}
 
@@ -159,7 +254,7 @@ func (thread *CommentThread) updateResolvedStatus() {
 		return
 	}
 
-	if thread.Comment.Resolved == nil || !*thread.Comment.Resolved {
+	if status == nil || !*status {
 		thread.Resolved = nil
 		return
 	}
@@ -167,6 +262,33 @@ func (thread *CommentThread) updateResolvedStatus() {
 	thread.Resolved = resolved
 }
 
+// unresolvedThreads appends every (sub)thread of the given thread whose own
+// comment needs more work and has not been addressed by a reply.
+func (thread *CommentThread) unresolvedThreads(unresolved []CommentThread) []CommentThread {
+	if status := thread.Comment.Status(); status != nil && !*status &&
+		thread.Resolved != nil && !*thread.Resolved {
+		unresolved = append(unresolved, *thread)
+	}
+	for i := range thread.Children {
+		unresolved = thread.Children[i].unresolvedThreads(unresolved)
+	}
+	return unresolved
+}
+
+// HasHash reports whether the given hash identifies the comment that started
+// the thread, either as its current hash or its legacy hash.
+func (thread *CommentThread) HasHash(hash string) bool {
+	if thread.Hash == hash {
+		return true
+	}
+	original := &thread.Comment
+	if thread.Original != nil {
+		original = thread.Original
+	}
+	legacyHash, err := original.LegacyHash()
+	return err == nil && legacyHash == hash
+}
+
 // Verify verifies the signature on a comment.
 func (thread *CommentThread) Verify() error {
 	err := gpg.Verify(&thread.Comment)
@@ -183,6 +305,23 @@ func (thread *CommentThread) Verify() error {
 	return nil
 }
 
+// verifySigned verifies that a comment thread, and all of its replies, are
+// signed and that those signatures are verifiable.
+func (thread *CommentThread) verifySigned() error {
+	if thread.Comment.Sig.Sig == "" {
+		return fmt.Errorf("comment [%s] is not signed", thread.Hash)
+	}
+	if err := gpg.Verify(&thread.Comment); err != nil {
+		return fmt.Errorf("verification of comment [%s] failed: %s", thread.Hash, err)
+	}
+	for i := range thread.Children {
+		if err := thread.Children[i].verifySigned(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // mutableThread is an internal-only data structure used to store partially constructed comment threads.
 type mutableThread struct {
 	Hash     string
@@ -212,7 +351,7 @@ func fixMutableThread(mutableThread *mutableThread) CommentThread {
 
 	return CommentThread{
 		Hash:     mutableThread.Hash,
-		Comment:  *comment,
+		Comment:  comment.WithNormalizedRange(),
 		Original: &mutableThread.Comment,
 		Edits:    mutableThread.Edits,
 		Children: children,
@@ -226,6 +365,9 @@ func fixMutableThread(mutableThread *mutableThread) CommentThread {
 // data structure, and then converts it to the proper CommentThread structure at the end.
 func buildCommentThreads(commentsByHash map[string]comment.Comment) []CommentThread {
 	threadsByHash := make(map[string]*mutableThread)
+	// Comments written before hashes were canonicalized refer to other
+	// comments by their legacy hashes, so those are resolved too.
+	hashesByLegacyHash := make(map[string]string)
 	for hash, comment := range commentsByHash {
 		thread, ok := threadsByHash[hash]
 		if !ok {
@@ -235,20 +377,39 @@ func buildCommentThreads(commentsByHash map[string]comment.Comment) []CommentThr
 			}
 			threadsByHash[hash] = thread
 		}
+		if legacyHash, err := comment.LegacyHash(); err == nil {
+			hashesByLegacyHash[legacyHash] = hash
+		}
+	}
+	lookup := func(hash string) (*mutableThread, bool) {
+		if thread, ok := threadsByHash[hash]; ok {
+			return thread, true
+		}
+		thread, ok := threadsByHash[hashesByLegacyHash[hash]]
+		return thread, ok
 	}
+	cyclic := findCommentCycles(threadsByHash, lookup)
 	var rootHashes []string
+	var orphanHashes []string
+	var cyclicHashes []string
 	for hash, thread := range threadsByHash {
-		if thread.Comment.Original != "" {
-			original, ok := threadsByHash[thread.Comment.Original]
+		if cyclic[hash] {
+			cyclicHashes = append(cyclicHashes, hash)
+		} else if thread.Comment.Original != "" {
+			original, ok := lookup(thread.Comment.Original)
 			if ok {
 				original.Edits = append(original.Edits, &thread.Comment)
+			} else {
+				orphanHashes = append(orphanHashes, hash)
 			}
 		} else if thread.Comment.Parent == "" {
 			rootHashes = append(rootHashes, hash)
 		} else {
-			parent, ok := threadsByHash[thread.Comment.Parent]
+			parent, ok := lookup(thread.Comment.Parent)
 			if ok {
 				parent.Children = append(parent.Children, thread)
+			} else {
+				orphanHashes = append(orphanHashes, hash)
 			}
 		}
 	}
@@ -256,9 +417,103 @@ func buildCommentThreads(commentsByHash map[string]comment.Comment) []CommentThr
 	for _, hash := range rootHashes {
 		threads = append(threads, fixMutableThread(threadsByHash[hash]))
 	}
+	for _, hash := range orphanHashes {
+		thread := fixMutableThread(threadsByHash[hash])
+		thread.Orphaned = true
+		threads = append(threads, thread)
+	}
+	for _, hash := range cyclicHashes {
+		thread := fixMutableThread(threadsByHash[hash])
+		thread.Cyclic = true
+		threads = append(threads, thread)
+	}
 	return threads
 }
 
+// findCommentCycles finds the cycles of comments that reply to (or edit)
+// each other, and returns the hashes of the comments to detach from their
+// parents (or originals) in order to break them.
+//
+// Each cycle is broken at the comment with the smallest hash, so that the
+// result does not depend on the order in which the comments were read.
+func findCommentCycles(threadsByHash map[string]*mutableThread, lookup func(string) (*mutableThread, bool)) map[string]bool {
+	next := func(hash string) (string, bool) {
+		c := threadsByHash[hash].Comment
+		link := c.Original
+		if link == "" {
+			link = c.Parent
+		}
+		if link == "" {
+			return "", false
+		}
+		target, ok := lookup(link)
+		if !ok {
+			return "", false
+		}
+		return target.Hash, true
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	broken := make(map[string]bool)
+	for start := range threadsByHash {
+		var path []string
+		hash, ok := start, true
+		for ok && state[hash] == unvisited {
+			state[hash] = visiting
+			path = append(path, hash)
+			hash, ok = next(hash)
+		}
+		if ok && state[hash] == visiting {
+			// The path loops back to a comment on it, so the comments
+			// from that one to the end of the path form a cycle.
+			i := len(path) - 1
+			for path[i] != hash {
+				i--
+			}
+			cycle := path[i:]
+			smallest := cycle[0]
+			for _, member := range cycle {
+				if member < smallest {
+					smallest = member
+				}
+			}
+			broken[smallest] = true
+		}
+		for _, member := range path {
+			state[member] = visited
+		}
+	}
+	return broken
+}
+
+// Cycles returns the comment threads of the review that were detached from
+// their parent comment (or, for edits, the original comment) to break a cycle.
+func (r *Summary) Cycles() []CommentThread {
+	var cycles []CommentThread
+	for _, thread := range r.Comments {
+		if thread.Cyclic {
+			cycles = append(cycles, thread)
+		}
+	}
+	return cycles
+}
+
+// Orphans returns the comment threads of the review whose parent comment
+// (or, for edits, the original comment) could not be found.
+func (r *Summary) Orphans() []CommentThread {
+	var orphans []CommentThread
+	for _, thread := range r.Comments {
+		if thread.Orphaned {
+			orphans = append(orphans, thread)
+		}
+	}
+	return orphans
+}
+
 // getCommentsFromNotes parses the log-structured sequence of comments for a commit,
 // and then builds the corresponding tree-structured comment threads.
 func getCommentsFromNotes(repo repository.Repo, revision string, commentNotes []repository.Note) ([]CommentThread, *bool) {
@@ -280,12 +535,35 @@ func getSummaryFromNotes(repo repository.Repo, revision string, requestNotes, co
 		Request:     requests[len(requests)-1],
 		AllRequests: requests,
 	}
+	reviewSummary.Fork, reviewSummary.Branch = splitReviewRef(reviewSummary.Request.ReviewRef)
 	comments, resolved := getCommentsFromNotes(repo, revision, commentNotes)
 	reviewSummary.Comments = comments
 	reviewSummary.Resolved = resolved
 	return &reviewSummary, nil
 }
 
+// splitReviewRef returns the name of the fork that the given review ref was
+// fetched from, if any, and the short name of the branch.
+//
+// The refs of forks are named refs/forks/<fork>/<branch>, where the names of
+// the forks registered by `request --from-remote` are URLForkName followed by
+// a hash, and those of other forks have no slashes, so the branch is
+// everything after the fork's name.
+func splitReviewRef(reviewRef string) (string, string) {
+	if !strings.HasPrefix(reviewRef, ForkRefPrefix) {
+		return "", strings.TrimPrefix(strings.TrimPrefix(reviewRef, "refs/heads/"), "refs/")
+	}
+	rest := strings.TrimPrefix(reviewRef, ForkRefPrefix)
+	offset := 0
+	if strings.HasPrefix(rest, URLForkName) {
+		offset = len(URLForkName)
+	}
+	if i := strings.Index(rest[offset:], "/"); i > 0 {
+		return rest[:offset+i], rest[offset+i+1:]
+	}
+	return "", rest
+}
+
 func GetComments(repo repository.Repo, revision string) ([]CommentThread, error) {
 	commentNotes := repo.GetNotes(comment.Ref, revision)
 	c, _ := getCommentsFromNotes(repo, revision, commentNotes)
@@ -300,18 +578,25 @@ func GetSummaryViaRefs(repo repository.Repo, requestRef, commentRef, revision st
 	if err := repo.VerifyCommit(revision); err != nil {
 		return nil, fmt.Errorf("Could not find a commit named %q", revision)
 	}
+	// Use the full hash, even if the revision was given abbreviated.
+	if hash, err := repo.GetCommitHash(revision); err == nil {
+		revision = hash
+	}
 	requestNotes := repo.GetNotes(requestRef, revision)
 	commentNotes := repo.GetNotes(commentRef, revision)
 	summary, err := getSummaryFromNotes(repo, revision, requestNotes, commentNotes)
 	if err != nil {
 		return nil, err
 	}
+	summary.Claims = claim.ParseAllValid(repo.GetNotes(claim.Ref, revision))
 	currentCommit := revision
 	if summary.Request.Alias != "" {
 		currentCommit = summary.Request.Alias
 	}
 
-	if !summary.IsAbandoned() {
+	if summary.Request.TargetTag != "" {
+		summary.Submitted = isTagSubmitted(repo, summary.Request.TargetTag, currentCommit)
+	} else if !summary.IsAbandoned() {
 		submitted, err := repo.IsAncestor(currentCommit, summary.Request.TargetRef)
 		if err != nil {
 			return nil, err
@@ -325,18 +610,24 @@ func GetSummaryViaRefs(repo repository.Repo, requestRef, commentRef, revision st
 //
 // If no review request exists, the returned review summary is nil.
 func GetSummary(repo repository.Repo, revision string) (*Summary, error) {
-	return GetSummaryViaRefs(repo, request.Ref, comment.Ref, revision)
+	span := tracing.Start("review.get", tracing.Attribute{Key: "appraise.revision", Value: revision})
+	summary, err := GetSummaryViaRefs(repo, request.Ref, comment.Ref, revision)
+	span.End(err)
+	return summary, err
 }
 
 // Details returns the detailed review for the given summary.
 func (r *Summary) Details() (*Review, error) {
 	review := Review{
-		Summary: r,
+		Summary:     r,
+		Submissions: submission.ParseAllValid(r.Repo.GetNotes(submission.Ref, r.Revision)),
+		Provenance:  provenance.ParseAllValid(r.Repo.GetNotes(provenance.Ref, r.Revision)),
 	}
 	currentCommit, err := review.GetHeadCommit()
 	if err == nil {
 		review.Reports = ci.ParseAllValid(review.Repo.GetNotes(ci.Ref, currentCommit))
 		review.Analyses = analyses.ParseAllValid(review.Repo.GetNotes(analyses.Ref, currentCommit))
+		review.Benchmarks = benchmark.ParseAllValid(review.Repo.GetNotes(benchmark.Ref, currentCommit))
 	}
 	return &review, nil
 }
@@ -351,6 +642,173 @@ func (r *Summary) IsOpen() bool {
 	return !r.Submitted && !r.IsAbandoned()
 }
 
+// StatusString returns a human friendly string encapsulating both the review's
+// resolved status, and its submitted status, as shown by the list command.
+func (r *Summary) StatusString() string {
+	if r.Missing {
+		return "unfetched"
+	}
+	if r.IsDraft() {
+		return "wip"
+	}
+	if r.Resolved == nil && r.Submitted {
+		return "tbr"
+	}
+	if r.Resolved == nil {
+		return "pending"
+	}
+	if *r.Resolved && r.Submitted {
+		return "submitted"
+	}
+	if *r.Resolved {
+		return "accepted"
+	}
+	if r.Submitted {
+		return "danger"
+	}
+	if r.Request.TargetRef == "" {
+		return "abandon"
+	}
+	return "rejected"
+}
+
+// IsDraft returns whether the review is open, but its requester has marked
+// it as a work in progress that is not yet ready to be reviewed.
+func (r *Summary) IsDraft() bool {
+	return r.IsOpen() && r.Request.WIP
+}
+
+// TeamApproval is the acceptance of a change on behalf of a reviewer team.
+type TeamApproval struct {
+	// Reviewer is who accepted the change, or empty if no one has yet.
+	Reviewer string
+	// Delegated is whether the reviewer accepted on behalf of the team as a
+	// delegate, rather than personally as one of its members.
+	Delegated bool
+}
+
+// CheckDelegation returns an error unless the given user can accept the
+// change on behalf of the named team, as defined in the given teams.
+//
+// The team must have been asked to review the change, and the user must be
+// one of its leads.
+func (r *Summary) CheckDelegation(definedTeams map[string][]string, team, user string) error {
+	if _, ok := r.Request.ReviewerTeams[team]; !ok {
+		return fmt.Errorf("The team %q was not asked to review the change.", team)
+	}
+	if !teams.IsLead(definedTeams, team, user) {
+		return fmt.Errorf("%s is not a lead of the team %q, so can not accept on its behalf.", user, team)
+	}
+	return nil
+}
+
+// TeamAcceptance returns, for every team asked to review the change, the
+// first acceptance that satisfies that team: either by a member of the team,
+// or by a delegate on its behalf. Teams that no one has accepted for yet map
+// to the zero TeamApproval.
+//
+// Delegated acceptances only count if they were written by their actor, and
+// that actor is a lead of the team as defined at the change's target ref.
+func (r *Summary) TeamAcceptance() map[string]TeamApproval {
+	if len(r.Request.ReviewerTeams) == 0 {
+		return nil
+	}
+	var definedTeams map[string][]string
+	isDelegate := func(c comment.Comment) bool {
+		if c.Delegation.Actor != c.Author {
+			return false
+		}
+		if definedTeams == nil {
+			// A missing or invalid teams file defines no leads.
+			definedTeams, _ = teams.Load(r.Repo, r.Request.TargetRef)
+			if definedTeams == nil {
+				definedTeams = map[string][]string{}
+			}
+		}
+		return r.CheckDelegation(definedTeams, c.Delegation.Team, c.Author) == nil
+	}
+	accepted := r.acceptances()
+	acceptance := make(map[string]TeamApproval)
+	for team, members := range r.Request.ReviewerTeams {
+		acceptance[team] = TeamApproval{}
+		for _, c := range accepted {
+			if c.Delegation != nil {
+				if c.Delegation.Team == team && isDelegate(c) {
+					acceptance[team] = TeamApproval{Reviewer: c.Author, Delegated: true}
+					break
+				}
+			} else if containsString(members, c.Author) {
+				acceptance[team] = TeamApproval{Reviewer: c.Author}
+				break
+			}
+		}
+	}
+	return acceptance
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveClaims returns the claims of the reviewers who are still reviewing
+// the change at the given time.
+func (r *Summary) ActiveClaims(now time.Time) []claim.Claim {
+	if !r.IsOpen() {
+		return nil
+	}
+	return claim.Active(r.Claims, now)
+}
+
+// IsOverdue returns whether or not the given review is still open after its deadline.
+func (r *Summary) IsOverdue(now time.Time) bool {
+	return r.IsOpen() && r.Request.DueBy != "" && now.After(r.Request.DueTime())
+}
+
+// acceptances returns the top-level comments that accepted the review
+// without any subsequent unresolved replies, in chronological order.
+func (r *Summary) acceptances() []comment.Comment {
+	var accepted []comment.Comment
+	for _, thread := range r.Comments {
+		if thread.detached() || thread.Comment.Resolved == nil || !*thread.Comment.Resolved {
+			continue
+		}
+		if thread.Resolved == nil || !*thread.Resolved {
+			continue
+		}
+		accepted = append(accepted, thread.Comment)
+	}
+	return accepted
+}
+
+// AcceptedBy returns the authors of the top-level comments that accepted the
+// review without any subsequent unresolved replies, in chronological order.
+func (r *Summary) AcceptedBy() []string {
+	var authors []string
+	seen := make(map[string]bool)
+	for _, c := range r.acceptances() {
+		if author := c.Author; author != "" && !seen[author] {
+			seen[author] = true
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
+
+// UnresolvedThreads returns every comment thread in the review, at any depth,
+// that asked for more work and has not since been addressed by a reply.
+func (r *Summary) UnresolvedThreads() []CommentThread {
+	var unresolved []CommentThread
+	for i := range r.Comments {
+		unresolved = r.Comments[i].unresolvedThreads(unresolved)
+	}
+	return unresolved
+}
+
 // Verify returns whether or not a summary's comments are a) signed, and b)
 /// that those signatures are verifiable.
 func (r *Summary) Verify() error {
@@ -368,6 +826,85 @@ func (r *Summary) Verify() error {
 	return nil
 }
 
+// VerifyAcceptance verifies that the review request, and every comment
+// thread that contributed to the review being accepted, are a) signed, and
+// b) that those signatures are verifiable.
+//
+// Unlike Verify, this treats unsigned content as a verification failure.
+func (r *Summary) VerifyAcceptance() error {
+	if r.Request.Sig.Sig == "" {
+		return fmt.Errorf("the request targeting %q is not signed", r.Request.TargetRef)
+	}
+	if err := gpg.Verify(&r.Request); err != nil {
+		return fmt.Errorf("couldn't verify request targeting: %q: %s",
+			r.Request.TargetRef, err)
+	}
+	for i := range r.Comments {
+		thread := &r.Comments[i]
+		if thread.detached() || thread.Comment.Resolved == nil || !*thread.Comment.Resolved {
+			continue
+		}
+		if err := thread.verifySigned(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsAcceptedAt returns whether or not one of the comments that accepted the
+// review was made against the given commit.
+func (r *Summary) IsAcceptedAt(commit string) bool {
+	return r.isAcceptedCommit(commit)
+}
+
+// isAcceptedCommit returns whether or not one of the comments that accepted
+// the review was made against the given commit.
+func (r *Summary) isAcceptedCommit(commit string) bool {
+	for _, thread := range r.Comments {
+		c := thread.Comment
+		if !thread.detached() && c.Resolved != nil && *c.Resolved && c.Location != nil && c.Location.Commit == commit {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySubmissions verifies every submission record for the review.
+//
+// This checks that each record is signed with a verifiable signature, that
+// the accepted commit it records was actually accepted, and that the commit
+// it records as submitted contains the submitted source. For merges and
+// fast-forwards, the submitted source must also be the accepted commit.
+func (r *Review) VerifySubmissions() error {
+	if len(r.Submissions) == 0 {
+		return fmt.Errorf("the review has no submission records")
+	}
+	for i := range r.Submissions {
+		s := &r.Submissions[i]
+		if s.Sig.Sig == "" {
+			return fmt.Errorf("the submission to %q is not signed", s.TargetRef)
+		}
+		if err := gpg.Verify(s); err != nil {
+			return fmt.Errorf("couldn't verify the submission to %q: %s", s.TargetRef, err)
+		}
+		if !r.isAcceptedCommit(s.Accepted) {
+			return fmt.Errorf("the submitted commit %.12s was never accepted", s.Accepted)
+		}
+		if s.Strategy != submission.StrategyRebase && s.Source != s.Accepted {
+			return fmt.Errorf("the %s submission of %.12s does not match the accepted commit %.12s",
+				s.Strategy, s.Source, s.Accepted)
+		}
+		contained, err := r.Repo.IsAncestor(s.Source, s.Submitted)
+		if err != nil {
+			return err
+		}
+		if !contained {
+			return fmt.Errorf("the submitted commit %.12s does not contain %.12s", s.Submitted, s.Source)
+		}
+	}
+	return nil
+}
+
 // Get returns the specified code review.
 //
 // If no review request exists, the returned review is nil.
@@ -382,6 +919,13 @@ func Get(repo repository.Repo, revision string) (*Review, error) {
 	return summary.Details()
 }
 
+// isTagSubmitted reports whether the tag requested by a release review has
+// been created at the reviewed commit.
+func isTagSubmitted(repo repository.Repo, tag, commit string) bool {
+	tagCommit, err := repo.GetCommitHash("refs/tags/" + tag)
+	return err == nil && tagCommit == commit
+}
+
 func getIsSubmittedCheck(repo repository.Repo) func(ref, commit string) bool {
 	refCommitsMap := make(map[string]map[string]bool)
 
@@ -404,28 +948,165 @@ func getIsSubmittedCheck(repo repository.Repo) func(ref, commit string) bool {
 }
 
 func unsortedListAll(repo repository.Repo) []Summary {
-	reviewNotesMap, err := repo.GetAllNotes(request.Ref)
-	if err != nil {
+	var reviews []Summary
+	EachSummary(repo, func(summary Summary) error {
+		reviews = append(reviews, summary)
 		return nil
+	})
+	return reviews
+}
+
+// EachSummary calls the given function with the summary of every review, as
+// soon as it has been read, rather than after all of them have been read.
+//
+// The reviews are visited in no particular order. If the function returns an
+// error, then no more reviews are visited, and that error is returned.
+func EachSummary(repo repository.Repo, visit func(Summary) error) error {
+	span := tracing.Start("review.list")
+	err := eachSummary(repo, visit)
+	span.End(err)
+	return err
+}
+
+func eachSummary(repo repository.Repo, visit func(Summary) error) error {
+	isSubmittedCheck := getIsSubmittedCheck(repo)
+	listed := make(map[string]bool)
+	err := repo.EachNotes([]string{request.Ref, comment.Ref, claim.Ref}, func(commit string, notes [][]repository.Note) error {
+		listed[commit] = true
+		summary, err := getSummaryFromNotes(repo, commit, notes[0], notes[1])
+		if err != nil {
+			return nil
+		}
+		summary.Claims = claim.ParseAllValid(notes[2])
+		if summary.Request.TargetTag != "" {
+			summary.Submitted = isTagSubmitted(repo, summary.Request.TargetTag, summary.getStartingCommit())
+		} else if !summary.IsAbandoned() {
+			summary.Submitted = isSubmittedCheck(summary.Request.TargetRef, summary.getStartingCommit())
+		}
+		return visit(*summary)
+	})
+	if err != nil {
+		return err
 	}
-	discussNotesMap, err := repo.GetAllNotes(comment.Ref)
+	return eachMissing(repo, listed, visit)
+}
+
+// eachMissing calls the given function with the reviews of commits that are
+// missing from the repo, given the commits whose reviews were already listed.
+//
+// EachNotes only visits the notes on commits, so the notes on objects that
+// have not been fetched yet have to be read one at a time. Submission cannot be
+// checked without the commit, so these reviews are reported as unsubmitted.
+func eachMissing(repo repository.Repo, listed map[string]bool, visit func(Summary) error) error {
+	objects, err := repo.ListNotedObjects(request.Ref)
 	if err != nil {
 		return nil
 	}
-
-	isSubmittedCheck := getIsSubmittedCheck(repo)
-	var reviews []Summary
-	for commit, notes := range reviewNotesMap {
-		summary, err := getSummaryFromNotes(repo, commit, notes, discussNotesMap[commit])
+	for _, object := range objects {
+		if listed[object] {
+			continue
+		}
+		if exists, err := repo.HasObject(object); err != nil || exists {
+			continue
+		}
+		summary, err := getSummaryFromNotes(repo, object, repo.GetNotes(request.Ref, object), repo.GetNotes(comment.Ref, object))
 		if err != nil {
 			continue
 		}
-		if !summary.IsAbandoned() {
-			summary.Submitted = isSubmittedCheck(summary.Request.TargetRef, summary.getStartingCommit())
+		summary.Missing = true
+		if err := visit(*summary); err != nil {
+			return err
 		}
-		reviews = append(reviews, *summary)
 	}
-	return reviews
+	return nil
+}
+
+// Skip describes a review request note that is skipped when listing the
+// reviews, and why, so that problems with the data are not silently hidden.
+type Skip struct {
+	Revision string
+	// Note is the skipped note, unless the skip applies to all of the revision's notes.
+	Note   repository.Note
+	Reason string
+}
+
+// ListSkipped returns the review request notes that ListAll skips.
+//
+// Reviews of commits that have not been fetched are listed rather than
+// skipped, but their notes are still checked.
+func ListSkipped(repo repository.Repo) ([]Skip, error) {
+	reviewNotesMap, err := repo.GetAllNotes(request.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the notes in %s, so no reviews can be listed: %v", request.Ref, err)
+	}
+	if _, err := repo.GetAllNotes(comment.Ref); err != nil {
+		return nil, fmt.Errorf("failed to read the notes in %s, so no reviews can be listed: %v", comment.Ref, err)
+	}
+	objects, err := repo.ListNotedObjects(request.Ref)
+	if err != nil {
+		return nil, err
+	}
+	var skips []Skip
+	for _, object := range objects {
+		notes, ok := reviewNotesMap[object]
+		if !ok {
+			if exists, err := repo.HasObject(object); err != nil || exists {
+				skips = append(skips, Skip{Revision: object, Reason: "the annotated object is not a commit"})
+				continue
+			}
+			notes = repo.GetNotes(request.Ref, object)
+		}
+		valid := 0
+		for _, note := range notes {
+			if len(bytes.TrimSpace(note)) == 0 {
+				continue
+			}
+			r, err := request.Parse(note)
+			if err != nil {
+				skips = append(skips, Skip{Revision: object, Note: note, Reason: fmt.Sprintf("the note is not a valid request: %v", err)})
+			} else if r.Version != request.FormatVersion {
+				skips = append(skips, Skip{Revision: object, Note: note, Reason: fmt.Sprintf("the request has the unsupported format version %d", r.Version)})
+			} else {
+				valid++
+			}
+		}
+		if valid == 0 {
+			skips = append(skips, Skip{Revision: object, Reason: "the commit has no valid review requests"})
+		}
+	}
+	return skips, nil
+}
+
+// CountInvalidTimestamps returns the number of review requests and comments
+// with timestamps that are not a number of seconds since the Unix epoch.
+//
+// Those notes are still read, as older tools may have written them, but they
+// sort before every other note, so this allows them to be reported.
+func CountInvalidTimestamps(repo repository.Repo) (int, error) {
+	requestNotes, err := repo.GetAllNotes(request.Ref)
+	if err != nil {
+		return 0, err
+	}
+	commentNotes, err := repo.GetAllNotes(comment.Ref)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, notes := range requestNotes {
+		for _, r := range request.ParseAllValid(notes) {
+			if r.CheckTimestamps() != nil {
+				count++
+			}
+		}
+	}
+	for _, notes := range commentNotes {
+		for _, c := range comment.ParseAllValid(notes) {
+			if c.CheckTimestamp() != nil {
+				count++
+			}
+		}
+	}
+	return count, nil
 }
 
 // ListAll returns all reviews stored in the git-notes.
@@ -451,10 +1132,40 @@ func ListOpen(repo repository.Repo) []Summary {
 //
 // If there are multiple matching reviews, then an error is returned.
 func GetCurrent(repo repository.Repo) (*Review, error) {
+	summary, err := GetCurrentSummary(repo)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	return summary.Details()
+}
+
+// GetCurrentSummary returns the summary of the current, open code review,
+// without loading the rest of its details.
+//
+// If there are multiple matching reviews, then an error is returned.
+func GetCurrentSummary(repo repository.Repo) (*Summary, error) {
 	reviewRef, err := repo.GetHeadRef()
 	if err != nil {
 		return nil, err
 	}
+	return getSummaryByReviewRef(repo, reviewRef)
+}
+
+// GetByReviewRef returns the open code review of the given review ref, or nil
+// if there is none.
+//
+// If there are multiple matching reviews, then an error is returned.
+func GetByReviewRef(repo repository.Repo, reviewRef string) (*Review, error) {
+	summary, err := getSummaryByReviewRef(repo, reviewRef)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	return summary.Details()
+}
+
+// getSummaryByReviewRef returns the summary of the open code review of the
+// given review ref, or nil if there is none.
+func getSummaryByReviewRef(repo repository.Repo, reviewRef string) (*Summary, error) {
 	var matchingReviews []Summary
 	for _, review := range ListOpen(repo) {
 		if review.Request.ReviewRef == reviewRef {
@@ -467,7 +1178,7 @@ func GetCurrent(repo repository.Repo) (*Review, error) {
 	if len(matchingReviews) != 1 {
 		return nil, fmt.Errorf("There are %d open reviews for the ref \"%s\"", len(matchingReviews), reviewRef)
 	}
-	return matchingReviews[0].Details()
+	return &matchingReviews[0], nil
 }
 
 // GetBuildStatusMessage returns a string of the current build-and-test status
@@ -484,6 +1195,89 @@ func (r *Review) GetBuildStatusMessage() string {
 	return statusMessage
 }
 
+// GetBenchmarkThreshold returns the largest regression of a benchmark
+// metric that passes, as configured by the BenchmarkThresholdConfig key.
+func GetBenchmarkThreshold(repo repository.Repo) (float64, error) {
+	configured, err := config.Get(repo, BenchmarkThresholdConfig)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(configured) == "" {
+		return benchmark.DefaultThreshold, nil
+	}
+	threshold, err := config.ParseFraction(configured)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %q", BenchmarkThresholdConfig, configured)
+	}
+	return threshold, nil
+}
+
+// GetLatestBenchmarks returns the latest benchmark report of each agent.
+func (r *Review) GetLatestBenchmarks() ([]benchmark.Report, error) {
+	return benchmark.GetLatestReports(r.Benchmarks)
+}
+
+// GetBenchmarkFailures returns the results in the latest benchmark report
+// of each agent that regressed by more than the configured threshold.
+func (r *Review) GetBenchmarkFailures() ([]benchmark.Result, error) {
+	threshold, err := GetBenchmarkThreshold(r.Repo)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := r.GetLatestBenchmarks()
+	if err != nil {
+		return nil, err
+	}
+	var failures []benchmark.Result
+	for _, report := range reports {
+		failures = append(failures, report.Failures(threshold)...)
+	}
+	return failures, nil
+}
+
+// GetAnalysesFetcher returns a fetcher for the details of analysis reports,
+// configured by the AnalysesTimeoutConfig, AnalysesRetriesConfig, and
+// AnalysesMaxSizeConfig keys, that caches the details of the most recently
+// used reports in the git directory.
+func GetAnalysesFetcher(repo repository.Repo) (*analyses.Fetcher, error) {
+	fetcher := &analyses.Fetcher{
+		Client:          &http.Client{Timeout: analyses.DefaultTimeout},
+		Retries:         analyses.DefaultRetries,
+		RetryDelay:      500 * time.Millisecond,
+		MaxSize:         analyses.DefaultMaxSize,
+		CacheDir:        filepath.Join(repo.GetGitDir(), analysesCacheDirname),
+		MaxCacheEntries: analyses.DefaultMaxCacheEntries,
+	}
+	if configured, err := config.Get(repo, AnalysesTimeoutConfig); err != nil {
+		return nil, err
+	} else if strings.TrimSpace(configured) != "" {
+		timeout, err := time.ParseDuration(strings.TrimSpace(configured))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid value for %s: %q", AnalysesTimeoutConfig, configured)
+		}
+		fetcher.Client.Timeout = timeout
+	}
+	if configured, err := config.Get(repo, AnalysesRetriesConfig); err != nil {
+		return nil, err
+	} else if strings.TrimSpace(configured) != "" {
+		retries, err := strconv.Atoi(strings.TrimSpace(configured))
+		if err != nil || retries < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %q", AnalysesRetriesConfig, configured)
+		}
+		fetcher.Retries = retries
+	}
+	if configured, err := config.Get(repo, AnalysesMaxSizeConfig); err != nil {
+		return nil, err
+	} else if strings.TrimSpace(configured) != "" {
+		size, err := config.ParseSize(configured)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q", AnalysesMaxSizeConfig, configured)
+		}
+		fetcher.MaxSize = int64(size)
+	}
+	return fetcher, nil
+}
+
 // GetAnalysesNotes returns all of the notes from the most recent static
 // analysis run recorded in the git notes.
 func (r *Review) GetAnalysesNotes() ([]analyses.Note, error) {
@@ -494,7 +1288,11 @@ func (r *Review) GetAnalysesNotes() ([]analyses.Note, error) {
 	if latestAnalyses == nil {
 		return nil, fmt.Errorf("No analyses available")
 	}
-	return latestAnalyses.GetNotes()
+	fetcher, err := GetAnalysesFetcher(r.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return fetcher.Notes(*latestAnalyses)
 }
 
 // GetAnalysesMessage returns a string summarizing the results of the
@@ -511,10 +1309,16 @@ func (r *Review) GetAnalysesMessage() string {
 	if status != "" && status != analyses.StatusNeedsMoreWork {
 		return status
 	}
-	analysesNotes, err := latestAnalyses.GetNotes()
+	fetcher, err := GetAnalysesFetcher(r.Repo)
 	if err != nil {
 		return err.Error()
 	}
+	analysesNotes, err := fetcher.Notes(*latestAnalyses)
+	if err != nil {
+		// The analysis server being slow or down should not keep the
+		// rest of the review from being shown.
+		return fmt.Sprintf("unavailable (%v)", err)
+	}
 	if analysesNotes == nil {
 		return "passed"
 	}
@@ -541,8 +1345,13 @@ func GetCommentsJSON(cs []CommentThread) (string, error) {
 }
 
 // GetJSON returns the pretty printed JSON for a review summary.
+//
+// Like that of a review, this includes a "positionEncoding" field.
 func (r *Summary) GetJSON() (string, error) {
-	jsonBytes, err := json.Marshal(*r)
+	jsonBytes, err := json.Marshal(struct {
+		*Summary
+		PositionEncoding string `json:"positionEncoding"`
+	}{r, comment.PositionEncoding})
 	if err != nil {
 		return "", err
 	}
@@ -550,8 +1359,14 @@ func (r *Summary) GetJSON() (string, error) {
 }
 
 // GetJSON returns the pretty printed JSON for a review.
+//
+// The JSON includes a "positionEncoding" field, which says how the lines and
+// columns in the ranges of comments are counted.
 func (r *Review) GetJSON() (string, error) {
-	jsonBytes, err := json.Marshal(*r)
+	jsonBytes, err := json.Marshal(struct {
+		*Review
+		PositionEncoding string `json:"positionEncoding"`
+	}{r, comment.PositionEncoding})
 	if err != nil {
 		return "", err
 	}
@@ -602,6 +1417,29 @@ func (r *Review) findLastCommit(startingCommit, latestCommit string, commentThre
 	return latestCommit
 }
 
+// GetForeignCommitter returns the email address of the committer of the
+// given commit, or the empty string if that is the given requester.
+func GetForeignCommitter(repo repository.Repo, commit, requester string) (string, error) {
+	details, err := repo.GetCommitDetails(commit)
+	if err != nil {
+		return "", err
+	}
+	if details.CommitterEmail == "" || strings.EqualFold(details.CommitterEmail, requester) {
+		return "", nil
+	}
+	return details.CommitterEmail, nil
+}
+
+// GetForeignCommitter returns the email address of the committer of the
+// commit at which the review is anchored, or the empty string if that is the
+// requester.
+//
+// This is read from the commit, rather than from the ForeignCommitter that
+// the requester recorded in the request, so that it can be trusted.
+func (r *Summary) GetForeignCommitter() (string, error) {
+	return GetForeignCommitter(r.Repo, r.Revision, r.Request.Requester)
+}
+
 func (r *Summary) getStartingCommit() string {
 	if r.Request.Alias != "" {
 		return r.Request.Alias
@@ -636,6 +1474,160 @@ func (r *Review) GetHeadCommit() (string, error) {
 	return r.findLastCommit(currentCommit, currentCommit, r.Comments), nil
 }
 
+// Divergence describes how far the head commit of a review has diverged
+// from its target ref.
+type Divergence struct {
+	// Ahead is the number of commits in the review that are not in the target.
+	Ahead int `json:"ahead"`
+	// Behind is the number of commits in the target that are not in the review.
+	Behind int `json:"behind"`
+}
+
+// String returns a short human readable description of the divergence.
+func (d Divergence) String() string {
+	return fmt.Sprintf("%d ahead / %d behind", d.Ahead, d.Behind)
+}
+
+// divergenceFrom computes the divergence of the review's head commit from
+// the given, already resolved, target commit.
+func (r *Summary) divergenceFrom(targetCommit string) (*Divergence, error) {
+	headCommit, err := (&Review{Summary: r}).GetHeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	ahead, behind, err := r.Repo.CountAheadBehind(targetCommit, headCommit)
+	if err != nil {
+		return nil, err
+	}
+	return &Divergence{Ahead: ahead, Behind: behind}, nil
+}
+
+// Divergence returns the number of commits that the review's head commit is
+// ahead of and behind its target ref.
+//
+// This tells whether a change needs to be rebased before it is reviewed in
+// detail.
+func (r *Summary) Divergence() (*Divergence, error) {
+	if r.Request.TargetRef == "" {
+		return nil, fmt.Errorf("review %s has no target ref", r.Revision)
+	}
+	targetCommit, err := r.Repo.ResolveRefCommit(r.Request.TargetRef)
+	if err != nil {
+		return nil, err
+	}
+	return r.divergenceFrom(targetCommit)
+}
+
+// GetDivergences returns the divergence of each of the given open reviews
+// from its target ref, keyed by the reviews' revisions.
+//
+// Each target ref is only resolved once, which makes this cheaper than
+// calling Divergence on each of the reviews when listing them. Reviews whose
+// divergence can not be computed, e.g. because their code has not been
+// fetched, are left out.
+func GetDivergences(summaries []Summary) map[string]Divergence {
+	divergences := make(map[string]Divergence)
+	targetCommits := make(map[string]string)
+	for i := range summaries {
+		r := &summaries[i]
+		if !r.IsOpen() {
+			continue
+		}
+		targetCommit, ok := targetCommits[r.Request.TargetRef]
+		if !ok {
+			targetCommit, _ = r.Repo.ResolveRefCommit(r.Request.TargetRef)
+			targetCommits[r.Request.TargetRef] = targetCommit
+		}
+		if targetCommit == "" {
+			continue
+		}
+		if divergence, err := r.divergenceFrom(targetCommit); err == nil {
+			divergences[r.Revision] = *divergence
+		}
+	}
+	return divergences
+}
+
+// Sources of the heads listed in a review's history.
+const (
+	HistoryFromRequest = "request"
+	HistoryFromArchive = "archive"
+	HistoryFromCurrent = "current"
+)
+
+// HistoryEntry describes one of the heads that a review has had.
+type HistoryEntry struct {
+	Commit string `json:"commit"`
+	// Timestamp is when the head was recorded, in seconds since the epoch.
+	Timestamp string `json:"timestamp,omitempty"`
+	// Source is where the head was recorded; one of the HistoryFrom* constants.
+	Source string `json:"source"`
+}
+
+type historyByTimestamp []HistoryEntry
+
+// Interface methods for sorting history entries by timestamp
+func (entries historyByTimestamp) Len() int { return len(entries) }
+func (entries historyByTimestamp) Swap(i, j int) {
+	entries[i], entries[j] = entries[j], entries[i]
+}
+func (entries historyByTimestamp) Less(i, j int) bool {
+	return timestamp.Time(entries[i].Timestamp).Before(timestamp.Time(entries[j].Timestamp))
+}
+
+// GetHistory returns each of the heads that the review has had, oldest first.
+//
+// The heads are recovered from the review's requests (the original revision,
+// and the aliases recorded when the review was rebased), and from the commits
+// that were archived before the review ref was rewritten. This means that
+// prior versions of a review are still listed after its review ref has been
+// force-pushed.
+func (r *Review) GetHistory() ([]HistoryEntry, error) {
+	var history []HistoryEntry
+	seen := make(map[string]bool)
+	add := func(commit, recorded, source string) {
+		if commit == "" || seen[commit] {
+			return
+		}
+		seen[commit] = true
+		history = append(history, HistoryEntry{Commit: commit, Timestamp: recorded, Source: source})
+	}
+
+	add(r.Revision, r.AllRequests[0].Timestamp, HistoryFromRequest)
+	for _, request := range r.AllRequests {
+		if request.Alias != "" {
+			add(request.Alias, request.Timestamp, HistoryFromRequest)
+		}
+	}
+
+	archived, err := r.Repo.ListArchivedCommits(archiveRef)
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range archived {
+		// Only the heads that were archived as heads of this review are
+		// included, as any other commit descending from its revision may
+		// be the head of another review, e.g. one stacked on top of it.
+		if commit.Review == r.Revision {
+			add(commit.Hash, commit.Time, HistoryFromArchive)
+		}
+	}
+
+	head, err := r.GetHeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	if !seen[head] {
+		headTime, err := r.Repo.GetCommitTime(head)
+		if err != nil {
+			return nil, err
+		}
+		add(head, headTime, HistoryFromCurrent)
+	}
+	sort.Stable(historyByTimestamp(history))
+	return history, nil
+}
+
 // GetBaseCommit returns the commit against which a review should be compared.
 func (r *Review) GetBaseCommit() (string, error) {
 	if !r.IsOpen() {
@@ -681,28 +1673,95 @@ func (r *Review) ListCommits() ([]string, error) {
 
 // GetDiff returns the diff for a review.
 func (r *Review) GetDiff(diffArgs ...string) (string, error) {
+	return r.GetDiffWithOptions(repository.DiffOptions{}, diffArgs...)
+}
+
+// GetDiffWithOptions returns the diff for a review, as controlled by the
+// given options.
+func (r *Review) GetDiffWithOptions(options repository.DiffOptions, diffArgs ...string) (string, error) {
 	var baseCommit, headCommit string
 	baseCommit, err := r.GetBaseCommit()
 	if err == nil {
 		headCommit, err = r.GetHeadCommit()
 	}
 	if err == nil {
-		return r.Repo.Diff(baseCommit, headCommit, diffArgs...)
+		return r.Repo.DiffWithOptions(baseCommit, headCommit, options, diffArgs...)
 	}
 	return "", err
 }
 
+// getMaxCommentSize returns the maximum size of a comment's note, as
+// configured by the MaxCommentSizeConfig key.
+func getMaxCommentSize(repo repository.Repo) (int, error) {
+	configured, err := config.Get(repo, MaxCommentSizeConfig)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(configured) == "" {
+		return DefaultMaxCommentSize, nil
+	}
+	size, err := config.ParseSize(configured)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %q", MaxCommentSizeConfig, configured)
+	}
+	return size, nil
+}
+
+// checkCommentSize checks that the given comment note is within the
+// configured size limit.
+//
+// Very large comments, such as pasted logs, bloat the notes ref that every
+// clone has to fetch, so they are rejected with a suggestion of what to do
+// instead.
+func checkCommentSize(repo repository.Repo, commentNote repository.Note) error {
+	limit, err := getMaxCommentSize(repo)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(commentNote) > limit {
+		return fmt.Errorf("the comment is %d bytes, which is more than the limit of %d bytes. "+
+			"Consider committing large content such as logs, or linking to it, instead. "+
+			"The limit can be changed with `git config %s <size>`, or removed by setting it to 0",
+			len(commentNote), limit, MaxCommentSizeConfig)
+	}
+	return nil
+}
+
 // AddComment adds the given comment to the review.
 func (r *Review) AddComment(c comment.Comment) error {
 	commentNote, err := c.Write()
 	if err != nil {
 		return err
 	}
+	if err := checkCommentSize(r.Repo, commentNote); err != nil {
+		return err
+	}
 
 	r.Repo.AppendNote(comment.Ref, r.Revision, commentNote)
 	return nil
 }
 
+// AddComments adds the given comments to the review in a single batch.
+func (r *Review) AddComments(comments []comment.Comment) error {
+	var notes []string
+	for i, c := range comments {
+		commentNote, err := c.Write()
+		if err != nil {
+			return err
+		}
+		if err := checkCommentSize(r.Repo, commentNote); err != nil {
+			return fmt.Errorf("comment %d: %v", i+1, err)
+		}
+		notes = append(notes, string(commentNote))
+	}
+	if len(notes) == 0 {
+		return nil
+	}
+	// Each line of a note is parsed separately, so the comments can all be
+	// appended in a single note.
+	return r.Repo.AppendNote(comment.Ref, r.Revision, repository.Note(strings.Join(notes, "\n")))
+}
+
 // Rebase performs an interactive rebase of the review onto its target ref.
 //
 // If the 'archivePrevious' argument is true, then the previous head of the
@@ -715,7 +1774,7 @@ func (r *Review) Rebase(archivePrevious bool) error {
 		if err != nil {
 			return err
 		}
-		if err := r.Repo.ArchiveRef(orig, archiveRef); err != nil {
+		if err := r.Repo.ArchiveReviewHead(orig, archiveRef, r.Revision); err != nil {
 			return err
 		}
 	}
@@ -727,12 +1786,34 @@ func (r *Review) Rebase(archivePrevious bool) error {
 	if err != nil {
 		return err
 	}
+	return r.RecordRebase(false)
+}
 
+// RecordRebase updates the review request to track the result of a completed
+// rebase, which is expected to be the current HEAD.
+//
+// This is done automatically by Rebase and RebaseAndSign, and only needs to be
+// called directly if a rebase had to be resumed after it was interrupted.
+//
+// If 'sign' is true, then the updated request is (re)signed.
+func (r *Review) RecordRebase(sign bool) error {
 	alias, err := r.Repo.GetCommitHash("HEAD")
 	if err != nil {
 		return err
 	}
 	r.Request.Alias = alias
+
+	if sign {
+		key, err := r.Repo.GetUserSigningKey()
+		if err != nil {
+			return err
+		}
+		err = gpg.Sign(key, &r.Request)
+		if err != nil {
+			return err
+		}
+	}
+
 	newNote, err := r.Request.Write()
 	if err != nil {
 		return err
@@ -754,7 +1835,7 @@ func (r *Review) RebaseAndSign(archivePrevious bool) error {
 		if err != nil {
 			return err
 		}
-		if err := r.Repo.ArchiveRef(orig, archiveRef); err != nil {
+		if err := r.Repo.ArchiveReviewHead(orig, archiveRef, r.Revision); err != nil {
 			return err
 		}
 	}
@@ -766,27 +1847,7 @@ func (r *Review) RebaseAndSign(archivePrevious bool) error {
 	if err != nil {
 		return err
 	}
-
-	alias, err := r.Repo.GetCommitHash("HEAD")
-	if err != nil {
-		return err
-	}
-	r.Request.Alias = alias
-
-	key, err := r.Repo.GetUserSigningKey()
-	if err != nil {
-		return err
-	}
-	err = gpg.Sign(key, &r.Request)
-	if err != nil {
-		return err
-	}
-
-	newNote, err := r.Request.Write()
-	if err != nil {
-		return err
-	}
-	return r.Repo.AppendNote(request.Ref, r.Revision, newNote)
+	return r.RecordRebase(true)
 }
 
 func wellKnownCommitForPath(repo repository.Repo, path string, archive bool) (string, error) {
@@ -813,15 +1874,18 @@ func wellKnownCommitForPath(repo repository.Repo, path string, archive bool) (st
 }
 
 func AddDetachedComment(repo repository.Repo, c *comment.Comment) error {
+	commentNote, err := c.Write()
+	if err != nil {
+		return err
+	}
+	if err := checkCommentSize(repo, commentNote); err != nil {
+		return err
+	}
 	path := c.Location.Path
 	wellKnownCommit, err := wellKnownCommitForPath(repo, path, true)
 	if err != nil {
 		return fmt.Errorf("Failure finding the well-known commit for detached comments on %q: %v", path, err)
 	}
-	commentNote, err := c.Write()
-	if err != nil {
-		return err
-	}
 	return repo.AppendNote(comment.Ref, wellKnownCommit, commentNote)
 }
 
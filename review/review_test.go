@@ -17,11 +17,16 @@ limitations under the License.
 package review
 
 import (
+	"encoding/json"
 	"github.com/google/git-appraise/repository"
 	"github.com/google/git-appraise/review/comment"
 	"github.com/google/git-appraise/review/request"
+	"github.com/google/git-appraise/review/teams"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCommentSorting(t *testing.T) {
@@ -133,6 +138,59 @@ func TestRequestSorting(t *testing.T) {
 	}
 }
 
+func TestRequestSortingWithUnpaddedTimestamps(t *testing.T) {
+	sampleRequests := []request.Request{
+		request.Request{
+			Timestamp:   "10",
+			Description: "Second",
+		},
+		request.Request{
+			Timestamp:   "9",
+			Description: "First",
+		},
+	}
+	sort.Stable(requestsByTimestamp(sampleRequests))
+	if sampleRequests[0].Description != "First" || sampleRequests[1].Description != "Second" {
+		t.Fatalf("Review request ordering failed. Got %v", sampleRequests)
+	}
+	malformed, err := request.Parse(repository.Note(`{"timestamp": "yesterday", "targetRef": "refs/heads/master"}`))
+	if err != nil {
+		t.Fatalf("Failed to parse a request with a malformed timestamp: %v", err)
+	}
+	if malformed.Timestamp != "yesterday" || !malformed.Time().IsZero() {
+		t.Errorf("Unexpected timestamp for a malformed request: %q, %v", malformed.Timestamp, malformed.Time())
+	}
+	if err := malformed.CheckTimestamps(); err == nil {
+		t.Error("Failed to report a request with a malformed timestamp")
+	}
+}
+
+func TestCountInvalidTimestamps(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if count, err := CountInvalidTimestamps(repo); err != nil || count != 0 {
+		t.Fatalf("Unexpected count of invalid timestamps: %d, %v", count, err)
+	}
+	note := repository.Note(`{"timestamp": "yesterday", "author": "bob", "description": "LGTM"}`)
+	if err := repo.AppendNote(comment.Ref, repository.TestCommitG, note); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := CountInvalidTimestamps(repo); err != nil || count != 1 {
+		t.Fatalf("Unexpected count of invalid timestamps: %d, %v", count, err)
+	}
+	// The comment is still read, rather than dropped.
+	r, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, thread := range r.Comments {
+		found = found || thread.Comment.Timestamp == "yesterday"
+	}
+	if !found {
+		t.Errorf("The comment with an invalid timestamp was dropped: %v", r.Comments)
+	}
+}
+
 func validateUnresolved(t *testing.T, resolved *bool) {
 	if resolved != nil {
 		t.Fatalf("Expected resolved status to be unset, but instead it was %v", *resolved)
@@ -544,6 +602,50 @@ func TestFYIThenRejectedThreadsStatus(t *testing.T) {
 	validateRejected(t, status)
 }
 
+func TestBlockingThreadStatus(t *testing.T) {
+	blockingThread := CommentThread{
+		Comment: comment.Comment{
+			Kind: comment.KindBlocking,
+		},
+	}
+	blockingThread.updateResolvedStatus()
+	blockingThread.validateRejected(t)
+	if unresolved := blockingThread.unresolvedThreads(nil); len(unresolved) != 1 {
+		t.Errorf("Unexpected unresolved threads of a blocking comment: %v", unresolved)
+	}
+}
+
+func TestBlockingThenAcceptedThreadStatus(t *testing.T) {
+	accepted := true
+	sampleThread := CommentThread{
+		Comment: comment.Comment{
+			Kind: comment.KindBlocking,
+		},
+		Children: []CommentThread{
+			CommentThread{
+				Comment: comment.Comment{
+					Timestamp: "012345",
+					Resolved:  &accepted,
+				},
+			},
+		},
+	}
+	sampleThread.updateResolvedStatus()
+	sampleThread.validateUnresolved(t)
+}
+
+func TestRejectedNitThreadStatus(t *testing.T) {
+	rejected := false
+	nitThread := CommentThread{
+		Comment: comment.Comment{
+			Kind:     comment.KindNit,
+			Resolved: &rejected,
+		},
+	}
+	nitThread.updateResolvedStatus()
+	nitThread.validateUnresolved(t)
+}
+
 func TestBuildCommentThreads(t *testing.T) {
 	rejected := false
 	accepted := true
@@ -868,3 +970,593 @@ func TestRebaseDetachedHead(t *testing.T) {
 		t.Fatalf("Failed to submit the review: %q", submittedReviewJSON)
 	}
 }
+
+func TestAcceptedBy(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	submittedReview, err := GetSummary(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptedBy := submittedReview.AcceptedBy()
+	if len(acceptedBy) != 1 || acceptedBy[0] != "ojarjur" {
+		t.Fatalf("Unexpected accepted-by list for a submitted review: %v", acceptedBy)
+	}
+	pendingReview, err := GetSummary(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptedBy := pendingReview.AcceptedBy(); len(acceptedBy) != 0 {
+		t.Fatalf("Unexpected accepted-by list for a pending review: %v", acceptedBy)
+	}
+}
+
+func TestUnresolvedThreads(t *testing.T) {
+	rejected := false
+	accepted := true
+	addressed := comment.Comment{
+		Timestamp:   "012345",
+		Resolved:    &rejected,
+		Description: "addressed",
+	}
+	addressedHash, err := addressed.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := comment.Comment{
+		Timestamp:   "012346",
+		Resolved:    &accepted,
+		Parent:      addressedHash,
+		Description: "done",
+	}
+	replyHash, err := reply.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lgtm := comment.Comment{
+		Timestamp:   "012347",
+		Resolved:    &accepted,
+		Description: "lgtm",
+	}
+	lgtmHash, err := lgtm.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unaddressed := comment.Comment{
+		Timestamp:   "012348",
+		Resolved:    &rejected,
+		Parent:      lgtmHash,
+		Description: "one more thing",
+	}
+	unaddressedHash, err := unaddressed.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads := buildCommentThreads(map[string]comment.Comment{
+		addressedHash:   addressed,
+		replyHash:       reply,
+		lgtmHash:        lgtm,
+		unaddressedHash: unaddressed,
+	})
+	summary := Summary{Comments: threads}
+	summary.Resolved = updateThreadsStatus(summary.Comments)
+	unresolved := summary.UnresolvedThreads()
+	if len(unresolved) != 1 || unresolved[0].Hash != unaddressedHash {
+		t.Fatalf("Unexpected unresolved threads: %v", unresolved)
+	}
+}
+
+func TestVerifyAcceptanceRequiresSignatures(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	submittedReview, err := GetSummary(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := submittedReview.VerifyAcceptance(); err == nil {
+		t.Fatal("Failed to reject an unsigned review")
+	}
+}
+
+func TestIsOverdue(t *testing.T) {
+	r := &Summary{
+		Request: request.Request{
+			TargetRef: "refs/heads/master",
+			DueBy:     "0000000100",
+		},
+	}
+	if r.IsOverdue(time.Unix(99, 0)) {
+		t.Fatal("A review was reported as overdue before its deadline")
+	}
+	if !r.IsOverdue(time.Unix(101, 0)) {
+		t.Fatal("A review was not reported as overdue after its deadline")
+	}
+	r.Submitted = true
+	if r.IsOverdue(time.Unix(101, 0)) {
+		t.Fatal("A submitted review was reported as overdue")
+	}
+	r.Submitted = false
+	r.Request.DueBy = ""
+	if r.IsOverdue(time.Unix(101, 0)) {
+		t.Fatal("A review without a deadline was reported as overdue")
+	}
+}
+
+// repoWithTeams overrides the teams file of the mock repository.
+type repoWithTeams struct {
+	repository.Repo
+	teams string
+}
+
+func (r repoWithTeams) HasObject(object string) (bool, error) {
+	if strings.HasSuffix(object, ":"+teams.File) {
+		return true, nil
+	}
+	return r.Repo.HasObject(object)
+}
+
+func (r repoWithTeams) Show(commit, path string) (string, error) {
+	if path == teams.File {
+		return r.teams, nil
+	}
+	return r.Repo.Show(commit, path)
+}
+
+func TestTeamAcceptance(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := GetSummary(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptance := r.TeamAcceptance(); acceptance != nil {
+		t.Fatalf("Unexpected team acceptance for a review without teams: %v", acceptance)
+	}
+	r.Request.ReviewerTeams = map[string][]string{
+		"core": {"someone", "ojarjur"},
+		"docs": {"someone"},
+	}
+	acceptance := r.TeamAcceptance()
+	if acceptance["core"] != (TeamApproval{Reviewer: "ojarjur"}) || acceptance["docs"] != (TeamApproval{}) {
+		t.Fatalf("Unexpected team acceptance: %v", acceptance)
+	}
+	delegate := func(delegation *comment.Delegation) {
+		for i, thread := range r.Comments {
+			if thread.Comment.Author == "ojarjur" && thread.Comment.Resolved != nil && *thread.Comment.Resolved {
+				r.Comments[i].Comment.Delegation = delegation
+			}
+		}
+	}
+	delegate(&comment.Delegation{Actor: "ojarjur", Team: "docs"})
+	if acceptance := r.TeamAcceptance(); acceptance["docs"] != (TeamApproval{}) {
+		t.Fatalf("Unexpected team acceptance by someone who is not a lead: %v", acceptance)
+	}
+	r.Repo = repoWithTeams{Repo: repo, teams: "docs/leads: [ojarjur]\n"}
+	acceptance = r.TeamAcceptance()
+	if acceptance["core"] != (TeamApproval{}) || acceptance["docs"] != (TeamApproval{Reviewer: "ojarjur", Delegated: true}) {
+		t.Fatalf("Unexpected team acceptance with a delegated acceptance: %v", acceptance)
+	}
+	delegate(&comment.Delegation{Actor: "someone", Team: "docs"})
+	if acceptance := r.TeamAcceptance(); acceptance["docs"] != (TeamApproval{}) {
+		t.Fatalf("Unexpected team acceptance with a delegation made by someone else: %v", acceptance)
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	pendingReview, err := GetSummary(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	divergence, err := pendingReview.Divergence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if divergence.Ahead != 3 || divergence.Behind != 1 {
+		t.Errorf("Unexpected divergence for a pending review: %v", divergence)
+	}
+	divergences := GetDivergences(ListOpen(repo))
+	if divergences[repository.TestCommitG] != *divergence {
+		t.Errorf("Unexpected batched divergences: %v", divergences)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	pendingReview, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A descendant of the review's revision that was archived as the head
+	// of another review is not part of its history.
+	if err := repo.ArchiveReviewHead(repository.TestCommitH, archiveRef, repository.TestCommitJ); err != nil {
+		t.Fatal(err)
+	}
+	if err := pendingReview.Rebase(true); err != nil {
+		t.Fatal(err)
+	}
+	rebasedReview, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := rebasedReview.GetHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]string)
+	for _, entry := range history {
+		sources[entry.Commit] = entry.Source
+	}
+	if sources[repository.TestCommitG] != HistoryFromRequest {
+		t.Errorf("The original revision is missing from the history: %v", history)
+	}
+	if sources[repository.TestCommitI] != HistoryFromArchive {
+		t.Errorf("The archived head is missing from the history: %v", history)
+	}
+	if sources[rebasedReview.Request.Alias] != HistoryFromRequest {
+		t.Errorf("The rebased head is missing from the history: %v", history)
+	}
+	if _, ok := sources[repository.TestCommitH]; ok {
+		t.Errorf("The head of another review is included in the history: %v", history)
+	}
+}
+
+func TestAddComments(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	pendingReview, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := []comment.Comment{
+		comment.New("reviewer@example.com", "First"),
+		comment.New("reviewer@example.com", "Second"),
+	}
+	if err := pendingReview.AddComments(comments); err != nil {
+		t.Fatal(err)
+	}
+	updatedReview, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updatedReview.Comments) != len(pendingReview.Comments)+2 {
+		t.Errorf("Unexpected comments after a batch: %v", updatedReview.Comments)
+	}
+}
+
+func TestCommentSizeLimit(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	pendingReview, err := Get(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := comment.New("reviewer@example.com", strings.Repeat("log line\n", DefaultMaxCommentSize/8))
+	if err := pendingReview.AddComment(large); err == nil {
+		t.Error("Expected a comment larger than the default limit to be rejected")
+	}
+	if err := repo.AddConfigValue(MaxCommentSizeConfig, "1m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pendingReview.AddComment(large); err != nil {
+		t.Errorf("Expected a comment within the configured limit to be added: %v", err)
+	}
+	if err := repo.AddConfigValue(MaxCommentSizeConfig, "lots"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getMaxCommentSize(repo); err == nil {
+		t.Error("Expected an invalid size limit to be rejected")
+	}
+}
+
+func TestPrettyPrintedNotes(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	prettyRequest, err := json.MarshalIndent(request.New("tool@example.com", []string{"reviewer@example.com"},
+		repository.TestReviewRef, repository.TestTargetRef, "Pretty-printed request"), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := true
+	prettyComment := comment.New("tool@example.com", "Pretty-printed comment")
+	prettyComment.Resolved = &resolved
+	prettyCommentBytes, err := json.MarshalIndent(prettyComment, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(request.Ref, repository.TestCommitJ, repository.Note(prettyRequest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(comment.Ref, repository.TestCommitJ, repository.Note(prettyCommentBytes)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Get(repo, repository.TestCommitJ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Request.Description != "Pretty-printed request" {
+		t.Errorf("Unexpected request read from a pretty-printed note: %+v", r.Request)
+	}
+	if len(r.Comments) != 1 || r.Comments[0].Comment.Description != "Pretty-printed comment" || r.Resolved == nil || !*r.Resolved {
+		t.Errorf("Unexpected comments read from a pretty-printed note: %+v", r.Comments)
+	}
+
+	found := false
+	for _, summary := range ListAll(repo) {
+		if summary.Revision == repository.TestCommitJ {
+			found = summary.Request.Description == "Pretty-printed request" && len(summary.Comments) == 1
+		}
+	}
+	if !found {
+		t.Error("The review with pretty-printed notes was not listed correctly")
+	}
+}
+
+func TestBuildCommentThreadsWithLegacyParent(t *testing.T) {
+	root := comment.New("author@example.com", "Root")
+	root.Timestamp = "0000000001"
+	legacyHash, err := root.LegacyHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := root.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacyHash == hash {
+		t.Fatal("Expected the legacy hash to differ from the canonical hash")
+	}
+	reply := comment.New("reviewer@example.com", "Reply")
+	reply.Timestamp = "0000000002"
+	reply.Parent = legacyHash
+	replyHash, err := reply.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads := buildCommentThreads(map[string]comment.Comment{
+		hash:      root,
+		replyHash: reply,
+	})
+	if len(threads) != 1 || len(threads[0].Children) != 1 {
+		t.Fatalf("Expected the reply to be attached via its legacy parent hash: %+v", threads)
+	}
+	if !threads[0].HasHash(hash) || !threads[0].HasHash(legacyHash) {
+		t.Error("Expected the thread to be identified by both its canonical and legacy hashes")
+	}
+}
+
+func TestBuildCommentThreadsWithCycles(t *testing.T) {
+	accepted := true
+	first := comment.New("author@example.com", "First")
+	first.Timestamp = "0000000001"
+	first.Parent = "bbbb"
+	second := comment.New("reviewer@example.com", "Second")
+	second.Timestamp = "0000000002"
+	second.Parent = "aaaa"
+	second.Resolved = &accepted
+	third := comment.New("author@example.com", "Third")
+	third.Timestamp = "0000000003"
+	third.Parent = "bbbb"
+	// The comments are keyed by made up hashes, since no comment can
+	// actually contain the hash of a comment that contains its own.
+	commentsByHash := map[string]comment.Comment{
+		"aaaa": first,
+		"bbbb": second,
+		"cccc": third,
+	}
+	for i := 0; i < 10; i++ {
+		threads := buildCommentThreads(commentsByHash)
+		if len(threads) != 1 {
+			t.Fatalf("Unexpected threads: %v", threads)
+		}
+		root := threads[0]
+		if root.Hash != "aaaa" || !root.Cyclic || len(root.Children) != 1 {
+			t.Fatalf("Unexpected root thread: %v", root)
+		}
+		child := root.Children[0]
+		if child.Hash != "bbbb" || child.Cyclic || len(child.Children) != 1 || child.Children[0].Hash != "cccc" {
+			t.Fatalf("Unexpected child thread: %v", child)
+		}
+		if resolved := updateThreadsStatus(threads); resolved != nil {
+			t.Errorf("Unexpected status from a cyclic comment: %v", *resolved)
+		}
+		summary := Summary{Comments: threads}
+		if cycles := summary.Cycles(); len(cycles) != 1 {
+			t.Errorf("Unexpected cycles: %v", cycles)
+		}
+	}
+}
+
+func TestBuildCommentThreadsWithOrphans(t *testing.T) {
+	accepted := true
+	root := comment.New("author@example.com", "Root")
+	root.Timestamp = "0000000001"
+	rootHash, err := root.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := comment.New("reviewer@example.com", "LGTM")
+	reply.Timestamp = "0000000002"
+	reply.Parent = "0123456789abcdef"
+	reply.Resolved = &accepted
+	replyHash, err := reply.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads := buildCommentThreads(map[string]comment.Comment{
+		rootHash:  root,
+		replyHash: reply,
+	})
+	if len(threads) != 2 {
+		t.Fatalf("Unexpected threads: %v", threads)
+	}
+	resolved := updateThreadsStatus(threads)
+	if resolved != nil {
+		t.Errorf("Unexpected status from an orphaned comment: %v", *resolved)
+	}
+	summary := Summary{Comments: threads}
+	orphans := summary.Orphans()
+	if len(orphans) != 1 || orphans[0].Hash != replyHash || orphans[0].Comment.Description != "LGTM" {
+		t.Fatalf("Unexpected orphans: %v", orphans)
+	}
+	if accepted := summary.AcceptedBy(); len(accepted) != 0 {
+		t.Errorf("Unexpected acceptance from an orphaned comment: %v", accepted)
+	}
+}
+
+func TestListSkipped(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if err := repo.AppendNote(request.Ref, repository.TestCommitA, repository.Note(`{"timestamp": "0000000001"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AppendNote(request.Ref, repository.TestCommitA, repository.Note(`{"timestamp": "0000000002", "v": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	skips, err := ListSkipped(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reasons []string
+	for _, skip := range skips {
+		if skip.Revision != repository.TestCommitA {
+			t.Errorf("Unexpected skip of a valid review: %+v", skip)
+		}
+		reasons = append(reasons, skip.Reason)
+	}
+	if len(reasons) != 3 ||
+		!strings.HasPrefix(reasons[0], "the note is not a valid request") ||
+		reasons[1] != "the request has the unsupported format version 1" ||
+		reasons[2] != "the commit has no valid review requests" {
+		t.Errorf("Unexpected skips: %q", reasons)
+	}
+}
+
+func TestListAllIncludesMissingCommits(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	const missing = "0123456789abcdef0123456789abcdef01234567"
+	if err := repo.AppendNote(request.Ref, missing, repository.Note(`{"timestamp": "0000000001", "targetRef": "refs/heads/master", "description": "Not fetched"}`)); err != nil {
+		t.Fatal(err)
+	}
+	var found *Summary
+	for _, summary := range ListAll(repo) {
+		if summary.Revision == missing {
+			found = &summary
+		} else if summary.Missing {
+			t.Errorf("Review of a known commit marked as missing: %+v", summary)
+		}
+	}
+	if found == nil {
+		t.Fatal("The review of the missing commit was not listed")
+	}
+	if !found.Missing || found.Submitted || found.Request.Description != "Not fetched" {
+		t.Errorf("Unexpected summary of the review of the missing commit: %+v", found)
+	}
+	skips, err := ListSkipped(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, skip := range skips {
+		if skip.Revision == missing {
+			t.Errorf("Unexpected skip of the review of the missing commit: %+v", skip)
+		}
+	}
+}
+
+func TestSplitReviewRef(t *testing.T) {
+	cases := []struct {
+		ref, fork, branch string
+	}{
+		{"refs/heads/feature", "", "feature"},
+		{"refs/heads/user/feature", "", "user/feature"},
+		{"refs/tags/v1", "", "tags/v1"},
+		{"refs/forks/alice/user/feature", "alice", "user/feature"},
+		{"refs/forks/url/0123abcd/fix", "url/0123abcd", "fix"},
+		{"refs/forks/url/0123abcd/feature/x", "url/0123abcd", "feature/x"},
+		{"refs/forks/bob", "", "bob"},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		if fork, branch := splitReviewRef(c.ref); fork != c.fork || branch != c.branch {
+			t.Errorf("splitReviewRef(%q) = (%q, %q), expected (%q, %q)", c.ref, fork, branch, c.fork, c.branch)
+		}
+	}
+}
+
+// repoCountingRemoteLookups counts the lookups of the config of remotes.
+type repoCountingRemoteLookups struct {
+	repository.Repo
+	lookups int
+}
+
+func (r *repoCountingRemoteLookups) GetConfig(key string) (string, error) {
+	if strings.HasPrefix(key, "remote.") {
+		r.lookups++
+	}
+	return r.Repo.GetConfig(key)
+}
+
+func TestListAllForksWithoutConfig(t *testing.T) {
+	mock := repository.NewMockRepoForTest()
+	forked := request.New("alice@example.com", nil, "refs/forks/alice/user/feature", "refs/heads/master", "From a fork")
+	note, err := forked.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.AppendNote(request.Ref, repository.TestCommitJ, note); err != nil {
+		t.Fatal(err)
+	}
+	repo := &repoCountingRemoteLookups{Repo: mock}
+	var found bool
+	for _, summary := range ListAll(repo) {
+		if summary.Revision == repository.TestCommitJ {
+			found = summary.Fork == "alice" && summary.Branch == "user/feature"
+		}
+	}
+	if !found {
+		t.Error("The fork and branch of the review were not listed")
+	}
+	if repo.lookups != 0 {
+		t.Errorf("Unexpected lookups of the config of remotes while listing reviews: %d", repo.lookups)
+	}
+}
+
+func TestRequestHistory(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	r, err := GetSummary(repo, repository.TestCommitG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := r.RequestHistory()
+	if len(history) != 3 {
+		t.Fatalf("Unexpected request history: %v", history)
+	}
+	if len(history[0].Changes) != 0 {
+		t.Errorf("Unexpected changes in the first request: %v", history[0].Changes)
+	}
+	expected := []RequestChange{{Field: "description", Old: "G", New: "Updated description of G"}}
+	if !reflect.DeepEqual(history[1].Changes, expected) {
+		t.Errorf("Unexpected changes in the updated request: %v", history[1].Changes)
+	}
+	expected = []RequestChange{{Field: "description", Old: "Updated description of G", New: "Final description of G"}}
+	if !reflect.DeepEqual(history[2].Changes, expected) {
+		t.Errorf("Unexpected changes in the final request: %v", history[2].Changes)
+	}
+}
+
+func TestGetForeignCommitter(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if committer, err := GetForeignCommitter(repo, repository.TestCommitB, "Author@example.com"); err != nil || committer != "" {
+		t.Errorf("Unexpected foreign committer of the requester's own commit: %q, %v", committer, err)
+	}
+	if committer, err := GetForeignCommitter(repo, repository.TestCommitB, "user@example.com"); err != nil || committer != "author@example.com" {
+		t.Errorf("Unexpected foreign committer: %q, %v", committer, err)
+	}
+	if _, err := GetForeignCommitter(repo, "missing", "user@example.com"); err == nil {
+		t.Error("Unexpected success for a missing commit")
+	}
+
+	// The committer recorded by the requester is not trusted.
+	r, err := GetSummary(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Request.ForeignCommitter = ""
+	if committer, err := r.GetForeignCommitter(); err != nil || committer != "author@example.com" {
+		t.Errorf("Unexpected foreign committer of a review: %q, %v", committer, err)
+	}
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+// searchIndexFilename is the name of the file (relative to the repo's .git directory)
+// in which the local search index is cached.
+const searchIndexFilename = "appraise-search-index.json"
+
+// searchIndexVersion is bumped whenever the format of the cached index changes,
+// so that stale caches are discarded rather than misread.
+const searchIndexVersion = 2
+
+// Query represents a parsed search query.
+//
+// Free-form terms must all appear (case-insensitively) in either the review
+// description or the body of one of its comments. The qualifiers further
+// restrict the results to reviews with a matching author, status, or path.
+// The author must be either the whole email of a requester or commenter, or,
+// if it has no "@", the part of such an email before the "@".
+type Query struct {
+	Terms  []string
+	Author string
+	Status string
+	Path   string
+}
+
+// ParseQuery parses a search query of the form "<term>... [author:<email>] [status:<status>] [path:<path>]".
+func ParseQuery(query string) (*Query, error) {
+	q := &Query{}
+	for _, field := range strings.Fields(query) {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			q.Terms = append(q.Terms, strings.ToLower(field))
+			continue
+		}
+		switch parts[0] {
+		case "author":
+			q.Author = strings.ToLower(parts[1])
+		case "status":
+			q.Status = strings.ToLower(parts[1])
+		case "path":
+			q.Path = parts[1]
+		default:
+			q.Terms = append(q.Terms, strings.ToLower(field))
+		}
+	}
+	switch q.Status {
+	case "", "open", "wip", "pending", "accepted", "rejected", "submitted", "abandoned":
+	default:
+		return nil, fmt.Errorf("unsupported status qualifier %q", q.Status)
+	}
+	return q, nil
+}
+
+// searchDocument is the indexed form of a single review.
+type searchDocument struct {
+	NotesHash string   `json:"notesHash"`
+	Text      string   `json:"text"`
+	Authors   []string `json:"authors,omitempty"`
+	Paths     []string `json:"paths,omitempty"`
+}
+
+// searchIndex is the locally cached collection of indexed reviews, keyed by revision.
+//
+// The commits of the notes refs that the documents were indexed from are
+// recorded, so that the notes are only read again once either ref changes.
+type searchIndex struct {
+	Version      int                        `json:"v"`
+	RequestsHash string                     `json:"requestsHash,omitempty"`
+	CommentsHash string                     `json:"commentsHash,omitempty"`
+	Documents    map[string]*searchDocument `json:"documents"`
+}
+
+func searchIndexPath(repo repository.Repo) string {
+	return filepath.Join(repo.GetGitDir(), searchIndexFilename)
+}
+
+// loadSearchIndex reads the cached search index, returning an empty index if
+// the cache is missing or unreadable.
+func loadSearchIndex(repo repository.Repo) *searchIndex {
+	index := &searchIndex{
+		Version:   searchIndexVersion,
+		Documents: make(map[string]*searchDocument),
+	}
+	contents, err := ioutil.ReadFile(searchIndexPath(repo))
+	if err != nil {
+		return index
+	}
+	var cached searchIndex
+	if err := json.Unmarshal(contents, &cached); err != nil || cached.Version != searchIndexVersion || cached.Documents == nil {
+		return index
+	}
+	return &cached
+}
+
+// save writes the search index back to the cache. The index is only an
+// optimization, so failures to write it are not fatal.
+func (index *searchIndex) save(repo repository.Repo) {
+	contents, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(searchIndexPath(repo), contents, 0644)
+}
+
+// hashNotes computes a hash identifying the exact set of notes for a review.
+func hashNotes(requestNotes, commentNotes []repository.Note) string {
+	h := sha1.New()
+	for _, note := range requestNotes {
+		h.Write(note)
+		h.Write([]byte("\n"))
+	}
+	h.Write([]byte{0})
+	for _, note := range commentNotes {
+		h.Write(note)
+		h.Write([]byte("\n"))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func newSearchDocument(notesHash string, requestNotes, commentNotes []repository.Note) *searchDocument {
+	doc := &searchDocument{NotesHash: notesHash}
+	var text []string
+	authors := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, r := range request.ParseAllValid(requestNotes) {
+		text = append(text, r.Description)
+		if r.Requester != "" {
+			authors[strings.ToLower(r.Requester)] = true
+		}
+	}
+	for _, c := range comment.ParseAllValid(commentNotes) {
+		text = append(text, c.Description)
+		if c.Author != "" {
+			authors[strings.ToLower(c.Author)] = true
+		}
+		if c.Location != nil && c.Location.Path != "" {
+			paths[c.Location.Path] = true
+		}
+	}
+	doc.Text = strings.ToLower(strings.Join(text, "\n"))
+	for author := range authors {
+		doc.Authors = append(doc.Authors, author)
+	}
+	sort.Strings(doc.Authors)
+	for path := range paths {
+		doc.Paths = append(doc.Paths, path)
+	}
+	sort.Strings(doc.Paths)
+	return doc
+}
+
+// updateSearchIndex brings the search index up to date with the notes in the repo.
+//
+// If neither notes ref has changed since the index was last saved, then the
+// notes are not read at all. Otherwise, only reviews whose notes have changed
+// are reparsed; every other review reuses its previously indexed document.
+func updateSearchIndex(repo repository.Repo) (*searchIndex, error) {
+	index := loadSearchIndex(repo)
+	// The refs might not exist yet, if there are no reviews or comments.
+	requestsHash, _ := repo.GetCommitHash(request.Ref)
+	commentsHash, _ := repo.GetCommitHash(comment.Ref)
+	if requestsHash != "" && requestsHash == index.RequestsHash && commentsHash == index.CommentsHash {
+		return index, nil
+	}
+	requestNotesMap, err := repo.GetAllNotes(request.Ref)
+	if err != nil {
+		return nil, err
+	}
+	commentNotesMap, err := repo.GetAllNotes(comment.Ref)
+	if err != nil {
+		return nil, err
+	}
+	updated := make(map[string]*searchDocument)
+	changed := false
+	for revision, requestNotes := range requestNotesMap {
+		commentNotes := commentNotesMap[revision]
+		notesHash := hashNotes(requestNotes, commentNotes)
+		if doc, ok := index.Documents[revision]; ok && doc.NotesHash == notesHash {
+			updated[revision] = doc
+			continue
+		}
+		updated[revision] = newSearchDocument(notesHash, requestNotes, commentNotes)
+		changed = true
+	}
+	if len(updated) != len(index.Documents) || requestsHash != index.RequestsHash || commentsHash != index.CommentsHash {
+		changed = true
+	}
+	index.Documents = updated
+	index.RequestsHash, index.CommentsHash = requestsHash, commentsHash
+	if changed {
+		index.save(repo)
+	}
+	return index, nil
+}
+
+func (doc *searchDocument) matches(q *Query) bool {
+	for _, term := range q.Terms {
+		if !strings.Contains(doc.Text, term) {
+			return false
+		}
+	}
+	if q.Author != "" {
+		found := false
+		for _, author := range doc.Authors {
+			if matchesAuthor(author, q.Author) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Path != "" {
+		found := false
+		for _, path := range doc.Paths {
+			if path == q.Path || strings.HasPrefix(path, strings.TrimSuffix(q.Path, "/")+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAuthor reports whether the given (lowercased) author matches the
+// author qualifier of a query, which is either a whole email or, if it has no
+// "@", the part of one before the "@".
+func matchesAuthor(author, qualifier string) bool {
+	if author == qualifier {
+		return true
+	}
+	if strings.Contains(qualifier, "@") {
+		return false
+	}
+	return strings.SplitN(author, "@", 2)[0] == qualifier
+}
+
+// matchesStatus reports whether the given review summary has the status named in the query.
+func (q *Query) matchesStatus(r *Summary) bool {
+	switch q.Status {
+	case "":
+		return true
+	case "open":
+		return r.IsOpen()
+	case "wip":
+		return r.IsDraft()
+	case "pending":
+		return r.IsOpen() && r.Resolved == nil
+	case "accepted":
+		return r.IsOpen() && r.Resolved != nil && *r.Resolved
+	case "rejected":
+		return r.IsOpen() && r.Resolved != nil && !*r.Resolved
+	case "submitted":
+		return r.Submitted
+	case "abandoned":
+		return r.IsAbandoned()
+	}
+	return false
+}
+
+// Search returns the summaries of all reviews that match the given query.
+//
+// The results are sorted with the newest requests first.
+func Search(repo repository.Repo, query string) ([]Summary, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	index, err := updateSearchIndex(repo)
+	if err != nil {
+		return nil, err
+	}
+	var results []Summary
+	for revision, doc := range index.Documents {
+		if !doc.matches(q) {
+			continue
+		}
+		summary, err := GetSummary(repo, revision)
+		if err != nil || summary == nil {
+			continue
+		}
+		if q.matchesStatus(summary) {
+			results = append(results, *summary)
+		}
+	}
+	sort.Stable(summariesWithNewestRequestsFirst(results))
+	return results, nil
+}
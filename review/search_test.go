@@ -0,0 +1,133 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery("Fix author:OJarjur status:open path:review/ bug:123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Terms) != 2 || q.Terms[0] != "fix" || q.Terms[1] != "bug:123" {
+		t.Fatalf("Unexpected query terms: %v", q.Terms)
+	}
+	if q.Author != "ojarjur" || q.Status != "open" || q.Path != "review/" {
+		t.Fatalf("Unexpected query qualifiers: %+v", q)
+	}
+	if _, err := ParseQuery("status:bogus"); err == nil {
+		t.Fatal("Failed to reject an unsupported status qualifier")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	results, err := Search(repo, "description")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Revision != repository.TestCommitG {
+		t.Fatalf("Unexpected search results: %v", results)
+	}
+
+	results, err = Search(repo, "author:ojarjur status:submitted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Revision != repository.TestCommitD || results[1].Revision != repository.TestCommitB {
+		t.Fatalf("Unexpected search results: %v", results)
+	}
+
+	results, err = Search(repo, "author:nobody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("Unexpected search results: %v", results)
+	}
+}
+
+func TestMatchesAuthor(t *testing.T) {
+	for _, test := range []struct {
+		author, qualifier string
+		matches           bool
+	}{
+		{"bob@example.com", "bob@example.com", true},
+		{"bob@example.com", "bob", true},
+		{"bob@example.com", "bo", false},
+		{"bobby@example.com", "bob", false},
+		{"bob@example.com", "example.com", false},
+		{"bob@example.com", "bob@example", false},
+		{"ojarjur", "ojarjur", true},
+	} {
+		if matches := matchesAuthor(test.author, test.qualifier); matches != test.matches {
+			t.Errorf("matchesAuthor(%q, %q) = %v", test.author, test.qualifier, matches)
+		}
+	}
+}
+
+// repoWithCountedNotesReads has a writable git directory, so that the search
+// index is saved, and counts the reads of every note.
+type repoWithCountedNotesReads struct {
+	repository.Repo
+	gitDir string
+	reads  int
+	refs   map[string]string
+}
+
+func (r *repoWithCountedNotesReads) GetGitDir() string { return r.gitDir }
+
+func (r *repoWithCountedNotesReads) GetAllNotes(notesRef string) (map[string][]repository.Note, error) {
+	r.reads++
+	return r.Repo.GetAllNotes(notesRef)
+}
+
+func (r *repoWithCountedNotesReads) GetCommitHash(ref string) (string, error) {
+	if hash, ok := r.refs[ref]; ok {
+		return hash, nil
+	}
+	return r.Repo.GetCommitHash(ref)
+}
+
+func TestSearchIndexReusedUntilNotesChange(t *testing.T) {
+	repo := &repoWithCountedNotesReads{
+		Repo:   repository.NewMockRepoForTest(),
+		gitDir: t.TempDir(),
+		refs:   map[string]string{request.Ref: "requests-1", comment.Ref: "comments-1"},
+	}
+	for i := 0; i < 2; i++ {
+		if results, err := Search(repo, "description"); err != nil || len(results) != 1 {
+			t.Fatalf("Unexpected search results: %v, %v", results, err)
+		}
+	}
+	if repo.reads != 2 {
+		t.Errorf("Unexpected reads of the notes refs while they did not change: %d", repo.reads)
+	}
+	repo.refs[comment.Ref] = "comments-2"
+	if results, err := Search(repo, "description"); err != nil || len(results) != 1 {
+		t.Fatalf("Unexpected search results: %v, %v", results, err)
+	}
+	if repo.reads != 4 {
+		t.Errorf("Unexpected reads of the notes refs after they changed: %d", repo.reads)
+	}
+}
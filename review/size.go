@@ -0,0 +1,176 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+const (
+	// MaxLinesConfig is the git config key that sets the number of changed
+	// lines above which a review is considered too large. Zero disables the
+	// check.
+	MaxLinesConfig = "appraise.size.maxLines"
+	// MaxFilesConfig is the git config key that sets the number of changed
+	// files above which a review is considered too large. Zero disables the
+	// check.
+	MaxFilesConfig = "appraise.size.maxFiles"
+	// LinesPerHourConfig is the git config key that sets how many changed
+	// lines a reviewer is assumed to read per hour.
+	LinesPerHourConfig = "appraise.size.linesPerHour"
+
+	// DefaultMaxLines is the default value of MaxLinesConfig. Reviews of more
+	// than a few hundred lines tend to get less thorough.
+	DefaultMaxLines = 400
+	// DefaultMaxFiles is the default value of MaxFilesConfig.
+	DefaultMaxFiles = 20
+	// DefaultLinesPerHour is the default value of LinesPerHourConfig.
+	DefaultLinesPerHour = 300
+)
+
+// Size summarizes how much a review changes.
+type Size struct {
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Lines returns the number of changed lines.
+func (s Size) Lines() int {
+	return s.Insertions + s.Deletions
+}
+
+// String returns a short human readable description of the size, e.g.
+// "3 files, +120/-40".
+func (s Size) String() string {
+	files := "files"
+	if s.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s, +%d/-%d", s.Files, files, s.Insertions, s.Deletions)
+}
+
+// GetSize returns the size of the changes between the given commits.
+func GetSize(repo repository.Repo, from, to string) (*Size, error) {
+	out, err := repo.Diff(from, to, "--numstat")
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstat(out)
+}
+
+// parseNumstat parses the output of `git diff --numstat`, in which each line
+// holds the inserted and deleted lines and the path of a file, or "-" for
+// the counts of binary files.
+func parseNumstat(out string) (*Size, error) {
+	size := &Size{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		size.Files++
+		if fields[0] == "-" && fields[1] == "-" {
+			continue
+		}
+		insertions, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected diff stat line %q", line)
+		}
+		deletions, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected diff stat line %q", line)
+		}
+		size.Insertions += insertions
+		size.Deletions += deletions
+	}
+	return size, nil
+}
+
+// GetSize returns the size of the review's changes.
+func (r *Review) GetSize() (*Size, error) {
+	baseCommit, err := r.GetBaseCommit()
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := r.GetHeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	return GetSize(r.Repo, baseCommit, headCommit)
+}
+
+// getSizeSetting returns the value of the given integer setting, or the given
+// default if it is not set.
+func getSizeSetting(repo repository.Repo, key string, defaultValue int) (int, error) {
+	configured, err := config.Get(repo, key)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(configured) == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(configured))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid value for %s: %q", key, configured)
+	}
+	return value, nil
+}
+
+// EstimateReviewTime returns roughly how long it takes to review a change
+// of the given size, based on the configured reading rate.
+func EstimateReviewTime(repo repository.Repo, size Size) (time.Duration, error) {
+	linesPerHour, err := getSizeSetting(repo, LinesPerHourConfig, DefaultLinesPerHour)
+	if err != nil {
+		return 0, err
+	}
+	if linesPerHour == 0 {
+		linesPerHour = DefaultLinesPerHour
+	}
+	estimate := time.Duration(size.Lines()) * time.Hour / time.Duration(linesPerHour)
+	if estimate < time.Minute {
+		estimate = time.Minute
+	}
+	return estimate.Round(time.Minute), nil
+}
+
+// CheckSize returns a warning for each of the configured thresholds that a
+// change of the given size exceeds.
+func CheckSize(repo repository.Repo, size Size) ([]string, error) {
+	maxLines, err := getSizeSetting(repo, MaxLinesConfig, DefaultMaxLines)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles, err := getSizeSetting(repo, MaxFilesConfig, DefaultMaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	if maxLines > 0 && size.Lines() > maxLines {
+		warnings = append(warnings, fmt.Sprintf("the review changes %d lines, which is more than the %d set by %s", size.Lines(), maxLines, MaxLinesConfig))
+	}
+	if maxFiles > 0 && size.Files > maxFiles {
+		warnings = append(warnings, fmt.Sprintf("the review changes %d files, which is more than the %d set by %s", size.Files, maxFiles, MaxFilesConfig))
+	}
+	return warnings, nil
+}
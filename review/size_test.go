@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestParseNumstat(t *testing.T) {
+	size, err := parseNumstat("3\t1\tmain.go\n-\t-\timage.png\n10\t0\tdocs/README.md\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *size != (Size{Files: 3, Insertions: 13, Deletions: 1}) {
+		t.Errorf("Unexpected size: %+v", size)
+	}
+	if _, err := parseNumstat("x\t1\tmain.go"); err == nil {
+		t.Error("Unexpected success parsing an invalid line")
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	if s := (Size{Files: 1, Insertions: 3}).String(); s != "1 file, +3/-0" {
+		t.Errorf("Unexpected description of a single file: %q", s)
+	}
+	if s := (Size{Files: 4, Insertions: 120, Deletions: 30}).String(); s != "4 files, +120/-30" {
+		t.Errorf("Unexpected description of several files: %q", s)
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if warnings, err := CheckSize(repo, Size{Files: 2, Insertions: 100}); err != nil || len(warnings) != 0 {
+		t.Errorf("Unexpected warnings for a small review: %q, %v", warnings, err)
+	}
+	if warnings, err := CheckSize(repo, Size{Files: 21, Insertions: 300, Deletions: 101}); err != nil || len(warnings) != 2 {
+		t.Errorf("Unexpected warnings for a large review: %q, %v", warnings, err)
+	}
+	if err := repo.SetConfig(MaxLinesConfig, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if warnings, err := CheckSize(repo, Size{Files: 1, Insertions: 1000}); err != nil || len(warnings) != 0 {
+		t.Errorf("Unexpected warnings with the line limit disabled: %q, %v", warnings, err)
+	}
+	if err := repo.SetConfig(MaxFilesConfig, "many"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckSize(repo, Size{Files: 1}); err == nil {
+		t.Error("Unexpected success with an invalid file limit")
+	}
+}
+
+func TestEstimateReviewTime(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if estimate, err := EstimateReviewTime(repo, Size{Insertions: 1}); err != nil || estimate != time.Minute {
+		t.Errorf("Unexpected estimate for a tiny review: %v, %v", estimate, err)
+	}
+	if estimate, err := EstimateReviewTime(repo, Size{Insertions: 400, Deletions: 50}); err != nil || estimate != 90*time.Minute {
+		t.Errorf("Unexpected estimate: %v, %v", estimate, err)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package submission defines the internal representation of a record of a
+// review being submitted.
+package submission
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/gpg"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+const (
+	// Ref defines the git-notes ref that we expect to contain submission records.
+	Ref = "refs/notes/devtools/submissions"
+
+	// StrategyMerge is the strategy used when the review was merged into the target.
+	StrategyMerge = "merge"
+	// StrategyRebase is the strategy used when the review was rebased onto the target.
+	StrategyRebase = "rebase"
+	// StrategyFastForward is the strategy used when the target was fast-forwarded to the review.
+	StrategyFastForward = "fast-forward"
+	// StrategyTag is the strategy used when a release review was submitted by creating a tag.
+	StrategyTag = "tag"
+
+	// FormatVersion defines the latest version of the submission format supported by the tool.
+	FormatVersion = 0
+)
+
+// Submission records how a review was submitted, so that it can later be
+// verified that what was submitted matches what was accepted.
+//
+// Submissions are attached to the first revision in the review, and are
+// normally signed.
+type Submission struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Submitter string `json:"submitter,omitempty"`
+	// Accepted is the head commit of the review when it was submitted.
+	Accepted string `json:"accepted"`
+	// Source is the commit that was submitted. This is the same as Accepted,
+	// unless the review was rebased or had trailers added when submitted.
+	Source string `json:"source"`
+	// TargetRef is the ref that the review was submitted to.
+	TargetRef string `json:"targetRef"`
+	// Submitted is the commit that TargetRef pointed to once the review was submitted.
+	Submitted string `json:"submitted"`
+	Strategy  string `json:"strategy"`
+	// Version represents the version of the metadata format.
+	Version int `json:"v,omitempty"`
+
+	gpg.Sig
+}
+
+// New returns a new submission record.
+func New(submitter, accepted, source, targetRef, submitted, strategy string) Submission {
+	return Submission{
+		Submitter: submitter,
+		Accepted:  accepted,
+		Source:    source,
+		TargetRef: targetRef,
+		Submitted: submitted,
+		Strategy:  strategy,
+	}
+}
+
+// Parse parses a submission record from a git note.
+func Parse(note repository.Note) (Submission, error) {
+	bytes := []byte(note)
+	var s Submission
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		return s, err
+	}
+	normalized, err := timestamp.Normalize(s.Timestamp)
+	if err != nil {
+		return s, err
+	}
+	s.Timestamp = normalized
+	return s, nil
+}
+
+// Time returns the time at which the review was submitted.
+func (s *Submission) Time() time.Time {
+	return timestamp.Time(s.Timestamp)
+}
+
+// ParseAllValid takes collection of git notes and tries to parse a
+// submission record from each one. Any notes that are not valid submission
+// records get ignored.
+func ParseAllValid(notes []repository.Note) []Submission {
+	var submissions []Submission
+	for _, note := range notes {
+		s, err := Parse(note)
+		if err == nil && s.Version == FormatVersion && s.Submitted != "" {
+			submissions = append(submissions, s)
+		}
+	}
+	return submissions
+}
+
+// Write writes a submission record as a JSON-formatted git note.
+func (s *Submission) Write() (repository.Note, error) {
+	bytes, err := json.Marshal(s)
+	return repository.Note(bytes), err
+}
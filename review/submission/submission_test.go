@@ -0,0 +1,56 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package submission
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestParseAllValid(t *testing.T) {
+	submissions := ParseAllValid([]repository.Note{
+		repository.Note(`{"timestamp":"1234","accepted":"a","source":"b","targetRef":"refs/heads/master","submitted":"c","strategy":"rebase"}`),
+		repository.Note(`{"timestamp":"12x4","accepted":"a","source":"a","targetRef":"refs/heads/master","submitted":"c","strategy":"merge"}`),
+		repository.Note(`{"timestamp":"1234","accepted":"a","source":"a","targetRef":"refs/heads/master","strategy":"merge"}`),
+		repository.Note(`{"timestamp":"1234","accepted":"a","source":"a","targetRef":"refs/heads/master","submitted":"c","strategy":"merge","v":1}`),
+		repository.Note(`not JSON`),
+	})
+	if len(submissions) != 1 {
+		t.Fatalf("Unexpected submissions: %v", submissions)
+	}
+	s := submissions[0]
+	if s.Timestamp != "0000001234" || s.Strategy != StrategyRebase || s.Source != "b" {
+		t.Errorf("Unexpected submission: %+v", s)
+	}
+}
+
+func TestWriteAndParse(t *testing.T) {
+	s := New("user@example.com", "a", "b", "refs/heads/master", "c", StrategyFastForward)
+	s.Timestamp = "0000000001"
+	note, err := s.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != s {
+		t.Errorf("Round trip mismatch: %+v vs %+v", parsed, s)
+	}
+}
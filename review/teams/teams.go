@@ -0,0 +1,188 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package teams contains helper methods for reading the reviewer groups that
+// are defined in a repository, and for expanding references to them.
+package teams
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// File is the path, relative to the root of the repository, of the file
+// in which reviewer groups are defined.
+const File = ".appraise/teams.yml"
+
+// Prefix marks a reviewer as a reference to a team rather than an individual.
+const Prefix = "team:"
+
+// LeadsSuffix is appended to the name of a team to name the team of its
+// leads, who can accept changes on its behalf.
+const LeadsSuffix = "/leads"
+
+// Parse parses the contents of a teams file into a map from each team's name
+// to the email addresses of its members.
+//
+// The file uses a small subset of YAML, in which every top-level key is the
+// name of a team, and its value is a list of members written either as a
+// block sequence or as a flow sequence:
+//
+//	backend:
+//	  - alice@example.com
+//	  - bob@example.com
+//	frontend: [carol@example.com, dave@example.com]
+//	backend/leads: [alice@example.com]
+func Parse(contents string) (map[string][]string, error) {
+	teams := make(map[string][]string)
+	currentTeam := ""
+	for i, line := range strings.Split(contents, "\n") {
+		if commentStart := strings.Index(line, "#"); commentStart >= 0 {
+			line = line[:commentStart]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "-") {
+			if currentTeam == "" || trimmed == line {
+				return nil, fmt.Errorf("line %d: list item outside of a team", i+1)
+			}
+			teams[currentTeam] = append(teams[currentTeam], unquote(strings.TrimPrefix(trimmed, "-")))
+			continue
+		}
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 || trimmed != line {
+			return nil, fmt.Errorf("line %d: expected a team name", i+1)
+		}
+		currentTeam = unquote(parts[0])
+		if _, ok := teams[currentTeam]; ok {
+			return nil, fmt.Errorf("line %d: duplicate team %q", i+1, currentTeam)
+		}
+		teams[currentTeam] = nil
+		value := strings.TrimSpace(parts[1])
+		if value == "" {
+			continue
+		}
+		if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("line %d: the members of team %q must be a list", i+1, currentTeam)
+		}
+		for _, member := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"), ",") {
+			if member = unquote(member); member != "" {
+				teams[currentTeam] = append(teams[currentTeam], member)
+			}
+		}
+		currentTeam = ""
+	}
+	return teams, nil
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Load reads the teams defined in the teams file at the given ref.
+//
+// If the ref does not contain a teams file, then no teams are defined. If it
+// does, but the file can not be read or parsed, then an error is returned.
+func Load(repo repository.Repo, ref string) (map[string][]string, error) {
+	exists, err := repo.HasObject(ref + ":" + File)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return map[string][]string{}, nil
+	}
+	contents, err := repo.Show(ref, File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the teams file %q at %q: %v", File, ref, err)
+	}
+	teams, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid teams file %q at %q: %v", File, ref, err)
+	}
+	return teams, nil
+}
+
+// Expand replaces every team reference (e.g. "team:backend") in the given
+// list of reviewers with the members of that team.
+//
+// It returns the resulting list of individual reviewers, along with the
+// membership of every team that was referenced.
+func Expand(repo repository.Repo, ref string, reviewers []string) ([]string, map[string][]string, error) {
+	var teams map[string][]string
+	var expanded []string
+	referenced := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(reviewer string) {
+		if !seen[reviewer] {
+			seen[reviewer] = true
+			expanded = append(expanded, reviewer)
+		}
+	}
+	for _, reviewer := range reviewers {
+		if !strings.HasPrefix(reviewer, Prefix) {
+			add(reviewer)
+			continue
+		}
+		if teams == nil {
+			var err error
+			if teams, err = Load(repo, ref); err != nil {
+				return nil, nil, err
+			}
+		}
+		name := strings.TrimPrefix(reviewer, Prefix)
+		members, ok := teams[name]
+		if !ok || len(members) == 0 {
+			return nil, nil, fmt.Errorf("unknown or empty team %q", name)
+		}
+		referenced[name] = members
+		for _, member := range members {
+			add(member)
+		}
+	}
+	if len(referenced) == 0 {
+		referenced = nil
+	}
+	return expanded, referenced, nil
+}
+
+// IsLead returns whether the given user is one of the leads of the named team.
+func IsLead(teams map[string][]string, name, user string) bool {
+	for _, lead := range teams[name+LeadsSuffix] {
+		if lead == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of the given teams in sorted order.
+func Names(teams map[string][]string) []string {
+	var names []string
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
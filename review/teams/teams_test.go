@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package teams
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+)
+
+func TestParse(t *testing.T) {
+	teams, err := Parse(`# Reviewer groups
+backend:
+  - alice@example.com
+  - "bob@example.com"  # on leave
+frontend: [carol@example.com, 'dave@example.com']
+empty:
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"backend":  {"alice@example.com", "bob@example.com"},
+		"frontend": {"carol@example.com", "dave@example.com"},
+		"empty":    nil,
+	}
+	if !reflect.DeepEqual(teams, expected) {
+		t.Fatalf("Unexpected teams: %v", teams)
+	}
+	for _, invalid := range []string{
+		"- alice@example.com",
+		"backend: alice@example.com",
+		"backend:\n  - a@example.com\nbackend:\n  - b@example.com",
+		"just some text",
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Failed to reject the invalid teams file %q", invalid)
+		}
+	}
+}
+
+func TestIsLead(t *testing.T) {
+	teams := map[string][]string{
+		"backend":       {"alice@example.com", "bob@example.com"},
+		"backend/leads": {"carol@example.com"},
+	}
+	if !IsLead(teams, "backend", "carol@example.com") {
+		t.Error("Failed to recognize a lead of the team")
+	}
+	if IsLead(teams, "backend", "alice@example.com") {
+		t.Error("Unexpectedly treated a member of the team as a lead")
+	}
+	if IsLead(teams, "frontend", "carol@example.com") {
+		t.Error("Unexpectedly treated a lead of another team as a lead")
+	}
+}
+
+// repoWithTeamsFile has a teams file with the given contents, or one that
+// can not be read if readErr is set, or none if neither is set.
+type repoWithTeamsFile struct {
+	repository.Repo
+	contents string
+	readErr  error
+}
+
+func (r repoWithTeamsFile) HasObject(object string) (bool, error) {
+	return r.contents != "" || r.readErr != nil, nil
+}
+
+func (r repoWithTeamsFile) Show(commit, path string) (string, error) {
+	return r.contents, r.readErr
+}
+
+func TestLoad(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if teams, err := Load(repoWithTeamsFile{Repo: repo}, repository.TestTargetRef); err != nil || len(teams) != 0 {
+		t.Errorf("Unexpected teams without a teams file: %v, %v", teams, err)
+	}
+	teams, err := Load(repoWithTeamsFile{Repo: repo, contents: "backend: [alice@example.com]\n"}, repository.TestTargetRef)
+	if err != nil || !reflect.DeepEqual(teams, map[string][]string{"backend": {"alice@example.com"}}) {
+		t.Errorf("Unexpected teams: %v, %v", teams, err)
+	}
+	if _, err := Load(repoWithTeamsFile{Repo: repo, contents: "just some text"}, repository.TestTargetRef); err == nil {
+		t.Error("Failed to report an invalid teams file")
+	}
+	if _, err := Load(repoWithTeamsFile{Repo: repo, readErr: errors.New("bad object")}, repository.TestTargetRef); err == nil {
+		t.Error("Failed to report a teams file that could not be read")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+)
+
+// ThreadContext is a single comment thread of a review, along with the
+// comments that it replies to.
+type ThreadContext struct {
+	Revision string `json:"revision"`
+	// Ancestors are the comments that the thread replies to, starting
+	// with the top-level one, each without its other replies.
+	Ancestors []CommentThread `json:"ancestors,omitempty"`
+	Thread    CommentThread   `json:"thread"`
+}
+
+// GetThread returns the comment thread, at any depth, whose hash is or
+// starts with the given hash, from the review for the given revision.
+//
+// This only reads the comments of the review, so it is much cheaper than
+// loading the whole review, for instance to link to a single comment.
+func GetThread(repo repository.Repo, revision, hash string) (*ThreadContext, error) {
+	if err := repo.VerifyCommit(revision); err != nil {
+		return nil, fmt.Errorf("Could not find a commit named %q", revision)
+	}
+	if fullHash, err := repo.GetCommitHash(revision); err == nil {
+		revision = fullHash
+	}
+	threads, _ := getCommentsFromNotes(repo, revision, repo.GetNotes(comment.Ref, revision))
+	path, err := findThreadPath(threads, hash)
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		return nil, fmt.Errorf("There is no comment thread matching %q in review %.12s.", hash, revision)
+	}
+	context := &ThreadContext{
+		Revision: revision,
+		Thread:   path[len(path)-1],
+	}
+	for _, ancestor := range path[:len(path)-1] {
+		ancestor.Children = nil
+		context.Ancestors = append(context.Ancestors, ancestor)
+	}
+	return context, nil
+}
+
+// findThreadPath returns the comment (sub)thread whose hash is or starts with
+// the given hash, preceded by each of the threads that it is nested in, or
+// nil if there is no such thread.
+//
+// If the given hash is a prefix of the hashes of several threads, and not
+// the whole hash of one of them, then an error is returned.
+func findThreadPath(threads []CommentThread, hash string) ([]CommentThread, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	var matches [][]CommentThread
+	collectThreadPaths(threads, hash, nil, &matches)
+	for _, path := range matches {
+		if path[len(path)-1].Hash == hash {
+			return path, nil
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("The comment hash %q is ambiguous, as it matches %d comments.", hash, len(matches))
+}
+
+// collectThreadPaths appends the path to every comment (sub)thread whose hash
+// starts with the given hash to the given matches, where each path starts
+// with the given parents.
+func collectThreadPaths(threads []CommentThread, hash string, parents []CommentThread, matches *[][]CommentThread) {
+	for _, thread := range threads {
+		path := append(append([]CommentThread(nil), parents...), thread)
+		if strings.HasPrefix(thread.Hash, hash) {
+			*matches = append(*matches, path)
+		}
+		collectThreadPaths(thread.Children, hash, path, matches)
+	}
+}
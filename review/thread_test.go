@@ -0,0 +1,75 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review/comment"
+)
+
+func TestGetThread(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	appendComment := func(c comment.Comment) string {
+		note, err := c.Write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AppendNote(comment.Ref, repository.TestCommitG, note); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := c.Hash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	root := comment.New("reviewer@example.com", "Root")
+	root.Timestamp = "0000000001"
+	rootHash := appendComment(root)
+	other := comment.New("reviewer@example.com", "Other")
+	other.Timestamp = "0000000002"
+	other.Parent = rootHash
+	appendComment(other)
+	reply := comment.New("author@example.com", "Reply")
+	reply.Timestamp = "0000000003"
+	reply.Parent = rootHash
+	replyHash := appendComment(reply)
+	leaf := comment.New("reviewer@example.com", "Leaf")
+	leaf.Timestamp = "0000000004"
+	leaf.Parent = replyHash
+	leafHash := appendComment(leaf)
+
+	context, err := GetThread(repo, repository.TestCommitG, replyHash[:12])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if context.Revision != repository.TestCommitG || context.Thread.Hash != replyHash {
+		t.Fatalf("Unexpected thread: %v", context)
+	}
+	if len(context.Ancestors) != 1 || context.Ancestors[0].Hash != rootHash || context.Ancestors[0].Children != nil {
+		t.Errorf("Unexpected ancestors: %v", context.Ancestors)
+	}
+	if len(context.Thread.Children) != 1 || context.Thread.Children[0].Hash != leafHash {
+		t.Errorf("Unexpected replies: %v", context.Thread.Children)
+	}
+
+	if _, err := GetThread(repo, repository.TestCommitG, "not-a-hash"); err == nil {
+		t.Error("Unexpected thread for a hash that does not match any comment")
+	}
+}
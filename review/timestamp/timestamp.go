@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timestamp contains helper methods for validating and comparing the
+// timestamps stored in review metadata.
+//
+// Timestamps are stored as the number of seconds since the Unix epoch, written
+// as a decimal string that is zero-padded to at least 10 digits. The padding
+// allows timestamps to be compared as strings, but older tools did not always
+// write it, so the typed accessors should be used for any comparisons.
+package timestamp
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Normalize validates the given timestamp, and returns it zero-padded to at
+// least 10 digits.
+//
+// Empty timestamps are allowed, as every timestamp field is optional.
+func Normalize(timestamp string) (string, error) {
+	if timestamp == "" {
+		return "", nil
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || seconds < 0 {
+		return "", fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if len(timestamp) < 10 {
+		return fmt.Sprintf("%010d", seconds), nil
+	}
+	return timestamp, nil
+}
+
+// Time returns the time represented by the given timestamp.
+//
+// Empty and invalid timestamps are treated as the zero time, so that they sort
+// before every valid timestamp.
+func Time(timestamp string) time.Time {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// ParseReport returns the time represented by the timestamp of a CI,
+// analysis, or benchmark report.
+//
+// Reports are written by bots rather than by this tool, and some of them use
+// RFC 3339 timestamps, so those are accepted along with the number of seconds.
+// Empty timestamps are treated as the zero time.
+func ParseReport(timestamp string) (time.Time, error) {
+	if normalized, err := Normalize(timestamp); err == nil {
+		return Time(normalized), nil
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil || t.Unix() < 0 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	return t, nil
+}
+
+// Format returns the timestamp of the given time, zero-padded to at least 10
+// digits.
+func Format(t time.Time) string {
+	return fmt.Sprintf("%010d", t.Unix())
+}
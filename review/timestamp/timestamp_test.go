@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"":           "",
+		"1":          "0000000001",
+		"012345":     "0000012345",
+		"1700000000": "1700000000",
+	}
+	for input, expected := range cases {
+		normalized, err := Normalize(input)
+		if err != nil {
+			t.Errorf("Unexpected error normalizing %q: %v", input, err)
+		} else if normalized != expected {
+			t.Errorf("Unexpected normalization of %q: %q", input, normalized)
+		}
+	}
+	for _, input := range []string{"yesterday", "-1", "12.5", " 1700000000", "2023-11-14T22:13:20Z"} {
+		if _, err := Normalize(input); err == nil {
+			t.Errorf("Failed to reject the invalid timestamp %q", input)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	if !Time("9").Before(Time("10")) {
+		t.Error("Timestamps of different lengths were compared as strings")
+	}
+	if !Time("bogus").IsZero() || !Time("").IsZero() {
+		t.Error("Invalid timestamps were not treated as the zero time")
+	}
+	if Time("1700000000").Unix() != 1700000000 {
+		t.Errorf("Unexpected time: %v", Time("1700000000"))
+	}
+	if !Time("2023-11-14T22:13:20Z").IsZero() {
+		t.Error("An RFC 3339 timestamp was accepted outside of a report")
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	for _, input := range []string{"1700000000", "2023-11-14T22:13:20Z", "2023-11-14T23:13:20+01:00"} {
+		if parsed, err := ParseReport(input); err != nil || parsed.Unix() != 1700000000 {
+			t.Errorf("Unexpected time for %q: %v, %v", input, parsed, err)
+		}
+	}
+	if parsed, err := ParseReport(""); err != nil || !parsed.IsZero() {
+		t.Errorf("Unexpected time for an empty timestamp: %v, %v", parsed, err)
+	}
+	for _, input := range []string{"yesterday", "-1", "1969-12-31T23:59:59Z"} {
+		if _, err := ParseReport(input); err == nil {
+			t.Errorf("Failed to reject the invalid timestamp %q", input)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(time.Unix(12345, 0)); got != "0000012345" {
+		t.Errorf("Format() = %q; want %q", got, "0000012345")
+	}
+	if got := Format(Time("1700000000")); got != "1700000000" {
+		t.Errorf("Format() = %q; want %q", got, "1700000000")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trailer contains helper methods for reading and writing the
+// "Key: value" trailers at the end of a commit message.
+package trailer
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// ReviewedBy is the trailer key used to record a reviewer who accepted a change.
+	ReviewedBy = "Reviewed-by"
+	// AckedBy is the trailer key used to record someone who acknowledged a change.
+	AckedBy = "Acked-by"
+	// ReviewedOn is the trailer key used to record the review in which a change was accepted.
+	ReviewedOn = "Reviewed-on"
+)
+
+var trailerRegexp = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+var emailRegexp = regexp.MustCompile(`<([^<>]+)>`)
+
+// Trailer represents a single "Key: value" line in a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// lastParagraph returns the final block of non-empty lines in the message.
+func lastParagraph(message string) []string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	return lines[start:]
+}
+
+// Parse returns the trailers found in the given commit message.
+//
+// Following git's conventions, only the final paragraph of the message is
+// considered, and that paragraph is only treated as a trailer block if every
+// line in it is a trailer. The first paragraph (the subject) is never treated
+// as a trailer block.
+func Parse(message string) []Trailer {
+	trimmed := strings.TrimSpace(message)
+	if !strings.Contains(trimmed, "\n\n") {
+		return nil
+	}
+	var trailers []Trailer
+	for _, line := range lastParagraph(trimmed) {
+		match := trailerRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: match[1], Value: strings.TrimSpace(match[2])})
+	}
+	return trailers
+}
+
+// String returns the trailer formatted as a line of a commit message.
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// Format returns the given trailers as a block of commit message lines.
+func Format(trailers ...Trailer) string {
+	var lines []string
+	for _, t := range trailers {
+		lines = append(lines, t.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Append adds the given trailers to the end of a commit message.
+//
+// If the message already ends with a trailer block, then the new trailers
+// are added to that block, and any trailers that are already present are
+// skipped. Otherwise, a new trailer block is started.
+func Append(message string, trailers ...Trailer) string {
+	existing := make(map[string]bool)
+	for _, t := range Parse(message) {
+		existing[t.String()] = true
+	}
+	var lines []string
+	for _, t := range trailers {
+		if line := t.String(); !existing[line] {
+			existing[line] = true
+			lines = append(lines, line)
+		}
+	}
+	message = strings.TrimRight(message, "\n")
+	if len(lines) == 0 {
+		return message + "\n"
+	}
+	separator := "\n\n"
+	if len(Parse(message)) > 0 {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(lines, "\n") + "\n"
+}
+
+// Email extracts the email address from a trailer value of the form
+// "Name <email>". If the value has no angle brackets, it is returned as-is.
+func Email(value string) string {
+	if match := emailRegexp.FindStringSubmatch(value); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return strings.TrimSpace(value)
+}
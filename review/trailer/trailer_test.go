@@ -0,0 +1,60 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trailer
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	message := `Fix the frobnicator
+
+The frobnicator was broken: it did not frob.
+
+Reviewed-by: Jane Doe <jane@example.com>
+Acked-by: joe@example.com
+`
+	trailers := Parse(message)
+	if len(trailers) != 2 {
+		t.Fatalf("Unexpected trailers: %v", trailers)
+	}
+	if trailers[0].Key != ReviewedBy || Email(trailers[0].Value) != "jane@example.com" {
+		t.Fatalf("Unexpected first trailer: %v", trailers[0])
+	}
+	if trailers[1].Key != AckedBy || Email(trailers[1].Value) != "joe@example.com" {
+		t.Fatalf("Unexpected second trailer: %v", trailers[1])
+	}
+
+	if trailers := Parse("Subject: with a colon"); trailers != nil {
+		t.Fatalf("Treated a subject line as a trailer: %v", trailers)
+	}
+	if trailers := Parse("Subject\n\nReviewed-by: someone\nnot a trailer"); trailers != nil {
+		t.Fatalf("Treated a mixed paragraph as a trailer block: %v", trailers)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	reviewed := Trailer{Key: ReviewedBy, Value: "jane@example.com"}
+	message := Append("Subject\n\nBody text\n", reviewed)
+	if message != "Subject\n\nBody text\n\nReviewed-by: jane@example.com\n" {
+		t.Fatalf("Unexpected message after appending a trailer: %q", message)
+	}
+	message = Append(message, reviewed, Trailer{Key: ReviewedOn, Value: "abcdef"})
+	if message != "Subject\n\nBody text\n\nReviewed-by: jane@example.com\nReviewed-on: abcdef\n" {
+		t.Fatalf("Unexpected message after appending to a trailer block: %q", message)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import "fmt"
+
+// TruncateThreads returns the given comment threads, after skipping the given
+// number of them, limited to the given number of threads at each level and to
+// the given depth. A limit of 0 means that there is no limit, and a depth of
+// 1 only keeps the given threads, without any of their replies.
+//
+// Each thread whose replies were left out records how many in its
+// ChildrenTruncated field, so that they can be fetched later by the thread's
+// hash. The number of the given threads that were left out is also returned.
+func TruncateThreads(threads []CommentThread, skip, maxThreads, maxDepth int) ([]CommentThread, int) {
+	if skip > len(threads) {
+		skip = len(threads)
+	}
+	kept := threads[skip:]
+	if maxThreads > 0 && len(kept) > maxThreads {
+		kept = kept[:maxThreads]
+	}
+	truncated := len(threads) - len(kept)
+	var result []CommentThread
+	for _, thread := range kept {
+		if maxDepth == 1 {
+			thread.ChildrenTruncated += len(thread.Children)
+			thread.Children = nil
+		} else {
+			var childrenTruncated int
+			thread.Children, childrenTruncated = TruncateThreads(thread.Children, 0, maxThreads, maxDepth-1)
+			thread.ChildrenTruncated += childrenTruncated
+		}
+		result = append(result, thread)
+	}
+	return result, truncated
+}
+
+// FindThread returns the comment (sub)thread, at any depth, whose hash is or
+// starts with the given hash.
+//
+// An error is returned if there is no such thread, or if the given hash is
+// the prefix of several threads' hashes.
+func FindThread(threads []CommentThread, hash string) (CommentThread, error) {
+	path, err := findThreadPath(threads, hash)
+	if err != nil {
+		return CommentThread{}, err
+	}
+	if path == nil {
+		return CommentThread{}, fmt.Errorf("There is no comment matching %q.", hash)
+	}
+	return path[len(path)-1], nil
+}
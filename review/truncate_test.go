@@ -0,0 +1,79 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package review
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestThread(hash string, children ...CommentThread) CommentThread {
+	return CommentThread{Hash: hash, Children: children}
+}
+
+func TestTruncateThreads(t *testing.T) {
+	threads := []CommentThread{
+		newTestThread("a", newTestThread("a1", newTestThread("a1x")), newTestThread("a2"), newTestThread("a3")),
+		newTestThread("b"),
+		newTestThread("c", newTestThread("c1")),
+	}
+	unlimited, truncated := TruncateThreads(threads, 0, 0, 0)
+	if truncated != 0 || len(unlimited) != 3 || len(unlimited[0].Children) != 3 || len(unlimited[0].Children[0].Children) != 1 {
+		t.Fatalf("Unexpected unlimited threads: %v, %d", unlimited, truncated)
+	}
+
+	shallow, truncated := TruncateThreads(threads, 0, 2, 2)
+	if truncated != 1 || len(shallow) != 2 || shallow[0].Hash != "a" || shallow[1].Hash != "b" {
+		t.Fatalf("Unexpected shallow threads: %v, %d", shallow, truncated)
+	}
+	if shallow[0].ChildrenTruncated != 1 || len(shallow[0].Children) != 2 {
+		t.Errorf("Unexpected replies: %v", shallow[0])
+	}
+	if child := shallow[0].Children[0]; child.ChildrenTruncated != 1 || len(child.Children) != 0 {
+		t.Errorf("Unexpected nested replies: %v", child)
+	}
+	if len(threads[0].Children) != 3 || len(threads[0].Children[0].Children) != 1 {
+		t.Errorf("The given threads were modified: %v", threads)
+	}
+
+	page, truncated := TruncateThreads(threads, 2, 2, 1)
+	if truncated != 2 || len(page) != 1 || page[0].Hash != "c" || page[0].ChildrenTruncated != 1 || page[0].Children != nil {
+		t.Errorf("Unexpected page of threads: %v, %d", page, truncated)
+	}
+}
+
+func TestFindThread(t *testing.T) {
+	threads := []CommentThread{
+		newTestThread("aaaa", newTestThread("abcd", newTestThread("abef"))),
+		newTestThread("bbbb"),
+	}
+	if found, err := FindThread(threads, "abe"); err != nil || found.Hash != "abef" {
+		t.Errorf("Unexpected thread found: %v, %v", found, err)
+	}
+	if found, err := FindThread(threads, "bbbb"); err != nil || found.Hash != "bbbb" {
+		t.Errorf("Unexpected thread found: %v, %v", found, err)
+	}
+	if found, err := FindThread(threads, "cccc"); err == nil {
+		t.Errorf("Unexpected thread found: %v", found)
+	}
+	if found, err := FindThread(threads, "ab"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Unexpected result for an ambiguous hash: %v, %v", found, err)
+	}
+	if found, err := FindThread(threads, ""); err == nil {
+		t.Errorf("Unexpected thread found for an empty hash: %v", found)
+	}
+}
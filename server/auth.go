@@ -0,0 +1,165 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Authenticator identifies the client that made a request.
+type Authenticator interface {
+	// Authenticate returns the identity of the client that made the given
+	// request, or an empty string if the request is not authenticated.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// Tokens authenticates requests by the bearer token in their Authorization
+// header, mapping each token to the identity it was issued to.
+type Tokens map[string]string
+
+// Authenticate returns the identity the request's bearer token was issued to.
+//
+// Every token is compared, in constant time, so that the time taken does not
+// reveal how much of a token was guessed correctly. The hashes of the tokens
+// are compared, rather than the tokens, as those all have the same length.
+func (t Tokens) Authenticate(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if token == "" {
+		return "", nil
+	}
+	tokenHash := sha256.Sum256([]byte(token))
+	identity := ""
+	for candidate, candidateIdentity := range t {
+		candidateHash := sha256.Sum256([]byte(candidate))
+		if subtle.ConstantTimeCompare(tokenHash[:], candidateHash[:]) == 1 {
+			identity = candidateIdentity
+		}
+	}
+	return identity, nil
+}
+
+// TrustedHeader authenticates requests by a header set by a reverse proxy
+// that has already authenticated the client, such as the X-Forwarded-Email
+// header of a proxy that signs users in with OpenID Connect.
+//
+// The server must only be reachable through that proxy, as otherwise any
+// client could set the header.
+type TrustedHeader string
+
+// Authenticate returns the value of the trusted header.
+func (h TrustedHeader) Authenticate(r *http.Request) (string, error) {
+	return strings.TrimSpace(r.Header.Get(string(h))), nil
+}
+
+// ClientCert authenticates requests by the client's TLS certificate, which
+// must have been verified against the server's client CAs. The identity is the
+// certificate's first email address, or its common name if it has none.
+type ClientCert struct{}
+
+// Authenticate returns the identity in the client's verified certificate.
+func (ClientCert) Authenticate(r *http.Request) (string, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0], nil
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// Identities maps authenticated identities to the emails they use in git.
+type Identities map[string]string
+
+// Email returns the git email of the given identity. Identities that are not
+// mapped are their own emails if they look like one.
+func (ids Identities) Email(identity string) (string, bool) {
+	if email, ok := ids[identity]; ok {
+		return email, true
+	}
+	if strings.Contains(identity, "@") {
+		return identity, true
+	}
+	return "", false
+}
+
+// ReadPairs reads a file of whitespace separated pairs, one per line, such as
+// a file of tokens and the identities they were issued to, or of identities
+// and their emails. Blank lines and lines starting with '#' are skipped.
+func ReadPairs(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	pairs := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected two fields, but found %d", path, lineNumber, len(fields))
+		}
+		pairs[fields[0]] = fields[1]
+	}
+	return pairs, scanner.Err()
+}
+
+type userKey struct{}
+
+// User returns the git email of the authenticated user who made the request,
+// or an empty string if the request was not authenticated.
+func User(r *http.Request) string {
+	email, _ := r.Context().Value(userKey{}).(string)
+	return email
+}
+
+// Authenticate returns a handler that only passes requests to the given one if
+// they are authenticated as an identity with a git email, which User returns.
+func Authenticate(auth Authenticator, ids Identities, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := auth.Authenticate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if identity == "" {
+			http.Error(w, "the request is not authenticated", http.StatusUnauthorized)
+			return
+		}
+		email, ok := ids.Email(identity)
+		if !ok {
+			http.Error(w, fmt.Sprintf("%q has no git email", identity), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, email)))
+	})
+}
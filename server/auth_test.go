@@ -0,0 +1,161 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+func TestAuthenticators(t *testing.T) {
+	r := httptest.NewRequest("GET", APIPrefix, nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Forwarded-Email", "user@example.com")
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "user"}}}},
+	}
+	for _, test := range []struct {
+		auth Authenticator
+		want string
+	}{
+		{Tokens{"secret": "user"}, "user"},
+		{Tokens{"other": "user"}, ""},
+		{Tokens{"secre": "user", "secrets": "user"}, ""},
+		{Tokens{"other": "someone", "secret": "user"}, "user"},
+		{TrustedHeader("X-Forwarded-Email"), "user@example.com"},
+		{TrustedHeader("X-Forwarded-User"), ""},
+		{ClientCert{}, "user"},
+	} {
+		if identity, err := test.auth.Authenticate(r); err != nil || identity != test.want {
+			t.Errorf("Unexpected identity from %#v: %q, %v; want %q", test.auth, identity, err, test.want)
+		}
+	}
+	empty := httptest.NewRequest("GET", APIPrefix, nil)
+	empty.Header.Set("Authorization", "Bearer ")
+	if identity, _ := (Tokens{"": "user"}).Authenticate(empty); identity != "" {
+		t.Errorf("Unexpected identity from an empty token: %q", identity)
+	}
+	if identity, _ := (ClientCert{}).Authenticate(httptest.NewRequest("GET", APIPrefix, nil)); identity != "" {
+		t.Errorf("Unexpected identity without a client certificate: %q", identity)
+	}
+}
+
+func TestIdentities(t *testing.T) {
+	ids := Identities{"user": "user@example.com"}
+	for identity, want := range map[string]string{
+		"user":              "user@example.com",
+		"other@example.com": "other@example.com",
+		"other":             "",
+	} {
+		if email, ok := ids.Email(identity); email != want || ok != (want != "") {
+			t.Errorf("Unexpected email for %q: %q, %v; want %q", identity, email, ok, want)
+		}
+	}
+}
+
+func post(t *testing.T, h http.Handler, token, path, body string) (int, string) {
+	r := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, r)
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestPostComment(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	repo := repository.NewMockRepoForTest()
+	s := New(root, func(path string) (repository.Repo, error) {
+		return repo, nil
+	})
+	h := Authenticate(Tokens{"secret": "user", "stranger": "nobody"}, Identities{"user": "user@example.com"}, s)
+	path := APIPrefix + "/alpha/reviews/" + repository.TestCommitB + "/comments"
+
+	if code, _ := post(t, s, "", path, `{"description": "Unauthenticated"}`); code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status without authentication: %d", code)
+	}
+	if code, _ := post(t, h, "", path, `{"description": "No token"}`); code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status without a token: %d", code)
+	}
+	if code, _ := post(t, h, "stranger", path, `{"description": "No email"}`); code != http.StatusForbidden {
+		t.Errorf("Unexpected status for an identity without an email: %d", code)
+	}
+	if code, _ := post(t, h, "secret", path, `{"author": "other@example.com", "description": "Forged"}`); code != http.StatusForbidden {
+		t.Errorf("Unexpected status for a forged author: %d", code)
+	}
+	if code, body := post(t, h, "secret", path, `{"description": "Looks good", "resolved": true}`); code != http.StatusCreated {
+		t.Fatalf("Unexpected response to a comment: %d %s", code, body)
+	}
+
+	rvw, err := review.Get(repo, repository.TestCommitB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, thread := range rvw.Comments {
+		if thread.Comment.Description == "Looks good" {
+			found = true
+			if thread.Comment.Author != "user@example.com" || thread.Comment.Timestamp == "" {
+				t.Errorf("Unexpected comment: %+v", thread.Comment)
+			}
+		}
+		if strings.Contains(thread.Comment.Description, "Forged") {
+			t.Errorf("The forged comment was written: %+v", thread.Comment)
+		}
+	}
+	if !found {
+		t.Errorf("The comment was not written: %+v", rvw.Comments)
+	}
+
+	var others string
+	for _, thread := range rvw.Comments {
+		if thread.Comment.Author == "ojarjur" {
+			others = thread.Hash
+		}
+	}
+	for _, body := range []string{
+		`{"description": "Backdated", "timestamp": "0000000001"}`,
+		`{"description": "Edited", "original": "` + others + `"}`,
+		`{"description": "On behalf", "resolved": true, "delegation": {"team": "core"}}`,
+		`{"description": "` + strings.Repeat("x", maxCommentBodySize) + `"}`,
+	} {
+		if code, _ := post(t, h, "secret", path, body); code == http.StatusCreated {
+			t.Errorf("Unexpected comment accepted: %.80s", body)
+		}
+	}
+	if code, body := post(t, h, "secret", path, `{"description": "Reply", "parent": "`+others[:8]+`"}`); code != http.StatusCreated {
+		t.Fatalf("Unexpected response to a reply: %d %s", code, body)
+	}
+	if rvw, err = review.Get(repo, repository.TestCommitB); err != nil {
+		t.Fatal(err)
+	}
+	for _, thread := range rvw.Comments {
+		if thread.Hash == others && (len(thread.Children) != 1 || thread.Children[0].Comment.Parent != others) {
+			t.Errorf("The reply was not recorded against the full hash of its parent: %+v", thread.Children)
+		}
+	}
+}
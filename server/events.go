@@ -0,0 +1,290 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/git-appraise/review"
+)
+
+// DefaultPollInterval is how often the streams of events check their
+// repositories for updates, unless the server's PollInterval is set.
+const DefaultPollInterval = 2 * time.Second
+
+// DefaultKeepaliveInterval is how often an idle stream of events sends a
+// comment, so that proxies do not close its connection, unless the server's
+// KeepaliveInterval is set.
+const DefaultKeepaliveInterval = 30 * time.Second
+
+// streamBufferSize is the number of updates that are buffered for each
+// stream of events. A stream that falls further behind is closed, so that
+// it does not hold up the other streams of the same repository.
+const streamBufferSize = 16
+
+// The types of events.
+const (
+	// EventReview is sent when a review is requested.
+	EventReview = "review"
+	// EventComment is sent when a comment is added to a review.
+	EventComment = "comment"
+	// EventStatus is sent when the status of a review changes, e.g. when it
+	// is accepted or submitted.
+	EventStatus = "status"
+)
+
+// Event is an update to the reviews of a repository.
+type Event struct {
+	Type     string `json:"type"`
+	Repo     string `json:"repo"`
+	Revision string `json:"revision"`
+	// Comment is the hash of the added comment, for EventComment.
+	Comment string `json:"comment,omitempty"`
+	// Status is the status of the review, as shown by the list command.
+	Status string `json:"status,omitempty"`
+}
+
+// addCommentHashes adds the hashes of every comment in the given threads.
+func addCommentHashes(hashes map[string]bool, threads []review.CommentThread) {
+	for _, thread := range threads {
+		hashes[thread.Hash] = true
+		addCommentHashes(hashes, thread.Children)
+	}
+}
+
+// newCommentEvents returns the events for the comments in the given threads
+// whose hashes are not in the given set.
+func newCommentEvents(repo, revision string, seen map[string]bool, threads []review.CommentThread) []Event {
+	var events []Event
+	for _, thread := range threads {
+		if !seen[thread.Hash] {
+			events = append(events, Event{Type: EventComment, Repo: repo, Revision: revision, Comment: thread.Hash})
+		}
+		events = append(events, newCommentEvents(repo, revision, seen, thread.Children)...)
+	}
+	return events
+}
+
+// diffSummaries returns the events that took the reviews of the named
+// repository from the old summaries to the new ones.
+func diffSummaries(repo string, old, new []review.Summary) []Event {
+	previous := make(map[string]*review.Summary)
+	for i := range old {
+		previous[old[i].Revision] = &old[i]
+	}
+	var events []Event
+	for i := range new {
+		current := &new[i]
+		status := current.StatusString()
+		before, ok := previous[current.Revision]
+		if !ok {
+			events = append(events, Event{Type: EventReview, Repo: repo, Revision: current.Revision, Status: status})
+			continue
+		}
+		seen := make(map[string]bool)
+		addCommentHashes(seen, before.Comments)
+		events = append(events, newCommentEvents(repo, current.Revision, seen, current.Comments)...)
+		if status != before.StatusString() {
+			events = append(events, Event{Type: EventStatus, Repo: repo, Revision: current.Revision, Status: status})
+		}
+	}
+	return events
+}
+
+// snapshot returns the state hash of the named repository, along with the
+// summaries of its reviews.
+func (s *Server) snapshot(name string) (string, []review.Summary, error) {
+	c, err := s.cached(name)
+	if err != nil {
+		return "", nil, err
+	}
+	defer c.mu.Unlock()
+	return c.stateHash, c.summaries(), nil
+}
+
+// update is sent to the streams of events of a repository when its reviews
+// change, or when reading them fails.
+type update struct {
+	events []Event
+	err    error
+}
+
+// watcher polls a single repository for updates on behalf of every stream
+// of its events, so that the repository is read once per PollInterval,
+// however many clients are streaming its events.
+type watcher struct {
+	// ready is closed once the watcher has read the initial state of the
+	// repository, after which err is the error from reading it, if any.
+	ready chan struct{}
+	err   error
+	// streams are guarded by the server's lock.
+	streams map[chan update]bool
+}
+
+// subscribe returns the updates to the reviews of the named repository, as
+// of when it is called, and a function that stops them. The watcher of the
+// repository is started if it is not already running.
+func (s *Server) subscribe(name string) (<-chan update, func(), error) {
+	stream := make(chan update, streamBufferSize)
+	s.mu.Lock()
+	w, ok := s.watchers[name]
+	if !ok {
+		w = &watcher{
+			ready:   make(chan struct{}),
+			streams: make(map[chan update]bool),
+		}
+		s.watchers[name] = w
+		go s.watch(name, w)
+	}
+	w.streams[stream] = true
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(w.streams, stream)
+	}
+	<-w.ready
+	if w.err != nil {
+		unsubscribe()
+		return nil, nil, w.err
+	}
+	return stream, unsubscribe, nil
+}
+
+// watch polls the named repository every PollInterval, and sends the updates
+// to its reviews to the watcher's streams, until there are none left.
+func (s *Server) watch(name string, w *watcher) {
+	stateHash, summaries, err := s.snapshot(name)
+	w.err = err
+	close(w.ready)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.watchers, name)
+		s.mu.Unlock()
+		return
+	}
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		idle := len(w.streams) == 0
+		if idle {
+			delete(s.watchers, name)
+		}
+		s.mu.Unlock()
+		if idle {
+			return
+		}
+		newStateHash, newSummaries, err := s.snapshot(name)
+		if err == nil && newStateHash == stateHash {
+			continue
+		}
+		u := update{err: err}
+		if err == nil {
+			u.events = diffSummaries(name, summaries, newSummaries)
+			stateHash, summaries = newStateHash, newSummaries
+		}
+		s.mu.Lock()
+		for stream := range w.streams {
+			select {
+			case stream <- u:
+			default:
+				delete(w.streams, stream)
+				close(stream)
+			}
+		}
+		if err != nil {
+			// The streams end after the error, and a later stream starts
+			// a new watcher.
+			delete(s.watchers, name)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes the given event in the server-sent events format.
+func writeEvent(w http.ResponseWriter, eventType string, data interface{}) error {
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, serialized)
+	return err
+}
+
+// serveEvents streams the updates to the reviews of the named repository as
+// server-sent events, until the client disconnects.
+//
+// The repository is checked for updates every PollInterval, so this includes
+// the reviews and comments that are pulled from remotes by other processes.
+// A comment is sent every KeepaliveInterval, so that idle streams are not
+// closed by proxies.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request, name string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	updates, unsubscribe, err := s.subscribe(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(s.KeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case u, ok := <-updates:
+			if !ok {
+				// The stream fell too far behind, so the client has to
+				// reconnect and read the reviews again.
+				return
+			}
+			if u.err != nil {
+				writeEvent(w, "error", u.err.Error())
+				flusher.Flush()
+				return
+			}
+			for _, event := range u.events {
+				if err := writeEvent(w, event.Type, event); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestDiffSummaries(t *testing.T) {
+	resolved := true
+	old := []review.Summary{
+		{Revision: "A", Request: request.Request{TargetRef: "refs/heads/master"}, Comments: []review.CommentThread{{Hash: "a1"}}},
+		{Revision: "B", Request: request.Request{TargetRef: "refs/heads/master"}},
+	}
+	new := []review.Summary{
+		{Revision: "A", Request: request.Request{TargetRef: "refs/heads/master"}, Comments: []review.CommentThread{
+			{Hash: "a1", Children: []review.CommentThread{{Hash: "a2"}}},
+		}},
+		{Revision: "B", Request: request.Request{TargetRef: "refs/heads/master"}, Resolved: &resolved},
+		{Revision: "C", Request: request.Request{TargetRef: "refs/heads/master"}},
+	}
+	want := []Event{
+		{Type: EventComment, Repo: "r", Revision: "A", Comment: "a2"},
+		{Type: EventStatus, Repo: "r", Revision: "B", Status: "accepted"},
+		{Type: EventReview, Repo: "r", Revision: "C", Status: "pending"},
+	}
+	if events := diffSummaries("r", old, new); !reflect.DeepEqual(events, want) {
+		t.Errorf("Unexpected events: %+v; want %+v", events, want)
+	}
+}
+
+func TestServeEvents(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	repo := repository.NewMockRepoForTest()
+	s := New(root, func(path string) (repository.Repo, error) {
+		return repo, nil
+	})
+	s.PollInterval = 10 * time.Millisecond
+	h := Authenticate(Tokens{"secret": "user@example.com"}, Identities{}, s)
+	httpServer := httptest.NewServer(h)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+APIPrefix+"/alpha/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Unexpected content type: %q", contentType)
+	}
+
+	path := APIPrefix + "/alpha/reviews/" + repository.TestCommitB + "/comments"
+	if code, body := post(t, h, "secret", path, `{"description": "Live"}`); code != http.StatusCreated {
+		t.Fatalf("Unexpected response to a comment: %d %s", code, body)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 || lines[0] != "event: comment" || !strings.HasPrefix(lines[1], "data: ") {
+		t.Fatalf("Unexpected event: %q", lines)
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Repo != "alpha" || event.Revision != repository.TestCommitB || event.Comment == "" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestSubscribeSharesWatcher(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	repo := repository.NewMockRepoForTest()
+	s := New(root, func(path string) (repository.Repo, error) {
+		return repo, nil
+	})
+	s.PollInterval = 10 * time.Millisecond
+	if _, err := s.Repos(); err != nil {
+		t.Fatal(err)
+	}
+	first, unsubscribeFirst, err := s.subscribe("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, unsubscribeSecond, err := s.subscribe("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	watchers := len(s.watchers)
+	s.mu.Unlock()
+	if watchers != 1 {
+		t.Fatalf("Unexpected number of watchers: %d", watchers)
+	}
+
+	// The repository is locked while it changes, as the watcher reads it.
+	c, err := s.cached("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.SetRef("refs/heads/new", repository.TestCommitA, "")
+	c.mu.Unlock()
+	for _, updates := range []<-chan update{first, second} {
+		if u := <-updates; u.err != nil {
+			t.Errorf("Unexpected update: %+v", u)
+		}
+	}
+
+	// The watcher stops once it has no streams left.
+	unsubscribeFirst()
+	unsubscribeSecond()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		s.mu.Lock()
+		watchers = len(s.watchers)
+		s.mu.Unlock()
+		if watchers == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The watcher did not stop")
+		}
+	}
+}
+
+func TestServeEventsKeepalive(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	s := New(root, func(path string) (repository.Repo, error) {
+		return repository.NewMockRepoForTest(), nil
+	})
+	s.KeepaliveInterval = 10 * time.Millisecond
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+APIPrefix+"/alpha/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() || scanner.Text() != ": keepalive" {
+		t.Errorf("Unexpected line instead of a keepalive comment: %q", scanner.Text())
+	}
+}
@@ -0,0 +1,510 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package server serves JSON APIs for the reviews of every git repository
+// under a root directory, such as the bare repositories of an organization, so
+// that one daemon can back a review dashboard for all of them.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/git-appraise/metrics"
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/teams"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+// APIPrefix is the prefix of the paths of every repository's APIs, which are:
+//
+//	/api/repos                            the names of the repositories
+//	/api/repos/<name>/reviews             the open reviews; ?all=true for all of them
+//	/api/repos/<name>/reviews/<revision>  a single review
+//	/api/repos/<name>/reviews/<revision>/comments
+//	                                      POST a comment on a review
+//	/api/repos/<name>/metrics             the metrics of the repository
+//	/api/repos/<name>/events              a stream of updates to the reviews
+//
+// Requests that write notes must be authenticated; see Authenticate.
+const APIPrefix = "/api/repos"
+
+// MetricsPath is the path of the metrics of every repository, in the
+// Prometheus text format, with each sample labelled with its repository.
+const MetricsPath = "/metrics"
+
+// OpenFunc opens the git repository at the given path.
+type OpenFunc func(path string) (repository.Repo, error)
+
+// repoCache holds what has been read from a single repository, as of the
+// state of its refs given by stateHash.
+//
+// Its lock is held while the repository is read, so that requests for
+// different repositories do not wait for each other.
+type repoCache struct {
+	mu        sync.Mutex
+	path      string
+	repo      repository.Repo
+	stateHash string
+	all       []review.Summary
+	// reviews are keyed by their full revision, so that there is at most one
+	// entry for each review in the repository.
+	reviews map[string]*review.Review
+}
+
+// Server serves the review APIs of the repositories under a root directory.
+//
+// The repositories are found again on every request for the list of them, so
+// that repositories can be added without restarting the server. What is read
+// from each repository is cached until its refs change.
+type Server struct {
+	Root string
+	// NotesRefs are the notes refs whose notes are counted by the metrics.
+	NotesRefs []string
+	// PollInterval is how often the streams of events check their
+	// repositories for updates.
+	PollInterval time.Duration
+	// KeepaliveInterval is how often idle streams of events send a comment.
+	KeepaliveInterval time.Duration
+	open              OpenFunc
+
+	// mu guards the maps of repositories, but not their caches.
+	mu       sync.Mutex
+	paths    map[string]string
+	repos    map[string]*repoCache
+	watchers map[string]*watcher
+}
+
+// New returns a server for the repositories under the given root directory,
+// which are opened with the given function.
+func New(root string, open OpenFunc) *Server {
+	return &Server{
+		Root:              root,
+		PollInterval:      DefaultPollInterval,
+		KeepaliveInterval: DefaultKeepaliveInterval,
+		open:              open,
+		paths:             make(map[string]string),
+		repos:             make(map[string]*repoCache),
+		watchers:          make(map[string]*watcher),
+	}
+}
+
+// isRepo returns whether the given directory is a git repository, either a
+// bare one or one with a work tree.
+func isRepo(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	head, headErr := os.Stat(filepath.Join(dir, "HEAD"))
+	objects, objectsErr := os.Stat(filepath.Join(dir, "objects"))
+	return headErr == nil && !head.IsDir() && objectsErr == nil && objects.IsDir()
+}
+
+// findRepos returns the paths of the repositories under the root directory,
+// by name. The name of a repository is its path relative to the root, with
+// any ".git" suffix removed, e.g. "team/project" for "team/project.git".
+func (s *Server) findRepos() (map[string]string, error) {
+	paths := make(map[string]string)
+	err := filepath.Walk(s.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || !isRepo(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".git")
+		if name == "." || name == "" {
+			name = filepath.Base(s.Root)
+		}
+		paths[name] = path
+		// Repositories are not nested, and their git directories can be large.
+		return filepath.SkipDir
+	})
+	return paths, err
+}
+
+// Repos returns the names of the repositories under the root directory.
+func (s *Server) Repos() ([]string, error) {
+	paths, err := s.findRepos()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.paths = paths
+	s.mu.Unlock()
+	var names []string
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// cached returns the cache of the named repository, locked, which is emptied
+// if the refs of the repository have changed since it was filled.
+//
+// The caller must unlock the cache when it is done with it. The server's lock
+// must not be held, as it is only held while the cache is found, and not
+// while the repository is read.
+func (s *Server) cached(name string) (*repoCache, error) {
+	s.mu.Lock()
+	c, ok := s.repos[name]
+	if !ok {
+		c = &repoCache{path: s.paths[name]}
+		s.repos[name] = c
+	}
+	s.mu.Unlock()
+
+	c.mu.Lock()
+	if err := c.refresh(s.open); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	return c, nil
+}
+
+// refresh opens the repository, if it has not been yet, and empties the
+// cache if the refs of the repository have changed since it was filled.
+//
+// The cache's lock must be held.
+func (c *repoCache) refresh(open OpenFunc) error {
+	if c.repo == nil {
+		repo, err := open(c.path)
+		if err != nil {
+			return err
+		}
+		c.repo = repo
+	}
+	stateHash, err := c.repo.GetRepoStateHash()
+	if err != nil {
+		return err
+	}
+	if stateHash != c.stateHash {
+		c.stateHash = stateHash
+		c.all = nil
+		c.reviews = make(map[string]*review.Review)
+	}
+	return nil
+}
+
+// getReview returns the review of the given, possibly abbreviated, revision,
+// or nil if there is none.
+//
+// The cache's lock must be held.
+func (c *repoCache) getReview(revision string) (*review.Review, error) {
+	hash, err := c.repo.GetCommitHash(revision)
+	if err != nil {
+		return nil, nil
+	}
+	if rvw, ok := c.reviews[hash]; ok {
+		return rvw, nil
+	}
+	rvw, err := review.Get(c.repo, hash)
+	if err != nil || rvw == nil {
+		return nil, err
+	}
+	c.reviews[hash] = rvw
+	return rvw, nil
+}
+
+// summaries returns the summaries of every review in the repository.
+func (c *repoCache) summaries() []review.Summary {
+	if c.all == nil {
+		c.all = review.ListAll(c.repo)
+	}
+	return c.all
+}
+
+// route splits the given path, relative to APIPrefix, into the name of a
+// repository and the rest of the path. If no repository matches, the
+// repositories are found again, in case it was added since they last were.
+func (s *Server) route(path string) (string, string, bool) {
+	if name, rest, ok := s.match(path); ok {
+		return name, rest, true
+	}
+	if _, err := s.Repos(); err != nil {
+		return "", "", false
+	}
+	return s.match(path)
+}
+
+// match splits the given path into the name of a known repository and the
+// rest of the path. Repository names can contain slashes, so the longest
+// matching name is used.
+func (s *Server) match(path string) (string, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var match string
+	for name := range s.paths {
+		if (path == name || strings.HasPrefix(path, name+"/")) && len(name) > len(match) {
+			match = name
+		}
+	}
+	if match == "" {
+		return "", "", false
+	}
+	return match, strings.TrimPrefix(path[len(match):], "/"), true
+}
+
+// writeJSON writes the given value as the JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// ServeHTTP serves the review APIs.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "only GET and POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Path == MetricsPath && r.Method != http.MethodPost {
+		s.serveMetrics(w)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, APIPrefix), "/")
+	if !strings.HasPrefix(r.URL.Path, APIPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	if path == "" {
+		names, err := s.Repos()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, names)
+		return
+	}
+	name, rest, ok := s.route(path)
+	if !ok {
+		http.Error(w, "there is no repository at "+path, http.StatusNotFound)
+		return
+	}
+	s.serveRepo(w, r, name, rest)
+}
+
+// serveMetrics serves the metrics of every repository.
+func (s *Server) serveMetrics(w http.ResponseWriter) {
+	names, err := s.Repos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	snapshots := make(map[string]*metrics.Snapshot)
+	for _, name := range names {
+		c, err := s.cached(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		snapshots[name], err = metrics.Collect(c.repo, s.NotesRefs)
+		c.mu.Unlock()
+		if err != nil {
+			http.Error(w, "failed to collect the metrics of "+name+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics.WriteAll(w, snapshots)
+}
+
+// serveRepo serves the APIs of the named repository, given the rest of the path.
+func (s *Server) serveRepo(w http.ResponseWriter, r *http.Request, name, rest string) {
+	if rest == "events" && r.Method != http.MethodPost {
+		// The stream only locks the repository while its watcher checks
+		// for updates.
+		s.serveEvents(w, r, name)
+		return
+	}
+	c, err := s.cached(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer c.mu.Unlock()
+	if r.Method == http.MethodPost {
+		if !strings.HasPrefix(rest, "reviews/") || !strings.HasSuffix(rest, "/comments") {
+			http.Error(w, "only comments can be posted", http.StatusMethodNotAllowed)
+			return
+		}
+		s.postComment(w, r, c, strings.TrimSuffix(strings.TrimPrefix(rest, "reviews/"), "/comments"))
+		return
+	}
+	switch {
+	case rest == "metrics":
+		metrics.Handler(c.repo, s.NotesRefs).ServeHTTP(w, r)
+	case rest == "reviews":
+		reviews := []review.Summary{}
+		for _, summary := range c.summaries() {
+			if summary.IsOpen() || r.URL.Query().Get("all") == "true" {
+				reviews = append(reviews, summary)
+			}
+		}
+		writeJSON(w, reviews)
+	case strings.HasPrefix(rest, "reviews/"):
+		revision := strings.TrimPrefix(rest, "reviews/")
+		rvw, err := c.getReview(revision)
+		if err != nil || rvw == nil {
+			http.Error(w, "there is no review for "+revision, http.StatusNotFound)
+			return
+		}
+		serialized, err := rvw.GetJSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(serialized + "\n"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// maxCommentBodySize is the largest request body that is read when a
+// comment is posted, in bytes.
+const maxCommentBodySize = 1 << 20
+
+// commentRequest is the body of a request to post a comment. It only has the
+// fields of a comment that clients can set, as the server fills in the rest.
+type commentRequest struct {
+	// Author, if given, must be the authenticated user.
+	Author      string              `json:"author,omitempty"`
+	Parent      string              `json:"parent,omitempty"`
+	Original    string              `json:"original,omitempty"`
+	Location    *comment.Location   `json:"location,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Resolved    *bool               `json:"resolved,omitempty"`
+	Kind        string              `json:"kind,omitempty"`
+	Delegation  *comment.Delegation `json:"delegation,omitempty"`
+}
+
+// postComment adds the comment in the body of the request to the given
+// review, authored by the authenticated user.
+//
+// Replies and edits are recorded against the full hash of the comment they
+// refer to, and only the author of a comment can edit it. Acceptances on
+// behalf of a team are only allowed for the team's leads.
+//
+// The repository's cache must be locked.
+func (s *Server) postComment(w http.ResponseWriter, r *http.Request, c *repoCache, revision string) {
+	user := User(r)
+	if user == "" {
+		http.Error(w, "comments can only be posted by authenticated users", http.StatusUnauthorized)
+		return
+	}
+	var req commentRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCommentBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "the comment is not valid: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Author != "" && req.Author != user {
+		http.Error(w, "comments can only be authored as "+user, http.StatusForbidden)
+		return
+	}
+	if err := comment.CheckKind(req.Kind); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rvw, err := review.Get(c.repo, revision)
+	if err != nil || rvw == nil {
+		http.Error(w, "there is no review for "+revision, http.StatusNotFound)
+		return
+	}
+	cmt := comment.New(user, req.Description)
+	cmt.Location = req.Location
+	cmt.Resolved = req.Resolved
+	cmt.Kind = req.Kind
+	if req.Parent != "" {
+		parent, err := review.FindThread(rvw.Comments, req.Parent)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cmt.Parent = parent.Hash
+	}
+	if req.Original != "" {
+		original, err := review.FindThread(rvw.Comments, req.Original)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if original.Comment.Author != user {
+			http.Error(w, "only the author of a comment can edit it", http.StatusForbidden)
+			return
+		}
+		cmt.Original = original.Hash
+	}
+	if req.Delegation != nil {
+		if req.Delegation.Actor != "" && req.Delegation.Actor != user {
+			http.Error(w, "delegations can only be made by "+user, http.StatusForbidden)
+			return
+		}
+		definedTeams, err := teams.Load(c.repo, rvw.Request.TargetRef)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := rvw.CheckDelegation(definedTeams, req.Delegation.Team, user); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		cmt.Delegation = &comment.Delegation{Actor: user, Team: req.Delegation.Team}
+	}
+	cmt.Timestamp = timestamp.Format(time.Now())
+	if cmt.Location == nil {
+		cmt.Location = &comment.Location{}
+	}
+	if cmt.Location.Commit == "" {
+		if cmt.Location.Commit, err = rvw.GetHeadCommit(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if cmt.Location.Path != "" {
+		if err := cmt.Location.Check(c.repo); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	hash, err := cmt.Hash()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := rvw.AddComment(cmt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"hash": hash})
+}
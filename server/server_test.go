@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+)
+
+// makeRepoDirs creates the directories of a bare repo and of a repo with a
+// work tree under the given root.
+func makeRepoDirs(t *testing.T, root string) {
+	for _, dir := range []string{"alpha.git/objects", "team/beta/.git", "notes/not-a-repo"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "alpha.git", "HEAD"), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, s *Server, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	opened := make(map[string]int)
+	repos := make(map[string]repository.Repo)
+	s := New(root, func(path string) (repository.Repo, error) {
+		opened[path]++
+		repos[path] = repository.NewMockRepoForTest()
+		return repos[path], nil
+	})
+
+	code, body := get(t, s, APIPrefix)
+	var names []string
+	if err := json.Unmarshal([]byte(body), &names); code != 200 || err != nil {
+		t.Fatalf("Unexpected list of repos: %d %s", code, body)
+	}
+	if want := []string{"alpha", "team/beta"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Unexpected repos: %v; want %v", names, want)
+	}
+
+	code, body = get(t, s, APIPrefix+"/team/beta/reviews?all=true")
+	var summaries []review.Summary
+	if err := json.Unmarshal([]byte(body), &summaries); code != 200 || err != nil || len(summaries) != 3 {
+		t.Fatalf("Unexpected reviews: %d %s", code, body)
+	}
+	code, body = get(t, s, APIPrefix+"/team/beta/reviews/"+repository.TestCommitB)
+	if code != 200 || !strings.Contains(body, `"revision": "B"`) {
+		t.Errorf("Unexpected review: %d %s", code, body)
+	}
+	code, body = get(t, s, APIPrefix+"/team/beta/metrics")
+	if code != 200 || !strings.Contains(body, "appraise_reviews 3") {
+		t.Errorf("Unexpected metrics: %d %s", code, body)
+	}
+	if opened[filepath.Join(root, "team", "beta")] != 1 {
+		t.Errorf("Unexpected number of times the repo was opened: %v", opened)
+	}
+	code, body = get(t, s, MetricsPath)
+	if code != 200 || !strings.Contains(body, `appraise_reviews{repo="alpha"} 3`) || !strings.Contains(body, `appraise_reviews{repo="team/beta"} 3`) {
+		t.Errorf("Unexpected metrics of every repo: %d %s", code, body)
+	}
+
+	if code, _ := get(t, s, APIPrefix+"/team/gamma/reviews"); code != 404 {
+		t.Errorf("Unexpected status for a missing repo: %d", code)
+	}
+	if code, _ := get(t, s, APIPrefix+"/alpha/reviews/Z"); code != 404 {
+		t.Errorf("Unexpected status for a missing review: %d", code)
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	root := t.TempDir()
+	makeRepoDirs(t, root)
+	repo := repository.NewMockRepoForTest()
+	s := New(root, func(path string) (repository.Repo, error) {
+		return repo, nil
+	})
+	if _, err := s.Repos(); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := get(t, s, APIPrefix+"/alpha/reviews?all=true"); strings.Count(body, `"revision"`) != 3 {
+		t.Fatalf("Unexpected reviews: %s", body)
+	}
+	c := s.repos["alpha"]
+	c.all = c.all[:1]
+	if _, body := get(t, s, APIPrefix+"/alpha/reviews?all=true"); strings.Count(body, `"revision"`) != 1 {
+		t.Errorf("The cached reviews were not used: %s", body)
+	}
+	// Changing the refs of the repo empties the cache.
+	repo.SetRef("refs/heads/new", repository.TestCommitA, "")
+	if _, body := get(t, s, APIPrefix+"/alpha/reviews?all=true"); strings.Count(body, `"revision"`) != 3 {
+		t.Errorf("The cache was not emptied: %s", body)
+	}
+	// Only reviews are cached, once each, however they are named.
+	for _, revision := range []string{repository.TestCommitA, "Z", repository.TestCommitB, repository.TestCommitB} {
+		get(t, s, APIPrefix+"/alpha/reviews/"+revision)
+	}
+	if len(c.reviews) != 1 || c.reviews[repository.TestCommitB] == nil {
+		t.Errorf("Unexpected cached reviews: %v", c.reviews)
+	}
+}
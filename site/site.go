@@ -0,0 +1,169 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package site renders reviews as a static website, with an index of every
+// review and a page for each one, which can be published by any web server.
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/timestamp"
+)
+
+// The files written to the site's directory, besides the review pages.
+const (
+	IndexFile      = "index.html"
+	StylesheetFile = "style.css"
+	// ReviewsDir holds a page for each review, named after its revision.
+	ReviewsDir = "reviews"
+)
+
+// Review is a review, along with what is shown about it on the site.
+type Review struct {
+	*review.Review
+	// Status is the status of the review, as shown by the list command.
+	Status string
+	// Diff is the diff of the review, which is empty if it is unavailable.
+	Diff string
+	// DiffError explains why the diff is unavailable, if it is.
+	DiffError string
+}
+
+// Title returns the first line of the review's description.
+func (r Review) Title() string {
+	return strings.SplitN(strings.TrimSpace(r.Request.Description), "\n", 2)[0]
+}
+
+// Page returns the path of the review's page, relative to the site's root.
+func (r Review) Page() string {
+	return ReviewsDir + "/" + r.Revision + ".html"
+}
+
+// DiffLine is a line of a diff, with the class it is styled with.
+type DiffLine struct {
+	Class string
+	Text  string
+}
+
+// diffLines splits the given diff into lines, classifying each by whether
+// it is a header, a hunk header, an addition, a deletion, or context.
+func diffLines(diff string) []DiffLine {
+	var lines []DiffLine
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		class := "context"
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			class = "header"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		lines = append(lines, DiffLine{class, line})
+	}
+	return lines
+}
+
+// threadStatus returns the status of a comment thread, as shown by the show command.
+func threadStatus(thread review.CommentThread) string {
+	if thread.Resolved == nil {
+		return "fyi"
+	}
+	if *thread.Resolved {
+		return "lgtm"
+	}
+	return "needs work"
+}
+
+// formatTimestamp formats a timestamp of the form "0123456789" in UTC,
+// leaving timestamps that are not of that form alone.
+func formatTimestamp(ts string) string {
+	if _, err := timestamp.Normalize(ts); err != nil || ts == "" {
+		return ts
+	}
+	return timestamp.Time(ts).UTC().Format("2006-01-02 15:04 MST")
+}
+
+var templates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"diffLines":    diffLines,
+	"threadStatus": threadStatus,
+	"time":         formatTimestamp,
+	"short": func(hash string) string {
+		if len(hash) > 12 {
+			return hash[:12]
+		}
+		return hash
+	},
+}).Parse(pageTemplates))
+
+// Write renders the given reviews into a static website in the given
+// directory, which is created if it does not exist. Existing files with
+// the same names as those of the site are overwritten.
+func Write(dir, title string, reviews []Review) error {
+	if err := os.MkdirAll(filepath.Join(dir, ReviewsDir), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, StylesheetFile), []byte(stylesheet), 0644); err != nil {
+		return err
+	}
+	generated := time.Now().UTC().Format("2006-01-02 15:04 MST")
+	index := struct {
+		Title     string
+		Root      string
+		Generated string
+		Reviews   []Review
+	}{title, "", generated, reviews}
+	if err := render(filepath.Join(dir, IndexFile), "index", index); err != nil {
+		return err
+	}
+	for _, r := range reviews {
+		page := struct {
+			Title     string
+			Root      string
+			Generated string
+			Review    Review
+		}{title, "../", generated, r}
+		if err := render(filepath.Join(dir, filepath.FromSlash(r.Page())), "review", page); err != nil {
+			return fmt.Errorf("failed to render review %.12s: %v", r.Revision, err)
+		}
+	}
+	return nil
+}
+
+// render writes the given template, executed with the given data, to the
+// file at the given path.
+func render(path, name string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := templates.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+func TestWrite(t *testing.T) {
+	resolved := false
+	r := Review{
+		Review: &review.Review{Summary: &review.Summary{
+			Revision: "0123456789abcdef",
+			Request: request.Request{
+				Timestamp:   "0000000001",
+				Requester:   "alice@example.com",
+				Reviewers:   []string{"bob@example.com"},
+				Description: "Fix <script> handling\n\nMore details",
+			},
+			Comments: []review.CommentThread{{
+				Hash:     "fedcba9876543210",
+				Comment:  comment.Comment{Author: "bob@example.com", Description: "Please escape this"},
+				Resolved: &resolved,
+			}},
+		}},
+		Status: "pending",
+		Diff:   "diff --git a/x b/x\n@@ -1 +1 @@\n-old\n+new\n",
+	}
+	dir := t.TempDir()
+	if err := Write(dir, "Reviews", []Review{r}); err != nil {
+		t.Fatal(err)
+	}
+	read := func(path string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+	index := read(IndexFile)
+	for _, want := range []string{`href="reviews/0123456789abcdef.html"`, "Fix &lt;script&gt; handling", "1 reviews"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("The index does not contain %q:\n%s", want, index)
+		}
+	}
+	page := read(r.Page())
+	for _, want := range []string{
+		`href="../style.css"`,
+		"More details",
+		`id="fedcba9876543210"`,
+		"Please escape this",
+		"needs work",
+		`<span class="del">-old</span>`,
+		`<span class="add">&#43;new</span>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("The review page does not contain %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<script>") {
+		t.Errorf("The review page contains unescaped HTML:\n%s", page)
+	}
+	read(StylesheetFile)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+// pageTemplates are the templates of the pages of the site. The "index" and
+// "review" templates each render a whole page.
+const pageTemplates = `
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Title}}</a></header>
+{{end}}
+
+{{define "footer"}}<footer>Generated by git-appraise on {{.Generated}}</footer>
+</body>
+</html>
+{{end}}
+
+{{define "index"}}{{template "header" .}}<h1>{{len .Reviews}} reviews</h1>
+<table>
+<tr><th>Status</th><th>Review</th><th>Requester</th><th>Requested</th><th>Description</th></tr>
+{{range .Reviews}}<tr class="{{.Status}}">
+<td class="status">{{.Status}}</td>
+<td><a href="{{.Page}}"><code>{{short .Revision}}</code></a></td>
+<td>{{.Request.Requester}}</td>
+<td>{{time .Request.Timestamp}}</td>
+<td><a href="{{.Page}}">{{.Title}}</a></td>
+</tr>
+{{end}}</table>
+{{template "footer" .}}{{end}}
+
+{{define "thread"}}<div class="thread" id="{{.Hash}}">
+<div class="comment {{threadStatus .}}">
+<div class="meta"><a href="#{{.Hash}}"><code>{{short .Hash}}</code></a> {{.Comment.Author}}, {{time .Comment.Timestamp}}: <span class="status">{{threadStatus .}}</span>
+{{with .Comment.Location}}{{if .Path}}<br>on <code>{{.Path}}{{with .Range}}{{if .StartLine}}:{{.StartLine}}{{end}}{{end}}</code>{{end}}{{end}}</div>
+<pre class="description">{{.Comment.Description}}</pre>
+</div>
+{{range .Children}}{{template "thread" .}}{{end}}</div>
+{{end}}
+
+{{define "review"}}{{template "header" .}}{{with .Review}}<h1>{{.Title}}</h1>
+<table class="details">
+<tr><th>Review</th><td><code>{{.Revision}}</code></td></tr>
+<tr><th>Status</th><td class="status {{.Status}}">{{.Status}}</td></tr>
+<tr><th>Requester</th><td>{{.Request.Requester}}</td></tr>
+<tr><th>Requested</th><td>{{time .Request.Timestamp}}</td></tr>
+<tr><th>Reviewers</th><td>{{range $i, $r := .Request.Reviewers}}{{if $i}}, {{end}}{{$r}}{{end}}</td></tr>
+{{if .Request.CC}}<tr><th>CC</th><td>{{range $i, $c := .Request.CC}}{{if $i}}, {{end}}{{$c}}{{end}}</td></tr>
+{{end}}<tr><th>Review ref</th><td><code>{{.Request.ReviewRef}}</code></td></tr>
+<tr><th>Target ref</th><td><code>{{.Request.TargetRef}}</code></td></tr>
+</table>
+<pre class="description">{{.Request.Description}}</pre>
+<h2>Comments</h2>
+{{range .Comments}}{{template "thread" .}}{{else}}<p>There are no comments.</p>
+{{end}}<h2>Diff</h2>
+{{if .Diff}}<pre class="diff">{{range diffLines .Diff}}<span class="{{.Class}}">{{.Text}}</span>
+{{end}}</pre>
+{{else if .DiffError}}<p>The diff is unavailable: {{.DiffError}}</p>
+{{else}}<p>There are no changes.</p>
+{{end}}{{end}}{{template "footer" .}}{{end}}
+`
+
+// stylesheet is the stylesheet shared by every page of the site.
+const stylesheet = `body { font-family: sans-serif; margin: 0 auto; max-width: 960px; padding: 1em; }
+header { border-bottom: 1px solid #ccc; margin-bottom: 1em; padding-bottom: 0.5em; }
+footer { border-top: 1px solid #ccc; color: #666; margin-top: 2em; padding-top: 0.5em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+pre { white-space: pre-wrap; }
+.accepted .status, .submitted .status, .lgtm > .meta .status { color: #080; }
+.rejected .status, .danger .status, .needs.work > .meta .status { color: #c00; }
+.thread { margin-left: 1.5em; }
+.comment { border-left: 3px solid #ccc; margin: 0.5em 0; padding-left: 0.5em; }
+.comment.lgtm { border-color: #080; }
+.comment.needs.work { border-color: #c00; }
+.meta { color: #666; font-size: 0.9em; }
+.diff { background: #f8f8f8; font-size: 0.85em; padding: 0.5em; }
+.diff .header { font-weight: bold; }
+.diff .hunk { color: #06c; }
+.diff .add { background: #e6ffe6; }
+.diff .del { background: #ffe6e6; }
+`
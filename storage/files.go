@@ -0,0 +1,202 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/git-appraise/repository"
+)
+
+// Files is a storage that keeps the notes in plain files under a directory,
+// with the notes of each object in the file <notes ref>/<object hash>, in the
+// same format as the contents of a git note.
+type Files string
+
+// refDir returns the directory of the notes under the given ref.
+func (f Files) refDir(notesRef string) (string, error) {
+	for _, part := range strings.Split(notesRef, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid notes ref %q", notesRef)
+		}
+	}
+	return filepath.Join(string(f), filepath.FromSlash(notesRef)), nil
+}
+
+// notesPath returns the path of the file of notes on the given object.
+func (f Files) notesPath(notesRef, object string) (string, error) {
+	dir, err := f.refDir(notesRef)
+	if err != nil {
+		return "", err
+	}
+	if object == "" || strings.HasPrefix(object, ".") || strings.ContainsAny(object, `/\`) {
+		return "", fmt.Errorf("invalid object %q", object)
+	}
+	return filepath.Join(dir, object), nil
+}
+
+// readNotes reads the notes in the given file.
+func readNotes(path string) ([]repository.Note, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return repository.SplitNotes(bytes.TrimSuffix(contents, []byte("\n"))), nil
+}
+
+// GetNotes reads the notes from the given ref that annotate the given revision.
+func (f Files) GetNotes(notesRef, revision string) []repository.Note {
+	path, err := f.notesPath(notesRef, revision)
+	if err != nil {
+		return nil
+	}
+	notes, err := readNotes(path)
+	if err != nil {
+		return nil
+	}
+	return notes
+}
+
+// GetAllNotes reads the notes under the given ref for every object that they annotate.
+func (f Files) GetAllNotes(notesRef string) (map[string][]repository.Note, error) {
+	objects, err := f.ListNotedObjects(notesRef)
+	if err != nil {
+		return nil, err
+	}
+	dir, _ := f.refDir(notesRef)
+	allNotes := make(map[string][]repository.Note)
+	for _, object := range objects {
+		notes, err := readNotes(filepath.Join(dir, object))
+		if err != nil {
+			return nil, err
+		}
+		allNotes[object] = notes
+	}
+	return allNotes, nil
+}
+
+// AppendNote appends a note to a revision under the given ref.
+func (f Files) AppendNote(notesRef, revision string, note repository.Note) error {
+	path, err := f.notesPath(notesRef, revision)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(append([]byte(nil), note...), '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ListNotedObjects returns the hashes of every object annotated by notes in the given ref.
+func (f Files) ListNotedObjects(notesRef string) ([]string, error) {
+	dir, err := f.refDir(notesRef)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var objects []string
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			objects = append(objects, entry.Name())
+		}
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
+// SetNotes replaces the notes on an object under the given ref, removing them
+// if there are none.
+func (f Files) SetNotes(notesRef, object string, notes []repository.Note) error {
+	path, err := f.notesPath(notesRef, object)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var contents []byte
+	for _, note := range notes {
+		contents = append(append(contents, note...), '\n')
+	}
+	// The notes are written to a temporary file that replaces the old one, so
+	// that they are never left half-written. It is hidden from ListNotedObjects.
+	temp := filepath.Join(filepath.Dir(path), "."+object+".tmp")
+	if err := ioutil.WriteFile(temp, contents, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// ListNotesRefs returns the refs that have notes in the storage.
+func (f Files) ListNotesRefs() ([]string, error) {
+	root := string(f)
+	var notesRefs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return filepath.SkipDir
+		}
+		if err != nil || !info.IsDir() || path == root {
+			return err
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Mode().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				notesRefs = append(notesRefs, filepath.ToSlash(rel))
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(notesRefs)
+	return notesRefs, nil
+}
@@ -0,0 +1,451 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storage abstracts where the notes that hold review metadata are
+// read from and written to.
+//
+// Git notes are the canonical format, and every repository.Repo is a Storage
+// backed by its own notes. Other backends, such as a database kept by a
+// server, or plain files, can be used where git notes are too slow or
+// unavailable, by wrapping a repository with New so that the review packages
+// read and write its notes through them. The commands do so for the Files
+// storage configured by the DirConfig key.
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/git-appraise/config"
+	"github.com/google/git-appraise/repository"
+)
+
+// DirConfig is the config key of the directory of a Files storage that a
+// repository's notes are kept in, instead of its git notes.
+const DirConfig = "appraise.storage.dir"
+
+// notesRefPrefix is the prefix of every notes ref.
+const notesRefPrefix = "refs/notes/"
+
+// Storage reads and writes notes, each of which annotates an object under a
+// notes ref.
+type Storage interface {
+	// GetNotes reads the notes from the given ref that annotate the given revision.
+	GetNotes(notesRef, revision string) []repository.Note
+
+	// GetAllNotes reads the notes under the given ref for every object that
+	// they annotate, as a mapping from the hash of the object to its notes.
+	GetAllNotes(notesRef string) (map[string][]repository.Note, error)
+
+	// AppendNote appends a note to a revision under the given ref.
+	AppendNote(notesRef, revision string, note repository.Note) error
+
+	// ListNotedObjects returns the hashes of every object annotated by notes
+	// in the given ref.
+	ListNotedObjects(notesRef string) ([]string, error)
+}
+
+// Backend is a storage that a repository's notes can be kept in instead of
+// its git notes, with New.
+type Backend interface {
+	Storage
+
+	// SetNotes replaces the notes on an object under the given ref, removing
+	// them if there are none.
+	SetNotes(notesRef, object string, notes []repository.Note) error
+
+	// ListNotesRefs returns the refs that have notes in the storage.
+	ListNotesRefs() ([]string, error)
+}
+
+// storageRepo is a repository whose notes are read from and written to a
+// storage, rather than to its git notes.
+type storageRepo struct {
+	repository.Repo
+	storage Backend
+
+	// mu guards states.
+	mu sync.Mutex
+	// states holds the notes of every state of a notes ref that has been
+	// returned by GetCommitHash, so that SetRef can restore them.
+	states map[string]map[string][]repository.Note
+}
+
+// New returns the given repository, with its notes read from and written to
+// the given storage.
+//
+// The git notes of the repository remain the canonical copy that is pushed
+// and pulled: the notes in the storage are copied to them before they are
+// pushed, and the notes that are pulled are copied to the storage.
+func New(repo repository.Repo, storage Backend) repository.Repo {
+	return &storageRepo{Repo: repo, storage: storage}
+}
+
+// FromConfig returns the given repository with its notes kept in the Files
+// storage configured by the DirConfig key, or the repository itself if no
+// storage is configured.
+//
+// A relative directory is relative to the repository's git directory. If the
+// directory does not exist yet, it is filled with the repository's git notes.
+func FromConfig(repo repository.Repo) (repository.Repo, error) {
+	dir, err := config.Get(repo, DirConfig)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return repo, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo.GetGitDir(), dir)
+	}
+	stored := &storageRepo{Repo: repo, storage: Files(dir)}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := stored.importNotes(notesRefPrefix); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// GetNotes reads the notes from the given ref that annotate the given revision.
+func (r *storageRepo) GetNotes(notesRef, revision string) []repository.Note {
+	return r.storage.GetNotes(notesRef, revision)
+}
+
+// GetAllNotes reads the contents of the notes under the given ref for every commit.
+//
+// As with git notes, the notes of objects that are not commits in the
+// repository are left out.
+func (r *storageRepo) GetAllNotes(notesRef string) (map[string][]repository.Note, error) {
+	allNotes, err := r.storage.GetAllNotes(notesRef)
+	if err != nil {
+		return nil, err
+	}
+	for object := range allNotes {
+		if r.VerifyCommit(object) != nil {
+			delete(allNotes, object)
+		}
+	}
+	return allNotes, nil
+}
+
+// EachNotes calls visit with the notes under the given refs for every commit
+// that is annotated in the first of them, reading each commit's notes only
+// when it is visited.
+func (r *storageRepo) EachNotes(notesRefs []string, visit func(commit string, notes [][]repository.Note) error) error {
+	if len(notesRefs) == 0 {
+		return nil
+	}
+	objects, err := r.storage.ListNotedObjects(notesRefs[0])
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if r.VerifyCommit(object) != nil {
+			continue
+		}
+		notes := make([][]repository.Note, len(notesRefs))
+		for i, notesRef := range notesRefs {
+			notes[i] = r.storage.GetNotes(notesRef, object)
+		}
+		if err := visit(object, notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendNote appends a note to a revision under the given ref.
+func (r *storageRepo) AppendNote(notesRef, revision string, note repository.Note) error {
+	return r.storage.AppendNote(notesRef, revision, note)
+}
+
+// ListNotedRevisions returns the commits that are annotated by notes in the given ref.
+func (r *storageRepo) ListNotedRevisions(notesRef string) []string {
+	objects, err := r.storage.ListNotedObjects(notesRef)
+	if err != nil {
+		return nil
+	}
+	var revisions []string
+	for _, object := range objects {
+		if r.VerifyCommit(object) == nil {
+			revisions = append(revisions, object)
+		}
+	}
+	return revisions
+}
+
+// ListNotedObjects returns the hashes of every object annotated by notes in the given ref.
+func (r *storageRepo) ListNotedObjects(notesRef string) ([]string, error) {
+	return r.storage.ListNotedObjects(notesRef)
+}
+
+// notesState returns the notes under the given ref in the storage, along with
+// a hash of them that identifies their state, which is empty if there are none.
+func (r *storageRepo) notesState(notesRef string) (map[string][]repository.Note, string, error) {
+	allNotes, err := r.storage.GetAllNotes(notesRef)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(allNotes) == 0 {
+		return nil, "", nil
+	}
+	var objects []string
+	for object := range allNotes {
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+	hash := sha1.New()
+	for _, object := range objects {
+		fmt.Fprintf(hash, "%s %d\n", object, len(allNotes[object]))
+		for _, note := range allNotes[object] {
+			fmt.Fprintf(hash, "%d\n%s\n", len(note), note)
+		}
+	}
+	return allNotes, fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// GetRepoStateHash returns a hash which embodies the entire current state of
+// the repository, including the notes in the storage.
+func (r *storageRepo) GetRepoStateHash() (string, error) {
+	stateHash, err := r.Repo.GetRepoStateHash()
+	if err != nil {
+		return "", err
+	}
+	notesRefs, err := r.storage.ListNotesRefs()
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s\n", stateHash)
+	for _, notesRef := range notesRefs {
+		_, state, err := r.notesState(notesRef)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %s\n", notesRef, state)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// GetCommitHash returns the hash of the commit pointed to by the given ref.
+//
+// For a notes ref, it instead returns a hash of the notes under it in the
+// storage, which SetRef can restore them to.
+func (r *storageRepo) GetCommitHash(ref string) (string, error) {
+	if !strings.HasPrefix(ref, notesRefPrefix) {
+		return r.Repo.GetCommitHash(ref)
+	}
+	allNotes, state, err := r.notesState(ref)
+	if err != nil {
+		return "", err
+	}
+	if state == "" {
+		return "", fmt.Errorf("there are no notes under %q", ref)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.states == nil {
+		r.states = make(map[string]map[string][]repository.Note)
+	}
+	r.states[state] = allNotes
+	return state, nil
+}
+
+// SetRef sets the commit pointed to by the specified ref to `newCommitHash`,
+// iff the ref currently points `previousCommitHash`, or does not exist if
+// that is empty.
+//
+// For a notes ref, the hashes are those returned by GetCommitHash, and the
+// notes under it in the storage are restored to the new state, or removed if
+// it is empty.
+func (r *storageRepo) SetRef(ref, newCommitHash, previousCommitHash string) error {
+	if !strings.HasPrefix(ref, notesRefPrefix) {
+		return r.Repo.SetRef(ref, newCommitHash, previousCommitHash)
+	}
+	current, state, err := r.notesState(ref)
+	if err != nil {
+		return err
+	}
+	if state != previousCommitHash {
+		return fmt.Errorf("the notes under %q are not in the state %q", ref, previousCommitHash)
+	}
+	r.mu.Lock()
+	restored, ok := r.states[newCommitHash]
+	r.mu.Unlock()
+	if !ok && newCommitHash != "" {
+		return fmt.Errorf("unknown state %q of the notes under %q", newCommitHash, ref)
+	}
+	for object := range current {
+		if _, ok := restored[object]; !ok {
+			if err := r.storage.SetNotes(ref, object, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for object, notes := range restored {
+		if err := r.storage.SetNotes(ref, object, notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportNotes copies the notes in the storage to the git notes of the
+// repository, so that they can be pushed.
+func (r *storageRepo) exportNotes() error {
+	notesRefs, err := r.storage.ListNotesRefs()
+	if err != nil {
+		return err
+	}
+	for _, notesRef := range notesRefs {
+		if _, err := Copy(r.Repo, r.storage, notesRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importNotes copies the git notes of the repository under the refs that
+// match the given pattern to the storage, once they have been pulled.
+func (r *storageRepo) importNotes(notesRefPattern string) error {
+	refs, err := r.Repo.ListRefs(strings.TrimSuffix(notesRefPattern, "*"))
+	if err != nil {
+		return err
+	}
+	for notesRef := range refs {
+		if strings.HasPrefix(notesRef, notesRefPrefix+"remotes/") {
+			continue
+		}
+		if _, err := Copy(r.storage, r.Repo, notesRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushNotes pushes git notes to a remote repo, once the notes in the storage
+// have been copied to them.
+func (r *storageRepo) PushNotes(remote, notesRefPattern string) error {
+	if err := r.exportNotes(); err != nil {
+		return err
+	}
+	return r.Repo.PushNotes(remote, notesRefPattern)
+}
+
+// PushNotesAndArchive pushes the given notes and archive refs to a remote
+// repo, once the notes in the storage have been copied to them.
+func (r *storageRepo) PushNotesAndArchive(remote, notesRefPattern, archiveRefPattern string) error {
+	if err := r.exportNotes(); err != nil {
+		return err
+	}
+	return r.Repo.PushNotesAndArchive(remote, notesRefPattern, archiveRefPattern)
+}
+
+// CreateBundle writes a bundle of the refs that match the given patterns,
+// once the notes in the storage have been copied to the git notes.
+func (r *storageRepo) CreateBundle(path string, refPatterns ...string) (map[string]string, error) {
+	if err := r.exportNotes(); err != nil {
+		return nil, err
+	}
+	return r.Repo.CreateBundle(path, refPatterns...)
+}
+
+// PullNotes pulls the given notes from a remote repo, and copies them to the storage.
+func (r *storageRepo) PullNotes(remote, notesRefPattern string) error {
+	if err := r.Repo.PullNotes(remote, notesRefPattern); err != nil {
+		return err
+	}
+	return r.importNotes(notesRefPattern)
+}
+
+// PullNotesAndArchive pulls the given notes and archives from a remote repo,
+// and copies the notes to the storage.
+func (r *storageRepo) PullNotesAndArchive(remote, notesRefPattern, archiveRefPattern string) error {
+	if err := r.Repo.PullNotesAndArchive(remote, notesRefPattern, archiveRefPattern); err != nil {
+		return err
+	}
+	return r.importNotes(notesRefPattern)
+}
+
+// PullNotesAndArchiveWithProgress is PullNotesAndArchive, with the progress
+// of the fetch written to the given writer.
+func (r *storageRepo) PullNotesAndArchiveWithProgress(ctx context.Context, remote, notesRefPattern, archiveRefPattern string, progress io.Writer) error {
+	if err := r.Repo.PullNotesAndArchiveWithProgress(ctx, remote, notesRefPattern, archiveRefPattern, progress); err != nil {
+		return err
+	}
+	return r.importNotes(notesRefPattern)
+}
+
+// PullNotesAndArchiveFromBundle pulls the given notes and archives from a
+// bundle, and copies the notes to the storage.
+func (r *storageRepo) PullNotesAndArchiveFromBundle(path, notesRefPattern, archiveRefPattern string) error {
+	if err := r.Repo.PullNotesAndArchiveFromBundle(path, notesRefPattern, archiveRefPattern); err != nil {
+		return err
+	}
+	return r.importNotes(notesRefPattern)
+}
+
+// MergeNotes merges the notes fetched from a remote repo into the local
+// notes, and copies them to the storage.
+func (r *storageRepo) MergeNotes(remote, notesRefPattern string) error {
+	if err := r.Repo.MergeNotes(remote, notesRefPattern); err != nil {
+		return err
+	}
+	return r.importNotes(notesRefPattern)
+}
+
+// Copy appends the notes under the given ref in the source storage to the
+// destination storage, skipping those that the destination already has, e.g.
+// to populate a storage from the canonical git notes after they are pulled.
+//
+// It returns the number of notes that were appended.
+func Copy(dst, src Storage, notesRef string) (int, error) {
+	allNotes, err := src.GetAllNotes(notesRef)
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for object, notes := range allNotes {
+		existing := make(map[string]bool)
+		for _, note := range dst.GetNotes(notesRef, object) {
+			existing[string(note)] = true
+		}
+		for _, note := range notes {
+			if len(note) == 0 || existing[string(note)] {
+				continue
+			}
+			if err := dst.AppendNote(notesRef, object, note); err != nil {
+				return copied, err
+			}
+			existing[string(note)] = true
+			copied++
+		}
+	}
+	return copied, nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/git-appraise/repository"
+	"github.com/google/git-appraise/review"
+	"github.com/google/git-appraise/review/analyses"
+	"github.com/google/git-appraise/review/ci"
+	"github.com/google/git-appraise/review/comment"
+	"github.com/google/git-appraise/review/request"
+)
+
+var notesRefs = []string{request.Ref, comment.Ref, ci.Ref, analyses.Ref}
+
+func TestFiles(t *testing.T) {
+	files := Files(t.TempDir())
+	if objects, err := files.ListNotedObjects(request.Ref); err != nil || len(objects) != 0 {
+		t.Errorf("Unexpected objects in an empty storage: %v, %v", objects, err)
+	}
+	for _, note := range []string{"first", "second"} {
+		if err := files.AppendNote(request.Ref, "abc", repository.Note(note)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notes := files.GetNotes(request.Ref, "abc")
+	if len(notes) != 2 || string(notes[0]) != "first" || string(notes[1]) != "second" {
+		t.Errorf("Unexpected notes: %q", notes)
+	}
+	if err := files.AppendNote("refs/notes/../escape", "abc", repository.Note("x")); err == nil {
+		t.Errorf("A note was written outside of the storage")
+	}
+	if err := files.AppendNote(request.Ref, "../abc", repository.Note("x")); err == nil {
+		t.Errorf("A note was written for an invalid object")
+	}
+}
+
+func TestStorageRepo(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	files := Files(t.TempDir())
+	for _, ref := range notesRefs {
+		if _, err := Copy(files, repo, ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if copied, err := Copy(files, repo, comment.Ref); err != nil || copied != 0 {
+		t.Errorf("Unexpected notes copied a second time: %d, %v", copied, err)
+	}
+
+	stored := New(repo, files)
+	want := review.ListAll(repo)
+	got := review.ListAll(stored)
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected reviews from the storage: %d; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Revision != want[i].Revision || len(got[i].Comments) != len(want[i].Comments) {
+			t.Errorf("Unexpected review from the storage: %+v; want %+v", got[i], want[i])
+		}
+	}
+
+	rvw, err := review.Get(stored, repository.TestCommitB)
+	if err != nil || rvw == nil {
+		t.Fatalf("Failed to read a review from the storage: %v", err)
+	}
+	if err := rvw.AddComment(comment.New("user@example.com", "Stored")); err != nil {
+		t.Fatal(err)
+	}
+	inStorage := func(r repository.Repo) bool {
+		for _, note := range r.GetNotes(comment.Ref, repository.TestCommitB) {
+			if strings.Contains(string(note), "Stored") {
+				return true
+			}
+		}
+		return false
+	}
+	if !inStorage(stored) || inStorage(repo) {
+		t.Errorf("The comment was not written to the storage alone")
+	}
+}
+
+func TestFilesSetNotes(t *testing.T) {
+	files := Files(t.TempDir())
+	if refs, err := files.ListNotesRefs(); err != nil || len(refs) != 0 {
+		t.Errorf("Unexpected refs in an empty storage: %v, %v", refs, err)
+	}
+	notes := []repository.Note{repository.Note("first"), repository.Note("second")}
+	if err := files.SetNotes(comment.Ref, "abc", notes); err != nil {
+		t.Fatal(err)
+	}
+	if err := files.AppendNote(request.Ref, "def", repository.Note("request")); err != nil {
+		t.Fatal(err)
+	}
+	if got := files.GetNotes(comment.Ref, "abc"); len(got) != 2 || string(got[1]) != "second" {
+		t.Errorf("Unexpected notes: %q", got)
+	}
+	if refs, err := files.ListNotesRefs(); err != nil || len(refs) != 2 || refs[0] != comment.Ref || refs[1] != request.Ref {
+		t.Errorf("Unexpected refs: %v, %v", refs, err)
+	}
+	if err := files.SetNotes(comment.Ref, "abc", nil); err != nil {
+		t.Fatal(err)
+	}
+	if objects, err := files.ListNotedObjects(comment.Ref); err != nil || len(objects) != 0 {
+		t.Errorf("Unexpected objects after removing their notes: %v, %v", objects, err)
+	}
+}
+
+func TestStorageRepoRefs(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	files := Files(t.TempDir())
+	if _, err := Copy(files, repo, comment.Ref); err != nil {
+		t.Fatal(err)
+	}
+	stored := New(repo, files)
+
+	stateHash, err := stored.GetRepoStateHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prior, err := stored.GetCommitHash(comment.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stored.AppendNote(comment.Ref, repository.TestCommitB, repository.Note("added")); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := stored.GetRepoStateHash(); err != nil || changed == stateHash {
+		t.Errorf("The state hash did not change with the notes in the storage: %v", err)
+	}
+	added, err := stored.GetCommitHash(comment.Ref)
+	if err != nil || added == prior {
+		t.Fatalf("The notes ref did not change with the notes in the storage: %v", err)
+	}
+	if err := stored.SetRef(comment.Ref, prior, prior); err == nil {
+		t.Errorf("The notes were restored from a state that they were not in")
+	}
+	if err := stored.SetRef(comment.Ref, prior, added); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := stored.GetCommitHash(comment.Ref); err != nil || restored != prior {
+		t.Errorf("The notes were not restored: %q, %v", restored, err)
+	}
+	if err := stored.SetRef(comment.Ref, "", prior); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stored.GetCommitHash(comment.Ref); err == nil {
+		t.Errorf("The notes were not removed")
+	}
+}
+
+func TestStorageRepoPush(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	stored := New(repo, Files(t.TempDir()))
+	if err := stored.AppendNote(comment.Ref, repository.TestCommitB, repository.Note("pushed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stored.PushNotes("origin", "refs/notes/devtools/*"); err != nil {
+		t.Fatal(err)
+	}
+	notes := repo.GetNotes(comment.Ref, repository.TestCommitB)
+	if len(notes) == 0 || string(notes[len(notes)-1]) != "pushed" {
+		t.Errorf("The notes in the storage were not copied to the git notes before pushing: %q", notes)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	repo := repository.NewMockRepoForTest()
+	if got, err := FromConfig(repo); err != nil || got != repo {
+		t.Errorf("Unexpected repository without a configured storage: %v, %v", got, err)
+	}
+	dir := filepath.Join(t.TempDir(), "notes")
+	repo.SetConfig(DirConfig, dir)
+	got, err := FromConfig(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.(*storageRepo); !ok {
+		t.Errorf("The configured storage was not used: %T", got)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("The storage directory was not created: %v", err)
+	}
+}
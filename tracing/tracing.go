@@ -0,0 +1,371 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing records OpenTelemetry traces of the commands that are run,
+// and of the git commands that they run in turn, and exports them to an
+// OTLP collector, so that the time spent by the tool can be analyzed.
+//
+// Tracing is disabled unless an OTLP endpoint is set in the environment, as
+// described by the OpenTelemetry specification:
+//
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or OTEL_EXPORTER_OTLP_ENDPOINT
+//	OTEL_EXPORTER_OTLP_TRACES_HEADERS, or OTEL_EXPORTER_OTLP_HEADERS
+//	OTEL_EXPORTER_OTLP_TRACES_PROTOCOL, or OTEL_EXPORTER_OTLP_PROTOCOL
+//	OTEL_EXPORTER_OTLP_TIMEOUT
+//	OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+//	OTEL_TRACES_EXPORTER and OTEL_SDK_DISABLED
+//
+// Only the "http/json" protocol is supported. If the TRACEPARENT variable
+// holds a W3C trace context, e.g. one set by a CI system, then the spans are
+// recorded as part of that trace.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultServiceName is the name of the service that spans are
+	// attributed to, unless OTEL_SERVICE_NAME is set.
+	DefaultServiceName = "git-appraise"
+	// DefaultTimeout is how long exporting a batch of spans may take,
+	// unless OTEL_EXPORTER_OTLP_TIMEOUT is set.
+	DefaultTimeout = 10 * time.Second
+	// ProtocolJSON is the only supported OTLP protocol.
+	ProtocolJSON = "http/json"
+
+	// batchSize is the number of ended spans that are buffered before they
+	// are exported, so that long-running commands such as serve do not
+	// buffer every span until they exit.
+	batchSize = 512
+)
+
+// Attribute is a key and value describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Span is a timed operation within a trace.
+//
+// A nil span is valid, and does nothing, so that callers do not have to
+// check whether tracing is enabled.
+type Span struct {
+	tracer     *Tracer
+	traceID    string
+	spanID     string
+	parentID   string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes []Attribute
+	err        error
+}
+
+// Tracer records spans, and exports them to an OTLP collector.
+type Tracer struct {
+	// Endpoint is the URL to which spans are posted.
+	Endpoint string
+	// Headers are added to the requests that post spans, e.g. for
+	// authentication.
+	Headers map[string]string
+	// Resource are the attributes of the process recording the spans,
+	// including its service.name.
+	Resource []Attribute
+	// Client is used to post the spans.
+	Client *http.Client
+
+	mu sync.Mutex
+	// traceID and parentID are those of the trace context that the tracer
+	// was started in, if any.
+	traceID  string
+	parentID string
+	// open are the spans that have been started but not ended, innermost last.
+	open  []*Span
+	ended []*Span
+	// exportErr is the first error from exporting a batch of spans.
+	exportErr error
+	// exports tracks the batches that are being exported in the background.
+	exports sync.WaitGroup
+}
+
+// randomID returns a random, hex-encoded ID of the given number of bytes.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseTraceParent returns the trace and parent span IDs of the given W3C
+// traceparent header, or empty strings if it is not valid.
+func parseTraceParent(traceParent string) (string, string) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+	for _, id := range parts[1:3] {
+		if _, err := hex.DecodeString(id); err != nil || strings.Trim(id, "0") == "" {
+			return "", ""
+		}
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2])
+}
+
+// parsePairs parses a comma-separated list of URL-encoded key=value pairs,
+// as used by OTEL_EXPORTER_OTLP_HEADERS and OTEL_RESOURCE_ATTRIBUTES.
+func parsePairs(value string) (map[string]string, []string, error) {
+	pairs := make(map[string]string)
+	var keys []string
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("%q is not a key=value pair", pair)
+		}
+		key, err := url.QueryUnescape(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := pairs[key]; !ok {
+			keys = append(keys, key)
+		}
+		pairs[key] = value
+	}
+	return pairs, keys, nil
+}
+
+// NewFromEnv returns a tracer configured by the given environment lookup
+// function (e.g. os.Getenv), or nil if tracing is not enabled.
+func NewFromEnv(getenv func(string) string) (*Tracer, error) {
+	lookup := func(specific, general string) string {
+		if value := getenv(specific); value != "" {
+			return value
+		}
+		return getenv(general)
+	}
+	if strings.EqualFold(getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+	if exporter := getenv("OTEL_TRACES_EXPORTER"); exporter != "" && exporter != "otlp" {
+		if exporter == "none" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q; only otlp is supported", exporter)
+	}
+	endpoint := getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+	if protocol := lookup("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != ProtocolJSON {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q; only %s is supported", protocol, ProtocolJSON)
+	}
+	headers, _, err := parsePairs(lookup("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP headers: %v", err)
+	}
+	timeout := DefaultTimeout
+	if configured := lookup("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", "OTEL_EXPORTER_OTLP_TIMEOUT"); configured != "" {
+		millis, err := strconv.Atoi(configured)
+		if err != nil || millis < 0 {
+			return nil, fmt.Errorf("invalid OTLP timeout %q; expected a number of milliseconds", configured)
+		}
+		timeout = time.Duration(millis) * time.Millisecond
+	}
+	attributes, keys, err := parsePairs(getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %v", err)
+	}
+	if name := getenv("OTEL_SERVICE_NAME"); name != "" {
+		attributes["service.name"] = name
+	} else if _, ok := attributes["service.name"]; !ok {
+		attributes["service.name"] = DefaultServiceName
+	}
+	resource := []Attribute{{Key: "service.name", Value: attributes["service.name"]}}
+	for _, key := range keys {
+		if key != "service.name" {
+			resource = append(resource, Attribute{Key: key, Value: attributes[key]})
+		}
+	}
+	t := &Tracer{
+		Endpoint: endpoint,
+		Headers:  headers,
+		Resource: resource,
+		Client:   &http.Client{Timeout: timeout},
+	}
+	// Every span of the process is part of the same trace, which is that of
+	// the environment if there is one.
+	t.traceID, t.parentID = parseTraceParent(getenv("TRACEPARENT"))
+	if t.traceID == "" {
+		t.traceID = randomID(16)
+	}
+	return t, nil
+}
+
+// Start starts a span with the given name and attributes.
+//
+// The span is a child of the innermost span that has been started but not
+// yet ended. Spans started concurrently may therefore be attributed to the
+// wrong parent, but are still recorded.
+func (t *Tracer) Start(name string, attributes ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &Span{
+		tracer:     t,
+		traceID:    t.traceID,
+		spanID:     randomID(8),
+		parentID:   t.parentID,
+		name:       name,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+	if len(t.open) > 0 {
+		parent := t.open[len(t.open)-1]
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	}
+	if span.traceID == "" {
+		span.traceID = randomID(16)
+	}
+	t.open = append(t.open, span)
+	return span
+}
+
+// SetAttribute adds an attribute to the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+}
+
+// End ends the span, recording the given error, if any, as its status.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	t := s.tracer
+	t.mu.Lock()
+	s.end = time.Now()
+	s.err = err
+	for i, open := range t.open {
+		if open == s {
+			t.open = append(t.open[:i], t.open[i+1:]...)
+			break
+		}
+	}
+	t.ended = append(t.ended, s)
+	if len(t.ended) < batchSize {
+		t.mu.Unlock()
+		return
+	}
+	// The batch is exported in the background, so that the caller, e.g. a
+	// request handler of serve, does not wait for the collector.
+	batch := t.ended
+	t.ended = nil
+	t.exports.Add(1)
+	t.mu.Unlock()
+	go func() {
+		defer t.exports.Done()
+		t.recordExportError(t.export(batch))
+	}()
+}
+
+// recordExportError records the given error from exporting a batch of spans,
+// unless an earlier error has already been recorded.
+func (t *Tracer) recordExportError(err error) {
+	if err == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exportErr == nil {
+		t.exportErr = err
+	}
+}
+
+// Flush exports every span that has ended, waiting for any batches that are
+// being exported in the background, and returns the first error, if any, from
+// exporting them or any earlier batch.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.exports.Wait()
+	t.mu.Lock()
+	batch := t.ended
+	t.ended = nil
+	err := t.exportErr
+	t.exportErr = nil
+	t.mu.Unlock()
+	if len(batch) > 0 {
+		if exportErr := t.export(batch); err == nil {
+			err = exportErr
+		}
+	}
+	return err
+}
+
+// defaultTracer is the tracer used by the package-level functions.
+var defaultTracer *Tracer
+
+// SetDefault sets the tracer used by Start and Flush. A nil tracer disables
+// tracing.
+func SetDefault(t *Tracer) {
+	defaultTracer = t
+}
+
+// SetupFromEnv sets the default tracer from the environment of the process.
+func SetupFromEnv() error {
+	t, err := NewFromEnv(os.Getenv)
+	SetDefault(t)
+	return err
+}
+
+// Start starts a span of the default tracer, or returns nil if tracing is
+// disabled.
+func Start(name string, attributes ...Attribute) *Span {
+	return defaultTracer.Start(name, attributes...)
+}
+
+// Flush exports the spans of the default tracer that have ended.
+func Flush() error {
+	return defaultTracer.Flush()
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestNewFromEnv(t *testing.T) {
+	for _, disabled := range []map[string]string{
+		{},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_SDK_DISABLED": "true"},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "none"},
+	} {
+		if tracer, err := NewFromEnv(env(disabled)); err != nil || tracer != nil {
+			t.Errorf("Unexpected tracer for %v: %+v, %v", disabled, tracer, err)
+		}
+	}
+	for _, invalid := range []map[string]string{
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "zipkin"},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_EXPORTER_OTLP_HEADERS": "token"},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_EXPORTER_OTLP_TIMEOUT": "soon"},
+	} {
+		if _, err := NewFromEnv(env(invalid)); err == nil {
+			t.Errorf("Unexpected success for %v", invalid)
+		}
+	}
+	tracer, err := NewFromEnv(env(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT":       "http://localhost:4318/",
+		"OTEL_EXPORTER_OTLP_HEADERS":        "Authorization=Bearer%20secret",
+		"OTEL_EXPORTER_OTLP_TRACES_HEADERS": "X-Team=reviews",
+		"OTEL_RESOURCE_ATTRIBUTES":          "deployment.environment=ci,service.name=ignored",
+		"TRACEPARENT":                       "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracer.Endpoint != "http://localhost:4318/v1/traces" {
+		t.Errorf("Unexpected endpoint: %q", tracer.Endpoint)
+	}
+	if len(tracer.Headers) != 1 || tracer.Headers["X-Team"] != "reviews" {
+		t.Errorf("Unexpected headers: %v", tracer.Headers)
+	}
+	want := []Attribute{{"service.name", "ignored"}, {"deployment.environment", "ci"}}
+	if len(tracer.Resource) != 2 || tracer.Resource[0] != want[0] || tracer.Resource[1] != want[1] {
+		t.Errorf("Unexpected resource: %v", tracer.Resource)
+	}
+	span := tracer.Start("root")
+	if span.traceID != "0af7651916cd43dd8448eb211c80319c" || span.parentID != "b7ad6b7169203331" {
+		t.Errorf("Failed to continue the trace of the environment: %+v", span)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var received []otlpRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = append(received, req)
+	}))
+	defer server.Close()
+
+	tracer, err := NewFromEnv(env(map[string]string{
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": server.URL + "/v1/traces",
+		"OTEL_EXPORTER_OTLP_HEADERS":         "Authorization=Bearer%20secret",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := tracer.Start("git-appraise list", Attribute{"appraise.command", "list"})
+	git := tracer.Start("git notes")
+	git.End(errors.New("exit status 1"))
+	command.End(nil)
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || authorization != "Bearer secret" {
+		t.Fatalf("Unexpected export requests: %+v, with authorization %q", received, authorization)
+	}
+	resource := received[0].ResourceSpans[0]
+	if resource.Resource.Attributes[0].Value.StringValue != DefaultServiceName {
+		t.Errorf("Unexpected resource: %+v", resource.Resource)
+	}
+	spans := resource.ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].Name != "git notes" || spans[1].Name != "git-appraise list" {
+		t.Fatalf("Unexpected spans: %+v", spans)
+	}
+	if spans[0].ParentSpanID != spans[1].SpanID || spans[0].TraceID != spans[1].TraceID || spans[1].ParentSpanID != "" {
+		t.Errorf("Unexpected parents of the spans: %+v", spans)
+	}
+	if spans[0].Status.Code != statusCodeError || spans[1].Status.Code != statusCodeOK {
+		t.Errorf("Unexpected statuses of the spans: %+v", spans)
+	}
+	if err := tracer.Flush(); err != nil || len(received) != 1 {
+		t.Errorf("Unexpected export of no spans: %v", err)
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	tracer.Endpoint = missing.URL + "/v1/traces"
+	tracer.Start("lost").End(nil)
+	if err := tracer.Flush(); err == nil {
+		t.Error("Unexpected success exporting to a missing endpoint")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("nothing")
+	span.SetAttribute("key", "value")
+	span.End(nil)
+	if err := tracer.Flush(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var exported int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		exported += len(req.ResourceSpans[0].ScopeSpans[0].Spans)
+	}))
+	defer server.Close()
+
+	tracer, err := NewFromEnv(env(map[string]string{
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": server.URL + "/v1/traces",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ending the last span of a full batch must not wait for the collector,
+	// which does not respond until it is released.
+	for i := 0; i < batchSize+1; i++ {
+		tracer.Start("git show").End(nil)
+	}
+	close(release)
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if exported != batchSize+1 {
+		t.Errorf("Unexpected number of exported spans: %d", exported)
+	}
+}